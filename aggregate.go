@@ -0,0 +1,312 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var (
+	ErrAggregateUnsupported = errors.New("err_aggregateunsupported")
+)
+
+// AggregateFunc is the function computed by a Measure.
+type AggregateFunc string
+
+const (
+	CountFunc AggregateFunc = "count"
+	SumFunc   AggregateFunc = "sum"
+	AvgFunc   AggregateFunc = "avg"
+	MinFunc   AggregateFunc = "min"
+	MaxFunc   AggregateFunc = "max"
+)
+
+// Measure is an aggregate function over the Field values of the aggregated
+// entities, its result is named Alias. A count without Field counts the
+// entities, the other functions skip the nil values of Field.
+type Measure struct {
+	Func  AggregateFunc
+	Field string
+	Alias string
+}
+
+func CountAll(alias string) Measure {
+	return Measure{Func: CountFunc, Alias: alias}
+}
+
+func Sum(field string, alias string) Measure {
+	return Measure{Func: SumFunc, Field: field, Alias: alias}
+}
+
+func Avg(field string, alias string) Measure {
+	return Measure{Func: AvgFunc, Field: field, Alias: alias}
+}
+
+func Min(field string, alias string) Measure {
+	return Measure{Func: MinFunc, Field: field, Alias: alias}
+}
+
+func Max(field string, alias string) Measure {
+	return Measure{Func: MaxFunc, Field: field, Alias: alias}
+}
+
+// Aggregation computes the Measures over the entities selected by the
+// filters of Query, one result for each distinct value of the GroupBy fields.
+// The query orders and limit are ignored, the results are ordered by the
+// group values.
+type Aggregation struct {
+	Query    Query
+	Measures []Measure
+	GroupBy  []string
+}
+
+func NewAggregation(query Query, measures ...Measure) Aggregation {
+	return Aggregation{Query: query, Measures: measures}
+}
+
+// GroupedBy returns a copy of the aggregation grouped by fields.
+func (a Aggregation) GroupedBy(fields ...string) Aggregation {
+	groupBy := make([]string, len(a.GroupBy), len(a.GroupBy)+len(fields))
+	copy(groupBy, a.GroupBy)
+	a.GroupBy = append(groupBy, fields...)
+	return a
+}
+
+// Fields returns the distinct group and measure fields, the fields read by
+// the backends that stream the entities.
+func (a Aggregation) Fields() []string {
+	var (
+		fields = make([]string, 0, len(a.GroupBy)+len(a.Measures))
+		seen   = make(map[string]bool, cap(fields))
+	)
+	for _, field := range a.GroupBy {
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	for _, measure := range a.Measures {
+		if measure.Field != "" && !seen[measure.Field] {
+			seen[measure.Field] = true
+			fields = append(fields, measure.Field)
+		}
+	}
+	return fields
+}
+
+func (a Aggregation) validate() error {
+	if len(a.Measures) == 0 {
+		return fmt.Errorf("%w: aggregation without measures", ErrInvalidArgument)
+	}
+	aliases := make(map[string]bool, len(a.Measures))
+	for _, measure := range a.Measures {
+		if measure.Alias == "" || aliases[measure.Alias] {
+			return fmt.Errorf("%w: blank or repeated alias %q", ErrInvalidArgument, measure.Alias)
+		}
+		aliases[measure.Alias] = true
+		switch measure.Func {
+		case CountFunc:
+		case SumFunc, AvgFunc, MinFunc, MaxFunc:
+			if measure.Field == "" {
+				return fmt.Errorf("%w: %s without field", ErrInvalidArgument, measure.Func)
+			}
+		default:
+			return fmt.Errorf("%w: aggregate function %q", ErrInvalidArgument, measure.Func)
+		}
+	}
+	return nil
+}
+
+// AggregateResult is one group of an aggregation. Group has the GroupBy field
+// values and Values the measure results by alias: an int64 count, a float64
+// sum and average, and the field value of min and max. Sum, average, min and
+// max are nil when the group has no values of the field.
+type AggregateResult struct {
+	Group  map[string]interface{}
+	Values map[string]interface{}
+}
+
+// Int64 returns the integer value of the alias result.
+func (r AggregateResult) Int64(alias string) (int64, bool) {
+	value := reflect.ValueOf(r.Values[alias])
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint()), true
+	}
+	return 0, false
+}
+
+// Float64 returns the value of the alias result converted to float64.
+func (r AggregateResult) Float64(alias string) (float64, bool) {
+	return numberOf(reflect.ValueOf(r.Values[alias]))
+}
+
+// Aggregator is implemented by repositories that compute aggregations, with
+// native aggregate queries or by streaming the entities into an Accumulator.
+type Aggregator interface {
+	Aggregate(context.Context, Aggregation) ([]AggregateResult, error)
+}
+
+// Aggregate returns the results of the aggregation, the repository must
+// implement Aggregator or ErrAggregateUnsupported is returned.
+func Aggregate(ctx context.Context, repository Repository, aggregation Aggregation) ([]AggregateResult, error) {
+	aggregator, ok := repository.(Aggregator)
+	if !ok {
+		return nil, ErrAggregateUnsupported
+	}
+	if err := aggregation.validate(); err != nil {
+		return nil, err
+	}
+	return aggregator.Aggregate(ctx, aggregation)
+}
+
+type accumulatedGroup struct {
+	group  []interface{}
+	counts []int64
+	sums   []float64
+	values []interface{}
+}
+
+// Accumulator computes an aggregation from the field values of the streamed
+// entities, for the backends without native aggregate queries.
+type Accumulator struct {
+	aggregation Aggregation
+	groups      map[string]*accumulatedGroup
+}
+
+func NewAccumulator(aggregation Aggregation) *Accumulator {
+	return &Accumulator{
+		aggregation: aggregation,
+		groups:      make(map[string]*accumulatedGroup),
+	}
+}
+
+// Add accumulates one entity, row has the values of the aggregation Fields
+// by field name. Missing fields and nil pointers are nil values.
+func (a *Accumulator) Add(row map[string]interface{}) error {
+	fieldValue := func(field string) interface{} {
+		value := reflect.ValueOf(row[field])
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return nil
+			}
+			value = value.Elem()
+		}
+		if !value.IsValid() {
+			return nil
+		}
+		return value.Interface()
+	}
+	group := make([]interface{}, len(a.aggregation.GroupBy))
+	for index, field := range a.aggregation.GroupBy {
+		group[index] = fieldValue(field)
+	}
+	var (
+		groupKey    = fmt.Sprintf("%#v", group)
+		accumulated = a.groups[groupKey]
+		numMeasures = len(a.aggregation.Measures)
+	)
+	if accumulated == nil {
+		accumulated = &accumulatedGroup{
+			group:  group,
+			counts: make([]int64, numMeasures),
+			sums:   make([]float64, numMeasures),
+			values: make([]interface{}, numMeasures),
+		}
+		a.groups[groupKey] = accumulated
+	}
+	for index, measure := range a.aggregation.Measures {
+		if measure.Field == "" {
+			accumulated.counts[index]++
+			continue
+		}
+		value := fieldValue(measure.Field)
+		if value == nil {
+			continue
+		}
+		switch measure.Func {
+		case SumFunc, AvgFunc:
+			number, ok := numberOf(reflect.ValueOf(value))
+			if !ok {
+				return fmt.Errorf("%w: %s of %T", ErrInvalidArgument, measure.Func, value)
+			}
+			accumulated.sums[index] += number
+		case MinFunc, MaxFunc:
+			if accumulated.counts[index] > 0 {
+				compared, err := CompareValues(value, accumulated.values[index])
+				if err != nil {
+					return err
+				}
+				if (measure.Func == MinFunc) != (compared < 0) {
+					accumulated.counts[index]++
+					continue
+				}
+			}
+			accumulated.values[index] = value
+		}
+		accumulated.counts[index]++
+	}
+	return nil
+}
+
+// Results returns the aggregation results ordered by the group values, an
+// aggregation without GroupBy always has one result.
+func (a *Accumulator) Results() []AggregateResult {
+	if len(a.aggregation.GroupBy) == 0 && len(a.groups) == 0 {
+		numMeasures := len(a.aggregation.Measures)
+		a.groups[""] = &accumulatedGroup{
+			counts: make([]int64, numMeasures),
+			sums:   make([]float64, numMeasures),
+			values: make([]interface{}, numMeasures),
+		}
+	}
+	groups := make([]*accumulatedGroup, 0, len(a.groups))
+	for _, accumulated := range a.groups {
+		groups = append(groups, accumulated)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		for index := range groups[i].group {
+			compared, err := CompareValues(groups[i].group[index], groups[j].group[index])
+			if err != nil {
+				compared, _ = CompareValues(
+					fmt.Sprint(groups[i].group[index]), fmt.Sprint(groups[j].group[index]),
+				)
+			}
+			if compared != 0 {
+				return compared < 0
+			}
+		}
+		return false
+	})
+	results := make([]AggregateResult, len(groups))
+	for resultIndex, accumulated := range groups {
+		result := AggregateResult{
+			Group:  make(map[string]interface{}, len(a.aggregation.GroupBy)),
+			Values: make(map[string]interface{}, len(a.aggregation.Measures)),
+		}
+		for index, field := range a.aggregation.GroupBy {
+			result.Group[field] = accumulated.group[index]
+		}
+		for index, measure := range a.aggregation.Measures {
+			var value interface{}
+			switch {
+			case measure.Func == CountFunc:
+				value = accumulated.counts[index]
+			case accumulated.counts[index] == 0:
+			case measure.Func == SumFunc:
+				value = accumulated.sums[index]
+			case measure.Func == AvgFunc:
+				value = accumulated.sums[index] / float64(accumulated.counts[index])
+			default:
+				value = accumulated.values[index]
+			}
+			result.Values[measure.Alias] = value
+		}
+		results[resultIndex] = result
+	}
+	return results
+}
@@ -0,0 +1,148 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type aggregatorRepository struct {
+	keyRecorderRepository
+	aggregations []Aggregation
+}
+
+func (r *aggregatorRepository) Aggregate(_ context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	r.aggregations = append(r.aggregations, aggregation)
+	return nil, nil
+}
+
+func TestAggregate(test *testing.T) {
+	var (
+		ctx         = context.Background()
+		aggregation = NewAggregation(NewQuery("entities"), CountAll("total"))
+	)
+	_, err := Aggregate(ctx, &keyRecorderRepository{}, aggregation)
+	require.Equal(test, ErrAggregateUnsupported, err, "unsupported aggregate error")
+
+	var (
+		aggregator = &aggregatorRepository{}
+		repository = NewTenantRepository(aggregator)
+	)
+	_, err = Aggregate(ctx, repository, aggregation)
+	require.Equal(test, ErrTenantRequired, err, "tenant aggregate error")
+	_, err = Aggregate(WithTenant(ctx, "tenant1"), repository, aggregation)
+	require.Nil(test, err, "tenant aggregate error")
+	require.Equal(test, "tenant1", aggregator.aggregations[0].Query.Tenant, "aggregation tenant")
+
+	for _, invalid := range []Aggregation{
+		NewAggregation(NewQuery("entities")),
+		NewAggregation(NewQuery("entities"), CountAll("")),
+		NewAggregation(NewQuery("entities"), Sum("", "total")),
+		NewAggregation(NewQuery("entities"), Measure{Func: "median", Field: "age", Alias: "total"}),
+	} {
+		_, err = Aggregate(ctx, aggregator, invalid)
+		require.True(test, errors.Is(err, ErrInvalidArgument), "invalid aggregation error %v", err)
+	}
+	require.Len(test, aggregator.aggregations, 1, "invalid aggregations delegated")
+}
+
+func TestAggregationFields(test *testing.T) {
+	var (
+		aggregation = NewAggregation(NewQuery("entities"), CountAll("total"), Sum("age", "ages"), Max("age", "oldest"))
+		grouped     = aggregation.GroupedBy("city", "age")
+	)
+	require.Empty(test, aggregation.GroupBy, "aggregation copy")
+	require.Equal(test, []string{"city", "age"}, grouped.Fields(), "grouped fields")
+	require.Equal(test, []string{"age"}, aggregation.Fields(), "measure fields")
+}
+
+type testAccumulator struct {
+	name        string
+	aggregation Aggregation
+	rows        []map[string]interface{}
+	results     []AggregateResult
+	err         error
+}
+
+func TestAccumulator(test *testing.T) {
+	var (
+		age      = 40
+		earlier  = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		later    = earlier.Add(time.Hour)
+		nilCount *int
+	)
+	scenarios := []testAccumulator{
+		{
+			name: "Accumulate the rows by group",
+			aggregation: NewAggregation(
+				NewQuery("entities"), CountAll("total"), Measure{Func: CountFunc, Field: "age", Alias: "ages"},
+				Avg("age", "average"), Min("at", "first"), Max("at", "last"),
+			).GroupedBy("city"),
+			rows: []map[string]interface{}{
+				{"city": "b", "age": 10, "at": later},
+				{"city": "a", "age": &age, "at": earlier},
+				{"city": "b", "age": nilCount, "at": earlier},
+				{"city": "b", "age": int64(20)},
+			},
+			results: []AggregateResult{
+				{
+					Group: map[string]interface{}{"city": "a"},
+					Values: map[string]interface{}{
+						"total": int64(1), "ages": int64(1), "average": 40.0, "first": earlier, "last": earlier,
+					},
+				},
+				{
+					Group: map[string]interface{}{"city": "b"},
+					Values: map[string]interface{}{
+						"total": int64(3), "ages": int64(2), "average": 15.0, "first": earlier, "last": later,
+					},
+				},
+			},
+		},
+		{
+			name:        "Accumulate no rows by group",
+			aggregation: NewAggregation(NewQuery("entities"), CountAll("total")).GroupedBy("city"),
+			results:     []AggregateResult{},
+		},
+		{
+			name:        "Error when the values are incomparable",
+			aggregation: NewAggregation(NewQuery("entities"), Max("value", "max")),
+			rows:        []map[string]interface{}{{"value": 1}, {"value": "a"}},
+			err:         ErrIncomparable,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				accumulator := NewAccumulator(scenario.aggregation)
+				for _, row := range scenario.rows {
+					if err := accumulator.Add(row); err != nil {
+						require.True(t, errors.Is(err, scenario.err), "add error %v", err)
+						return
+					}
+				}
+				require.Nil(t, scenario.err, "add error")
+				require.Equal(t, scenario.results, accumulator.Results(), "accumulated results")
+			},
+		)
+	}
+}
+
+func TestAggregateResultValues(test *testing.T) {
+	result := AggregateResult{Values: map[string]interface{}{"total": int64(3), "ages": 7.5, "min": uint8(2)}}
+	total, ok := result.Int64("total")
+	require.True(test, ok && total == 3, "int64 value")
+	_, ok = result.Int64("ages")
+	require.False(test, ok, "int64 of a float")
+	ages, ok := result.Float64("ages")
+	require.True(test, ok && ages == 7.5, "float64 value")
+	min, ok := result.Float64("min")
+	require.True(test, ok && min == 2, "float64 of an unsigned")
+	_, ok = result.Float64("unknown")
+	require.False(test, ok, "unknown alias")
+}
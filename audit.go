@@ -0,0 +1,353 @@
+package raizel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// AuditEntityName is the entity name of the AuditRecord entities.
+const AuditEntityName = "audit_records"
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor recorded by the audited
+// repositories.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, it returns false when
+// ctx has no actor or the actor is blank.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	if !ok || actor == "" {
+		return "", false
+	}
+	return actor, true
+}
+
+// AuditChange is the change of an entity field, Before is nil for an added
+// field and After is nil for a removed field.
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditRecord is a mutation of the entity of EntityName and Key, the key
+// value formatted as a string. Tenant is always stored, blank for the
+// entities without a tenant, so AuditTrail can filter it. Diff is the json
+// of the changed fields, read it with Changes.
+type AuditRecord struct {
+	_          struct{}  `raizel:"audit_records,entity"`
+	ID         string    `raizel:"id,key"`
+	EntityName string    `raizel:"entity_name,index"`
+	Key        string    `raizel:"key,index"`
+	Tenant     string    `raizel:"tenant,index"`
+	Actor      string    `raizel:"actor"`
+	Operation  string    `raizel:"operation"`
+	Timestamp  time.Time `raizel:"timestamp"`
+	Diff       string    `raizel:"diff"`
+}
+
+// Changes decodes the changed fields of the record.
+func (r AuditRecord) Changes() ([]AuditChange, error) {
+	var changes []AuditChange
+	if r.Diff == "" {
+		return changes, nil
+	}
+	if err := json.Unmarshal([]byte(r.Diff), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// AuditTrail returns the audit records of the key and its tenant by
+// timestamp, the audit repository must implement Queryable or
+// ErrNotQueryable is returned.
+func AuditTrail(ctx context.Context, audit Repository, key EntityKey) ([]AuditRecord, error) {
+	queryable, ok := audit.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	tenant, _ := TenantOf(key)
+	query := NewQuery(AuditEntityName).
+		Where("entity_name", Equal, key.EntityName()).
+		Where("key", Equal, fmt.Sprint(key.Value())).
+		Where("tenant", Equal, tenant).
+		OrderBy("timestamp", Asc)
+	iterator, err := queryable.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Stop()
+	var records []AuditRecord
+	for {
+		var record AuditRecord
+		if err := iterator.Next(ctx, &record); err != nil {
+			if errors.Is(err, ErrIteratorDone) {
+				return records, nil
+			}
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+type AuditOption func(*auditRepository)
+
+// WithAuditClock replaces the clock of the audit record timestamps.
+func WithAuditClock(now func() time.Time) AuditOption {
+	return func(r *auditRepository) {
+		r.now = now
+	}
+}
+
+type auditRepository struct {
+	repository Repository
+	audit      Repository
+	now        func() time.Time
+}
+
+// NewAuditRepository wraps repository writing an AuditRecord to audit for
+// every Set, Patch and Delete, with the actor of the context and the diff of
+// the entity fields. A nil audit, or audit being repository itself, writes
+// the records to repository in the same transaction as the mutation when it
+// is Transactional.
+//
+// WARNING: a separate audit repository is not atomic with the mutation. The
+// record is written after the mutation commits, outside of any transaction,
+// so a failed record write returns its error with the mutation already
+// applied and a crash between the writes loses the record. Use a nil audit
+// on a Transactional repository when every mutation must have its record.
+//
+// The state before a Delete or a Patch is read with the entity type
+// registered with RegisterEntity, the mutations of unregistered entities
+// are recorded without the fields they remove or patch.
+func NewAuditRepository(repository Repository, audit Repository, options ...AuditOption) Repository {
+	if sameRepository(repository, audit) {
+		audit = nil
+	}
+	audited := &auditRepository{repository: repository, audit: audit, now: time.Now}
+	for _, option := range options {
+		option(audited)
+	}
+	return audited
+}
+
+// sameRepository returns true when a and b are the same repository value.
+func sameRepository(a, b Repository) bool {
+	aType := reflect.TypeOf(a)
+	return aType != nil && aType == reflect.TypeOf(b) && aType.Comparable() && a == b
+}
+
+// mutate runs the mutation and writes its audit record, atomically when the
+// records are written to a Transactional repository.
+func (r *auditRepository) mutate(
+	ctx context.Context, operation Operation, key EntityKey, entityType reflect.Type,
+	fn func(context.Context, Repository) (Entity, error),
+) error {
+	run := func(ctx context.Context, repository Repository, audit Repository) error {
+		before, err := r.load(ctx, repository, key, entityType)
+		if err != nil {
+			return err
+		}
+		after, err := fn(ctx, repository)
+		if err != nil {
+			return err
+		}
+		record, err := r.record(ctx, operation, key, before, after)
+		if err != nil {
+			return err
+		}
+		return audit.Set(ctx, NewDynamicKey(AuditEntityName, "id", record.ID), record)
+	}
+	if r.audit != nil {
+		return run(ctx, r.repository, r.audit)
+	}
+	if _, ok := r.repository.(Transactional); !ok {
+		return run(ctx, r.repository, r.repository)
+	}
+	return Transaction(ctx, r.repository, func(ctx context.Context, tx Repository) error {
+		return run(ctx, tx, tx)
+	})
+}
+
+// load returns the stored entity of the key, nil when it is missing or its
+// type is unknown.
+func (r *auditRepository) load(ctx context.Context, repository Repository, key EntityKey, entityType reflect.Type) (Entity, error) {
+	if entityType == nil {
+		return nil, nil
+	}
+	entity := reflect.New(entityType).Interface()
+	if err := repository.Get(ctx, key, entity); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *auditRepository) record(ctx context.Context, operation Operation, key EntityKey, before, after Entity) (*AuditRecord, error) {
+	changes, err := diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	var (
+		timestamp = r.now().UTC()
+		tenant, _ = TenantOf(key)
+		actor, _  = ActorFromContext(ctx)
+	)
+	return &AuditRecord{
+		ID:         fmt.Sprintf("%d-%s", timestamp.UnixNano(), hex.EncodeToString(id)),
+		EntityName: key.EntityName(),
+		Key:        fmt.Sprint(key.Value()),
+		Tenant:     tenant,
+		Actor:      actor,
+		Operation:  string(operation),
+		Timestamp:  timestamp,
+		Diff:       string(data),
+	}, nil
+}
+
+// fieldsOf returns the field values of the entity by name, nil for a nil
+// entity and the entity itself for a value that is not a struct.
+func fieldsOf(entity Entity) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	if fields, ok := entity.(map[string]interface{}); ok {
+		return fields, nil
+	}
+	descriptor, found := DescriptorOf(entity)
+	if !found {
+		var err error
+		if descriptor, err = Describe(entity); err != nil {
+			return map[string]interface{}{"": entity}, nil
+		}
+	}
+	values, err := descriptor.Values(entity)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{}, len(values))
+	for index, field := range descriptor.Fields {
+		fields[field.Name] = values[index]
+	}
+	return fields, nil
+}
+
+// diff returns the changed fields between before and after by field name.
+func diff(before, after Entity) ([]AuditChange, error) {
+	beforeFields, err := fieldsOf(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fieldsOf(after)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(afterFields))
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+	changes := make([]AuditChange, 0, len(names))
+	for name := range names {
+		beforeValue, afterValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, AuditChange{Field: name, Before: beforeValue, After: afterValue})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// registeredType returns the entity type registered with the entity name.
+func registeredType(entityName string) reflect.Type {
+	descriptor, found := EntityDescriptor(entityName)
+	if !found {
+		return nil
+	}
+	return descriptor.Type
+}
+
+func (r *auditRepository) Get(ctx context.Context, key EntityKey, entity Entity) error {
+	return r.repository.Get(ctx, key, entity)
+}
+
+func (r *auditRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	entityType := reflect.TypeOf(entity)
+	for entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType != nil && entityType.Kind() != reflect.Struct {
+		entityType = nil
+	}
+	return r.mutate(ctx, SetOperation, key, entityType, func(ctx context.Context, repository Repository) (Entity, error) {
+		return entity, repository.Set(ctx, key, entity)
+	})
+}
+
+func (r *auditRepository) Delete(ctx context.Context, key EntityKey) error {
+	return r.mutate(ctx, DeleteOperation, key, registeredType(key.EntityName()), func(ctx context.Context, repository Repository) (Entity, error) {
+		return nil, repository.Delete(ctx, key)
+	})
+}
+
+// Patch records the patched entity when its type is registered, and the
+// assigned values of the updates otherwise.
+func (r *auditRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	entityType := registeredType(key.EntityName())
+	return r.mutate(ctx, PatchOperation, key, entityType, func(ctx context.Context, repository Repository) (Entity, error) {
+		if err := Patch(ctx, repository, key, updates...); err != nil {
+			return nil, err
+		}
+		if entityType != nil {
+			return r.load(ctx, repository, key, entityType)
+		}
+		assigned := make(map[string]interface{}, len(updates))
+		for _, update := range updates {
+			assigned[update.Field] = update.Value
+		}
+		return assigned, nil
+	})
+}
+
+func (r *auditRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	return Exists(ctx, r.repository, key)
+}
+
+func (r *auditRepository) Count(ctx context.Context, query Query) (int64, error) {
+	return Count(ctx, r.repository, query)
+}
+
+func (r *auditRepository) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	return Aggregate(ctx, r.repository, aggregation)
+}
+
+func (r *auditRepository) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
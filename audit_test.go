@@ -0,0 +1,177 @@
+package raizel_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type auditedAccount struct {
+	_       struct{} `raizel:"audited_accounts,entity"`
+	ID      string   `raizel:"id,key"`
+	Owner   string   `raizel:"owner"`
+	Balance int      `raizel:"balance"`
+}
+
+// failingAudit fails every audit record write.
+type failingAudit struct {
+	raizel.Repository
+}
+
+func (failingAudit) Set(context.Context, raizel.EntityKey, raizel.Entity) error {
+	return raizel.ErrUnavailable
+}
+
+func TestAuditRepository(test *testing.T) {
+	_, err := raizel.RegisterEntity(auditedAccount{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = raizel.WithActor(context.Background(), "alice")
+		store      = memory.NewRepository()
+		clock      = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		repository = raizel.NewAuditRepository(store, nil, raizel.WithAuditClock(func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		}))
+		key = raizel.NewDynamicKey("audited_accounts", "id", "mock1")
+	)
+	require.Nil(test, repository.Set(ctx, key, &auditedAccount{ID: "mock1", Owner: "Mock", Balance: 10}), "set error")
+	require.Nil(test, repository.Set(ctx, key, &auditedAccount{ID: "mock1", Owner: "Mock", Balance: 25}), "update error")
+	require.Nil(test, raizel.Patch(ctx, repository, key, raizel.Increment("balance", 5)), "patch error")
+	require.Nil(test, repository.Delete(raizel.WithActor(ctx, "bob"), key), "delete error")
+
+	records, err := raizel.AuditTrail(ctx, store, key)
+	require.Nil(test, err, "audit trail error")
+	require.Len(test, records, 4, "audit records invalid length")
+	var (
+		operations []string
+		actors     []string
+	)
+	for _, record := range records {
+		operations = append(operations, record.Operation)
+		actors = append(actors, record.Actor)
+		require.Equal(test, "audited_accounts", record.EntityName, "record entity invalid value")
+		require.Equal(test, "mock1", record.Key, "record key invalid value")
+	}
+	require.Equal(test, []string{"set", "set", "patch", "delete"}, operations, "record operations invalid value")
+	require.Equal(test, []string{"alice", "alice", "alice", "bob"}, actors, "record actors invalid value")
+	require.True(test, records[0].Timestamp.Before(records[1].Timestamp), "record timestamps invalid order")
+
+	changes, err := records[1].Changes()
+	require.Nil(test, err, "changes error")
+	require.Equal(test, []raizel.AuditChange{{Field: "balance", Before: 10.0, After: 25.0}}, changes, "update changes invalid value")
+	changes, err = records[2].Changes()
+	require.Nil(test, err, "changes error")
+	require.Equal(test, []raizel.AuditChange{{Field: "balance", Before: 25.0, After: 30.0}}, changes, "patch changes invalid value")
+	changes, err = records[3].Changes()
+	require.Nil(test, err, "changes error")
+	require.Len(test, changes, 3, "delete changes invalid length")
+	require.Nil(test, changes[0].After, "delete change after invalid value")
+
+	_, err = raizel.AuditTrail(ctx, failingAudit{}, key)
+	require.True(test, errors.Is(err, raizel.ErrNotQueryable), "unqueryable audit trail error")
+}
+
+func TestAuditRepositoryTransaction(test *testing.T) {
+	var (
+		ctx     = context.Background()
+		store   = memory.NewRepository()
+		key     = raizel.NewDynamicKey("audited_accounts", "id", "mock1")
+		account = auditedAccount{ID: "mock1", Owner: "Mock", Balance: 10}
+		result  auditedAccount
+	)
+	audited := raizel.NewAuditRepository(store, failingAudit{Repository: store})
+	require.Equal(test, raizel.ErrUnavailable, audited.Set(ctx, key, &account), "separate audit error")
+	require.Nil(test, store.Get(ctx, key, &result), "separate audit write is not atomic")
+	require.Nil(test, store.Delete(ctx, key), "delete error")
+
+	audited = raizel.NewAuditRepository(failingTransaction{store}, nil)
+	require.Equal(test, raizel.ErrUnavailable, audited.Set(ctx, key, &account), "transactional audit error")
+	require.Equal(test, raizel.ErrNotFound, store.Get(ctx, key, &result), "transactional write committed")
+
+	transactional := failingTransaction{store}
+	audited = raizel.NewAuditRepository(transactional, transactional)
+	require.Equal(test, raizel.ErrUnavailable, audited.Set(ctx, key, &account), "same audit error")
+	require.Equal(test, raizel.ErrNotFound, store.Get(ctx, key, &result), "same audit write committed")
+}
+
+// queryRecorder records the queries of the repository.
+type queryRecorder struct {
+	raizel.Repository
+	queries []raizel.Query
+}
+
+func (r *queryRecorder) Query(ctx context.Context, query raizel.Query) (raizel.Iterator, error) {
+	r.queries = append(r.queries, query)
+	return r.Repository.(raizel.Queryable).Query(ctx, query)
+}
+
+func TestAuditTrailTenant(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		store      = memory.NewRepository()
+		recorder   = &queryRecorder{Repository: store}
+		repository = raizel.NewAuditRepository(store, nil)
+		key        = raizel.NewDynamicKey("audited_accounts", "id", "mock1")
+		tenantKey  = raizel.NewTenantKey("acme", key)
+	)
+	require.Nil(test, repository.Set(ctx, key, &auditedAccount{ID: "mock1", Balance: 10}), "set error")
+	require.Nil(test, repository.Set(ctx, tenantKey, &auditedAccount{ID: "mock1", Balance: 20}), "tenant set error")
+	require.Nil(test, repository.Delete(ctx, tenantKey), "tenant delete error")
+
+	scenarios := []struct {
+		name       string
+		key        raizel.EntityKey
+		operations []string
+	}{
+		{name: "Without tenant", key: key, operations: []string{"set"}},
+		{name: "With tenant", key: tenantKey, operations: []string{"set", "delete"}},
+	}
+	for index, scenario := range scenarios {
+		test.Run(fmt.Sprintf("[%d]-%s", index, scenario.name), func(t *testing.T) {
+			recorder.queries = nil
+			records, err := raizel.AuditTrail(ctx, recorder, scenario.key)
+			require.Nil(t, err, "audit trail error")
+			var operations []string
+			for _, record := range records {
+				operations = append(operations, record.Operation)
+			}
+			require.Equal(t, scenario.operations, operations, "record operations invalid value")
+			require.Len(t, recorder.queries, 1, "audit trail queries invalid length")
+			tenant, _ := raizel.TenantOf(scenario.key)
+			require.Contains(
+				t, recorder.queries[0].Filters, raizel.Filter{Field: "tenant", Operator: raizel.Equal, Value: tenant},
+				"audit trail tenant filter",
+			)
+		})
+	}
+}
+
+// failingTransaction runs the transactions of the repository with an audit
+// record write that fails.
+type failingTransaction struct {
+	raizel.Repository
+}
+
+func (r failingTransaction) Transaction(ctx context.Context, fn func(context.Context, raizel.Repository) error) error {
+	return raizel.Transaction(ctx, r.Repository, func(ctx context.Context, tx raizel.Repository) error {
+		return fn(ctx, failingAuditTx{tx})
+	})
+}
+
+type failingAuditTx struct {
+	raizel.Repository
+}
+
+func (r failingAuditTx) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if key.EntityName() == raizel.AuditEntityName {
+		return raizel.ErrUnavailable
+	}
+	return r.Repository.Set(ctx, key, entity)
+}
@@ -0,0 +1,43 @@
+package cassandra
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"github.com/scylladb/gocqlx/qb"
+)
+
+// Aggregate streams the rows of the query into a raizel.Accumulator, the rows
+// are selected with the aggregation columns only and the filters must be
+// allowed by the table keys like the Page filters.
+func (r *repository) Aggregate(ctx context.Context, aggregation raizel.Aggregation) ([]raizel.AggregateResult, error) {
+	query := aggregation.Query
+	table, err := queryTable(query)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		builder     = qb.Select(table).Columns(aggregation.Fields()...)
+		accumulator = raizel.NewAccumulator(aggregation)
+	)
+	values, err := whereFilters(builder, append(query.ParentFilters(), query.Filters...))
+	if err != nil {
+		return nil, err
+	}
+	cql, _ := builder.ToCql()
+	iter := r.session.Query(cql, values...).Iter()
+	for {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		if err := accumulator.Add(row); err != nil {
+			_ = iter.Close()
+			return nil, err
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return accumulator.Results(), nil
+}
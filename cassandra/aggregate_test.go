@@ -0,0 +1,107 @@
+package cassandra_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryAggregate struct {
+	name        string
+	aggregation raizel.Aggregation
+	cql         string
+	args        []interface{}
+	rows        []map[string]interface{}
+	results     []raizel.AggregateResult
+	err         error
+}
+
+func TestRepositoryAggregate(test *testing.T) {
+	scenarios := []testRepositoryAggregate{
+		{
+			name: "Aggregate the rows by group",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entities").Where("day", raizel.Equal, 7),
+				raizel.CountAll("total"),
+				raizel.Max("views", "most"),
+			).GroupedBy("kind"),
+			cql:  "SELECT kind,views FROM entities WHERE day=? ",
+			args: []interface{}{7},
+			rows: []map[string]interface{}{
+				{"kind": "b", "views": 3},
+				{"kind": "a", "views": 1},
+				{"kind": "b", "views": 5},
+			},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{"kind": "a"},
+					Values: map[string]interface{}{"total": int64(1), "most": 1},
+				},
+				{
+					Group:  map[string]interface{}{"kind": "b"},
+					Values: map[string]interface{}{"total": int64(2), "most": 5},
+				},
+			},
+		},
+		{
+			name: "Aggregate the rows of the tenant keyspace",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entities").InTenant("tenant1"),
+				raizel.Avg("views", "average"),
+			),
+			cql:  "SELECT views FROM tenant1.entities ",
+			args: []interface{}{},
+			rows: []map[string]interface{}{{"views": 2}, {"views": 4}},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{},
+					Values: map[string]interface{}{"average": 3.0},
+				},
+			},
+		},
+		{
+			name: "Error when the filter is invalid",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entities").Where("day", raizel.Operator("!="), 7), raizel.CountAll("total"),
+			),
+			err: cassandra.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					session = cmock.NewSessionMock()
+					query   = cmock.NewQueryMock()
+					iter    = cmock.NewIterMock()
+				)
+				session.On("Query", scenario.cql, scenario.args).Return(query)
+				query.On("Iter").Return(iter)
+				for _, row := range scenario.rows {
+					row := row
+					iter.On("MapScan", mock.Anything).Run(func(args mock.Arguments) {
+						for name, value := range row {
+							args.Get(0).(map[string]interface{})[name] = value
+						}
+					}).Return(true).Once()
+				}
+				iter.On("MapScan", mock.Anything).Return(false)
+				iter.On("Close").Return(nil)
+
+				results, err := raizel.Aggregate(context.Background(), cassandra.NewRepository(session), scenario.aggregation)
+				require.Equal(t, scenario.err, err, "aggregate error")
+				require.Equal(t, scenario.results, results, "aggregate results")
+				if scenario.err == nil {
+					iter.AssertExpectations(t)
+				}
+			},
+		)
+	}
+}
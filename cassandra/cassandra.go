@@ -7,7 +7,8 @@ import (
 )
 
 var (
-	ErrBlankSession = errors.New("err_blanksession")
+	ErrBlankSession  = errors.New("err_blanksession")
+	ErrInvalidFilter = errors.New("err_invalidfilter")
 	// 	NotFoundErr        = gocql.ErrNotFound
 )
 
@@ -19,18 +20,21 @@ type Session interface {
 
 type Query interface {
 	Scan(...interface{}) error
+	ScanCAS(...interface{}) (bool, error)
 	Exec() error
 	Iter() Iter
 	Consistency(gocql.Consistency) Query
 	PageSize(int) Query
+	PageState([]byte) Query
 	Release()
 	String() string
-	delegate() *gocql.Query
 }
 
 type Iter interface {
 	Close() error
+	MapScan(map[string]interface{}) bool
 	NumRows() int
+	PageState() []byte
 	Scanner() gocql.Scanner
 }
 
@@ -71,6 +75,8 @@ func (delegate *query) PageSize(size int) Query {
 	}
 }
 
-func (delegate *query) delegate() *gocql.Query {
-	return delegate.Query
+func (delegate *query) PageState(state []byte) Query {
+	return &query{
+		Query: delegate.Query.PageState(state),
+	}
 }
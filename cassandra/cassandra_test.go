@@ -1,4 +1,4 @@
-package cassandra
+package cassandra_test
 
 import (
 	"errors"
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel/cassandra"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,7 +32,7 @@ func TestSession(test *testing.T) {
 		},
 		{
 			name: "Returns error because session is blank",
-			err:  ErrBlankSession,
+			err:  cassandra.ErrBlankSession,
 		},
 	}
 
@@ -42,7 +43,7 @@ func TestSession(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				session, err := newSession(scenario.session)
+				session, err := cassandra.NewSession(scenario.session)
 				require.Equal(t, scenario.err, err, "newsession error")
 				if scenario.err == nil {
 					require.NotNil(t, session, "session instance")
@@ -91,15 +92,17 @@ func TestQuery(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				session, err := newSession(scenario.session)
+				session, err := cassandra.NewSession(scenario.session)
 				require.Nil(t, err, "newsession error")
 				require.NotNil(t, session, "session instance")
 				query := session.Query(scenario.cql, scenario.arguments...)
 				require.NotNil(t, query, "query invalid instance")
-				require.NotNil(t, query.delegate(), "querydelegate invalid instance")
+				require.NotNil(t, cassandra.QueryDelegate(query), "querydelegate invalid instance")
 
 				query = query.Consistency(gocql.Any)
 				query = query.PageSize(100)
+				query = query.PageState([]byte("state"))
+				require.NotNil(t, cassandra.QueryDelegate(query), "querydelegate invalid instance")
 
 				require.Panics(t,
 					func() {
@@ -108,6 +111,13 @@ func TestQuery(test *testing.T) {
 					},
 				)
 
+				require.Panics(t,
+					func() {
+						var id, text string
+						_, _ = query.ScanCAS(&id, &text)
+					},
+				)
+
 				require.Panics(t,
 					func() {
 						_ = query.Iter()
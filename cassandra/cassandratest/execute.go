@@ -0,0 +1,706 @@
+package cassandratest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	bigintType = cqlType{name: "bigint"}
+	intType    = cqlType{name: "int"}
+)
+
+// binding evaluates the terms of a statement with its bound values.
+type binding struct {
+	values []interface{}
+	now    time.Time
+}
+
+func (b binding) value(current term, typ cqlType) (interface{}, error) {
+	switch {
+	case current.marker > 0:
+		value, err := convert(typ, b.values[current.marker-1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bind marker %d: %v", ErrInvalidRequest, current.marker, err)
+		}
+		return value, nil
+	case current.null:
+		return nil, nil
+	case current.now && typ.name == "timestamp":
+		return b.now.Truncate(time.Millisecond), nil
+	case current.now && typ.name == "timeuuid":
+		return gocql.UUIDFromTime(b.now), nil
+	case current.now:
+		return nil, fmt.Errorf("%w: now() is not a %s", ErrInvalidRequest, typ)
+	case current.collection != nil:
+		if typ.elem == nil {
+			return nil, fmt.Errorf("%w: collection literal is not a %s", ErrInvalidRequest, typ)
+		}
+		elems := make([]interface{}, len(current.collection))
+		for index, elem := range current.collection {
+			value, err := b.value(elem, *typ.elem)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				return nil, fmt.Errorf("%w: null element of %s", ErrInvalidRequest, typ)
+			}
+			elems[index] = value
+		}
+		if typ.name == "set" {
+			elems = sortSet(elems)
+		}
+		if len(elems) == 0 {
+			return nil, nil
+		}
+		return elems, nil
+	}
+	value, err := parseLiteral(typ, current.literal)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return value, nil
+}
+
+// expires returns the expiration of the TTL in seconds, zero is no
+// expiration.
+func (b binding) expires(ttl *term) (time.Time, error) {
+	if ttl == nil {
+		return time.Time{}, nil
+	}
+	seconds, err := b.value(*ttl, intType)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch {
+	case seconds == nil, seconds.(int64) == 0:
+		return time.Time{}, nil
+	case seconds.(int64) < 0:
+		return time.Time{}, fmt.Errorf("%w: negative TTL %d", ErrInvalidRequest, seconds)
+	}
+	return b.now.Add(time.Duration(seconds.(int64)) * time.Second), nil
+}
+
+// restriction is a relation of a table column with its values, an IN
+// relation has one value for each element.
+type restriction struct {
+	column int
+	op     string
+	values []interface{}
+}
+
+func (b binding) restrictions(target *table, where []relation) ([]restriction, error) {
+	restrictions := make([]restriction, len(where))
+	for index, current := range where {
+		position, err := target.column(current.column)
+		if err != nil {
+			return nil, err
+		}
+		typ := target.columns[position].typ
+		restrictions[index] = restriction{column: position, op: current.op}
+		if current.op != "IN" {
+			value, err := b.value(current.value, typ)
+			if err != nil {
+				return nil, err
+			}
+			restrictions[index].values = []interface{}{value}
+			continue
+		}
+		values, err := b.value(current.value, cqlType{name: "list", elem: &typ})
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			restrictions[index].values = values.([]interface{})
+		}
+	}
+	return restrictions, nil
+}
+
+// matches reports whether the row values satisfy the restrictions, a null
+// value satisfies no restriction.
+func matches(values []interface{}, restrictions []restriction) bool {
+	for _, current := range restrictions {
+		value := values[current.column]
+		if value == nil {
+			return false
+		}
+		matched := false
+		for _, restricted := range current.values {
+			if restricted == nil {
+				continue
+			}
+			compared := compareValues(value, restricted)
+			switch current.op {
+			case "=", "IN":
+				matched = compared == 0
+			case "<":
+				matched = compared < 0
+			case "<=":
+				matched = compared <= 0
+			case ">":
+				matched = compared > 0
+			case ">=":
+				matched = compared >= 0
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func equality(op string) bool {
+	return op == "=" || op == "IN"
+}
+
+// needsFiltering reports whether the restrictions need ALLOW FILTERING: they
+// restrict a regular column, part of the partition key, or a clustering
+// column after an unrestricted or range restricted clustering column.
+func (t *table) needsFiltering(restrictions []restriction) bool {
+	restricted := make(map[int][]string)
+	for _, current := range restrictions {
+		if !t.isKey(current.column) {
+			return true
+		}
+		restricted[current.column] = append(restricted[current.column], current.op)
+	}
+	partitioned := 0
+	for _, index := range t.partition {
+		for _, op := range restricted[index] {
+			if !equality(op) {
+				return true
+			}
+		}
+		if len(restricted[index]) > 0 {
+			partitioned++
+		}
+	}
+	if partitioned != 0 && partitioned != len(t.partition) {
+		return true
+	}
+	gap := partitioned == 0
+	for _, index := range t.clustering {
+		ops := restricted[index]
+		if len(ops) == 0 {
+			gap = true
+			continue
+		}
+		if gap {
+			return true
+		}
+		for _, op := range ops {
+			if !equality(op) {
+				gap = true
+			}
+		}
+	}
+	return false
+}
+
+// partitionRestricted reports whether the restrictions restrict every
+// partition column by equality.
+func (t *table) partitionRestricted(restrictions []restriction) bool {
+	for _, index := range t.partition {
+		restricted := false
+		for _, current := range restrictions {
+			if current.column == index && equality(current.op) {
+				restricted = true
+			}
+		}
+		if !restricted {
+			return false
+		}
+	}
+	return true
+}
+
+// primaryKey returns the key values of restrictions that restrict every
+// primary key column by one equality and no other column.
+func (t *table) primaryKey(restrictions []restriction) ([]interface{}, error) {
+	key := make([]interface{}, len(t.columns))
+	for _, current := range restrictions {
+		switch {
+		case !t.isKey(current.column):
+			return nil, fmt.Errorf("%w: non PRIMARY KEY column %s found in where clause", ErrInvalidRequest, t.columns[current.column].name)
+		case current.op != "=":
+			return nil, fmt.Errorf("%w: invalid operator %s for PRIMARY KEY part %s", ErrInvalidRequest, current.op, t.columns[current.column].name)
+		case current.values[0] == nil:
+			return nil, fmt.Errorf("%w: invalid null value for PRIMARY KEY part %s", ErrInvalidRequest, t.columns[current.column].name)
+		}
+		key[current.column] = current.values[0]
+	}
+	for index := range t.columns {
+		if t.isKey(index) && key[index] == nil {
+			return nil, fmt.Errorf("%w: missing PRIMARY KEY part %s", ErrInvalidRequest, t.columns[index].name)
+		}
+	}
+	return key, nil
+}
+
+func (s *Session) table(name string) (*table, error) {
+	found, exists := s.tables[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: unconfigured table %s", ErrInvalidRequest, name)
+	}
+	return found, nil
+}
+
+// execute parses and runs the statement.
+func (s *Session) execute(statement string, values []interface{}, pageSize int, pageState []byte) (*result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, gocql.ErrSessionClosed
+	}
+	p, err := newParser(statement)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	var run func(binding) (*result, error)
+	switch {
+	case p.keyword("SELECT"):
+		parsed, err := parseSelect(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		run = func(b binding) (*result, error) { return s.runSelect(parsed, b, pageSize, pageState) }
+	case p.keyword("INSERT", "INTO"):
+		parsed, err := parseInsert(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		run = func(b binding) (*result, error) { return s.runInsert(parsed, b) }
+	case p.keyword("UPDATE"):
+		parsed, err := parseUpdate(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		run = func(b binding) (*result, error) { return s.runUpdate(parsed, b) }
+	case p.keyword("DELETE"):
+		parsed, err := parseDelete(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		run = func(b binding) (*result, error) { return s.runDelete(parsed, b) }
+	default:
+		if err := s.runSchema(p); err != nil {
+			return nil, err
+		}
+		return &result{}, nil
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrSyntax, p.peek().text)
+	}
+	if p.markers != len(values) {
+		return nil, fmt.Errorf("%w: expected %d values, found %d", ErrInvalidRequest, p.markers, len(values))
+	}
+	return run(binding{values: values, now: s.now()})
+}
+
+// runSchema runs CREATE TABLE, DROP TABLE, TRUNCATE and DROP KEYSPACE, the
+// other keyspace, index and USE statements are accepted and ignored.
+func (s *Session) runSchema(p *parser) error {
+	switch {
+	case p.keyword("CREATE", "TABLE"):
+		ifNotExists := p.keyword("IF", "NOT", "EXISTS")
+		created, err := parseCreateTable(p)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if _, exists := s.tables[created.name]; exists {
+			if ifNotExists {
+				return nil
+			}
+			return fmt.Errorf("%w: table %s already exists", ErrInvalidRequest, created.name)
+		}
+		s.tables[created.name] = created
+		return nil
+	case p.keyword("DROP", "TABLE"):
+		ifExists := p.keyword("IF", "EXISTS")
+		name, err := p.tableName()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if _, exists := s.tables[name]; !exists && !ifExists {
+			return fmt.Errorf("%w: unconfigured table %s", ErrInvalidRequest, name)
+		}
+		delete(s.tables, name)
+		return nil
+	case p.keyword("TRUNCATE"):
+		p.keyword("TABLE")
+		name, err := p.tableName()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		truncated, err := s.table(name)
+		if err != nil {
+			return err
+		}
+		truncated.rows = nil
+		return nil
+	case p.keyword("DROP", "KEYSPACE"):
+		p.keyword("IF", "EXISTS")
+		keyspace, err := p.ident()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		for name := range s.tables {
+			if strings.HasPrefix(name, keyspace+".") {
+				delete(s.tables, name)
+			}
+		}
+		return nil
+	case p.keyword("CREATE", "KEYSPACE"), p.keyword("CREATE", "INDEX"), p.keyword("CREATE", "CUSTOM", "INDEX"),
+		p.keyword("DROP", "INDEX"), p.keyword("USE"):
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported statement %q", ErrSyntax, p.peek().text)
+}
+
+func (s *Session) runSelect(statement *selectStatement, b binding, pageSize int, pageState []byte) (*result, error) {
+	target, err := s.table(statement.table)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := b.restrictions(target, statement.where)
+	if err != nil {
+		return nil, err
+	}
+	if !statement.allowFiltering && target.needsFiltering(restrictions) {
+		return nil, fmt.Errorf(
+			"%w: cannot execute this query as it might involve data filtering, use ALLOW FILTERING", ErrInvalidRequest,
+		)
+	}
+	reversed, err := target.reversed(statement.orders, restrictions)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]interface{}
+	for _, stored := range target.rows {
+		values, live := target.values(stored, b.now)
+		if live && matches(values, restrictions) {
+			rows = append(rows, values)
+		}
+	}
+	if reversed {
+		rows = target.reverse(rows)
+	}
+	if statement.limit != nil {
+		limit, err := b.value(*statement.limit, intType)
+		if err != nil {
+			return nil, err
+		}
+		if limit == nil || limit.(int64) <= 0 {
+			return nil, fmt.Errorf("%w: LIMIT must be strictly positive", ErrInvalidRequest)
+		}
+		if int(limit.(int64)) < len(rows) {
+			rows = rows[:limit.(int64)]
+		}
+	}
+	if statement.count {
+		return &result{
+			columns: []column{{name: "count", typ: bigintType}},
+			rows:    [][]interface{}{{int64(len(rows))}},
+		}, nil
+	}
+	selected := &result{columns: target.columns}
+	if statement.columns != nil {
+		positions := make([]int, len(statement.columns))
+		selected.columns = make([]column, len(statement.columns))
+		for index, name := range statement.columns {
+			if positions[index], err = target.column(name); err != nil {
+				return nil, err
+			}
+			selected.columns[index] = target.columns[positions[index]]
+		}
+		for index, values := range rows {
+			projected := make([]interface{}, len(positions))
+			for position, column := range positions {
+				projected[position] = values[column]
+			}
+			rows[index] = projected
+		}
+	}
+	selected.rows = rows
+	if pageSize > 0 {
+		if selected.rows, selected.pageState, err = pageOf(rows, pageSize, pageState); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// reversed checks that the orders follow the clustering columns in the
+// clustering order or all in the reverse order, and reports the reverse
+// order.
+func (t *table) reversed(orders []ordering, restrictions []restriction) (bool, error) {
+	if len(orders) == 0 {
+		return false, nil
+	}
+	if !t.partitionRestricted(restrictions) {
+		return false, fmt.Errorf("%w: ORDER BY is only supported when the partition key is restricted by an EQ or an IN", ErrInvalidRequest)
+	}
+	reversed := len(t.clustering) > 0 && orders[0].desc != t.desc[0]
+	for position, order := range orders {
+		if position >= len(t.clustering) || t.columns[t.clustering[position]].name != order.column {
+			return false, fmt.Errorf("%w: ORDER BY must follow the clustering columns, found %s", ErrInvalidRequest, order.column)
+		}
+		if (order.desc != t.desc[position]) != reversed {
+			return false, fmt.Errorf("%w: unsupported ORDER BY of %s", ErrInvalidRequest, order.column)
+		}
+	}
+	return reversed, nil
+}
+
+// reverse reverses the clustering order of the rows of each partition.
+func (t *table) reverse(rows [][]interface{}) [][]interface{} {
+	samePartition := func(a, b []interface{}) bool {
+		for _, index := range t.partition {
+			if compareValues(a[index], b[index]) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && samePartition(rows[start], rows[end]) {
+			end++
+		}
+		for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+		start = end
+	}
+	return rows
+}
+
+func (s *Session) runInsert(statement *insertStatement, b binding) (*result, error) {
+	target, err := s.table(statement.table)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		values  = make([]interface{}, len(target.columns))
+		written = make(map[int]bool, len(statement.columns))
+	)
+	for index, name := range statement.columns {
+		position, err := target.column(name)
+		if err != nil {
+			return nil, err
+		}
+		if written[position] {
+			return nil, fmt.Errorf("%w: multiple definitions of column %s", ErrInvalidRequest, name)
+		}
+		written[position] = true
+		if values[position], err = b.value(statement.values[index], target.columns[position].typ); err != nil {
+			return nil, err
+		}
+	}
+	for index, definition := range target.columns {
+		if target.isKey(index) && values[index] == nil {
+			return nil, fmt.Errorf("%w: invalid null value for PRIMARY KEY part %s", ErrInvalidRequest, definition.name)
+		}
+	}
+	expires, err := b.expires(statement.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if statement.ifNotExists {
+		if position, found := target.find(values); found {
+			if existing, live := target.values(target.rows[position], b.now); live {
+				return appliedResult(false, target, existing), nil
+			}
+		}
+	}
+	stored := target.upsert(values)
+	stored.marker, stored.markerExpires = true, expires
+	for position := range written {
+		if !target.isKey(position) {
+			stored.cells[position] = cell{value: values[position], expires: expires}
+		}
+	}
+	if statement.ifNotExists {
+		return appliedResult(true, nil, nil), nil
+	}
+	return &result{}, nil
+}
+
+func (s *Session) runUpdate(statement *updateStatement, b binding) (*result, error) {
+	target, err := s.table(statement.table)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := b.restrictions(target, statement.where)
+	if err != nil {
+		return nil, err
+	}
+	key, err := target.primaryKey(restrictions)
+	if err != nil {
+		return nil, err
+	}
+	expires, err := b.expires(statement.ttl)
+	if err != nil {
+		return nil, err
+	}
+	updated := make(map[int]cell, len(statement.assignments))
+	position, found := target.find(key)
+	for _, current := range statement.assignments {
+		index, err := target.column(current.column)
+		if err != nil {
+			return nil, err
+		}
+		if target.isKey(index) {
+			return nil, fmt.Errorf("%w: PRIMARY KEY part %s found in SET part", ErrInvalidRequest, current.column)
+		}
+		typ := target.columns[index].typ
+		value, err := b.value(current.value, typ)
+		if err != nil {
+			return nil, err
+		}
+		if current.op != "=" {
+			var existing interface{}
+			if found {
+				existing = target.rows[position].cells[index].at(b.now)
+			}
+			if value, err = combine(typ, current.op, existing, value); err != nil {
+				return nil, fmt.Errorf("%w: column %s: %v", ErrInvalidRequest, current.column, err)
+			}
+		}
+		updated[index] = cell{value: value, expires: expires}
+	}
+	if statement.ifExists {
+		if !found {
+			return appliedResult(false, nil, nil), nil
+		}
+		if _, live := target.values(target.rows[position], b.now); !live {
+			return appliedResult(false, nil, nil), nil
+		}
+	}
+	stored := target.upsert(key)
+	for index, updatedCell := range updated {
+		stored.cells[index] = updatedCell
+	}
+	if statement.ifExists {
+		return appliedResult(true, nil, nil), nil
+	}
+	return &result{}, nil
+}
+
+// combine adds or removes value from the existing counter or collection.
+func combine(typ cqlType, op string, existing, value interface{}) (interface{}, error) {
+	switch {
+	case typ.integer():
+		var current, delta int64
+		if existing != nil {
+			current = existing.(int64)
+		}
+		if value != nil {
+			delta = value.(int64)
+		}
+		if op == "-" {
+			delta = -delta
+		}
+		return current + delta, nil
+	case typ.elem != nil:
+		var current, elems []interface{}
+		if existing != nil {
+			current = existing.([]interface{})
+		}
+		if value != nil {
+			elems = value.([]interface{})
+		}
+		if op == "+" {
+			combined := append(append([]interface{}(nil), current...), elems...)
+			if typ.name == "set" {
+				combined = sortSet(combined)
+			}
+			if len(combined) == 0 {
+				return nil, nil
+			}
+			return combined, nil
+		}
+		var kept []interface{}
+		for _, elem := range current {
+			removed := false
+			for _, remove := range elems {
+				if compareValues(elem, remove) == 0 {
+					removed = true
+				}
+			}
+			if !removed {
+				kept = append(kept, elem)
+			}
+		}
+		if len(kept) == 0 {
+			return nil, nil
+		}
+		return kept, nil
+	}
+	return nil, fmt.Errorf("invalid operation %s for non counter or collection type %s", op, typ)
+}
+
+func (s *Session) runDelete(statement *deleteStatement, b binding) (*result, error) {
+	target, err := s.table(statement.table)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := b.restrictions(target, statement.where)
+	if err != nil {
+		return nil, err
+	}
+	for _, current := range restrictions {
+		if !target.isKey(current.column) {
+			return nil, fmt.Errorf("%w: non PRIMARY KEY column %s found in where clause", ErrInvalidRequest, target.columns[current.column].name)
+		}
+	}
+	if !target.partitionRestricted(restrictions) {
+		return nil, fmt.Errorf("%w: some partition key parts are missing", ErrInvalidRequest)
+	}
+	columns := make([]int, len(statement.columns))
+	for index, name := range statement.columns {
+		if columns[index], err = target.column(name); err != nil {
+			return nil, err
+		}
+		if target.isKey(columns[index]) {
+			return nil, fmt.Errorf("%w: invalid identifier %s for deletion, it must not be part of the PRIMARY KEY", ErrInvalidRequest, name)
+		}
+	}
+	var (
+		kept    = make([]*row, 0, len(target.rows))
+		deleted []*row
+		live    bool
+	)
+	for _, stored := range target.rows {
+		if !matches(target.keyValues(stored), restrictions) {
+			kept = append(kept, stored)
+			continue
+		}
+		_, rowLive := target.values(stored, b.now)
+		live = live || rowLive
+		deleted = append(deleted, stored)
+	}
+	if statement.ifExists && !live {
+		return appliedResult(false, nil, nil), nil
+	}
+	if len(columns) == 0 {
+		target.rows = kept
+	}
+	for _, stored := range deleted {
+		for _, index := range columns {
+			stored.cells[index] = cell{}
+		}
+	}
+	if statement.ifExists {
+		return appliedResult(true, nil, nil), nil
+	}
+	return &result{}, nil
+}
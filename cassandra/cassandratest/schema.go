@@ -0,0 +1,316 @@
+package cassandratest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type column struct {
+	name string
+	typ  cqlType
+}
+
+// cell is a column value of a row, a cell written with a TTL is null after
+// it expires.
+type cell struct {
+	value   interface{}
+	expires time.Time
+}
+
+func (c cell) at(now time.Time) interface{} {
+	if !c.expires.IsZero() && !now.Before(c.expires) {
+		return nil
+	}
+	return c.value
+}
+
+// row is a stored row, the INSERT statements write the row marker that keeps
+// a row with null regular columns alive like cassandra does.
+type row struct {
+	cells         []cell
+	marker        bool
+	markerExpires time.Time
+}
+
+type table struct {
+	name       string
+	columns    []column
+	index      map[string]int
+	partition  []int
+	clustering []int
+	desc       []bool
+	rows       []*row
+}
+
+func (t *table) column(name string) (int, error) {
+	index, found := t.index[name]
+	if !found {
+		return 0, fmt.Errorf("%w: undefined column name %s in table %s", ErrInvalidRequest, name, t.name)
+	}
+	return index, nil
+}
+
+func (t *table) isKey(index int) bool {
+	for _, key := range t.partition {
+		if key == index {
+			return true
+		}
+	}
+	for _, key := range t.clustering {
+		if key == index {
+			return true
+		}
+	}
+	return false
+}
+
+// compareKeys compares the primary keys of two rows, the partitions in
+// ascending order and the clustering columns in the clustering order.
+func (t *table) compareKeys(a, b []interface{}) int {
+	for _, index := range t.partition {
+		if compared := compareValues(a[index], b[index]); compared != 0 {
+			return compared
+		}
+	}
+	for position, index := range t.clustering {
+		compared := compareValues(a[index], b[index])
+		if t.desc[position] {
+			compared = -compared
+		}
+		if compared != 0 {
+			return compared
+		}
+	}
+	return 0
+}
+
+// values returns the row values at now.
+func (t *table) values(stored *row, now time.Time) ([]interface{}, bool) {
+	var (
+		values = make([]interface{}, len(t.columns))
+		live   = stored.marker && (stored.markerExpires.IsZero() || now.Before(stored.markerExpires))
+	)
+	for index, stored := range stored.cells {
+		values[index] = stored.at(now)
+		if values[index] != nil && !t.isKey(index) {
+			live = true
+		}
+	}
+	return values, live
+}
+
+// find returns the position of the row of the key values, or the position
+// where it would be inserted.
+func (t *table) find(key []interface{}) (int, bool) {
+	position := sort.Search(len(t.rows), func(index int) bool {
+		return t.compareKeys(t.keyValues(t.rows[index]), key) >= 0
+	})
+	return position, position < len(t.rows) && t.compareKeys(t.keyValues(t.rows[position]), key) == 0
+}
+
+func (t *table) keyValues(stored *row) []interface{} {
+	values := make([]interface{}, len(t.columns))
+	for index := range t.columns {
+		if t.isKey(index) {
+			values[index] = stored.cells[index].value
+		}
+	}
+	return values
+}
+
+// upsert returns the row of the key values, a missing row is created.
+func (t *table) upsert(key []interface{}) *row {
+	position, found := t.find(key)
+	if found {
+		return t.rows[position]
+	}
+	created := &row{cells: make([]cell, len(t.columns))}
+	for index := range t.columns {
+		if t.isKey(index) {
+			created.cells[index].value = key[index]
+		}
+	}
+	t.rows = append(t.rows, nil)
+	copy(t.rows[position+1:], t.rows[position:])
+	t.rows[position] = created
+	return created
+}
+
+// parseCreateTable parses the statement after CREATE TABLE [IF NOT EXISTS]:
+// name (column type [PRIMARY KEY], ..., [PRIMARY KEY ((partition, ...),
+// clustering, ...)]) [WITH CLUSTERING ORDER BY (clustering ASC|DESC, ...)].
+// The other table options are ignored.
+func parseCreateTable(p *parser) (*table, error) {
+	name, err := p.tableName()
+	if err != nil {
+		return nil, err
+	}
+	created := &table{name: name, index: make(map[string]int)}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var partition, clustering []string
+	for index := 0; !p.symbol(")"); index++ {
+		if index > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		if p.keyword("PRIMARY", "KEY") {
+			if partition != nil {
+				return nil, fmt.Errorf("multiple primary keys in table %s", name)
+			}
+			if partition, clustering, err = parsePrimaryKey(p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		columnName, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := created.index[columnName]; exists {
+			return nil, fmt.Errorf("duplicate column %q", columnName)
+		}
+		typ, err := parseType(p)
+		if err != nil {
+			return nil, err
+		}
+		p.keyword("STATIC")
+		if p.keyword("PRIMARY", "KEY") {
+			if partition != nil {
+				return nil, fmt.Errorf("multiple primary keys in table %s", name)
+			}
+			partition = []string{columnName}
+		}
+		created.index[columnName] = len(created.columns)
+		created.columns = append(created.columns, column{name: columnName, typ: typ})
+	}
+	if partition == nil {
+		return nil, fmt.Errorf("table %s has no primary key", name)
+	}
+	for _, keyName := range append(partition, clustering...) {
+		index, found := created.index[keyName]
+		if !found {
+			return nil, fmt.Errorf("unknown primary key column %q", keyName)
+		}
+		if created.isKey(index) {
+			return nil, fmt.Errorf("duplicate primary key column %q", keyName)
+		}
+		if len(created.partition) < len(partition) {
+			created.partition = append(created.partition, index)
+			continue
+		}
+		created.clustering = append(created.clustering, index)
+		created.desc = append(created.desc, false)
+	}
+	created.sortColumns()
+	if p.keyword("WITH", "CLUSTERING", "ORDER", "BY") {
+		if err := parseClusteringOrder(p, created); err != nil {
+			return nil, err
+		}
+	}
+	for !p.done() {
+		p.next()
+	}
+	return created, nil
+}
+
+// sortColumns orders the columns like cassandra does: the partition columns,
+// the clustering columns and the regular columns by name.
+func (t *table) sortColumns() {
+	var (
+		keys    = append(append([]int(nil), t.partition...), t.clustering...)
+		regular []column
+		sorted  = make([]column, 0, len(t.columns))
+	)
+	for _, index := range keys {
+		sorted = append(sorted, t.columns[index])
+	}
+	for index, definition := range t.columns {
+		if !t.isKey(index) {
+			regular = append(regular, definition)
+		}
+	}
+	sort.Slice(regular, func(i, j int) bool { return regular[i].name < regular[j].name })
+	t.columns = append(sorted, regular...)
+	for index, definition := range t.columns {
+		t.index[definition.name] = index
+	}
+	for position := range t.partition {
+		t.partition[position] = position
+	}
+	for position := range t.clustering {
+		t.clustering[position] = len(t.partition) + position
+	}
+}
+
+// parsePrimaryKey parses the partition and clustering columns of a primary
+// key definition.
+func parsePrimaryKey(p *parser) ([]string, []string, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, nil, err
+	}
+	var keyNames []string
+	if p.symbol("(") {
+		for !p.symbol(")") {
+			if len(keyNames) > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return nil, nil, err
+				}
+			}
+			keyName, err := p.ident()
+			if err != nil {
+				return nil, nil, err
+			}
+			keyNames = append(keyNames, keyName)
+		}
+		if len(keyNames) == 0 {
+			return nil, nil, fmt.Errorf("blank partition key")
+		}
+		if !p.symbol(",") {
+			return keyNames, nil, p.expectSymbol(")")
+		}
+	}
+	partition := len(keyNames)
+	for {
+		keyName, err := p.ident()
+		if err != nil {
+			return nil, nil, err
+		}
+		keyNames = append(keyNames, keyName)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if partition == 0 {
+		partition = 1
+	}
+	return keyNames[:partition], keyNames[partition:], p.expectSymbol(")")
+}
+
+func parseClusteringOrder(p *parser, created *table) error {
+	if err := p.expectSymbol("("); err != nil {
+		return err
+	}
+	for position := 0; !p.symbol(")"); position++ {
+		if position > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return err
+			}
+		}
+		columnName, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if position >= len(created.clustering) || created.columns[created.clustering[position]].name != columnName {
+			return fmt.Errorf("clustering order of table %s must follow the clustering columns", created.name)
+		}
+		created.desc[position] = p.keyword("DESC")
+		if !created.desc[position] {
+			p.keyword("ASC")
+		}
+	}
+	return nil
+}
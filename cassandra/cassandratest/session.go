@@ -0,0 +1,281 @@
+// Package cassandratest provides an in-memory cassandra.Session for the tests
+// of raizel cassandra repositories and migrations, without a cassandra node.
+package cassandratest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel/cassandra"
+)
+
+var (
+	ErrSyntax         = errors.New("err_syntax")
+	ErrInvalidRequest = errors.New("err_invalidrequest")
+)
+
+// Session is an in-memory cassandra.Session that runs the CQL the raizel
+// cassandra package generates: CREATE and DROP TABLE, SELECT with WHERE
+// relations on the table columns, ORDER BY and LIMIT, INSERT, UPDATE and
+// DELETE by primary key with IF [NOT] EXISTS conditions and TTLs. The WHERE
+// restrictions follow the cassandra rules, so a query that filters without
+// ALLOW FILTERING fails like it fails on a cassandra node.
+//
+// A query with a page size reads one page and returns the state of the next
+// page, like a query with a page state does on gocql.
+type Session struct {
+	mu     sync.Mutex
+	tables map[string]*table
+	offset time.Duration
+	closed bool
+}
+
+var _ cassandra.Session = (*Session)(nil)
+
+// NewSession returns a Session with the tables of the CQL statements.
+func NewSession(statements ...string) (*Session, error) {
+	session := &Session{tables: make(map[string]*table)}
+	for _, statement := range statements {
+		if err := session.Query(statement).Exec(); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// Advance moves the session clock forward, the cells written with a TTL
+// expire when the clock passes their TTL.
+func (s *Session) Advance(duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += duration
+}
+
+// Reset removes the rows of every table.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.tables {
+		stored.rows = nil
+	}
+}
+
+// Close closes the session, the queries of a closed session fail with
+// gocql.ErrSessionClosed.
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Query returns the query of the statement, it runs when it is executed,
+// scanned or iterated.
+func (s *Session) Query(statement string, values ...interface{}) cassandra.Query {
+	return &query{session: s, statement: statement, values: values, consistency: gocql.Quorum}
+}
+
+func (s *Session) now() time.Time {
+	return time.Now().Add(s.offset).UTC()
+}
+
+// result is the columns and rows of a statement, a conditional statement has
+// one row with the [applied] column first.
+type result struct {
+	columns   []column
+	rows      [][]interface{}
+	pageState []byte
+}
+
+var appliedColumn = column{name: "[applied]", typ: cqlType{name: "boolean"}}
+
+func appliedResult(applied bool, existing *table, values []interface{}) *result {
+	if existing == nil {
+		return &result{columns: []column{appliedColumn}, rows: [][]interface{}{{applied}}}
+	}
+	return &result{
+		columns: append([]column{appliedColumn}, existing.columns...),
+		rows:    [][]interface{}{append([]interface{}{applied}, values...)},
+	}
+}
+
+type query struct {
+	session     *Session
+	statement   string
+	values      []interface{}
+	consistency gocql.Consistency
+	pageSize    int
+	pageState   []byte
+}
+
+func (q *query) run() (*result, error) {
+	return q.session.execute(q.statement, q.values, q.pageSize, q.pageState)
+}
+
+// Scan scans the first row, a statement without rows fails with
+// gocql.ErrNotFound.
+func (q *query) Scan(dest ...interface{}) error {
+	executed, err := q.run()
+	if err != nil {
+		return err
+	}
+	if len(executed.rows) == 0 {
+		return gocql.ErrNotFound
+	}
+	return scanRow(executed.rows[0], dest)
+}
+
+// ScanCAS runs a conditional statement and reports whether it was applied,
+// the existing row is scanned into dest when it was not.
+func (q *query) ScanCAS(dest ...interface{}) (bool, error) {
+	executed, err := q.run()
+	if err != nil {
+		return false, err
+	}
+	if len(executed.rows) == 0 || len(executed.columns) == 0 || executed.columns[0] != appliedColumn {
+		return false, fmt.Errorf("%w: statement is not conditional", ErrInvalidRequest)
+	}
+	applied := executed.rows[0][0].(bool)
+	if len(executed.columns) > 1 {
+		if err := scanRow(executed.rows[0][1:], dest); err != nil {
+			return false, err
+		}
+	}
+	return applied, nil
+}
+
+func (q *query) Exec() error {
+	_, err := q.run()
+	return err
+}
+
+func (q *query) Iter() cassandra.Iter {
+	executed, err := q.run()
+	if err != nil {
+		return &iter{err: err}
+	}
+	return &iter{columns: executed.columns, rows: executed.rows, pageState: executed.pageState}
+}
+
+func (q *query) Consistency(consistency gocql.Consistency) cassandra.Query {
+	q.consistency = consistency
+	return q
+}
+
+func (q *query) PageSize(size int) cassandra.Query {
+	q.pageSize = size
+	return q
+}
+
+func (q *query) PageState(state []byte) cassandra.Query {
+	q.pageState = state
+	return q
+}
+
+func (q *query) Release() {}
+
+func (q *query) String() string {
+	return fmt.Sprintf("[query statement=%q values=%+v consistency=%s]", q.statement, q.values, q.consistency)
+}
+
+func scanRow(values []interface{}, dest []interface{}) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("%w: %d columns scanned into %d destinations", ErrInvalidRequest, len(values), len(dest))
+	}
+	for index, value := range values {
+		if err := assign(dest[index], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iter is the cassandra.Iter and gocql.Scanner of the rows of one page.
+type iter struct {
+	columns   []column
+	rows      [][]interface{}
+	pos       int
+	current   []interface{}
+	pageState []byte
+	err       error
+}
+
+func (i *iter) Close() error {
+	return i.err
+}
+
+// MapScan reads the next row into row with the go types gocql unmarshals
+// the columns to.
+func (i *iter) MapScan(row map[string]interface{}) bool {
+	if i.err != nil || i.pos >= len(i.rows) {
+		return false
+	}
+	for index, definition := range i.columns {
+		row[definition.name] = nativeValue(definition.typ, i.rows[i.pos][index])
+	}
+	i.pos++
+	return true
+}
+
+func (i *iter) NumRows() int {
+	return len(i.rows)
+}
+
+func (i *iter) PageState() []byte {
+	return i.pageState
+}
+
+func (i *iter) Scanner() gocql.Scanner {
+	return i
+}
+
+func (i *iter) Next() bool {
+	if i.err != nil || i.pos >= len(i.rows) {
+		i.current = nil
+		return false
+	}
+	i.current = i.rows[i.pos]
+	i.pos++
+	return true
+}
+
+func (i *iter) Scan(dest ...interface{}) error {
+	if i.current == nil {
+		return errors.New("Scan called without calling Next")
+	}
+	return scanRow(i.current, dest)
+}
+
+func (i *iter) Err() error {
+	return i.err
+}
+
+// pageOf returns the page of the rows, the page state is the offset of the
+// next page.
+func pageOf(rows [][]interface{}, size int, state []byte) ([][]interface{}, []byte, error) {
+	offset := 0
+	if len(state) > 0 {
+		parsed, err := strconv.Atoi(string(state))
+		if err != nil || parsed < 0 {
+			return nil, nil, fmt.Errorf("%w: invalid page state", ErrInvalidRequest)
+		}
+		offset = parsed
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	end := offset + size
+	if end >= len(rows) {
+		return rows[offset:], nil, nil
+	}
+	return rows[offset:end], []byte(strconv.Itoa(end)), nil
+}
@@ -0,0 +1,438 @@
+package cassandratest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	"github.com/rjansen/raizel/cassandra/cassandratest"
+	"github.com/rjansen/raizel/migrate"
+	"github.com/stretchr/testify/require"
+)
+
+var testCQL = []string{
+	`CREATE KEYSPACE IF NOT EXISTS shop WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}`,
+	`CREATE TABLE IF NOT EXISTS shop.orders (
+		customer text,
+		order_id int,
+		total double,
+		tags set<text>,
+		items list<text>,
+		PRIMARY KEY ((customer), order_id)
+	) WITH CLUSTERING ORDER BY (order_id DESC)`,
+	`CREATE TABLE shop.hits (page text PRIMARY KEY, total counter)`,
+	`CREATE TABLE users (
+		id text PRIMARY KEY,
+		name text,
+		age int,
+		created_at timestamp,
+		updated_at timestamp
+	)`,
+	`CREATE TABLE items (
+		order_id text,
+		item_id bigint,
+		quantity bigint,
+		PRIMARY KEY (order_id, item_id)
+	)`,
+}
+
+type user struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	Age       int       `db:"age"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type item struct {
+	OrderID  string `db:"order_id"`
+	ItemID   int64  `db:"item_id"`
+	Quantity int64  `db:"quantity"`
+}
+
+func newTestSession(t *testing.T) *cassandratest.Session {
+	session, err := cassandratest.NewSession(testCQL...)
+	require.Nil(t, err, "new session error")
+	return session
+}
+
+func setupOrders(t *testing.T, session *cassandratest.Session) {
+	for _, order := range []struct {
+		customer string
+		orderID  int
+		total    float64
+	}{
+		{customer: "ann", orderID: 1, total: 10},
+		{customer: "ann", orderID: 2, total: 20},
+		{customer: "ann", orderID: 3, total: 30},
+		{customer: "bob", orderID: 1, total: 5},
+	} {
+		err := session.Query(
+			"INSERT INTO shop.orders (customer,order_id,total) VALUES (?,?,?)", order.customer, order.orderID, order.total,
+		).Exec()
+		require.Nil(t, err, "setup error")
+	}
+}
+
+func readRows(iter cassandra.Iter) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			return rows
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestSessionQuery(test *testing.T) {
+	session := newTestSession(test)
+	defer session.Close()
+	setupOrders(test, session)
+
+	scenarios := []struct {
+		name   string
+		cql    string
+		values []interface{}
+		rows   []map[string]interface{}
+		err    error
+	}{
+		{
+			name:   "Select partition in clustering order",
+			cql:    "SELECT order_id,total FROM shop.orders WHERE customer=?",
+			values: []interface{}{"ann"},
+			rows: []map[string]interface{}{
+				{"order_id": 3, "total": float64(30)},
+				{"order_id": 2, "total": float64(20)},
+				{"order_id": 1, "total": float64(10)},
+			},
+		},
+		{
+			name:   "Select partition in reverse clustering order",
+			cql:    "SELECT order_id FROM shop.orders WHERE customer=? ORDER BY order_id ASC",
+			values: []interface{}{"ann"},
+			rows:   []map[string]interface{}{{"order_id": 1}, {"order_id": 2}, {"order_id": 3}},
+		},
+		{
+			name:   "Select clustering range with limit",
+			cql:    "SELECT order_id FROM shop.orders WHERE customer=? AND order_id<=? LIMIT 1",
+			values: []interface{}{"ann", 2},
+			rows:   []map[string]interface{}{{"order_id": 2}},
+		},
+		{
+			name:   "Select partitions in",
+			cql:    "SELECT customer FROM shop.orders WHERE customer IN ? AND order_id=1",
+			values: []interface{}{[]string{"bob", "ann", "cid"}},
+			rows:   []map[string]interface{}{{"customer": "ann"}, {"customer": "bob"}},
+		},
+		{
+			name: "Select with literals",
+			cql:  "SELECT order_id FROM shop.orders WHERE customer IN ('ann') AND order_id > 1 AND order_id < 3",
+			rows: []map[string]interface{}{{"order_id": 2}},
+		},
+		{
+			name:   "Count partition",
+			cql:    "SELECT count(*) FROM shop.orders WHERE customer=?",
+			values: []interface{}{"ann"},
+			rows:   []map[string]interface{}{{"count": int64(3)}},
+		},
+		{
+			name:   "Filter regular column",
+			cql:    "SELECT order_id FROM shop.orders WHERE total>=? ALLOW FILTERING",
+			values: []interface{}{20},
+			rows:   []map[string]interface{}{{"order_id": 3}, {"order_id": 2}},
+		},
+		{
+			name:   "Filter regular column without allow filtering",
+			cql:    "SELECT order_id FROM shop.orders WHERE total>=?",
+			values: []interface{}{20},
+			err:    cassandratest.ErrInvalidRequest,
+		},
+		{
+			name:   "Filter clustering column without partition",
+			cql:    "SELECT order_id FROM shop.orders WHERE order_id=?",
+			values: []interface{}{1},
+			err:    cassandratest.ErrInvalidRequest,
+		},
+		{
+			name: "Order without partition",
+			cql:  "SELECT order_id FROM shop.orders ORDER BY order_id",
+			err:  cassandratest.ErrInvalidRequest,
+		},
+		{
+			name:   "Order by regular column",
+			cql:    "SELECT order_id FROM shop.orders WHERE customer=? ORDER BY total",
+			values: []interface{}{"ann"},
+			err:    cassandratest.ErrInvalidRequest,
+		},
+		{
+			name: "Unconfigured table",
+			cql:  "SELECT * FROM shop.missing",
+			err:  cassandratest.ErrInvalidRequest,
+		},
+		{
+			name: "Missing bound values",
+			cql:  "SELECT * FROM shop.orders WHERE customer=?",
+			err:  cassandratest.ErrInvalidRequest,
+		},
+		{
+			name:   "Invalid bound value",
+			cql:    "SELECT * FROM shop.orders WHERE customer=?",
+			values: []interface{}{1},
+			err:    cassandratest.ErrInvalidRequest,
+		},
+		{
+			name: "Syntax error",
+			cql:  "SELECT FROM WHERE",
+			err:  cassandratest.ErrSyntax,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				iter := session.Query(scenario.cql, scenario.values...).Iter()
+				rows := readRows(iter)
+				err := iter.Close()
+				if scenario.err != nil {
+					require.True(t, errors.Is(err, scenario.err), "query error: %v", err)
+					return
+				}
+				require.Nil(t, err, "query error")
+				require.Equal(t, scenario.rows, rows, "query rows")
+			},
+		)
+	}
+}
+
+func TestSessionWrites(test *testing.T) {
+	var (
+		session = newTestSession(test)
+		key     = []interface{}{"ann", 1}
+		total   float64
+		tags    []string
+		items   []string
+	)
+	defer session.Close()
+
+	applied, err := session.Query(
+		"INSERT INTO shop.orders (customer,order_id,total,tags) VALUES (?,?,?,?) IF NOT EXISTS", "ann", 1, 10, []string{"b", "a", "b"},
+	).ScanCAS()
+	require.Nil(test, err, "insert error")
+	require.True(test, applied, "insert applied")
+	var (
+		customer string
+		orderID  int
+	)
+	applied, err = session.Query(
+		"INSERT INTO shop.orders (customer,order_id,total) VALUES (?,?,?) IF NOT EXISTS", "ann", 1, 20,
+	).ScanCAS(&customer, &orderID, &items, &tags, &total)
+	require.Nil(test, err, "insert existing error")
+	require.False(test, applied, "insert existing applied")
+	require.Equal(test, float64(10), total, "existing total")
+	require.Equal(test, []string{"a", "b"}, tags, "existing tags")
+
+	err = session.Query(
+		"UPDATE shop.orders SET total=?,tags=tags-?,items=items+? WHERE customer=? AND order_id=?",
+		append([]interface{}{15, []string{"a"}, []string{"x", "y"}}, key...)...,
+	).Exec()
+	require.Nil(test, err, "update error")
+	err = session.Query("SELECT total,tags,items FROM shop.orders WHERE customer=? AND order_id=?", key...).
+		Scan(&total, &tags, &items)
+	require.Nil(test, err, "select updated error")
+	require.Equal(test, float64(15), total, "updated total")
+	require.Equal(test, []string{"b"}, tags, "updated tags")
+	require.Equal(test, []string{"x", "y"}, items, "updated items")
+
+	applied, err = session.Query("UPDATE shop.orders SET total=1 WHERE customer='bob' AND order_id=1 IF EXISTS").ScanCAS()
+	require.Nil(test, err, "update missing error")
+	require.False(test, applied, "update missing applied")
+	err = session.Query("UPDATE shop.orders SET total=1 WHERE customer='bob' AND order_id=1").Exec()
+	require.Nil(test, err, "upsert error")
+	err = session.Query("SELECT total FROM shop.orders WHERE customer='bob' AND order_id=1").Scan(&total)
+	require.Nil(test, err, "select upserted error")
+	require.Equal(test, float64(1), total, "upserted total")
+	err = session.Query("UPDATE shop.orders SET customer=? WHERE customer='bob' AND order_id=1", "cid").Exec()
+	require.True(test, errors.Is(err, cassandratest.ErrInvalidRequest), "update key error: %v", err)
+	err = session.Query("UPDATE shop.orders SET total=1 WHERE customer='bob'").Exec()
+	require.True(test, errors.Is(err, cassandratest.ErrInvalidRequest), "update partition error: %v", err)
+
+	for _, delta := range []int64{2, 3, -1} {
+		err = session.Query("UPDATE shop.hits SET total=total+? WHERE page=?", delta, "home").Exec()
+		require.Nil(test, err, "increment error")
+	}
+	var hits int64
+	require.Nil(test, session.Query("SELECT total FROM shop.hits WHERE page='home'").Scan(&hits), "select hits error")
+	require.Equal(test, int64(4), hits, "hits")
+
+	err = session.Query("DELETE total FROM shop.orders WHERE customer=? AND order_id=?", key...).Exec()
+	require.Nil(test, err, "delete column error")
+	err = session.Query("SELECT total FROM shop.orders WHERE customer=? AND order_id=?", key...).Scan(&total)
+	require.Nil(test, err, "select deleted column error")
+	require.Zero(test, total, "deleted total")
+	applied, err = session.Query("DELETE FROM shop.orders WHERE customer=? AND order_id=? IF EXISTS", key...).ScanCAS()
+	require.Nil(test, err, "delete error")
+	require.True(test, applied, "delete applied")
+	err = session.Query("SELECT total FROM shop.orders WHERE customer=? AND order_id=?", key...).Scan(&total)
+	require.Equal(test, gocql.ErrNotFound, err, "select deleted error")
+
+	err = session.Query("INSERT INTO shop.orders (customer,order_id,total) VALUES (?,?,?) USING TTL ?", "ann", 2, 10, 60).Exec()
+	require.Nil(test, err, "insert ttl error")
+	err = session.Query("UPDATE shop.orders USING TTL 10 SET items=['z'] WHERE customer='ann' AND order_id=2").Exec()
+	require.Nil(test, err, "update ttl error")
+	session.Advance(30 * time.Second)
+	err = session.Query("SELECT total,items FROM shop.orders WHERE customer='ann' AND order_id=2").Scan(&total, &items)
+	require.Nil(test, err, "select ttl error")
+	require.Equal(test, float64(10), total, "ttl total")
+	require.Empty(test, items, "expired items")
+	session.Advance(time.Minute)
+	err = session.Query("SELECT total FROM shop.orders WHERE customer='ann' AND order_id=2").Scan(&total)
+	require.Equal(test, gocql.ErrNotFound, err, "select expired error")
+
+	session.Reset()
+	err = session.Query("SELECT * FROM shop.hits WHERE page='home'").Scan(&hits)
+	require.Equal(test, gocql.ErrNotFound, err, "select reset error")
+	session.Close()
+	require.True(test, session.Closed(), "closed")
+	require.Equal(test, gocql.ErrSessionClosed, session.Query("SELECT * FROM shop.hits").Exec(), "closed error")
+}
+
+func TestSessionPages(test *testing.T) {
+	session := newTestSession(test)
+	defer session.Close()
+	setupOrders(test, session)
+
+	var (
+		state    []byte
+		orderIDs []int
+	)
+	for pages := 0; ; pages++ {
+		require.True(test, pages < 3, "too many pages")
+		var (
+			iter    = session.Query("SELECT order_id FROM shop.orders").PageSize(3).PageState(state).Iter()
+			scanner = iter.Scanner()
+		)
+		for scanner.Next() {
+			var orderID int
+			require.Nil(test, scanner.Scan(&orderID), "scan error")
+			orderIDs = append(orderIDs, orderID)
+		}
+		require.Nil(test, scanner.Err(), "scanner error")
+		require.Nil(test, iter.Close(), "close error")
+		if state = iter.PageState(); len(state) == 0 {
+			break
+		}
+	}
+	require.Equal(test, []int{3, 2, 1, 1}, orderIDs, "order ids")
+
+	iter := session.Query("SELECT order_id FROM shop.orders").PageSize(3).PageState([]byte("page")).Iter()
+	require.True(test, errors.Is(iter.Close(), cassandratest.ErrInvalidRequest), "invalid page state error")
+}
+
+func TestSessionRepository(test *testing.T) {
+	session := newTestSession(test)
+	defer session.Close()
+	var (
+		ctx        = context.Background()
+		repository = cassandra.NewRepository(session)
+		key        = raizel.NewDynamicKey("users", "id", "ann")
+		createdAt  = time.Date(2019, 10, 3, 10, 0, 0, 0, time.UTC)
+		stored     = user{ID: "ann", Name: "Ann", Age: 30, CreatedAt: createdAt, UpdatedAt: createdAt}
+		entity     user
+	)
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key, &entity), "get missing error")
+	require.Nil(test, repository.Set(ctx, key, stored), "set error")
+	require.Nil(test, repository.Get(ctx, key, &entity), "get error")
+	require.Equal(test, stored, entity, "entity")
+
+	exists, err := repository.Exists(ctx, key)
+	require.Nil(test, err, "exists error")
+	require.True(test, exists, "exists")
+	require.Nil(test, repository.Patch(ctx, key, raizel.Assign("age", 31), raizel.ServerTimestamp("updated_at")), "patch error")
+	require.Nil(test, repository.Get(ctx, key, &entity), "get patched error")
+	require.Equal(test, 31, entity.Age, "patched age")
+	require.True(test, entity.UpdatedAt.After(createdAt), "patched updated at")
+
+	require.Nil(test, repository.Delete(ctx, key), "delete error")
+	exists, err = repository.Exists(ctx, key)
+	require.Nil(test, err, "exists deleted error")
+	require.False(test, exists, "exists deleted")
+
+	parent := raizel.NewDynamicKey("orders", "order_id", "order1")
+	for itemID := int64(1); itemID <= 3; itemID++ {
+		key := raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "item_id", itemID))
+		require.Nil(test, repository.Set(ctx, key, &item{OrderID: "order1", ItemID: itemID, Quantity: itemID * 10}), "set item error")
+	}
+	var child item
+	childKey := raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "item_id", int64(2)))
+	require.Nil(test, repository.Get(ctx, childKey, &child), "get item error")
+	require.Equal(test, item{OrderID: "order1", ItemID: 2, Quantity: 20}, child, "item")
+
+	query := raizel.Children(parent, "items").Where("item_id", raizel.GreaterEqual, int64(2)).OrderBy("item_id", raizel.Desc)
+	count, err := raizel.Count(ctx, repository, query)
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(2), count, "count")
+
+	page, err := repository.Page(ctx, query.WithLimit(1), "")
+	require.Nil(test, err, "page error")
+	require.Nil(test, page.Next(ctx, &child), "page next error")
+	require.Equal(test, int64(3), child.ItemID, "first page item")
+	require.Equal(test, raizel.ErrIteratorDone, page.Next(ctx, &child), "first page done")
+	next, err := repository.Page(ctx, query.WithLimit(1), page.NextPageToken())
+	require.Nil(test, err, "next page error")
+	require.Nil(test, next.Next(ctx, &child), "next page next error")
+	require.Equal(test, int64(2), child.ItemID, "next page item")
+	require.Equal(test, raizel.ErrIteratorDone, next.Next(ctx, &child), "next page done")
+	require.Empty(test, next.NextPageToken(), "last page token")
+
+	_, err = raizel.Count(ctx, repository, raizel.Children(parent, "items").Where("quantity", raizel.Equal, int64(10)))
+	require.True(test, errors.Is(err, cassandratest.ErrInvalidRequest), "count filtering error: %v", err)
+}
+
+func TestSessionMigrations(test *testing.T) {
+	session := newTestSession(test)
+	defer session.Close()
+	var (
+		ctx        = context.Background()
+		driver     = cassandra.NewMigrationDriver(session)
+		migrations = []migrate.Migration{
+			{
+				Version: 1,
+				Name:    "create_carts",
+				Up:      "CREATE TABLE carts (id text PRIMARY KEY, total double);",
+				Down:    "DROP TABLE carts;",
+			},
+			{
+				Version: 2,
+				Name:    "seed_carts",
+				Up:      "INSERT INTO carts (id, total) VALUES ('cart1', 10.5);",
+				Down:    "DELETE FROM carts WHERE id = 'cart1';",
+			},
+		}
+	)
+	migrator, err := migrate.NewMigrator(driver, migrations)
+	require.Nil(test, err, "new migrator error")
+	steps, err := migrator.Up(ctx)
+	require.Nil(test, err, "up error")
+	require.Len(test, steps, 2, "up steps")
+	var total float64
+	require.Nil(test, session.Query("SELECT total FROM carts WHERE id='cart1'").Scan(&total), "select seeded error")
+	require.Equal(test, 10.5, total, "seeded total")
+
+	require.Nil(test, driver.Lock(ctx), "lock error")
+	require.Equal(test, migrate.ErrLocked, driver.Lock(ctx), "locked error")
+	require.Nil(test, driver.Unlock(ctx), "unlock error")
+
+	steps, err = migrator.Down(ctx)
+	require.Nil(test, err, "down error")
+	require.Len(test, steps, 1, "down steps")
+	records, err := driver.Applied(ctx)
+	require.Nil(test, err, "applied error")
+	require.Len(test, records, 1, "applied records")
+	require.Equal(test, int64(1), records[0].Version, "applied version")
+}
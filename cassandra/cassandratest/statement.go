@@ -0,0 +1,412 @@
+package cassandratest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// term is a bind marker, a literal, null, the current time function or a
+// collection literal of terms.
+type term struct {
+	marker     int
+	literal    token
+	null       bool
+	now        bool
+	collection []term
+}
+
+type relation struct {
+	column string
+	op     string
+	value  term
+}
+
+type ordering struct {
+	column string
+	desc   bool
+}
+
+type assignment struct {
+	column string
+	op     string
+	value  term
+}
+
+type selectStatement struct {
+	table          string
+	columns        []string
+	count          bool
+	where          []relation
+	orders         []ordering
+	limit          *term
+	allowFiltering bool
+}
+
+type insertStatement struct {
+	table       string
+	columns     []string
+	values      []term
+	ifNotExists bool
+	ttl         *term
+}
+
+type updateStatement struct {
+	table       string
+	assignments []assignment
+	where       []relation
+	ifExists    bool
+	ttl         *term
+}
+
+type deleteStatement struct {
+	table    string
+	columns  []string
+	where    []relation
+	ifExists bool
+}
+
+// parseTerm parses a term, the bind markers are numbered in the statement
+// order.
+func parseTerm(p *parser) (term, error) {
+	current := p.peek()
+	switch {
+	case current.kind == markerToken:
+		p.next()
+		p.markers++
+		return term{marker: p.markers}, nil
+	case current.kind == numberToken, current.kind == stringToken:
+		p.next()
+		return term{literal: current}, nil
+	case current.kind == symbolToken && current.text == "-":
+		p.next()
+		number := p.next()
+		if number.kind != numberToken {
+			return term{}, fmt.Errorf("expected number, found %q", number.text)
+		}
+		return term{literal: token{kind: numberToken, text: "-" + number.text}}, nil
+	case current.kind == symbolToken && (current.text == "[" || current.text == "{"):
+		p.next()
+		closing := "]"
+		if current.text == "{" {
+			closing = "}"
+		}
+		collection := make([]term, 0)
+		for !p.symbol(closing) {
+			if len(collection) > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return term{}, err
+				}
+			}
+			elem, err := parseTerm(p)
+			if err != nil {
+				return term{}, err
+			}
+			collection = append(collection, elem)
+		}
+		return term{collection: collection}, nil
+	case p.keyword("NULL"):
+		return term{null: true}, nil
+	case p.isKeyword("TRUE"), p.isKeyword("FALSE"):
+		return term{literal: p.next()}, nil
+	case p.keyword("toTimestamp"):
+		if err := p.expectSymbol("("); err != nil {
+			return term{}, err
+		}
+		if err := p.expectKeyword("now"); err != nil {
+			return term{}, err
+		}
+		for _, symbol := range []string{"(", ")", ")"} {
+			if err := p.expectSymbol(symbol); err != nil {
+				return term{}, err
+			}
+		}
+		return term{now: true}, nil
+	case p.keyword("now"):
+		for _, symbol := range []string{"(", ")"} {
+			if err := p.expectSymbol(symbol); err != nil {
+				return term{}, err
+			}
+		}
+		return term{now: true}, nil
+	}
+	return term{}, fmt.Errorf("unexpected term %q", current.text)
+}
+
+// parseIdents parses a comma separated list of identifiers.
+func parseIdents(p *parser) ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.symbol(",") {
+			return names, nil
+		}
+	}
+}
+
+// parseWhere parses the relations after WHERE, a relation is column op term
+// or column IN (term, ...) or column IN ?.
+func parseWhere(p *parser) ([]relation, error) {
+	var relations []relation
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		current := relation{column: name}
+		switch {
+		case p.keyword("IN"):
+			current.op = "IN"
+			if p.symbol("(") {
+				collection := make([]term, 0)
+				for !p.symbol(")") {
+					if len(collection) > 0 {
+						if err := p.expectSymbol(","); err != nil {
+							return nil, err
+						}
+					}
+					elem, err := parseTerm(p)
+					if err != nil {
+						return nil, err
+					}
+					collection = append(collection, elem)
+				}
+				current.value = term{collection: collection}
+			} else if current.value, err = parseTerm(p); err != nil {
+				return nil, err
+			}
+		default:
+			operator := p.next()
+			switch operator.text {
+			case "=", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("unsupported relation operator %q", operator.text)
+			}
+			current.op = operator.text
+			if current.value, err = parseTerm(p); err != nil {
+				return nil, err
+			}
+		}
+		relations = append(relations, current)
+		if !p.keyword("AND") {
+			return relations, nil
+		}
+	}
+}
+
+// parseTTL parses the TTL after USING.
+func parseTTL(p *parser) (*term, error) {
+	if err := p.expectKeyword("TTL"); err != nil {
+		return nil, err
+	}
+	ttl, err := parseTerm(p)
+	if err != nil {
+		return nil, err
+	}
+	return &ttl, nil
+}
+
+// parseSelect parses the statement after SELECT: * | count(*) | column, ...
+// FROM table [WHERE relations] [ORDER BY column [ASC|DESC], ...] [LIMIT n]
+// [ALLOW FILTERING].
+func parseSelect(p *parser) (*selectStatement, error) {
+	var (
+		statement = new(selectStatement)
+		err       error
+	)
+	switch {
+	case p.symbol("*"):
+	case p.isKeyword("count") && len(p.tokens) > p.pos+1 && p.tokens[p.pos+1].text == "(":
+		p.next()
+		p.next()
+		if !p.symbol("*") && p.next().text != "1" {
+			return nil, fmt.Errorf("expected count(*)")
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		statement.count = true
+	default:
+		if statement.columns, err = parseIdents(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if statement.table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if p.keyword("WHERE") {
+		if statement.where, err = parseWhere(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("ORDER", "BY") {
+		for {
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			order := ordering{column: name, desc: p.keyword("DESC")}
+			if !order.desc {
+				p.keyword("ASC")
+			}
+			statement.orders = append(statement.orders, order)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("LIMIT") {
+		limit, err := parseTerm(p)
+		if err != nil {
+			return nil, err
+		}
+		statement.limit = &limit
+	}
+	statement.allowFiltering = p.keyword("ALLOW", "FILTERING")
+	return statement, nil
+}
+
+// parseInsert parses the statement after INSERT INTO: table (column, ...)
+// VALUES (term, ...) [IF NOT EXISTS] [USING TTL ttl], the condition and the
+// TTL are accepted in any order.
+func parseInsert(p *parser) (*insertStatement, error) {
+	var (
+		statement = new(insertStatement)
+		err       error
+	)
+	if statement.table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	if statement.columns, err = parseIdents(p); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.symbol(")") {
+		if len(statement.values) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		value, err := parseTerm(p)
+		if err != nil {
+			return nil, err
+		}
+		statement.values = append(statement.values, value)
+	}
+	if len(statement.values) != len(statement.columns) {
+		return nil, fmt.Errorf("unmatched column names and values: %d and %d", len(statement.columns), len(statement.values))
+	}
+	for !p.done() {
+		switch {
+		case p.keyword("IF", "NOT", "EXISTS"):
+			statement.ifNotExists = true
+		case p.keyword("USING"):
+			if statement.ttl, err = parseTTL(p); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q after INSERT", p.peek().text)
+		}
+	}
+	return statement, nil
+}
+
+// parseUpdate parses the statement after UPDATE: table [USING TTL ttl] SET
+// column = term | column = column + term | column = column - term, ...
+// WHERE relations [IF EXISTS].
+func parseUpdate(p *parser) (*updateStatement, error) {
+	var (
+		statement = new(updateStatement)
+		err       error
+	)
+	if statement.table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if p.keyword("USING") {
+		if statement.ttl, err = parseTTL(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, err
+		}
+		current := assignment{column: name, op: "="}
+		if next := p.peek(); (next.kind == identToken && strings.ToLower(next.text) == name) ||
+			(next.kind == quotedIdentToken && next.text == name) {
+			p.next()
+			operator := p.next()
+			if operator.text != "+" && operator.text != "-" {
+				return nil, fmt.Errorf("unsupported operation %q", operator.text)
+			}
+			current.op = operator.text
+		}
+		if current.value, err = parseTerm(p); err != nil {
+			return nil, err
+		}
+		statement.assignments = append(statement.assignments, current)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if err := p.expectKeyword("WHERE"); err != nil {
+		return nil, err
+	}
+	if statement.where, err = parseWhere(p); err != nil {
+		return nil, err
+	}
+	statement.ifExists = p.keyword("IF", "EXISTS")
+	return statement, nil
+}
+
+// parseDelete parses the statement after DELETE: [column, ...] FROM table
+// WHERE relations [IF EXISTS].
+func parseDelete(p *parser) (*deleteStatement, error) {
+	var (
+		statement = new(deleteStatement)
+		err       error
+	)
+	if !p.isKeyword("FROM") {
+		if statement.columns, err = parseIdents(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if statement.table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("WHERE"); err != nil {
+		return nil, err
+	}
+	if statement.where, err = parseWhere(p); err != nil {
+		return nil, err
+	}
+	statement.ifExists = p.keyword("IF", "EXISTS")
+	return statement, nil
+}
@@ -0,0 +1,335 @@
+package cassandratest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel"
+)
+
+// cqlType is a column type, elem is the element type of the list and set
+// types. The values of a type are stored as string, int64, float64, bool,
+// time.Time, gocql.UUID, []byte or []interface{}.
+type cqlType struct {
+	name string
+	elem *cqlType
+}
+
+func (t cqlType) String() string {
+	if t.elem != nil {
+		return fmt.Sprintf("%s<%s>", t.name, t.elem)
+	}
+	return t.name
+}
+
+// parseType parses a column type, the frozen types are the type itself.
+func parseType(p *parser) (cqlType, error) {
+	name, err := p.ident()
+	if err != nil {
+		return cqlType{}, err
+	}
+	switch name {
+	case "text", "varchar", "ascii":
+		return cqlType{name: "text"}, nil
+	case "int", "bigint", "smallint", "tinyint", "varint", "counter",
+		"float", "double", "boolean", "timestamp", "uuid", "timeuuid", "blob":
+		return cqlType{name: name}, nil
+	case "frozen":
+		if err := p.expectSymbol("<"); err != nil {
+			return cqlType{}, err
+		}
+		frozen, err := parseType(p)
+		if err != nil {
+			return cqlType{}, err
+		}
+		return frozen, p.expectSymbol(">")
+	case "list", "set":
+		if err := p.expectSymbol("<"); err != nil {
+			return cqlType{}, err
+		}
+		elem, err := parseType(p)
+		if err != nil {
+			return cqlType{}, err
+		}
+		if elem.elem != nil {
+			return cqlType{}, fmt.Errorf("unsupported nested type %s<%s>", name, elem)
+		}
+		return cqlType{name: name, elem: &elem}, p.expectSymbol(">")
+	}
+	return cqlType{}, fmt.Errorf("unsupported type %q", name)
+}
+
+func (t cqlType) integer() bool {
+	switch t.name {
+	case "int", "bigint", "smallint", "tinyint", "varint", "counter":
+		return true
+	}
+	return false
+}
+
+// convert converts a bound or literal value to the stored value of the type,
+// nil and nil pointers are null.
+func convert(typ cqlType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	reflected := reflect.ValueOf(value)
+	for reflected.Kind() == reflect.Ptr {
+		if reflected.IsNil() {
+			return nil, nil
+		}
+		reflected = reflected.Elem()
+	}
+	value = reflected.Interface()
+	switch {
+	case typ.name == "text":
+		if reflected.Kind() == reflect.String {
+			return reflected.String(), nil
+		}
+	case typ.integer():
+		switch reflected.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflected.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(reflected.Uint()), nil
+		}
+	case typ.name == "float", typ.name == "double":
+		switch reflected.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return reflected.Float(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(reflected.Int()), nil
+		}
+	case typ.name == "boolean":
+		if reflected.Kind() == reflect.Bool {
+			return reflected.Bool(), nil
+		}
+	case typ.name == "timestamp":
+		switch current := value.(type) {
+		case time.Time:
+			return current.UTC().Truncate(time.Millisecond), nil
+		case int64:
+			return time.Unix(0, current*int64(time.Millisecond)).UTC(), nil
+		}
+	case typ.name == "uuid", typ.name == "timeuuid":
+		switch current := value.(type) {
+		case gocql.UUID:
+			return current, nil
+		case [16]byte:
+			return gocql.UUID(current), nil
+		case string:
+			return gocql.ParseUUID(current)
+		}
+	case typ.name == "blob":
+		if current, valid := value.([]byte); valid {
+			return append([]byte(nil), current...), nil
+		}
+	case typ.elem != nil:
+		if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+			break
+		}
+		if reflected.Kind() == reflect.Slice && reflected.IsNil() {
+			return nil, nil
+		}
+		elems := make([]interface{}, 0, reflected.Len())
+		for index := 0; index < reflected.Len(); index++ {
+			elem, err := convert(*typ.elem, reflected.Index(index).Interface())
+			if err != nil {
+				return nil, err
+			}
+			if elem == nil {
+				return nil, fmt.Errorf("null element of %s", typ)
+			}
+			elems = append(elems, elem)
+		}
+		if typ.name == "set" {
+			elems = sortSet(elems)
+		}
+		if len(elems) == 0 {
+			return nil, nil
+		}
+		return elems, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, typ)
+}
+
+// sortSet sorts the set elements and removes the duplicates.
+func sortSet(elems []interface{}) []interface{} {
+	sort.SliceStable(elems, func(i, j int) bool {
+		return compareValues(elems[i], elems[j]) < 0
+	})
+	unique := elems[:0]
+	for _, elem := range elems {
+		if len(unique) > 0 && compareValues(unique[len(unique)-1], elem) == 0 {
+			continue
+		}
+		unique = append(unique, elem)
+	}
+	return unique
+}
+
+// compareValues compares two stored values of the same type, null is less
+// than every value.
+func compareValues(a, b interface{}) int {
+	switch ta := a.(type) {
+	case gocql.UUID:
+		if tb, valid := b.(gocql.UUID); valid {
+			return bytes.Compare(ta[:], tb[:])
+		}
+	case []byte:
+		if tb, valid := b.([]byte); valid {
+			return bytes.Compare(ta, tb)
+		}
+	case []interface{}:
+		if tb, valid := b.([]interface{}); valid {
+			for index := 0; index < len(ta) && index < len(tb); index++ {
+				if compared := compareValues(ta[index], tb[index]); compared != 0 {
+					return compared
+				}
+			}
+			return len(ta) - len(tb)
+		}
+	}
+	compared, _ := raizel.CompareValues(a, b)
+	return compared
+}
+
+// parseLiteral converts a number or string literal to the stored value of
+// the type.
+func parseLiteral(typ cqlType, literal token) (interface{}, error) {
+	switch {
+	case literal.kind == numberToken && typ.integer():
+		return strconv.ParseInt(literal.text, 10, 64)
+	case literal.kind == numberToken && (typ.name == "float" || typ.name == "double"):
+		return strconv.ParseFloat(literal.text, 64)
+	case literal.kind == numberToken && typ.name == "timestamp":
+		millis, err := strconv.ParseInt(literal.text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return convert(typ, millis)
+	case literal.kind == stringToken && typ.name == "timestamp":
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, literal.text); err == nil {
+				return convert(typ, parsed)
+			}
+		}
+		return nil, fmt.Errorf("invalid timestamp %q", literal.text)
+	case literal.kind == stringToken:
+		return convert(typ, literal.text)
+	case literal.kind == identToken && typ.name == "boolean":
+		switch strings.ToLower(literal.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s literal %q", typ, literal.text)
+}
+
+// assign scans a stored value into the destination pointer, the numbers are
+// converted to the destination kind and null is the destination zero value.
+func assign(dest interface{}, value interface{}) error {
+	if unmarshaled, valid := dest.(*interface{}); valid {
+		*unmarshaled = value
+		return nil
+	}
+	pointer := reflect.ValueOf(dest)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() {
+		return fmt.Errorf("cannot scan into %T", dest)
+	}
+	return assignValue(pointer.Elem(), value)
+}
+
+func assignValue(target reflect.Value, value interface{}) error {
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	if target.Kind() == reflect.Ptr {
+		allocated := reflect.New(target.Type().Elem())
+		if err := assignValue(allocated.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(allocated)
+		return nil
+	}
+	reflected := reflect.ValueOf(value)
+	if uuid, valid := value.(gocql.UUID); valid && target.Kind() == reflect.String {
+		target.SetString(uuid.String())
+		return nil
+	}
+	if blob, valid := value.([]byte); valid {
+		value = append([]byte(nil), blob...)
+		reflected = reflect.ValueOf(value)
+	}
+	if reflected.Type().AssignableTo(target.Type()) {
+		target.Set(reflected)
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if reflected.Kind() == reflect.Int64 {
+			target.SetInt(reflected.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if reflected.Kind() == reflect.Int64 {
+			target.SetUint(uint64(reflected.Int()))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if reflected.Kind() == reflect.Float64 {
+			target.SetFloat(reflected.Float())
+			return nil
+		}
+	case reflect.String:
+		if reflected.Kind() == reflect.String {
+			target.SetString(reflected.String())
+			return nil
+		}
+	case reflect.Bool:
+		if reflected.Kind() == reflect.Bool {
+			target.SetBool(reflected.Bool())
+			return nil
+		}
+	case reflect.Slice:
+		if elems, valid := value.([]interface{}); valid {
+			slice := reflect.MakeSlice(target.Type(), len(elems), len(elems))
+			for index, elem := range elems {
+				if err := assignValue(slice.Index(index), elem); err != nil {
+					return err
+				}
+			}
+			target.Set(slice)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot scan %T into %s", value, target.Type())
+}
+
+// nativeValue returns the value of a MapScan row with the go type gocql
+// unmarshals the column type to.
+func nativeValue(typ cqlType, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch typ.name {
+	case "int":
+		return int(value.(int64))
+	case "smallint":
+		return int16(value.(int64))
+	case "tinyint":
+		return int8(value.(int64))
+	case "float":
+		return float32(value.(float64))
+	}
+	return value
+}
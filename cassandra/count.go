@@ -0,0 +1,53 @@
+package cassandra
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"github.com/scylladb/gocqlx/qb"
+)
+
+// queryTable returns the query entity table, inside the keyspace of the query
+// tenant when it is set.
+func queryTable(query raizel.Query) (string, error) {
+	return tenantTable(query.Tenant, query.EntityName)
+}
+
+// Exists counts the rows of the key, the row columns are not read.
+func (r *repository) Exists(ctx context.Context, key raizel.EntityKey) (bool, error) {
+	table, err := entityTable(key)
+	if err != nil {
+		return false, err
+	}
+	var (
+		comparisons, values = keyComparisons(key)
+		cql, _              = qb.Select(table).CountAll().Where(comparisons...).ToCql()
+		count               int64
+	)
+	if err := r.session.Query(cql, values...).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Count runs a count(*) of the query filters, the filters must be allowed by
+// the table keys like the Page filters.
+func (r *repository) Count(ctx context.Context, query raizel.Query) (int64, error) {
+	table, err := queryTable(query)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		builder = qb.Select(table).CountAll()
+		count   int64
+	)
+	values, err := whereFilters(builder, append(query.ParentFilters(), query.Filters...))
+	if err != nil {
+		return 0, err
+	}
+	cql, _ := builder.ToCql()
+	if err := r.session.Query(cql, values...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
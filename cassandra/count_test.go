@@ -0,0 +1,90 @@
+package cassandra_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryCount struct {
+	name  string
+	ctx   context.Context
+	query raizel.Query
+	cql   string
+	args  []interface{}
+	err   error
+}
+
+func TestRepositoryCount(test *testing.T) {
+	scenarios := []testRepositoryCount{
+		{
+			name:  "Count the filtered rows",
+			ctx:   context.Background(),
+			query: raizel.NewQuery("entities").Where("day", raizel.GreaterEqual, 7),
+			cql:   "SELECT count(*) FROM entities WHERE day>=? ",
+			args:  []interface{}{7},
+		},
+		{
+			name:  "Count the rows of the tenant keyspace",
+			ctx:   raizel.WithTenant(context.Background(), "tenant1"),
+			query: raizel.NewQuery("entities"),
+			cql:   "SELECT count(*) FROM tenant1.entities ",
+			args:  []interface{}{},
+		},
+		{
+			name:  "Error when the filter is invalid",
+			ctx:   context.Background(),
+			query: raizel.NewQuery("entities").Where("day", raizel.Operator("!="), 7),
+			err:   cassandra.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					session = cmock.NewSessionMock()
+					query   = cmock.NewQueryMock()
+				)
+				session.On("Query", scenario.cql, scenario.args).Return(query)
+				query.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					*args.Get(0).([]interface{})[0].(*int64) = 42
+				}).Return(nil)
+
+				repository := raizel.Repository(cassandra.NewRepository(session))
+				if _, tenant := raizel.TenantFromContext(scenario.ctx); tenant {
+					repository = cassandra.NewTenantRepository(session)
+				}
+				count, err := raizel.Count(scenario.ctx, repository, scenario.query)
+				require.Equal(t, scenario.err, err, "count error")
+				if scenario.err != nil {
+					session.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+					return
+				}
+				require.Equal(t, int64(42), count, "count result")
+				session.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+func TestRepositoryExists(test *testing.T) {
+	var (
+		session = cmock.NewSessionMock()
+		query   = cmock.NewQueryMock()
+		key     = raizel.NewCompositeKey("entities", []string{"id", "day"}, []interface{}{"mock1", 7})
+	)
+	session.On("Query", "SELECT count(*) FROM entities WHERE id=? AND day=? ", []interface{}{"mock1", 7}).Return(query)
+	query.On("Scan", mock.Anything).Return(nil)
+
+	exists, err := raizel.Exists(context.Background(), cassandra.NewRepository(session), key)
+	require.Nil(test, err, "exists error")
+	require.False(test, exists, "missing row")
+	session.AssertExpectations(test)
+}
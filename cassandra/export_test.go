@@ -0,0 +1,11 @@
+package cassandra
+
+import (
+	"github.com/gocql/gocql"
+)
+
+var NewSession = newSession
+
+func QueryDelegate(cqlQuery Query) *gocql.Query {
+	return cqlQuery.(*query).Query
+}
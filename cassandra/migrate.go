@@ -0,0 +1,117 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rjansen/raizel/migrate"
+)
+
+type migrationDriver struct {
+	session Session
+}
+
+// NewMigrationDriver returns a cassandra migrate.Driver that runs the CQL
+// statements of a script one at a time. The lock is a lightweight transaction
+// over the migrate.LockTable.
+func NewMigrationDriver(session Session) migrate.Driver {
+	return &migrationDriver{session: session}
+}
+
+func (d *migrationDriver) Init(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, name text, applied_at timestamp)",
+			migrate.Table,
+		),
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (id int PRIMARY KEY, locked_at timestamp)",
+			migrate.LockTable,
+		),
+	}
+	for _, statement := range statements {
+		if err := d.session.Query(statement).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *migrationDriver) Lock(ctx context.Context) error {
+	var (
+		id       int
+		lockedAt time.Time
+	)
+	applied, err := d.session.Query(
+		fmt.Sprintf(
+			"INSERT INTO %s (id, locked_at) VALUES (1, toTimestamp(now())) IF NOT EXISTS",
+			migrate.LockTable,
+		),
+	).ScanCAS(&id, &lockedAt)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return migrate.ErrLocked
+	}
+	return nil
+}
+
+func (d *migrationDriver) Unlock(ctx context.Context) error {
+	return d.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE id = 1", migrate.LockTable),
+	).Exec()
+}
+
+func (d *migrationDriver) Applied(ctx context.Context) ([]migrate.Record, error) {
+	var (
+		iter = d.session.Query(
+			fmt.Sprintf("SELECT version, name, applied_at FROM %s", migrate.Table),
+		).Iter()
+		scanner = iter.Scanner()
+		records []migrate.Record
+	)
+	for scanner.Next() {
+		var record migrate.Record
+		if err := scanner.Scan(&record.Version, &record.Name, &record.AppliedAt); err != nil {
+			_ = iter.Close()
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		_ = iter.Close()
+		return nil, err
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+	return records, nil
+}
+
+func (d *migrationDriver) Exec(ctx context.Context, script string) error {
+	for _, statement := range migrate.Statements(script) {
+		if err := d.session.Query(statement).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *migrationDriver) Insert(ctx context.Context, record migrate.Record) error {
+	return d.session.Query(
+		fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", migrate.Table),
+		record.Version, record.Name, record.AppliedAt,
+	).Exec()
+}
+
+func (d *migrationDriver) Delete(ctx context.Context, version int64) error {
+	return d.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE version = ?", migrate.Table), version,
+	).Exec()
+}
@@ -0,0 +1,111 @@
+package cassandra_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
+	"github.com/rjansen/raizel/migrate"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	lockCQL = "INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, toTimestamp(now())) IF NOT EXISTS"
+)
+
+func TestMigrationDriverLock(test *testing.T) {
+	scenarios := []struct {
+		name    string
+		applied bool
+		err     error
+		want    error
+	}{
+		{name: "Lock", applied: true},
+		{name: "Locked by another migrator", want: migrate.ErrLocked},
+		{name: "Lock error", err: errors.New("errMock"), want: errors.New("errMock")},
+	}
+	for _, scenario := range scenarios {
+		test.Run(scenario.name, func(t *testing.T) {
+			var (
+				session = cmock.NewSessionMock()
+				lock    = cmock.NewQueryMock()
+				unlock  = cmock.NewQueryMock()
+			)
+			lock.On("ScanCAS", mock.Anything).Return(scenario.applied, scenario.err)
+			unlock.On("Exec").Return(nil)
+			session.On("Query", lockCQL, []interface{}(nil)).Return(lock)
+			session.On("Query", "DELETE FROM schema_migrations_lock WHERE id = 1", []interface{}(nil)).Return(unlock)
+
+			driver := cassandra.NewMigrationDriver(session)
+			require.Equal(t, scenario.want, driver.Lock(context.Background()), "lock error")
+			require.Nil(t, driver.Unlock(context.Background()), "unlock error")
+		})
+	}
+}
+
+func TestMigrationDriver(test *testing.T) {
+	var (
+		ctx       = context.Background()
+		session   = cmock.NewSessionMock()
+		exec      = cmock.NewQueryMock()
+		selection = cmock.NewQueryMock()
+		iter      = cmock.NewIterMock()
+		scanner   = cmock.NewScannerMock()
+		appliedAt = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+		driver    = cassandra.NewMigrationDriver(session)
+		versions  = []int64{2, 1}
+	)
+	exec.On("Exec").Return(nil)
+	session.On(
+		"Query", "SELECT version, name, applied_at FROM schema_migrations", []interface{}(nil),
+	).Return(selection)
+	session.On("Query", mock.AnythingOfType("string"), mock.Anything).Return(exec)
+	selection.On("Iter").Return(iter)
+	iter.On("Scanner").Return(scanner)
+	iter.On("Close").Return(nil)
+	scanner.On("Next").Return(true).Twice()
+	scanner.On("Next").Return(false)
+	scanner.On("Err").Return(nil)
+	scanner.On("Scan", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		dest := args.Get(0).([]interface{})
+		*dest[0].(*int64), versions = versions[0], versions[1:]
+		*dest[2].(*time.Time) = appliedAt
+	})
+
+	require.Nil(test, driver.Init(ctx), "init error")
+	require.Nil(
+		test,
+		driver.Exec(ctx, "CREATE TABLE entity (id text PRIMARY KEY);\nCREATE INDEX ON entity (name);"),
+		"exec error",
+	)
+	require.Nil(
+		test,
+		driver.Insert(ctx, migrate.Record{Version: 1, Name: "create_entity", AppliedAt: appliedAt}),
+		"insert error",
+	)
+	require.Nil(test, driver.Delete(ctx, 1), "delete error")
+	records, err := driver.Applied(ctx)
+	require.Nil(test, err, "applied error")
+	require.Equal(
+		test,
+		[]migrate.Record{{Version: 1, AppliedAt: appliedAt}, {Version: 2, AppliedAt: appliedAt}},
+		records,
+		"applied records sorted by version",
+	)
+
+	session.AssertCalled(test, "Query", "CREATE TABLE entity (id text PRIMARY KEY)", []interface{}(nil))
+	session.AssertCalled(test, "Query", "CREATE INDEX ON entity (name)", []interface{}(nil))
+	session.AssertCalled(
+		test, "Query",
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		[]interface{}{int64(1), "create_entity", appliedAt},
+	)
+	session.AssertCalled(
+		test, "Query", "DELETE FROM schema_migrations WHERE version = ?", []interface{}{int64(1)},
+	)
+	exec.AssertNumberOfCalls(test, "Exec", 6)
+}
@@ -0,0 +1,238 @@
+// Package mock provides testify mocks of the raizel cassandra interfaces.
+package mock
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel/cassandra"
+	rmock "github.com/rjansen/raizel/mock"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionMock struct {
+	mock.Mock
+}
+
+func NewSessionMock() *SessionMock {
+	return new(SessionMock)
+}
+
+func (mock *SessionMock) Close() {
+	mock.Called()
+}
+
+func (mock *SessionMock) Query(cql string, arguments ...interface{}) cassandra.Query {
+	var (
+		args   = mock.Called(cql, arguments)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(cassandra.Query)
+}
+
+func (mock *SessionMock) Closed() bool {
+	args := mock.Called()
+	return args.Bool(0)
+}
+
+type QueryMock struct {
+	mock.Mock
+}
+
+func NewQueryMock() *QueryMock {
+	return new(QueryMock)
+}
+
+func (mock *QueryMock) Scan(dest ...interface{}) error {
+	args := mock.Called(dest)
+	return args.Error(0)
+}
+
+func (mock *QueryMock) ScanCAS(dest ...interface{}) (bool, error) {
+	args := mock.Called(dest)
+	return args.Bool(0), args.Error(1)
+}
+
+func (mock *QueryMock) Exec() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+func (mock *QueryMock) Iter() cassandra.Iter {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(cassandra.Iter)
+}
+
+func (mock *QueryMock) Consistency(consistency gocql.Consistency) cassandra.Query {
+	var (
+		args   = mock.Called(consistency)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(cassandra.Query)
+}
+
+func (mock *QueryMock) PageSize(size int) cassandra.Query {
+	var (
+		args   = mock.Called(size)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(cassandra.Query)
+}
+
+func (mock *QueryMock) PageState(state []byte) cassandra.Query {
+	var (
+		args   = mock.Called(state)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(cassandra.Query)
+}
+
+func (mock *QueryMock) Release() {
+	mock.Called()
+}
+
+func (mock *QueryMock) String() string {
+	args := mock.Called()
+	return args.String(0)
+}
+
+// OnScan expects a Scan that assigns the values to the destinations.
+func (query *QueryMock) OnScan(values ...interface{}) *mock.Call {
+	return query.On("Scan", mock.Anything).Run(rmock.Scan(values...)).Return(nil)
+}
+
+// OnScanStruct expects a Scan that assigns the source struct fields to the
+// destinations.
+func (query *QueryMock) OnScanStruct(source interface{}) *mock.Call {
+	return query.OnScan(rmock.StructValues(source)...)
+}
+
+// OnScanCAS expects a ScanCAS that reports applied, the values of the
+// existing row are assigned to the destinations when it was not applied.
+func (query *QueryMock) OnScanCAS(applied bool, values ...interface{}) *mock.Call {
+	call := query.On("ScanCAS", mock.Anything)
+	if !applied {
+		call = call.Run(rmock.Scan(values...))
+	}
+	return call.Return(applied, nil)
+}
+
+type IterMock struct {
+	mock.Mock
+}
+
+func NewIterMock() *IterMock {
+	return new(IterMock)
+}
+
+func (mock *IterMock) Close() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+func (mock *IterMock) MapScan(row map[string]interface{}) bool {
+	args := mock.Called(row)
+	return args.Bool(0)
+}
+
+func (mock *IterMock) NumRows() int {
+	args := mock.Called()
+	return args.Int(0)
+}
+
+func (mock *IterMock) PageState() []byte {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.([]byte)
+}
+
+func (mock *IterMock) Scanner() gocql.Scanner {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(gocql.Scanner)
+}
+
+// OnMapScan expects the MapScan iteration of the rows, each MapScan copies
+// the next row into its map. Close returns nil.
+func (iter *IterMock) OnMapScan(rows ...map[string]interface{}) {
+	for _, row := range rows {
+		row := row
+		iter.On("MapScan", mock.Anything).Run(func(args mock.Arguments) {
+			scanned := args.Get(0).(map[string]interface{})
+			for column, value := range row {
+				scanned[column] = value
+			}
+		}).Return(true).Once()
+	}
+	iter.On("MapScan", mock.Anything).Return(false)
+	iter.On("Close").Return(nil)
+}
+
+type ScannerMock struct {
+	mock.Mock
+}
+
+func NewScannerMock() *ScannerMock {
+	return new(ScannerMock)
+}
+
+func (mock *ScannerMock) Next() bool {
+	args := mock.Called()
+	return args.Bool(0)
+}
+
+func (mock *ScannerMock) Scan(dest ...interface{}) error {
+	args := mock.Called(dest)
+	return args.Error(0)
+}
+
+func (mock *ScannerMock) Err() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+// OnRows expects the iteration of the rows, each Scan assigns the values of
+// the next row to the destinations. Err returns nil.
+func (scanner *ScannerMock) OnRows(values ...[]interface{}) {
+	for _, row := range values {
+		scanner.On("Next").Return(true).Once()
+		scanner.On("Scan", mock.Anything).Run(rmock.Scan(row...)).Return(nil).Once()
+	}
+	scanner.On("Next").Return(false)
+	scanner.On("Err").Return(nil)
+}
+
+// OnStructs expects the iteration of the rows of the source structs fields.
+func (scanner *ScannerMock) OnStructs(sources ...interface{}) {
+	values := make([][]interface{}, len(sources))
+	for index, source := range sources {
+		values[index] = rmock.StructValues(source)
+	}
+	scanner.OnRows(values...)
+}
@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel/cassandra"
+	"github.com/stretchr/testify/require"
+)
+
+type entity struct {
+	ID   string `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestSessionMock(t *testing.T) {
+	var (
+		session = NewSessionMock()
+		query   = NewQueryMock()
+	)
+	require.Implements(t, (*cassandra.Session)(nil), session, "invalid session type")
+	require.Implements(t, (*cassandra.Query)(nil), query, "invalid query type")
+	require.Implements(t, (*cassandra.Iter)(nil), NewIterMock(), "invalid iter type")
+	require.Implements(t, (*gocql.Scanner)(nil), NewScannerMock(), "invalid scanner type")
+
+	session.On("Query", "SELECT id,name FROM entity WHERE id=?", []interface{}{"id"}).Return(query)
+	session.On("Close")
+	query.On("PageSize", 10).Return(query)
+	query.OnScanStruct(entity{ID: "id", Name: "name"})
+
+	var scanned entity
+	err := session.Query("SELECT id,name FROM entity WHERE id=?", "id").PageSize(10).Scan(&scanned.ID, &scanned.Name)
+	require.Nil(t, err, "scan error")
+	require.Equal(t, entity{ID: "id", Name: "name"}, scanned, "scanned entity")
+	session.Close()
+	session.AssertExpectations(t)
+	query.AssertExpectations(t)
+}
+
+func TestQueryMockScanCAS(t *testing.T) {
+	var (
+		applied = NewQueryMock()
+		locked  = NewQueryMock()
+		id      int
+	)
+	applied.OnScanCAS(true)
+	locked.OnScanCAS(false, 7)
+
+	result, err := applied.ScanCAS(&id)
+	require.Nil(t, err, "applied scan_cas error")
+	require.True(t, result, "applied")
+	require.Zero(t, id, "applied id")
+	result, err = locked.ScanCAS(&id)
+	require.Nil(t, err, "locked scan_cas error")
+	require.False(t, result, "locked")
+	require.Equal(t, 7, id, "existing id")
+}
+
+func TestIterMock(t *testing.T) {
+	var (
+		iter    = NewIterMock()
+		scanner = NewScannerMock()
+		rows    []map[string]interface{}
+		scanned []entity
+	)
+	iter.OnMapScan(map[string]interface{}{"id": "one"}, map[string]interface{}{"id": "two"})
+	for {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		rows = append(rows, row)
+	}
+	require.Nil(t, iter.Close(), "close error")
+	require.Equal(t, []map[string]interface{}{{"id": "one"}, {"id": "two"}}, rows, "map scanned rows")
+
+	scanner.OnStructs(entity{ID: "one"}, &entity{ID: "two", Name: "second"})
+	for scanner.Next() {
+		var current entity
+		require.Nil(t, scanner.Scan(&current.ID, &current.Name), "scan error")
+		scanned = append(scanned, current)
+	}
+	require.Nil(t, scanner.Err(), "scanner error")
+	require.Equal(t, []entity{{ID: "one"}, {ID: "two", Name: "second"}}, scanned, "scanned entities")
+}
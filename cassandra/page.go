@@ -0,0 +1,159 @@
+package cassandra
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/rjansen/raizel"
+	"github.com/scylladb/gocqlx/qb"
+)
+
+var comparators = map[raizel.Operator]func(string) qb.Cmp{
+	raizel.Equal:        qb.Eq,
+	raizel.Less:         qb.Lt,
+	raizel.LessEqual:    qb.LtOrEq,
+	raizel.Greater:      qb.Gt,
+	raizel.GreaterEqual: qb.GtOrEq,
+}
+
+func entityFields(entity raizel.Entity) ([]string, []interface{}) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	if descriptor, described := raizel.DescriptorOf(entity); described {
+		addrs := make([]interface{}, len(descriptor.Fields))
+		for index, field := range descriptor.Fields {
+			addrs[index] = value.FieldByIndex(field.Path).Addr().Interface()
+		}
+		return descriptor.Names(), addrs
+	}
+	var (
+		columns = make([]string, 0, value.NumField())
+		addrs   = make([]interface{}, 0, value.NumField())
+	)
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		column := field.Tag.Get("db")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = strings.ToLower(field.Name)
+		}
+		columns = append(columns, column)
+		addrs = append(addrs, value.Field(index).Addr().Interface())
+	}
+	return columns, addrs
+}
+
+type pageIterator struct {
+	session       Session
+	builder       *qb.SelectBuilder
+	values        []interface{}
+	size          int
+	state         []byte
+	iter          Iter
+	scanner       gocql.Scanner
+	nextPageToken string
+}
+
+// Next runs the query with the columns of the first entity, the rows are
+// scanned into the entity fields by the db tag or the lower case field name.
+func (i *pageIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	columns, addrs := entityFields(entity)
+	if i.iter == nil {
+		cql, _ := i.builder.Columns(columns...).ToCql()
+		i.iter = i.session.Query(cql, i.values...).PageSize(i.size).PageState(i.state).Iter()
+		i.scanner = i.iter.Scanner()
+	}
+	if !i.scanner.Next() {
+		if err := i.scanner.Err(); err != nil {
+			return err
+		}
+		if state := i.iter.PageState(); len(state) > 0 {
+			token, err := raizel.EncodePageToken(state)
+			if err != nil {
+				return err
+			}
+			i.nextPageToken = token
+		}
+		return raizel.ErrIteratorDone
+	}
+	return i.scanner.Scan(addrs...)
+}
+
+func (i *pageIterator) NextPageToken() string {
+	return i.nextPageToken
+}
+
+func (i *pageIterator) Stop() {
+	if i.iter != nil {
+		_ = i.iter.Close()
+	}
+}
+
+// whereFilters adds the filter comparisons to builder and returns their bound
+// values.
+func whereFilters(builder *qb.SelectBuilder, filters []raizel.Filter) ([]interface{}, error) {
+	values := make([]interface{}, len(filters))
+	for index, filter := range filters {
+		comparator, valid := comparators[filter.Operator]
+		if !valid {
+			return nil, ErrInvalidFilter
+		}
+		builder.Where(comparator(filter.Field))
+		values[index] = filter.Value
+	}
+	return values, nil
+}
+
+// Page reads one driver page of the query, pageToken carries the driver page
+// state so the query may be unordered. Orders must follow the table
+// clustering order.
+func (r *repository) Page(ctx context.Context, query raizel.Query, pageToken string) (raizel.PageIterator, error) {
+	var (
+		builder = qb.Select(query.EntityName)
+		state   []byte
+	)
+	values, err := whereFilters(builder, append(query.ParentFilters(), query.Filters...))
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range query.Orders {
+		direction := qb.ASC
+		if order.Direction == raizel.Desc {
+			direction = qb.DESC
+		}
+		builder.OrderBy(order.Field, direction)
+	}
+	if pageToken != "" {
+		decoded, err := raizel.DecodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != 1 {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		pageState, valid := decoded[0].([]byte)
+		if !valid {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		state = pageState
+	}
+	return &pageIterator{
+		session: r.session,
+		builder: builder,
+		values:  values,
+		size:    raizel.PageSize(query),
+		state:   state,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package cassandra_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func mustPageToken(values ...interface{}) string {
+	token, err := raizel.EncodePageToken(values...)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+type testRepositoryPage struct {
+	name          string
+	session       *cmock.SessionMock
+	query         *cmock.QueryMock
+	iter          *cmock.IterMock
+	scanner       *cmock.ScannerMock
+	page          raizel.Query
+	pageToken     string
+	cql           string
+	arguments     []interface{}
+	state         []byte
+	results       int
+	nextState     []byte
+	nextPageToken string
+	err           error
+}
+
+func (scenario *testRepositoryPage) setup(t *testing.T) {
+	var (
+		session = cmock.NewSessionMock()
+		query   = cmock.NewQueryMock()
+		iter    = cmock.NewIterMock()
+		scanner = cmock.NewScannerMock()
+	)
+	if scenario.results > 0 {
+		scanner.On("Next").Return(true).Times(scenario.results)
+	}
+	scanner.On("Next").Return(false)
+	scanner.On("Scan", mock.Anything).Return(nil)
+	scanner.On("Err").Return(nil)
+	iter.On("Scanner").Return(scanner)
+	iter.On("PageState").Return(scenario.nextState)
+	iter.On("Close").Return(nil)
+	query.On("PageSize", raizel.PageSize(scenario.page)).Return(query)
+	query.On("PageState", scenario.state).Return(query)
+	query.On("Iter").Return(iter)
+	session.On("Query", scenario.cql, scenario.arguments).Return(query)
+
+	scenario.session = session
+	scenario.query = query
+	scenario.iter = iter
+	scenario.scanner = scanner
+}
+
+func TestRepositoryPage(test *testing.T) {
+	scenarios := []testRepositoryPage{
+		{
+			name: "Read the first page",
+			page: raizel.NewQuery("entity_table").
+				Where("id", raizel.Equal, "identifier").
+				Where("age", raizel.GreaterEqual, 18).
+				OrderBy("age", raizel.Desc).
+				WithLimit(2),
+			cql:           "SELECT id,name,age,created_at,updated_at FROM entity_table WHERE id=? AND age>=? ORDER BY age DESC ",
+			arguments:     []interface{}{"identifier", 18},
+			results:       2,
+			nextState:     []byte("state"),
+			nextPageToken: mustPageToken([]byte("state")),
+		},
+		{
+			name:      "Read the last page with the token page state",
+			page:      raizel.NewQuery("entity_table"),
+			pageToken: mustPageToken([]byte("state")),
+			cql:       "SELECT id,name,age,created_at,updated_at FROM entity_table ",
+			arguments: []interface{}{},
+			state:     []byte("state"),
+			results:   1,
+		},
+		{
+			name:      "Error when the token does not carry a page state",
+			page:      raizel.NewQuery("entity_table"),
+			pageToken: mustPageToken("state"),
+			err:       raizel.ErrInvalidPageToken,
+		},
+		{
+			name: "Error when the filter operator is invalid",
+			page: raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 18),
+			err:  cassandra.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := cassandra.NewRepository(scenario.session)
+				page, err := repository.Page(context.Background(), scenario.page, scenario.pageToken)
+				require.Equal(t, scenario.err, err, "page error")
+				if scenario.err != nil {
+					require.Nil(t, page, "page instance")
+					scenario.session.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+					return
+				}
+				for result := 0; result < scenario.results; result++ {
+					require.Nil(t, page.Next(context.Background(), new(testEntity)), "next error")
+				}
+				require.Equal(t, raizel.ErrIteratorDone, page.Next(context.Background(), new(testEntity)), "next done error")
+				require.Equal(t, scenario.nextPageToken, page.NextPageToken(), "next page token")
+				page.Stop()
+				scenario.session.AssertExpectations(t)
+				scenario.query.AssertExpectations(t)
+				scenario.iter.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -0,0 +1,69 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rjansen/raizel"
+	"github.com/scylladb/gocqlx/qb"
+)
+
+// Patch runs an UPDATE IF EXISTS of the updated columns only, a missing row
+// returns raizel.ErrNotFound. Increment maps to the counter addition and the
+// array transforms to the list addition and removal, a set column keeps one
+// copy of the appended values. Cassandra refuses conditions on the counter
+// tables, so an Increment patch runs without IF EXISTS and a missing counter
+// row is inserted like by every cassandra UPDATE. The updates of the key
+// columns return raizel.ErrInvalidArgument.
+func (r *repository) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	if err := raizel.CheckUpdates(key, updates); err != nil {
+		return err
+	}
+	table, err := entityTable(key)
+	if err != nil {
+		return err
+	}
+	var (
+		builder = qb.Update(table)
+		values  = make([]interface{}, 0, len(updates))
+		counter bool
+	)
+	for _, update := range updates {
+		switch update.Transform {
+		case raizel.AssignTransform:
+			builder.Set(update.Field)
+			values = append(values, update.Value)
+		case raizel.IncrementTransform:
+			builder.Add(update.Field)
+			values = append(values, update.Value)
+			counter = true
+		case raizel.ArrayAppendTransform:
+			builder.Add(update.Field)
+			values = append(values, update.Value)
+		case raizel.ArrayRemoveTransform:
+			builder.Remove(update.Field)
+			values = append(values, update.Value)
+		case raizel.ServerTimestampTransform:
+			builder.SetLit(update.Field, "toTimestamp(now())")
+		case raizel.DeleteTransform:
+			builder.SetLit(update.Field, "null")
+		default:
+			return fmt.Errorf("%w: transform %d", raizel.ErrInvalidArgument, update.Transform)
+		}
+	}
+	comparisons, keyValues := keyComparisons(key)
+	builder.Where(comparisons...)
+	if counter {
+		cql, _ := builder.ToCql()
+		return r.session.Query(cql, append(values, keyValues...)...).Exec()
+	}
+	cql, _ := builder.Existing().ToCql()
+	applied, err := r.session.Query(cql, append(values, keyValues...)...).ScanCAS()
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return raizel.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cassandra_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryPatch struct {
+	name    string
+	key     raizel.EntityKey
+	updates []raizel.Update
+	cql     string
+	args    []interface{}
+	counter bool
+	applied bool
+	err     error
+}
+
+func TestRepositoryPatch(test *testing.T) {
+	scenarios := []testRepositoryPatch{
+		{
+			name: "Patch the updated columns",
+			key:  raizel.NewDynamicKey("entities", "id", "mock1"),
+			updates: []raizel.Update{
+				raizel.Assign("name", "mock"),
+				raizel.ServerTimestamp("updated_at"),
+				raizel.DeleteField("nickname"),
+			},
+			cql:     "UPDATE entities SET name=?,updated_at=toTimestamp(now()),nickname=null WHERE id=? IF EXISTS ",
+			args:    []interface{}{"mock", "mock1"},
+			applied: true,
+		},
+		{
+			name: "Patch the list columns of a composite key",
+			key: raizel.NewCompositeKey(
+				"entities", []string{"id", "day"}, []interface{}{"mock1", 7},
+			),
+			updates: []raizel.Update{
+				raizel.ArrayAppend("tags", "a"),
+				raizel.ArrayRemove("tags", "b"),
+			},
+			cql: "UPDATE entities SET tags=tags+?,tags=tags-? WHERE id=? AND day=? IF EXISTS ",
+			args: []interface{}{
+				[]interface{}{"a"}, []interface{}{"b"}, "mock1", 7,
+			},
+			applied: true,
+		},
+		{
+			name:    "Patch the counter columns without condition",
+			key:     raizel.NewDynamicKey("entities", "id", "mock1"),
+			updates: []raizel.Update{raizel.Increment("views", 1)},
+			cql:     "UPDATE entities SET views=views+? WHERE id=? ",
+			args:    []interface{}{1, "mock1"},
+			counter: true,
+		},
+		{
+			name:    "Error when the row is not found",
+			key:     raizel.NewDynamicKey("entities", "id", "missing"),
+			updates: []raizel.Update{raizel.Assign("name", "mock")},
+			cql:     "UPDATE entities SET name=? WHERE id=? IF EXISTS ",
+			args:    []interface{}{"mock", "missing"},
+			err:     raizel.ErrNotFound,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					session = cmock.NewSessionMock()
+					query   = cmock.NewQueryMock()
+				)
+				session.On("Query", scenario.cql, scenario.args).Return(query)
+				if scenario.counter {
+					query.On("Exec").Return(nil)
+				} else {
+					query.On("ScanCAS", mock.Anything).Return(scenario.applied, nil)
+				}
+
+				err := cassandra.NewRepository(session).Patch(context.Background(), scenario.key, scenario.updates...)
+				require.Equal(t, scenario.err, err, "patch error")
+				session.AssertExpectations(t)
+				query.AssertExpectations(t)
+			},
+		)
+	}
+}
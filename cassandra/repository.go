@@ -2,7 +2,12 @@ package cassandra
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
 
+	"github.com/gocql/gocql"
 	"github.com/rjansen/raizel"
 	"github.com/scylladb/gocqlx/qb"
 )
@@ -15,39 +20,113 @@ func NewRepository(session Session) *repository {
 	return &repository{session: session}
 }
 
-func (r *repository) Get(tree context.Context, key raizel.EntityKey, entity raizel.Entity) error {
-	var (
-		cql, _ = qb.Select(key.EntityName()).Columns(
-			"id", "name", "age", "created_at", "updated_at",
-		).Where(
-			qb.Eq(key.Name()),
-		).ToCql()
-		query = r.session.Query(cql)
-		// queryx = gocqlx.Query(query.delegate(), args).BindStruct(entity)
-	)
-	return query.Scan(entity)
-}
-
-func (r *repository) Set(tree context.Context, key raizel.EntityKey, entity raizel.Entity) error {
-	var (
-		cql, _ = qb.Insert(key.EntityName()).Columns(
-			"id", "name", "age", "created_at", "updated_at",
-		).ToCql()
-		query = r.session.Query(cql)
-		// queryx = gocqlx.Query(query.delegate(), args).BindStruct(entity)
-	)
-	return query.Exec()
-}
-
-func (r *repository) Delete(tree context.Context, key raizel.EntityKey) error {
-	var (
-		cql, _ = qb.Delete(key.EntityName()).Where(
-			qb.Eq(key.Name()),
-		).ToCql()
-		query = r.session.Query(cql)
-		// queryx = gocqlx.Query(query.delegate(), args).BindStruct(entity)
-	)
-	return query.Exec()
+// NewTenantRepository returns a repository that reads and writes the entity
+// table inside the keyspace named after the context tenant.
+func NewTenantRepository(session Session) raizel.Repository {
+	return raizel.NewTenantRepository(NewRepository(session))
+}
+
+// keyspacePattern matches the unquoted cassandra keyspace names.
+var keyspacePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,47}$`)
+
+// tenantTable returns the table inside the keyspace of the tenant when it is
+// set, a tenant that is not a valid keyspace name is refused.
+func tenantTable(tenant string, table string) (string, error) {
+	if tenant == "" {
+		return table, nil
+	}
+	if !keyspacePattern.MatchString(tenant) {
+		return "", fmt.Errorf("%w: invalid tenant keyspace %q", raizel.ErrInvalidArgument, tenant)
+	}
+	return fmt.Sprintf("%s.%s", tenant, table), nil
+}
+
+func entityTable(key raizel.EntityKey) (string, error) {
+	tenant, _ := raizel.TenantOf(key)
+	return tenantTable(tenant, key.EntityName())
+}
+
+// keyComparisons returns the equality of every key path column and the key
+// values in the same order.
+func keyComparisons(key raizel.EntityKey) ([]qb.Cmp, []interface{}) {
+	names, values := raizel.KeyPathParts(key)
+	comparisons := make([]qb.Cmp, len(names))
+	for index, name := range names {
+		comparisons[index] = qb.Eq(name)
+	}
+	return comparisons, values
+}
+
+// entityValues returns the columns and field values of the entity, like
+// entityFields without requiring an addressable entity.
+func entityValues(entity raizel.Entity) ([]string, []interface{}) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	addressable := reflect.New(value.Type())
+	addressable.Elem().Set(value)
+	columns, addrs := entityFields(addressable.Interface())
+	values := make([]interface{}, len(addrs))
+	for index, addr := range addrs {
+		values[index] = reflect.ValueOf(addr).Elem().Interface()
+	}
+	return columns, values
+}
+
+// Get reads the entity columns of the key row, the row columns are scanned
+// into the entity fields by the db tag or the lower case field name.
+func (r *repository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	columns, addrs := entityFields(entity)
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: entity %T has no columns", raizel.ErrInvalidArgument, entity)
+	}
+	table, err := entityTable(key)
+	if err != nil {
+		return err
+	}
+	comparisons, values := keyComparisons(key)
+	cql, _ := qb.Select(table).Columns(columns...).Where(comparisons...).ToCql()
+	if err := r.session.Query(cql, values...).Scan(addrs...); err != nil {
+		if errors.Is(err, gocql.ErrNotFound) {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+// Set inserts the entity columns, the entity must map the key columns.
+func (r *repository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	columns, values := entityValues(entity)
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: entity %T has no columns", raizel.ErrInvalidArgument, entity)
+	}
+	table, err := entityTable(key)
+	if err != nil {
+		return err
+	}
+	cql, _ := qb.Insert(table).Columns(columns...).ToCql()
+	return r.session.Query(cql, values...).Exec()
+}
+
+func (r *repository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	table, err := entityTable(key)
+	if err != nil {
+		return err
+	}
+	comparisons, values := keyComparisons(key)
+	cql, _ := qb.Delete(table).Where(comparisons...).ToCql()
+	return r.session.Query(cql, values...).Exec()
 }
 
 func (r *repository) Close(tree context.Context) error {
@@ -1,4 +1,4 @@
-package cassandra
+package cassandra_test
 
 import (
 	"context"
@@ -7,7 +7,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gocql/gocql"
 	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/cassandra"
+	cmock "github.com/rjansen/raizel/cassandra/mock"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -39,30 +42,34 @@ func (k testEntityKey) Name() string {
 }
 
 func TestNewRepository(test *testing.T) {
-	repository := NewRepository(nil)
+	repository := cassandra.NewRepository(nil)
 	require.NotNil(test, repository, "invalid repository instance")
 }
 
 type testRepositoryGet struct {
 	name    string
 	ctx     context.Context
-	query   *queryMock
-	session *sessionMock
+	query   *cmock.QueryMock
+	session *cmock.SessionMock
 	key     raizel.EntityKey
 	result  raizel.Entity
+	scanErr error
 	err     error
 }
 
 func (scenario *testRepositoryGet) setup(t *testing.T) {
 	var (
-		query   = newQueryMock()
-		session = newSessionMock()
+		query   = cmock.NewQueryMock()
+		session = cmock.NewSessionMock()
 	)
 	require.NotNil(t, query, "mock query instance")
 	require.NotNil(t, session, "mock session instance")
 
-	query.On("Scan", mock.Anything).Return(scenario.err)
-	// query.On("delegate").Return(new(gocql.Query))
+	scanErr := scenario.scanErr
+	if scanErr == nil {
+		scanErr = scenario.err
+	}
+	query.On("Scan", mock.Anything).Return(scanErr)
 	session.On("Query", mock.AnythingOfType("string"), mock.Anything).Return(query)
 	session.On("Close")
 
@@ -92,6 +99,17 @@ func TestRepositoryGet(test *testing.T) {
 			result: &testEntity{},
 			err:    errors.New("errMock"),
 		},
+		{
+			name: "Get a missing entity",
+			key: testEntityKey{
+				entityName: "testEntityKey",
+				name:       "id",
+				value:      "identifier",
+			},
+			result:  &testEntity{},
+			scanErr: gocql.ErrNotFound,
+			err:     raizel.ErrNotFound,
+		},
 	}
 	for index, scenario := range scenarios {
 		test.Run(
@@ -99,7 +117,7 @@ func TestRepositoryGet(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.session)
+				repository := cassandra.NewRepository(scenario.session)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Get(scenario.ctx, scenario.key, scenario.result)
 				require.Equal(t, scenario.err, err, "get error")
@@ -115,8 +133,8 @@ func TestRepositoryGet(test *testing.T) {
 type testRepositorySet struct {
 	name    string
 	ctx     context.Context
-	query   *queryMock
-	session *sessionMock
+	query   *cmock.QueryMock
+	session *cmock.SessionMock
 	key     raizel.EntityKey
 	data    raizel.Entity
 	err     error
@@ -124,14 +142,13 @@ type testRepositorySet struct {
 
 func (scenario *testRepositorySet) setup(t *testing.T) {
 	var (
-		query   = newQueryMock()
-		session = newSessionMock()
+		query   = cmock.NewQueryMock()
+		session = cmock.NewSessionMock()
 	)
 	require.NotNil(t, query, "mock query instance")
 	require.NotNil(t, session, "mock session instance")
 
 	query.On("Exec").Return(scenario.err)
-	// query.On("delegate").Return(new(gocql.Query))
 	session.On("Query", mock.AnythingOfType("string"), mock.Anything).Return(query)
 	session.On("Close")
 
@@ -168,7 +185,7 @@ func TestRepositorySet(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.session)
+				repository := cassandra.NewRepository(scenario.session)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Set(scenario.ctx, scenario.key, scenario.data)
 				require.Equal(t, scenario.err, err, "set error")
@@ -184,8 +201,8 @@ func TestRepositorySet(test *testing.T) {
 type testRepositoryDelete struct {
 	name    string
 	ctx     context.Context
-	query   *queryMock
-	session *sessionMock
+	query   *cmock.QueryMock
+	session *cmock.SessionMock
 	key     raizel.EntityKey
 	data    raizel.Entity
 	err     error
@@ -193,14 +210,13 @@ type testRepositoryDelete struct {
 
 func (scenario *testRepositoryDelete) setup(t *testing.T) {
 	var (
-		query   = newQueryMock()
-		session = newSessionMock()
+		query   = cmock.NewQueryMock()
+		session = cmock.NewSessionMock()
 	)
 	require.NotNil(t, query, "mock query instance")
 	require.NotNil(t, session, "mock session instance")
 
 	query.On("Exec").Return(scenario.err)
-	// query.On("delegate").Return(new(gocql.Query))
 	session.On("Query", mock.AnythingOfType("string"), mock.Anything).Return(query)
 	session.On("Close")
 
@@ -235,7 +251,7 @@ func TestRepositoryDelete(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.session)
+				repository := cassandra.NewRepository(scenario.session)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Delete(scenario.ctx, scenario.key)
 				require.Equal(t, scenario.err, err, "set error")
@@ -246,3 +262,84 @@ func TestRepositoryDelete(test *testing.T) {
 		)
 	}
 }
+
+type testTenantRepository struct {
+	name    string
+	ctx     context.Context
+	query   *cmock.QueryMock
+	session *cmock.SessionMock
+	key     raizel.EntityKey
+	cql     string
+	err     error
+}
+
+func (scenario *testTenantRepository) setup(t *testing.T) {
+	var (
+		query   = cmock.NewQueryMock()
+		session = cmock.NewSessionMock()
+	)
+	require.NotNil(t, query, "mock query instance")
+	require.NotNil(t, session, "mock session instance")
+
+	query.On("Exec").Return(nil)
+	session.On("Query", scenario.cql, mock.Anything).Return(query)
+	session.On("Close")
+
+	scenario.query = query
+	scenario.session = session
+}
+
+func TestTenantRepository(test *testing.T) {
+	scenarios := []testTenantRepository{
+		{
+			name: "Delete entity from the tenant keyspace",
+			ctx:  raizel.WithTenant(context.Background(), "tenant_a"),
+			key: testEntityKey{
+				entityName: "testEntityKey",
+				name:       "id",
+				value:      "identifier",
+			},
+			cql: "DELETE FROM tenant_a.testEntityKey WHERE id=? ",
+		},
+		{
+			name: "Error when try to Delete an entity without tenant",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				entityName: "testEntityKey",
+				name:       "id",
+				value:      "identifier",
+			},
+			err: raizel.ErrTenantRequired,
+		},
+		{
+			name: "Error when try to Delete an entity of an invalid tenant keyspace",
+			ctx:  raizel.WithTenant(context.Background(), "tenant_a.entities; DROP KEYSPACE tenant_b"),
+			key: testEntityKey{
+				entityName: "testEntityKey",
+				name:       "id",
+				value:      "identifier",
+			},
+			err: raizel.ErrInvalidArgument,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := cassandra.NewTenantRepository(scenario.session)
+				require.NotNil(t, repository, "repository instance")
+				err := repository.Delete(scenario.ctx, scenario.key)
+				require.True(t, errors.Is(err, scenario.err), "delete error")
+				repository.Close(scenario.ctx)
+				if scenario.err == nil {
+					scenario.session.AssertExpectations(t)
+					scenario.query.AssertExpectations(t)
+				} else {
+					scenario.session.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+				}
+			},
+		)
+	}
+}
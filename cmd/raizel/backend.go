@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	database "database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	_ "github.com/lib/pq"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/sql"
+)
+
+var (
+	ErrUnknownBackend = errors.New("err_unknownbackend")
+)
+
+// backend is a repository the copy reads with pages and writes with Set.
+type backend interface {
+	raizel.Repository
+	raizel.Pageable
+}
+
+// opener opens the backend of an url for the entities named entityName,
+// entity is a pointer to an entity that describes the fields.
+type opener func(ctx context.Context, location *url.URL, entityName string, entity raizel.Entity) (backend, error)
+
+var openers = map[string]opener{
+	"postgres":   openSQL("postgres", sqlbuilder.PostgreSQL, (*url.URL).String),
+	"postgresql": openSQL("postgres", sqlbuilder.PostgreSQL, (*url.URL).String),
+	"firestore":  openFirestore,
+}
+
+func openSQL(driverName string, flavor sqlbuilder.Flavor, dataSource func(*url.URL) string) opener {
+	return func(ctx context.Context, location *url.URL, entityName string, entity raizel.Entity) (backend, error) {
+		sqlDB, err := database.Open(driverName, dataSource(location))
+		if err != nil {
+			return nil, err
+		}
+		db, err := sql.NewDB(sqlDB)
+		if err != nil {
+			return nil, err
+		}
+		mapper := sql.NewMapperBuilder().Set(entityName, sqlbuilder.NewStruct(entity).For(flavor)).NewMapper()
+		return sql.NewRepository(db, mapper), nil
+	}
+}
+
+// openFirestore opens the firestore project named by the url host, the
+// FIRESTORE_EMULATOR_HOST environment variable points the client to an
+// emulator.
+func openFirestore(ctx context.Context, location *url.URL, entityName string, entity raizel.Entity) (backend, error) {
+	client, err := firestore.OpenClient(ctx, location.Host)
+	if err != nil {
+		return nil, err
+	}
+	repository, ok := firestore.NewRepository(client).(backend)
+	if !ok {
+		return nil, fmt.Errorf("%w: firestore is not pageable", ErrUnknownBackend)
+	}
+	return repository, nil
+}
+
+func openBackend(ctx context.Context, rawURL string, entityName string, entity raizel.Entity) (backend, error) {
+	location, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	open, found := openers[location.Scheme]
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, location.Scheme)
+	}
+	return open(ctx, location, entityName, entity)
+}
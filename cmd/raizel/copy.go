@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/transfer"
+)
+
+func copyCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		flags      = flag.NewFlagSet("copy", flag.ContinueOnError)
+		from       = flags.String("from", "", "source backend url")
+		to         = flags.String("to", "", "target backend url")
+		entityName = flags.String("entity", "", "entity name, the table or collection")
+		keyField   = flags.String("key", "id", "key field, orders the source pages")
+		fields     = flags.String("fields", "", "entity fields as name:type, types are "+
+			"string, int, float, bool, time, bytes and strings")
+		batchSize  = flags.Int("batch", transfer.DefaultBatchSize, "entities read and written per batch")
+		rate       = flags.Float64("rate", 0, "maximum entities written per second, 0 is unlimited")
+		checkpoint = flags.String("checkpoint", "", "file that stores the progress to resume the copy")
+		dryRun     = flags.Bool("dry-run", false, "reads the source without writing the target")
+		verify     = flags.Bool("verify", false, "compares counts and checksums after the copy")
+	)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" || *entityName == "" || *fields == "" {
+		flags.Usage()
+		return fmt.Errorf("%w: from, to, entity and fields are required", transfer.ErrInvalidOptions)
+	}
+	entity, err := entityType(*fields)
+	if err != nil {
+		return err
+	}
+	newEntity := func() raizel.Entity {
+		return reflect.New(entity).Interface()
+	}
+	source, err := openBackend(ctx, *from, *entityName, newEntity())
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer source.Close(ctx)
+	target, err := openBackend(ctx, *to, *entityName, newEntity())
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer target.Close(ctx)
+
+	options := transfer.Options{
+		EntityName: *entityName,
+		KeyField:   *keyField,
+		NewEntity:  newEntity,
+		BatchSize:  *batchSize,
+		Rate:       *rate,
+		DryRun:     *dryRun,
+	}
+	if *checkpoint != "" {
+		options.Checkpoint = transfer.NewFileCheckpoint(*checkpoint)
+	}
+	report, err := transfer.Copy(ctx, source, target, options)
+	if err != nil {
+		return fmt.Errorf("copied %d entities: %w", report.Entities, err)
+	}
+	mode := "copied"
+	if *dryRun {
+		mode = "dry run read"
+	}
+	if report.Resumed {
+		mode = "resumed and " + mode
+	}
+	fmt.Fprintf(stdout, "%s %d entities in %d batches\n", mode, report.Entities, report.Batches)
+	if !*verify || *dryRun {
+		return nil
+	}
+	verification, err := transfer.Verify(ctx, source, target, options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(
+		stdout, "verified %d entities checksum %s\n", verification.TargetCount, verification.TargetChecksum,
+	)
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidFields = errors.New("err_invalidfields")
+
+	fieldTypes = map[string]reflect.Type{
+		"string":  reflect.TypeOf(""),
+		"int":     reflect.TypeOf(int64(0)),
+		"float":   reflect.TypeOf(float64(0)),
+		"bool":    reflect.TypeOf(false),
+		"time":    reflect.TypeOf(time.Time{}),
+		"bytes":   reflect.TypeOf([]byte(nil)),
+		"strings": reflect.TypeOf([]string(nil)),
+	}
+)
+
+// entityType builds the entity struct of fields written as name:type pairs
+// separated by commas, the name is the db, firestore, spanner and json tag of
+// the field.
+func entityType(fields string) (reflect.Type, error) {
+	var structFields []reflect.StructField
+	for index, field := range strings.Split(fields, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFields, field)
+		}
+		fieldType, found := fieldTypes[parts[1]]
+		if !found {
+			return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidFields, parts[1])
+		}
+		structFields = append(structFields, reflect.StructField{
+			Name: fmt.Sprintf("Field%d", index),
+			Type: fieldType,
+			Tag: reflect.StructTag(fmt.Sprintf(
+				`db:"%[1]s" firestore:"%[1]s" spanner:"%[1]s" json:"%[1]s"`, parts[0],
+			)),
+		})
+	}
+	return reflect.StructOf(structFields), nil
+}
@@ -0,0 +1,42 @@
+// Command raizel runs maintenance tasks over raizel backends.
+//
+//	raizel copy -from postgres://user@host/db -to firestore://project \
+//	    -entity customer -key id -fields id:string,name:string,created_at:time
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: raizel <command> [flags]
+
+commands:
+  copy    copies the entities of a backend to another backend
+`
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "copy":
+		err = copyCommand(ctx, args[1:], stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "raizel %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func expectPages(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT id, name, age FROM customer ORDER BY "id" ASC LIMIT 2`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "age"}).
+			AddRow("customer1", "Customer One", int64(10)).
+			AddRow("customer2", "Customer Two", int64(20)),
+	)
+	mock.ExpectQuery(`SELECT id, name, age FROM customer WHERE "id" > \$1 ORDER BY "id" ASC LIMIT 2`).
+		WithArgs("customer2").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "age"}).AddRow("customer3", "Customer Three", int64(30)),
+		)
+}
+
+type testRun struct {
+	name   string
+	args   []string
+	setup  func(sqlmock.Sqlmock)
+	code   int
+	stdout string
+	stored int
+}
+
+func TestRun(test *testing.T) {
+	scenarios := []testRun{
+		{
+			name: "Copy and verify postgres entities",
+			args: []string{
+				"copy", "-from", "sqlmock://%s", "-to", "memory://", "-entity", "customer",
+				"-fields", "id:string,name:string,age:int", "-batch", "2", "-verify",
+			},
+			setup: func(mock sqlmock.Sqlmock) {
+				expectPages(mock)
+				expectPages(mock)
+				mock.ExpectClose()
+			},
+			stdout: "copied 3 entities in 2 batches\nverified 3 entities checksum ",
+			stored: 3,
+		},
+		{
+			name: "Dry run postgres entities",
+			args: []string{
+				"copy", "-from", "sqlmock://%s", "-to", "memory://", "-entity", "customer",
+				"-fields", "id:string,name:string,age:int", "-batch", "2", "-dry-run", "-verify",
+			},
+			setup: func(mock sqlmock.Sqlmock) {
+				expectPages(mock)
+				mock.ExpectClose()
+			},
+			stdout: "dry run read 3 entities in 2 batches\n",
+		},
+		{
+			name: "Error when the fields are invalid",
+			args: []string{
+				"copy", "-from", "sqlmock://%s", "-to", "memory://", "-entity", "customer", "-fields", "id:uuid",
+			},
+			setup: func(sqlmock.Sqlmock) {},
+			code:  1,
+		},
+		{
+			name: "Error when the backend is unknown",
+			args: []string{
+				"copy", "-from", "mysql://%s", "-to", "memory://", "-entity", "customer", "-fields", "id:string",
+			},
+			setup: func(sqlmock.Sqlmock) {},
+			code:  1,
+		},
+		{
+			name:  "Error when the command is unknown",
+			args:  []string{"move"},
+			setup: func(sqlmock.Sqlmock) {},
+			code:  2,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					dsn            = fmt.Sprintf("raizel_copy_%d", index)
+					target         = memory.NewRepository()
+					stdout, stderr bytes.Buffer
+					args           = make([]string, len(scenario.args))
+				)
+				_, mock, err := sqlmock.NewWithDSN(dsn)
+				require.Nil(t, err, "sqlmock error")
+				scenario.setup(mock)
+
+				openers["sqlmock"] = openSQL("sqlmock", sqlbuilder.PostgreSQL, func(location *url.URL) string {
+					return location.Host
+				})
+				openers["memory"] = func(context.Context, *url.URL, string, raizel.Entity) (backend, error) {
+					return target, nil
+				}
+				defer delete(openers, "sqlmock")
+				defer delete(openers, "memory")
+
+				for index, arg := range scenario.args {
+					args[index] = arg
+					if arg == "sqlmock://%s" || arg == "mysql://%s" {
+						args[index] = fmt.Sprintf(arg, dsn)
+					}
+				}
+				code := run(context.Background(), args, &stdout, &stderr)
+				require.Equal(t, scenario.code, code, "exit code: %s", stderr.String())
+				require.Contains(t, stdout.String(), scenario.stdout, "stdout")
+				require.Nil(t, mock.ExpectationsWereMet(), "sqlmock expectations")
+
+				iterator, err := target.Query(context.Background(), raizel.NewQuery("customer"))
+				require.Nil(t, err, "query error")
+				var stored int
+				for {
+					entity, _ := entityType("id:string,name:string,age:int")
+					if iterator.Next(context.Background(), reflect.New(entity).Interface()) != nil {
+						break
+					}
+					stored++
+				}
+				require.Equal(t, scenario.stored, stored, "stored entities")
+			},
+		)
+	}
+}
+
+func TestRunFirestore(test *testing.T) {
+	server, err := firestoretest.NewServer()
+	require.Nil(test, err, "firestore server error")
+	defer server.Close()
+	test.Setenv("FIRESTORE_EMULATOR_HOST", server.Addr)
+
+	dsn := "raizel_copy_firestore"
+	_, mock, err := sqlmock.NewWithDSN(dsn)
+	require.Nil(test, err, "sqlmock error")
+	expectPages(mock)
+	expectPages(mock)
+	mock.ExpectClose()
+	openers["sqlmock"] = openSQL("sqlmock", sqlbuilder.PostgreSQL, func(location *url.URL) string {
+		return location.Host
+	})
+	defer delete(openers, "sqlmock")
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"copy", "-from", "sqlmock://" + dsn, "-to", "firestore://raizel-copy", "-entity", "customer",
+		"-fields", "id:string,name:string,age:int", "-batch", "2", "-verify",
+	}, &stdout, &stderr)
+	require.Equal(test, 0, code, "exit code: %s", stderr.String())
+	require.Contains(test, stdout.String(), "copied 3 entities in 2 batches\nverified 3 entities checksum ", "stdout")
+	require.Nil(test, mock.ExpectationsWereMet(), "sqlmock expectations")
+	for _, id := range []string{"customer1", "customer2", "customer3"} {
+		_, found := server.Document("projects/raizel-copy/databases/(default)/documents/customer/" + id)
+		require.True(test, found, "copied document %s", id)
+	}
+}
+
+func TestRunFirestoreCredentials(test *testing.T) {
+	test.Setenv("FIRESTORE_EMULATOR_HOST", "")
+	test.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/raizel/missing/credentials.json")
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"copy", "-from", "firestore://raizel-copy", "-to", "firestore://raizel-copy", "-entity", "customer",
+		"-fields", "id:string",
+	}, &stdout, &stderr)
+	require.Equal(test, 1, code, "exit code")
+	require.Contains(test, stderr.String(), "source: ", "stderr")
+}
@@ -0,0 +1,79 @@
+package raizel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIncomparable = errors.New("err_incomparable")
+)
+
+// numberOf returns the float64 of a value of any numeric kind.
+func numberOf(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+// CompareValues orders numbers of any kind, strings, booleans and times, nil
+// is ordered before any value. It returns -1, 0 or 1 like strings.Compare.
+func CompareValues(a, b interface{}) (int, error) {
+	var (
+		va = reflect.ValueOf(a)
+		vb = reflect.ValueOf(b)
+	)
+	if !va.IsValid() || !vb.IsValid() {
+		switch {
+		case va.IsValid():
+			return 1, nil
+		case vb.IsValid():
+			return -1, nil
+		}
+		return 0, nil
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1, nil
+			case ta.After(tb):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if na, ok := numberOf(va); ok {
+		if nb, ok := numberOf(vb); ok {
+			switch {
+			case na < nb:
+				return -1, nil
+			case na > nb:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	switch {
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return strings.Compare(va.String(), vb.String()), nil
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		switch {
+		case va.Bool() == vb.Bool():
+			return 0, nil
+		case vb.Bool():
+			return -1, nil
+		}
+		return 1, nil
+	}
+	return 0, fmt.Errorf("%w: %T and %T", ErrIncomparable, a, b)
+}
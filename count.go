@@ -0,0 +1,44 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrCountUnsupported = errors.New("err_countunsupported")
+)
+
+// Counter is implemented by repositories that check and count entities
+// without transferring them. Count ignores the query orders and limit.
+type Counter interface {
+	Exists(context.Context, EntityKey) (bool, error)
+	Count(context.Context, Query) (int64, error)
+}
+
+// InTenant returns a copy of the query over the entities of tenant, the
+// tenant repositories scope the queries they count with it.
+func (q Query) InTenant(tenant string) Query {
+	q.Tenant = tenant
+	return q
+}
+
+// Exists reports whether the entity of the key is stored, the repository must
+// implement Counter or ErrCountUnsupported is returned.
+func Exists(ctx context.Context, repository Repository, key EntityKey) (bool, error) {
+	counter, ok := repository.(Counter)
+	if !ok {
+		return false, ErrCountUnsupported
+	}
+	return counter.Exists(ctx, key)
+}
+
+// Count returns the number of entities selected by the query, the repository
+// must implement Counter or ErrCountUnsupported is returned.
+func Count(ctx context.Context, repository Repository, query Query) (int64, error) {
+	counter, ok := repository.(Counter)
+	if !ok {
+		return 0, ErrCountUnsupported
+	}
+	return counter.Count(ctx, query)
+}
@@ -0,0 +1,56 @@
+package raizel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type counterRepository struct {
+	keyRecorderRepository
+	queries []Query
+}
+
+func (r *counterRepository) Exists(_ context.Context, key EntityKey) (bool, error) {
+	r.keys = append(r.keys, key)
+	return true, nil
+}
+
+func (r *counterRepository) Count(_ context.Context, query Query) (int64, error) {
+	r.queries = append(r.queries, query)
+	return 3, nil
+}
+
+func TestCount(test *testing.T) {
+	var (
+		ctx   = context.Background()
+		key   = NewDynamicKey("entities", "id", "mock1")
+		query = NewQuery("entities").Where("age", Greater, 18)
+	)
+	_, err := Exists(ctx, &keyRecorderRepository{}, key)
+	require.Equal(test, ErrCountUnsupported, err, "unsupported exists error")
+	_, err = Count(ctx, &keyRecorderRepository{}, query)
+	require.Equal(test, ErrCountUnsupported, err, "unsupported count error")
+
+	var (
+		counter    = &counterRepository{}
+		repository = NewTenantRepository(counter)
+	)
+	_, err = Exists(ctx, repository, key)
+	require.Equal(test, ErrTenantRequired, err, "tenant exists error")
+	_, err = Count(ctx, repository, query)
+	require.Equal(test, ErrTenantRequired, err, "tenant count error")
+
+	ctx = WithTenant(ctx, "tenant1")
+	exists, err := Exists(ctx, repository, key)
+	require.Nil(test, err, "exists error")
+	require.True(test, exists, "exists result")
+	require.Equal(test, []EntityKey{NewTenantKey("tenant1", key)}, counter.keys, "tenant key")
+
+	count, err := Count(ctx, repository, query)
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(3), count, "count result")
+	require.Equal(test, []Query{query.InTenant("tenant1")}, counter.queries, "tenant query")
+	require.Empty(test, query.Tenant, "query copy")
+}
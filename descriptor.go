@@ -0,0 +1,371 @@
+package raizel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	omitEmptyTag = "omitempty"
+	indexTag     = "index"
+	uniqueTag    = "unique"
+	encryptTag   = "encrypt"
+	// deterministicTag encrypts the field with a deterministic nonce.
+	deterministicTag = "deterministic"
+)
+
+var (
+	ErrDuplicateEntity = errors.New("err_duplicateentity")
+
+	// legacyTags name the fields of entities without the raizel tag.
+	legacyTags = []string{"db", "firestore", "spanner", "json"}
+)
+
+// Field describes an entity field. Name is the field name in every backend,
+// Path is the reflect index of the field, embedded fields included.
+type Field struct {
+	Name      string
+	GoName    string
+	Path      []int
+	Type      reflect.Type
+	Key       bool
+	OmitEmpty bool
+	Indexed   bool
+	Unique    bool
+	// Encrypted fields are encrypted by NewEncryptedRepository, the
+	// Deterministic ones to the same ciphertext for the same value and key.
+	Encrypted     bool
+	Deterministic bool
+}
+
+// Descriptor is the metadata of an entity read from the raizel tag:
+//
+//	type Customer struct {
+//		_     struct{} `raizel:"customers,entity"`
+//		ID    string   `raizel:"id,key"`
+//		Email string   `raizel:"email,unique"`
+//		Name  string   `raizel:"name,omitempty"`
+//		TaxID string   `raizel:"tax_id,encrypt,deterministic"`
+//	}
+//
+// A field without the raizel tag is named by its db, firestore, spanner or
+// json tag, or by the field name in snake case. The "-" name skips a field.
+type Descriptor struct {
+	Name   string
+	Type   reflect.Type
+	Fields []Field
+
+	tagged sync.Map
+}
+
+// snakeCase converts a Go name to lower case words separated by underscores,
+// MockEntity is mock_entity and UserID is user_id.
+func snakeCase(name string) string {
+	var (
+		words strings.Builder
+		runes = []rune(name)
+	)
+	for index, char := range runes {
+		if unicode.IsUpper(char) && index > 0 {
+			previous := runes[index-1]
+			nextLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words.WriteByte('_')
+			}
+		}
+		words.WriteRune(unicode.ToLower(char))
+	}
+	return words.String()
+}
+
+func tagOptions(field reflect.StructField) (string, map[string]bool) {
+	var (
+		parts   = strings.Split(field.Tag.Get(TagName), ",")
+		options = make(map[string]bool, len(parts)-1)
+	)
+	for _, option := range parts[1:] {
+		options[option] = true
+	}
+	return parts[0], options
+}
+
+func fieldName(field reflect.StructField, name string) string {
+	if name != "" {
+		return name
+	}
+	for _, tag := range legacyTags {
+		if legacy := strings.Split(field.Tag.Get(tag), ",")[0]; legacy != "" {
+			return legacy
+		}
+	}
+	return snakeCase(field.Name)
+}
+
+func (d *Descriptor) addFields(structType reflect.Type, path []int) {
+	for index := 0; index < structType.NumField(); index++ {
+		var (
+			field         = structType.Field(index)
+			name, options = tagOptions(field)
+			fieldPath     = append(append([]int{}, path...), index)
+		)
+		if options[entityTag] {
+			if name != "" {
+				d.Name = name
+			}
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			d.addFields(field.Type, fieldPath)
+			continue
+		}
+		if field.PkgPath != "" || name == "-" || (name == "" && field.Tag.Get("db") == "-") {
+			continue
+		}
+		d.Fields = append(d.Fields, Field{
+			Name:          fieldName(field, name),
+			GoName:        field.Name,
+			Path:          fieldPath,
+			Type:          field.Type,
+			Key:           options[keyTag],
+			OmitEmpty:     options[omitEmptyTag],
+			Indexed:       options[indexTag] || options[uniqueTag],
+			Unique:        options[uniqueTag],
+			Encrypted:     options[encryptTag] || options[deterministicTag],
+			Deterministic: options[deterministicTag],
+		})
+	}
+}
+
+// Describe reads the descriptor of the entity struct, it does not register
+// the descriptor.
+func Describe(entity Entity) (*Descriptor, error) {
+	structType, err := entityType(entity)
+	if err != nil {
+		return nil, err
+	}
+	descriptor := &Descriptor{Name: snakeCase(structType.Name()), Type: structType}
+	descriptor.addFields(structType, nil)
+	return descriptor, nil
+}
+
+// Field returns the field named name.
+func (d *Descriptor) Field(name string) (Field, bool) {
+	for _, field := range d.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// Keys returns the key fields in declaration order.
+func (d *Descriptor) Keys() []Field {
+	var keys []Field
+	for _, field := range d.Fields {
+		if field.Key {
+			keys = append(keys, field)
+		}
+	}
+	return keys
+}
+
+// Indexes returns the indexed fields, the unique fields included.
+func (d *Descriptor) Indexes() []Field {
+	var indexes []Field
+	for _, field := range d.Fields {
+		if field.Indexed {
+			indexes = append(indexes, field)
+		}
+	}
+	return indexes
+}
+
+func (d *Descriptor) Names() []string {
+	names := make([]string, len(d.Fields))
+	for index, field := range d.Fields {
+		names[index] = field.Name
+	}
+	return names
+}
+
+func (d *Descriptor) structValue(entity Entity) (reflect.Value, error) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.IsValid() || value.Type() != d.Type {
+		return reflect.Value{}, fmt.Errorf("%w: %T is not %s", ErrInvalidEntity, entity, d.Type)
+	}
+	return value, nil
+}
+
+// Values returns the field values of the entity in the Fields order.
+func (d *Descriptor) Values(entity Entity) ([]interface{}, error) {
+	value, err := d.structValue(entity)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(d.Fields))
+	for index, field := range d.Fields {
+		values[index] = value.FieldByIndex(field.Path).Interface()
+	}
+	return values, nil
+}
+
+// Map returns the field values of the entity by name, the empty values of
+// the omitempty fields are left out.
+func (d *Descriptor) Map(entity Entity) (map[string]interface{}, error) {
+	value, err := d.structValue(entity)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(d.Fields))
+	for _, field := range d.Fields {
+		fieldValue := value.FieldByIndex(field.Path)
+		if field.OmitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		values[field.Name] = fieldValue.Interface()
+	}
+	return values, nil
+}
+
+// Key returns the key of the entity read from the key fields.
+func (d *Descriptor) Key(entity Entity) (EntityKey, error) {
+	keys := d.Keys()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingKey, d.Type)
+	}
+	value, err := d.structValue(entity)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		names  = make([]string, len(keys))
+		values = make([]interface{}, len(keys))
+	)
+	for index, key := range keys {
+		names[index] = key.Name
+		values[index] = value.FieldByIndex(key.Path).Interface()
+	}
+	if len(keys) == 1 {
+		return NewDynamicKey(d.Name, names[0], values[0]), nil
+	}
+	return NewCompositeKey(d.Name, names, values), nil
+}
+
+// TaggedType returns a flat struct with the entity fields tagged with tag and
+// the field names, so libraries that read their own struct tags map the
+// entity with the descriptor names. The omitempty fields keep the option when
+// omitEmpty is true.
+func (d *Descriptor) TaggedType(tag string, omitEmpty bool) reflect.Type {
+	cacheKey := fmt.Sprintf("%s,%t", tag, omitEmpty)
+	if tagged, found := d.tagged.Load(cacheKey); found {
+		return tagged.(reflect.Type)
+	}
+	fields := make([]reflect.StructField, len(d.Fields))
+	for index, field := range d.Fields {
+		value := field.Name
+		if omitEmpty && field.OmitEmpty {
+			value += "," + omitEmptyTag
+		}
+		fields[index] = reflect.StructField{
+			Name: fmt.Sprintf("Field%d", index),
+			Type: field.Type,
+			Tag:  reflect.StructTag(fmt.Sprintf("%s:%q", tag, value)),
+		}
+	}
+	tagged := reflect.StructOf(fields)
+	d.tagged.Store(cacheKey, tagged)
+	return tagged
+}
+
+// ToTagged returns a pointer to a new tagged struct with the entity values.
+func (d *Descriptor) ToTagged(entity Entity, tagged reflect.Type) (interface{}, error) {
+	value, err := d.structValue(entity)
+	if err != nil {
+		return nil, err
+	}
+	target := reflect.New(tagged)
+	for index, field := range d.Fields {
+		target.Elem().Field(index).Set(value.FieldByIndex(field.Path))
+	}
+	return target.Interface(), nil
+}
+
+// FromTagged copies the values of a tagged struct pointer to the entity.
+func (d *Descriptor) FromTagged(source interface{}, entity Entity) error {
+	target := reflect.ValueOf(entity)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Type() != d.Type {
+		return fmt.Errorf("%w: %T is not *%s", ErrInvalidEntity, entity, d.Type)
+	}
+	value := reflect.Indirect(reflect.ValueOf(source))
+	for index, field := range d.Fields {
+		target.Elem().FieldByIndex(field.Path).Set(value.Field(index))
+	}
+	return nil
+}
+
+var registry = struct {
+	sync.RWMutex
+	byName map[string]*Descriptor
+	byType map[reflect.Type]*Descriptor
+}{
+	byName: make(map[string]*Descriptor),
+	byType: make(map[reflect.Type]*Descriptor),
+}
+
+// init registers the entities written by raizel itself, so the backends map
+// the outbox events and the audit records with their descriptors.
+func init() {
+	for _, entity := range []Entity{&OutboxEvent{}, &AuditRecord{}} {
+		if _, err := RegisterEntity(entity); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterEntity describes and registers the entity, the backends map the
+// registered entity types with the descriptor instead of their own tags.
+// Registering another type with a registered name returns ErrDuplicateEntity.
+func RegisterEntity(entity Entity) (*Descriptor, error) {
+	descriptor, err := Describe(entity)
+	if err != nil {
+		return nil, err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if registered, found := registry.byName[descriptor.Name]; found {
+		if registered.Type != descriptor.Type {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateEntity, descriptor.Name)
+		}
+		return registered, nil
+	}
+	registry.byName[descriptor.Name] = descriptor
+	registry.byType[descriptor.Type] = descriptor
+	return descriptor, nil
+}
+
+// EntityDescriptor returns the descriptor registered with the entity name.
+func EntityDescriptor(entityName string) (*Descriptor, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	descriptor, found := registry.byName[entityName]
+	return descriptor, found
+}
+
+// DescriptorOf returns the descriptor registered for the entity type.
+func DescriptorOf(entity Entity) (*Descriptor, bool) {
+	entityType := reflect.TypeOf(entity)
+	for entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	descriptor, found := registry.byType[entityType]
+	return descriptor, found
+}
@@ -0,0 +1,158 @@
+package raizel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Audit struct {
+	CreatedBy string `raizel:"created_by,omitempty"`
+}
+
+type describedEntity struct {
+	_      struct{} `raizel:"described,entity"`
+	UserID string   `raizel:",key"`
+	Email  string   `raizel:"email,unique"`
+	Age    int      `raizel:"age,index,omitempty"`
+	Legacy string   `firestore:"legacy_name"`
+	Hidden string   `raizel:"-"`
+	Audit
+	internal string
+}
+
+func TestSnakeCase(test *testing.T) {
+	for name, expected := range map[string]string{
+		"MockEntity": "mock_entity",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Item2Name":  "item2_name",
+		"id":         "id",
+	} {
+		require.Equal(test, expected, snakeCase(name), "snake case of %s", name)
+	}
+}
+
+func TestDescribe(test *testing.T) {
+	descriptor, err := Describe(&describedEntity{})
+	require.Nil(test, err, "describe error")
+	require.Equal(test, "described", descriptor.Name, "descriptor name")
+	require.Equal(
+		test, []string{"user_id", "email", "age", "legacy_name", "created_by"}, descriptor.Names(), "field names",
+	)
+	require.Len(test, descriptor.Keys(), 1, "key fields")
+	require.Equal(test, "user_id", descriptor.Keys()[0].Name, "key field name")
+	require.Len(test, descriptor.Indexes(), 2, "indexed fields")
+
+	field, found := descriptor.Field("created_by")
+	require.True(test, found, "embedded field")
+	require.Equal(test, []int{6, 0}, field.Path, "embedded field path")
+	require.True(test, field.OmitEmpty, "embedded field omitempty")
+
+	entity := &describedEntity{UserID: "mock1", Email: "mock@mock.com", Legacy: "legacy"}
+	values, err := descriptor.Map(entity)
+	require.Nil(test, err, "map error")
+	require.Equal(
+		test,
+		map[string]interface{}{"user_id": "mock1", "email": "mock@mock.com", "legacy_name": "legacy"},
+		values,
+		"map values",
+	)
+	key, err := descriptor.Key(entity)
+	require.Nil(test, err, "key error")
+	require.Equal(test, NewDynamicKey("described", "user_id", "mock1"), key, "entity key")
+
+	_, err = descriptor.Values(&keyedEntity{})
+	require.True(test, errors.Is(err, ErrInvalidEntity), "values of another type error")
+}
+
+type testTagged struct {
+	name      string
+	tag       string
+	omitEmpty bool
+	tags      []string
+}
+
+func TestDescriptorTagged(test *testing.T) {
+	descriptor, err := Describe(describedEntity{})
+	require.Nil(test, err, "describe error")
+	scenarios := []testTagged{
+		{
+			name: "Tagged type with the field names",
+			tag:  "db",
+			tags: []string{"user_id", "email", "age", "legacy_name", "created_by"},
+		},
+		{
+			name:      "Tagged type with the omitempty option",
+			tag:       "firestore",
+			omitEmpty: true,
+			tags:      []string{"user_id", "email", "age,omitempty", "legacy_name", "created_by,omitempty"},
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				tagged := descriptor.TaggedType(scenario.tag, scenario.omitEmpty)
+				require.Equal(t, tagged, descriptor.TaggedType(scenario.tag, scenario.omitEmpty), "cached type")
+				require.Equal(t, len(scenario.tags), tagged.NumField(), "tagged fields")
+				for index, tag := range scenario.tags {
+					require.Equal(t, tag, tagged.Field(index).Tag.Get(scenario.tag), "field tag")
+				}
+
+				var (
+					entity = describedEntity{UserID: "mock1", Age: 7, Audit: Audit{CreatedBy: "mock"}}
+					copied describedEntity
+				)
+				value, err := descriptor.ToTagged(entity, tagged)
+				require.Nil(t, err, "to tagged error")
+				require.Equal(t, reflect.PtrTo(tagged), reflect.TypeOf(value), "tagged value type")
+				require.Nil(t, descriptor.FromTagged(value, &copied), "from tagged error")
+				require.Equal(t, entity, copied, "copied entity")
+			},
+		)
+	}
+}
+
+type registeredEntity struct {
+	_  struct{} `raizel:"registered,entity"`
+	ID string   `raizel:"id,key"`
+}
+
+type duplicatedEntity struct {
+	_  struct{} `raizel:"registered,entity"`
+	ID string   `raizel:"id,key"`
+}
+
+func TestRegisterEntity(test *testing.T) {
+	descriptor, err := RegisterEntity(&registeredEntity{})
+	require.Nil(test, err, "register error")
+	again, err := RegisterEntity(registeredEntity{})
+	require.Nil(test, err, "register again error")
+	require.True(test, descriptor == again, "registered descriptor")
+
+	_, err = RegisterEntity(&duplicatedEntity{})
+	require.True(test, errors.Is(err, ErrDuplicateEntity), "duplicated name error")
+
+	byName, found := EntityDescriptor("registered")
+	require.True(test, found, "descriptor by name")
+	require.True(test, descriptor == byName, "descriptor by name")
+	byType, found := DescriptorOf(&registeredEntity{})
+	require.True(test, found, "descriptor by type")
+	require.True(test, descriptor == byType, "descriptor by type")
+	_, found = DescriptorOf(&duplicatedEntity{})
+	require.False(test, found, "unregistered descriptor")
+}
+
+func TestRegisteredRaizelEntities(test *testing.T) {
+	for _, entity := range []Entity{&OutboxEvent{}, &AuditRecord{}} {
+		descriptor, found := DescriptorOf(entity)
+		require.True(test, found, "descriptor of %T", entity)
+		byName, found := EntityDescriptor(descriptor.Name)
+		require.True(test, found, "descriptor of %s", descriptor.Name)
+		require.True(test, descriptor == byName, "descriptor by name of %s", descriptor.Name)
+	}
+}
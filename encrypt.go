@@ -0,0 +1,508 @@
+package raizel
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownKey        = errors.New("err_unknownkey")
+	ErrInvalidCiphertext = errors.New("err_invalidciphertext")
+)
+
+// KeyProvider supplies the encryption keys. CurrentKey encrypts the new
+// values and Key decrypts the values encrypted with a previous key, so a key
+// is rotated by changing the current key while keeping the old ones.
+type KeyProvider interface {
+	CurrentKey(context.Context) (string, []byte, error)
+	Key(context.Context, string) ([]byte, error)
+}
+
+type staticKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider returns a KeyProvider with fixed keys by id, current
+// is the id of the key encrypting the new values.
+func NewStaticKeyProvider(current string, keys map[string][]byte) KeyProvider {
+	return staticKeyProvider{current: current, keys: keys}
+}
+
+func (p staticKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := p.Key(ctx, p.current)
+	return p.current, key, err
+}
+
+func (p staticKeyProvider) Key(_ context.Context, id string) ([]byte, error) {
+	key, found := p.keys[id]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// Cipher encrypts the field values with a key, authenticating the associated
+// data with the ciphertext so it only decrypts with the same associated data.
+// A deterministic encryption returns the same ciphertext for the same
+// plaintext, associated data and key.
+type Cipher interface {
+	Encrypt(key []byte, plaintext []byte, additionalData []byte, deterministic bool) ([]byte, error)
+	Decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error)
+}
+
+type aesGCM struct{}
+
+// AESGCM returns the AES-GCM Cipher, the default cipher, with 16, 24 or 32
+// bytes keys. The nonce prefixes the ciphertext, a deterministic nonce is
+// the HMAC-SHA256 of the associated data and the plaintext.
+func AESGCM() Cipher {
+	return aesGCM{}
+}
+
+func (aesGCM) aead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c aesGCM) Encrypt(key []byte, plaintext []byte, additionalData []byte, deterministic bool) ([]byte, error) {
+	aead, err := c.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		derived := hmac.New(sha256.New, key)
+		derived.Write([]byte("raizel.deterministic"))
+		mac := hmac.New(sha256.New, derived.Sum(nil))
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(additionalData)))
+		mac.Write(length[:])
+		mac.Write(additionalData)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func (c aesGCM) Decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := c.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCiphertext, err)
+	}
+	return plaintext, nil
+}
+
+type EncryptionOption func(*encryptedRepository)
+
+// WithCipher replaces the AES-GCM cipher of the encrypted fields.
+func WithCipher(cipher Cipher) EncryptionOption {
+	return func(r *encryptedRepository) {
+		r.cipher = cipher
+	}
+}
+
+type encryptedRepository struct {
+	repository  Repository
+	provider    KeyProvider
+	cipher      Cipher
+	descriptors sync.Map
+}
+
+// NewEncryptedRepository wraps repository encrypting the fields tagged with
+// the encrypt option before Set and decrypting them after Get and Query, so
+// every backend stores the ciphertext. The encrypted fields are strings or
+// byte slices holding the key id and the base64 ciphertext, "id:ciphertext",
+// and the empty values are stored empty.
+//
+// The ciphertext of a field is bound to the entity name, the key tenant, the
+// key path from the root ancestor and the field name, so a ciphertext copied
+// to another field, entity, parent or tenant fails with ErrInvalidCiphertext.
+// Query derives the key of the results with KeyOf, scoped to the parent and
+// the tenant of the query, so the entities with encrypted fields must have
+// their key fields tagged, the registered ones without return
+// ErrInvalidArgument before the query runs.
+//
+// The fields tagged deterministic can be compared with Equal filters and
+// Assign updates, which are encrypted with the current key when the entity
+// is registered with RegisterEntity. The lookups do not know the key, so a
+// deterministic ciphertext is bound to "entityName||fieldName" only and can
+// be copied between the entities of the same name. A deterministic lookup
+// only finds the values encrypted with the current key, so the entities must
+// be set again after a key rotation.
+func NewEncryptedRepository(repository Repository, provider KeyProvider, options ...EncryptionOption) Repository {
+	encrypted := &encryptedRepository{repository: repository, provider: provider, cipher: AESGCM()}
+	for _, option := range options {
+		option(encrypted)
+	}
+	return encrypted
+}
+
+// encryptedFields returns the encrypted fields of the entity type.
+func (r *encryptedRepository) encryptedFields(entityType reflect.Type) []Field {
+	if cached, found := r.descriptors.Load(entityType); found {
+		return cached.([]Field)
+	}
+	var fields []Field
+	if entityType.Kind() == reflect.Struct {
+		descriptor := &Descriptor{Type: entityType}
+		descriptor.addFields(entityType, nil)
+		for _, field := range descriptor.Fields {
+			if field.Encrypted {
+				fields = append(fields, field)
+			}
+		}
+	}
+	r.descriptors.Store(entityType, fields)
+	return fields
+}
+
+// associatedData returns the data the ciphertext of the field is bound to,
+// the length prefixed entity name, key tenant, key path and field name. The
+// deterministic fields are not bound to the key.
+func associatedData(entityName string, key EntityKey, field Field) []byte {
+	if field.Deterministic {
+		return []byte(entityName + "||" + field.Name)
+	}
+	tenant, _ := TenantOf(key)
+	parts := []string{entityName, tenant}
+	for _, pathKey := range KeyPath(key) {
+		parts = append(parts, pathKey.EntityName(), fmt.Sprint(pathKey.Value()))
+	}
+	var data strings.Builder
+	for _, part := range append(parts, field.Name) {
+		fmt.Fprintf(&data, "%d:%s|", len(part), part)
+	}
+	return []byte(data.String())
+}
+
+func (r *encryptedRepository) encrypt(ctx context.Context, field Field, plaintext []byte, additionalData []byte) (string, error) {
+	id, key, err := r.provider.CurrentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := r.cipher.Encrypt(key, plaintext, additionalData, field.Deterministic)
+	if err != nil {
+		return "", err
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (r *encryptedRepository) decrypt(ctx context.Context, field Field, encrypted string, additionalData []byte) ([]byte, error) {
+	separator := strings.LastIndex(encrypted, ":")
+	if separator < 0 {
+		return nil, fmt.Errorf("%w: field %s without key id", ErrInvalidCiphertext, field.Name)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted[separator+1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: field %s: %v", ErrInvalidCiphertext, field.Name, err)
+	}
+	key, err := r.provider.Key(ctx, encrypted[:separator])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := r.cipher.Decrypt(key, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	return plaintext, nil
+}
+
+// encryptValue returns the stored value of a field value, a string or a
+// byte slice with the type of the value.
+func (r *encryptedRepository) encryptValue(
+	ctx context.Context, field Field, value reflect.Value, additionalData []byte,
+) (reflect.Value, error) {
+	var plaintext []byte
+	switch {
+	case value.Kind() == reflect.String:
+		plaintext = []byte(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		plaintext = value.Bytes()
+	default:
+		return reflect.Value{}, fmt.Errorf("%w: encrypted field %s is %s, not a string or []byte", ErrInvalidEntity, field.Name, value.Type())
+	}
+	if len(plaintext) == 0 {
+		return value, nil
+	}
+	encrypted, err := r.encrypt(ctx, field, plaintext, additionalData)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if value.Kind() == reflect.String {
+		return reflect.ValueOf(encrypted).Convert(value.Type()), nil
+	}
+	return reflect.ValueOf([]byte(encrypted)).Convert(value.Type()), nil
+}
+
+// encryptEntity returns a copy of the entity with the encrypted fields, the
+// entity itself when it has no encrypted fields.
+func (r *encryptedRepository) encryptEntity(ctx context.Context, key EntityKey, entity Entity) (Entity, error) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return entity, nil
+	}
+	fields := r.encryptedFields(value.Type())
+	if len(fields) == 0 {
+		return entity, nil
+	}
+	encrypted := reflect.New(value.Type())
+	encrypted.Elem().Set(value)
+	for _, field := range fields {
+		target := encrypted.Elem().FieldByIndex(field.Path)
+		stored, err := r.encryptValue(ctx, field, target, associatedData(key.EntityName(), key, field))
+		if err != nil {
+			return nil, err
+		}
+		target.Set(stored)
+	}
+	return encrypted.Interface(), nil
+}
+
+// decryptEntity decrypts the encrypted fields of the entity in place.
+func (r *encryptedRepository) decryptEntity(ctx context.Context, entityName string, key EntityKey, entity Entity) error {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.CanAddr() {
+		return nil
+	}
+	for _, field := range r.encryptedFields(value.Type()) {
+		target := value.FieldByIndex(field.Path)
+		var encrypted string
+		switch {
+		case target.Kind() == reflect.String:
+			encrypted = target.String()
+		case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8:
+			encrypted = string(target.Bytes())
+		default:
+			return fmt.Errorf("%w: encrypted field %s is %s, not a string or []byte", ErrInvalidEntity, field.Name, target.Type())
+		}
+		if encrypted == "" {
+			continue
+		}
+		plaintext, err := r.decrypt(ctx, field, encrypted, associatedData(entityName, key, field))
+		if err != nil {
+			return err
+		}
+		if target.Kind() == reflect.String {
+			target.SetString(string(plaintext))
+		} else {
+			target.SetBytes(plaintext)
+		}
+	}
+	return nil
+}
+
+// resultKey returns the key of a query result with encrypted fields, read
+// with KeyOf and scoped to the parent and the tenant of the query.
+func (r *encryptedRepository) resultKey(query Query, entity Entity) (EntityKey, error) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.IsValid() || len(r.encryptedFields(value.Type())) == 0 {
+		return nil, nil
+	}
+	key, err := KeyOf(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s results are decrypted with their key: %v", ErrInvalidArgument, query.EntityName, err)
+	}
+	if query.Parent != nil {
+		key = NewChildKey(query.Parent, key)
+	}
+	if query.Tenant != "" {
+		key = NewTenantKey(query.Tenant, key)
+	}
+	return key, nil
+}
+
+// checkResultKeys returns ErrInvalidArgument when the registered query
+// entity has encrypted fields but no key fields to decrypt them with.
+func checkResultKeys(query Query) error {
+	descriptor, found := EntityDescriptor(query.EntityName)
+	if !found || len(descriptor.Keys()) > 0 {
+		return nil
+	}
+	for _, field := range descriptor.Fields {
+		if field.Encrypted {
+			return fmt.Errorf("%w: %s has encrypted fields without key fields", ErrInvalidArgument, query.EntityName)
+		}
+	}
+	return nil
+}
+
+// encryptedField returns the encrypted field of the registered entity.
+func encryptedField(entityName string, name string) (Field, bool) {
+	descriptor, found := EntityDescriptor(entityName)
+	if !found {
+		return Field{}, false
+	}
+	field, found := descriptor.Field(name)
+	return field, found && field.Encrypted
+}
+
+// encryptQuery encrypts the Equal filter values of the deterministic fields.
+func (r *encryptedRepository) encryptQuery(ctx context.Context, query Query) (Query, error) {
+	filters := make([]Filter, len(query.Filters))
+	for index, filter := range query.Filters {
+		filters[index] = filter
+		field, found := encryptedField(query.EntityName, filter.Field)
+		if !found {
+			continue
+		}
+		if !field.Deterministic || filter.Operator != Equal {
+			return Query{}, fmt.Errorf("%w: filter %s %s on encrypted field", ErrInvalidArgument, filter.Field, filter.Operator)
+		}
+		stored, err := r.encryptValue(ctx, field, reflect.ValueOf(filter.Value), associatedData(query.EntityName, nil, field))
+		if err != nil {
+			return Query{}, err
+		}
+		filters[index].Value = stored.Interface()
+	}
+	query.Filters = filters
+	return query, nil
+}
+
+// Get runs the AfterGet hook of the entity on the decrypted values.
+func (r *encryptedRepository) Get(ctx context.Context, key EntityKey, entity Entity) error {
+	if err := r.repository.Get(withHooksApplied(ctx), key, entity); err != nil {
+		return err
+	}
+	if err := r.decryptEntity(ctx, key.EntityName(), key, entity); err != nil {
+		return err
+	}
+	return AfterGet(ctx, entity)
+}
+
+// Set runs the BeforeSet and Validate hooks of the entity on the plaintext
+// values.
+func (r *encryptedRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	if err := BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	encrypted, err := r.encryptEntity(ctx, key, entity)
+	if err != nil {
+		return err
+	}
+	return r.repository.Set(withHooksApplied(ctx), key, encrypted)
+}
+
+func (r *encryptedRepository) Delete(ctx context.Context, key EntityKey) error {
+	if err := BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	return r.repository.Delete(withHooksApplied(ctx), key)
+}
+
+// Patch encrypts the Assign updates of the encrypted fields, the other
+// transforms of an encrypted field are refused.
+func (r *encryptedRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	encrypted := make([]Update, len(updates))
+	for index, update := range updates {
+		encrypted[index] = update
+		field, found := encryptedField(key.EntityName(), update.Field)
+		if !found || update.Transform == DeleteTransform {
+			continue
+		}
+		if update.Transform != AssignTransform {
+			return fmt.Errorf("%w: transform %d of encrypted field %s", ErrInvalidArgument, update.Transform, update.Field)
+		}
+		stored, err := r.encryptValue(ctx, field, reflect.ValueOf(update.Value), associatedData(key.EntityName(), key, field))
+		if err != nil {
+			return err
+		}
+		encrypted[index].Value = stored.Interface()
+	}
+	return Patch(ctx, r.repository, key, encrypted...)
+}
+
+func (r *encryptedRepository) DeleteCascade(ctx context.Context, key EntityKey) error {
+	return DeleteCascade(ctx, r.repository, key)
+}
+
+func (r *encryptedRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	return Exists(ctx, r.repository, key)
+}
+
+func (r *encryptedRepository) Count(ctx context.Context, query Query) (int64, error) {
+	encrypted, err := r.encryptQuery(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return Count(ctx, r.repository, encrypted)
+}
+
+// Query decrypts the query results, the repository must implement Queryable
+// or ErrNotQueryable is returned.
+func (r *encryptedRepository) Query(ctx context.Context, query Query) (Iterator, error) {
+	queryable, ok := r.repository.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	if err := checkResultKeys(query); err != nil {
+		return nil, err
+	}
+	encrypted, err := r.encryptQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := queryable.Query(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingIterator{iterator: iterator, repository: r, query: query}, nil
+}
+
+func (r *encryptedRepository) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
+
+type decryptingIterator struct {
+	iterator   Iterator
+	repository *encryptedRepository
+	query      Query
+}
+
+func (i *decryptingIterator) Next(ctx context.Context, entity Entity) error {
+	if err := i.iterator.Next(ctx, entity); err != nil {
+		return err
+	}
+	key, err := i.repository.resultKey(i.query, entity)
+	if err != nil {
+		return err
+	}
+	return i.repository.decryptEntity(ctx, i.query.EntityName, key, entity)
+}
+
+func (i *decryptingIterator) Stop() {
+	i.iterator.Stop()
+}
@@ -0,0 +1,222 @@
+package raizel_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type encryptedCustomer struct {
+	_        struct{} `raizel:"encrypted_customers,entity"`
+	ID       string   `raizel:"id,key"`
+	Name     string   `raizel:"name"`
+	Email    string   `raizel:"email,encrypt,deterministic"`
+	Document []byte   `raizel:"document,encrypt"`
+	Note     string   `raizel:"note,encrypt"`
+}
+
+var (
+	keyV1 = bytes.Repeat([]byte{1}, 32)
+	keyV2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestAESGCM(test *testing.T) {
+	var (
+		cipher    = raizel.AESGCM()
+		plaintext = []byte("mock@raizel.io")
+		aad       = []byte("customers|mock1|email")
+	)
+	first, err := cipher.Encrypt(keyV1, plaintext, aad, false)
+	require.Nil(test, err, "encrypt error")
+	second, err := cipher.Encrypt(keyV1, plaintext, aad, false)
+	require.Nil(test, err, "encrypt error")
+	require.NotEqual(test, first, second, "randomized ciphertext invalid value")
+
+	first, err = cipher.Encrypt(keyV1, plaintext, aad, true)
+	require.Nil(test, err, "deterministic encrypt error")
+	second, err = cipher.Encrypt(keyV1, plaintext, aad, true)
+	require.Nil(test, err, "deterministic encrypt error")
+	require.Equal(test, first, second, "deterministic ciphertext invalid value")
+	other, err := cipher.Encrypt(keyV1, plaintext, []byte("customers|mock1|name"), true)
+	require.Nil(test, err, "deterministic encrypt error")
+	require.NotEqual(test, first[:12], other[:12], "deterministic nonce reused with other associated data")
+
+	decrypted, err := cipher.Decrypt(keyV1, first, aad)
+	require.Nil(test, err, "decrypt error")
+	require.Equal(test, plaintext, decrypted, "decrypted invalid value")
+
+	_, err = cipher.Decrypt(keyV2, first, aad)
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "wrong key error")
+	_, err = cipher.Decrypt(keyV1, first, []byte("customers|mock2|email"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "wrong associated data error")
+	_, err = cipher.Encrypt([]byte("short"), plaintext, aad, false)
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "invalid key error")
+}
+
+func TestEncryptedRepository(test *testing.T) {
+	_, err := raizel.RegisterEntity(encryptedCustomer{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx      = context.Background()
+		store    = memory.NewRepository()
+		v1       = raizel.NewEncryptedRepository(store, raizel.NewStaticKeyProvider("v1", map[string][]byte{"v1": keyV1}))
+		customer = encryptedCustomer{ID: "mock1", Name: "Mock One", Email: "mock1@raizel.io", Document: []byte("123.456")}
+		key      = raizel.NewDynamicKey("encrypted_customers", "id", "mock1")
+		result   encryptedCustomer
+		stored   encryptedCustomer
+	)
+	require.Nil(test, v1.Set(ctx, key, &customer), "set error")
+	require.Equal(test, "mock1@raizel.io", customer.Email, "set changed the entity")
+
+	require.Nil(test, store.Get(ctx, key, &stored), "stored get error")
+	require.Equal(test, "Mock One", stored.Name, "stored plain field invalid value")
+	require.True(test, strings.HasPrefix(stored.Email, "v1:"), "stored email key id")
+	require.NotContains(test, stored.Email, "mock1@raizel.io", "stored email is plaintext")
+	require.NotEqual(test, customer.Document, stored.Document, "stored document is plaintext")
+	require.Empty(test, stored.Note, "stored empty field invalid value")
+
+	require.Nil(test, v1.Get(ctx, key, &result), "get error")
+	require.Equal(test, customer, result, "get invalid value")
+
+	var (
+		v2 = raizel.NewEncryptedRepository(store, raizel.NewStaticKeyProvider(
+			"v2", map[string][]byte{"v1": keyV1, "v2": keyV2},
+		))
+		query = raizel.NewQuery("encrypted_customers").Where("email", raizel.Equal, "mock1@raizel.io")
+	)
+	require.Nil(test, v2.Get(ctx, key, &result), "rotated get error")
+	require.Equal(test, customer, result, "rotated get invalid value")
+
+	count, err := raizel.Count(ctx, v2, query)
+	require.Nil(test, err, "count before rewrite error")
+	require.Zero(test, count, "count before rewrite invalid value")
+	require.Nil(test, v2.Set(ctx, key, &result), "rotated set error")
+	require.Nil(test, store.Get(ctx, key, &stored), "stored get error")
+	require.True(test, strings.HasPrefix(stored.Email, "v2:"), "rotated email key id")
+
+	iterator, err := v2.(raizel.Queryable).Query(ctx, query)
+	require.Nil(test, err, "query error")
+	defer iterator.Stop()
+	var queried encryptedCustomer
+	require.Nil(test, iterator.Next(ctx, &queried), "next error")
+	require.Equal(test, customer, queried, "next invalid value")
+	require.Equal(test, raizel.ErrIteratorDone, iterator.Next(ctx, &queried), "done error")
+
+	require.Nil(test, raizel.Patch(ctx, v2, key, raizel.Assign("note", "vip")), "patch error")
+	require.Nil(test, v2.Get(ctx, key, &result), "patched get error")
+	require.Equal(test, "vip", result.Note, "patched note invalid value")
+	require.Nil(test, store.Get(ctx, key, &stored), "stored get error")
+	require.NotEqual(test, "vip", stored.Note, "stored note is plaintext")
+
+	_, err = v2.(raizel.Queryable).Query(ctx, raizel.NewQuery("encrypted_customers").Where("note", raizel.Equal, "vip"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "randomized filter error")
+
+	err = raizel.NewEncryptedRepository(store, raizel.NewStaticKeyProvider("v2", map[string][]byte{"v2": keyV2})).Get(ctx, key, &result)
+	require.Nil(test, err, "current key get error")
+	require.True(test, errors.Is(v1.Get(ctx, key, &result), raizel.ErrUnknownKey), "unknown key error")
+}
+
+func TestEncryptedRepositorySwappedCiphertext(test *testing.T) {
+	_, err := raizel.RegisterEntity(encryptedCustomer{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = context.Background()
+		store      = memory.NewRepository()
+		repository = raizel.NewEncryptedRepository(store, raizel.NewStaticKeyProvider("v1", map[string][]byte{"v1": keyV1}))
+		key1       = raizel.NewDynamicKey("encrypted_customers", "id", "mock1")
+		key2       = raizel.NewDynamicKey("encrypted_customers", "id", "mock2")
+		stored1    encryptedCustomer
+		stored2    encryptedCustomer
+		result     encryptedCustomer
+	)
+	require.Nil(test, repository.Set(ctx, key1, &encryptedCustomer{
+		ID: "mock1", Email: "mock1@raizel.io", Document: []byte("123.456"), Note: "first",
+	}), "set mock1 error")
+	require.Nil(test, repository.Set(ctx, key2, &encryptedCustomer{
+		ID: "mock2", Email: "mock2@raizel.io", Document: []byte("654.321"), Note: "second",
+	}), "set mock2 error")
+	require.Nil(test, store.Get(ctx, key1, &stored1), "stored mock1 get error")
+	require.Nil(test, store.Get(ctx, key2, &stored2), "stored mock2 get error")
+
+	stored1.Document = stored2.Document
+	require.Nil(test, store.Set(ctx, key1, &stored1), "swap document error")
+	err = repository.Get(ctx, key1, &result)
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "swapped entity ciphertext error")
+
+	require.Nil(test, store.Get(ctx, key2, &stored2), "stored mock2 get error")
+	stored2.Note = string(stored2.Document)
+	require.Nil(test, store.Set(ctx, key2, &stored2), "swap note error")
+	err = repository.Get(ctx, key2, &result)
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "swapped field ciphertext error")
+}
+
+type encryptedItem struct {
+	_      struct{} `raizel:"encrypted_items,entity"`
+	ID     string   `raizel:"id,key"`
+	Secret string   `raizel:"secret,encrypt"`
+}
+
+type encryptedMemo struct {
+	_    struct{} `raizel:"encrypted_memos,entity"`
+	Text string   `raizel:"text,encrypt"`
+}
+
+func TestEncryptedRepositoryKeyScope(test *testing.T) {
+	_, err := raizel.RegisterEntity(encryptedItem{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = context.Background()
+		store      = memory.NewRepository()
+		repository = raizel.NewEncryptedRepository(store, raizel.NewStaticKeyProvider("v1", map[string][]byte{"v1": keyV1}))
+		item       = raizel.NewDynamicKey("encrypted_items", "id", "item1")
+		parent1    = raizel.NewDynamicKey("orders", "id", "order1")
+		parent2    = raizel.NewDynamicKey("orders", "id", "order2")
+		stored     encryptedItem
+		copied     encryptedItem
+		result     encryptedItem
+	)
+	require.Nil(test, repository.Set(ctx, raizel.NewTenantKey("t1", item), &encryptedItem{ID: "item1", Secret: "first"}), "set t1 error")
+	require.Nil(test, repository.Set(ctx, raizel.NewTenantKey("t2", item), &encryptedItem{ID: "item1", Secret: "second"}), "set t2 error")
+	require.Nil(test, store.Get(ctx, raizel.NewTenantKey("t1", item), &stored), "stored t1 get error")
+	require.Nil(test, store.Get(ctx, raizel.NewTenantKey("t2", item), &copied), "stored t2 get error")
+	copied.Secret = stored.Secret
+	require.Nil(test, store.Set(ctx, raizel.NewTenantKey("t2", item), &copied), "copy across tenants error")
+	err = repository.Get(ctx, raizel.NewTenantKey("t2", item), &result)
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "copied tenant ciphertext error")
+
+	var (
+		child1 = raizel.NewChildKey(parent1, item)
+		child2 = raizel.NewChildKey(parent2, item)
+	)
+	require.Nil(test, repository.Set(ctx, child1, &encryptedItem{ID: "item1", Secret: "first"}), "set child1 error")
+	require.Nil(test, repository.Set(ctx, child2, &encryptedItem{ID: "item1", Secret: "second"}), "set child2 error")
+
+	children, err := repository.(raizel.Queryable).Query(ctx, raizel.Children(parent1, "encrypted_items"))
+	require.Nil(test, err, "query children error")
+	require.Nil(test, children.Next(ctx, &result), "next child error")
+	require.Equal(test, "first", result.Secret, "queried child secret")
+	children.Stop()
+
+	require.Nil(test, store.Get(ctx, child1, &stored), "stored child1 get error")
+	require.Nil(test, store.Get(ctx, child2, &copied), "stored child2 get error")
+	copied.Secret = stored.Secret
+	require.Nil(test, store.Set(ctx, child2, &copied), "copy across parents error")
+	err = repository.Get(ctx, child2, &result)
+	require.True(test, errors.Is(err, raizel.ErrInvalidCiphertext), "copied parent ciphertext error")
+}
+
+func TestEncryptedRepositoryQueryWithoutKey(test *testing.T) {
+	_, err := raizel.RegisterEntity(encryptedMemo{})
+	require.Nil(test, err, "register error")
+	repository := raizel.NewEncryptedRepository(
+		memory.NewRepository(), raizel.NewStaticKeyProvider("v1", map[string][]byte{"v1": keyV1}),
+	)
+	_, err = repository.(raizel.Queryable).Query(context.Background(), raizel.NewQuery("encrypted_memos"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "query without key error")
+}
@@ -0,0 +1,285 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnavailable = errors.New("err_unavailable")
+)
+
+// Operation is a repository call a FaultRule injects faults into.
+type Operation string
+
+const (
+	GetOperation           Operation = "get"
+	SetOperation           Operation = "set"
+	DeleteOperation        Operation = "delete"
+	PatchOperation         Operation = "patch"
+	ExistsOperation        Operation = "exists"
+	CountOperation         Operation = "count"
+	AggregateOperation     Operation = "aggregate"
+	DeleteCascadeOperation Operation = "deletecascade"
+	QueryOperation         Operation = "query"
+	PageOperation          Operation = "page"
+	WatchOperation         Operation = "watch"
+	TransactionOperation   Operation = "transaction"
+)
+
+func (o Operation) write() bool {
+	switch o {
+	case SetOperation, DeleteOperation, PatchOperation, DeleteCascadeOperation:
+		return true
+	}
+	return false
+}
+
+// FaultRule injects a fault into the repository calls it matches, the calls
+// of Operations over the entities of Entities, every call when they are
+// blank. Sequence scripts the matching calls in order, a true entry injects
+// the fault and the calls after the end of the sequence are left alone, so
+// {false, false, true} fails the third write of a batch. Without a sequence
+// Always injects the fault on every call, otherwise the fault is injected
+// with Probability and never when it is zero. A Transaction call has no
+// entity, only the rules without Entities match it.
+//
+// The fault waits Latency, honoring the context deadline, and then fails
+// with Err, like ErrNotFound, ErrUnavailable or context.DeadlineExceeded.
+// Drop makes the writes succeed without reaching the wrapped repository.
+type FaultRule struct {
+	Operations  []Operation
+	Entities    []string
+	Sequence    []bool
+	Always      bool
+	Probability float64
+	Latency     time.Duration
+	Err         error
+	Drop        bool
+}
+
+func (rule FaultRule) matches(operation Operation, entityName string) bool {
+	return matchesAny(rule.Operations, operation) && matchesAny(rule.Entities, entityName)
+}
+
+func matchesAny[T comparable](values []T, value T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, current := range values {
+		if current == value {
+			return true
+		}
+	}
+	return false
+}
+
+type FaultOption func(*faultyRepository)
+
+// WithFaultSeed seeds the source of the rule probabilities, a run with the
+// same seed and the same call order injects the same faults.
+func WithFaultSeed(seed int64) FaultOption {
+	return func(r *faultyRepository) {
+		r.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// faults are the rules and their state, shared by the repositories of the
+// transactions of a faulty repository.
+type faults struct {
+	rules  []FaultRule
+	mutex  sync.Mutex
+	random *rand.Rand
+	calls  []int
+}
+
+type faultyRepository struct {
+	repository Repository
+	*faults
+}
+
+// NewFaultyRepository wraps repository injecting the faults of rules into
+// its calls, for resilience and chaos tests. Every matching rule is applied
+// in order: the latencies add up, the first error wins and any drop drops
+// the write. The probabilities use a fixed seed unless WithFaultSeed is set.
+func NewFaultyRepository(repository Repository, rules []FaultRule, options ...FaultOption) Repository {
+	faulty := &faultyRepository{
+		repository: repository,
+		faults: &faults{
+			rules:  rules,
+			random: rand.New(rand.NewSource(1)),
+			calls:  make([]int, len(rules)),
+		},
+	}
+	for _, option := range options {
+		option(faulty)
+	}
+	return faulty
+}
+
+func (r *faultyRepository) triggered(index int) bool {
+	rule := r.rules[index]
+	if len(rule.Sequence) > 0 {
+		call := r.calls[index]
+		r.calls[index]++
+		return call < len(rule.Sequence) && rule.Sequence[call]
+	}
+	if rule.Always {
+		return true
+	}
+	return rule.Probability > 0 && r.random.Float64() < rule.Probability
+}
+
+// inject applies the faults of the call, it returns true when the write must
+// be dropped.
+func (r *faultyRepository) inject(ctx context.Context, operation Operation, entityName string) (bool, error) {
+	var (
+		latency time.Duration
+		drop    bool
+		err     error
+	)
+	r.mutex.Lock()
+	for index, rule := range r.rules {
+		if !rule.matches(operation, entityName) || !r.triggered(index) {
+			continue
+		}
+		latency += rule.Latency
+		if err == nil {
+			err = rule.Err
+		}
+		drop = drop || (rule.Drop && operation.write())
+	}
+	r.mutex.Unlock()
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return drop, err
+}
+
+func (r *faultyRepository) Get(ctx context.Context, key EntityKey, entity Entity) error {
+	if _, err := r.inject(ctx, GetOperation, key.EntityName()); err != nil {
+		return err
+	}
+	return r.repository.Get(ctx, key, entity)
+}
+
+func (r *faultyRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	drop, err := r.inject(ctx, SetOperation, key.EntityName())
+	if err != nil || drop {
+		return err
+	}
+	return r.repository.Set(ctx, key, entity)
+}
+
+func (r *faultyRepository) Delete(ctx context.Context, key EntityKey) error {
+	drop, err := r.inject(ctx, DeleteOperation, key.EntityName())
+	if err != nil || drop {
+		return err
+	}
+	return r.repository.Delete(ctx, key)
+}
+
+func (r *faultyRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	drop, err := r.inject(ctx, PatchOperation, key.EntityName())
+	if err != nil || drop {
+		return err
+	}
+	return Patch(ctx, r.repository, key, updates...)
+}
+
+func (r *faultyRepository) DeleteCascade(ctx context.Context, key EntityKey) error {
+	drop, err := r.inject(ctx, DeleteCascadeOperation, key.EntityName())
+	if err != nil || drop {
+		return err
+	}
+	return DeleteCascade(ctx, r.repository, key)
+}
+
+func (r *faultyRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	if _, err := r.inject(ctx, ExistsOperation, key.EntityName()); err != nil {
+		return false, err
+	}
+	return Exists(ctx, r.repository, key)
+}
+
+func (r *faultyRepository) Count(ctx context.Context, query Query) (int64, error) {
+	if _, err := r.inject(ctx, CountOperation, query.EntityName); err != nil {
+		return 0, err
+	}
+	return Count(ctx, r.repository, query)
+}
+
+func (r *faultyRepository) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	if _, err := r.inject(ctx, AggregateOperation, aggregation.Query.EntityName); err != nil {
+		return nil, err
+	}
+	return Aggregate(ctx, r.repository, aggregation)
+}
+
+func (r *faultyRepository) Query(ctx context.Context, query Query) (Iterator, error) {
+	if _, err := r.inject(ctx, QueryOperation, query.EntityName); err != nil {
+		return nil, err
+	}
+	queryable, ok := r.repository.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	return queryable.Query(ctx, query)
+}
+
+func (r *faultyRepository) Page(ctx context.Context, query Query, pageToken string) (PageIterator, error) {
+	if _, err := r.inject(ctx, PageOperation, query.EntityName); err != nil {
+		return nil, err
+	}
+	pageable, ok := r.repository.(Pageable)
+	if !ok {
+		return nil, ErrPageUnsupported
+	}
+	return pageable.Page(ctx, query, pageToken)
+}
+
+func (r *faultyRepository) Watch(ctx context.Context, entityName string) (ChangeStream, error) {
+	if _, err := r.inject(ctx, WatchOperation, entityName); err != nil {
+		return nil, err
+	}
+	watcher, ok := r.repository.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	return watcher.Watch(ctx, entityName)
+}
+
+func (r *faultyRepository) WatchKey(ctx context.Context, key EntityKey) (ChangeStream, error) {
+	if _, err := r.inject(ctx, WatchOperation, key.EntityName()); err != nil {
+		return nil, err
+	}
+	watcher, ok := r.repository.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	return watcher.WatchKey(ctx, key)
+}
+
+// Transaction injects the faults of the transaction call and of the calls of
+// the transaction repository, the rules of both calls share their sequences.
+func (r *faultyRepository) Transaction(ctx context.Context, fn func(context.Context, Repository) error) error {
+	if _, err := r.inject(ctx, TransactionOperation, ""); err != nil {
+		return err
+	}
+	return Transaction(ctx, r.repository, func(ctx context.Context, tx Repository) error {
+		return fn(ctx, &faultyRepository{repository: tx, faults: r.faults})
+	})
+}
+
+func (r *faultyRepository) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
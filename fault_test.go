@@ -0,0 +1,202 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testFaultyRepository struct {
+	name      string
+	rules     []FaultRule
+	calls     int
+	entity    string
+	errs      []error
+	forwarded int
+}
+
+func TestFaultyRepository(test *testing.T) {
+	scenarios := []testFaultyRepository{
+		{
+			name:      "Forwards the calls without rules",
+			calls:     2,
+			entity:    "entity_name",
+			errs:      []error{nil, nil},
+			forwarded: 2,
+		},
+		{
+			name:      "Fails every matching call",
+			rules:     []FaultRule{{Always: true, Err: ErrUnavailable}},
+			calls:     2,
+			entity:    "entity_name",
+			errs:      []error{ErrUnavailable, ErrUnavailable},
+			forwarded: 0,
+		},
+		{
+			name:      "Never fails without a sequence, Always or a probability",
+			rules:     []FaultRule{{Err: ErrUnavailable}},
+			calls:     2,
+			entity:    "entity_name",
+			errs:      []error{nil, nil},
+			forwarded: 2,
+		},
+		{
+			name:      "Fails the scripted calls of a batch",
+			rules:     []FaultRule{{Sequence: []bool{false, true, false}, Err: ErrNotFound}},
+			calls:     4,
+			entity:    "entity_name",
+			errs:      []error{nil, ErrNotFound, nil, nil},
+			forwarded: 3,
+		},
+		{
+			name:      "Drops the writes silently",
+			rules:     []FaultRule{{Operations: []Operation{SetOperation}, Always: true, Drop: true}},
+			calls:     2,
+			entity:    "entity_name",
+			errs:      []error{nil, nil},
+			forwarded: 0,
+		},
+		{
+			name:      "Skips the calls of other entities",
+			rules:     []FaultRule{{Entities: []string{"other_entity"}, Always: true, Err: ErrUnavailable}},
+			calls:     2,
+			entity:    "entity_name",
+			errs:      []error{nil, nil},
+			forwarded: 2,
+		},
+		{
+			name:      "Skips the calls of other operations",
+			rules:     []FaultRule{{Operations: []Operation{GetOperation}, Always: true, Err: ErrNotFound}},
+			calls:     1,
+			entity:    "entity_name",
+			errs:      []error{nil},
+			forwarded: 1,
+		},
+		{
+			name: "Returns the first error of the matching rules",
+			rules: []FaultRule{
+				{Sequence: []bool{false}, Err: ErrNotFound},
+				{Always: true, Err: context.DeadlineExceeded},
+				{Always: true, Err: ErrUnavailable},
+			},
+			calls:     1,
+			entity:    "entity_name",
+			errs:      []error{context.DeadlineExceeded},
+			forwarded: 0,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					recorder   = new(keyRecorderRepository)
+					repository = NewFaultyRepository(recorder, scenario.rules)
+					key        = NewDynamicKey(scenario.entity, "key_name", "key_value")
+				)
+				for call := 0; call < scenario.calls; call++ {
+					require.Equal(t, scenario.errs[call], repository.Set(context.Background(), key, nil), "set error")
+				}
+				require.Len(t, recorder.keys, scenario.forwarded, "forwarded calls invalid length")
+				require.Nil(t, repository.Close(context.Background()), "close error")
+			},
+		)
+	}
+}
+
+func TestFaultyRepositorySeed(test *testing.T) {
+	var (
+		rules = []FaultRule{{Probability: 0.5, Err: ErrUnavailable}}
+		key   = NewDynamicKey("entity_name", "key_name", "key_value")
+		runs  [3][]bool
+	)
+	for run, seed := range []int64{42, 42, 7} {
+		repository := NewFaultyRepository(new(keyRecorderRepository), rules, WithFaultSeed(seed))
+		for call := 0; call < 64; call++ {
+			runs[run] = append(runs[run], repository.Get(context.Background(), key, nil) != nil)
+		}
+	}
+	require.Equal(test, runs[0], runs[1], "same seed faults invalid value")
+	require.NotEqual(test, runs[0], runs[2], "other seed faults invalid value")
+	require.Contains(test, runs[0], true, "seeded run without faults")
+	require.Contains(test, runs[0], false, "seeded run without successes")
+}
+
+func TestFaultyRepositoryLatency(test *testing.T) {
+	var (
+		recorder   = new(keyRecorderRepository)
+		repository = NewFaultyRepository(recorder, []FaultRule{{Always: true, Latency: 20 * time.Millisecond}})
+		key        = NewDynamicKey("entity_name", "key_name", "key_value")
+	)
+	started := time.Now()
+	require.Nil(test, repository.Get(context.Background(), key, nil), "get error")
+	require.True(test, time.Since(started) >= 20*time.Millisecond, "latency invalid value")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	require.Equal(test, context.DeadlineExceeded, repository.Get(ctx, key, nil), "deadline error")
+	require.Len(test, recorder.keys, 1, "forwarded calls invalid length")
+}
+
+func TestFaultyRepositoryUnsupported(test *testing.T) {
+	var (
+		repository = NewFaultyRepository(repositoryMock{}, nil)
+		key        = NewDynamicKey("entity_name", "key_name", "key_value")
+	)
+	_, err := Count(context.Background(), repository, NewQuery("entity_name"))
+	require.True(test, errors.Is(err, ErrCountUnsupported), "count error")
+	require.True(test, errors.Is(Patch(context.Background(), repository, key, DeleteField("field")), ErrPatchUnsupported), "patch error")
+}
+
+type queryRecorderRepository struct {
+	keyRecorderRepository
+	queries []Query
+}
+
+func (r *queryRecorderRepository) Query(_ context.Context, query Query) (Iterator, error) {
+	r.queries = append(r.queries, query)
+	return nil, nil
+}
+
+func (r *queryRecorderRepository) Page(_ context.Context, query Query, _ string) (PageIterator, error) {
+	r.queries = append(r.queries, query)
+	return nil, nil
+}
+
+func (r *queryRecorderRepository) Transaction(ctx context.Context, fn func(context.Context, Repository) error) error {
+	return fn(ctx, r)
+}
+
+func TestFaultyRepositoryForward(test *testing.T) {
+	var (
+		ctx      = context.Background()
+		recorder = new(queryRecorderRepository)
+		query    = NewQuery("entity_name")
+		key      = NewDynamicKey("entity_name", "key_name", "key_value")
+		rules    = []FaultRule{
+			{Operations: []Operation{PageOperation}, Always: true, Err: ErrUnavailable},
+			{Operations: []Operation{SetOperation}, Sequence: []bool{false, true}, Err: ErrNotFound},
+		}
+		repository = NewFaultyRepository(recorder, rules)
+	)
+	_, err := repository.(Queryable).Query(ctx, query)
+	require.Nil(test, err, "query error")
+	_, err = repository.(Pageable).Page(ctx, query, "")
+	require.Equal(test, ErrUnavailable, err, "page error")
+	require.Equal(test, []Query{query}, recorder.queries, "forwarded queries")
+	_, err = repository.(Watcher).Watch(ctx, "entity_name")
+	require.Equal(test, ErrWatchUnsupported, err, "watch error")
+
+	require.Nil(test, repository.Set(ctx, key, nil), "set error")
+	err = Transaction(ctx, repository, func(ctx context.Context, tx Repository) error {
+		return tx.Set(ctx, key, nil)
+	})
+	require.Equal(test, ErrNotFound, err, "transaction set error")
+	require.Len(test, recorder.keys, 1, "forwarded calls invalid length")
+	err = Transaction(ctx, NewFaultyRepository(repositoryMock{}, nil), func(context.Context, Repository) error { return nil })
+	require.Equal(test, ErrTransactionUnsupported, err, "unsupported transaction error")
+}
@@ -0,0 +1,44 @@
+package firestore
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+// Aggregate streams the documents of the query into a raizel.Accumulator,
+// the documents are read with the aggregation fields only and a missing
+// field is a nil value.
+func (r *repository) Aggregate(ctx context.Context, aggregation raizel.Aggregation) ([]raizel.AggregateResult, error) {
+	query := aggregation.Query
+	collection, err := queryCollection(query)
+	if err != nil {
+		return nil, err
+	}
+	query.Orders, query.Limit = nil, 0
+	var (
+		fields      = aggregation.Fields()
+		accumulator = raizel.NewAccumulator(aggregation)
+		documents   = entityQuery(r.client.Collection(collection).Select(fields...), query).Documents(ctx)
+	)
+	defer documents.Stop()
+	for {
+		doc, err := documents.Next()
+		if err == iterator.Done {
+			return accumulator.Results(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, err := doc.DataAt(field); err == nil {
+				row[field] = value
+			}
+		}
+		if err := accumulator.Add(row); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+func TestRepositoryAggregate(test *testing.T) {
+	var (
+		ctx        = raizel.WithTenant(context.Background(), "tenant1")
+		client     = fmock.NewClientMock()
+		collection = fmock.NewCollectionRefMock()
+		documents  = fmock.NewDocumentIteratorMock()
+		missing    = errors.New("missing field")
+		rows       = []map[string]interface{}{
+			{"city": "b", "age": int64(20)},
+			{"city": "a", "age": int64(30)},
+			{"city": "b"},
+		}
+	)
+	client.On("Collection", "tenants/tenant1/entities").Return(collection)
+	collection.On("Select", []string{"city", "age"}).Return(collection)
+	collection.On("Where", "age", ">=", 18).Return(collection)
+	collection.On("Documents", mock.Anything).Return(documents)
+	for _, row := range rows {
+		doc := fmock.NewDocumentSnapshotMock()
+		for _, field := range []string{"city", "age"} {
+			if value, found := row[field]; found {
+				doc.On("DataAt", field).Return(value, nil)
+			} else {
+				doc.On("DataAt", field).Return(nil, missing)
+			}
+		}
+		documents.On("Next").Return(doc, nil).Once()
+	}
+	documents.On("Next").Return(nil, iterator.Done)
+	documents.On("Stop")
+
+	aggregation := raizel.NewAggregation(
+		raizel.NewQuery("entities").Where("age", raizel.GreaterEqual, 18),
+		raizel.CountAll("total"),
+		raizel.Sum("age", "ages"),
+	).GroupedBy("city")
+	results, err := raizel.Aggregate(ctx, firestore.NewTenantRepository(client), aggregation)
+	require.Nil(test, err, "aggregate error")
+	require.Equal(
+		test,
+		[]raizel.AggregateResult{
+			{
+				Group:  map[string]interface{}{"city": "a"},
+				Values: map[string]interface{}{"total": int64(1), "ages": 30.0},
+			},
+			{
+				Group:  map[string]interface{}{"city": "b"},
+				Values: map[string]interface{}{"total": int64(2), "ages": 20.0},
+			},
+		},
+		results,
+		"aggregate results",
+	)
+	documents.AssertExpectations(test)
+}
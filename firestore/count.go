@@ -0,0 +1,63 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+// queryCollection returns the collection path of the query entities, under
+// the document of the query tenant when it is set.
+func queryCollection(query raizel.Query) (string, error) {
+	collection, err := entityCollection(query)
+	if err != nil || query.Tenant == "" {
+		return collection, err
+	}
+	return fmt.Sprintf("%s/%s/%s", tenantsCollection, escapeID(query.Tenant), collection), nil
+}
+
+// Exists iterates a query that selects no fields filtered by the document
+// ID, like Count the document is read without its data.
+func (r *repository) Exists(ctx context.Context, key raizel.EntityKey) (bool, error) {
+	path, err := entityDocRef(key)
+	if err != nil {
+		return false, err
+	}
+	collection := path[:strings.LastIndex(path, "/")]
+	documents := r.client.Collection(collection).Select().
+		Where(DocumentID, "==", r.client.Doc(path)).Limit(1).Documents(ctx)
+	defer documents.Stop()
+	if _, err := documents.Next(); err != nil {
+		if err == iterator.Done {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Count iterates a query that selects no fields, the documents are read
+// without their data.
+func (r *repository) Count(ctx context.Context, query raizel.Query) (int64, error) {
+	collection, err := queryCollection(query)
+	if err != nil {
+		return 0, err
+	}
+	query.Orders, query.Limit = nil, 0
+	documents := entityQuery(r.client.Collection(collection).Select(), query).Documents(ctx)
+	defer documents.Stop()
+	var count int64
+	for {
+		_, err := documents.Next()
+		if err == iterator.Done {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+}
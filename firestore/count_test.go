@@ -0,0 +1,179 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+type testRepositoryExists struct {
+	name    string
+	key     raizel.EntityKey
+	coll    string
+	path    string
+	nextErr error
+	exists  bool
+	err     error
+}
+
+func TestRepositoryExists(test *testing.T) {
+	nextErr := errors.New("next error")
+	scenarios := []testRepositoryExists{
+		{
+			name:   "Exists the stored document",
+			key:    raizel.NewDynamicKey("entities", "id", "mock1"),
+			coll:   "entities",
+			path:   "entities/mock1",
+			exists: true,
+		},
+		{
+			name: "Exists the stored child document",
+			key: raizel.NewChildKey(
+				raizel.NewDynamicKey("orders", "id", "order1"), raizel.NewDynamicKey("items", "id", "item1"),
+			),
+			coll:   "orders/order1/items",
+			path:   "orders/order1/items/item1",
+			exists: true,
+		},
+		{
+			name:    "Missing document",
+			key:     raizel.NewDynamicKey("entities", "id", "mock1"),
+			coll:    "entities",
+			path:    "entities/mock1",
+			nextErr: iterator.Done,
+		},
+		{
+			name:    "Error when the query fails",
+			key:     raizel.NewDynamicKey("entities", "id", "mock1"),
+			coll:    "entities",
+			path:    "entities/mock1",
+			nextErr: nextErr,
+			err:     nextErr,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					ctx        = context.Background()
+					client     = fmock.NewClientMock()
+					ref        = fmock.NewDocumentRefMock()
+					collection = fmock.NewCollectionRefMock()
+					documents  = fmock.NewDocumentIteratorMock()
+				)
+				client.On("Doc", scenario.path).Return(ref)
+				client.On("Collection", scenario.coll).Return(collection)
+				collection.On("Select", []string(nil)).Return(collection)
+				collection.On("Where", firestore.DocumentID, "==", ref).Return(collection)
+				collection.On("Limit", 1).Return(collection)
+				collection.On("Documents", ctx).Return(documents)
+				if scenario.nextErr != nil {
+					documents.On("Next").Return(nil, scenario.nextErr)
+				} else {
+					documents.On("Next").Return(fmock.NewDocumentSnapshotMock(), nil)
+				}
+				documents.On("Stop")
+
+				repository := firestore.NewRepository(client)
+				exists, err := raizel.Exists(ctx, repository, scenario.key)
+				require.Equal(t, scenario.err, err, "exists error")
+				require.Equal(t, scenario.exists, exists, "exists result")
+				ref.AssertNotCalled(t, "Get", mock.Anything)
+				collection.AssertExpectations(t)
+				documents.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+func TestRepositoryExistsServer(test *testing.T) {
+	server, err := firestoretest.NewServer()
+	require.Nil(test, err, "new server error")
+	defer server.Close()
+	fclient, err := server.NewClient(context.Background(), "exists")
+	require.Nil(test, err, "new firestore client error")
+	client, err := firestore.WrapClient(fclient)
+	require.Nil(test, err, "wrap client error")
+
+	var (
+		ctx        = context.Background()
+		repository = firestore.NewRepository(client)
+		stored     = raizel.NewDynamicKey("entities", "id", "stored")
+	)
+	defer repository.Close(ctx)
+	require.Nil(test, repository.Set(ctx, stored, testEntity{ID: "stored"}), "set error")
+	require.Nil(
+		test, repository.Set(ctx, raizel.NewDynamicKey("entities", "id", "other"), testEntity{ID: "other"}), "set error",
+	)
+
+	exists, err := raizel.Exists(ctx, repository, stored)
+	require.Nil(test, err, "exists error")
+	require.True(test, exists, "stored document exists")
+	exists, err = raizel.Exists(ctx, repository, raizel.NewDynamicKey("entities", "id", "missing"))
+	require.Nil(test, err, "missing exists error")
+	require.False(test, exists, "missing document exists")
+}
+
+type testRepositoryCount struct {
+	name       string
+	ctx        context.Context
+	repository func(firestore.Client) raizel.Repository
+	collection string
+	documents  int
+}
+
+func TestRepositoryCount(test *testing.T) {
+	scenarios := []testRepositoryCount{
+		{
+			name:       "Count the collection documents",
+			ctx:        context.Background(),
+			repository: firestore.NewRepository,
+			collection: "entities",
+			documents:  3,
+		},
+		{
+			name:       "Count the tenant collection documents",
+			ctx:        raizel.WithTenant(context.Background(), "tenant1"),
+			repository: firestore.NewTenantRepository,
+			collection: "tenants/tenant1/entities",
+			documents:  2,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					client     = fmock.NewClientMock()
+					collection = fmock.NewCollectionRefMock()
+					documents  = fmock.NewDocumentIteratorMock()
+				)
+				client.On("Collection", scenario.collection).Return(collection)
+				collection.On("Select", []string(nil)).Return(collection)
+				collection.On("Where", "age", ">", 18).Return(collection)
+				collection.On("Documents", mock.Anything).Return(documents)
+				documents.On("Next").Return(fmock.NewDocumentSnapshotMock(), nil).Times(scenario.documents)
+				documents.On("Next").Return(nil, iterator.Done)
+				documents.On("Stop")
+
+				query := raizel.NewQuery("entities").Where("age", raizel.Greater, 18).OrderBy("age", raizel.Asc)
+				count, err := raizel.Count(scenario.ctx, scenario.repository(client), query)
+				require.Nil(t, err, "count error")
+				require.Equal(t, int64(scenario.documents), count, "count result")
+				collection.AssertNotCalled(t, "OrderBy", mock.Anything, mock.Anything)
+				collection.AssertExpectations(t)
+				documents.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -14,33 +14,81 @@ type SetOption interface {
 
 type DocumentRef interface {
 	Get(context.Context) (DocumentSnapshot, error)
+	Create(context.Context, interface{}) error
 	Set(context.Context, interface{}, ...SetOption) error
+	Update(context.Context, []Update) error
 	Delete(context.Context) error
+	Snapshots(context.Context) DocumentSnapshotIterator
+	Collections(context.Context) CollectionIterator
 	delegate() *firestore.DocumentRef
 }
 
 type CollectionRef interface {
 	Query
+	DocumentRefs(context.Context) DocumentRefIterator
 	delegate() *firestore.CollectionRef
 }
 
+// CollectionIterator returns the subcollections of a document, Next returns
+// iterator.Done after the last collection.
+type CollectionIterator interface {
+	Next() (CollectionRef, error)
+}
+
+// DocumentRefIterator returns the document references of a collection, the
+// missing documents with subcollections included. Next returns iterator.Done
+// after the last reference.
+type DocumentRefIterator interface {
+	Next() (DocumentRef, error)
+}
+
 type DocumentSnapshot interface {
 	DataTo(interface{}) error
+	DataAt(string) (interface{}, error)
 	Exists() bool
 }
 
 type DocumentIterator interface {
 	GetAll() ([]DocumentSnapshot, error)
+	Next() (DocumentSnapshot, error)
+	Stop()
+}
+
+type DocumentChangeKind = firestore.DocumentChangeKind
+
+type DocumentChange struct {
+	Kind DocumentChangeKind
+	ID   string
+	Doc  DocumentSnapshot
+}
+
+// QuerySnapshotIterator returns the document changes of each query snapshot,
+// Next returns iterator.Done after Stop.
+type QuerySnapshotIterator interface {
+	Next() ([]DocumentChange, error)
+	Stop()
+}
+
+// DocumentSnapshotIterator returns the document snapshot each time it changes,
+// deleted documents are returned as snapshots that do not exist.
+type DocumentSnapshotIterator interface {
+	Next() (DocumentSnapshot, error)
+	Stop()
 }
 
 type Direction = firestore.Direction
 
+type Update = firestore.Update
+
 type Query interface {
 	Documents(context.Context) DocumentIterator
+	Snapshots(context.Context) QuerySnapshotIterator
 	Where(string, string, interface{}) Query
+	Select(...string) Query
 	OrderBy(string, Direction) Query
 	Offset(int) Query
 	Limit(int) Query
+	StartAfter(...interface{}) Query
 }
 
 type Client interface {
@@ -49,6 +97,7 @@ type Client interface {
 	Collection(string) CollectionRef
 	GetAll(context.Context, ...DocumentRef) ([]DocumentSnapshot, error)
 	Batch() WriteBatch
+	RunTransaction(context.Context, func(context.Context, Transaction) error) error
 }
 
 type WriteBatch interface {
@@ -57,12 +106,28 @@ type WriteBatch interface {
 	Commit(context.Context) error
 }
 
+// Transaction reads and writes documents atomically, every read must come
+// before the first write.
+type Transaction interface {
+	Get(DocumentRef) (DocumentSnapshot, error)
+	Set(DocumentRef, interface{}, ...SetOption) error
+	Update(DocumentRef, []Update) error
+	Delete(DocumentRef) error
+}
+
+// DocumentID is the field path of the document name, its filter values are
+// DocumentRefs.
+const DocumentID = firestore.DocumentID
+
 // delegate implementation
 var (
-	MergeAll                          = mergeSetOption{firestore.MergeAll}
-	Asc                     Direction = firestore.Asc
-	Desc                    Direction = firestore.Desc
-	ErrBlankFirestoreClient           = errors.New("err_blankclient")
+	MergeAll                                   = mergeSetOption{firestore.MergeAll}
+	Asc                     Direction          = firestore.Asc
+	Desc                    Direction          = firestore.Desc
+	DocumentAdded           DocumentChangeKind = firestore.DocumentAdded
+	DocumentModified        DocumentChangeKind = firestore.DocumentModified
+	DocumentRemoved         DocumentChangeKind = firestore.DocumentRemoved
+	ErrBlankFirestoreClient                    = errors.New("err_blankclient")
 )
 
 type mergeSetOption struct {
@@ -86,6 +151,11 @@ func (doc *documentRef) Get(ctx context.Context) (DocumentSnapshot, error) {
 	return doc.DocumentRef.Get(ctx)
 }
 
+func (doc *documentRef) Create(ctx context.Context, data interface{}) error {
+	_, err := doc.DocumentRef.Create(ctx, data)
+	return err
+}
+
 func (doc *documentRef) Set(ctx context.Context, data interface{}, opts ...SetOption) error {
 	fopts := make([]firestore.SetOption, len(opts))
 	for index, opt := range opts {
@@ -95,15 +165,99 @@ func (doc *documentRef) Set(ctx context.Context, data interface{}, opts ...SetOp
 	return err
 }
 
+func (doc *documentRef) Update(ctx context.Context, updates []Update) error {
+	_, err := doc.DocumentRef.Update(ctx, updates)
+	return err
+}
+
 func (doc *documentRef) Delete(ctx context.Context) error {
 	_, err := doc.DocumentRef.Delete(ctx)
 	return err
 }
 
+func (doc *documentRef) Snapshots(ctx context.Context) DocumentSnapshotIterator {
+	return &documentSnapshotIterator{
+		DocumentSnapshotIterator: doc.DocumentRef.Snapshots(ctx),
+	}
+}
+
+func (doc *documentRef) Collections(ctx context.Context) CollectionIterator {
+	return &collectionIterator{
+		CollectionIterator: doc.DocumentRef.Collections(ctx),
+	}
+}
+
+type collectionIterator struct {
+	*firestore.CollectionIterator
+}
+
+func (iter *collectionIterator) Next() (CollectionRef, error) {
+	ref, err := iter.CollectionIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &collectionRef{
+		query:         query{Query: ref.Query},
+		CollectionRef: ref,
+	}, nil
+}
+
+type documentRefIterator struct {
+	*firestore.DocumentRefIterator
+}
+
+func (iter *documentRefIterator) Next() (DocumentRef, error) {
+	ref, err := iter.DocumentRefIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &documentRef{DocumentRef: ref}, nil
+}
+
+type documentSnapshotIterator struct {
+	*firestore.DocumentSnapshotIterator
+}
+
+func (iter *documentSnapshotIterator) Next() (DocumentSnapshot, error) {
+	doc, err := iter.DocumentSnapshotIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+type querySnapshotIterator struct {
+	*firestore.QuerySnapshotIterator
+}
+
+func (iter *querySnapshotIterator) Next() ([]DocumentChange, error) {
+	snapshot, err := iter.QuerySnapshotIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]DocumentChange, len(snapshot.Changes))
+	for index, change := range snapshot.Changes {
+		changes[index] = DocumentChange{
+			Kind: change.Kind,
+			ID:   change.Doc.Ref.ID,
+			Doc:  change.Doc,
+		}
+	}
+	return changes, nil
+}
+
 type documentIterator struct {
 	*firestore.DocumentIterator
 }
 
+func (iter *documentIterator) Next() (DocumentSnapshot, error) {
+	doc, err := iter.DocumentIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
 func (iter *documentIterator) GetAll() ([]DocumentSnapshot, error) {
 	docs, err := iter.DocumentIterator.GetAll()
 	if err != nil {
@@ -120,9 +274,24 @@ type query struct {
 	firestore.Query
 }
 
+// whereValue returns the delegate of the DocumentRef values, the values of
+// the DocumentID filters.
+func whereValue(value interface{}) interface{} {
+	if ref, isRef := value.(DocumentRef); isRef {
+		return ref.delegate()
+	}
+	return value
+}
+
 func (q query) Where(path, op string, value interface{}) Query {
 	return query{
-		Query: q.Query.Where(path, op, value),
+		Query: q.Query.Where(path, op, whereValue(value)),
+	}
+}
+
+func (q query) Select(paths ...string) Query {
+	return query{
+		Query: q.Query.Select(paths...),
 	}
 }
 
@@ -131,6 +300,12 @@ func (q query) Documents(ctx context.Context) DocumentIterator {
 		DocumentIterator: q.Query.Documents(ctx),
 	}
 }
+
+func (q query) Snapshots(ctx context.Context) QuerySnapshotIterator {
+	return &querySnapshotIterator{
+		QuerySnapshotIterator: q.Query.Snapshots(ctx),
+	}
+}
 func (q query) OrderBy(path string, direction Direction) Query {
 	return query{
 		Query: q.Query.OrderBy(path, direction),
@@ -149,6 +324,12 @@ func (q query) Limit(n int) Query {
 	}
 }
 
+func (q query) StartAfter(values ...interface{}) Query {
+	return query{
+		Query: q.Query.StartAfter(values...),
+	}
+}
+
 type collectionRef struct {
 	query
 	*firestore.CollectionRef
@@ -160,7 +341,7 @@ func (coll *collectionRef) delegate() *firestore.CollectionRef {
 
 func (coll *collectionRef) Where(path, op string, value interface{}) Query {
 	return query{
-		Query: coll.CollectionRef.Where(path, op, value),
+		Query: coll.CollectionRef.Where(path, op, whereValue(value)),
 	}
 }
 
@@ -170,6 +351,18 @@ func (coll *collectionRef) Documents(ctx context.Context) DocumentIterator {
 	}
 }
 
+func (coll *collectionRef) Snapshots(ctx context.Context) QuerySnapshotIterator {
+	return &querySnapshotIterator{
+		QuerySnapshotIterator: coll.CollectionRef.Snapshots(ctx),
+	}
+}
+
+func (coll *collectionRef) DocumentRefs(ctx context.Context) DocumentRefIterator {
+	return &documentRefIterator{
+		DocumentRefIterator: coll.CollectionRef.DocumentRefs(ctx),
+	}
+}
+
 type writeBatch struct {
 	*firestore.WriteBatch
 }
@@ -195,6 +388,34 @@ func (w *writeBatch) Commit(ctx context.Context) error {
 	return err
 }
 
+type transaction struct {
+	*firestore.Transaction
+}
+
+func (t *transaction) Get(ref DocumentRef) (DocumentSnapshot, error) {
+	doc, err := t.Transaction.Get(ref.delegate())
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (t *transaction) Set(ref DocumentRef, data interface{}, opts ...SetOption) error {
+	fopts := make([]firestore.SetOption, len(opts))
+	for index, opt := range opts {
+		fopts[index] = opt.delegate()
+	}
+	return t.Transaction.Set(ref.delegate(), data, fopts...)
+}
+
+func (t *transaction) Update(ref DocumentRef, updates []Update) error {
+	return t.Transaction.Update(ref.delegate(), updates)
+}
+
+func (t *transaction) Delete(ref DocumentRef) error {
+	return t.Transaction.Delete(ref.delegate())
+}
+
 type client struct {
 	*firestore.Client
 }
@@ -206,8 +427,10 @@ func (c *client) Doc(path string) DocumentRef {
 }
 
 func (c *client) Collection(path string) CollectionRef {
+	ref := c.Client.Collection(path)
 	return &collectionRef{
-		CollectionRef: c.Client.Collection(path),
+		query:         query{Query: ref.Query},
+		CollectionRef: ref,
 	}
 }
 
@@ -233,6 +456,14 @@ func (c *client) Batch() WriteBatch {
 	}
 }
 
+// RunTransaction runs fn in a transaction, firestore runs fn again when the
+// transaction conflicts with another one.
+func (c *client) RunTransaction(ctx context.Context, fn func(context.Context, Transaction) error) error {
+	return c.Client.RunTransaction(ctx, func(ctx context.Context, ftransaction *firestore.Transaction) error {
+		return fn(ctx, &transaction{Transaction: ftransaction})
+	})
+}
+
 func newFirestoreClient(projectID string) (*firestore.Client, error) {
 	fmt.Println("begin_firestore_client")
 	defer fmt.Println("end_firestore_client")
@@ -246,6 +477,23 @@ func newClient(fclient *firestore.Client) (Client, error) {
 	return &client{fclient}, nil
 }
 
+// WrapClient returns a Client of an already configured firestore client, like
+// the clients of a firestoretest.Server.
+func WrapClient(fclient *firestore.Client) (Client, error) {
+	return newClient(fclient)
+}
+
+// OpenClient returns a Client of projectID, the FIRESTORE_EMULATOR_HOST
+// environment variable points the client to an emulator. Unlike NewClient it
+// returns the errors of the credentials and the connection.
+func OpenClient(ctx context.Context, projectID string) (Client, error) {
+	fclient, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(fclient)
+}
+
 func NewClient(projectID string) Client {
 	fcli, err := newFirestoreClient(projectID)
 	if err != nil {
@@ -0,0 +1,280 @@
+package firestoretest
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type queryOrder struct {
+	path       []string
+	descending bool
+}
+
+// fromCollection reports whether the document path relative to the query
+// parent belongs to the collection selector.
+func fromCollection(path []string, selector *pb.StructuredQuery_CollectionSelector) bool {
+	if len(path)%2 != 0 {
+		return false
+	}
+	if selector.GetAllDescendants() {
+		return path[len(path)-2] == selector.GetCollectionId()
+	}
+	return len(path) == 2 && path[0] == selector.GetCollectionId()
+}
+
+// matchFilter reports whether the document matches the filter, the range
+// operators only match values of the same type rank.
+func matchFilter(doc *pb.Document, filter *pb.StructuredQuery_Filter) (bool, error) {
+	switch filterType := filter.GetFilterType().(type) {
+	case nil:
+		return true, nil
+	case *pb.StructuredQuery_Filter_CompositeFilter:
+		if filterType.CompositeFilter.GetOp() != pb.StructuredQuery_CompositeFilter_AND {
+			return false, status.Error(codes.InvalidArgument, "unsupported composite filter")
+		}
+		for _, nested := range filterType.CompositeFilter.GetFilters() {
+			matched, err := matchFilter(doc, nested)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	case *pb.StructuredQuery_Filter_UnaryFilter:
+		path, err := parseFieldPath(filterType.UnaryFilter.GetField().GetFieldPath())
+		if err != nil {
+			return false, status.Error(codes.InvalidArgument, err.Error())
+		}
+		value, found := fieldValue(doc, path)
+		if !found {
+			return false, nil
+		}
+		switch filterType.UnaryFilter.GetOp() {
+		case pb.StructuredQuery_UnaryFilter_IS_NAN:
+			return isNaN(value), nil
+		case pb.StructuredQuery_UnaryFilter_IS_NULL:
+			_, isNull := value.GetValueType().(*pb.Value_NullValue)
+			return isNull, nil
+		}
+		return false, status.Error(codes.InvalidArgument, "unsupported unary filter")
+	case *pb.StructuredQuery_Filter_FieldFilter:
+		var (
+			fieldFilter = filterType.FieldFilter
+			operand     = fieldFilter.GetValue()
+		)
+		path, err := parseFieldPath(fieldFilter.GetField().GetFieldPath())
+		if err != nil {
+			return false, status.Error(codes.InvalidArgument, err.Error())
+		}
+		value, found := fieldValue(doc, path)
+		if !found {
+			return false, nil
+		}
+		if fieldFilter.GetOp() == pb.StructuredQuery_FieldFilter_ARRAY_CONTAINS {
+			return containsValue(value.GetArrayValue().GetValues(), operand), nil
+		}
+		if typeOrder(value) != typeOrder(operand) || isNaN(value) || isNaN(operand) {
+			return false, nil
+		}
+		compared := compareValues(value, operand)
+		switch fieldFilter.GetOp() {
+		case pb.StructuredQuery_FieldFilter_EQUAL:
+			return compared == 0, nil
+		case pb.StructuredQuery_FieldFilter_LESS_THAN:
+			return compared < 0, nil
+		case pb.StructuredQuery_FieldFilter_LESS_THAN_OR_EQUAL:
+			return compared <= 0, nil
+		case pb.StructuredQuery_FieldFilter_GREATER_THAN:
+			return compared > 0, nil
+		case pb.StructuredQuery_FieldFilter_GREATER_THAN_OR_EQUAL:
+			return compared >= 0, nil
+		}
+		return false, status.Error(codes.InvalidArgument, "unsupported field filter")
+	}
+	return false, status.Error(codes.InvalidArgument, "unknown filter")
+}
+
+// queryOrders returns the query orders followed by the implicit order by
+// document ID in the direction of the last order.
+func queryOrders(query *pb.StructuredQuery) ([]queryOrder, error) {
+	var (
+		orders     = make([]queryOrder, 0, len(query.GetOrderBy())+1)
+		descending bool
+	)
+	for _, order := range query.GetOrderBy() {
+		path, err := parseFieldPath(order.GetField().GetFieldPath())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		descending = order.GetDirection() == pb.StructuredQuery_DESCENDING
+		orders = append(orders, queryOrder{path: path, descending: descending})
+		if len(path) == 1 && path[0] == documentIDPath {
+			return orders, nil
+		}
+	}
+	return append(orders, queryOrder{path: []string{documentIDPath}, descending: descending}), nil
+}
+
+// orderValues returns the values of the order fields of the document, it
+// returns false when the document misses one of them.
+func orderValues(doc *pb.Document, orders []queryOrder) ([]*pb.Value, bool) {
+	values := make([]*pb.Value, len(orders))
+	for index, order := range orders {
+		value, found := fieldValue(doc, order.path)
+		if !found {
+			return nil, false
+		}
+		values[index] = value
+	}
+	return values, true
+}
+
+// compareCursor compares the document values with the cursor values, the
+// cursor may have fewer values than the orders.
+func compareCursor(values []*pb.Value, cursor *pb.Cursor, orders []queryOrder) int {
+	for index, cursorValue := range cursor.GetValues() {
+		if index >= len(orders) {
+			break
+		}
+		compared := compareValues(values[index], cursorValue)
+		if orders[index].descending {
+			compared = -compared
+		}
+		if compared != 0 {
+			return compared
+		}
+	}
+	return 0
+}
+
+func afterStart(values []*pb.Value, cursor *pb.Cursor, orders []queryOrder) bool {
+	if cursor == nil {
+		return true
+	}
+	compared := compareCursor(values, cursor, orders)
+	if cursor.GetBefore() {
+		return compared >= 0
+	}
+	return compared > 0
+}
+
+func beforeEnd(values []*pb.Value, cursor *pb.Cursor, orders []queryOrder) bool {
+	if cursor == nil {
+		return true
+	}
+	compared := compareCursor(values, cursor, orders)
+	if cursor.GetBefore() {
+		return compared < 0
+	}
+	return compared <= 0
+}
+
+// runQuery returns the documents of the query, it must be called with the
+// lock held.
+func (s *Server) runQuery(parent string, query *pb.StructuredQuery) ([]*pb.Document, error) {
+	if len(query.GetFrom()) != 1 {
+		return nil, status.Error(codes.InvalidArgument, "query must have one collection selector")
+	}
+	orders, err := queryOrders(query)
+	if err != nil {
+		return nil, err
+	}
+	type orderedDocument struct {
+		doc    *pb.Document
+		values []*pb.Value
+	}
+	var documents []orderedDocument
+	for name, doc := range s.documents {
+		path, under := childPath(parent, name)
+		if !under || !fromCollection(path, query.GetFrom()[0]) {
+			continue
+		}
+		matched, err := matchFilter(doc, query.GetWhere())
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		values, ordered := orderValues(doc, orders)
+		if !ordered {
+			continue
+		}
+		if afterStart(values, query.GetStartAt(), orders) && beforeEnd(values, query.GetEndAt(), orders) {
+			documents = append(documents, orderedDocument{doc: doc, values: values})
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		for index, order := range orders {
+			compared := compareValues(documents[i].values[index], documents[j].values[index])
+			if order.descending {
+				compared = -compared
+			}
+			if compared != 0 {
+				return compared < 0
+			}
+		}
+		return false
+	})
+	offset := int(query.GetOffset())
+	if offset > len(documents) {
+		offset = len(documents)
+	}
+	documents = documents[offset:]
+	if limit := query.GetLimit(); limit != nil && int(limit.GetValue()) < len(documents) {
+		documents = documents[:limit.GetValue()]
+	}
+	var fields []string
+	if projection := query.GetSelect(); projection != nil {
+		for _, field := range projection.GetFields() {
+			fields = append(fields, field.GetFieldPath())
+		}
+	}
+	results := make([]*pb.Document, len(documents))
+	for index, document := range documents {
+		if query.GetSelect() == nil {
+			results[index] = proto.Clone(document.doc).(*pb.Document)
+			continue
+		}
+		projected, err := project(document.doc, fields)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		results[index] = projected
+	}
+	return results, nil
+}
+
+// RunQuery streams the documents of the structured query, a query without
+// results streams the read time only.
+func (s *Server) RunQuery(req *pb.RunQueryRequest, stream pb.Firestore_RunQueryServer) error {
+	s.mu.Lock()
+	var transaction []byte
+	if err := s.checkTransaction(req.GetTransaction()); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if req.GetNewTransaction() != nil {
+		transaction = s.newTransaction()
+	}
+	readTime := s.timestamp()
+	documents, err := s.runQuery(req.GetParent(), req.GetStructuredQuery())
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 {
+		return stream.Send(&pb.RunQueryResponse{ReadTime: readTime, Transaction: transaction})
+	}
+	for _, doc := range documents {
+		response := &pb.RunQueryResponse{Document: doc, ReadTime: readTime, Transaction: transaction}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+		transaction = nil
+	}
+	return nil
+}
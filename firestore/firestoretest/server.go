@@ -0,0 +1,310 @@
+// Package firestoretest provides an in-memory Firestore gRPC service for the
+// tests of firestore clients and raizel firestore repositories, without the
+// emulator or network access.
+package firestoretest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/rjansen/raizel/firestore/internal/testutil"
+	"google.golang.org/api/option"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server is an in-memory Firestore service listening on the local loopback
+// interface. It supports documents, batch gets, commits with preconditions
+// and transforms, structured queries with filters, orders, limits and
+// cursors, listings and transactions. Transactions are serialized by the
+// server and never abort, Listen and the single document write RPCs are not
+// implemented.
+type Server struct {
+	pb.UnimplementedFirestoreServer
+
+	Addr string
+
+	srv          *testutil.Server
+	mu           sync.Mutex
+	documents    map[string]*pb.Document
+	transactions map[string]bool
+	sequence     int
+	lastTime     time.Time
+}
+
+// NewServer starts a Server without documents.
+func NewServer() (*Server, error) {
+	srv, err := testutil.NewServer()
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		Addr:         srv.Addr,
+		srv:          srv,
+		documents:    make(map[string]*pb.Document),
+		transactions: make(map[string]bool),
+	}
+	pb.RegisterFirestoreServer(srv.Gsrv, server)
+	srv.Start()
+	return server, nil
+}
+
+// NewClient returns a firestore client of projectID connected to the server,
+// closing the client closes its connection.
+func (s *Server) NewClient(ctx context.Context, projectID string) (*firestore.Client, error) {
+	conn, err := grpc.DialContext(ctx, s.Addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return firestore.NewClient(ctx, projectID, option.WithGRPCConn(conn))
+}
+
+// Reset removes every document and transaction.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.documents = make(map[string]*pb.Document)
+	s.transactions = make(map[string]bool)
+}
+
+// Document returns a copy of the stored document of the full resource name,
+// projects/{project}/databases/(default)/documents/{path}.
+func (s *Server) Document(name string) (*pb.Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, found := s.documents[name]
+	if !found {
+		return nil, false
+	}
+	return proto.Clone(doc).(*pb.Document), true
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// now returns a strictly increasing time, so every commit has its own update
+// time. It must be called with the lock held.
+func (s *Server) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.lastTime) {
+		now = s.lastTime.Add(time.Microsecond)
+	}
+	s.lastTime = now
+	return now
+}
+
+func (s *Server) timestamp() *tspb.Timestamp {
+	ts, _ := ptypes.TimestampProto(s.now())
+	return ts
+}
+
+// newTransaction returns the ID of a new transaction, it must be called with
+// the lock held.
+func (s *Server) newTransaction() []byte {
+	s.sequence++
+	id := fmt.Sprintf("transaction-%d", s.sequence)
+	s.transactions[id] = true
+	return []byte(id)
+}
+
+func (s *Server) checkTransaction(id []byte) error {
+	if len(id) > 0 && !s.transactions[string(id)] {
+		return status.Errorf(codes.InvalidArgument, "transaction %q is not active", id)
+	}
+	return nil
+}
+
+func maskPaths(mask *pb.DocumentMask) []string {
+	if mask == nil {
+		return nil
+	}
+	return mask.GetFieldPaths()
+}
+
+// read returns a copy of the document projected to the mask fields, it must
+// be called with the lock held.
+func (s *Server) read(name string, mask *pb.DocumentMask) (*pb.Document, bool, error) {
+	doc, found := s.documents[name]
+	if !found {
+		return nil, false, nil
+	}
+	if mask == nil {
+		return proto.Clone(doc).(*pb.Document), true, nil
+	}
+	projected, err := project(doc, maskPaths(mask))
+	if err != nil {
+		return nil, false, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return projected, true, nil
+}
+
+func (s *Server) GetDocument(_ context.Context, req *pb.GetDocumentRequest) (*pb.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTransaction(req.GetTransaction()); err != nil {
+		return nil, err
+	}
+	doc, found, err := s.read(req.GetName(), req.GetMask())
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "document %q not found", req.GetName())
+	}
+	return doc, nil
+}
+
+func (s *Server) BatchGetDocuments(req *pb.BatchGetDocumentsRequest, stream pb.Firestore_BatchGetDocumentsServer) error {
+	s.mu.Lock()
+	var (
+		transaction []byte
+		responses   = make([]*pb.BatchGetDocumentsResponse, len(req.GetDocuments()))
+		readTime    = s.timestamp()
+	)
+	if err := s.checkTransaction(req.GetTransaction()); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if req.GetNewTransaction() != nil {
+		transaction = s.newTransaction()
+	}
+	for index, name := range req.GetDocuments() {
+		response := &pb.BatchGetDocumentsResponse{ReadTime: readTime, Transaction: transaction}
+		doc, found, err := s.read(name, req.GetMask())
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		if found {
+			response.Result = &pb.BatchGetDocumentsResponse_Found{Found: doc}
+		} else {
+			response.Result = &pb.BatchGetDocumentsResponse_Missing{Missing: name}
+		}
+		responses[index], transaction = response, nil
+	}
+	s.mu.Unlock()
+	for _, response := range responses {
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) BeginTransaction(_ context.Context, req *pb.BeginTransactionRequest) (*pb.BeginTransactionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &pb.BeginTransactionResponse{Transaction: s.newTransaction()}, nil
+}
+
+func (s *Server) Rollback(_ context.Context, req *pb.RollbackRequest) (*empty.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTransaction(req.GetTransaction()); err != nil {
+		return nil, err
+	}
+	delete(s.transactions, string(req.GetTransaction()))
+	return &empty.Empty{}, nil
+}
+
+// Commit applies the writes atomically, a failed precondition discards every
+// write of the request.
+func (s *Server) Commit(_ context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTransaction(req.GetTransaction()); err != nil {
+		return nil, err
+	}
+	var (
+		commitTime = s.timestamp()
+		staged     = make(map[string]*pb.Document, len(s.documents))
+		results    = make([]*pb.WriteResult, len(req.GetWrites()))
+	)
+	for name, doc := range s.documents {
+		staged[name] = doc
+	}
+	for index, write := range req.GetWrites() {
+		result, err := applyWrite(staged, write, commitTime)
+		if err != nil {
+			return nil, err
+		}
+		results[index] = result
+	}
+	s.documents = staged
+	delete(s.transactions, string(req.GetTransaction()))
+	return &pb.CommitResponse{WriteResults: results, CommitTime: commitTime}, nil
+}
+
+// childPath returns the path of name relative to parent, it returns false
+// when name is not under parent.
+func childPath(parent string, name string) ([]string, bool) {
+	if !strings.HasPrefix(name, parent+"/") {
+		return nil, false
+	}
+	return strings.Split(strings.TrimPrefix(name, parent+"/"), "/"), true
+}
+
+// ListDocuments returns every document of the collection in one page, the
+// missing documents with subcollections are listed when ShowMissing is set.
+func (s *Server) ListDocuments(_ context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		collection = fmt.Sprintf("%s/%s", req.GetParent(), req.GetCollectionId())
+		names      = make(map[string]bool)
+	)
+	for name := range s.documents {
+		path, under := childPath(collection, name)
+		if !under || (len(path) > 1 && !req.GetShowMissing()) {
+			continue
+		}
+		names[fmt.Sprintf("%s/%s", collection, path[0])] = true
+	}
+	response := &pb.ListDocumentsResponse{}
+	for _, name := range sortedNames(names) {
+		doc, found, err := s.read(name, req.GetMask())
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			doc = &pb.Document{Name: name}
+		}
+		response.Documents = append(response.Documents, doc)
+	}
+	return response, nil
+}
+
+// ListCollectionIds returns the IDs of the collections under the parent
+// document in one page.
+func (s *Server) ListCollectionIds(_ context.Context, req *pb.ListCollectionIdsRequest) (*pb.ListCollectionIdsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make(map[string]bool)
+	for name := range s.documents {
+		if path, under := childPath(req.GetParent(), name); under && len(path) > 1 {
+			ids[path[0]] = true
+		}
+	}
+	return &pb.ListCollectionIdsResponse{CollectionIds: sortedNames(ids)}, nil
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,300 @@
+package firestoretest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rjansen/raizel"
+	rfirestore "github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testProjectID = "firestoretest"
+)
+
+type testEntity struct {
+	ID    string   `firestore:"id"`
+	Name  string   `firestore:"name"`
+	Age   int      `firestore:"age"`
+	Score float64  `firestore:"score"`
+	Tags  []string `firestore:"tags"`
+}
+
+func newTestClient(t *testing.T) (*firestoretest.Server, *firestore.Client) {
+	server, err := firestoretest.NewServer()
+	require.Nil(t, err, "new server error")
+	client, err := server.NewClient(context.Background(), testProjectID)
+	require.Nil(t, err, "new client error")
+	return server, client
+}
+
+func documentIDs(t *testing.T, docs *firestore.DocumentIterator) []string {
+	defer docs.Stop()
+	var ids []string
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return ids
+		}
+		require.Nil(t, err, "next document error")
+		ids = append(ids, doc.Ref.ID)
+	}
+}
+
+func TestServerDocuments(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	var (
+		ctx = context.Background()
+		ref = client.Doc("entities/entity1")
+	)
+
+	_, err := ref.Get(ctx)
+	require.Equal(test, codes.NotFound, status.Code(err), "get missing error")
+
+	_, err = ref.Create(ctx, testEntity{ID: "entity1", Name: "one", Age: 1})
+	require.Nil(test, err, "create error")
+	_, err = ref.Create(ctx, testEntity{ID: "entity1"})
+	require.Equal(test, codes.AlreadyExists, status.Code(err), "create existing error")
+
+	_, err = ref.Update(ctx, []firestore.Update{
+		{Path: "name", Value: "updated"},
+		{Path: "age", Value: firestore.Increment(2)},
+		{Path: "score", Value: firestore.Increment(0.5)},
+		{Path: "tags", Value: firestore.ArrayUnion("a", "b")},
+	})
+	require.Nil(test, err, "update error")
+	_, err = ref.Update(ctx, []firestore.Update{{Path: "tags", Value: firestore.ArrayRemove("a")}})
+	require.Nil(test, err, "array remove error")
+
+	doc, err := ref.Get(ctx)
+	require.Nil(test, err, "get error")
+	var entity testEntity
+	require.Nil(test, doc.DataTo(&entity), "data to error")
+	require.Equal(
+		test, testEntity{ID: "entity1", Name: "updated", Age: 3, Score: 0.5, Tags: []string{"b"}}, entity, "entity",
+	)
+	stored, found := server.Document(fmt.Sprintf("projects/%s/databases/(default)/documents/entities/entity1", testProjectID))
+	require.True(test, found, "stored document")
+	require.Equal(test, "updated", stored.GetFields()["name"].GetStringValue(), "stored name")
+
+	_, err = client.Doc("entities/missing").Update(ctx, []firestore.Update{{Path: "name", Value: "x"}})
+	require.Equal(test, codes.NotFound, status.Code(err), "update missing error")
+
+	_, err = ref.Set(ctx, map[string]interface{}{"name": "merged"}, firestore.MergeAll)
+	require.Nil(test, err, "set merge error")
+	doc, err = ref.Get(ctx)
+	require.Nil(test, err, "get merged error")
+	require.Equal(test, map[string]interface{}{
+		"id": "entity1", "name": "merged", "age": int64(3), "score": 0.5, "tags": []interface{}{"b"},
+	}, doc.Data(), "merged data")
+
+	_, err = ref.Delete(ctx)
+	require.Nil(test, err, "delete error")
+	_, err = ref.Get(ctx)
+	require.Equal(test, codes.NotFound, status.Code(err), "get deleted error")
+}
+
+type testServerQuery struct {
+	name  string
+	query func(*firestore.CollectionRef) firestore.Query
+	ids   []string
+}
+
+func TestServerQuery(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	ctx := context.Background()
+	for index, age := range []int{30, 10, 20, 40, 20} {
+		id := fmt.Sprintf("entity%d", index)
+		_, err := client.Collection("entities").Doc(id).Set(ctx, testEntity{
+			ID: id, Age: age, Tags: []string{fmt.Sprintf("tag%d", index%2)},
+		})
+		require.Nil(test, err, "setup error")
+	}
+	_, err := client.Doc("entities/entity0/children/child0").Set(ctx, testEntity{ID: "child0", Age: 20})
+	require.Nil(test, err, "setup child error")
+
+	scenarios := []testServerQuery{
+		{
+			name:  "Lists the collection documents by ID",
+			query: func(c *firestore.CollectionRef) firestore.Query { return c.Query },
+			ids:   []string{"entity0", "entity1", "entity2", "entity3", "entity4"},
+		},
+		{
+			name:  "Filters by equality",
+			query: func(c *firestore.CollectionRef) firestore.Query { return c.Where("age", "==", 20) },
+			ids:   []string{"entity2", "entity4"},
+		},
+		{
+			name: "Filters by range and orders descending",
+			query: func(c *firestore.CollectionRef) firestore.Query {
+				return c.Where("age", ">", 10).Where("age", "<=", 30).OrderBy("age", firestore.Desc)
+			},
+			ids: []string{"entity0", "entity4", "entity2"},
+		},
+		{
+			name: "Filters by array contains",
+			query: func(c *firestore.CollectionRef) firestore.Query {
+				return c.Where("tags", "array-contains", "tag1")
+			},
+			ids: []string{"entity1", "entity3"},
+		},
+		{
+			name: "Orders, offsets and limits",
+			query: func(c *firestore.CollectionRef) firestore.Query {
+				return c.OrderBy("age", firestore.Asc).Offset(1).Limit(2)
+			},
+			ids: []string{"entity2", "entity4"},
+		},
+		{
+			name: "Starts after and ends at cursors",
+			query: func(c *firestore.CollectionRef) firestore.Query {
+				return c.OrderBy("age", firestore.Asc).StartAfter(10).EndAt(30)
+			},
+			ids: []string{"entity2", "entity4", "entity0"},
+		},
+		{
+			name: "Starts at the document ID cursor",
+			query: func(c *firestore.CollectionRef) firestore.Query {
+				return c.OrderBy("age", firestore.Asc).OrderBy(firestore.DocumentID, firestore.Asc).StartAt(20, "entity4")
+			},
+			ids: []string{"entity4", "entity0", "entity3"},
+		},
+		{
+			name:  "Returns no documents",
+			query: func(c *firestore.CollectionRef) firestore.Query { return c.Where("age", "==", "20") },
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				ids := documentIDs(t, scenario.query(client.Collection("entities")).Documents(ctx))
+				require.Equal(t, scenario.ids, ids, "query ids")
+			},
+		)
+	}
+}
+
+func TestServerCollectionGroupAndSelect(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	ctx := context.Background()
+	_, err := client.Doc("orders/order1/items/item1").Set(ctx, testEntity{ID: "item1", Name: "one", Age: 1})
+	require.Nil(test, err, "setup error")
+	_, err = client.Doc("orders/order2/items/item2").Set(ctx, testEntity{ID: "item2", Name: "two", Age: 2})
+	require.Nil(test, err, "setup error")
+
+	ids := documentIDs(test, client.CollectionGroup("items").Documents(ctx))
+	require.Equal(test, []string{"item1", "item2"}, ids, "collection group ids")
+
+	docs := client.Collection("orders/order1/items").Select("name").Documents(ctx)
+	defer docs.Stop()
+	doc, err := docs.Next()
+	require.Nil(test, err, "next error")
+	require.Equal(test, map[string]interface{}{"name": "one"}, doc.Data(), "selected data")
+
+	refs, err := client.Collection("orders").DocumentRefs(ctx).GetAll()
+	require.Nil(test, err, "document refs error")
+	require.Len(test, refs, 2, "missing parent refs")
+	collections, err := client.Doc("orders/order1").Collections(ctx).GetAll()
+	require.Nil(test, err, "collections error")
+	require.Len(test, collections, 1, "collections")
+	require.Equal(test, "items", collections[0].ID, "collection id")
+}
+
+func TestServerTransaction(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	var (
+		ctx = context.Background()
+		ref = client.Doc("counters/counter1")
+	)
+	_, err := ref.Set(ctx, map[string]interface{}{"value": 1})
+	require.Nil(test, err, "setup error")
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		value, err := doc.DataAt("value")
+		if err != nil {
+			return err
+		}
+		return tx.Set(ref, map[string]interface{}{"value": value.(int64) + 1})
+	})
+	require.Nil(test, err, "transaction error")
+
+	rollback := fmt.Errorf("rollback")
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Set(ref, map[string]interface{}{"value": 100}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	require.Equal(test, rollback, err, "rollback error")
+
+	doc, err := ref.Get(ctx)
+	require.Nil(test, err, "get error")
+	require.Equal(test, map[string]interface{}{"value": int64(2)}, doc.Data(), "committed value")
+}
+
+func TestServerRepository(test *testing.T) {
+	server, fclient := newTestClient(test)
+	defer server.Close()
+	defer fclient.Close()
+	client, err := rfirestore.WrapClient(fclient)
+	require.Nil(test, err, "wrap client error")
+	var (
+		ctx        = context.Background()
+		repository = rfirestore.NewRepository(client)
+		order      = raizel.NewDynamicKey("orders", "id", "order1")
+	)
+	for index := 1; index <= 3; index++ {
+		var (
+			id  = fmt.Sprintf("item%d", index)
+			key = raizel.NewChildKey(order, raizel.NewDynamicKey("items", "id", id))
+		)
+		require.Nil(test, repository.Set(ctx, key, testEntity{ID: id, Age: index * 10}), "set error")
+	}
+	require.Nil(test, repository.Set(ctx, order, testEntity{ID: "order1"}), "set parent error")
+
+	var entity testEntity
+	key := raizel.NewChildKey(order, raizel.NewDynamicKey("items", "id", "item2"))
+	require.Nil(test, repository.Get(ctx, key, &entity), "get error")
+	require.Equal(test, testEntity{ID: "item2", Age: 20}, entity, "entity")
+
+	query := raizel.Children(order, "items").Where("age", raizel.GreaterEqual, 20).OrderBy("age", raizel.Desc)
+	count, err := raizel.Count(ctx, repository, query)
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(2), count, "count")
+
+	iterator, err := repository.(raizel.Pageable).Page(ctx, query.WithLimit(1), "")
+	require.Nil(test, err, "page error")
+	require.Nil(test, iterator.Next(ctx, &entity), "page next error")
+	require.Equal(test, "item3", entity.ID, "first page entity")
+	require.Equal(test, raizel.ErrIteratorDone, iterator.Next(ctx, &entity), "first page done")
+	next, err := repository.(raizel.Pageable).Page(ctx, query.WithLimit(1), iterator.NextPageToken())
+	require.Nil(test, err, "next page error")
+	require.Nil(test, next.Next(ctx, &entity), "next page next error")
+	require.Equal(test, "item2", entity.ID, "next page entity")
+
+	require.Nil(test, raizel.DeleteCascade(ctx, repository, order), "delete cascade error")
+	count, err = raizel.Count(ctx, repository, raizel.Children(order, "items"))
+	require.Nil(test, err, "count deleted error")
+	require.Zero(test, count, "count deleted")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, order, &entity), "get deleted error")
+}
@@ -0,0 +1,300 @@
+package firestoretest
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+)
+
+const (
+	documentIDPath = "__name__"
+)
+
+// typeOrder returns the rank of the value type in the firestore ordering,
+// integers and doubles share the number rank.
+func typeOrder(value *pb.Value) int {
+	switch value.GetValueType().(type) {
+	case *pb.Value_NullValue:
+		return 0
+	case *pb.Value_BooleanValue:
+		return 1
+	case *pb.Value_IntegerValue, *pb.Value_DoubleValue:
+		return 2
+	case *pb.Value_TimestampValue:
+		return 3
+	case *pb.Value_StringValue:
+		return 4
+	case *pb.Value_BytesValue:
+		return 5
+	case *pb.Value_ReferenceValue:
+		return 6
+	case *pb.Value_GeoPointValue:
+		return 7
+	case *pb.Value_ArrayValue:
+		return 8
+	case *pb.Value_MapValue:
+		return 9
+	}
+	return 0
+}
+
+func isNaN(value *pb.Value) bool {
+	double, isDouble := value.GetValueType().(*pb.Value_DoubleValue)
+	return isDouble && math.IsNaN(double.DoubleValue)
+}
+
+func sign(compared int) int {
+	switch {
+	case compared < 0:
+		return -1
+	case compared > 0:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareNumbers compares integers exactly and the other numbers as doubles.
+func compareNumbers(a, b *pb.Value) int {
+	ia, aIsInt := a.GetValueType().(*pb.Value_IntegerValue)
+	ib, bIsInt := b.GetValueType().(*pb.Value_IntegerValue)
+	if aIsInt && bIsInt {
+		switch {
+		case ia.IntegerValue < ib.IntegerValue:
+			return -1
+		case ia.IntegerValue > ib.IntegerValue:
+			return 1
+		}
+		return 0
+	}
+	return compareFloats(number(a), number(b))
+}
+
+func number(value *pb.Value) float64 {
+	if integer, isInt := value.GetValueType().(*pb.Value_IntegerValue); isInt {
+		return float64(integer.IntegerValue)
+	}
+	return value.GetDoubleValue()
+}
+
+// compareValues orders the values like firestore: by type rank and then by
+// the value of the type.
+func compareValues(a, b *pb.Value) int {
+	if orderA, orderB := typeOrder(a), typeOrder(b); orderA != orderB {
+		return sign(orderA - orderB)
+	}
+	switch a.GetValueType().(type) {
+	case *pb.Value_BooleanValue:
+		switch {
+		case a.GetBooleanValue() == b.GetBooleanValue():
+			return 0
+		case b.GetBooleanValue():
+			return -1
+		}
+		return 1
+	case *pb.Value_IntegerValue, *pb.Value_DoubleValue:
+		return compareNumbers(a, b)
+	case *pb.Value_TimestampValue:
+		ta, tb := a.GetTimestampValue(), b.GetTimestampValue()
+		if ta.GetSeconds() != tb.GetSeconds() {
+			return sign(int(ta.GetSeconds() - tb.GetSeconds()))
+		}
+		return sign(int(ta.GetNanos() - tb.GetNanos()))
+	case *pb.Value_StringValue:
+		return strings.Compare(a.GetStringValue(), b.GetStringValue())
+	case *pb.Value_BytesValue:
+		return bytes.Compare(a.GetBytesValue(), b.GetBytesValue())
+	case *pb.Value_ReferenceValue:
+		var (
+			pathA = strings.Split(a.GetReferenceValue(), "/")
+			pathB = strings.Split(b.GetReferenceValue(), "/")
+		)
+		for index := 0; index < len(pathA) && index < len(pathB); index++ {
+			if compared := strings.Compare(pathA[index], pathB[index]); compared != 0 {
+				return compared
+			}
+		}
+		return sign(len(pathA) - len(pathB))
+	case *pb.Value_GeoPointValue:
+		ga, gb := a.GetGeoPointValue(), b.GetGeoPointValue()
+		if compared := compareFloats(ga.GetLatitude(), gb.GetLatitude()); compared != 0 {
+			return compared
+		}
+		return compareFloats(ga.GetLongitude(), gb.GetLongitude())
+	case *pb.Value_ArrayValue:
+		var (
+			valuesA = a.GetArrayValue().GetValues()
+			valuesB = b.GetArrayValue().GetValues()
+		)
+		for index := 0; index < len(valuesA) && index < len(valuesB); index++ {
+			if compared := compareValues(valuesA[index], valuesB[index]); compared != 0 {
+				return compared
+			}
+		}
+		return sign(len(valuesA) - len(valuesB))
+	case *pb.Value_MapValue:
+		var (
+			fieldsA = a.GetMapValue().GetFields()
+			fieldsB = b.GetMapValue().GetFields()
+			keysA   = sortedKeys(fieldsA)
+			keysB   = sortedKeys(fieldsB)
+		)
+		for index := 0; index < len(keysA) && index < len(keysB); index++ {
+			if compared := strings.Compare(keysA[index], keysB[index]); compared != 0 {
+				return compared
+			}
+			if compared := compareValues(fieldsA[keysA[index]], fieldsB[keysB[index]]); compared != 0 {
+				return compared
+			}
+		}
+		return sign(len(keysA) - len(keysB))
+	}
+	return 0
+}
+
+// equalValues reports whether the values are equal, NaN is never equal.
+func equalValues(a, b *pb.Value) bool {
+	if isNaN(a) || isNaN(b) {
+		return false
+	}
+	return compareValues(a, b) == 0
+}
+
+func sortedKeys(fields map[string]*pb.Value) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseFieldPath splits a dotted field path, the segments quoted with
+// backticks may contain dots and escaped backticks.
+func parseFieldPath(path string) ([]string, error) {
+	var (
+		segments []string
+		segment  strings.Builder
+		quoted   bool
+	)
+	for index := 0; index < len(path); index++ {
+		char := path[index]
+		switch {
+		case char == '\\' && quoted && index+1 < len(path):
+			index++
+			segment.WriteByte(path[index])
+		case char == '`':
+			quoted = !quoted
+		case char == '.' && !quoted:
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(char)
+		}
+	}
+	segments = append(segments, segment.String())
+	if quoted {
+		return nil, fmt.Errorf("unterminated field path %q", path)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("empty field path segment in %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// fieldValue returns the value at path of the document fields, the document
+// ID path returns the reference to the document.
+func fieldValue(doc *pb.Document, path []string) (*pb.Value, bool) {
+	if len(path) == 1 && path[0] == documentIDPath {
+		return &pb.Value{ValueType: &pb.Value_ReferenceValue{ReferenceValue: doc.GetName()}}, true
+	}
+	fields := doc.GetFields()
+	for index, segment := range path {
+		value, found := fields[segment]
+		if !found {
+			return nil, false
+		}
+		if index == len(path)-1 {
+			return value, true
+		}
+		mapValue, isMap := value.GetValueType().(*pb.Value_MapValue)
+		if !isMap {
+			return nil, false
+		}
+		fields = mapValue.MapValue.GetFields()
+	}
+	return nil, false
+}
+
+// setField sets the value at path of fields, creating the missing maps and
+// replacing the values of other types on the path.
+func setField(fields map[string]*pb.Value, path []string, value *pb.Value) {
+	for _, segment := range path[:len(path)-1] {
+		current, isMap := fields[segment].GetValueType().(*pb.Value_MapValue)
+		if !isMap || current.MapValue == nil {
+			current = &pb.Value_MapValue{MapValue: &pb.MapValue{}}
+			fields[segment] = &pb.Value{ValueType: current}
+		}
+		if current.MapValue.Fields == nil {
+			current.MapValue.Fields = make(map[string]*pb.Value)
+		}
+		fields = current.MapValue.Fields
+	}
+	fields[path[len(path)-1]] = value
+}
+
+func deleteField(fields map[string]*pb.Value, path []string) {
+	for _, segment := range path[:len(path)-1] {
+		current, isMap := fields[segment].GetValueType().(*pb.Value_MapValue)
+		if !isMap {
+			return
+		}
+		fields = current.MapValue.GetFields()
+	}
+	delete(fields, path[len(path)-1])
+}
+
+// project returns a copy of the document with the fields of paths only.
+func project(doc *pb.Document, paths []string) (*pb.Document, error) {
+	projected := &pb.Document{
+		Name:       doc.GetName(),
+		Fields:     make(map[string]*pb.Value, len(paths)),
+		CreateTime: doc.GetCreateTime(),
+		UpdateTime: doc.GetUpdateTime(),
+	}
+	for _, path := range paths {
+		if path == documentIDPath {
+			continue
+		}
+		segments, err := parseFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if value, found := fieldValue(doc, segments); found {
+			setField(projected.Fields, segments, proto.Clone(value).(*pb.Value))
+		}
+	}
+	return projected, nil
+}
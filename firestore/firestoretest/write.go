@@ -0,0 +1,208 @@
+package firestoretest
+
+import (
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkPrecondition checks the write precondition against the staged
+// document of the write.
+func checkPrecondition(current *pb.Document, precondition *pb.Precondition) error {
+	switch condition := precondition.GetConditionType().(type) {
+	case *pb.Precondition_Exists:
+		if condition.Exists && current == nil {
+			return status.Error(codes.NotFound, "document not found")
+		}
+		if !condition.Exists && current != nil {
+			return status.Errorf(codes.AlreadyExists, "document %q already exists", current.GetName())
+		}
+	case *pb.Precondition_UpdateTime:
+		if current == nil || !proto.Equal(current.GetUpdateTime(), condition.UpdateTime) {
+			return status.Error(codes.FailedPrecondition, "document update time mismatch")
+		}
+	}
+	return nil
+}
+
+func writeName(write *pb.Write) string {
+	switch operation := write.GetOperation().(type) {
+	case *pb.Write_Update:
+		return operation.Update.GetName()
+	case *pb.Write_Delete:
+		return operation.Delete
+	case *pb.Write_Transform:
+		return operation.Transform.GetDocument()
+	}
+	return ""
+}
+
+// applyWrite applies the write to the staged documents, the stored
+// documents are copied before they change.
+func applyWrite(staged map[string]*pb.Document, write *pb.Write, commitTime *tspb.Timestamp) (*pb.WriteResult, error) {
+	var (
+		name    = writeName(write)
+		current = staged[name]
+	)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "write without document")
+	}
+	if err := checkPrecondition(current, write.GetCurrentDocument()); err != nil {
+		return nil, err
+	}
+	switch operation := write.GetOperation().(type) {
+	case *pb.Write_Delete:
+		delete(staged, name)
+		return &pb.WriteResult{UpdateTime: commitTime}, nil
+	case *pb.Write_Update:
+		doc := &pb.Document{Name: name, Fields: make(map[string]*pb.Value), CreateTime: commitTime}
+		if current != nil {
+			doc.CreateTime = current.GetCreateTime()
+		}
+		if write.GetUpdateMask() == nil {
+			for field, value := range operation.Update.GetFields() {
+				doc.Fields[field] = proto.Clone(value).(*pb.Value)
+			}
+		} else {
+			if current != nil {
+				doc.Fields = proto.Clone(current).(*pb.Document).GetFields()
+				if doc.Fields == nil {
+					doc.Fields = make(map[string]*pb.Value)
+				}
+			}
+			for _, path := range write.GetUpdateMask().GetFieldPaths() {
+				segments, err := parseFieldPath(path)
+				if err != nil {
+					return nil, status.Error(codes.InvalidArgument, err.Error())
+				}
+				if value, found := fieldValue(operation.Update, segments); found {
+					setField(doc.Fields, segments, proto.Clone(value).(*pb.Value))
+				} else {
+					deleteField(doc.Fields, segments)
+				}
+			}
+		}
+		doc.UpdateTime = commitTime
+		staged[name] = doc
+		return &pb.WriteResult{UpdateTime: commitTime}, nil
+	case *pb.Write_Transform:
+		doc := &pb.Document{Name: name, Fields: make(map[string]*pb.Value), CreateTime: commitTime}
+		if current != nil {
+			doc = proto.Clone(current).(*pb.Document)
+			if doc.Fields == nil {
+				doc.Fields = make(map[string]*pb.Value)
+			}
+		}
+		results := make([]*pb.Value, len(operation.Transform.GetFieldTransforms()))
+		for index, transform := range operation.Transform.GetFieldTransforms() {
+			segments, err := parseFieldPath(transform.GetFieldPath())
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			previous, _ := fieldValue(doc, segments)
+			value, err := applyTransform(previous, transform, commitTime)
+			if err != nil {
+				return nil, err
+			}
+			setField(doc.Fields, segments, value)
+			results[index] = value
+		}
+		doc.UpdateTime = commitTime
+		staged[name] = doc
+		return &pb.WriteResult{UpdateTime: commitTime, TransformResults: results}, nil
+	}
+	return nil, status.Error(codes.InvalidArgument, "unknown write operation")
+}
+
+func isNumber(value *pb.Value) bool {
+	return value.GetValueType() != nil && typeOrder(value) == 2
+}
+
+func integerValue(integer int64) *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_IntegerValue{IntegerValue: integer}}
+}
+
+func doubleValue(double float64) *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_DoubleValue{DoubleValue: double}}
+}
+
+// increment adds the numbers, integers overflow to the int64 limits like
+// firestore does.
+func increment(previous *pb.Value, operand *pb.Value) *pb.Value {
+	if !isNumber(previous) {
+		return proto.Clone(operand).(*pb.Value)
+	}
+	a, aIsInt := previous.GetValueType().(*pb.Value_IntegerValue)
+	b, bIsInt := operand.GetValueType().(*pb.Value_IntegerValue)
+	if !aIsInt || !bIsInt {
+		return doubleValue(number(previous) + number(operand))
+	}
+	sum := a.IntegerValue + b.IntegerValue
+	switch {
+	case b.IntegerValue > 0 && sum < a.IntegerValue:
+		sum = math.MaxInt64
+	case b.IntegerValue < 0 && sum > a.IntegerValue:
+		sum = math.MinInt64
+	}
+	return integerValue(sum)
+}
+
+func arrayValue(values []*pb.Value) *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: values}}}
+}
+
+func containsValue(values []*pb.Value, value *pb.Value) bool {
+	for _, element := range values {
+		if equalValues(element, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTransform returns the new value of the field transform over the
+// previous field value, nil when the field is missing.
+func applyTransform(
+	previous *pb.Value, transform *pb.DocumentTransform_FieldTransform, commitTime *tspb.Timestamp,
+) (*pb.Value, error) {
+	switch transformType := transform.GetTransformType().(type) {
+	case *pb.DocumentTransform_FieldTransform_SetToServerValue:
+		return &pb.Value{ValueType: &pb.Value_TimestampValue{TimestampValue: commitTime}}, nil
+	case *pb.DocumentTransform_FieldTransform_Increment:
+		if !isNumber(transformType.Increment) {
+			return nil, status.Error(codes.InvalidArgument, "increment of a value that is not a number")
+		}
+		return increment(previous, transformType.Increment), nil
+	case *pb.DocumentTransform_FieldTransform_Maximum:
+		if !isNumber(previous) || compareNumbers(transformType.Maximum, previous) > 0 {
+			return proto.Clone(transformType.Maximum).(*pb.Value), nil
+		}
+		return previous, nil
+	case *pb.DocumentTransform_FieldTransform_Minimum:
+		if !isNumber(previous) || compareNumbers(transformType.Minimum, previous) < 0 {
+			return proto.Clone(transformType.Minimum).(*pb.Value), nil
+		}
+		return previous, nil
+	case *pb.DocumentTransform_FieldTransform_AppendMissingElements:
+		values := append([]*pb.Value{}, previous.GetArrayValue().GetValues()...)
+		for _, element := range transformType.AppendMissingElements.GetValues() {
+			if !containsValue(values, element) {
+				values = append(values, proto.Clone(element).(*pb.Value))
+			}
+		}
+		return arrayValue(values), nil
+	case *pb.DocumentTransform_FieldTransform_RemoveAllFromArray:
+		values := make([]*pb.Value, 0, len(previous.GetArrayValue().GetValues()))
+		for _, element := range previous.GetArrayValue().GetValues() {
+			if !containsValue(transformType.RemoveAllFromArray.GetValues(), element) {
+				values = append(values, element)
+			}
+		}
+		return arrayValue(values), nil
+	}
+	return nil, status.Error(codes.InvalidArgument, "unknown field transform")
+}
@@ -0,0 +1,60 @@
+package firestore
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+type entityIterator struct {
+	documents DocumentIterator
+}
+
+// NewIterator returns a raizel.Iterator that reads one document at a time
+// from documents.
+func NewIterator(documents DocumentIterator) raizel.Iterator {
+	return &entityIterator{documents: documents}
+}
+
+func (i *entityIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	doc, err := i.documents.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return raizel.ErrIteratorDone
+		}
+		return err
+	}
+	return dataTo(doc, entity)
+}
+
+func (i *entityIterator) Stop() {
+	i.documents.Stop()
+}
+
+func entityQuery(collection Query, query raizel.Query) Query {
+	result := collection
+	for _, filter := range query.Filters {
+		result = result.Where(filter.Field, string(filter.Operator), filter.Value)
+	}
+	for _, order := range query.Orders {
+		direction := Asc
+		if order.Direction == raizel.Desc {
+			direction = Desc
+		}
+		result = result.OrderBy(order.Field, direction)
+	}
+	if query.Limit > 0 {
+		result = result.Limit(query.Limit)
+	}
+	return result
+}
+
+func (r *repository) Query(ctx context.Context, query raizel.Query) (raizel.Iterator, error) {
+	collection, err := entityCollection(query)
+	if err != nil {
+		return nil, err
+	}
+	documents := entityQuery(r.client.Collection(collection), query).Documents(ctx)
+	return NewIterator(documents), nil
+}
@@ -0,0 +1,132 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testRepositoryQuery struct {
+	name       string
+	repository raizel.Repository
+	query      raizel.Query
+	data       []map[string]*pb.Value
+	err        error
+}
+
+func (scenario *testRepositoryQuery) setup(t *testing.T) {
+	var (
+		fclient, server = newMock(t)
+		client, err     = newClient(fclient)
+		rootPath        = "projects/projectID/databases/(default)/documents"
+		request         = &pb.RunQueryRequest{
+			Parent: rootPath,
+			QueryType: &pb.RunQueryRequest_StructuredQuery{
+				StructuredQuery: &pb.StructuredQuery{
+					From: []*pb.StructuredQuery_CollectionSelector{
+						{CollectionId: scenario.query.EntityName},
+					},
+					Where: &pb.StructuredQuery_Filter{
+						FilterType: &pb.StructuredQuery_Filter_FieldFilter{
+							FieldFilter: &pb.StructuredQuery_FieldFilter{
+								Field: &pb.StructuredQuery_FieldReference{FieldPath: "age"},
+								Op:    pb.StructuredQuery_FieldFilter_GREATER_THAN,
+								Value: intval(10),
+							},
+						},
+					},
+					OrderBy: []*pb.StructuredQuery_Order{
+						{
+							Field:     &pb.StructuredQuery_FieldReference{FieldPath: "age"},
+							Direction: pb.StructuredQuery_DESCENDING,
+						},
+					},
+					Limit: &wrappers.Int32Value{Value: int32(scenario.query.Limit)},
+				},
+			},
+		}
+	)
+	require.Nil(t, err, "new client error")
+	if scenario.err != nil {
+		server.addRPC(request, scenario.err)
+	} else {
+		responses := make([]interface{}, len(scenario.data))
+		for index, data := range scenario.data {
+			responses[index] = &pb.RunQueryResponse{
+				Document: &pb.Document{
+					Name:       fmt.Sprintf("%s/%s/mockref%d", rootPath, scenario.query.EntityName, index),
+					CreateTime: aTimestamp,
+					UpdateTime: aTimestamp,
+					Fields:     data,
+				},
+				ReadTime: aTimestamp,
+			}
+		}
+		server.addRPC(request, responses)
+	}
+	scenario.repository = NewRepository(client)
+}
+
+func TestRepositoryQuery(test *testing.T) {
+	scenarios := []testRepositoryQuery{
+		{
+			name: "Query entities one document at a time",
+			query: raizel.NewQuery("mockCol1").
+				Where("age", raizel.Greater, 10).
+				OrderBy("age", raizel.Desc).
+				WithLimit(2),
+			data: []map[string]*pb.Value{
+				{"name": strval("Mock Two"), "age": intval(35)},
+				{"name": strval("Mock One"), "age": intval(25)},
+			},
+		},
+		{
+			name: "Returns a server error",
+			query: raizel.NewQuery("mockCol1").
+				Where("age", raizel.Greater, 10).
+				OrderBy("age", raizel.Desc).
+				WithLimit(2),
+			err: status.Error(codes.Unknown, "mockBadGateway"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				queryable, ok := scenario.repository.(raizel.Queryable)
+				require.True(t, ok, "repository is not queryable")
+				iterator, err := queryable.Query(context.Background(), scenario.query)
+				require.Nil(t, err, "query error")
+				defer iterator.Stop()
+
+				for _, data := range scenario.data {
+					var entity struct {
+						Name string `firestore:"name"`
+						Age  int    `firestore:"age"`
+					}
+					err := iterator.Next(context.Background(), &entity)
+					require.Nil(t, err, "next error")
+					require.Equal(t, data["name"].GetStringValue(), entity.Name, "entity name")
+					require.Equal(t, int(data["age"].GetIntegerValue()), entity.Age, "entity age")
+				}
+				err = iterator.Next(context.Background(), &struct{}{})
+				if scenario.err != nil {
+					require.Equal(t, status.Code(scenario.err), status.Code(err), "next error code")
+					require.False(t, errors.Is(err, raizel.ErrIteratorDone), "next done error")
+				} else {
+					require.Equal(t, raizel.ErrIteratorDone, err, "next done error")
+				}
+			},
+		)
+	}
+}
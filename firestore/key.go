@@ -0,0 +1,166 @@
+package firestore
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	// maxDocumentIDSize is the firestore limit of a document ID in bytes.
+	maxDocumentIDSize = 1500
+	keySeparator      = ","
+)
+
+var idEscaper = strings.NewReplacer("%", "%25", "/", "%2F", keySeparator, "%2C")
+
+// legalID reports whether value is a legal document ID that decodes to
+// itself, it has no path separator, it is not reserved and it has no percent
+// escape.
+func legalID(value string) bool {
+	if strings.Contains(value, "/") || value == "." || value == ".." ||
+		len(value) >= 4 && strings.HasPrefix(value, "__") && strings.HasSuffix(value, "__") {
+		return false
+	}
+	unescaped, err := url.PathUnescape(value)
+	return err != nil || unescaped == value
+}
+
+// escapeID returns the legal document IDs unchanged and escapes the others,
+// "%" "/" and "," are percent encoded and the reserved IDs ".", ".." and
+// "__name__" get their first byte escaped.
+func escapeID(value string) string {
+	if legalID(value) {
+		return value
+	}
+	return escapeAll(value)
+}
+
+func escapeAll(value string) string {
+	escaped := idEscaper.Replace(value)
+	switch {
+	case escaped == "." || escaped == "..":
+		return "%2E" + escaped[1:]
+	case len(escaped) >= 4 && strings.HasPrefix(escaped, "__") && strings.HasSuffix(escaped, "__"):
+		return "%5F" + escaped[1:]
+	}
+	return escaped
+}
+
+// encodePart returns the document ID part of a key value, the parts of a
+// composite key have their commas escaped.
+func encodePart(value interface{}, composite bool) (string, error) {
+	switch part := value.(type) {
+	case string:
+		if part == "" {
+			return "", fmt.Errorf("%w: blank key value", raizel.ErrInvalidArgument)
+		}
+		if composite && strings.Contains(part, keySeparator) {
+			return escapeAll(part), nil
+		}
+		return escapeID(part), nil
+	case fmt.Stringer:
+		if reflect.ValueOf(part).Kind() == reflect.Ptr && reflect.ValueOf(part).IsNil() {
+			return "", fmt.Errorf("%w: nil key value", raizel.ErrInvalidArgument)
+		}
+		return encodePart(part.String(), composite)
+	}
+	partValue := reflect.ValueOf(value)
+	switch partValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(partValue.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(partValue.Uint(), 10), nil
+	case reflect.String:
+		return encodePart(partValue.String(), composite)
+	}
+	return "", fmt.Errorf("%w: unsupported key value %T", raizel.ErrInvalidArgument, value)
+}
+
+// decodePart returns the string value of a document ID part, the parts that
+// are not percent escaped are returned unchanged.
+func decodePart(part string) (interface{}, error) {
+	if part == "" {
+		return nil, fmt.Errorf("%w: blank key part", raizel.ErrInvalidArgument)
+	}
+	value, err := url.PathUnescape(part)
+	if err != nil {
+		return part, nil
+	}
+	return value, nil
+}
+
+func checkCollection(name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("%w: invalid collection %q", raizel.ErrInvalidArgument, name)
+	}
+	return nil
+}
+
+// EncodeKey returns the document ID of the key. Integers and the strings
+// that are legal document IDs keep the IDs formatted with %v, the other
+// strings are escaped so they never reach a nested path, "%" "/" and "," are
+// percent encoded. UUIDs and other fmt.Stringer values are encoded as their
+// string and the parts of a composite key are joined with commas. Blank,
+// nil, unsupported and too long values return raizel.ErrInvalidArgument.
+func EncodeKey(key raizel.EntityKey) (string, error) {
+	if key == nil {
+		return "", fmt.Errorf("%w: nil key", raizel.ErrInvalidArgument)
+	}
+	_, values := raizel.KeyParts(key)
+	parts := make([]string, len(values))
+	for index, value := range values {
+		part, err := encodePart(value, len(values) > 1)
+		if err != nil {
+			return "", err
+		}
+		parts[index] = part
+	}
+	id := strings.Join(parts, keySeparator)
+	if len(id) > maxDocumentIDSize {
+		return "", fmt.Errorf("%w: document id over %d bytes", raizel.ErrInvalidArgument, maxDocumentIDSize)
+	}
+	return id, nil
+}
+
+// DecodeKey returns the key of the entity document ID encoded by EncodeKey,
+// the key values are strings. The ID is split at its commas unless the
+// registered entity descriptor has a single key field, many parts return a
+// raizel.CompositeKey named by the key fields of the descriptor.
+func DecodeKey(entityName, id string) (raizel.EntityKey, error) {
+	var (
+		descriptor, described = raizel.EntityDescriptor(entityName)
+		parts                 = []string{id}
+	)
+	if !described || len(descriptor.Keys()) > 1 {
+		parts = strings.Split(id, keySeparator)
+	}
+	values := make([]interface{}, len(parts))
+	for index, part := range parts {
+		value, err := decodePart(part)
+		if err != nil {
+			return nil, err
+		}
+		values[index] = value
+	}
+	if len(values) == 1 {
+		return raizel.NewDynamicKey(entityName, firestore.DocumentID, values[0]), nil
+	}
+	names := make([]string, len(values))
+	for index := range names {
+		names[index] = firestore.DocumentID
+	}
+	if described {
+		if keys := descriptor.Keys(); len(keys) == len(names) {
+			for index, key := range keys {
+				names[index] = key.Name
+			}
+		}
+	}
+	return raizel.NewCompositeKey(entityName, names, values), nil
+}
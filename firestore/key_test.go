@@ -0,0 +1,180 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeyCodec struct {
+	name    string
+	key     raizel.EntityKey
+	id      string
+	decoded raizel.EntityKey
+	err     error
+}
+
+type legacyUser struct {
+	_     struct{} `raizel:"legacy_users,entity"`
+	Email string   `raizel:"email,key"`
+}
+
+func TestKeyCodec(test *testing.T) {
+	_, err := raizel.RegisterEntity(legacyUser{})
+	require.Nil(test, err, "register error")
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	scenarios := []testKeyCodec{
+		{
+			name:    "Plain string key",
+			key:     raizel.NewDynamicKey("entities", "id", "mock1"),
+			id:      "mock1",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "mock1"),
+		},
+		{
+			name:    "String key with a path separator",
+			key:     raizel.NewDynamicKey("entities", "id", "a/b%c,d"),
+			id:      "a%2Fb%25c%2Cd",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "a/b%c,d"),
+		},
+		{
+			name:    "Reserved document ids",
+			key:     raizel.NewDynamicKey("entities", "id", ".."),
+			id:      "%2E.",
+			decoded: raizel.NewDynamicKey("entities", "__name__", ".."),
+		},
+		{
+			name:    "Legal string with a percent sign",
+			key:     raizel.NewDynamicKey("entities", "id", "50%off"),
+			id:      "50%off",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "50%off"),
+		},
+		{
+			name:    "String with a percent escape",
+			key:     raizel.NewDynamicKey("entities", "id", "a%2Fb"),
+			id:      "a%252Fb",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "a%2Fb"),
+		},
+		{
+			name:    "Reserved double underscore id",
+			key:     raizel.NewDynamicKey("entities", "id", "__mock__"),
+			id:      "%5F_mock__",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "__mock__"),
+		},
+		{
+			name:    "Integer key",
+			key:     raizel.NewDynamicKey("entities", "id", 42),
+			id:      "42",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "42"),
+		},
+		{
+			name:    "Unsigned integer key",
+			key:     raizel.NewDynamicKey("entities", "id", uint8(7)),
+			id:      "7",
+			decoded: raizel.NewDynamicKey("entities", "__name__", "7"),
+		},
+		{
+			name:    "UUID key",
+			key:     raizel.NewDynamicKey("entities", "id", id),
+			id:      id.String(),
+			decoded: raizel.NewDynamicKey("entities", "__name__", id.String()),
+		},
+		{
+			name: "Composite key",
+			key: raizel.NewCompositeKey(
+				"entities", []string{"tenant", "number"}, []interface{}{"a,b", -3},
+			),
+			id: "a%2Cb,-3",
+			decoded: raizel.NewCompositeKey(
+				"entities", []string{"__name__", "__name__"}, []interface{}{"a,b", "-3"},
+			),
+		},
+		{
+			name:    "Single key of a registered entity with a comma",
+			key:     raizel.NewDynamicKey("legacy_users", "email", "doe,john@example.com"),
+			id:      "doe,john@example.com",
+			decoded: raizel.NewDynamicKey("legacy_users", "__name__", "doe,john@example.com"),
+		},
+		{
+			name: "Error when the key is blank",
+			key:  raizel.NewDynamicKey("entities", "id", ""),
+			err:  raizel.ErrInvalidArgument,
+		},
+		{
+			name: "Error when the key is nil",
+			key:  raizel.NewDynamicKey("entities", "id", nil),
+			err:  raizel.ErrInvalidArgument,
+		},
+		{
+			name: "Error when the key type is not supported",
+			key:  raizel.NewDynamicKey("entities", "id", 1.5),
+			err:  raizel.ErrInvalidArgument,
+		},
+		{
+			name: "Error when the document id is too long",
+			key:  raizel.NewDynamicKey("entities", "id", strings.Repeat("/", 600)),
+			err:  raizel.ErrInvalidArgument,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				id, err := firestore.EncodeKey(scenario.key)
+				require.True(t, errors.Is(err, scenario.err), "encode error %v", err)
+				if scenario.err != nil {
+					return
+				}
+				require.Equal(t, scenario.id, id, "document id")
+				require.NotContains(t, id, "/", "document id separator")
+
+				decoded, err := firestore.DecodeKey(scenario.key.EntityName(), id)
+				require.Nil(t, err, "decode error")
+				require.Equal(t, scenario.decoded, decoded, "decoded key")
+			},
+		)
+	}
+}
+
+// TestEncodeKeyLegacy pins the document IDs written before the key codec,
+// formatted with %v, for the integers and the legal strings.
+func TestEncodeKeyLegacy(test *testing.T) {
+	values := []interface{}{
+		"mock1", "john.doe@example.com", "50%off", "a,b", "Ünïcode key", 42, int64(-7), uint(9),
+		uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+	}
+	for _, value := range values {
+		id, err := firestore.EncodeKey(raizel.NewDynamicKey("entities", "id", value))
+		require.Nil(test, err, "encode %v error", value)
+		require.Equal(test, fmt.Sprintf("%v", value), id, "legacy document id")
+	}
+}
+
+func TestDecodeKeyInvalid(test *testing.T) {
+	for _, id := range []string{"", "a,,b", "a,"} {
+		_, err := firestore.DecodeKey("entities", id)
+		require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "decode %s error", id)
+	}
+}
+
+func TestRepositoryInvalidKey(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		client     = fmock.NewClientMock()
+		repository = firestore.NewRepository(client)
+	)
+	err := repository.Set(ctx, raizel.NewDynamicKey("entities", "id", ""), &testEntity{})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "set blank key error")
+	err = repository.Get(ctx, raizel.NewDynamicKey("entities/nested", "id", "mock1"), &testEntity{})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "get nested collection error")
+	err = repository.Delete(ctx, raizel.NewDynamicKey("entities", "id", nil))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "delete nil key error")
+	client.AssertNotCalled(test, "Doc")
+}
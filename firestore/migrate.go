@@ -0,0 +1,90 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rjansen/raizel/migrate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	lockDocument = "lock"
+)
+
+type migrationRecord struct {
+	Version   int64     `firestore:"version"`
+	Name      string    `firestore:"name"`
+	AppliedAt time.Time `firestore:"appliedAt"`
+}
+
+type migrationLock struct {
+	LockedAt time.Time `firestore:"lockedAt"`
+}
+
+type migrationDriver struct {
+	client Client
+}
+
+// NewMigrationDriver returns a firestore migrate.Driver. Firestore has no
+// schema, so its migrations are data backfills written as migrate.Func and
+// scripts return migrate.ErrUnsupportedStep.
+func NewMigrationDriver(client Client) migrate.Driver {
+	return &migrationDriver{client: client}
+}
+
+func (d *migrationDriver) recordRef(version int64) DocumentRef {
+	return d.client.Doc(fmt.Sprintf("%s/%d", migrate.Table, version))
+}
+
+func (d *migrationDriver) Init(ctx context.Context) error {
+	return nil
+}
+
+func (d *migrationDriver) Lock(ctx context.Context) error {
+	err := d.client.Doc(fmt.Sprintf("%s/%s", migrate.LockTable, lockDocument)).Create(
+		ctx, migrationLock{LockedAt: time.Now().UTC()},
+	)
+	if status.Code(err) == codes.AlreadyExists {
+		return migrate.ErrLocked
+	}
+	return err
+}
+
+func (d *migrationDriver) Unlock(ctx context.Context) error {
+	return d.client.Doc(fmt.Sprintf("%s/%s", migrate.LockTable, lockDocument)).Delete(ctx)
+}
+
+func (d *migrationDriver) Applied(ctx context.Context) ([]migrate.Record, error) {
+	docs, err := d.client.Collection(migrate.Table).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]migrate.Record, len(docs))
+	for index, doc := range docs {
+		var record migrationRecord
+		if err := doc.DataTo(&record); err != nil {
+			return nil, err
+		}
+		records[index] = migrate.Record(record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+	return records, nil
+}
+
+func (d *migrationDriver) Exec(ctx context.Context, script string) error {
+	return migrate.ErrUnsupportedStep
+}
+
+func (d *migrationDriver) Insert(ctx context.Context, record migrate.Record) error {
+	return d.recordRef(record.Version).Set(ctx, migrationRecord(record))
+}
+
+func (d *migrationDriver) Delete(ctx context.Context, version int64) error {
+	return d.recordRef(version).Delete(ctx)
+}
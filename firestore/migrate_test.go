@@ -0,0 +1,84 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel/firestore"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/rjansen/raizel/migrate"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMigrationDriverLock(test *testing.T) {
+	scenarios := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Lock"},
+		{name: "Locked by another migrator", err: status.Error(codes.AlreadyExists, "exists"), want: migrate.ErrLocked},
+		{name: "Lock error", err: errors.New("errMock"), want: errors.New("errMock")},
+	}
+	for _, scenario := range scenarios {
+		test.Run(scenario.name, func(t *testing.T) {
+			var (
+				client = fmock.NewClientMock()
+				ref    = fmock.NewDocumentRefMock()
+			)
+			ref.On("Create", mock.Anything, mock.Anything).Return(scenario.err)
+			ref.On("Delete", mock.Anything).Return(nil)
+			client.On("Doc", "schema_migrations_lock/lock").Return(ref)
+
+			driver := firestore.NewMigrationDriver(client)
+			require.Equal(t, scenario.want, driver.Lock(context.Background()), "lock error")
+			require.Nil(t, driver.Unlock(context.Background()), "unlock error")
+		})
+	}
+}
+
+func TestMigrationDriver(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		client     = fmock.NewClientMock()
+		collection = fmock.NewCollectionRefMock()
+		documents  = fmock.NewDocumentIteratorMock()
+		ref        = fmock.NewDocumentRefMock()
+		appliedAt  = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+		record     = migrate.Record{Version: 2, Name: "backfill_names", AppliedAt: appliedAt}
+		docs       = []firestore.DocumentSnapshot{fmock.NewDocumentSnapshotMock(), fmock.NewDocumentSnapshotMock()}
+		versions   = []int64{2, 1}
+		driver     = firestore.NewMigrationDriver(client)
+	)
+	for index, doc := range docs {
+		version := versions[index]
+		doc.(*fmock.DocumentSnapshotMock).On("DataTo", mock.Anything).Return(nil).Run(
+			func(args mock.Arguments) {
+				reflect.ValueOf(args.Get(0)).Elem().FieldByName("Version").SetInt(version)
+			},
+		)
+	}
+	documents.On("GetAll").Return(docs, nil)
+	collection.On("Documents", mock.Anything).Return(documents)
+	client.On("Collection", "schema_migrations").Return(collection)
+	client.On("Doc", "schema_migrations/2").Return(ref)
+	ref.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ref.On("Delete", mock.Anything).Return(nil)
+
+	require.Nil(test, driver.Init(ctx), "init error")
+	require.Equal(test, migrate.ErrUnsupportedStep, driver.Exec(ctx, "create table"), "exec error")
+	require.Nil(test, driver.Insert(ctx, record), "insert error")
+	require.Nil(test, driver.Delete(ctx, 2), "delete error")
+	records, err := driver.Applied(ctx)
+	require.Nil(test, err, "applied error")
+	require.Len(test, records, 2, "applied records length")
+	require.Equal(test, int64(1), records[0].Version, "applied records sorted by version")
+	require.Equal(test, int64(2), records[1].Version, "applied records sorted by version")
+	ref.AssertExpectations(test)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/rjansen/raizel/firestore"
+	rmock "github.com/rjansen/raizel/mock"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/api/iterator"
 )
 
 type CollectionRefMock struct {
@@ -27,6 +29,28 @@ func (mock *CollectionRefMock) Documents(ctx context.Context) firestore.Document
 	return result.(firestore.DocumentIterator)
 }
 
+func (mock *CollectionRefMock) DocumentRefs(ctx context.Context) firestore.DocumentRefIterator {
+	var (
+		args   = mock.Called(ctx)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.DocumentRefIterator)
+}
+
+func (mock *CollectionRefMock) Snapshots(ctx context.Context) firestore.QuerySnapshotIterator {
+	var (
+		args   = mock.Called(ctx)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.QuerySnapshotIterator)
+}
+
 func (mock *CollectionRefMock) Where(path string, op string, value interface{}) firestore.Query {
 	var (
 		args   = mock.Called(path, op, value)
@@ -38,6 +62,17 @@ func (mock *CollectionRefMock) Where(path string, op string, value interface{})
 	return result.(firestore.Query)
 }
 
+func (mock *CollectionRefMock) Select(paths ...string) firestore.Query {
+	var (
+		args   = mock.Called(paths)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.Query)
+}
+
 func (mock *CollectionRefMock) OrderBy(path string, direction firestore.Direction) firestore.Query {
 	var (
 		args   = mock.Called(path, direction)
@@ -71,6 +106,17 @@ func (mock *CollectionRefMock) Limit(n int) firestore.Query {
 	return result.(firestore.Query)
 }
 
+func (mock *CollectionRefMock) StartAfter(values ...interface{}) firestore.Query {
+	var (
+		args   = mock.Called(values)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.Query)
+}
+
 type DocumentSnapshotMock struct {
 	mock.Mock
 }
@@ -84,11 +130,23 @@ func (mock *DocumentSnapshotMock) DataTo(data interface{}) error {
 	return args.Error(0)
 }
 
+func (mock *DocumentSnapshotMock) DataAt(path string) (interface{}, error) {
+	args := mock.Called(path)
+	return args.Get(0), args.Error(1)
+}
+
 func (mock *DocumentSnapshotMock) Exists() bool {
 	args := mock.Called()
 	return args.Bool(0)
 }
 
+// OnDataTo expects an existing document whose DataTo assigns the source
+// struct to the target.
+func (snapshot *DocumentSnapshotMock) OnDataTo(source interface{}) *mock.Call {
+	snapshot.On("Exists").Return(true)
+	return snapshot.On("DataTo", mock.Anything).Run(rmock.Copy(source)).Return(nil)
+}
+
 type DocumentRefMock struct {
 	firestore.DocumentRef
 	mock.Mock
@@ -110,16 +168,136 @@ func (mock *DocumentRefMock) Get(ctx context.Context) (firestore.DocumentSnapsho
 	return result.(firestore.DocumentSnapshot), err
 }
 
+func (mock *DocumentRefMock) Create(ctx context.Context, data interface{}) error {
+	args := mock.Called(ctx, data)
+	return args.Error(0)
+}
+
 func (mock *DocumentRefMock) Set(ctx context.Context, data interface{}, opts ...firestore.SetOption) error {
 	args := mock.Called(ctx, data, opts)
 	return args.Error(0)
 }
 
+func (mock *DocumentRefMock) Update(ctx context.Context, updates []firestore.Update) error {
+	args := mock.Called(ctx, updates)
+	return args.Error(0)
+}
+
 func (mock *DocumentRefMock) Delete(ctx context.Context) error {
 	args := mock.Called(ctx)
 	return args.Error(0)
 }
 
+func (mock *DocumentRefMock) Snapshots(ctx context.Context) firestore.DocumentSnapshotIterator {
+	var (
+		args   = mock.Called(ctx)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.DocumentSnapshotIterator)
+}
+
+func (mock *DocumentRefMock) Collections(ctx context.Context) firestore.CollectionIterator {
+	var (
+		args   = mock.Called(ctx)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(firestore.CollectionIterator)
+}
+
+type CollectionIteratorMock struct {
+	mock.Mock
+}
+
+func NewCollectionIteratorMock() *CollectionIteratorMock {
+	return new(CollectionIteratorMock)
+}
+
+func (mock *CollectionIteratorMock) Next() (firestore.CollectionRef, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(firestore.CollectionRef), err
+}
+
+type DocumentRefIteratorMock struct {
+	mock.Mock
+}
+
+func NewDocumentRefIteratorMock() *DocumentRefIteratorMock {
+	return new(DocumentRefIteratorMock)
+}
+
+func (mock *DocumentRefIteratorMock) Next() (firestore.DocumentRef, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(firestore.DocumentRef), err
+}
+
+type QuerySnapshotIteratorMock struct {
+	mock.Mock
+}
+
+func NewQuerySnapshotIteratorMock() *QuerySnapshotIteratorMock {
+	return new(QuerySnapshotIteratorMock)
+}
+
+func (mock *QuerySnapshotIteratorMock) Next() ([]firestore.DocumentChange, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.([]firestore.DocumentChange), err
+}
+
+func (mock *QuerySnapshotIteratorMock) Stop() {
+	mock.Called()
+}
+
+type DocumentSnapshotIteratorMock struct {
+	mock.Mock
+}
+
+func NewDocumentSnapshotIteratorMock() *DocumentSnapshotIteratorMock {
+	return new(DocumentSnapshotIteratorMock)
+}
+
+func (mock *DocumentSnapshotIteratorMock) Next() (firestore.DocumentSnapshot, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(firestore.DocumentSnapshot), err
+}
+
+func (mock *DocumentSnapshotIteratorMock) Stop() {
+	mock.Called()
+}
+
 type DocumentIteratorMock struct {
 	mock.Mock
 }
@@ -128,6 +306,22 @@ func NewDocumentIteratorMock() *DocumentIteratorMock {
 	return new(DocumentIteratorMock)
 }
 
+func (mock *DocumentIteratorMock) Next() (firestore.DocumentSnapshot, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(firestore.DocumentSnapshot), err
+}
+
+func (mock *DocumentIteratorMock) Stop() {
+	mock.Called()
+}
+
 func (mock *DocumentIteratorMock) GetAll() ([]firestore.DocumentSnapshot, error) {
 	var (
 		args   = mock.Called()
@@ -140,6 +334,29 @@ func (mock *DocumentIteratorMock) GetAll() ([]firestore.DocumentSnapshot, error)
 	return result.([]firestore.DocumentSnapshot), err
 }
 
+// OnDocuments expects the Next iteration of the snapshots, Next returns
+// iterator.Done after the last snapshot and GetAll returns all of them.
+func (documents *DocumentIteratorMock) OnDocuments(snapshots ...firestore.DocumentSnapshot) {
+	for _, snapshot := range snapshots {
+		documents.On("Next").Return(snapshot, nil).Once()
+	}
+	documents.On("Next").Return(nil, iterator.Done)
+	documents.On("GetAll").Return(snapshots, nil)
+	documents.On("Stop")
+}
+
+// OnStructs expects the Next iteration of snapshots whose DataTo assigns the
+// source structs.
+func (documents *DocumentIteratorMock) OnStructs(sources ...interface{}) {
+	snapshots := make([]firestore.DocumentSnapshot, len(sources))
+	for index, source := range sources {
+		snapshot := NewDocumentSnapshotMock()
+		snapshot.OnDataTo(source)
+		snapshots[index] = snapshot
+	}
+	documents.OnDocuments(snapshots...)
+}
+
 type WriteBatchMock struct {
 	mock.Mock
 }
@@ -234,3 +451,52 @@ func (mock *ClientMock) Batch() firestore.WriteBatch {
 	}
 	return result.(firestore.WriteBatch)
 }
+
+// RunTransaction runs fn with the Transaction returned by the mock, when
+// there is one, and then returns the error of the mock.
+func (mock *ClientMock) RunTransaction(
+	ctx context.Context, fn func(context.Context, firestore.Transaction) error,
+) error {
+	args := mock.Called(ctx)
+	if transaction, ok := args.Get(0).(firestore.Transaction); ok {
+		if err := fn(ctx, transaction); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+type TransactionMock struct {
+	mock.Mock
+}
+
+func NewTransactionMock() *TransactionMock {
+	return new(TransactionMock)
+}
+
+func (mock *TransactionMock) Get(ref firestore.DocumentRef) (firestore.DocumentSnapshot, error) {
+	var (
+		args   = mock.Called(ref)
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(firestore.DocumentSnapshot), err
+}
+
+func (mock *TransactionMock) Set(ref firestore.DocumentRef, data interface{}, options ...firestore.SetOption) error {
+	args := mock.Called(ref, data, options)
+	return args.Error(0)
+}
+
+func (mock *TransactionMock) Update(ref firestore.DocumentRef, updates []firestore.Update) error {
+	args := mock.Called(ref, updates)
+	return args.Error(0)
+}
+
+func (mock *TransactionMock) Delete(ref firestore.DocumentRef) error {
+	args := mock.Called(ref)
+	return args.Error(0)
+}
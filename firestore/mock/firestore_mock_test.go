@@ -7,8 +7,11 @@ import (
 	"github.com/rjansen/raizel/firestore"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
 )
 
+var iteratorDone = iterator.Done
+
 func TestCollectionRefMock(t *testing.T) {
 	t.Run(
 		"Validates mock interface",
@@ -29,12 +32,14 @@ func TestCollectionRefMock(t *testing.T) {
 			ref.On("OrderBy", mock.Anything, mock.Anything).Return(nil)
 			ref.On("Offset", mock.Anything).Return(nil)
 			ref.On("Limit", mock.Anything).Return(nil)
+			ref.On("StartAfter", mock.Anything).Return(nil)
 
 			require.Nil(t, ref.Documents(nil), "invalid documents() response")
 			require.Nil(t, ref.Where("", "", nil), "invalid where() response")
 			require.Nil(t, ref.OrderBy("", 0), "invalid order_by() response")
 			require.Nil(t, ref.Offset(0), "invalid offset() response")
 			require.Nil(t, ref.Limit(0), "invalid limit() response")
+			require.Nil(t, ref.StartAfter("value"), "invalid start_after() response")
 		},
 	)
 
@@ -51,12 +56,14 @@ func TestCollectionRefMock(t *testing.T) {
 			ref.On("OrderBy", mock.Anything, mock.Anything).Return(ref)
 			ref.On("Offset", mock.Anything).Return(ref)
 			ref.On("Limit", mock.Anything).Return(ref)
+			ref.On("StartAfter", mock.Anything).Return(ref)
 
 			require.Equal(t, iterator, ref.Documents(nil), "invalid documents() response")
 			require.Equal(t, ref, ref.Where("", "", nil), "invalid where() response")
 			require.Equal(t, ref, ref.OrderBy("", 0), "invalid order_by() response")
 			require.Equal(t, ref, ref.Offset(0), "invalid offset() response")
 			require.Equal(t, ref, ref.Limit(0), "invalid limit() response")
+			require.Equal(t, ref, ref.StartAfter("value"), "invalid start_after() response")
 		},
 	)
 
@@ -78,9 +85,13 @@ func TestDocumentSnapshotMock(t *testing.T) {
 			snapshot := NewDocumentSnapshotMock()
 
 			snapshot.On("DataTo", mock.Anything).Return(nil)
+			snapshot.On("DataAt", mock.Anything).Return(nil, nil)
 			snapshot.On("Exists").Return(false)
 
 			require.Nil(t, snapshot.DataTo(nil), "invalid data_to() response")
+			value, err := snapshot.DataAt("field")
+			require.Nil(t, value, "invalid data_at() value")
+			require.Nil(t, err, "invalid data_at() error")
 			require.False(t, snapshot.Exists(), "invalid exists() response")
 		},
 	)
@@ -94,9 +105,13 @@ func TestDocumentSnapshotMock(t *testing.T) {
 			)
 
 			snapshot.On("DataTo", mock.Anything).Return(errDataTo)
+			snapshot.On("DataAt", "field").Return("value", nil)
 			snapshot.On("Exists").Return(true)
 
 			require.Equal(t, errDataTo, snapshot.DataTo(nil), "invalid data_to() response")
+			value, err := snapshot.DataAt("field")
+			require.Equal(t, "value", value, "invalid data_at() value")
+			require.Nil(t, err, "invalid data_at() error")
 			require.True(t, snapshot.Exists(), "invalid exists() response")
 		},
 	)
@@ -118,12 +133,14 @@ func TestDocumentRefMock(t *testing.T) {
 			ref := NewDocumentRefMock()
 
 			ref.On("Get", mock.Anything).Return(nil, nil)
+			ref.On("Create", mock.Anything, mock.Anything).Return(nil)
 			ref.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			ref.On("Delete", mock.Anything).Return(nil)
 
 			snapshot, err := ref.Get(nil)
 			require.Nil(t, err, "invalid get() error response")
 			require.Nil(t, snapshot, "invalid get() snapshot response")
+			require.Nil(t, ref.Create(nil, nil), "invalid create() response")
 			require.Nil(t, ref.Set(nil, nil), "invalid set() response")
 			require.Nil(t, ref.Delete(nil), "invalid delete() response")
 		},
@@ -136,17 +153,20 @@ func TestDocumentRefMock(t *testing.T) {
 				ref       = NewDocumentRefMock()
 				snapshot  = NewDocumentSnapshotMock()
 				errGet    = errors.New("err_mock_get")
+				errCreate = errors.New("err_mock_create")
 				errSet    = errors.New("err_mock_set")
 				errDelete = errors.New("err_mock_delete")
 			)
 
 			ref.On("Get", mock.Anything).Return(snapshot, errGet)
+			ref.On("Create", mock.Anything, mock.Anything).Return(errCreate)
 			ref.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(errSet)
 			ref.On("Delete", mock.Anything).Return(errDelete)
 
 			document, err := ref.Get(nil)
 			require.Equal(t, errGet, err, "invalid get() error response")
 			require.Equal(t, snapshot, document, "invalid get() snapshot response")
+			require.Equal(t, errCreate, ref.Create(nil, nil), "invalid create() response")
 			require.Equal(t, errSet, ref.Set(nil, nil), "invalid set() response")
 			require.Equal(t, errDelete, ref.Delete(nil), "invalid delete() response")
 		},
@@ -197,6 +217,90 @@ func TestDocumentIteratorMock(t *testing.T) {
 			require.Equal(t, snapshots, documents, "invalid get_all() documents response")
 		},
 	)
+	t.Run(
+		"Iterates the configured structs",
+		func(t *testing.T) {
+			type document struct {
+				Name string `firestore:"name"`
+			}
+			var (
+				iterator = NewDocumentIteratorMock()
+				names    []string
+			)
+			iterator.OnStructs(document{Name: "one"}, &document{Name: "two"})
+
+			for {
+				snapshot, err := iterator.Next()
+				if err == iteratorDone {
+					break
+				}
+				require.Nil(t, err, "invalid next() error response")
+				require.True(t, snapshot.Exists(), "invalid exists() response")
+				var current document
+				require.Nil(t, snapshot.DataTo(&current), "invalid data_to() error response")
+				names = append(names, current.Name)
+			}
+			iterator.Stop()
+			require.Equal(t, []string{"one", "two"}, names, "invalid data_to() targets")
+		},
+	)
+}
+
+func TestSnapshotIteratorMock(t *testing.T) {
+	t.Run(
+		"Validates mock interface",
+		func(t *testing.T) {
+			require.Implements(
+				t, (*firestore.QuerySnapshotIterator)(nil), NewQuerySnapshotIteratorMock(),
+				"invalid query_snapshot_iterator type",
+			)
+			require.Implements(
+				t, (*firestore.DocumentSnapshotIterator)(nil), NewDocumentSnapshotIteratorMock(),
+				"invalid document_snapshot_iterator type",
+			)
+		},
+	)
+
+	t.Run(
+		"Returns a configured result for function call",
+		func(t *testing.T) {
+			var (
+				queryIterator    = NewQuerySnapshotIteratorMock()
+				documentIterator = NewDocumentSnapshotIteratorMock()
+				snapshot         = NewDocumentSnapshotMock()
+				changes          = []firestore.DocumentChange{
+					{Kind: firestore.DocumentAdded, ID: "mockref1", Doc: snapshot},
+				}
+				errNext = errors.New("err_mock_next")
+			)
+
+			queryIterator.On("Next").Return(changes, nil).Once()
+			queryIterator.On("Next").Return(nil, errNext).Once()
+			queryIterator.On("Stop")
+			documentIterator.On("Next").Return(snapshot, nil).Once()
+			documentIterator.On("Next").Return(nil, errNext).Once()
+			documentIterator.On("Stop")
+
+			result, err := queryIterator.Next()
+			require.Nil(t, err, "invalid query next() error response")
+			require.Equal(t, changes, result, "invalid query next() changes response")
+			result, err = queryIterator.Next()
+			require.Equal(t, errNext, err, "invalid query next() error response")
+			require.Nil(t, result, "invalid query next() changes response")
+			queryIterator.Stop()
+
+			doc, err := documentIterator.Next()
+			require.Nil(t, err, "invalid document next() error response")
+			require.Equal(t, snapshot, doc, "invalid document next() snapshot response")
+			doc, err = documentIterator.Next()
+			require.Equal(t, errNext, err, "invalid document next() error response")
+			require.Nil(t, doc, "invalid document next() snapshot response")
+			documentIterator.Stop()
+
+			queryIterator.AssertExpectations(t)
+			documentIterator.AssertExpectations(t)
+		},
+	)
 }
 
 func TestWriteBatchMock(t *testing.T) {
@@ -0,0 +1,81 @@
+package firestore
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+type pageIterator struct {
+	documents     DocumentIterator
+	orders        []raizel.Order
+	size          int
+	count         int
+	last          DocumentSnapshot
+	nextPageToken string
+}
+
+func (i *pageIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	doc, err := i.documents.Next()
+	if err != nil {
+		if err != iterator.Done {
+			return err
+		}
+		if i.count == i.size && i.last != nil {
+			values := make([]interface{}, len(i.orders))
+			for index, order := range i.orders {
+				if values[index], err = i.last.DataAt(order.Field); err != nil {
+					return err
+				}
+			}
+			if i.nextPageToken, err = raizel.EncodePageToken(values...); err != nil {
+				return err
+			}
+			i.last = nil
+		}
+		return raizel.ErrIteratorDone
+	}
+	i.count++
+	i.last = doc
+	return dataTo(doc, entity)
+}
+
+func (i *pageIterator) NextPageToken() string {
+	return i.nextPageToken
+}
+
+func (i *pageIterator) Stop() {
+	i.documents.Stop()
+}
+
+// Page reads the query page that starts after the document ordering values
+// carried by pageToken.
+func (r *repository) Page(ctx context.Context, query raizel.Query, pageToken string) (raizel.PageIterator, error) {
+	if len(query.Orders) == 0 {
+		return nil, raizel.ErrUnorderedPage
+	}
+	collection, err := entityCollection(query)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		size   = raizel.PageSize(query)
+		fquery = entityQuery(r.client.Collection(collection), query.WithLimit(size))
+	)
+	if pageToken != "" {
+		values, err := raizel.DecodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(query.Orders) {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		fquery = fquery.StartAfter(values...)
+	}
+	return &pageIterator{
+		documents: fquery.Documents(ctx),
+		orders:    query.Orders,
+		size:      size,
+	}, nil
+}
@@ -0,0 +1,133 @@
+package firestore_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+func mustPageToken(values ...interface{}) string {
+	token, err := raizel.EncodePageToken(values...)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+type testRepositoryPage struct {
+	name          string
+	client        *fmock.ClientMock
+	collection    *fmock.CollectionRefMock
+	query         raizel.Query
+	pageToken     string
+	startAfter    []interface{}
+	entities      []testEntity
+	nextPageToken string
+	err           error
+}
+
+func (scenario *testRepositoryPage) setup(t *testing.T) {
+	var (
+		client     = fmock.NewClientMock()
+		collection = fmock.NewCollectionRefMock()
+		documents  = fmock.NewDocumentIteratorMock()
+	)
+	for _, entity := range scenario.entities {
+		var (
+			entity = entity
+			doc    = fmock.NewDocumentSnapshotMock()
+		)
+		doc.On("DataTo", mock.Anything).Return(nil).Run(
+			func(args mock.Arguments) {
+				*args.Get(0).(*testEntity) = entity
+			},
+		)
+		doc.On("DataAt", "age").Return(int64(entity.Age), nil)
+		doc.On("DataAt", "id").Return(entity.ID, nil)
+		documents.On("Next").Return(doc, nil).Once()
+	}
+	documents.On("Next").Return(nil, iterator.Done)
+	documents.On("Stop")
+	collection.On("Where", mock.Anything, mock.Anything, mock.Anything).Return(collection)
+	collection.On("OrderBy", mock.Anything, mock.Anything).Return(collection)
+	collection.On("Limit", raizel.PageSize(scenario.query)).Return(collection)
+	collection.On("StartAfter", scenario.startAfter).Return(collection)
+	collection.On("Documents", mock.Anything).Return(documents)
+	client.On("Collection", scenario.query.EntityName).Return(collection)
+
+	scenario.client = client
+	scenario.collection = collection
+}
+
+func TestRepositoryPage(test *testing.T) {
+	scenarios := []testRepositoryPage{
+		{
+			name: "Read the first page",
+			query: raizel.NewQuery("entities").
+				Where("age", raizel.Greater, 10).
+				OrderBy("age", raizel.Asc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			entities:      []testEntity{{ID: "a", Age: 20}, {ID: "b", Age: 30}},
+			nextPageToken: mustPageToken(30, "b"),
+		},
+		{
+			name: "Read the next page after the token cursor",
+			query: raizel.NewQuery("entities").
+				Where("age", raizel.Greater, 10).
+				OrderBy("age", raizel.Asc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			pageToken:  mustPageToken(30, "b"),
+			startAfter: []interface{}{int64(30), "b"},
+			entities:   []testEntity{{ID: "c", Age: 40}},
+		},
+		{
+			name:      "Error when the token is invalid",
+			query:     raizel.NewQuery("entities").OrderBy("id", raizel.Asc),
+			pageToken: "!invalid",
+			err:       raizel.ErrInvalidPageToken,
+		},
+		{
+			name:  "Error when the query is not ordered",
+			query: raizel.NewQuery("entities"),
+			err:   raizel.ErrUnorderedPage,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := firestore.NewRepository(scenario.client).(raizel.Pageable)
+				page, err := repository.Page(context.Background(), scenario.query, scenario.pageToken)
+				require.Equal(t, scenario.err, err, "page error")
+				if scenario.err != nil {
+					require.Nil(t, page, "page instance")
+					return
+				}
+				defer page.Stop()
+				for _, expected := range scenario.entities {
+					var entity testEntity
+					require.Nil(t, page.Next(context.Background(), &entity), "next error")
+					require.Equal(t, expected.ID, entity.ID, "entity id")
+				}
+				require.Equal(t, raizel.ErrIteratorDone, page.Next(context.Background(), &testEntity{}), "next done error")
+				require.Equal(t, scenario.nextPageToken, page.NextPageToken(), "next page token")
+				if scenario.startAfter != nil {
+					scenario.collection.AssertCalled(t, "StartAfter", scenario.startAfter)
+				} else {
+					scenario.collection.AssertNotCalled(t, "StartAfter", mock.Anything)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,119 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+func TestRepositoryChildKey(test *testing.T) {
+	var (
+		ctx    = context.Background()
+		client = fmock.NewClientMock()
+		ref    = fmock.NewDocumentRefMock()
+		query  = fmock.NewCollectionRefMock()
+		docs   = fmock.NewDocumentIteratorMock()
+		order  = raizel.NewDynamicKey("orders", "id", "order/1")
+		item   = raizel.NewChildKey(order, raizel.NewDynamicKey("items", "id", 2))
+	)
+	client.On("Doc", "orders/order%2F1/items/2").Return(ref)
+	client.On("Collection", "orders/order%2F1/items").Return(query)
+	ref.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	query.On("Documents", mock.Anything).Return(docs)
+	docs.On("Next").Return(nil, iterator.Done)
+
+	repository := firestore.NewRepository(client)
+	require.Nil(test, repository.Set(ctx, item, testEntity{ID: "2"}), "set child error")
+	children, err := repository.(raizel.Queryable).Query(ctx, raizel.Children(order, "items"))
+	require.Nil(test, err, "query children error")
+	require.Equal(test, raizel.ErrIteratorDone, children.Next(ctx, &testEntity{}), "children iterator")
+	client.AssertExpectations(test)
+	ref.AssertExpectations(test)
+}
+
+func TestRepositoryDeleteCascade(test *testing.T) {
+	var (
+		ctx         = context.Background()
+		client      = fmock.NewClientMock()
+		parent      = fmock.NewDocumentRefMock()
+		child       = fmock.NewDocumentRefMock()
+		collection  = fmock.NewCollectionRefMock()
+		collections = fmock.NewCollectionIteratorMock()
+		refs        = fmock.NewDocumentRefIteratorMock()
+		empty       = fmock.NewCollectionIteratorMock()
+	)
+	client.On("Doc", "orders/order1").Return(parent)
+	parent.On("Collections", ctx).Return(collections)
+	collections.On("Next").Return(collection, nil).Once()
+	collections.On("Next").Return(nil, iterator.Done)
+	collection.On("DocumentRefs", ctx).Return(refs)
+	refs.On("Next").Return(child, nil).Once()
+	refs.On("Next").Return(nil, iterator.Done)
+	child.On("Collections", ctx).Return(empty)
+	empty.On("Next").Return(nil, iterator.Done)
+	child.On("Delete", ctx).Return(nil)
+	parent.On("Delete", ctx).Return(nil)
+
+	repository := firestore.NewRepository(client)
+	err := raizel.DeleteCascade(ctx, repository, raizel.NewDynamicKey("orders", "id", "order1"))
+	require.Nil(test, err, "delete cascade error")
+	parent.AssertExpectations(test)
+	child.AssertExpectations(test)
+	refs.AssertExpectations(test)
+}
+
+var errLockedOrder = errors.New("err_lockedorder")
+
+type lockedOrder struct {
+	_      struct{} `raizel:"locked_orders,entity"`
+	ID     string   `raizel:"id,key" firestore:"id"`
+	Locked bool     `raizel:"locked" firestore:"locked"`
+}
+
+func (o *lockedOrder) AfterGet(context.Context) error {
+	return errors.New("err_unexpectedafterget")
+}
+
+func (o *lockedOrder) BeforeDelete(context.Context) error {
+	if o.Locked {
+		return errLockedOrder
+	}
+	return nil
+}
+
+func TestRepositoryDeleteCascadeBeforeDelete(test *testing.T) {
+	_, err := raizel.RegisterEntity(lockedOrder{})
+	require.Nil(test, err, "register error")
+	server, err := firestoretest.NewServer()
+	require.Nil(test, err, "new server error")
+	defer server.Close()
+	fclient, err := server.NewClient(context.Background(), "cascade")
+	require.Nil(test, err, "new firestore client error")
+	client, err := firestore.WrapClient(fclient)
+	require.Nil(test, err, "wrap client error")
+
+	var (
+		ctx        = context.Background()
+		repository = firestore.NewRepository(client)
+		order      = raizel.NewDynamicKey("locked_orders", "id", "order1")
+		item       = raizel.NewChildKey(order, raizel.NewDynamicKey("items", "id", "item1"))
+	)
+	defer repository.Close(ctx)
+	require.Nil(test, repository.Set(ctx, order, &lockedOrder{ID: "order1", Locked: true}), "set locked error")
+	require.Nil(test, repository.Set(ctx, item, testEntity{ID: "item1"}), "set child error")
+
+	require.Equal(test, errLockedOrder, raizel.DeleteCascade(ctx, repository, order), "locked delete cascade error")
+	require.Nil(test, repository.Get(ctx, item, &testEntity{}), "locked child deleted")
+
+	require.Nil(test, repository.Set(ctx, order, &lockedOrder{ID: "order1"}), "set unlocked error")
+	require.Nil(test, raizel.DeleteCascade(ctx, repository, order), "delete cascade error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, item, &testEntity{}), "child not deleted")
+}
@@ -0,0 +1,138 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// fieldUpdates maps the updates to firestore field updates, a dotted field
+// is a nested field path. ArrayAppend writes the array field of data with the
+// update values appended, firestore.ArrayUnion would drop the duplicates.
+func fieldUpdates(updates []raizel.Update, data map[string]interface{}) ([]Update, error) {
+	fieldUpdates := make([]Update, len(updates))
+	for index, update := range updates {
+		var value interface{}
+		switch update.Transform {
+		case raizel.AssignTransform:
+			value = update.Value
+		case raizel.IncrementTransform:
+			value = firestore.Increment(update.Value)
+		case raizel.ServerTimestampTransform:
+			value = firestore.ServerTimestamp
+		case raizel.ArrayAppendTransform:
+			values, err := arrayField(data, update.Field)
+			if err != nil {
+				return nil, err
+			}
+			value = append(values, arrayValues(update.Value)...)
+		case raizel.ArrayRemoveTransform:
+			value = firestore.ArrayRemove(arrayValues(update.Value)...)
+		case raizel.DeleteTransform:
+			value = firestore.Delete
+		default:
+			return nil, fmt.Errorf("%w: transform %d", raizel.ErrInvalidArgument, update.Transform)
+		}
+		fieldUpdates[index] = Update{Path: update.Field, Value: value}
+	}
+	return fieldUpdates, nil
+}
+
+func arrayValues(value interface{}) []interface{} {
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	return []interface{}{value}
+}
+
+// arrayField returns a copy of the array at the dotted path of data, a
+// missing field is an empty array.
+func arrayField(data map[string]interface{}, path string) ([]interface{}, error) {
+	var (
+		names = strings.Split(path, ".")
+		value interface{}
+	)
+	for index, name := range names {
+		value = data[name]
+		if value == nil {
+			return nil, nil
+		}
+		if index == len(names)-1 {
+			break
+		}
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a map", raizel.ErrInvalidArgument, name)
+		}
+		data = nested
+	}
+	array := reflect.ValueOf(value)
+	if array.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: %s is not an array", raizel.ErrInvalidArgument, path)
+	}
+	values := make([]interface{}, array.Len())
+	for index := range values {
+		values[index] = array.Index(index).Interface()
+	}
+	return values, nil
+}
+
+func hasArrayAppend(updates []raizel.Update) bool {
+	for _, update := range updates {
+		if update.Transform == raizel.ArrayAppendTransform {
+			return true
+		}
+	}
+	return false
+}
+
+// Patch updates the fields of the document, a missing document returns
+// raizel.ErrNotFound. The updates with ArrayAppend read and update the
+// document in a transaction. The updates of the key fields return
+// raizel.ErrInvalidArgument.
+func (r *repository) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	if err := raizel.CheckUpdates(key, updates); err != nil {
+		return err
+	}
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	ref := r.client.Doc(path)
+	if !hasArrayAppend(updates) {
+		fieldUpdates, err := fieldUpdates(updates, nil)
+		if err != nil {
+			return err
+		}
+		return notFound(ref.Update(ctx, fieldUpdates))
+	}
+	return r.client.RunTransaction(ctx, func(ctx context.Context, transaction Transaction) error {
+		doc, err := transaction.Get(ref)
+		if err != nil {
+			return notFound(err)
+		}
+		var data map[string]interface{}
+		if err := doc.DataTo(&data); err != nil {
+			return err
+		}
+		fieldUpdates, err := fieldUpdates(updates, data)
+		if err != nil {
+			return err
+		}
+		return transaction.Update(ref, fieldUpdates)
+	})
+}
+
+// notFound maps the firestore not found error to raizel.ErrNotFound.
+func notFound(err error) error {
+	if grpc.Code(err) == codes.NotFound {
+		return raizel.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	gfirestore "cloud.google.com/go/firestore"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	fmock "github.com/rjansen/raizel/firestore/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testRepositoryPatch struct {
+	name      string
+	updates   []raizel.Update
+	fields    []firestore.Update
+	updateErr error
+	err       error
+}
+
+func TestRepositoryPatch(test *testing.T) {
+	scenarios := []testRepositoryPatch{
+		{
+			name: "Patch the field paths with transforms",
+			updates: []raizel.Update{
+				raizel.Assign("profile.name", "mock"),
+				raizel.Increment("age", 1),
+				raizel.ServerTimestamp("updatedAt"),
+				raizel.ArrayRemove("tags", "b"),
+				raizel.DeleteField("nickname"),
+			},
+			fields: []firestore.Update{
+				{Path: "profile.name", Value: "mock"},
+				{Path: "age", Value: gfirestore.Increment(1)},
+				{Path: "updatedAt", Value: gfirestore.ServerTimestamp},
+				{Path: "tags", Value: gfirestore.ArrayRemove("b")},
+				{Path: "nickname", Value: gfirestore.Delete},
+			},
+		},
+		{
+			name:      "Error when the document is not found",
+			updates:   []raizel.Update{raizel.Assign("name", "mock")},
+			fields:    []firestore.Update{{Path: "name", Value: "mock"}},
+			updateErr: status.Error(codes.NotFound, "document not found"),
+			err:       raizel.ErrNotFound,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					ctx    = context.Background()
+					client = fmock.NewClientMock()
+					ref    = fmock.NewDocumentRefMock()
+				)
+				client.On("Doc", "entities/mock1").Return(ref)
+				ref.On("Update", ctx, scenario.fields).Return(scenario.updateErr)
+
+				repository := firestore.NewRepository(client)
+				err := raizel.Patch(ctx, repository, raizel.NewDynamicKey("entities", "id", "mock1"), scenario.updates...)
+				require.Equal(t, scenario.err, err, "patch error")
+				ref.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+type taggedEntity struct {
+	ID      string                 `firestore:"id"`
+	Tags    []string               `firestore:"tags"`
+	Profile map[string]interface{} `firestore:"profile"`
+}
+
+func TestRepositoryPatchArrayAppend(test *testing.T) {
+	server, err := firestoretest.NewServer()
+	require.Nil(test, err, "new server error")
+	defer server.Close()
+	fclient, err := server.NewClient(context.Background(), "patch")
+	require.Nil(test, err, "new firestore client error")
+	client, err := firestore.WrapClient(fclient)
+	require.Nil(test, err, "wrap client error")
+
+	var (
+		ctx        = context.Background()
+		repository = firestore.NewRepository(client)
+		key        = raizel.NewDynamicKey("entities", "id", "mock1")
+		entity     = taggedEntity{ID: "mock1", Tags: []string{"a", "b"}, Profile: map[string]interface{}{"name": "mock"}}
+	)
+	defer repository.Close(ctx)
+	require.Nil(test, repository.Set(ctx, key, &entity), "set error")
+
+	err = raizel.Patch(
+		ctx, repository, key,
+		raizel.ArrayAppend("tags", "a", "c"), raizel.ArrayAppend("profile.aliases", "m"),
+	)
+	require.Nil(test, err, "patch error")
+	var patched taggedEntity
+	require.Nil(test, repository.Get(ctx, key, &patched), "get error")
+	require.Equal(test, []string{"a", "b", "a", "c"}, patched.Tags, "appended tags")
+	require.Equal(test, []interface{}{"m"}, patched.Profile["aliases"], "appended missing field")
+
+	err = raizel.Patch(ctx, repository, key, raizel.ArrayAppend("profile.name", "m"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "append to a string error")
+	err = raizel.Patch(ctx, repository, raizel.NewDynamicKey("entities", "id", "missing"), raizel.ArrayAppend("tags", "a"))
+	require.Equal(test, raizel.ErrNotFound, err, "append to a missing document error")
+}
@@ -3,12 +3,19 @@ package firestore
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
+const (
+	tenantsCollection = "tenants"
+)
+
 type repository struct {
 	client Client
 }
@@ -17,36 +24,159 @@ func NewRepository(client Client) raizel.Repository {
 	return &repository{client: client}
 }
 
-func entityDocRef(key raizel.EntityKey) string {
-	return fmt.Sprintf("%s/%s", key.EntityName(), key.Value())
+// NewTenantRepository returns a repository that stores every entity under the
+// document of the context tenant, tenants/{tenant}/{entityName}/{key}.
+func NewTenantRepository(client Client) raizel.Repository {
+	return raizel.NewTenantRepository(NewRepository(client))
+}
+
+// entityDocRef returns the document path of the key with the IDs encoded by
+// EncodeKey, the document of a child key is in a subcollection of the parent
+// document, orders/{id}/items/{id}.
+func entityDocRef(key raizel.EntityKey) (string, error) {
+	path := make([]string, 0, 2)
+	if tenant, ok := raizel.TenantOf(key); ok {
+		if tenant == "" {
+			return "", fmt.Errorf("%w: blank tenant", raizel.ErrInvalidArgument)
+		}
+		path = append(path, tenantsCollection, escapeID(tenant))
+	}
+	for _, pathKey := range raizel.KeyPath(key) {
+		if err := checkCollection(pathKey.EntityName()); err != nil {
+			return "", err
+		}
+		id, err := EncodeKey(pathKey)
+		if err != nil {
+			return "", err
+		}
+		path = append(path, pathKey.EntityName(), id)
+	}
+	return strings.Join(path, "/"), nil
+}
+
+// entityCollection returns the collection path of the query entities, a
+// subcollection of the parent document when the query has a parent.
+func entityCollection(query raizel.Query) (string, error) {
+	if err := checkCollection(query.EntityName); err != nil {
+		return "", err
+	}
+	if query.Parent == nil {
+		return query.EntityName, nil
+	}
+	parent, err := entityDocRef(query.Parent)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", parent, query.EntityName), nil
+}
+
+// entityData returns the document data of the entity, the field map of its
+// descriptor when the entity type is registered.
+func entityData(entity raizel.Entity) (interface{}, error) {
+	descriptor, described := raizel.DescriptorOf(entity)
+	if !described {
+		return entity, nil
+	}
+	return descriptor.Map(entity)
+}
+
+// dataTo reads the document into the entity, through the firestore tagged
+// struct of its descriptor when the entity type is registered.
+func dataTo(doc DocumentSnapshot, entity raizel.Entity) error {
+	descriptor, described := raizel.DescriptorOf(entity)
+	if !described {
+		return doc.DataTo(entity)
+	}
+	value := reflect.New(descriptor.TaggedType("firestore", false)).Interface()
+	if err := doc.DataTo(value); err != nil {
+		return err
+	}
+	return descriptor.FromTagged(value, entity)
 }
 
 func (r *repository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
-	var (
-		ref      = r.client.Doc(entityDocRef(key))
-		doc, err = ref.Get(context.Background())
-	)
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	doc, err := r.client.Doc(path).Get(context.Background())
 	if err != nil {
 		if grpc.Code(err) == codes.NotFound {
 			return raizel.ErrNotFound
 		}
 		return err
 	}
-	return doc.DataTo(entity)
+	if err := dataTo(doc, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
 }
 
 func (r *repository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
-	var (
-		ref = r.client.Doc(entityDocRef(key))
-	)
-	return ref.Set(context.Background(), entity)
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	data, err := entityData(entity)
+	if err != nil {
+		return err
+	}
+	return r.client.Doc(path).Set(context.Background(), data)
 }
 
 func (r *repository) Delete(ctx context.Context, key raizel.EntityKey) error {
-	var (
-		ref = r.client.Doc(entityDocRef(key))
-	)
-	return ref.Delete(context.Background())
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	return r.client.Doc(path).Delete(context.Background())
+}
+
+// DeleteCascade deletes the document of the key with every document of its
+// subcollections, the BeforeDelete hook runs for the document of the key
+// only.
+func (r *repository) DeleteCascade(ctx context.Context, key raizel.EntityKey) error {
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	return deleteDocument(ctx, r.client.Doc(path))
+}
+
+func deleteDocument(ctx context.Context, doc DocumentRef) error {
+	collections := doc.Collections(ctx)
+	for {
+		collection, err := collections.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		refs := collection.DocumentRefs(ctx)
+		for {
+			ref, err := refs.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := deleteDocument(ctx, ref); err != nil {
+				return err
+			}
+		}
+	}
+	return doc.Delete(ctx)
 }
 
 func (r *repository) Close(ctx context.Context) error {
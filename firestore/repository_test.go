@@ -250,3 +250,74 @@ func TestRepositoryDelete(test *testing.T) {
 		)
 	}
 }
+
+type testTenantRepository struct {
+	name   string
+	ctx    context.Context
+	ref    *fmock.DocumentRefMock
+	client *fmock.ClientMock
+	key    raizel.EntityKey
+	path   string
+	err    error
+}
+
+func (scenario *testTenantRepository) setup(t *testing.T) {
+	var (
+		ref = fmock.NewDocumentRefMock()
+		cli = fmock.NewClientMock()
+	)
+	require.NotNil(t, ref, "mock docref instance")
+	require.NotNil(t, cli, "mock client instance")
+
+	ref.On("Delete", mock.Anything).Return(nil)
+	cli.On("Doc", scenario.path).Return(ref)
+	cli.On("Close").Return(nil)
+
+	scenario.ref = ref
+	scenario.client = cli
+}
+
+func TestTenantRepository(test *testing.T) {
+	scenarios := []testTenantRepository{
+		{
+			name: "Delete entity under the tenant document",
+			ctx:  raizel.WithTenant(context.Background(), "tenant_a"),
+			key: testEntityKey{
+				collection: "mymockcollection",
+				name:       "id",
+				value:      "identifier",
+			},
+			path: "tenants/tenant_a/mymockcollection/identifier",
+		},
+		{
+			name: "Error when try to Delete an entity without tenant",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				collection: "mymockcollection",
+				name:       "id",
+				value:      "identifier",
+			},
+			err: raizel.ErrTenantRequired,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := firestore.NewTenantRepository(scenario.client)
+				require.NotNil(t, repository, "repository instance")
+				err := repository.Delete(scenario.ctx, scenario.key)
+				require.Equal(t, scenario.err, err, "delete error")
+				repository.Close(scenario.ctx)
+				if scenario.err == nil {
+					scenario.client.AssertExpectations(t)
+					scenario.ref.AssertExpectations(t)
+				} else {
+					scenario.client.AssertNotCalled(t, "Doc", mock.Anything)
+				}
+			},
+		)
+	}
+}
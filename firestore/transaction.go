@@ -0,0 +1,72 @@
+package firestore
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// transactionRepository reads and writes the documents of a transaction,
+// firestore refuses the reads after the first write of the transaction.
+type transactionRepository struct {
+	client      Client
+	transaction Transaction
+}
+
+func (r *transactionRepository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	doc, err := r.transaction.Get(r.client.Doc(path))
+	if err != nil {
+		if grpc.Code(err) == codes.NotFound {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	if err := dataTo(doc, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (r *transactionRepository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	data, err := entityData(entity)
+	if err != nil {
+		return err
+	}
+	return r.transaction.Set(r.client.Doc(path), data)
+}
+
+func (r *transactionRepository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	path, err := entityDocRef(key)
+	if err != nil {
+		return err
+	}
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	return r.transaction.Delete(r.client.Doc(path))
+}
+
+func (r *transactionRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+// Transaction runs fn in a firestore transaction, firestore runs fn again
+// when the transaction conflicts so fn must not keep state between the runs.
+func (r *repository) Transaction(ctx context.Context, fn func(context.Context, raizel.Repository) error) error {
+	return r.client.RunTransaction(ctx, func(ctx context.Context, transaction Transaction) error {
+		return fn(ctx, &transactionRepository{client: r.client, transaction: transaction})
+	})
+}
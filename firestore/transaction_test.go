@@ -0,0 +1,66 @@
+package firestore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/firestore"
+	"github.com/rjansen/raizel/firestore/firestoretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryTransaction(test *testing.T) {
+	server, err := firestoretest.NewServer()
+	require.Nil(test, err, "new server error")
+	defer server.Close()
+	fclient, err := server.NewClient(context.Background(), "transaction")
+	require.Nil(test, err, "new firestore client error")
+	client, err := firestore.WrapClient(fclient)
+	require.Nil(test, err, "wrap client error")
+
+	var (
+		ctx        = context.Background()
+		repository = firestore.NewRepository(client)
+		key1       = raizel.NewDynamicKey("entities", "id", "entity1")
+		key2       = raizel.NewDynamicKey("entities", "id", "entity2")
+		errAbort   = errors.New("err_abort")
+	)
+	defer repository.Close(ctx)
+	require.Nil(test, repository.Set(ctx, key1, &testEntity{ID: "entity1", Age: 1}), "set error")
+
+	err = raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		var entity testEntity
+		if err := tx.Get(ctx, key1, &entity); err != nil {
+			return err
+		}
+		if err := tx.Get(ctx, key2, &testEntity{}); err != raizel.ErrNotFound {
+			return err
+		}
+		entity.Age++
+		if err := tx.Set(ctx, key1, &entity); err != nil {
+			return err
+		}
+		return tx.Set(ctx, key2, &testEntity{ID: "entity2", Age: entity.Age})
+	})
+	require.Nil(test, err, "transaction error")
+	var entity testEntity
+	require.Nil(test, repository.Get(ctx, key2, &entity), "get entity2 error")
+	require.Equal(test, testEntity{ID: "entity2", Age: 2}, entity, "entity2")
+
+	err = raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		if err := tx.Delete(ctx, key2); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.True(test, errors.Is(err, errAbort), "aborted transaction error")
+	require.Nil(test, repository.Get(ctx, key2, &entity), "get rolled back entity2 error")
+
+	err = raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		return tx.Delete(ctx, key2)
+	})
+	require.Nil(test, err, "delete transaction error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key2, &entity), "get deleted entity2 error")
+}
@@ -0,0 +1,139 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+type watcher struct {
+	client Client
+}
+
+// NewWatcher returns a raizel.Watcher that streams the entity changes from
+// the firestore collection and document snapshots.
+func NewWatcher(client Client) raizel.Watcher {
+	return &watcher{client: client}
+}
+
+func (w *watcher) Watch(ctx context.Context, entityName string) (raizel.ChangeStream, error) {
+	return &queryChangeStream{
+		entityName: entityName,
+		iterator:   w.client.Collection(entityName).Snapshots(ctx),
+	}, nil
+}
+
+func (w *watcher) WatchKey(ctx context.Context, key raizel.EntityKey) (raizel.ChangeStream, error) {
+	path, err := entityDocRef(key)
+	if err != nil {
+		return nil, err
+	}
+	return &documentChangeStream{
+		key:      key,
+		iterator: w.client.Doc(path).Snapshots(ctx),
+	}, nil
+}
+
+type change struct {
+	kind raizel.ChangeKind
+	key  raizel.EntityKey
+	doc  DocumentSnapshot
+}
+
+func (c change) Kind() raizel.ChangeKind {
+	return c.kind
+}
+
+func (c change) Key() raizel.EntityKey {
+	return c.key
+}
+
+func (c change) DataTo(entity raizel.Entity) error {
+	if c.doc == nil || !c.doc.Exists() {
+		return raizel.ErrNotFound
+	}
+	return dataTo(c.doc, entity)
+}
+
+func changeKind(kind DocumentChangeKind) raizel.ChangeKind {
+	switch kind {
+	case DocumentAdded:
+		return raizel.EntityAdded
+	case DocumentRemoved:
+		return raizel.EntityRemoved
+	default:
+		return raizel.EntityModified
+	}
+}
+
+type queryChangeStream struct {
+	entityName string
+	iterator   QuerySnapshotIterator
+	pending    []raizel.Change
+}
+
+func (s *queryChangeStream) Next() (raizel.Change, error) {
+	for len(s.pending) == 0 {
+		changes, err := s.iterator.Next()
+		if err != nil {
+			if err == iterator.Done {
+				return nil, raizel.ErrWatchStopped
+			}
+			return nil, err
+		}
+		for _, docChange := range changes {
+			key, err := DecodeKey(s.entityName, docChange.ID)
+			if err != nil {
+				key = raizel.NewDynamicKey(s.entityName, firestore.DocumentID, docChange.ID)
+			}
+			s.pending = append(s.pending, change{
+				kind: changeKind(docChange.Kind),
+				key:  key,
+				doc:  docChange.Doc,
+			})
+		}
+	}
+	next := s.pending[0]
+	s.pending = s.pending[1:]
+	return next, nil
+}
+
+func (s *queryChangeStream) Stop() {
+	s.iterator.Stop()
+}
+
+type documentChangeStream struct {
+	key      raizel.EntityKey
+	iterator DocumentSnapshotIterator
+	last     DocumentSnapshot
+}
+
+func (s *documentChangeStream) Next() (raizel.Change, error) {
+	for {
+		doc, err := s.iterator.Next()
+		if err != nil {
+			if err == iterator.Done {
+				return nil, raizel.ErrWatchStopped
+			}
+			return nil, err
+		}
+		last := s.last
+		if doc.Exists() {
+			s.last = doc
+			if last == nil {
+				return change{kind: raizel.EntityAdded, key: s.key, doc: doc}, nil
+			}
+			return change{kind: raizel.EntityModified, key: s.key, doc: doc}, nil
+		}
+		if last != nil {
+			s.last = nil
+			return change{kind: raizel.EntityRemoved, key: s.key, doc: last}, nil
+		}
+	}
+}
+
+func (s *documentChangeStream) Stop() {
+	s.iterator.Stop()
+}
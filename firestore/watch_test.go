@@ -0,0 +1,143 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/genproto/googleapis/firestore/v1"
+)
+
+const watchTargetIDMock int32 = 'g' + 'o'
+
+func listenTargetChange(changeType pb.TargetChange_TargetChangeType, targetIDs ...int32) *pb.ListenResponse {
+	return &pb.ListenResponse{
+		ResponseType: &pb.ListenResponse_TargetChange{
+			TargetChange: &pb.TargetChange{TargetChangeType: changeType, TargetIds: targetIDs},
+		},
+	}
+}
+
+func listenSnapshot() []interface{} {
+	return []interface{}{
+		listenTargetChange(pb.TargetChange_CURRENT),
+		&pb.ListenResponse{
+			ResponseType: &pb.ListenResponse_TargetChange{
+				TargetChange: &pb.TargetChange{
+					TargetChangeType: pb.TargetChange_NO_CHANGE,
+					ReadTime:         aTimestamp,
+				},
+			},
+		},
+	}
+}
+
+func listenDocumentChange(path string, fields map[string]*pb.Value) *pb.ListenResponse {
+	return &pb.ListenResponse{
+		ResponseType: &pb.ListenResponse_DocumentChange{
+			DocumentChange: &pb.DocumentChange{
+				Document: &pb.Document{
+					Name:       rootDocumentsMock + path,
+					CreateTime: aTimestamp,
+					UpdateTime: aTimestamp,
+					Fields:     fields,
+				},
+				TargetIds: []int32{watchTargetIDMock},
+			},
+		},
+	}
+}
+
+func listenDocumentDelete(path string) *pb.ListenResponse {
+	return &pb.ListenResponse{
+		ResponseType: &pb.ListenResponse_DocumentDelete{
+			DocumentDelete: &pb.DocumentDelete{
+				Document:         rootDocumentsMock + path,
+				RemovedTargetIds: []int32{watchTargetIDMock},
+			},
+		},
+	}
+}
+
+type testWatcher struct {
+	name    string
+	key     raizel.EntityKey
+	byKey   bool
+	server  *mockServer
+	watcher raizel.Watcher
+	kinds   []raizel.ChangeKind
+}
+
+func (scenario *testWatcher) setup(t *testing.T) {
+	var (
+		path      = fmt.Sprintf("%s/%s", scenario.key.EntityName(), scenario.key.Value())
+		responses = []interface{}{
+			listenTargetChange(pb.TargetChange_ADD, watchTargetIDMock),
+			listenDocumentChange(path, map[string]*pb.Value{"name": strval("mock name")}),
+		}
+	)
+	responses = append(responses, listenSnapshot()...)
+	responses = append(responses, listenDocumentDelete(path))
+	responses = append(responses, listenSnapshot()[1])
+
+	fclient, server := newMock(t)
+	client, err := newClient(fclient)
+	require.Nil(t, err, "new client error")
+	server.addRPC(nil, responses)
+
+	scenario.server = server
+	scenario.watcher = NewWatcher(client)
+}
+
+func TestWatcher(test *testing.T) {
+	scenarios := []testWatcher{
+		{
+			name:  "Watch entity changes",
+			key:   raizel.NewDynamicKey("mockCol1", "id", "mockref1"),
+			kinds: []raizel.ChangeKind{raizel.EntityAdded, raizel.EntityRemoved},
+		},
+		{
+			name:  "Watch key changes",
+			key:   raizel.NewDynamicKey("mockCol1", "id", "mockref1"),
+			byKey: true,
+			kinds: []raizel.ChangeKind{raizel.EntityAdded, raizel.EntityRemoved},
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				var (
+					stream raizel.ChangeStream
+					err    error
+				)
+				if scenario.byKey {
+					stream, err = scenario.watcher.WatchKey(context.Background(), scenario.key)
+				} else {
+					stream, err = scenario.watcher.Watch(context.Background(), scenario.key.EntityName())
+				}
+				require.Nil(t, err, "watch error")
+				defer stream.Stop()
+
+				for _, kind := range scenario.kinds {
+					change, err := stream.Next()
+					require.Nil(t, err, "next error")
+					require.Equal(t, kind, change.Kind(), "change kind invalid value")
+					require.Equal(t, scenario.key.EntityName(), change.Key().EntityName(), "change entityname")
+					require.Equal(t, scenario.key.Value(), change.Key().Value(), "change keyvalue")
+
+					var entity struct {
+						Name string `firestore:"name"`
+					}
+					err = change.DataTo(&entity)
+					require.Nil(t, err, "dataTo error")
+					require.Equal(t, "mock name", entity.Name, "entity invalid value")
+				}
+			},
+		)
+	}
+}
@@ -3,9 +3,6 @@ module github.com/rjansen/raizel
 require (
 	cloud.google.com/go/firestore v1.0.0
 	cloud.google.com/go/spanner v1.0.0
-	cloud.google.com/go/storage v1.0.0 // indirect
-	github.com/bitly/go-hostpool v0.0.0-20171023180738-a3a6125de932 // indirect
-	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
 	github.com/gocql/gocql v0.0.0-20181124151448-70385f88b28b
 	github.com/golang/protobuf v1.3.2
 	github.com/google/go-cmp v0.3.0
@@ -14,13 +11,33 @@ require (
 	github.com/lib/pq v1.0.0
 	github.com/scylladb/gocqlx v1.1.0
 	github.com/stretchr/testify v1.3.0
-	golang.org/x/exp v0.0.0-20191002040644-a1355ae1e2c3 // indirect
-	golang.org/x/lint v0.0.0-20190930215403-16217165b5de // indirect
-	golang.org/x/tools v0.0.0-20191010171213-8abd42400456 // indirect
 	google.golang.org/api v0.9.0
 	google.golang.org/genproto v0.0.0-20191009194640-548a555dbc03
 	google.golang.org/grpc v1.21.1
 	gopkg.in/DATA-DOG/go-sqlmock.v1 v1.3.0
 )
 
-go 1.13
+require (
+	cloud.google.com/go v0.46.3 // indirect
+	cloud.google.com/go/storage v1.0.0 // indirect
+	github.com/bitly/go-hostpool v0.0.0-20171023180738-a3a6125de932 // indirect
+	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/golang/snappy v0.0.0-20170215233205-553a64147049 // indirect
+	github.com/googleapis/gax-go/v2 v2.0.5 // indirect
+	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
+	github.com/hashicorp/golang-lru v0.5.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+	go.opencensus.io v0.22.0 // indirect
+	golang.org/x/exp v0.0.0-20191002040644-a1355ae1e2c3 // indirect
+	golang.org/x/lint v0.0.0-20190930215403-16217165b5de // indirect
+	golang.org/x/net v0.0.0-20190620200207-3b0461eec859 // indirect
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45 // indirect
+	golang.org/x/sys v0.0.0-20190624142023-c5567b49c5d0 // indirect
+	golang.org/x/text v0.3.2 // indirect
+	golang.org/x/tools v0.0.0-20191010171213-8abd42400456 // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
+)
+
+go 1.18
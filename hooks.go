@@ -0,0 +1,95 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// BeforeSetter is implemented by entities that normalize themselves before
+// they are set.
+type BeforeSetter interface {
+	BeforeSet(context.Context) error
+}
+
+// AfterGetter is implemented by entities that complete themselves after
+// they are loaded.
+type AfterGetter interface {
+	AfterGet(context.Context) error
+}
+
+// BeforeDeleter is implemented by entities that check their deletion, the
+// stored entity is loaded with the type registered with RegisterEntity.
+type BeforeDeleter interface {
+	BeforeDelete(context.Context) error
+}
+
+// Validator is implemented by entities that refuse invalid values, it runs
+// after BeforeSet.
+type Validator interface {
+	Validate() error
+}
+
+type hooksAppliedContextKey struct{}
+
+// withHooksApplied returns a copy of ctx telling the wrapped repositories
+// that a decorator already ran the entity hooks.
+func withHooksApplied(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hooksAppliedContextKey{}, true)
+}
+
+func hooksApplied(ctx context.Context) bool {
+	applied, _ := ctx.Value(hooksAppliedContextKey{}).(bool)
+	return applied
+}
+
+// BeforeSet runs the BeforeSet and Validate hooks of the entity, the
+// repositories call it before writing the entity and abort on error.
+func BeforeSet(ctx context.Context, entity Entity) error {
+	if hooksApplied(ctx) {
+		return nil
+	}
+	if setter, ok := entity.(BeforeSetter); ok {
+		if err := setter.BeforeSet(ctx); err != nil {
+			return err
+		}
+	}
+	if validator, ok := entity.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// AfterGet runs the AfterGet hook of the entity, the repositories call it
+// after loading the entity.
+func AfterGet(ctx context.Context, entity Entity) error {
+	if hooksApplied(ctx) {
+		return nil
+	}
+	if getter, ok := entity.(AfterGetter); ok {
+		return getter.AfterGet(ctx)
+	}
+	return nil
+}
+
+// BeforeDelete runs the BeforeDelete hook of the stored entity of the key
+// when its registered type implements BeforeDeleter, the repositories call
+// it before deleting the key. The entity is loaded without its AfterGet hook
+// and a missing entity is not checked.
+func BeforeDelete(ctx context.Context, repository Repository, key EntityKey) error {
+	if hooksApplied(ctx) {
+		return nil
+	}
+	descriptor, found := EntityDescriptor(key.EntityName())
+	if !found || !reflect.PtrTo(descriptor.Type).Implements(reflect.TypeOf((*BeforeDeleter)(nil)).Elem()) {
+		return nil
+	}
+	entity := reflect.New(descriptor.Type).Interface()
+	if err := repository.Get(withHooksApplied(ctx), key, entity); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return entity.(BeforeDeleter).BeforeDelete(ctx)
+}
@@ -0,0 +1,119 @@
+package raizel_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errInvalidEmail = errors.New("err_invalidemail")
+	errLockedUser   = errors.New("err_lockeduser")
+)
+
+type hookedUser struct {
+	_      struct{} `raizel:"hooked_users,entity"`
+	ID     string   `raizel:"id,key"`
+	Email  string   `raizel:"email,encrypt"`
+	Locked bool     `raizel:"locked"`
+	Domain string   `raizel:"-"`
+}
+
+func (u *hookedUser) BeforeSet(context.Context) error {
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	return nil
+}
+
+func (u *hookedUser) Validate() error {
+	if !strings.Contains(u.Email, "@") {
+		return errInvalidEmail
+	}
+	return nil
+}
+
+func (u *hookedUser) AfterGet(context.Context) error {
+	u.Domain = u.Email[strings.Index(u.Email, "@")+1:]
+	return nil
+}
+
+func (u *hookedUser) BeforeDelete(context.Context) error {
+	if u.Locked {
+		return errLockedUser
+	}
+	return nil
+}
+
+// deletedNote fails every load with its AfterGet hook.
+type deletedNote struct {
+	_    struct{} `raizel:"deleted_notes,entity"`
+	ID   string   `raizel:"id,key"`
+	Text string   `raizel:"text"`
+}
+
+func (n *deletedNote) AfterGet(context.Context) error {
+	return errors.New("err_unexpectedafterget")
+}
+
+func (n *deletedNote) BeforeDelete(context.Context) error {
+	return nil
+}
+
+func TestBeforeDeleteSkipsAfterGet(test *testing.T) {
+	_, err := raizel.RegisterEntity(deletedNote{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = context.Background()
+		repository = memory.NewRepository()
+		key        = raizel.NewDynamicKey("deleted_notes", "id", "note1")
+	)
+	require.Nil(test, repository.Set(ctx, key, &deletedNote{ID: "note1", Text: "mock"}), "set error")
+	require.Nil(test, raizel.BeforeDelete(ctx, repository, key), "before delete error")
+	require.Nil(test, repository.Delete(ctx, key), "delete error")
+	exists, err := raizel.Exists(ctx, repository, key)
+	require.Nil(test, err, "exists error")
+	require.False(test, exists, "deleted entity stored")
+}
+
+func TestHooks(test *testing.T) {
+	_, err := raizel.RegisterEntity(hookedUser{})
+	require.Nil(test, err, "register error")
+	provider := raizel.NewStaticKeyProvider("v1", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)})
+	for name, repository := range map[string]raizel.Repository{
+		"memory":    memory.NewRepository(),
+		"encrypted": raizel.NewEncryptedRepository(memory.NewRepository(), provider),
+	} {
+		test.Run(name, func(t *testing.T) {
+			var (
+				ctx    = context.Background()
+				key    = raizel.NewDynamicKey("hooked_users", "id", "user1")
+				locked = raizel.NewDynamicKey("hooked_users", "id", "user2")
+				result hookedUser
+			)
+			err := repository.Set(ctx, key, &hookedUser{ID: "user1", Email: "invalid"})
+			require.Equal(t, errInvalidEmail, err, "validate error")
+			require.Equal(t, raizel.ErrNotFound, repository.Get(ctx, key, &result), "invalid entity stored")
+
+			user := hookedUser{ID: "user1", Email: "  Mock@Raizel.IO "}
+			require.Nil(t, repository.Set(ctx, key, &user), "set error")
+			require.Equal(t, "mock@raizel.io", user.Email, "before set email")
+			require.Nil(t, repository.Get(ctx, key, &result), "get error")
+			require.Equal(t, "mock@raizel.io", result.Email, "stored email")
+			require.Equal(t, "raizel.io", result.Domain, "after get domain")
+
+			require.Nil(t, repository.Set(ctx, locked, &hookedUser{ID: "user2", Email: "locked@raizel.io", Locked: true}), "set locked error")
+			require.Equal(t, errLockedUser, repository.Delete(ctx, locked), "before delete error")
+			require.Nil(t, repository.Get(ctx, locked, &result), "locked entity deleted")
+			require.Equal(t, errLockedUser, raizel.DeleteCascade(ctx, repository, locked), "cascade before delete error")
+			require.Nil(t, repository.Get(ctx, locked, &result), "locked entity cascade deleted")
+			require.Nil(t, repository.Delete(ctx, key), "delete error")
+			require.Equal(t, raizel.ErrNotFound, repository.Get(ctx, key, &result), "deleted entity stored")
+			require.Nil(t, repository.Delete(ctx, key), "delete missing error")
+		})
+	}
+}
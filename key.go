@@ -0,0 +1,232 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	entityTag = "entity"
+	keyTag    = "key"
+)
+
+var (
+	ErrKeyMismatch = errors.New("err_keymismatch")
+
+	compositeEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`)
+)
+
+// Keyer is implemented by entities that build their own key, KeyOf prefers it
+// over the struct tags.
+type Keyer interface {
+	Key() EntityKey
+}
+
+// CompositeKey is the key of an entity with many key fields. Name and Value
+// join the names and values with commas for the backends that store a single
+// value key, Value escapes the commas and backslashes of the values so
+// different values never join to the same key.
+type CompositeKey interface {
+	EntityKey
+	Names() []string
+	Values() []interface{}
+}
+
+type compositeEntityKey struct {
+	entityName string
+	names      []string
+	values     []interface{}
+}
+
+func (key compositeEntityKey) EntityName() string {
+	return key.entityName
+}
+
+func (key compositeEntityKey) Name() string {
+	return strings.Join(key.names, ",")
+}
+
+func (key compositeEntityKey) Value() interface{} {
+	values := make([]string, len(key.values))
+	for index, value := range key.values {
+		values[index] = compositeEscaper.Replace(fmt.Sprint(value))
+	}
+	return strings.Join(values, ",")
+}
+
+func (key compositeEntityKey) Names() []string {
+	return key.names
+}
+
+func (key compositeEntityKey) Values() []interface{} {
+	return key.values
+}
+
+func NewCompositeKey(entityName string, names []string, values []interface{}) CompositeKey {
+	return compositeEntityKey{entityName: entityName, names: names, values: values}
+}
+
+// KeyParts returns the names and values of the key, a single name and value
+// when the key is not a CompositeKey. The parts of a tenant or child key are
+// the parts of the key it wraps, KeyPathParts adds the parent parts.
+func KeyParts(key EntityKey) ([]string, []interface{}) {
+	if tenantKey, ok := key.(tenantEntityKey); ok {
+		return KeyParts(tenantKey.EntityKey)
+	}
+	if childKey, ok := key.(childEntityKey); ok {
+		return KeyParts(childKey.EntityKey)
+	}
+	if composite, ok := key.(CompositeKey); ok {
+		return composite.Names(), composite.Values()
+	}
+	return []string{key.Name()}, []interface{}{key.Value()}
+}
+
+func entityType(entity Entity) (reflect.Type, error) {
+	valueType := reflect.TypeOf(entity)
+	for valueType != nil && valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if valueType == nil || valueType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a struct", ErrInvalidEntity, entity)
+	}
+	return valueType, nil
+}
+
+// KeyOf returns the key of the entity, from its Key method when it is a Keyer
+// or from the fields tagged as keys of its Descriptor. Many key fields return
+// a CompositeKey.
+func KeyOf(entity Entity) (EntityKey, error) {
+	if keyer, ok := entity.(Keyer); ok {
+		return keyer.Key(), nil
+	}
+	descriptor, found := DescriptorOf(entity)
+	if !found {
+		var err error
+		if descriptor, err = Describe(entity); err != nil {
+			return nil, err
+		}
+	}
+	return descriptor.Key(entity)
+}
+
+// CheckKey returns ErrKeyMismatch when the key differs from the key of the
+// entity, entities without a derivable key, the values that are not structs
+// and the structs without key fields, are not checked.
+func CheckKey(key EntityKey, entity Entity) error {
+	entityKey, err := KeyOf(entity)
+	if err != nil {
+		if _, typeErr := entityType(entity); typeErr != nil || errors.Is(err, ErrMissingKey) {
+			return nil
+		}
+		return err
+	}
+	if key.Name() != entityKey.Name() || fmt.Sprint(key.Value()) != fmt.Sprint(entityKey.Value()) {
+		return fmt.Errorf(
+			"%w: key %s=%v, entity %s=%v", ErrKeyMismatch,
+			key.Name(), key.Value(), entityKey.Name(), entityKey.Value(),
+		)
+	}
+	return nil
+}
+
+// Save sets the entity with the key returned by KeyOf.
+func Save(ctx context.Context, repository Repository, entity Entity) error {
+	key, err := KeyOf(entity)
+	if err != nil {
+		return err
+	}
+	return repository.Set(ctx, key, entity)
+}
+
+// Remove deletes the entity with the key returned by KeyOf.
+func Remove(ctx context.Context, repository Repository, entity Entity) error {
+	key, err := KeyOf(entity)
+	if err != nil {
+		return err
+	}
+	return repository.Delete(ctx, key)
+}
+
+type keyCheckedRepository struct {
+	Repository
+}
+
+// NewKeyCheckedRepository returns a repository that refuses to Set an entity
+// whose key fields differ from the key and to Patch the key fields. The
+// optional interfaces of the repository are forwarded, the transaction
+// repositories are key checked too.
+func NewKeyCheckedRepository(repository Repository) Repository {
+	return keyCheckedRepository{Repository: repository}
+}
+
+func (r keyCheckedRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	if err := CheckKey(key, entity); err != nil {
+		return err
+	}
+	return r.Repository.Set(ctx, key, entity)
+}
+
+func (r keyCheckedRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	if err := CheckUpdates(key, updates); err != nil {
+		return err
+	}
+	return Patch(ctx, r.Repository, key, updates...)
+}
+
+func (r keyCheckedRepository) DeleteCascade(ctx context.Context, key EntityKey) error {
+	return DeleteCascade(ctx, r.Repository, key)
+}
+
+func (r keyCheckedRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	return Exists(ctx, r.Repository, key)
+}
+
+func (r keyCheckedRepository) Count(ctx context.Context, query Query) (int64, error) {
+	return Count(ctx, r.Repository, query)
+}
+
+func (r keyCheckedRepository) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	return Aggregate(ctx, r.Repository, aggregation)
+}
+
+func (r keyCheckedRepository) Query(ctx context.Context, query Query) (Iterator, error) {
+	queryable, ok := r.Repository.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	return queryable.Query(ctx, query)
+}
+
+func (r keyCheckedRepository) Page(ctx context.Context, query Query, pageToken string) (PageIterator, error) {
+	pageable, ok := r.Repository.(Pageable)
+	if !ok {
+		return nil, ErrPageUnsupported
+	}
+	return pageable.Page(ctx, query, pageToken)
+}
+
+func (r keyCheckedRepository) Watch(ctx context.Context, entityName string) (ChangeStream, error) {
+	watcher, ok := r.Repository.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	return watcher.Watch(ctx, entityName)
+}
+
+func (r keyCheckedRepository) WatchKey(ctx context.Context, key EntityKey) (ChangeStream, error) {
+	watcher, ok := r.Repository.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	return watcher.WatchKey(ctx, key)
+}
+
+func (r keyCheckedRepository) Transaction(ctx context.Context, fn func(context.Context, Repository) error) error {
+	return Transaction(ctx, r.Repository, func(ctx context.Context, tx Repository) error {
+		return fn(ctx, keyCheckedRepository{Repository: tx})
+	})
+}
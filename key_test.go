@@ -0,0 +1,215 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyedEntity struct {
+	_    struct{} `raizel:"customers,entity"`
+	ID   string   `raizel:"id,key"`
+	Name string
+}
+
+type compositeEntity struct {
+	Tenant string `raizel:",key" db:"tenant_id"`
+	Number int    `raizel:"number,key"`
+}
+
+type OrderItem struct {
+	Item string `raizel:",key"`
+}
+
+type keyerEntity struct {
+	ID string
+}
+
+func (e keyerEntity) Key() EntityKey {
+	return NewDynamicKey("keyers", "code", "keyer-"+e.ID)
+}
+
+type testKeyOf struct {
+	name       string
+	entity     Entity
+	entityName string
+	keyName    string
+	value      interface{}
+	parts      []interface{}
+	err        error
+}
+
+func TestKeyOf(test *testing.T) {
+	scenarios := []testKeyOf{
+		{
+			name:       "Key of the tagged field and entity name",
+			entity:     &keyedEntity{ID: "mock1"},
+			entityName: "customers",
+			keyName:    "id",
+			value:      "mock1",
+			parts:      []interface{}{"mock1"},
+		},
+		{
+			name:       "Composite key of many tagged fields",
+			entity:     compositeEntity{Tenant: "tenant1", Number: 7},
+			entityName: "composite_entity",
+			keyName:    "tenant_id,number",
+			value:      "tenant1,7",
+			parts:      []interface{}{"tenant1", 7},
+		},
+		{
+			name:       "Key with the lower case field name",
+			entity:     &OrderItem{Item: "item1"},
+			entityName: "order_item",
+			keyName:    "item",
+			value:      "item1",
+			parts:      []interface{}{"item1"},
+		},
+		{
+			name:       "Key of a Keyer",
+			entity:     keyerEntity{ID: "mock1"},
+			entityName: "keyers",
+			keyName:    "code",
+			value:      "keyer-mock1",
+			parts:      []interface{}{"keyer-mock1"},
+		},
+		{
+			name:   "Error when no field is tagged as key",
+			entity: &struct{ ID string }{},
+			err:    ErrMissingKey,
+		},
+		{
+			name:   "Error when the entity is not a struct",
+			entity: "mock1",
+			err:    ErrInvalidEntity,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				key, err := KeyOf(scenario.entity)
+				require.True(t, errors.Is(err, scenario.err), "keyof error %v", err)
+				if scenario.err != nil {
+					return
+				}
+				require.Equal(t, scenario.entityName, key.EntityName(), "key entity name")
+				require.Equal(t, scenario.keyName, key.Name(), "key name")
+				require.Equal(t, scenario.value, key.Value(), "key value")
+
+				_, values := KeyParts(NewTenantKey("tenant", key))
+				require.Equal(t, scenario.parts, values, "key parts")
+			},
+		)
+	}
+}
+
+func TestCompositeKeyValue(test *testing.T) {
+	var (
+		names = []string{"a", "b"}
+		left  = NewCompositeKey("entity", names, []interface{}{"a,b", "c"})
+		right = NewCompositeKey("entity", names, []interface{}{"a", "b,c"})
+	)
+	require.Equal(test, `a\,b,c`, left.Value(), "escaped left value")
+	require.Equal(test, `a,b\,c`, right.Value(), "escaped right value")
+	require.Equal(test, `a\\,b`, NewCompositeKey("entity", names, []interface{}{`a\`, "b"}).Value(), "escaped backslash")
+}
+
+func TestCheckKey(test *testing.T) {
+	entity := &keyedEntity{ID: "mock1"}
+	require.Nil(test, CheckKey(NewDynamicKey("customers", "id", "mock1"), entity), "matching key error")
+	require.True(
+		test,
+		errors.Is(CheckKey(NewDynamicKey("customers", "id", "mock2"), entity), ErrKeyMismatch),
+		"mismatched key error",
+	)
+	require.Nil(test, CheckKey(NewDynamicKey("any", "id", "mock2"), struct{}{}), "unkeyed entity error")
+	require.Nil(test, CheckKey(NewDynamicKey("any", "id", "mock2"), map[string]interface{}{}), "map entity error")
+	require.True(
+		test,
+		errors.Is(CheckKey(NewDynamicKey("customers", "id", "mock1"), (*keyedEntity)(nil)), ErrInvalidEntity),
+		"nil entity error",
+	)
+
+	var (
+		recorder   = &keyRecorderRepository{}
+		repository = NewKeyCheckedRepository(recorder)
+	)
+	err := repository.Set(context.Background(), NewDynamicKey("customers", "id", "mock2"), entity)
+	require.True(test, errors.Is(err, ErrKeyMismatch), "checked set error")
+	require.Empty(test, recorder.keys, "mismatched key was written")
+}
+
+type patchRecorderRepository struct {
+	queryRecorderRepository
+	updates []Update
+}
+
+func (r *patchRecorderRepository) Patch(_ context.Context, key EntityKey, updates ...Update) error {
+	r.keys = append(r.keys, key)
+	r.updates = append(r.updates, updates...)
+	return nil
+}
+
+func (r *patchRecorderRepository) Exists(_ context.Context, key EntityKey) (bool, error) {
+	r.keys = append(r.keys, key)
+	return true, nil
+}
+
+func (r *patchRecorderRepository) Count(_ context.Context, query Query) (int64, error) {
+	r.queries = append(r.queries, query)
+	return 7, nil
+}
+
+func TestKeyCheckedRepositoryForward(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		recorder   = new(patchRecorderRepository)
+		repository = NewKeyCheckedRepository(recorder)
+		key        = NewDynamicKey("customers", "id", "mock1")
+		query      = NewQuery("customers")
+	)
+	require.Nil(test, Patch(ctx, repository, key, Assign("Name", "mock")), "patch error")
+	require.Equal(test, []Update{Assign("Name", "mock")}, recorder.updates, "forwarded updates")
+	err := Patch(ctx, repository, key, Assign("id", "mock2"))
+	require.True(test, errors.Is(err, ErrInvalidArgument), "patch key error")
+	require.Len(test, recorder.updates, 1, "key update was forwarded")
+
+	count, err := Count(ctx, repository, query)
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(7), count, "count result")
+	exists, err := Exists(ctx, repository, key)
+	require.Nil(test, err, "exists error")
+	require.True(test, exists, "exists result")
+	_, err = repository.(Queryable).Query(ctx, query)
+	require.Nil(test, err, "query error")
+	require.Equal(test, []Query{query, query}, recorder.queries, "forwarded queries")
+	_, err = repository.(Watcher).Watch(ctx, "customers")
+	require.Equal(test, ErrWatchUnsupported, err, "watch error")
+	_, err = Aggregate(ctx, repository, NewAggregation(query, CountAll("total")))
+	require.Equal(test, ErrAggregateUnsupported, err, "aggregate error")
+
+	err = Transaction(ctx, repository, func(ctx context.Context, tx Repository) error {
+		return tx.Set(ctx, NewDynamicKey("customers", "id", "mock2"), &keyedEntity{ID: "mock1"})
+	})
+	require.True(test, errors.Is(err, ErrKeyMismatch), "transaction set error")
+	require.Len(test, recorder.keys, 2, "forwarded keys")
+}
+
+func TestSaveAndRemove(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = &keyRecorderRepository{}
+		entity     = &keyedEntity{ID: "mock1"}
+	)
+	require.Nil(test, Save(ctx, repository, entity), "save error")
+	require.Nil(test, Remove(ctx, repository, entity), "remove error")
+	require.Len(test, repository.keys, 2, "recorded keys")
+	for _, key := range repository.keys {
+		require.Equal(test, NewDynamicKey("customers", "id", "mock1"), key, "recorded key")
+	}
+	require.True(test, errors.Is(Save(ctx, repository, "mock1"), ErrInvalidEntity), "save invalid error")
+}
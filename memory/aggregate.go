@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/rjansen/raizel"
+)
+
+// Aggregate streams the entities selected by the query filters into a
+// raizel.Accumulator, a field unknown by the entity is a nil value.
+func (r *repository) Aggregate(ctx context.Context, aggregation raizel.Aggregation) ([]raizel.AggregateResult, error) {
+	query := aggregation.Query
+	query.Orders = nil
+	values, err := r.entitiesOf(query)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		accumulator = raizel.NewAccumulator(aggregation)
+		fields      = aggregation.Fields()
+	)
+	for _, value := range values {
+		row := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			row[field], _ = fieldValue(reflect.ValueOf(value), field)
+		}
+		if err := accumulator.Add(row); err != nil {
+			return nil, err
+		}
+	}
+	return accumulator.Results(), nil
+}
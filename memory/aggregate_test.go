@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+type testAggregate struct {
+	name        string
+	aggregation raizel.Aggregation
+	results     []raizel.AggregateResult
+	err         error
+}
+
+func TestAggregate(test *testing.T) {
+	scenarios := []testAggregate{
+		{
+			name: "Aggregate every entity",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity"),
+				raizel.CountAll("total"),
+				raizel.Sum("age", "ages"),
+				raizel.Avg("age", "average"),
+				raizel.Min("name", "first"),
+				raizel.Max("age", "oldest"),
+			),
+			results: []raizel.AggregateResult{
+				{
+					Group: map[string]interface{}{},
+					Values: map[string]interface{}{
+						"total": int64(4), "ages": 80.0, "average": 20.0, "first": "Mock Four", "oldest": 30,
+					},
+				},
+			},
+		},
+		{
+			name: "Aggregate the filtered entities by group",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity").Where("age", raizel.GreaterEqual, 20).OrderBy("name", raizel.Desc),
+				raizel.CountAll("total"),
+				raizel.Max("name", "last"),
+			).GroupedBy("age"),
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{"age": 20},
+					Values: map[string]interface{}{"total": int64(2), "last": "Mock Three"},
+				},
+				{
+					Group:  map[string]interface{}{"age": 30},
+					Values: map[string]interface{}{"total": int64(1), "last": "Mock One"},
+				},
+			},
+		},
+		{
+			name: "Aggregate without matching entities",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity").Where("age", raizel.Greater, 99),
+				raizel.CountAll("total"),
+				raizel.Sum("age", "ages"),
+			),
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{},
+					Values: map[string]interface{}{"total": int64(0), "ages": nil},
+				},
+			},
+		},
+		{
+			name: "Error when the sum field is not a number",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity"), raizel.Sum("name", "names"),
+			),
+			err: raizel.ErrInvalidArgument,
+		},
+		{
+			name: "Error when the aliases are repeated",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity"), raizel.CountAll("total"), raizel.Sum("age", "total"),
+			),
+			err: raizel.ErrInvalidArgument,
+		},
+	}
+	repository := newQueryRepository(test)
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				results, err := raizel.Aggregate(context.Background(), repository, scenario.aggregation)
+				require.True(t, errors.Is(err, scenario.err), "aggregate error %v", err)
+				require.Equal(t, scenario.results, results, "aggregate results")
+			},
+		)
+	}
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/rjansen/raizel"
+)
+
+func (r *repository) Exists(ctx context.Context, key raizel.EntityKey) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.entities[key.EntityName()][storageKey(key)]
+	return exists, nil
+}
+
+// Count counts the entities selected by the query filters of the query
+// tenant.
+func (r *repository) Count(ctx context.Context, query raizel.Query) (int64, error) {
+	query.Orders = nil
+	values, err := r.entitiesOf(query)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(values)), nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+type testCount struct {
+	name  string
+	query raizel.Query
+	count int64
+	err   error
+}
+
+func TestCount(test *testing.T) {
+	scenarios := []testCount{
+		{
+			name:  "Count every entity",
+			query: raizel.NewQuery("entity"),
+			count: 4,
+		},
+		{
+			name:  "Count ignores orders and limit",
+			query: raizel.NewQuery("entity").Where("age", raizel.Equal, 20).OrderBy("name", raizel.Asc).WithLimit(1),
+			count: 2,
+		},
+		{
+			name:  "Count of an unknown entity",
+			query: raizel.NewQuery("unknown"),
+		},
+		{
+			name:  "Error when the filter field is unknown",
+			query: raizel.NewQuery("entity").Where("unknown", raizel.Equal, 1),
+			err:   ErrUnknownField,
+		},
+	}
+	repository := newQueryRepository(test)
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				count, err := raizel.Count(context.Background(), repository, scenario.query)
+				require.True(t, errors.Is(err, scenario.err), "count error %v", err)
+				require.Equal(t, scenario.count, count, "count result")
+			},
+		)
+	}
+}
+
+func TestExists(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = newQueryRepository(test)
+	)
+	exists, err := raizel.Exists(ctx, repository, raizel.NewDynamicKey("entity", "id", "mock1"))
+	require.Nil(test, err, "exists error")
+	require.True(test, exists, "stored entity")
+
+	exists, err = raizel.Exists(ctx, repository, raizel.NewDynamicKey("entity", "id", "mock9"))
+	require.Nil(test, err, "missing exists error")
+	require.False(test, exists, "missing entity")
+
+	parent := raizel.NewDynamicKey("entity", "id", "mock1")
+	exists, err = raizel.Exists(ctx, repository, raizel.NewChildKey(parent, raizel.NewDynamicKey("entity", "id", "mock1")))
+	require.Nil(test, err, "child exists error")
+	require.False(test, exists, "missing child entity")
+}
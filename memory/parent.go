@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel"
+)
+
+// childKey stores the entities of a raizel.ChildKey apart from the entities
+// with the same key value under other parents or tenants.
+type childKey struct {
+	entityName string
+	tenant     string
+	parent     string
+	value      interface{}
+}
+
+// tenantKey stores the entities of a raizel.TenantKey apart from the
+// entities with the same key value of other tenants.
+type tenantKey struct {
+	tenant string
+	value  interface{}
+}
+
+// keyPath returns the entityName/value path of the key and its ancestors.
+func keyPath(key raizel.EntityKey) string {
+	var (
+		path  = raizel.KeyPath(key)
+		parts = make([]string, len(path))
+	)
+	for index, pathKey := range path {
+		parts[index] = fmt.Sprintf("%s/%v", pathKey.EntityName(), pathKey.Value())
+	}
+	return strings.Join(parts, "/")
+}
+
+// storageKey returns the map key of the entity of the key, the key value for
+// the root keys without tenant.
+func storageKey(key raizel.EntityKey) interface{} {
+	var (
+		tenant, _       = raizel.TenantOf(key)
+		parent, isChild = raizel.ParentOf(key)
+	)
+	if isChild {
+		return childKey{entityName: key.EntityName(), tenant: tenant, parent: keyPath(parent), value: key.Value()}
+	}
+	if tenant != "" {
+		return tenantKey{tenant: tenant, value: key.Value()}
+	}
+	return key.Value()
+}
+
+// storedTenant returns the tenant of a map key returned by storageKey.
+func storedTenant(stored interface{}) string {
+	switch stored := stored.(type) {
+	case childKey:
+		return stored.tenant
+	case tenantKey:
+		return stored.tenant
+	default:
+		return ""
+	}
+}
+
+// DeleteCascade deletes the entity of the key and every child stored under
+// it, the descendants of its children included, of the key tenant. The BeforeDelete hook runs
+// for the entity of the key only, under the lock of the delete.
+func (r *repository) DeleteCascade(ctx context.Context, key raizel.EntityKey) error {
+	var (
+		path      = keyPath(key)
+		prefix    = path + "/"
+		tenant, _ = raizel.TenantOf(key)
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := raizel.BeforeDelete(ctx, lockedRepository{r}, key); err != nil {
+		return err
+	}
+	for child, entityKey := range r.children {
+		if child.tenant == tenant && (child.parent == path || strings.HasPrefix(child.parent, prefix)) {
+			r.delete(entityKey, child)
+		}
+	}
+	r.delete(key, storageKey(key))
+	return nil
+}
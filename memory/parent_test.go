@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryChildren(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = NewRepository()
+		order1     = raizel.NewDynamicKey("orders", "id", "order1")
+		order2     = raizel.NewDynamicKey("orders", "id", "order2")
+		itemKey    = func(order raizel.EntityKey, id string) raizel.EntityKey {
+			return raizel.NewChildKey(order, raizel.NewDynamicKey("items", "id", id))
+		}
+	)
+	require.Implements(test, (*raizel.CascadeDeleter)(nil), repository, "invalid cascade deleter type")
+	require.Nil(test, repository.Set(ctx, order1, &testEntity{ID: "order1"}), "set order1 error")
+	require.Nil(test, repository.Set(ctx, order2, &testEntity{ID: "order2"}), "set order2 error")
+	require.Nil(test, repository.Set(ctx, itemKey(order1, "item1"), &testEntity{ID: "item1", Age: 1}), "set item error")
+	require.Nil(test, repository.Set(ctx, itemKey(order1, "item2"), &testEntity{ID: "item2", Age: 2}), "set item error")
+	require.Nil(test, repository.Set(ctx, itemKey(order2, "item1"), &testEntity{ID: "item1", Age: 3}), "set item error")
+
+	var item testEntity
+	require.Nil(test, repository.Get(ctx, itemKey(order2, "item1"), &item), "get item error")
+	require.Equal(test, 3, item.Age, "item of the parent")
+
+	iterator, err := repository.Query(ctx, raizel.Children(order1, "items").OrderBy("ID", raizel.Asc))
+	require.Nil(test, err, "query children error")
+	var children []testEntity
+	for {
+		var child testEntity
+		if err := iterator.Next(ctx, &child); err != nil {
+			require.Equal(test, raizel.ErrIteratorDone, err, "iterator error")
+			break
+		}
+		children = append(children, child)
+	}
+	require.Equal(test, []testEntity{{ID: "item1", Age: 1}, {ID: "item2", Age: 2}}, children, "children")
+
+	require.Nil(test, raizel.DeleteCascade(ctx, repository, order1), "delete cascade error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, order1, &item), "deleted parent")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, itemKey(order1, "item2"), &item), "deleted child")
+	require.Nil(test, repository.Get(ctx, itemKey(order2, "item1"), &item), "child of another parent")
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/rjansen/raizel"
+)
+
+// convert returns the value converted to the field type.
+func convert(value interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(fieldType), nil
+	}
+	converted := reflect.ValueOf(value)
+	if !converted.Type().ConvertibleTo(fieldType) {
+		return reflect.Value{}, fmt.Errorf(
+			"%w: %T is not %s", raizel.ErrInvalidArgument, value, fieldType,
+		)
+	}
+	return converted.Convert(fieldType), nil
+}
+
+func increment(field reflect.Value, delta interface{}) error {
+	deltaValue := reflect.ValueOf(delta)
+	switch {
+	case field.CanInt() && deltaValue.CanInt():
+		field.SetInt(field.Int() + deltaValue.Int())
+	case field.CanUint() && deltaValue.CanUint():
+		field.SetUint(field.Uint() + deltaValue.Uint())
+	case field.CanFloat() && (deltaValue.CanFloat() || deltaValue.CanInt()):
+		converted, err := convert(delta, field.Type())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(field.Float() + converted.Float())
+	default:
+		return fmt.Errorf("%w: increment %s by %T", raizel.ErrInvalidArgument, field.Type(), delta)
+	}
+	return nil
+}
+
+func arrayValues(field reflect.Value, value interface{}) ([]reflect.Value, error) {
+	if field.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: %s is not an array", raizel.ErrInvalidArgument, field.Type())
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	converted := make([]reflect.Value, len(values))
+	for index, elem := range values {
+		elemValue, err := convert(elem, field.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		converted[index] = elemValue
+	}
+	return converted, nil
+}
+
+func transform(field reflect.Value, update raizel.Update) error {
+	switch update.Transform {
+	case raizel.AssignTransform:
+		value, err := convert(update.Value, field.Type())
+		if err != nil {
+			return err
+		}
+		field.Set(value)
+	case raizel.IncrementTransform:
+		return increment(field, update.Value)
+	case raizel.ServerTimestampTransform:
+		value, err := convert(time.Now().UTC(), field.Type())
+		if err != nil {
+			return err
+		}
+		field.Set(value)
+	case raizel.ArrayAppendTransform:
+		values, err := arrayValues(field, update.Value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, values...))
+	case raizel.ArrayRemoveTransform:
+		values, err := arrayValues(field, update.Value)
+		if err != nil {
+			return err
+		}
+		kept := reflect.MakeSlice(field.Type(), 0, field.Len())
+		for index := 0; index < field.Len(); index++ {
+			removed := false
+			for _, value := range values {
+				removed = removed || reflect.DeepEqual(field.Index(index).Interface(), value.Interface())
+			}
+			if !removed {
+				kept = reflect.Append(kept, field.Index(index))
+			}
+		}
+		field.Set(kept)
+	case raizel.DeleteTransform:
+		field.Set(reflect.Zero(field.Type()))
+	default:
+		return fmt.Errorf("%w: transform %d", raizel.ErrInvalidArgument, update.Transform)
+	}
+	return nil
+}
+
+// patch returns a patched copy of the entity value, a dotted field is a path
+// of nested struct fields.
+func patch(current interface{}, updates []raizel.Update) (interface{}, error) {
+	patched := reflect.New(reflect.TypeOf(current)).Elem()
+	patched.Set(reflect.ValueOf(current))
+	for _, update := range updates {
+		field := patched
+		for _, name := range strings.Split(update.Field, ".") {
+			var exists bool
+			if field, exists = structField(field, name); !exists {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownField, update.Field)
+			}
+		}
+		if err := transform(field, update); err != nil {
+			return nil, err
+		}
+	}
+	return patched.Interface(), nil
+}
+
+// Patch applies the updates to a copy of the stored entity. The stored
+// entity is replaced only when every update succeeds, the updates of the key
+// fields return raizel.ErrInvalidArgument.
+func (r *repository) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	if err := raizel.CheckUpdates(key, updates); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var (
+		stored         = storageKey(key)
+		current, found = r.entities[key.EntityName()][stored]
+	)
+	if !found {
+		return raizel.ErrNotFound
+	}
+	value, err := patch(current, updates)
+	if err != nil {
+		return err
+	}
+	r.entities[key.EntityName()][stored] = value
+	r.publish(change{kind: raizel.EntityModified, key: key, value: value})
+	return nil
+}
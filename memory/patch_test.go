@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+type patchedEntity struct {
+	ID        string
+	Counter   int64
+	Score     float64
+	Tags      []string
+	UpdatedAt time.Time
+	Profile   struct {
+		Name string
+	}
+}
+
+type testPatch struct {
+	name    string
+	updates []raizel.Update
+	check   func(*testing.T, patchedEntity)
+	err     error
+}
+
+func TestRepositoryPatch(test *testing.T) {
+	scenarios := []testPatch{
+		{
+			name: "Patch assigned and nested fields",
+			updates: []raizel.Update{
+				raizel.Assign("Profile.Name", "mock"),
+				raizel.Assign("Score", 2),
+			},
+			check: func(t *testing.T, entity patchedEntity) {
+				require.Equal(t, "mock", entity.Profile.Name, "nested field")
+				require.Equal(t, 2.0, entity.Score, "converted field")
+				require.Equal(t, int64(1), entity.Counter, "unchanged field")
+			},
+		},
+		{
+			name: "Patch with transforms",
+			updates: []raizel.Update{
+				raizel.Increment("Counter", 2),
+				raizel.Increment("Score", 0.5),
+				raizel.ArrayAppend("Tags", "c", "a"),
+				raizel.ArrayRemove("Tags", "a"),
+				raizel.ServerTimestamp("UpdatedAt"),
+			},
+			check: func(t *testing.T, entity patchedEntity) {
+				require.Equal(t, int64(3), entity.Counter, "incremented field")
+				require.Equal(t, 1.5, entity.Score, "incremented float field")
+				require.Equal(t, []string{"b", "c"}, entity.Tags, "array field")
+				require.False(t, entity.UpdatedAt.IsZero(), "timestamp field")
+			},
+		},
+		{
+			name:    "Patch a deleted field",
+			updates: []raizel.Update{raizel.DeleteField("Tags")},
+			check: func(t *testing.T, entity patchedEntity) {
+				require.Nil(t, entity.Tags, "deleted field")
+			},
+		},
+		{
+			name:    "Error when the field is unknown",
+			updates: []raizel.Update{raizel.Assign("Unknown", 1), raizel.Increment("Counter", 1)},
+			err:     ErrUnknownField,
+		},
+		{
+			name:    "Error when the update changes the key field",
+			updates: []raizel.Update{raizel.Increment("Counter", 1), raizel.Assign("ID", "mock2")},
+			err:     raizel.ErrInvalidArgument,
+		},
+		{
+			name:    "Error when the increment is not numeric",
+			updates: []raizel.Update{raizel.Increment("Counter", 1), raizel.Increment("Tags", 1)},
+			err:     raizel.ErrInvalidArgument,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					ctx        = context.Background()
+					repository = NewRepository()
+					key        = raizel.NewDynamicKey("entities", "id", "mock1")
+					original   = patchedEntity{ID: "mock1", Counter: 1, Score: 1, Tags: []string{"a", "b"}}
+					entity     patchedEntity
+				)
+				require.Nil(t, repository.Set(ctx, key, &original), "set error")
+				err := raizel.Patch(ctx, repository, key, scenario.updates...)
+				require.True(t, errors.Is(err, scenario.err), "patch error %v", err)
+				require.Nil(t, repository.Get(ctx, key, &entity), "get error")
+				if scenario.err != nil {
+					require.Equal(t, original, entity, "unpatched entity")
+					return
+				}
+				scenario.check(t, entity)
+			},
+		)
+	}
+}
+
+func TestRepositoryPatchNotFound(test *testing.T) {
+	err := NewRepository().Patch(
+		context.Background(), raizel.NewDynamicKey("entities", "id", "mock1"), raizel.Assign("Counter", 1),
+	)
+	require.Equal(test, raizel.ErrNotFound, err, "patch error")
+}
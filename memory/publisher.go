@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rjansen/raizel"
+)
+
+// Publisher is an in-memory raizel.Publisher that keeps the published
+// events, meant for tests of the outbox relay.
+type Publisher struct {
+	mu     sync.Mutex
+	events []raizel.OutboxEvent
+	err    error
+}
+
+func NewPublisher() *Publisher {
+	return new(Publisher)
+}
+
+// Publish keeps the event, or fails with the error set by FailWith.
+func (p *Publisher) Publish(ctx context.Context, event raizel.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// FailWith makes the next publications fail with err, a nil err publishes
+// again.
+func (p *Publisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events returns the published events in publication order, the duplicates
+// of the redelivered events included.
+func (p *Publisher) Events() []raizel.OutboxEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]raizel.OutboxEvent(nil), p.events...)
+}
@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rjansen/raizel"
+)
+
+var (
+	ErrUnknownField  = errors.New("err_unknownfield")
+	ErrInvalidFilter = errors.New("err_invalidfilter")
+	ErrIncomparable  = raizel.ErrIncomparable
+)
+
+var (
+	fieldTags         = []string{raizel.TagName, "db", "firestore", "spanner", "json"}
+	comparedOperators = map[raizel.Operator]func(int) bool{
+		raizel.Equal:        func(c int) bool { return c == 0 },
+		raizel.Less:         func(c int) bool { return c < 0 },
+		raizel.LessEqual:    func(c int) bool { return c <= 0 },
+		raizel.Greater:      func(c int) bool { return c > 0 },
+		raizel.GreaterEqual: func(c int) bool { return c >= 0 },
+	}
+)
+
+// structField returns the struct field named by the raizel, db, firestore,
+// spanner or json tags, or by the case insensitive field name.
+func structField(value reflect.Value, name string) (reflect.Value, bool) {
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	valueType := value.Type()
+	for index := 0; index < valueType.NumField(); index++ {
+		field := valueType.Field(index)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if embedded, found := structField(value.Field(index), name); found {
+				return embedded, true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		for _, tag := range fieldTags {
+			if strings.Split(field.Tag.Get(tag), ",")[0] == name {
+				return value.Field(index), true
+			}
+		}
+		if strings.EqualFold(field.Name, name) {
+			return value.Field(index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// fieldValue returns the value of the struct field found by structField.
+func fieldValue(value reflect.Value, name string) (interface{}, bool) {
+	field, found := structField(value, name)
+	if !found {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+type sliceIterator struct {
+	values        []interface{}
+	nextPageToken string
+}
+
+func (i *sliceIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	if len(i.values) == 0 {
+		return raizel.ErrIteratorDone
+	}
+	value := i.values[0]
+	if err := loadEntity(value, entity); err != nil {
+		return err
+	}
+	i.values = i.values[1:]
+	return nil
+}
+
+func (i *sliceIterator) NextPageToken() string {
+	return i.nextPageToken
+}
+
+func (i *sliceIterator) Stop() {
+	i.values = nil
+}
+
+// entitiesOf returns the stored entities of the query tenant selected by the
+// query filters and sorted by the query orders.
+func (r *repository) entitiesOf(query raizel.Query) ([]interface{}, error) {
+	r.mu.RLock()
+	values := make([]interface{}, 0, len(r.entities[query.EntityName]))
+	for stored, value := range r.entities[query.EntityName] {
+		if storedTenant(stored) != query.Tenant {
+			continue
+		}
+		if query.Parent != nil {
+			child, isChild := stored.(childKey)
+			if !isChild || child.parent != keyPath(query.Parent) {
+				continue
+			}
+		}
+		values = append(values, value)
+	}
+	r.mu.RUnlock()
+
+	var (
+		selected = values[:0]
+		field    = func(value interface{}, name string) (interface{}, error) {
+			fieldValue, found := fieldValue(reflect.ValueOf(value), name)
+			if !found {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+			}
+			return fieldValue, nil
+		}
+	)
+	for _, value := range values {
+		matches := true
+		for _, filter := range query.Filters {
+			operator, valid := comparedOperators[filter.Operator]
+			if !valid {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, filter.Operator)
+			}
+			fieldValue, err := field(value, filter.Field)
+			if err != nil {
+				return nil, err
+			}
+			compared, err := raizel.CompareValues(fieldValue, filter.Value)
+			if err != nil {
+				return nil, err
+			}
+			if matches = operator(compared); !matches {
+				break
+			}
+		}
+		if matches {
+			selected = append(selected, value)
+		}
+	}
+
+	var sortErr error
+	sort.SliceStable(selected, func(i, j int) bool {
+		for _, order := range query.Orders {
+			a, err := field(selected[i], order.Field)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			b, err := field(selected[j], order.Field)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			compared, err := raizel.CompareValues(a, b)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			if compared != 0 {
+				return (compared < 0) == (order.Direction == raizel.Asc)
+			}
+		}
+		return false
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	return selected, nil
+}
+
+// Query returns the stored entities of the query tenant, the entities
+// without orders are returned in no particular order.
+func (r *repository) Query(ctx context.Context, query raizel.Query) (raizel.Iterator, error) {
+	values, err := r.entitiesOf(query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && len(values) > query.Limit {
+		values = values[:query.Limit]
+	}
+	return &sliceIterator{values: values}, nil
+}
+
+func orderValues(value interface{}, orders []raizel.Order) []interface{} {
+	values := make([]interface{}, len(orders))
+	for index, order := range orders {
+		values[index], _ = fieldValue(reflect.ValueOf(value), order.Field)
+	}
+	return values
+}
+
+// after reports whether the order values of value come after the cursor.
+func after(value interface{}, orders []raizel.Order, cursor []interface{}) (bool, error) {
+	for index, current := range orderValues(value, orders) {
+		compared, err := raizel.CompareValues(current, cursor[index])
+		if err != nil {
+			return false, err
+		}
+		if compared != 0 {
+			return (compared > 0) == (orders[index].Direction == raizel.Asc), nil
+		}
+	}
+	return false, nil
+}
+
+func (r *repository) Page(ctx context.Context, query raizel.Query, pageToken string) (raizel.PageIterator, error) {
+	if len(query.Orders) == 0 {
+		return nil, raizel.ErrUnorderedPage
+	}
+	values, err := r.entitiesOf(query)
+	if err != nil {
+		return nil, err
+	}
+	if pageToken != "" {
+		cursor, err := raizel.DecodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(cursor) != len(query.Orders) {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		start := 0
+		for start < len(values) {
+			isAfter, err := after(values[start], query.Orders, cursor)
+			if err != nil {
+				return nil, err
+			}
+			if isAfter {
+				break
+			}
+			start++
+		}
+		values = values[start:]
+	}
+	var (
+		size     = raizel.PageSize(query)
+		iterator = &sliceIterator{values: values}
+	)
+	if len(values) > size {
+		iterator.values = values[:size]
+	}
+	if len(values) >= size {
+		token, err := raizel.EncodePageToken(orderValues(values[size-1], query.Orders)...)
+		if err != nil {
+			return nil, err
+		}
+		iterator.nextPageToken = token
+	}
+	return iterator, nil
+}
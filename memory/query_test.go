@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueryRepository(t *testing.T) *repository {
+	repository := NewRepository()
+	entities := []testEntity{
+		{ID: "mock1", Name: "Mock One", Age: 30},
+		{ID: "mock2", Name: "Mock Two", Age: 10},
+		{ID: "mock3", Name: "Mock Three", Age: 20},
+		{ID: "mock4", Name: "Mock Four", Age: 20},
+	}
+	for _, entity := range entities {
+		key := raizel.NewDynamicKey("entity", "id", entity.ID)
+		require.Nil(t, repository.Set(context.Background(), key, entity), "set error")
+	}
+	return repository
+}
+
+func iteratorIDs(t *testing.T, iterator raizel.Iterator) []string {
+	ids := []string{}
+	for {
+		var entity testEntity
+		err := iterator.Next(context.Background(), &entity)
+		if err == raizel.ErrIteratorDone {
+			return ids
+		}
+		require.Nil(t, err, "next error")
+		ids = append(ids, entity.ID)
+	}
+}
+
+type testQuery struct {
+	name  string
+	query raizel.Query
+	ids   []string
+	err   error
+}
+
+func TestQuery(test *testing.T) {
+	scenarios := []testQuery{
+		{
+			name:  "Query ordered entities",
+			query: raizel.NewQuery("entity").OrderBy("age", raizel.Desc).OrderBy("id", raizel.Asc),
+			ids:   []string{"mock1", "mock3", "mock4", "mock2"},
+		},
+		{
+			name: "Query filtered entities with limit",
+			query: raizel.NewQuery("entity").Where("age", raizel.GreaterEqual, int64(20)).
+				OrderBy("id", raizel.Asc).WithLimit(2),
+			ids: []string{"mock1", "mock3"},
+		},
+		{
+			name:  "Query unknown entity",
+			query: raizel.NewQuery("missing"),
+			ids:   []string{},
+		},
+		{
+			name:  "Error when the field is unknown",
+			query: raizel.NewQuery("entity").Where("missing", raizel.Equal, "mock1"),
+			err:   ErrUnknownField,
+		},
+		{
+			name:  "Error when the values are incomparable",
+			query: raizel.NewQuery("entity").Where("age", raizel.Equal, "mock1"),
+			err:   ErrIncomparable,
+		},
+		{
+			name:  "Error when the operator is invalid",
+			query: raizel.NewQuery("entity").Where("age", raizel.Operator("!="), 10),
+			err:   ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				iterator, err := newQueryRepository(t).Query(context.Background(), scenario.query)
+				if scenario.err != nil {
+					require.True(t, errors.Is(err, scenario.err), "query error %v", err)
+					return
+				}
+				require.Nil(t, err, "query error")
+				defer iterator.Stop()
+				require.Equal(t, scenario.ids, iteratorIDs(t, iterator), "query ids")
+			},
+		)
+	}
+}
+
+func TestPage(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = newQueryRepository(test)
+		query      = raizel.NewQuery("entity").OrderBy("age", raizel.Asc).OrderBy("id", raizel.Asc).WithLimit(2)
+		pages      [][]string
+		token      string
+	)
+	for {
+		iterator, err := repository.Page(ctx, query, token)
+		require.Nil(test, err, "page error")
+		pages = append(pages, iteratorIDs(test, iterator))
+		if token = iterator.NextPageToken(); token == "" {
+			break
+		}
+	}
+	require.Equal(test, [][]string{{"mock2", "mock3"}, {"mock4", "mock1"}, {}}, pages, "pages")
+
+	_, err := repository.Page(ctx, raizel.NewQuery("entity"), "")
+	require.Equal(test, raizel.ErrUnorderedPage, err, "unordered page error")
+
+	_, err = repository.Page(ctx, query, "invalid token")
+	require.Equal(test, raizel.ErrInvalidPageToken, err, "invalid token error")
+}
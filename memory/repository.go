@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/rjansen/raizel"
+)
+
+var (
+	ErrInvalidEntity = errors.New("err_invalidentity")
+)
+
+type repository struct {
+	mu       sync.RWMutex
+	entities map[string]map[interface{}]interface{}
+	children map[childKey]raizel.EntityKey
+	streams  map[*changeStream]struct{}
+}
+
+// NewRepository returns an in-memory raizel.Repository, raizel.Watcher,
+// raizel.Queryable, raizel.Pageable, raizel.CascadeDeleter, raizel.Patcher,
+// raizel.Counter, raizel.Aggregator and raizel.Transactional. It stores a
+// copy of the entity values and is meant for tests.
+func NewRepository() *repository {
+	return &repository{
+		entities: make(map[string]map[interface{}]interface{}),
+		children: make(map[childKey]raizel.EntityKey),
+		streams:  make(map[*changeStream]struct{}),
+	}
+}
+
+func entityValue(entity raizel.Entity) (interface{}, error) {
+	value := reflect.ValueOf(entity)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, ErrInvalidEntity
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, ErrInvalidEntity
+	}
+	return value.Interface(), nil
+}
+
+func loadEntity(stored interface{}, entity raizel.Entity) error {
+	target := reflect.ValueOf(entity)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return ErrInvalidEntity
+	}
+	value := reflect.ValueOf(stored)
+	if !value.Type().AssignableTo(target.Elem().Type()) {
+		return ErrInvalidEntity
+	}
+	target.Elem().Set(value)
+	return nil
+}
+
+func (r *repository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	r.mu.RLock()
+	stored, exists := r.entities[key.EntityName()][storageKey(key)]
+	r.mu.RUnlock()
+	if !exists {
+		return raizel.ErrNotFound
+	}
+	if err := loadEntity(stored, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (r *repository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	value, err := entityValue(entity)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(key, value)
+	return nil
+}
+
+func (r *repository) set(key raizel.EntityKey, value interface{}) {
+	entities, exists := r.entities[key.EntityName()]
+	if !exists {
+		entities = make(map[interface{}]interface{})
+		r.entities[key.EntityName()] = entities
+	}
+	var (
+		kind   = raizel.EntityModified
+		stored = storageKey(key)
+	)
+	if _, exists := entities[stored]; !exists {
+		kind = raizel.EntityAdded
+	}
+	if child, isChild := stored.(childKey); isChild {
+		r.children[child] = key
+	}
+	entities[stored] = value
+	r.publish(change{kind: kind, key: key, value: value})
+}
+
+// Delete checks and deletes the entity under the same lock, so the
+// BeforeDelete hook sees the entity it deletes and must not use the
+// repository.
+func (r *repository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := raizel.BeforeDelete(ctx, lockedRepository{r}, key); err != nil {
+		return err
+	}
+	r.delete(key, storageKey(key))
+	return nil
+}
+
+// lockedRepository reads the entities of a repository whose lock is held.
+type lockedRepository struct {
+	*repository
+}
+
+func (r lockedRepository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	stored, exists := r.entities[key.EntityName()][storageKey(key)]
+	if !exists {
+		return raizel.ErrNotFound
+	}
+	if err := loadEntity(stored, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (r *repository) delete(key raizel.EntityKey, stored interface{}) {
+	value, exists := r.entities[key.EntityName()][stored]
+	if !exists {
+		return
+	}
+	delete(r.entities[key.EntityName()], stored)
+	if child, isChild := stored.(childKey); isChild {
+		delete(r.children, child)
+	}
+	r.publish(change{kind: raizel.EntityRemoved, key: key, value: value})
+}
+
+func (r *repository) Close(ctx context.Context) error {
+	r.mu.Lock()
+	streams := r.streams
+	r.streams = make(map[*changeStream]struct{})
+	r.mu.Unlock()
+	for stream := range streams {
+		stream.stop()
+	}
+	return nil
+}
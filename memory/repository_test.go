@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntity struct {
+	ID   string
+	Name string
+	Age  int
+}
+
+func TestNewRepository(test *testing.T) {
+	repository := NewRepository()
+	require.NotNil(test, repository, "invalid repository instance")
+	require.Implements(test, (*raizel.Repository)(nil), repository, "invalid repository type")
+	require.Implements(test, (*raizel.Watcher)(nil), repository, "invalid watcher type")
+	require.Implements(test, (*raizel.Queryable)(nil), repository, "invalid queryable type")
+	require.Implements(test, (*raizel.Pageable)(nil), repository, "invalid pageable type")
+}
+
+type testRepository struct {
+	name   string
+	key    raizel.EntityKey
+	data   raizel.Entity
+	result raizel.Entity
+	err    error
+}
+
+func TestRepository(test *testing.T) {
+	scenarios := []testRepository{
+		{
+			name:   "Set, Get and Delete entity",
+			key:    raizel.NewDynamicKey("entity", "id", "mock1"),
+			data:   &testEntity{ID: "mock1", Name: "Mock One", Age: 10},
+			result: &testEntity{},
+		},
+		{
+			name:   "Set and Get entity by value",
+			key:    raizel.NewDynamicKey("entity", "id", 2),
+			data:   testEntity{ID: "mock2", Name: "Mock Two", Age: 20},
+			result: &testEntity{},
+		},
+		{
+			name:   "Error when try to Get into an incompatible entity",
+			key:    raizel.NewDynamicKey("entity", "id", "mock3"),
+			data:   &testEntity{ID: "mock3"},
+			result: &struct{ ID string }{},
+			err:    ErrInvalidEntity,
+		},
+		{
+			name: "Error when try to Set a nil entity",
+			key:  raizel.NewDynamicKey("entity", "id", "mock4"),
+			data: (*testEntity)(nil),
+			err:  ErrInvalidEntity,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					ctx        = context.Background()
+					repository = NewRepository()
+				)
+				err := repository.Get(ctx, scenario.key, scenario.result)
+				require.Equal(t, raizel.ErrNotFound, err, "get before set error")
+
+				err = repository.Set(ctx, scenario.key, scenario.data)
+				if scenario.result == nil {
+					require.Equal(t, scenario.err, err, "set error")
+					return
+				}
+				require.Nil(t, err, "set error")
+
+				err = repository.Get(ctx, scenario.key, scenario.result)
+				require.Equal(t, scenario.err, err, "get error")
+				if scenario.err != nil {
+					return
+				}
+				expected, _ := entityValue(scenario.data)
+				actual, _ := entityValue(scenario.result)
+				require.Equal(t, expected, actual, "get invalid result")
+
+				require.Nil(t, repository.Delete(ctx, scenario.key), "delete error")
+				require.Nil(t, repository.Delete(ctx, scenario.key), "delete missing error")
+				err = repository.Get(ctx, scenario.key, scenario.result)
+				require.Equal(t, raizel.ErrNotFound, err, "get after delete error")
+				require.Nil(t, repository.Close(ctx), "close error")
+			},
+		)
+	}
+}
+
+func TestRepositoryTenants(test *testing.T) {
+	var (
+		store      = NewRepository()
+		repository = raizel.NewTenantRepository(store)
+		key        = raizel.NewDynamicKey("entity", "id", "mock1")
+		query      = raizel.NewQuery("entity")
+		tenants    = map[string]testEntity{
+			"tenant1": {ID: "mock1", Name: "Tenant One", Age: 10},
+			"tenant2": {ID: "mock1", Name: "Tenant Two", Age: 20},
+		}
+	)
+	for tenant, entity := range tenants {
+		entity := entity
+		require.Nil(test, repository.Set(raizel.WithTenant(context.Background(), tenant), key, &entity), "set error")
+	}
+	for tenant, entity := range tenants {
+		test.Run(tenant, func(t *testing.T) {
+			var (
+				ctx    = raizel.WithTenant(context.Background(), tenant)
+				result testEntity
+			)
+			require.Nil(t, repository.Get(ctx, key, &result), "get error")
+			require.Equal(t, entity, result, "get invalid value")
+			count, err := raizel.Count(ctx, repository, query)
+			require.Nil(t, err, "count error")
+			require.Equal(t, int64(1), count, "count invalid value")
+			results, err := raizel.Aggregate(ctx, repository, raizel.NewAggregation(query, raizel.Sum("Age", "age")))
+			require.Nil(t, err, "aggregate error")
+			require.Len(t, results, 1, "aggregate results invalid length")
+			require.Equal(t, float64(entity.Age), results[0].Values["age"], "aggregate sum invalid value")
+
+			iterator, err := store.Query(ctx, query.InTenant(tenant))
+			require.Nil(t, err, "query error")
+			require.Nil(t, iterator.Next(ctx, &result), "next error")
+			require.Equal(t, entity, result, "next invalid value")
+			require.Equal(t, raizel.ErrIteratorDone, iterator.Next(ctx, &result), "done error")
+		})
+	}
+	require.Equal(test, raizel.ErrNotFound, store.Get(context.Background(), key, &testEntity{}), "untenanted get error")
+	count, err := store.Count(context.Background(), query)
+	require.Nil(test, err, "untenanted count error")
+	require.Zero(test, count, "untenanted count invalid value")
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rjansen/raizel"
+)
+
+var (
+	ErrTransactionDone = errors.New("err_transactiondone")
+)
+
+// write is a buffered write of a transaction, a nil value deletes the key.
+type write struct {
+	key   raizel.EntityKey
+	value interface{}
+}
+
+type writeKey struct {
+	entityName string
+	stored     interface{}
+}
+
+// transaction buffers the writes until the commit, its reads see its own
+// writes over the committed entities.
+type transaction struct {
+	repository *repository
+	writes     []write
+	pending    map[writeKey]int
+	done       bool
+}
+
+// Transaction runs fn with a transaction that buffers the writes and commits
+// them at once when fn returns nil. The transaction does not isolate the
+// reads, they see the entities committed by other writers meanwhile.
+func (r *repository) Transaction(ctx context.Context, fn func(context.Context, raizel.Repository) error) error {
+	tx := &transaction{repository: r, pending: make(map[writeKey]int)}
+	defer func() { tx.done = true }()
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, buffered := range tx.writes {
+		if buffered.value == nil {
+			r.delete(buffered.key, storageKey(buffered.key))
+			continue
+		}
+		r.set(buffered.key, buffered.value)
+	}
+	return nil
+}
+
+func (t *transaction) buffer(key raizel.EntityKey, value interface{}) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.pending[writeKey{entityName: key.EntityName(), stored: storageKey(key)}] = len(t.writes)
+	t.writes = append(t.writes, write{key: key, value: value})
+	return nil
+}
+
+// value returns the entity value of the key seen by the transaction.
+func (t *transaction) value(key raizel.EntityKey) (interface{}, error) {
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+	stored := storageKey(key)
+	if index, pending := t.pending[writeKey{entityName: key.EntityName(), stored: stored}]; pending {
+		if t.writes[index].value == nil {
+			return nil, raizel.ErrNotFound
+		}
+		return t.writes[index].value, nil
+	}
+	t.repository.mu.RLock()
+	value, exists := t.repository.entities[key.EntityName()][stored]
+	t.repository.mu.RUnlock()
+	if !exists {
+		return nil, raizel.ErrNotFound
+	}
+	return value, nil
+}
+
+func (t *transaction) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	value, err := t.value(key)
+	if err != nil {
+		return err
+	}
+	if err := loadEntity(value, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (t *transaction) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	value, err := entityValue(entity)
+	if err != nil {
+		return err
+	}
+	return t.buffer(key, value)
+}
+
+func (t *transaction) Delete(ctx context.Context, key raizel.EntityKey) error {
+	if err := raizel.BeforeDelete(ctx, t, key); err != nil {
+		return err
+	}
+	return t.buffer(key, nil)
+}
+
+func (t *transaction) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	if err := raizel.CheckUpdates(key, updates); err != nil {
+		return err
+	}
+	current, err := t.value(key)
+	if err != nil {
+		return err
+	}
+	value, err := patch(current, updates)
+	if err != nil {
+		return err
+	}
+	return t.buffer(key, value)
+}
+
+func (t *transaction) Close(ctx context.Context) error {
+	return nil
+}
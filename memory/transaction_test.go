@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = NewRepository()
+		key1       = raizel.NewDynamicKey("entity", "id", "mock1")
+		key2       = raizel.NewDynamicKey("entity", "id", "mock2")
+		result     testEntity
+		failed     = errors.New("err_failed")
+	)
+	require.Implements(test, (*raizel.Transactional)(nil), repository, "invalid transactional type")
+	require.Nil(test, repository.Set(ctx, key1, &testEntity{ID: "mock1", Name: "Mock One", Age: 10}), "set error")
+
+	err := raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		require.Nil(test, tx.Set(ctx, key2, &testEntity{ID: "mock2", Name: "Mock Two"}), "tx set error")
+		require.Nil(test, tx.Get(ctx, key2, &result), "tx get error")
+		require.Equal(test, "Mock Two", result.Name, "tx get invalid value")
+		require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key2, &result), "uncommitted get error")
+		require.Nil(test, tx.Delete(ctx, key1), "tx delete error")
+		return failed
+	})
+	require.Equal(test, failed, err, "rolled back transaction error")
+	require.Nil(test, repository.Get(ctx, key1, &result), "rolled back delete error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key2, &result), "rolled back set error")
+
+	var leaked raizel.Repository
+	err = raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		leaked = tx
+		if err := tx.Set(ctx, key2, &testEntity{ID: "mock2", Name: "Mock Two"}); err != nil {
+			return err
+		}
+		if err := raizel.Patch(ctx, tx, key2, raizel.Increment("age", 2)); err != nil {
+			return err
+		}
+		return tx.Delete(ctx, key1)
+	})
+	require.Nil(test, err, "committed transaction error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key1, &result), "committed delete error")
+	require.Nil(test, repository.Get(ctx, key2, &result), "committed set error")
+	require.Equal(test, testEntity{ID: "mock2", Name: "Mock Two", Age: 2}, result, "committed invalid value")
+	require.Equal(test, ErrTransactionDone, leaked.Set(ctx, key1, &result), "done transaction error")
+}
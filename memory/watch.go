@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/rjansen/raizel"
+)
+
+type change struct {
+	kind  raizel.ChangeKind
+	key   raizel.EntityKey
+	value interface{}
+}
+
+func (c change) Kind() raizel.ChangeKind {
+	return c.kind
+}
+
+func (c change) Key() raizel.EntityKey {
+	return c.key
+}
+
+func (c change) DataTo(entity raizel.Entity) error {
+	return loadEntity(c.value, entity)
+}
+
+type changeStream struct {
+	ctx        context.Context
+	entityName string
+	key        raizel.EntityKey
+	remove     func(*changeStream)
+
+	mu      sync.Mutex
+	pending []raizel.Change
+	stopped bool
+	notify  chan struct{}
+}
+
+func (s *changeStream) matches(c change) bool {
+	if c.key.EntityName() != s.entityName {
+		return false
+	}
+	return s.key == nil || reflect.DeepEqual(storageKey(s.key), storageKey(c.key))
+}
+
+func (s *changeStream) push(c raizel.Change) {
+	s.mu.Lock()
+	s.pending = append(s.pending, c)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *changeStream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *changeStream) Next() (raizel.Change, error) {
+	for {
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			return nil, raizel.ErrWatchStopped
+		}
+		if len(s.pending) > 0 {
+			next := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+			return next, nil
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+func (s *changeStream) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.pending = nil
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *changeStream) Stop() {
+	s.remove(s)
+	s.stop()
+}
+
+// publish must be called with the repository lock held.
+func (r *repository) publish(c change) {
+	for stream := range r.streams {
+		if stream.matches(c) {
+			stream.push(c)
+		}
+	}
+}
+
+func (r *repository) watch(ctx context.Context, entityName string, key raizel.EntityKey) *changeStream {
+	stream := &changeStream{
+		ctx:        ctx,
+		entityName: entityName,
+		key:        key,
+		notify:     make(chan struct{}, 1),
+		remove: func(stream *changeStream) {
+			r.mu.Lock()
+			delete(r.streams, stream)
+			r.mu.Unlock()
+		},
+	}
+	r.mu.Lock()
+	r.streams[stream] = struct{}{}
+	r.mu.Unlock()
+	return stream
+}
+
+func (r *repository) Watch(ctx context.Context, entityName string) (raizel.ChangeStream, error) {
+	return r.watch(ctx, entityName, nil), nil
+}
+
+func (r *repository) WatchKey(ctx context.Context, key raizel.EntityKey) (raizel.ChangeStream, error) {
+	return r.watch(ctx, key.EntityName(), key), nil
+}
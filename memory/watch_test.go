@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		repository = NewRepository()
+		key        = raizel.NewDynamicKey("entity", "id", "mock1")
+		otherKey   = raizel.NewDynamicKey("entity", "id", "mock2")
+	)
+	entityStream, err := repository.Watch(ctx, "entity")
+	require.Nil(test, err, "watch error")
+	keyStream, err := repository.WatchKey(ctx, key)
+	require.Nil(test, err, "watch key error")
+
+	require.Nil(test, repository.Set(ctx, key, &testEntity{ID: "mock1", Age: 1}), "set error")
+	require.Nil(test, repository.Set(ctx, otherKey, &testEntity{ID: "mock2"}), "set other error")
+	require.Nil(test, repository.Set(ctx, key, &testEntity{ID: "mock1", Age: 2}), "update error")
+	require.Nil(test, repository.Delete(ctx, key), "delete error")
+	require.Nil(test, repository.Set(ctx, raizel.NewDynamicKey("other", "id", "mock1"), &testEntity{}), "set other entity error")
+
+	expected := []struct {
+		kind raizel.ChangeKind
+		id   string
+		age  int
+	}{
+		{raizel.EntityAdded, "mock1", 1},
+		{raizel.EntityAdded, "mock2", 0},
+		{raizel.EntityModified, "mock1", 2},
+		{raizel.EntityRemoved, "mock1", 2},
+	}
+	for _, want := range expected {
+		change, err := entityStream.Next()
+		require.Nil(test, err, "entity stream next error")
+		require.Equal(test, want.kind, change.Kind(), "entity stream kind")
+		require.Equal(test, want.id, change.Key().Value(), "entity stream key")
+		var entity testEntity
+		require.Nil(test, change.DataTo(&entity), "entity stream data error")
+		require.Equal(test, want.age, entity.Age, "entity stream data")
+
+		if want.id != "mock1" {
+			continue
+		}
+		change, err = keyStream.Next()
+		require.Nil(test, err, "key stream next error")
+		require.Equal(test, want.kind, change.Kind(), "key stream kind")
+	}
+
+	entityStream.Stop()
+	_, err = entityStream.Next()
+	require.Equal(test, raizel.ErrWatchStopped, err, "stopped stream error")
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	timeoutStream, err := repository.Watch(timeout, "entity")
+	require.Nil(test, err, "watch error")
+	_, err = timeoutStream.Next()
+	require.Equal(test, context.DeadlineExceeded, err, "canceled stream error")
+
+	require.Nil(test, repository.Close(ctx), "close error")
+	_, err = keyStream.Next()
+	require.Equal(test, raizel.ErrWatchStopped, err, "closed stream error")
+}
+
+func TestChangeStreamMatches(test *testing.T) {
+	var (
+		parent = raizel.NewDynamicKey("orders", "id", "order1")
+		stream = &changeStream{
+			entityName: "items",
+			key:        raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "id", []string{"item1"})),
+		}
+	)
+	require.True(test, stream.matches(change{key: stream.key}), "same key")
+	require.False(test, stream.matches(change{
+		key: raizel.NewDynamicKey("items", "id", []string{"item1"}),
+	}), "key without parent")
+	require.False(test, stream.matches(change{
+		key: raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "id", []string{"item2"})),
+	}), "other key")
+}
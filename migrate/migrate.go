@@ -0,0 +1,347 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// Table is the table, or collection, that records the applied versions.
+	Table = "schema_migrations"
+	// LockTable is the table, or collection, that holds the migration lock, or
+	// the name of the postgres advisory lock.
+	LockTable = "schema_migrations_lock"
+)
+
+var (
+	ErrLocked           = errors.New("err_migrationlocked")
+	ErrDuplicateVersion = errors.New("err_duplicateversion")
+	ErrIrreversible     = errors.New("err_irreversiblemigration")
+	ErrUnknownVersion   = errors.New("err_unknownversion")
+	ErrUnsupportedStep  = errors.New("err_unsupportedstep")
+)
+
+// Func is a migration step written in Go, e.g. a data backfill.
+type Func func(context.Context) error
+
+// Migration is a versioned change. The Up and Down scripts are run by the
+// Driver, UpFunc and DownFunc run after the script when they are set. A
+// migration without Down and DownFunc cannot be reverted.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   Func
+	DownFunc Func
+}
+
+func (m Migration) reversible() bool {
+	return m.Down != "" || m.DownFunc != nil
+}
+
+// Record is an applied migration.
+type Record struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Driver stores the applied versions and runs the migration scripts of one
+// backend. Init creates the bookkeeping tables and must be idempotent, Lock
+// returns ErrLocked when another migrator holds the lock.
+type Driver interface {
+	Init(context.Context) error
+	Lock(context.Context) error
+	Unlock(context.Context) error
+	Applied(context.Context) ([]Record, error)
+	Exec(context.Context, string) error
+	Insert(context.Context, Record) error
+	Delete(context.Context, int64) error
+}
+
+// TxDriver is a Driver that applies a migration in one transaction, so a
+// failed script or Func does not leave its changes or its version behind.
+// Transaction runs fn with a Driver bound to a transaction that is committed
+// when fn returns nil and rolled back otherwise.
+type TxDriver interface {
+	Driver
+	Transaction(context.Context, func(Driver) error) error
+}
+
+type Direction int
+
+const (
+	Up Direction = iota
+	Down
+)
+
+func (d Direction) String() string {
+	if d == Down {
+		return "down"
+	}
+	return "up"
+}
+
+// Step is a migration that was, or would be when dry running, applied in
+// Direction.
+type Step struct {
+	Version   int64
+	Name      string
+	Direction Direction
+}
+
+func (s Step) String() string {
+	return fmt.Sprintf("%d_%s.%s", s.Version, s.Name, s.Direction)
+}
+
+// Status is the state of a migration, migrations that were applied but are
+// not known by the migrator are reported with Unknown.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool
+}
+
+type Migrator interface {
+	// Up applies every pending migration.
+	Up(context.Context) ([]Step, error)
+	// UpTo applies the pending migrations up to version.
+	UpTo(context.Context, int64) ([]Step, error)
+	// Down reverts the last applied migration.
+	Down(context.Context) ([]Step, error)
+	// DownTo reverts the applied migrations newer than version.
+	DownTo(context.Context, int64) ([]Step, error)
+	Status(context.Context) ([]Status, error)
+}
+
+type Option func(*migrator)
+
+// DryRun makes the migrator return the steps it would run without running
+// them, the driver still creates the bookkeeping tables.
+func DryRun() Option {
+	return func(m *migrator) {
+		m.dryRun = true
+	}
+}
+
+type migrator struct {
+	driver     Driver
+	migrations []Migration
+	dryRun     bool
+	now        func() time.Time
+}
+
+// NewMigrator returns a Migrator that applies migrations in version order.
+func NewMigrator(driver Driver, migrations []Migration, options ...Option) (Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for index := 1; index < len(sorted); index++ {
+		if sorted[index].Version == sorted[index-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, sorted[index].Version)
+		}
+	}
+	m := &migrator{driver: driver, migrations: sorted, now: time.Now}
+	for _, option := range options {
+		option(m)
+	}
+	return m, nil
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int64]Record, error) {
+	if err := m.driver.Init(ctx); err != nil {
+		return nil, err
+	}
+	records, err := m.driver.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *migrator) run(ctx context.Context, plan func(map[int64]Record) ([]Migration, error), direction Direction) ([]Step, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !m.dryRun {
+		if err := m.driver.Lock(ctx); err != nil {
+			return nil, err
+		}
+		defer m.driver.Unlock(ctx)
+		// reads again, another migrator may have finished before the lock
+		if applied, err = m.applied(ctx); err != nil {
+			return nil, err
+		}
+	}
+	migrations, err := plan(applied)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]Step, 0, len(migrations))
+	for _, migration := range migrations {
+		step := Step{Version: migration.Version, Name: migration.Name, Direction: direction}
+		if !m.dryRun {
+			if err := m.apply(ctx, migration, direction); err != nil {
+				return steps, fmt.Errorf("%s: %w", step, err)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// apply runs the script and the Func of migration and records its version,
+// in one transaction when the driver is a TxDriver.
+func (m *migrator) apply(ctx context.Context, migration Migration, direction Direction) error {
+	if driver, ok := m.driver.(TxDriver); ok {
+		return driver.Transaction(ctx, func(tx Driver) error {
+			return m.step(ctx, tx, migration, direction)
+		})
+	}
+	return m.step(ctx, m.driver, migration, direction)
+}
+
+func (m *migrator) step(ctx context.Context, driver Driver, migration Migration, direction Direction) error {
+	script, fn := migration.Up, migration.UpFunc
+	if direction == Down {
+		script, fn = migration.Down, migration.DownFunc
+	}
+	if script != "" {
+		if err := driver.Exec(ctx, script); err != nil {
+			return err
+		}
+	}
+	if fn != nil {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+	if direction == Down {
+		return driver.Delete(ctx, migration.Version)
+	}
+	return driver.Insert(ctx, Record{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: m.now().UTC(),
+	})
+}
+
+func (m *migrator) Up(ctx context.Context) ([]Step, error) {
+	return m.UpTo(ctx, -1)
+}
+
+func (m *migrator) UpTo(ctx context.Context, version int64) ([]Step, error) {
+	return m.run(ctx, func(applied map[int64]Record) ([]Migration, error) {
+		var pending []Migration
+		for _, migration := range m.migrations {
+			if version >= 0 && migration.Version > version {
+				break
+			}
+			if _, done := applied[migration.Version]; !done {
+				pending = append(pending, migration)
+			}
+		}
+		return pending, nil
+	}, Up)
+}
+
+func (m *migrator) Down(ctx context.Context) ([]Step, error) {
+	return m.down(ctx, func(applied []Migration) []Migration {
+		if len(applied) == 0 {
+			return nil
+		}
+		return applied[:1]
+	})
+}
+
+func (m *migrator) DownTo(ctx context.Context, version int64) ([]Step, error) {
+	return m.down(ctx, func(applied []Migration) []Migration {
+		var reverted []Migration
+		for _, migration := range applied {
+			if migration.Version <= version {
+				break
+			}
+			reverted = append(reverted, migration)
+		}
+		return reverted
+	})
+}
+
+// down plans the reverts of the applied migrations sorted newest first.
+func (m *migrator) down(ctx context.Context, choose func([]Migration) []Migration) ([]Step, error) {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	return m.run(ctx, func(applied map[int64]Record) ([]Migration, error) {
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+		migrations := make([]Migration, len(versions))
+		for index, version := range versions {
+			migration, found := known[version]
+			if !found {
+				migration = Migration{Version: version, Name: applied[version].Name}
+			}
+			migrations[index] = migration
+		}
+		reverted := choose(migrations)
+		for _, migration := range reverted {
+			if _, found := known[migration.Version]; !found {
+				return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, migration.Version)
+			}
+			if !migration.reversible() {
+				return nil, fmt.Errorf("%w: %d", ErrIrreversible, migration.Version)
+			}
+		}
+		return reverted, nil
+	}, Down)
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		record, done := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   done,
+			AppliedAt: record.AppliedAt,
+		})
+		delete(applied, migration.Version)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{
+			Version:   record.Version,
+			Name:      record.Name,
+			Applied:   true,
+			AppliedAt: record.AppliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
@@ -0,0 +1,315 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type driverFake struct {
+	records map[int64]Record
+	scripts []string
+	locked  bool
+	locks   int
+	lockErr error
+	execErr error
+}
+
+func newDriverFake(versions ...int64) *driverFake {
+	records := make(map[int64]Record)
+	for _, version := range versions {
+		records[version] = Record{Version: version, Name: fmt.Sprintf("applied_%d", version)}
+	}
+	return &driverFake{records: records}
+}
+
+func (d *driverFake) Init(context.Context) error {
+	return nil
+}
+
+func (d *driverFake) Lock(context.Context) error {
+	if d.lockErr != nil {
+		return d.lockErr
+	}
+	if d.locked {
+		return ErrLocked
+	}
+	d.locked = true
+	d.locks++
+	return nil
+}
+
+func (d *driverFake) Unlock(context.Context) error {
+	d.locked = false
+	return nil
+}
+
+func (d *driverFake) Applied(context.Context) ([]Record, error) {
+	records := make([]Record, 0, len(d.records))
+	for _, record := range d.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+	return records, nil
+}
+
+func (d *driverFake) Exec(ctx context.Context, script string) error {
+	if d.execErr != nil {
+		return d.execErr
+	}
+	d.scripts = append(d.scripts, script)
+	return nil
+}
+
+func (d *driverFake) Insert(ctx context.Context, record Record) error {
+	d.records[record.Version] = record
+	return nil
+}
+
+func (d *driverFake) Delete(ctx context.Context, version int64) error {
+	delete(d.records, version)
+	return nil
+}
+
+// txDriverFake stages the changes of a transaction in a copy of the fake,
+// kept on commit and dropped on rollback.
+type txDriverFake struct {
+	*driverFake
+	transactions int
+}
+
+func (d *txDriverFake) Transaction(ctx context.Context, fn func(Driver) error) error {
+	d.transactions++
+	staged := &driverFake{records: make(map[int64]Record, len(d.records))}
+	for version, record := range d.records {
+		staged.records[version] = record
+	}
+	if err := fn(staged); err != nil {
+		return err
+	}
+	d.records = staged.records
+	d.scripts = append(d.scripts, staged.scripts...)
+	return nil
+}
+
+func (d *driverFake) versions() []int64 {
+	records, _ := d.Applied(context.Background())
+	versions := make([]int64, len(records))
+	for index, record := range records {
+		versions[index] = record.Version
+	}
+	return versions
+}
+
+func testMigrations(backfills *int) []Migration {
+	return []Migration{
+		{Version: 3, Name: "backfill_names", UpFunc: func(context.Context) error {
+			*backfills++
+			return nil
+		}},
+		{Version: 1, Name: "create_entity", Up: "create table entity", Down: "drop table entity"},
+		{Version: 2, Name: "create_index", Up: "create index ix_entity", Down: "drop index ix_entity"},
+	}
+}
+
+func TestNewMigrator(test *testing.T) {
+	migrator, err := NewMigrator(newDriverFake(), []Migration{{Version: 1}, {Version: 2}, {Version: 1}})
+	require.True(test, errors.Is(err, ErrDuplicateVersion), "duplicate version error")
+	require.Nil(test, migrator, "migrator instance")
+}
+
+type testMigrator struct {
+	name      string
+	driver    *driverFake
+	options   []Option
+	run       func(context.Context, Migrator) ([]Step, error)
+	steps     []string
+	scripts   []string
+	versions  []int64
+	backfills int
+	err       error
+}
+
+func TestMigrator(test *testing.T) {
+	scenarios := []testMigrator{
+		{
+			name:   "Up applies every pending migration in version order",
+			driver: newDriverFake(),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Up(ctx)
+			},
+			steps:     []string{"1_create_entity.up", "2_create_index.up", "3_backfill_names.up"},
+			scripts:   []string{"create table entity", "create index ix_entity"},
+			versions:  []int64{1, 2, 3},
+			backfills: 1,
+		},
+		{
+			name:   "UpTo skips the applied migrations",
+			driver: newDriverFake(1),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.UpTo(ctx, 2)
+			},
+			steps:    []string{"2_create_index.up"},
+			scripts:  []string{"create index ix_entity"},
+			versions: []int64{1, 2},
+		},
+		{
+			name:   "Down reverts the last applied migration",
+			driver: newDriverFake(1, 2),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Down(ctx)
+			},
+			steps:    []string{"2_create_index.down"},
+			scripts:  []string{"drop index ix_entity"},
+			versions: []int64{1},
+		},
+		{
+			name:   "DownTo reverts the newer migrations",
+			driver: newDriverFake(1, 2),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.DownTo(ctx, 0)
+			},
+			steps:    []string{"2_create_index.down", "1_create_entity.down"},
+			scripts:  []string{"drop index ix_entity", "drop table entity"},
+			versions: []int64{},
+		},
+		{
+			name:    "Dry run returns the steps without running them",
+			driver:  newDriverFake(1),
+			options: []Option{DryRun()},
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Up(ctx)
+			},
+			steps:    []string{"2_create_index.up", "3_backfill_names.up"},
+			versions: []int64{1},
+		},
+		{
+			name:   "Error when the migration is irreversible",
+			driver: newDriverFake(1, 2, 3),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Down(ctx)
+			},
+			versions: []int64{1, 2, 3},
+			err:      ErrIrreversible,
+		},
+		{
+			name:   "Error when the applied version is unknown",
+			driver: newDriverFake(1, 7),
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Down(ctx)
+			},
+			versions: []int64{1, 7},
+			err:      ErrUnknownVersion,
+		},
+		{
+			name:   "Error when another migrator holds the lock",
+			driver: &driverFake{records: map[int64]Record{}, locked: true},
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Up(ctx)
+			},
+			versions: []int64{},
+			err:      ErrLocked,
+		},
+		{
+			name: "Error when the script fails",
+			driver: &driverFake{
+				records: map[int64]Record{}, execErr: errors.New("errMock"),
+			},
+			run: func(ctx context.Context, m Migrator) ([]Step, error) {
+				return m.Up(ctx)
+			},
+			versions: []int64{},
+			err:      errors.New("1_create_entity.up: errMock"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var backfills int
+				migrator, err := NewMigrator(scenario.driver, testMigrations(&backfills), scenario.options...)
+				require.Nil(t, err, "new migrator error")
+
+				steps, err := scenario.run(context.Background(), migrator)
+				if scenario.err != nil {
+					require.NotNil(t, err, "run error")
+					require.True(
+						t,
+						errors.Is(err, scenario.err) || err.Error() == scenario.err.Error(),
+						"run error %v", err,
+					)
+				} else {
+					require.Nil(t, err, "run error")
+				}
+				names := make([]string, len(steps))
+				for index, step := range steps {
+					names[index] = step.String()
+				}
+				require.Equal(t, len(scenario.steps), len(names), "steps length")
+				for index, step := range scenario.steps {
+					require.Equal(t, step, names[index], "step value")
+				}
+				require.Equal(t, scenario.scripts, scenario.driver.scripts, "scripts")
+				require.Equal(t, scenario.versions, scenario.driver.versions(), "versions")
+				require.Equal(t, scenario.backfills, backfills, "backfills")
+				require.False(t, scenario.driver.locked && scenario.err != ErrLocked, "lock was not released")
+			},
+		)
+	}
+}
+
+func TestMigratorStatus(test *testing.T) {
+	var (
+		backfills int
+		appliedAt = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+		driver    = newDriverFake(1, 9)
+	)
+	driver.records[1] = Record{Version: 1, Name: "create_entity", AppliedAt: appliedAt}
+	migrator, err := NewMigrator(driver, testMigrations(&backfills))
+	require.Nil(test, err, "new migrator error")
+
+	statuses, err := migrator.Status(context.Background())
+	require.Nil(test, err, "status error")
+	require.Equal(
+		test,
+		[]Status{
+			{Version: 1, Name: "create_entity", Applied: true, AppliedAt: appliedAt},
+			{Version: 2, Name: "create_index"},
+			{Version: 3, Name: "backfill_names"},
+			{Version: 9, Name: "applied_9", Applied: true, Unknown: true},
+		},
+		statuses,
+		"statuses",
+	)
+	require.Zero(test, driver.locks, "status must not lock")
+}
+
+func TestMigratorTransaction(test *testing.T) {
+	var (
+		backfills int
+		driver    = &txDriverFake{driverFake: newDriverFake()}
+	)
+	migrations := append(testMigrations(&backfills), Migration{
+		Version: 4, Name: "failed_backfill", Up: "alter table entity add column name text",
+		UpFunc: func(context.Context) error {
+			return errors.New("errMock")
+		},
+	})
+	migrator, err := NewMigrator(driver, migrations)
+	require.Nil(test, err, "new migrator error")
+
+	steps, err := migrator.Up(context.Background())
+	require.NotNil(test, err, "up error")
+	require.Equal(test, "4_failed_backfill.up: errMock", err.Error(), "up error")
+	require.Len(test, steps, 3, "applied steps")
+	require.Equal(test, 4, driver.transactions, "transactions")
+	require.Equal(test, []string{"create table entity", "create index ix_entity"}, driver.scripts, "scripts")
+	require.Equal(test, []int64{1, 2, 3}, driver.versions(), "versions")
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidFilename = errors.New("err_invalidfilename")
+
+	filenamePattern    = regexp.MustCompile(`^(\d+)_(\w+?)(\.(up|down))?\.\w+$`)
+	dollarQuotePattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+)
+
+// ReadDir reads the migrations of dir named {version}_{name}.up.{ext} and
+// {version}_{name}.down.{ext}, a file without the up or down suffix is an up
+// script. Files that do not start with a version are ignored.
+func ReadDir(dir string) ([]Migration, error) {
+	return ReadFS(os.DirFS(dir), ".")
+}
+
+// ReadFS reads the migrations of dir in fsys like ReadDir, the scripts can
+// be embedded with an embed.FS.
+func ReadFS(fsys fs.FS, dir string) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		parts := filenamePattern.FindStringSubmatch(file.Name())
+		if parts == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, file.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != parts[2] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateVersion, file.Name())
+		}
+		if parts[4] == "down" {
+			migration.Down = string(data)
+		} else {
+			migration.Up = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Statements splits a script into the statements terminated by a semicolon,
+// for backends that run one statement at a time. Semicolons inside quoted
+// strings and dollar quoted bodies, like $$ ... $$ or $fn$ ... $fn$, do not
+// split the statement. The -- and /* */ comments are dropped, so quotes or
+// semicolons inside them do not change the split.
+func Statements(script string) []string {
+	var (
+		statements []string
+		statement  strings.Builder
+		quote      string
+	)
+	flush := func() {
+		if text := strings.TrimSpace(statement.String()); text != "" {
+			statements = append(statements, text)
+		}
+		statement.Reset()
+	}
+	for index := 0; index < len(script); {
+		switch rest := script[index:]; {
+		case quote != "":
+			if strings.HasPrefix(rest, quote) {
+				statement.WriteString(quote)
+				index += len(quote)
+				quote = ""
+				continue
+			}
+		case strings.HasPrefix(rest, "--"):
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				index += end
+			} else {
+				index = len(script)
+			}
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				index += end + 4
+			} else {
+				index = len(script)
+			}
+			statement.WriteByte(' ')
+			continue
+		case rest[0] == '\'' || rest[0] == '"':
+			quote = rest[:1]
+		case rest[0] == '$':
+			if tag := dollarQuotePattern.FindString(rest); tag != "" {
+				statement.WriteString(tag)
+				index += len(tag)
+				quote = tag
+				continue
+			}
+		case rest[0] == ';':
+			flush()
+			index++
+			continue
+		}
+		statement.WriteByte(script[index])
+		index++
+	}
+	flush()
+	return statements
+}
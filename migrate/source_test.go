@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDir(test *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	require.Nil(test, err, "tempdir error")
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"002_create_index.up.sql":   "create index ix_entity",
+		"002_create_index.down.sql": "drop index ix_entity",
+		"001_create_entity.sql":     "create table entity",
+		"README.md":                 "ignored",
+	}
+	for name, content := range files {
+		require.Nil(test, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644), "write error")
+	}
+
+	migrations, err := ReadDir(dir)
+	require.Nil(test, err, "readdir error")
+	require.Equal(
+		test,
+		[]Migration{
+			{Version: 1, Name: "create_entity", Up: "create table entity"},
+			{Version: 2, Name: "create_index", Up: "create index ix_entity", Down: "drop index ix_entity"},
+		},
+		migrations,
+		"migrations",
+	)
+
+	_, err = ReadDir(filepath.Join(dir, "missing"))
+	require.NotNil(test, err, "readdir missing error")
+}
+
+func TestReadFS(test *testing.T) {
+	fsys := fstest.MapFS{
+		"scripts/001_create_entity.up.sql":   {Data: []byte("create table entity")},
+		"scripts/001_create_entity.down.sql": {Data: []byte("drop table entity")},
+		"scripts/nested/002_ignored.sql":     {Data: []byte("ignored")},
+	}
+	migrations, err := ReadFS(fsys, "scripts")
+	require.Nil(test, err, "readfs error")
+	require.Equal(
+		test,
+		[]Migration{{Version: 1, Name: "create_entity", Up: "create table entity", Down: "drop table entity"}},
+		migrations,
+		"migrations",
+	)
+
+	_, err = ReadFS(fsys, "missing")
+	require.NotNil(test, err, "readfs missing error")
+}
+
+func TestReadDirProjectScripts(test *testing.T) {
+	migrations, err := ReadDir("../test/scripts/integration/postgres")
+	require.Nil(test, err, "readdir error")
+	require.Len(test, migrations, 2, "migrations length")
+	require.Equal(test, "create_tables", migrations[0].Name, "first migration name")
+	require.Equal(test, "create_data", migrations[1].Name, "second migration name")
+}
+
+func TestStatements(test *testing.T) {
+	require.Equal(
+		test,
+		[]string{
+			"create table entity (id text primary key)",
+			"insert into entity (id) values ('a;b')",
+		},
+		Statements("create table entity (id text primary key);\n insert into entity (id) values ('a;b');\n\n"),
+		"statements",
+	)
+	require.Equal(
+		test,
+		[]string{
+			"create function touch() returns trigger as $$\nbegin\n  new.updated_at = now();\n  return new;\nend;\n$$ language plpgsql",
+			"create function notify() returns trigger as $body$ begin perform pg_notify('c', '$$;'); end; $body$ language plpgsql",
+			"select $1",
+		},
+		Statements(
+			"create function touch() returns trigger as $$\nbegin\n  new.updated_at = now();\n  return new;\nend;\n$$ language plpgsql;\n"+
+				"create function notify() returns trigger as $body$ begin perform pg_notify('c', '$$;'); end; $body$ language plpgsql;\n"+
+				"select $1;",
+		),
+		"dollar quoted statements",
+	)
+	require.Equal(
+		test,
+		[]string{
+			"create table entity (id text primary key)",
+			"insert into entity (id) values ('-- a;b')",
+			"select 1",
+		},
+		Statements(
+			"-- don't split here;\ncreate table entity (id text primary key); -- the entity's table\n"+
+				"insert into entity (id) values ('-- a;b');\n"+
+				"select/* it's; a comment */1;\n-- the end",
+		),
+		"commented statements",
+	)
+	require.Empty(test, Statements(" ;\n "), "blank statements")
+}
@@ -0,0 +1,96 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	ErrInvalidDestination = errors.New("err_invalid_destination")
+)
+
+// Assign sets the value pointed by dest to value, the value is converted to
+// the destination type when it is convertible and nil sets the zero value.
+func Assign(dest interface{}, value interface{}) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("%w: %T", ErrInvalidDestination, dest)
+	}
+	target = target.Elem()
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	source := reflect.ValueOf(value)
+	switch {
+	case source.Type().AssignableTo(target.Type()):
+		target.Set(source)
+	case source.Kind() == reflect.Ptr && !source.IsNil() && source.Elem().Type().AssignableTo(target.Type()):
+		target.Set(source.Elem())
+	case source.Type().ConvertibleTo(target.Type()) && source.Kind() != reflect.String && target.Kind() != reflect.String:
+		target.Set(source.Convert(target.Type()))
+	default:
+		return fmt.Errorf("%w: cannot assign %T to %T", ErrInvalidDestination, value, dest)
+	}
+	return nil
+}
+
+// ScanValues assigns the values to the scan destinations in order.
+func ScanValues(dest []interface{}, values ...interface{}) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("%w: %d values scanned into %d destinations", ErrInvalidDestination, len(values), len(dest))
+	}
+	for index, value := range values {
+		if err := Assign(dest[index], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StructValues returns the exported field values of the struct in field
+// order, skipping the fields tagged db:"-". It matches the scan order of the
+// repositories that scan an entity by its fields.
+func StructValues(source interface{}) []interface{} {
+	value := reflect.ValueOf(source)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return []interface{}{source}
+	}
+	values := make([]interface{}, 0, value.NumField())
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.PkgPath != "" || field.Tag.Get("db") == "-" {
+			continue
+		}
+		values = append(values, value.Field(index).Interface())
+	}
+	return values
+}
+
+// Scan returns a Run function of a mock call whose first argument is the
+// slice of scan destinations, it assigns the values to the destinations and
+// panics when they do not match.
+func Scan(values ...interface{}) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		if err := ScanValues(args.Get(0).([]interface{}), values...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Copy returns a Run function of a mock call whose first argument is a
+// decoding target, like DataTo or ToStruct, it assigns source to the target
+// and panics when it does not match.
+func Copy(source interface{}) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		if err := Assign(args.Get(0), source); err != nil {
+			panic(err)
+		}
+	}
+}
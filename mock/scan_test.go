@@ -0,0 +1,83 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scanEntity struct {
+	ID      string `db:"id"`
+	Age     int    `db:"age"`
+	ignored string
+	Skipped bool `db:"-"`
+}
+
+func TestAssign(test *testing.T) {
+	var (
+		text    string
+		integer int64
+		pointer *string
+		value   = "value"
+	)
+	scenarios := []struct {
+		name  string
+		dest  interface{}
+		value interface{}
+		want  interface{}
+		err   error
+	}{
+		{name: "Assign assignable value", dest: &text, value: "text", want: "text"},
+		{name: "Assign convertible number", dest: &integer, value: 42, want: int64(42)},
+		{name: "Assign pointed value", dest: &text, value: &value, want: "value"},
+		{name: "Assign pointer", dest: &pointer, value: &value, want: &value},
+		{name: "Assign nil", dest: &integer, value: nil, want: int64(0)},
+		{name: "Assign number to string", dest: &text, value: 42, err: ErrInvalidDestination},
+		{name: "Assign to value", dest: text, value: "text", err: ErrInvalidDestination},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				err := Assign(scenario.dest, scenario.value)
+				if scenario.err != nil {
+					require.True(t, errors.Is(err, scenario.err), "assign error: %v", err)
+					return
+				}
+				require.Nil(t, err, "assign error")
+				switch dest := scenario.dest.(type) {
+				case *string:
+					require.Equal(t, scenario.want, *dest, "assigned value")
+				case *int64:
+					require.Equal(t, scenario.want, *dest, "assigned value")
+				case **string:
+					require.Equal(t, scenario.want, *dest, "assigned value")
+				}
+			},
+		)
+	}
+}
+
+func TestScanValues(test *testing.T) {
+	var (
+		id  string
+		age int
+	)
+	require.Nil(test, ScanValues([]interface{}{&id, &age}, StructValues(&scanEntity{ID: "id", Age: 30, ignored: "x"})...), "scan error")
+	require.Equal(test, "id", id, "scanned id")
+	require.Equal(test, 30, age, "scanned age")
+	require.True(test, errors.Is(ScanValues([]interface{}{&id}, "a", "b"), ErrInvalidDestination), "scan count error")
+	require.Equal(test, []interface{}{"value"}, StructValues("value"), "non struct values")
+
+	Scan("scanned", 31)([]interface{}{[]interface{}{&id, &age}})
+	require.Equal(test, "scanned", id, "run scanned id")
+	require.Equal(test, 31, age, "run scanned age")
+	require.Panics(test, func() { Scan("a")([]interface{}{[]interface{}{&age}}) }, "run scan panic")
+
+	var entity scanEntity
+	Copy(scanEntity{ID: "copied"})([]interface{}{&entity})
+	require.Equal(test, "copied", entity.ID, "copied id")
+	require.Panics(test, func() { Copy(1)([]interface{}{&entity}) }, "copy panic")
+}
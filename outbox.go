@@ -0,0 +1,263 @@
+package raizel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OutboxEntityName is the entity name of the OutboxEvent entities.
+const OutboxEntityName = "outbox_events"
+
+// OutboxEvent is a domain event written to the outbox with the entities it
+// describes and published later by a Relay. Published is false until the
+// publisher accepts the event, the failed attempts are retried after
+// NextAttempt.
+type OutboxEvent struct {
+	_           struct{}  `raizel:"outbox_events,entity"`
+	ID          string    `raizel:"id,key"`
+	Topic       string    `raizel:"topic"`
+	Key         string    `raizel:"key"`
+	Payload     []byte    `raizel:"payload"`
+	CreatedAt   time.Time `raizel:"created_at"`
+	Published   bool      `raizel:"published,index"`
+	PublishedAt time.Time `raizel:"published_at"`
+	Attempts    int64     `raizel:"attempts"`
+	NextAttempt time.Time `raizel:"next_attempt"`
+	LastError   string    `raizel:"last_error"`
+}
+
+// NewOutboxEvent returns an event of the topic, key is the key of the entity
+// the event describes, the partition or ordering key of the publishers.
+func NewOutboxEvent(topic string, key string, payload []byte) OutboxEvent {
+	return OutboxEvent{Topic: topic, Key: key, Payload: payload}
+}
+
+func outboxKey(id string) EntityKey {
+	return NewDynamicKey(OutboxEntityName, "id", id)
+}
+
+// Enqueue writes the events to the outbox of the repository, usually the
+// repository of a Transaction that writes the entities too. The generated
+// ids keep the order of the events enqueued at once.
+func Enqueue(ctx context.Context, repository Repository, events ...OutboxEvent) error {
+	now := time.Now().UTC()
+	for index, event := range events {
+		if event.Topic == "" {
+			return fmt.Errorf("%w: blank outbox event topic", ErrInvalidArgument)
+		}
+		if event.ID == "" {
+			id := make([]byte, 8)
+			if _, err := rand.Read(id); err != nil {
+				return err
+			}
+			event.ID = fmt.Sprintf("%019d-%06d-%s", now.UnixNano(), index, hex.EncodeToString(id))
+		}
+		event.CreatedAt, event.NextAttempt = now, now
+		if err := repository.Set(ctx, outboxKey(event.ID), &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetWithEvents sets the entity and enqueues the events in a transaction,
+// the repository must implement Transactional or ErrTransactionUnsupported
+// is returned.
+func SetWithEvents(ctx context.Context, repository Repository, key EntityKey, entity Entity, events ...OutboxEvent) error {
+	return Transaction(ctx, repository, func(ctx context.Context, tx Repository) error {
+		if err := tx.Set(ctx, key, entity); err != nil {
+			return err
+		}
+		return Enqueue(ctx, tx, events...)
+	})
+}
+
+// Publisher delivers the outbox events, a nil error acknowledges the event.
+// The relay delivers an event at least once, so the consumers must tolerate
+// duplicates.
+type Publisher interface {
+	Publish(context.Context, OutboxEvent) error
+}
+
+type RelayOption func(*Relay)
+
+// WithRelayBatch sets the events dispatched by a Dispatch call, 100 by
+// default.
+func WithRelayBatch(size int) RelayOption {
+	return func(r *Relay) {
+		r.batch = size
+	}
+}
+
+// WithRelayBackoff sets the delay of the first retry, doubled on every
+// failed attempt up to max. The default is one second up to one minute.
+func WithRelayBackoff(initial, max time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.backoff, r.maxBackoff = initial, max
+	}
+}
+
+// WithRelayRetention keeps the published events for retention before
+// Cleanup deletes them, zero by default.
+func WithRelayRetention(retention time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.retention = retention
+	}
+}
+
+// WithRelayInterval sets the wait between the Run passes, one second by
+// default.
+func WithRelayInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// WithRelayClock replaces the clock of the relay.
+func WithRelayClock(now func() time.Time) RelayOption {
+	return func(r *Relay) {
+		r.now = now
+	}
+}
+
+// Relay dispatches the pending outbox events of a Queryable repository to a
+// Publisher. An event is marked published after the publisher accepts it,
+// so a crash in between publishes it again. Run a single relay per outbox
+// to keep the duplicates rare.
+type Relay struct {
+	repository Repository
+	publisher  Publisher
+	batch      int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	retention  time.Duration
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewRelay(repository Repository, publisher Publisher, options ...RelayOption) *Relay {
+	relay := &Relay{
+		repository: repository,
+		publisher:  publisher,
+		batch:      100,
+		backoff:    time.Second,
+		maxBackoff: time.Minute,
+		interval:   time.Second,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		option(relay)
+	}
+	return relay
+}
+
+func (r *Relay) query(ctx context.Context, query Query) ([]OutboxEvent, error) {
+	queryable, ok := r.repository.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	iterator, err := queryable.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Stop()
+	var events []OutboxEvent
+	for {
+		var event OutboxEvent
+		if err := iterator.Next(ctx, &event); err != nil {
+			if errors.Is(err, ErrIteratorDone) {
+				return events, nil
+			}
+			return nil, err
+		}
+		events = append(events, event)
+	}
+}
+
+// retryDelay returns the delay after the attempts failed attempts.
+func (r *Relay) retryDelay(attempts int64) time.Duration {
+	delay := r.backoff
+	for attempt := int64(1); attempt < attempts && delay < r.maxBackoff; attempt++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		return r.maxBackoff
+	}
+	return delay
+}
+
+// Dispatch publishes a batch of the pending events due for an attempt, in
+// creation order, and returns the published count. A failed event is kept
+// for a retry with backoff and does not stop the batch.
+func (r *Relay) Dispatch(ctx context.Context) (int, error) {
+	now := r.now().UTC()
+	events, err := r.query(ctx, NewQuery(OutboxEntityName).
+		Where("published", Equal, false).
+		Where("next_attempt", LessEqual, now).
+		OrderBy("created_at", Asc).
+		OrderBy("id", Asc).
+		WithLimit(r.batch),
+	)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return published, err
+		}
+		if publishErr := r.publisher.Publish(ctx, event); publishErr != nil {
+			event.Attempts++
+			event.NextAttempt = now.Add(r.retryDelay(event.Attempts))
+			event.LastError = publishErr.Error()
+		} else {
+			event.Published, event.PublishedAt, event.LastError = true, now, ""
+			published++
+		}
+		if err := r.repository.Set(ctx, outboxKey(event.ID), &event); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+// Cleanup deletes the events published before the retention and returns the
+// deleted count.
+func (r *Relay) Cleanup(ctx context.Context) (int, error) {
+	events, err := r.query(ctx, NewQuery(OutboxEntityName).
+		Where("published", Equal, true).
+		Where("published_at", LessEqual, r.now().UTC().Add(-r.retention)).
+		WithLimit(r.batch),
+	)
+	if err != nil {
+		return 0, err
+	}
+	for index, event := range events {
+		if err := r.repository.Delete(ctx, outboxKey(event.ID)); err != nil {
+			return index, err
+		}
+	}
+	return len(events), nil
+}
+
+// Run dispatches and cleans up the outbox every interval until ctx is done.
+// The dispatch errors are retried on the next pass, Run returns the context
+// error when it stops.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Dispatch(ctx); err == nil {
+			_, _ = r.Cleanup(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
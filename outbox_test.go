@@ -0,0 +1,100 @@
+package raizel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxOrder struct {
+	ID    string
+	Total int
+}
+
+func TestSetWithEvents(test *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = memory.NewRepository()
+		key   = raizel.NewDynamicKey("orders", "id", "order1")
+		order = outboxOrder{ID: "order1", Total: 10}
+		event = raizel.NewOutboxEvent("orders.created", "order1", []byte(`{"id":"order1"}`))
+	)
+	err := raizel.SetWithEvents(ctx, store, key, &order, raizel.OutboxEvent{})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "blank topic error")
+	require.Equal(test, raizel.ErrNotFound, store.Get(ctx, key, &outboxOrder{}), "rolled back entity")
+
+	require.Nil(test, raizel.SetWithEvents(ctx, store, key, &order, event, event), "set with events error")
+	count, err := raizel.Count(ctx, store, raizel.NewQuery(raizel.OutboxEntityName))
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(2), count, "outbox events count")
+
+	err = raizel.SetWithEvents(ctx, repositoryOnly{store}, key, &order, event)
+	require.Equal(test, raizel.ErrTransactionUnsupported, err, "unsupported transaction error")
+}
+
+func TestRelay(test *testing.T) {
+	var (
+		ctx       = context.Background()
+		store     = memory.NewRepository()
+		publisher = memory.NewPublisher()
+		offset    time.Duration
+		relay     = raizel.NewRelay(store, publisher,
+			raizel.WithRelayBackoff(time.Second, 4*time.Second),
+			raizel.WithRelayRetention(time.Hour),
+			raizel.WithRelayClock(func() time.Time { return time.Now().Add(offset) }),
+		)
+	)
+	err := raizel.Transaction(ctx, store, func(ctx context.Context, tx raizel.Repository) error {
+		return raizel.Enqueue(ctx, tx,
+			raizel.NewOutboxEvent("orders.created", "order1", nil),
+			raizel.NewOutboxEvent("orders.paid", "order1", nil),
+			raizel.NewOutboxEvent("orders.shipped", "order1", nil),
+		)
+	})
+	require.Nil(test, err, "enqueue error")
+
+	publisher.FailWith(raizel.ErrUnavailable)
+	published, err := relay.Dispatch(ctx)
+	require.Nil(test, err, "failed dispatch error")
+	require.Zero(test, published, "failed dispatch count")
+	published, err = relay.Dispatch(ctx)
+	require.Nil(test, err, "backoff dispatch error")
+	require.Zero(test, published, "backoff dispatch count")
+
+	publisher.FailWith(nil)
+	offset = 2 * time.Second
+	published, err = relay.Dispatch(ctx)
+	require.Nil(test, err, "dispatch error")
+	require.Equal(test, 3, published, "dispatch count")
+	var topics []string
+	for _, event := range publisher.Events() {
+		topics = append(topics, event.Topic)
+		require.Equal(test, int64(1), event.Attempts, "event attempts")
+		require.Equal(test, "err_unavailable", event.LastError, "event last error")
+	}
+	require.Equal(test, []string{"orders.created", "orders.paid", "orders.shipped"}, topics, "published topics")
+
+	published, err = relay.Dispatch(ctx)
+	require.Nil(test, err, "empty dispatch error")
+	require.Zero(test, published, "empty dispatch count")
+
+	cleaned, err := relay.Cleanup(ctx)
+	require.Nil(test, err, "retained cleanup error")
+	require.Zero(test, cleaned, "retained cleanup count")
+	offset = 2 * time.Hour
+	cleaned, err = relay.Cleanup(ctx)
+	require.Nil(test, err, "cleanup error")
+	require.Equal(test, 3, cleaned, "cleanup count")
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Equal(test, context.Canceled, relay.Run(ctx), "run error")
+
+	_, err = raizel.NewRelay(repositoryOnly{store}, publisher).Dispatch(ctx)
+	require.Equal(test, raizel.ErrNotQueryable, err, "unqueryable dispatch error")
+}
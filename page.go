@@ -0,0 +1,126 @@
+package raizel
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	DefaultPageSize = 100
+)
+
+var (
+	ErrInvalidPageToken = errors.New("err_invalidpagetoken")
+	ErrUnorderedPage    = errors.New("err_unorderedpage")
+	ErrPageUnsupported  = errors.New("err_pageunsupported")
+)
+
+// PageIterator streams one page of query results. NextPageToken is known
+// after Next returns ErrIteratorDone and is blank after the last page.
+type PageIterator interface {
+	Iterator
+	NextPageToken() string
+}
+
+// Pageable is implemented by repositories that page query results with
+// cursors instead of offsets. Query.Limit is the page size, DefaultPageSize
+// when not set. Cursors are built from the Query.Orders values, so the orders
+// must end with an unique field and a query without orders returns
+// ErrUnorderedPage. A blank pageToken reads the first page.
+type Pageable interface {
+	Page(ctx context.Context, query Query, pageToken string) (PageIterator, error)
+}
+
+// PageSize returns the page size of the query.
+func PageSize(query Query) int {
+	if query.Limit > 0 {
+		return query.Limit
+	}
+	return DefaultPageSize
+}
+
+type pageValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// EncodePageToken returns an opaque and URL safe token with values, the token
+// keeps the value types so DecodePageToken returns the same values.
+func EncodePageToken(values ...interface{}) (string, error) {
+	encoded := make([]pageValue, len(values))
+	for index, value := range values {
+		var kind string
+		switch typed := value.(type) {
+		case nil:
+			kind = "n"
+		case string:
+			kind = "s"
+		case bool:
+			kind = "b"
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+			kind, value = "i", reflect.ValueOf(value).Convert(reflect.TypeOf(int64(0))).Int()
+		case float32, float64:
+			kind, value = "f", reflect.ValueOf(value).Convert(reflect.TypeOf(float64(0))).Float()
+		case time.Time:
+			kind, value = "t", typed.Format(time.RFC3339Nano)
+		case []byte:
+			kind = "y"
+		default:
+			return "", fmt.Errorf("%w: unsupported value %T", ErrInvalidPageToken, value)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		encoded[index] = pageValue{Type: kind, Value: data}
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodePageToken returns the values of a token created by EncodePageToken.
+func DecodePageToken(token string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var encoded []pageValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	values := make([]interface{}, len(encoded))
+	for index, value := range encoded {
+		var target interface{}
+		switch value.Type {
+		case "n":
+			continue
+		case "s":
+			target = new(string)
+		case "b":
+			target = new(bool)
+		case "i":
+			target = new(int64)
+		case "f":
+			target = new(float64)
+		case "t":
+			target = new(time.Time)
+		case "y":
+			target = new([]byte)
+		default:
+			return nil, ErrInvalidPageToken
+		}
+		if err := json.Unmarshal(value.Value, target); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		values[index] = reflect.ValueOf(target).Elem().Interface()
+	}
+	return values, nil
+}
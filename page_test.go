@@ -0,0 +1,62 @@
+package raizel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageToken(test *testing.T) {
+	var (
+		now       = time.Date(2019, 10, 3, 21, 4, 5, 7, time.UTC)
+		scenarios = []struct {
+			name   string
+			values []interface{}
+			result []interface{}
+		}{
+			{
+				name:   "Encode every supported type",
+				values: []interface{}{"mock", true, 7, int32(8), uint16(9), float32(1.5), 2.25, now, []byte("raw"), nil},
+				result: []interface{}{"mock", true, int64(7), int64(8), int64(9), 1.5, 2.25, now, []byte("raw"), nil},
+			},
+			{
+				name:   "Encode url unsafe strings",
+				values: []interface{}{"a/b+c?d=e&f"},
+				result: []interface{}{"a/b+c?d=e&f"},
+			},
+			{
+				name:   "Encode no values",
+				values: []interface{}{},
+				result: []interface{}{},
+			},
+		}
+	)
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				token, err := EncodePageToken(scenario.values...)
+				require.Nil(t, err, "encode error")
+				require.False(t, strings.ContainsAny(token, "+/=?&"), "token is not url safe")
+				values, err := DecodePageToken(token)
+				require.Nil(t, err, "decode error")
+				require.Equal(t, scenario.result, values, "decoded values")
+			},
+		)
+	}
+}
+
+func TestPageTokenErrors(test *testing.T) {
+	_, err := EncodePageToken(struct{}{})
+	require.True(test, errors.Is(err, ErrInvalidPageToken), "unsupported value error")
+	for _, token := range []string{"!", "bm90IGpzb24", "W3sidCI6IngiLCJ2IjoxfV0"} {
+		_, err := DecodePageToken(token)
+		require.Equal(test, ErrInvalidPageToken, err, "decode error of %q", token)
+	}
+	require.Equal(test, 3, PageSize(NewQuery("entity").WithLimit(3)), "page size")
+	require.Equal(test, DefaultPageSize, PageSize(NewQuery("entity")), "default page size")
+}
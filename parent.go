@@ -0,0 +1,112 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrCascadeUnsupported = errors.New("err_cascadeunsupported")
+)
+
+// ChildKey is the key of an entity stored under a parent entity, like the
+// items of an order. Firestore stores the child in a subcollection of the
+// parent document and spanner in a table interleaved in the parent table.
+type ChildKey interface {
+	EntityKey
+	Parent() EntityKey
+}
+
+type childEntityKey struct {
+	EntityKey
+	parent EntityKey
+}
+
+func (key childEntityKey) Parent() EntityKey {
+	return key.parent
+}
+
+func NewChildKey(parent EntityKey, key EntityKey) ChildKey {
+	return childEntityKey{EntityKey: key, parent: parent}
+}
+
+// ParentOf returns the parent of a ChildKey, it returns false for root keys.
+func ParentOf(key EntityKey) (EntityKey, bool) {
+	if tenantKey, ok := key.(tenantEntityKey); ok {
+		return ParentOf(tenantKey.EntityKey)
+	}
+	childKey, ok := key.(ChildKey)
+	if !ok || childKey.Parent() == nil {
+		return nil, false
+	}
+	return childKey.Parent(), true
+}
+
+// KeyPath returns the ancestors of the key from the root followed by the key.
+func KeyPath(key EntityKey) []EntityKey {
+	path := []EntityKey{key}
+	for parent, ok := ParentOf(key); ok; parent, ok = ParentOf(parent) {
+		path = append([]EntityKey{parent}, path...)
+	}
+	return path
+}
+
+// KeyPathParts returns the names and values of the ancestor keys followed by
+// the parts of the key, the primary key of an interleaved table.
+func KeyPathParts(key EntityKey) ([]string, []interface{}) {
+	var (
+		names  []string
+		values []interface{}
+	)
+	for _, pathKey := range KeyPath(key) {
+		keyNames, keyValues := KeyParts(pathKey)
+		names = append(names, keyNames...)
+		values = append(values, keyValues...)
+	}
+	return names, values
+}
+
+// WithParent returns a copy of the query over the children of parent.
+func (q Query) WithParent(parent EntityKey) Query {
+	q.Parent = parent
+	return q
+}
+
+// ParentFilters returns the equality filters of the parent key parts, the
+// backends without child collections select the children of Parent with
+// them. It returns nil for queries without parent.
+func (q Query) ParentFilters() []Filter {
+	if q.Parent == nil {
+		return nil
+	}
+	var (
+		names, values = KeyPathParts(q.Parent)
+		filters       = make([]Filter, len(names))
+	)
+	for index, name := range names {
+		filters[index] = Filter{Field: name, Operator: Equal, Value: values[index]}
+	}
+	return filters
+}
+
+// Children returns the query of the entityName children of parent.
+func Children(parent EntityKey, entityName string) Query {
+	return NewQuery(entityName).WithParent(parent)
+}
+
+// CascadeDeleter is implemented by repositories that delete an entity with
+// every child stored under it.
+type CascadeDeleter interface {
+	DeleteCascade(context.Context, EntityKey) error
+}
+
+// DeleteCascade deletes the entity of the key and its children, the
+// repository must implement CascadeDeleter or ErrCascadeUnsupported is
+// returned.
+func DeleteCascade(ctx context.Context, repository Repository, key EntityKey) error {
+	deleter, ok := repository.(CascadeDeleter)
+	if !ok {
+		return ErrCascadeUnsupported
+	}
+	return deleter.DeleteCascade(ctx, key)
+}
@@ -0,0 +1,47 @@
+package raizel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildKey(test *testing.T) {
+	var (
+		order = NewDynamicKey("orders", "order_id", "order1")
+		item  = NewChildKey(order, NewDynamicKey("items", "item_id", 2))
+		note  = NewTenantKey("tenant1", NewChildKey(item, NewDynamicKey("notes", "note_id", "note1")))
+	)
+	parent, found := ParentOf(note)
+	require.True(test, found, "parent of a tenant child key")
+	require.Equal(test, item, parent, "parent key")
+	_, found = ParentOf(order)
+	require.False(test, found, "parent of a root key")
+	require.Equal(test, []EntityKey{order, item, note}, KeyPath(note), "key path")
+
+	names, values := KeyPathParts(note)
+	require.Equal(test, []string{"order_id", "item_id", "note_id"}, names, "key path names")
+	require.Equal(test, []interface{}{"order1", 2, "note1"}, values, "key path values")
+	names, values = KeyParts(note)
+	require.Equal(test, []string{"note_id"}, names, "key names")
+	require.Equal(test, []interface{}{"note1"}, values, "key values")
+
+	query := Children(item, "notes")
+	require.Equal(test, "notes", query.EntityName, "children entity name")
+	require.Equal(
+		test,
+		[]Filter{
+			{Field: "order_id", Operator: Equal, Value: "order1"},
+			{Field: "item_id", Operator: Equal, Value: 2},
+		},
+		query.ParentFilters(),
+		"parent filters",
+	)
+	require.Nil(test, NewQuery("notes").ParentFilters(), "root query filters")
+}
+
+func TestDeleteCascade(test *testing.T) {
+	err := DeleteCascade(context.Background(), &keyRecorderRepository{}, NewDynamicKey("orders", "id", "1"))
+	require.Equal(test, ErrCascadeUnsupported, err, "unsupported cascade error")
+}
@@ -0,0 +1,105 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrPatchUnsupported = errors.New("err_patchunsupported")
+)
+
+// Transform is the change that an Update applies to a field.
+type Transform int
+
+const (
+	// AssignTransform sets the field to the update value.
+	AssignTransform Transform = iota
+	// IncrementTransform adds the update value to the numeric field.
+	IncrementTransform
+	// ServerTimestampTransform sets the field to the backend commit time.
+	ServerTimestampTransform
+	// ArrayAppendTransform appends the update values to the end of the array
+	// field, the values already in the array are appended again. A missing
+	// array field is created with the update values.
+	ArrayAppendTransform
+	// ArrayRemoveTransform removes every occurrence of the update values from
+	// the array field.
+	ArrayRemoveTransform
+	// DeleteTransform removes the field, the column is set to null by the
+	// backends with a fixed schema.
+	DeleteTransform
+)
+
+// Update is a change of one field of an entity. Field is a column name or a
+// dotted path of a nested firestore field.
+type Update struct {
+	Field     string
+	Transform Transform
+	Value     interface{}
+}
+
+func Assign(field string, value interface{}) Update {
+	return Update{Field: field, Transform: AssignTransform, Value: value}
+}
+
+func Increment(field string, delta interface{}) Update {
+	return Update{Field: field, Transform: IncrementTransform, Value: delta}
+}
+
+func ServerTimestamp(field string) Update {
+	return Update{Field: field, Transform: ServerTimestampTransform}
+}
+
+// ArrayAppend appends the values to the array field keeping the duplicates,
+// every backend appends the same values in the same order.
+func ArrayAppend(field string, values ...interface{}) Update {
+	return Update{Field: field, Transform: ArrayAppendTransform, Value: values}
+}
+
+func ArrayRemove(field string, values ...interface{}) Update {
+	return Update{Field: field, Transform: ArrayRemoveTransform, Value: values}
+}
+
+func DeleteField(field string) Update {
+	return Update{Field: field, Transform: DeleteTransform}
+}
+
+// Patcher is implemented by repositories that change some fields of a stored
+// entity atomically, without rewriting the fields of other writers. Patching
+// a missing entity returns ErrNotFound.
+type Patcher interface {
+	Patch(context.Context, EntityKey, ...Update) error
+}
+
+// CheckUpdates returns ErrInvalidArgument when an update changes a key field
+// of the key path or one of the columns, compared case insensitively. The
+// backends check the updates before patching, so a patch never moves the
+// entity to another key or tenant.
+func CheckUpdates(key EntityKey, updates []Update, columns ...string) error {
+	names, _ := KeyPathParts(key)
+	names = append(names, columns...)
+	for _, update := range updates {
+		for _, name := range names {
+			if strings.EqualFold(update.Field, name) {
+				return fmt.Errorf("%w: update of the key field %s", ErrInvalidArgument, update.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// Patch applies the updates to the entity of the key, the repository must
+// implement Patcher or ErrPatchUnsupported is returned.
+func Patch(ctx context.Context, repository Repository, key EntityKey, updates ...Update) error {
+	patcher, ok := repository.(Patcher)
+	if !ok {
+		return ErrPatchUnsupported
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return patcher.Patch(ctx, key, updates...)
+}
@@ -0,0 +1,77 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdates(test *testing.T) {
+	require.Equal(test, Update{Field: "name", Value: "mock"}, Assign("name", "mock"), "assign update")
+	require.Equal(
+		test, Update{Field: "age", Transform: IncrementTransform, Value: 2}, Increment("age", 2), "increment update",
+	)
+	require.Equal(
+		test,
+		Update{Field: "tags", Transform: ArrayAppendTransform, Value: []interface{}{"a", "b"}},
+		ArrayAppend("tags", "a", "b"),
+		"array append update",
+	)
+	require.Equal(test, Update{Field: "name", Transform: DeleteTransform}, DeleteField("name"), "delete update")
+}
+
+func TestCheckUpdates(test *testing.T) {
+	var (
+		key = NewTenantKey("tenant1", NewChildKey(
+			NewDynamicKey("orders", "order_id", "order1"), NewDynamicKey("items", "item_id", 2),
+		))
+		scenarios = []struct {
+			name    string
+			updates []Update
+			err     error
+		}{
+			{
+				name:    "Updates of the other fields",
+				updates: []Update{Assign("name", "mock"), Increment("age", 1)},
+			},
+			{
+				name:    "Error when the update changes the key",
+				updates: []Update{Assign("item_id", 3)},
+				err:     ErrInvalidArgument,
+			},
+			{
+				name:    "Error when the update changes the parent key",
+				updates: []Update{DeleteField("ORDER_ID")},
+				err:     ErrInvalidArgument,
+			},
+			{
+				name:    "Error when the update changes a column",
+				updates: []Update{Assign("tenant_id", "tenant2")},
+				err:     ErrInvalidArgument,
+			},
+		}
+	)
+	for index, scenario := range scenarios {
+		test.Run(fmt.Sprintf("[%d]-%s", index, scenario.name), func(t *testing.T) {
+			err := CheckUpdates(key, scenario.updates, "tenant_id")
+			require.True(t, errors.Is(err, scenario.err), "check updates error %v", err)
+		})
+	}
+}
+
+func TestPatch(test *testing.T) {
+	var (
+		ctx = context.Background()
+		key = NewDynamicKey("entities", "id", "mock1")
+	)
+	err := Patch(ctx, &keyRecorderRepository{}, key, Assign("name", "mock"))
+	require.Equal(test, ErrPatchUnsupported, err, "unsupported patch error")
+
+	err = Patch(ctx, NewTenantRepository(&keyRecorderRepository{}), key, Assign("name", "mock"))
+	require.Equal(test, ErrTenantRequired, err, "tenant patch error")
+	err = Patch(WithTenant(ctx, "tenant1"), NewTenantRepository(&keyRecorderRepository{}), key, DeleteField("name"))
+	require.Equal(test, ErrPatchUnsupported, err, "tenant unsupported patch error")
+}
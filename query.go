@@ -0,0 +1,86 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrIteratorDone = errors.New("err_iteratordone")
+)
+
+type Operator string
+
+const (
+	Equal        Operator = "=="
+	Less         Operator = "<"
+	LessEqual    Operator = "<="
+	Greater      Operator = ">"
+	GreaterEqual Operator = ">="
+)
+
+type Direction int
+
+const (
+	Asc Direction = iota
+	Desc
+)
+
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    interface{}
+}
+
+type Order struct {
+	Field     string
+	Direction Direction
+}
+
+// Query is a backend neutral query over the entities of EntityName, or over
+// the children of Parent when it is set. Tenant scopes the query the way a
+// TenantKey scopes a key, it is honored by Count and Aggregate. The builder
+// methods return a copy of the query and never change the receiver.
+type Query struct {
+	EntityName string
+	Parent     EntityKey
+	Tenant     string
+	Filters    []Filter
+	Orders     []Order
+	Limit      int
+}
+
+func NewQuery(entityName string) Query {
+	return Query{EntityName: entityName}
+}
+
+func (q Query) Where(field string, operator Operator, value interface{}) Query {
+	filters := make([]Filter, len(q.Filters), len(q.Filters)+1)
+	copy(filters, q.Filters)
+	q.Filters = append(filters, Filter{Field: field, Operator: operator, Value: value})
+	return q
+}
+
+func (q Query) OrderBy(field string, direction Direction) Query {
+	orders := make([]Order, len(q.Orders), len(q.Orders)+1)
+	copy(orders, q.Orders)
+	q.Orders = append(orders, Order{Field: field, Direction: direction})
+	return q
+}
+
+func (q Query) WithLimit(limit int) Query {
+	q.Limit = limit
+	return q
+}
+
+// Iterator streams query results. Next loads the next result into entity and
+// returns ErrIteratorDone when there are no more results.
+type Iterator interface {
+	Next(context.Context, Entity) error
+	Stop()
+}
+
+// Queryable is implemented by repositories that can stream query results.
+type Queryable interface {
+	Query(context.Context, Query) (Iterator, error)
+}
@@ -0,0 +1,33 @@
+package raizel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(test *testing.T) {
+	var (
+		query    = NewQuery("entity_name")
+		filtered = query.Where("age", Greater, 10)
+		ordered  = filtered.OrderBy("name", Asc).OrderBy("age", Desc)
+		limited  = ordered.WithLimit(5)
+		branched = filtered.Where("name", Equal, "mock")
+	)
+	require.Equal(test, "entity_name", query.EntityName, "entityname invalid value")
+	require.Empty(test, query.Filters, "builder changed the receiver filters")
+	require.Empty(test, filtered.Orders, "builder changed the receiver orders")
+	require.Zero(test, ordered.Limit, "builder changed the receiver limit")
+
+	require.Equal(test, []Filter{{Field: "age", Operator: Greater, Value: 10}}, filtered.Filters, "filters invalid value")
+	require.Equal(
+		test,
+		[]Order{{Field: "name", Direction: Asc}, {Field: "age", Direction: Desc}},
+		limited.Orders,
+		"orders invalid value",
+	)
+	require.Equal(test, 5, limited.Limit, "limit invalid value")
+	require.Len(test, branched.Filters, 2, "branched filters invalid length")
+	require.Len(test, ordered.Filters, 1, "branch changed the sibling filters")
+	require.NotNil(test, ErrIteratorDone, "erriteratordone invalid instance")
+}
@@ -0,0 +1,446 @@
+package raizel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnexpectedCall = errors.New("err_unexpectedcall")
+	ErrUnreplayedCall = errors.New("err_unreplayedcall")
+)
+
+// recordedErrors are the errors replayed as themselves, the other recorded
+// errors that contain one of them are replayed wrapping it and the rest as new
+// errors with the same message.
+var recordedErrors = []error{
+	ErrNotFound, ErrInvalidArgument, ErrTenantRequired, ErrKeyMismatch,
+	ErrPatchUnsupported, ErrCascadeUnsupported, ErrCountUnsupported, ErrAggregateUnsupported,
+	ErrTransactionUnsupported, ErrNotQueryable, ErrPageUnsupported, ErrWatchUnsupported,
+	ErrUnavailable, context.DeadlineExceeded, context.Canceled,
+}
+
+// Record is a recorded repository call, a line of a golden JSONL file. Query
+// is the query of Count and the aggregation of Aggregate, Entity is the
+// payload of the written entities and Result the read entity or the result of
+// Exists, Count and Aggregate.
+type Record struct {
+	Operation  Operation       `json:"operation"`
+	EntityName string          `json:"entity_name"`
+	Tenant     string          `json:"tenant,omitempty"`
+	KeyName    string          `json:"key_name,omitempty"`
+	KeyValue   json.RawMessage `json:"key_value,omitempty"`
+	Query      json.RawMessage `json:"query,omitempty"`
+	Entity     json.RawMessage `json:"entity,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Err        string          `json:"error,omitempty"`
+}
+
+// recordedQuery is the JSON of a query, the parent key is recorded as the
+// entity name, key name and value of every key of its path.
+type recordedQuery struct {
+	Query
+	Parent []string `json:",omitempty"`
+}
+
+func newRecordedQuery(query Query) recordedQuery {
+	recorded := recordedQuery{Query: query}
+	if query.Parent == nil {
+		return recorded
+	}
+	for _, key := range KeyPath(query.Parent) {
+		recorded.Parent = append(recorded.Parent, fmt.Sprintf("%s/%s=%v", key.EntityName(), key.Name(), key.Value()))
+	}
+	return recorded
+}
+
+type recordedAggregation struct {
+	Aggregation
+	Query recordedQuery
+}
+
+func newKeyRecord(operation Operation, key EntityKey) (Record, error) {
+	value, err := json.Marshal(key.Value())
+	if err != nil {
+		return Record{}, err
+	}
+	tenant, _ := TenantOf(key)
+	return Record{
+		Operation:  operation,
+		EntityName: key.EntityName(),
+		Tenant:     tenant,
+		KeyName:    key.Name(),
+		KeyValue:   value,
+	}, nil
+}
+
+// newEntityRecord returns the record of the key with the entity payload, the
+// payload is read before the call runs the entity hooks.
+func newEntityRecord(operation Operation, key EntityKey, entity Entity) (Record, error) {
+	record, err := newKeyRecord(operation, key)
+	if err != nil {
+		return Record{}, err
+	}
+	if record.Entity, err = json.Marshal(entity); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// newQueryRecord returns the record of the query, value is the query or the
+// aggregation of the call.
+func newQueryRecord(operation Operation, query Query, value interface{}) (Record, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Operation: operation, EntityName: query.EntityName, Tenant: query.Tenant, Query: data}, nil
+}
+
+func newCountRecord(query Query) (Record, error) {
+	return newQueryRecord(CountOperation, query, newRecordedQuery(query))
+}
+
+func newAggregateRecord(aggregation Aggregation) (Record, error) {
+	return newQueryRecord(
+		AggregateOperation, aggregation.Query,
+		recordedAggregation{Aggregation: aggregation, Query: newRecordedQuery(aggregation.Query)},
+	)
+}
+
+// matches reports whether the call record is the recorded call with the same
+// query and entity payload.
+func (r Record) matches(call Record) bool {
+	return r.Operation == call.Operation &&
+		r.EntityName == call.EntityName &&
+		r.Tenant == call.Tenant &&
+		r.KeyName == call.KeyName &&
+		string(r.KeyValue) == string(call.KeyValue) &&
+		sameJSON(r.Query, call.Query) &&
+		sameJSON(r.Entity, call.Entity)
+}
+
+// sameJSON reports whether the documents have the same values, regardless of
+// the spacing and of the order of the object members.
+func sameJSON(document, other json.RawMessage) bool {
+	if len(document) == 0 || len(other) == 0 {
+		return len(document) == len(other)
+	}
+	var documentValue, otherValue interface{}
+	if json.Unmarshal(document, &documentValue) != nil || json.Unmarshal(other, &otherValue) != nil {
+		return string(document) == string(other)
+	}
+	return reflect.DeepEqual(documentValue, otherValue)
+}
+
+func (r Record) String() string {
+	call := fmt.Sprintf("%s %s", r.Operation, r.EntityName)
+	if r.KeyName != "" {
+		call = fmt.Sprintf("%s %s=%s", call, r.KeyName, r.KeyValue)
+	}
+	if len(r.Query) > 0 {
+		call = fmt.Sprintf("%s query %s", call, r.Query)
+	}
+	if len(r.Entity) > 0 {
+		call = fmt.Sprintf("%s entity %s", call, r.Entity)
+	}
+	return call
+}
+
+// err returns the recorded error, wrapping the recorded sentinel found in
+// the message so errors.Is matches it.
+func (r Record) err() error {
+	if r.Err == "" {
+		return nil
+	}
+	for _, recorded := range recordedErrors {
+		message := recorded.Error()
+		for offset := 0; ; {
+			index := strings.Index(r.Err[offset:], message)
+			if index < 0 {
+				break
+			}
+			start, end := offset+index, offset+index+len(message)
+			if (start == 0 || strings.HasSuffix(r.Err[:start], ": ")) && (end == len(r.Err) || r.Err[end] == ':') {
+				if start == 0 && end == len(r.Err) {
+					return recorded
+				}
+				return fmt.Errorf("%s%w%s", r.Err[:start], recorded, r.Err[end:])
+			}
+			offset = end
+		}
+	}
+	return errors.New(r.Err)
+}
+
+type recordingRepository struct {
+	repository Repository
+	mutex      sync.Mutex
+	encoder    *json.Encoder
+}
+
+// NewRecordingRepository wraps repository writing every call, with its
+// payload, result and error, as a JSONL Record to writer. The recorded file
+// feeds NewReplayRepository.
+func NewRecordingRepository(repository Repository, writer io.Writer) Repository {
+	return &recordingRepository{repository: repository, encoder: json.NewEncoder(writer)}
+}
+
+func (r *recordingRepository) write(record Record, result interface{}, err error) error {
+	if err != nil {
+		record.Err = err.Error()
+	} else if result != nil {
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		record.Result = data
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if encodeErr := r.encoder.Encode(record); encodeErr != nil {
+		return encodeErr
+	}
+	return err
+}
+
+func (r *recordingRepository) writeKey(operation Operation, key EntityKey, err error) error {
+	record, recordErr := newKeyRecord(operation, key)
+	if recordErr != nil {
+		return recordErr
+	}
+	return r.write(record, nil, err)
+}
+
+func (r *recordingRepository) Get(ctx context.Context, key EntityKey, entity Entity) error {
+	err := r.repository.Get(ctx, key, entity)
+	record, recordErr := newKeyRecord(GetOperation, key)
+	if recordErr != nil {
+		return recordErr
+	}
+	return r.write(record, entity, err)
+}
+
+func (r *recordingRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	record, err := newEntityRecord(SetOperation, key, entity)
+	if err != nil {
+		return err
+	}
+	return r.write(record, nil, r.repository.Set(ctx, key, entity))
+}
+
+func (r *recordingRepository) Delete(ctx context.Context, key EntityKey) error {
+	return r.writeKey(DeleteOperation, key, r.repository.Delete(ctx, key))
+}
+
+// Patch records the updates as the entity payload.
+func (r *recordingRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	record, err := newEntityRecord(PatchOperation, key, updates)
+	if err != nil {
+		return err
+	}
+	return r.write(record, nil, Patch(ctx, r.repository, key, updates...))
+}
+
+func (r *recordingRepository) DeleteCascade(ctx context.Context, key EntityKey) error {
+	return r.writeKey(DeleteCascadeOperation, key, DeleteCascade(ctx, r.repository, key))
+}
+
+func (r *recordingRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	exists, err := Exists(ctx, r.repository, key)
+	record, recordErr := newKeyRecord(ExistsOperation, key)
+	if recordErr != nil {
+		return false, recordErr
+	}
+	return exists, r.write(record, exists, err)
+}
+
+func (r *recordingRepository) Count(ctx context.Context, query Query) (int64, error) {
+	record, err := newCountRecord(query)
+	if err != nil {
+		return 0, err
+	}
+	count, err := Count(ctx, r.repository, query)
+	return count, r.write(record, count, err)
+}
+
+func (r *recordingRepository) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	record, err := newAggregateRecord(aggregation)
+	if err != nil {
+		return nil, err
+	}
+	results, err := Aggregate(ctx, r.repository, aggregation)
+	return results, r.write(record, results, err)
+}
+
+func (r *recordingRepository) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
+
+type replayRepository struct {
+	mutex   sync.Mutex
+	records []Record
+	next    int
+}
+
+// NewReplayRepository reads the JSONL records of a NewRecordingRepository
+// and returns a repository serving the recorded results and errors in order.
+// A call that is not the next recorded call fails with ErrUnexpectedCall and
+// Close fails with ErrUnreplayedCall while recorded calls remain.
+func NewReplayRepository(reader io.Reader) (Repository, error) {
+	var (
+		replay  = new(replayRepository)
+		scanner = bufio.NewScanner(reader)
+	)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidArgument, len(replay.records)+1, err)
+		}
+		replay.records = append(replay.records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// replay returns the recorded call when it is the next one.
+func (r *replayRepository) replay(call Record) (Record, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.next >= len(r.records) {
+		return Record{}, fmt.Errorf("%w: %s after the %d recorded calls", ErrUnexpectedCall, call, len(r.records))
+	}
+	recorded := r.records[r.next]
+	if !recorded.matches(call) {
+		return Record{}, fmt.Errorf("%w: %s, call %d recorded %s", ErrUnexpectedCall, call, r.next+1, recorded)
+	}
+	r.next++
+	return recorded, nil
+}
+
+func (r *replayRepository) replayKey(operation Operation, key EntityKey) (Record, error) {
+	call, err := newKeyRecord(operation, key)
+	if err != nil {
+		return Record{}, err
+	}
+	return r.replay(call)
+}
+
+// replayResult decodes the recorded result into result and returns the
+// recorded error.
+func replayResult(recorded Record, result interface{}) error {
+	if err := recorded.err(); err != nil {
+		return err
+	}
+	if len(recorded.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(recorded.Result, result)
+}
+
+func (r *replayRepository) Get(_ context.Context, key EntityKey, entity Entity) error {
+	recorded, err := r.replayKey(GetOperation, key)
+	if err != nil {
+		return err
+	}
+	return replayResult(recorded, entity)
+}
+
+// Set replays the recorded Set of the key with the same entity payload.
+func (r *replayRepository) Set(_ context.Context, key EntityKey, entity Entity) error {
+	call, err := newEntityRecord(SetOperation, key, entity)
+	if err != nil {
+		return err
+	}
+	recorded, err := r.replay(call)
+	if err != nil {
+		return err
+	}
+	return recorded.err()
+}
+
+func (r *replayRepository) Delete(_ context.Context, key EntityKey) error {
+	recorded, err := r.replayKey(DeleteOperation, key)
+	if err != nil {
+		return err
+	}
+	return recorded.err()
+}
+
+// Patch replays the recorded Patch of the key with the same updates.
+func (r *replayRepository) Patch(_ context.Context, key EntityKey, updates ...Update) error {
+	call, err := newEntityRecord(PatchOperation, key, updates)
+	if err != nil {
+		return err
+	}
+	recorded, err := r.replay(call)
+	if err != nil {
+		return err
+	}
+	return recorded.err()
+}
+
+func (r *replayRepository) DeleteCascade(_ context.Context, key EntityKey) error {
+	recorded, err := r.replayKey(DeleteCascadeOperation, key)
+	if err != nil {
+		return err
+	}
+	return recorded.err()
+}
+
+func (r *replayRepository) Exists(_ context.Context, key EntityKey) (bool, error) {
+	recorded, err := r.replayKey(ExistsOperation, key)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	return exists, replayResult(recorded, &exists)
+}
+
+func (r *replayRepository) Count(_ context.Context, query Query) (int64, error) {
+	call, err := newCountRecord(query)
+	if err != nil {
+		return 0, err
+	}
+	recorded, err := r.replay(call)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	return count, replayResult(recorded, &count)
+}
+
+func (r *replayRepository) Aggregate(_ context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	call, err := newAggregateRecord(aggregation)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := r.replay(call)
+	if err != nil {
+		return nil, err
+	}
+	var results []AggregateResult
+	return results, replayResult(recorded, &results)
+}
+
+func (r *replayRepository) Close(context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if remaining := len(r.records) - r.next; remaining > 0 {
+		return fmt.Errorf("%w: %d of %d recorded calls, next %s", ErrUnreplayedCall, remaining, len(r.records), r.records[r.next])
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package raizel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordedEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// storeRepository keeps the entities as json, like a real backend.
+type storeRepository struct {
+	counterRepository
+	entities map[string][]byte
+}
+
+func (r *storeRepository) Get(_ context.Context, key EntityKey, entity Entity) error {
+	data, found := r.entities[fmt.Sprint(key.Value())]
+	if !found {
+		return ErrNotFound
+	}
+	return json.Unmarshal(data, entity)
+}
+
+func (r *storeRepository) Set(_ context.Context, key EntityKey, entity Entity) error {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	r.entities[fmt.Sprint(key.Value())] = data
+	return nil
+}
+
+func (r *storeRepository) Delete(_ context.Context, key EntityKey) error {
+	delete(r.entities, fmt.Sprint(key.Value()))
+	return nil
+}
+
+// interact runs the same calls against the recorder and the replay.
+func interact(t *testing.T, repository Repository) {
+	var (
+		ctx    = context.Background()
+		key    = NewDynamicKey("entities", "id", "mock1")
+		entity = recordedEntity{ID: "mock1", Name: "Mock One", Age: 21}
+		result recordedEntity
+	)
+	require.Equal(t, ErrNotFound, repository.Get(ctx, key, &result), "missing get error")
+	require.Nil(t, repository.Set(ctx, key, &entity), "set error")
+	require.Nil(t, repository.Get(ctx, key, &result), "get error")
+	require.Equal(t, entity, result, "get invalid value")
+	exists, err := Exists(ctx, repository, key)
+	require.Nil(t, err, "exists error")
+	require.True(t, exists, "exists invalid value")
+	count, err := Count(ctx, repository, NewQuery("entities"))
+	require.Nil(t, err, "count error")
+	require.Equal(t, int64(3), count, "count invalid value")
+	require.Nil(t, repository.Delete(ctx, key), "delete error")
+}
+
+func TestRecordReplay(test *testing.T) {
+	var (
+		golden   bytes.Buffer
+		store    = &storeRepository{entities: make(map[string][]byte)}
+		recorder = NewRecordingRepository(store, &golden)
+	)
+	interact(test, recorder)
+	require.Nil(test, recorder.Close(context.Background()), "recorder close error")
+	require.Equal(test, 6, strings.Count(golden.String(), "\n"), "recorded lines invalid length")
+
+	replay, err := NewReplayRepository(bytes.NewReader(golden.Bytes()))
+	require.Nil(test, err, "new replay error")
+	interact(test, replay)
+	require.Nil(test, replay.Close(context.Background()), "replay close error")
+}
+
+type testReplayRepository struct {
+	name string
+	call func(Repository) error
+	err  error
+}
+
+func TestReplayRepository(test *testing.T) {
+	var (
+		ctx     = context.Background()
+		key     = NewDynamicKey("entities", "id", "mock1")
+		records = `{"operation":"get","entity_name":"entities","key_name":"id","key_value":"mock1","error":"err_unavailable"}` + "\n" +
+			`{"operation":"set","entity_name":"entities","key_name":"id","key_value":"mock1","entity":{"age":0,"id":"mock1","name":""},"error":"err_custom"}` + "\n" +
+			`{"operation":"count","entity_name":"entities","query":{"EntityName":"entities","Tenant":"","Filters":[{"Field":"age","Operator":">","Value":18}],"Orders":null,"Limit":0},"error":"source: err_notfound: count entities"}` + "\n"
+	)
+	scenarios := []testReplayRepository{
+		{
+			name: "Replays a recorded error",
+			call: func(repository Repository) error {
+				return repository.Get(ctx, key, new(recordedEntity))
+			},
+			err: ErrUnavailable,
+		},
+		{
+			name: "Flags an out of order call",
+			call: func(repository Repository) error {
+				return repository.Set(ctx, key, new(recordedEntity))
+			},
+			err: ErrUnexpectedCall,
+		},
+		{
+			name: "Flags a call with another key",
+			call: func(repository Repository) error {
+				return repository.Get(ctx, NewDynamicKey("entities", "id", "mock2"), new(recordedEntity))
+			},
+			err: ErrUnexpectedCall,
+		},
+		{
+			name: "Flags a set with another payload",
+			call: func(repository Repository) error {
+				_ = repository.Get(ctx, key, new(recordedEntity))
+				return repository.Set(ctx, key, &recordedEntity{ID: "mock1", Name: "other"})
+			},
+			err: ErrUnexpectedCall,
+		},
+		{
+			name: "Flags a count with another query",
+			call: func(repository Repository) error {
+				_ = repository.Get(ctx, key, new(recordedEntity))
+				_ = repository.Set(ctx, key, &recordedEntity{ID: "mock1"})
+				_, err := Count(ctx, repository, NewQuery("entities").Where("age", Greater, 21))
+				return err
+			},
+			err: ErrUnexpectedCall,
+		},
+		{
+			name: "Replays a wrapped recorded error",
+			call: func(repository Repository) error {
+				_ = repository.Get(ctx, key, new(recordedEntity))
+				_ = repository.Set(ctx, key, &recordedEntity{ID: "mock1"})
+				_, err := Count(ctx, repository, NewQuery("entities").Where("age", Greater, 18))
+				return err
+			},
+			err: ErrNotFound,
+		},
+		{
+			name: "Flags a call after the recorded calls",
+			call: func(repository Repository) error {
+				_ = repository.Get(ctx, key, new(recordedEntity))
+				_ = repository.Set(ctx, key, &recordedEntity{ID: "mock1"})
+				_, _ = Count(ctx, repository, NewQuery("entities").Where("age", Greater, 18))
+				return repository.Delete(ctx, key)
+			},
+			err: ErrUnexpectedCall,
+		},
+		{
+			name: "Flags the unreplayed calls on close",
+			call: func(repository Repository) error {
+				_ = repository.Get(ctx, key, new(recordedEntity))
+				return repository.Close(ctx)
+			},
+			err: ErrUnreplayedCall,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				repository, err := NewReplayRepository(strings.NewReader(records))
+				require.Nil(t, err, "new replay error")
+				err = scenario.call(repository)
+				require.True(t, errors.Is(err, scenario.err), "call error: %v", err)
+			},
+		)
+	}
+
+	_, err := NewReplayRepository(strings.NewReader("{invalid\n"))
+	require.True(test, errors.Is(err, ErrInvalidArgument), "invalid record error")
+
+	repository, err := NewReplayRepository(strings.NewReader(records))
+	require.Nil(test, err, "new replay error")
+	_ = repository.Get(ctx, key, new(recordedEntity))
+	require.EqualError(test, repository.Set(ctx, key, &recordedEntity{ID: "mock1"}), "err_custom", "custom error")
+	_, err = Count(ctx, repository, NewQuery("entities").Where("age", Greater, 18))
+	require.EqualError(test, err, "source: err_notfound: count entities", "wrapped error message")
+}
@@ -6,7 +6,8 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("err_notfound")
+	ErrNotFound        = errors.New("err_notfound")
+	ErrInvalidArgument = errors.New("err_invalidargument")
 )
 
 type EntityKey interface {
@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type cassandra struct{}
+
+func (cassandra) scalarType(columnType reflect.Type) (string, bool) {
+	switch columnType {
+	case timeType:
+		return "timestamp", true
+	case bytesType:
+		return "blob", true
+	}
+	switch columnType.Kind() {
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int8:
+		return "tinyint", true
+	case reflect.Int16:
+		return "smallint", true
+	case reflect.Int32:
+		return "int", true
+	case reflect.Int, reflect.Int64:
+		return "bigint", true
+	case reflect.Float32:
+		return "float", true
+	case reflect.Float64:
+		return "double", true
+	case reflect.String:
+		return "text", true
+	}
+	return "", false
+}
+
+func (cassandra) sliceType(element string) string {
+	return fmt.Sprintf("list<%s>", element)
+}
+
+func (cassandra) mapType(key, value string) (string, bool) {
+	return fmt.Sprintf("map<%s, %s>", key, value), true
+}
+
+func (cassandra) valuerType() string {
+	return "text"
+}
+
+func (cassandra) normalize(columnType string) string {
+	columnType = strings.ToLower(strings.Join(strings.Fields(columnType), ""))
+	return strings.Replace(columnType, ",", ", ", -1)
+}
+
+// notNull is false because cassandra has no not null columns.
+func (cassandra) notNull() bool {
+	return false
+}
+
+func (cassandra) createTable(table *Table, types []string) string {
+	var ddl strings.Builder
+	fmt.Fprintf(&ddl, "CREATE TABLE %s (\n", table.Name)
+	for index, column := range table.Columns {
+		fmt.Fprintf(&ddl, "    %s %s,\n", column.Name, types[index])
+	}
+	primaryKey := append([]string{fmt.Sprintf("(%s)", joinColumns(table.PrimaryKey))}, table.ClusteringKey...)
+	fmt.Fprintf(&ddl, "    PRIMARY KEY (%s)\n)", joinColumns(primaryKey))
+	return ddl.String()
+}
+
+// createIndex writes a secondary index, cassandra indexes have a single column
+// and are never unique.
+func (cassandra) createIndex(table *Table, index Index) (string, error) {
+	if index.Unique || len(index.Columns) != 1 {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedIndex, index.Name)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", index.Name, table.Name, index.Columns[0]), nil
+}
+
+func (cassandra) dropIndex(table *Table, index Index) string {
+	return fmt.Sprintf("DROP INDEX %s", index.Name)
+}
+
+func (cassandra) addColumn(table *Table, column Column, columnType string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s %s", table.Name, column.Name, columnType)
+}
+
+func (cassandra) dropColumn(table *Table, column Column) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP %s", table.Name, column.Name)
+}
+
+func (cassandra) alterColumn(table *Table, column Column, columnType string) (string, error) {
+	return "", fmt.Errorf("%w: %s.%s type", ErrUnsupportedChange, table.Name, column.Name)
+}
@@ -0,0 +1,217 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUnsupportedIndex = errors.New("err_unsupportedindex")
+)
+
+// Dialect writes the DDL statements of a backend.
+type Dialect interface {
+	// ColumnType returns the backend type of the column.
+	ColumnType(Column) (string, error)
+	// CreateTable returns the statements that create the table and indexes.
+	CreateTable(*Table) ([]string, error)
+	// Diff returns the statements that change the existing table into the
+	// desired one, changes the backend cannot apply return
+	// ErrUnsupportedChange. An existing table without columns is missing and
+	// its statements are the CreateTable ones.
+	Diff(existing, desired *Table) ([]string, error)
+}
+
+var (
+	Postgres  Dialect = dialect{postgres{}}
+	Cassandra Dialect = dialect{cassandra{}}
+	Spanner   Dialect = dialect{spanner{}}
+)
+
+// statements renders the statements of a dialect, dialect implements the
+// parts that are shared by every backend.
+type statements interface {
+	scalarType(reflect.Type) (string, bool)
+	sliceType(string) string
+	mapType(string, string) (string, bool)
+	valuerType() string
+	normalize(string) string
+	notNull() bool
+	createTable(*Table, []string) string
+	createIndex(*Table, Index) (string, error)
+	dropIndex(*Table, Index) string
+	addColumn(*Table, Column, string) string
+	dropColumn(*Table, Column) string
+	alterColumn(*Table, Column, string) (string, error)
+}
+
+type dialect struct {
+	statements
+}
+
+func implementsValuer(columnType reflect.Type) bool {
+	return columnType.Implements(valuerType) || reflect.PtrTo(columnType).Implements(valuerType)
+}
+
+func (d dialect) goType(columnType reflect.Type) (string, error) {
+	if columnType == nil {
+		return "", ErrUnsupportedType
+	}
+	if scalar, ok := d.scalarType(columnType); ok {
+		return scalar, nil
+	}
+	if implementsValuer(columnType) {
+		return d.valuerType(), nil
+	}
+	switch columnType.Kind() {
+	case reflect.Slice, reflect.Array:
+		element, err := d.goType(columnType.Elem())
+		if err != nil {
+			return "", err
+		}
+		return d.sliceType(element), nil
+	case reflect.Map:
+		key, err := d.goType(columnType.Key())
+		if err != nil {
+			return "", err
+		}
+		value, err := d.goType(columnType.Elem())
+		if err != nil {
+			return "", err
+		}
+		if mapped, ok := d.mapType(key, value); ok {
+			return mapped, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedType, columnType)
+}
+
+func (d dialect) ColumnType(column Column) (string, error) {
+	if column.DBType != "" {
+		return d.normalize(column.DBType), nil
+	}
+	columnType, err := d.goType(column.Type)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", column.Name, err)
+	}
+	return columnType, nil
+}
+
+func (d dialect) columnTypes(table *Table) ([]string, error) {
+	types := make([]string, len(table.Columns))
+	for index, column := range table.Columns {
+		columnType, err := d.ColumnType(column)
+		if err != nil {
+			return nil, err
+		}
+		types[index] = columnType
+	}
+	return types, nil
+}
+
+func (d dialect) CreateTable(table *Table) ([]string, error) {
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	types, err := d.columnTypes(table)
+	if err != nil {
+		return nil, err
+	}
+	statements := []string{d.createTable(table, types)}
+	for _, index := range table.Indexes {
+		statement, err := d.createIndex(table, index)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d dialect) Diff(existing, desired *Table) ([]string, error) {
+	if err := desired.Validate(); err != nil {
+		return nil, err
+	}
+	if existing == nil || len(existing.Columns) == 0 {
+		return d.CreateTable(desired)
+	}
+	if !sameColumns(existing.keys(), desired.keys()) {
+		return nil, fmt.Errorf("%w: %s primary key", ErrUnsupportedChange, desired.Name)
+	}
+	var (
+		drops   []string
+		changes []string
+		creates []string
+		indexes = make(map[string]Index, len(existing.Indexes))
+	)
+	for _, index := range existing.Indexes {
+		indexes[index.Name] = index
+	}
+	for _, index := range desired.Indexes {
+		current, found := indexes[index.Name]
+		delete(indexes, index.Name)
+		if found && current.Unique == index.Unique && sameColumns(current.Columns, index.Columns) {
+			continue
+		}
+		if found {
+			drops = append(drops, d.dropIndex(existing, current))
+		}
+		statement, err := d.createIndex(desired, index)
+		if err != nil {
+			return nil, err
+		}
+		creates = append(creates, statement)
+	}
+	for _, index := range existing.Indexes {
+		if _, dropped := indexes[index.Name]; dropped {
+			drops = append(drops, d.dropIndex(existing, index))
+		}
+	}
+	for _, column := range existing.Columns {
+		if _, found := desired.Column(column.Name); !found {
+			changes = append(changes, d.dropColumn(existing, column))
+		}
+	}
+	for _, column := range desired.Columns {
+		columnType, err := d.ColumnType(column)
+		if err != nil {
+			return nil, err
+		}
+		current, found := existing.Column(column.Name)
+		if !found {
+			changes = append(changes, d.addColumn(desired, column, columnType))
+			continue
+		}
+		currentType, err := d.ColumnType(current)
+		if err != nil {
+			return nil, err
+		}
+		if currentType == columnType && (!d.notNull() || current.Nullable == column.Nullable) {
+			continue
+		}
+		statement, err := d.alterColumn(desired, column, columnType)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, statement)
+	}
+	return append(append(drops, changes...), creates...), nil
+}
+
+func joinColumns(columns []string) string {
+	return strings.Join(columns, ", ")
+}
@@ -0,0 +1,289 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rjansen/raizel/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func mockEntityTable(t *testing.T) *Table {
+	table, err := NewTable("mock_entity", mock.NewMockEntity(), DefaultTag)
+	require.Nil(t, err, "new table error")
+	return table.WithPrimaryKey("id").WithIndex(false, "string")
+}
+
+type testCreateTable struct {
+	name       string
+	dialect    Dialect
+	table      func(*testing.T) *Table
+	statements []string
+	err        error
+}
+
+func TestCreateTable(test *testing.T) {
+	scenarios := []testCreateTable{
+		{
+			name:    "Postgres table",
+			dialect: Postgres,
+			table:   mockEntityTable,
+			statements: []string{
+				`create table mock_entity (
+    id text not null,
+    string text not null,
+    integer integer not null,
+    float real not null,
+    date_time timestamp not null,
+    boolean boolean not null,
+    object jsonb not null,
+    constraint pk_mock_entity primary key(id)
+)`,
+				"create index ix_mock_entity_string on mock_entity (string)",
+			},
+		},
+		{
+			name:    "Cassandra table with clustering key",
+			dialect: Cassandra,
+			table: func(t *testing.T) *Table {
+				return mockEntityTable(t).WithClusteringKey("date_time")
+			},
+			statements: []string{
+				`CREATE TABLE mock_entity (
+    id text,
+    string text,
+    integer int,
+    float float,
+    date_time timestamp,
+    boolean boolean,
+    object text,
+    PRIMARY KEY ((id), date_time)
+)`,
+				"CREATE INDEX ix_mock_entity_string ON mock_entity (string)",
+			},
+		},
+		{
+			name:    "Spanner table with nullable column",
+			dialect: Spanner,
+			table: func(t *testing.T) *Table {
+				table := mockEntityTable(t).WithIndex(true, "integer", "float")
+				table.Columns[1].Nullable = true
+				return table
+			},
+			statements: []string{
+				`CREATE TABLE mock_entity (
+    id STRING(MAX) NOT NULL,
+    string STRING(MAX),
+    integer INT64 NOT NULL,
+    float FLOAT64 NOT NULL,
+    date_time TIMESTAMP NOT NULL,
+    boolean BOOL NOT NULL,
+    object STRING(MAX) NOT NULL,
+) PRIMARY KEY (id)`,
+				"CREATE INDEX ix_mock_entity_string ON mock_entity (string)",
+				"CREATE UNIQUE INDEX ix_mock_entity_integer_float ON mock_entity (integer, float)",
+			},
+		},
+		{
+			name:    "Spanner table interleaved in the parent table",
+			dialect: Spanner,
+			table: func(t *testing.T) *Table {
+				table := &Table{
+					Name:   "items",
+					Parent: "orders",
+					Columns: []Column{
+						{Name: "order_id", Type: reflect.TypeOf("")},
+						{Name: "item_id", Type: reflect.TypeOf(int64(0))},
+					},
+				}
+				return table.WithPrimaryKey("order_id", "item_id")
+			},
+			statements: []string{
+				`CREATE TABLE items (
+    order_id STRING(MAX) NOT NULL,
+    item_id INT64 NOT NULL,
+) PRIMARY KEY (order_id, item_id),
+    INTERLEAVE IN PARENT orders ON DELETE CASCADE`,
+			},
+		},
+		{
+			name:    "Error when cassandra index is unique",
+			dialect: Cassandra,
+			table: func(t *testing.T) *Table {
+				return mockEntityTable(t).WithIndex(true, "integer")
+			},
+			err: ErrUnsupportedIndex,
+		},
+		{
+			name:    "Error when the column type is unsupported",
+			dialect: Spanner,
+			table: func(t *testing.T) *Table {
+				table := mockEntityTable(t)
+				table.Columns = append(table.Columns, Column{Name: "channel", Type: reflect.TypeOf(make(chan int))})
+				return table
+			},
+			err: ErrUnsupportedType,
+		},
+		{
+			name:    "Error when the primary key is missing",
+			dialect: Postgres,
+			table: func(t *testing.T) *Table {
+				return mockEntityTable(t).WithPrimaryKey()
+			},
+			err: ErrMissingKey,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				statements, err := scenario.dialect.CreateTable(scenario.table(t))
+				require.True(t, errors.Is(err, scenario.err), "create table error %v", err)
+				require.Equal(t, scenario.statements, statements, "statements")
+			},
+		)
+	}
+}
+
+func TestColumnType(test *testing.T) {
+	scenarios := []struct {
+		dialect Dialect
+		column  Column
+		want    string
+	}{
+		{dialect: Postgres, column: Column{Type: reflect.TypeOf([]int64(nil))}, want: "bigint[]"},
+		{dialect: Postgres, column: Column{Type: reflect.TypeOf([]byte(nil))}, want: "bytea"},
+		{dialect: Postgres, column: Column{DBType: "Timestamp  Without Time Zone"}, want: "timestamp"},
+		{dialect: Postgres, column: Column{DBType: "int8[]"}, want: "bigint[]"},
+		{dialect: Postgres, column: Column{DBType: "timestamp without time zone[]"}, want: "timestamp[]"},
+		{dialect: Postgres, column: Column{DBType: "Character Varying (255)"}, want: "varchar(255)"},
+		{dialect: Postgres, column: Column{DBType: "numeric(10, 2)"}, want: "numeric(10,2)"},
+		{dialect: Cassandra, column: Column{Type: reflect.TypeOf(map[string]int64(nil))}, want: "map<text, bigint>"},
+		{dialect: Cassandra, column: Column{Type: reflect.TypeOf([]float64(nil))}, want: "list<double>"},
+		{dialect: Cassandra, column: Column{DBType: "MAP<text,int>"}, want: "map<text, int>"},
+		{dialect: Spanner, column: Column{Type: reflect.TypeOf([]string(nil))}, want: "ARRAY<STRING(MAX)>"},
+		{dialect: Spanner, column: Column{DBType: "string(36)"}, want: "STRING(36)"},
+	}
+	for index, scenario := range scenarios {
+		columnType, err := scenario.dialect.ColumnType(scenario.column)
+		require.Nil(test, err, "[%d] column type error", index)
+		require.Equal(test, scenario.want, columnType, "[%d] column type", index)
+	}
+}
+
+type testDiff struct {
+	name       string
+	dialect    Dialect
+	existing   *Table
+	statements []string
+	err        error
+}
+
+func existingTable(primaryKey string, columns ...Column) *Table {
+	return (&Table{Name: "mock_entity", Columns: columns}).WithPrimaryKey(primaryKey)
+}
+
+func TestDiff(test *testing.T) {
+	scenarios := []testDiff{
+		{
+			name:    "Postgres adds, drops and alters columns",
+			dialect: Postgres,
+			existing: existingTable(
+				"id",
+				Column{Name: "id", DBType: "text"},
+				Column{Name: "string", DBType: "character varying"},
+				Column{Name: "integer", DBType: "integer"},
+				Column{Name: "float", DBType: "real"},
+				Column{Name: "date_time", DBType: "timestamp without time zone"},
+				Column{Name: "boolean", DBType: "boolean", Nullable: true},
+				Column{Name: "legacy", DBType: "text", Nullable: true},
+			).WithIndex(false, "legacy"),
+			statements: []string{
+				"drop index ix_mock_entity_legacy",
+				"alter table mock_entity drop column legacy",
+				"alter table mock_entity alter column string type text, alter column string set not null",
+				"alter table mock_entity alter column boolean type boolean, alter column boolean set not null",
+				"alter table mock_entity add column object jsonb not null",
+				"create index ix_mock_entity_string on mock_entity (string)",
+			},
+		},
+		{
+			name:    "Spanner adds a nullable column",
+			dialect: Spanner,
+			existing: existingTable(
+				"id",
+				Column{Name: "id", DBType: "STRING(MAX)"},
+				Column{Name: "string", DBType: "STRING(MAX)"},
+				Column{Name: "integer", DBType: "INT64"},
+				Column{Name: "float", DBType: "FLOAT64"},
+				Column{Name: "date_time", DBType: "TIMESTAMP"},
+				Column{Name: "boolean", DBType: "BOOL"},
+			).WithIndex(false, "string"),
+			statements: []string{
+				"ALTER TABLE mock_entity ADD COLUMN object STRING(MAX)",
+			},
+		},
+		{
+			name:    "Cassandra ignores nullability",
+			dialect: Cassandra,
+			existing: existingTable(
+				"id",
+				Column{Name: "id", DBType: "text", Nullable: true},
+				Column{Name: "string", DBType: "text", Nullable: true},
+				Column{Name: "integer", DBType: "int", Nullable: true},
+				Column{Name: "float", DBType: "float", Nullable: true},
+				Column{Name: "date_time", DBType: "timestamp", Nullable: true},
+				Column{Name: "boolean", DBType: "boolean", Nullable: true},
+				Column{Name: "object", DBType: "text", Nullable: true},
+			),
+			statements: []string{
+				"CREATE INDEX ix_mock_entity_string ON mock_entity (string)",
+			},
+		},
+		{
+			name:    "Error when cassandra column type changes",
+			dialect: Cassandra,
+			existing: existingTable(
+				"id",
+				Column{Name: "id", DBType: "text"},
+				Column{Name: "integer", DBType: "bigint"},
+			),
+			err: ErrUnsupportedChange,
+		},
+		{
+			name:     "Postgres creates the missing table",
+			dialect:  Postgres,
+			existing: &Table{Name: "mock_entity"},
+			statements: []string{
+				"create table mock_entity (\n" +
+					"    id text not null,\n" +
+					"    string text not null,\n" +
+					"    integer integer not null,\n" +
+					"    float real not null,\n" +
+					"    date_time timestamp not null,\n" +
+					"    boolean boolean not null,\n" +
+					"    object jsonb not null,\n" +
+					"    constraint pk_mock_entity primary key(id)\n)",
+				"create index ix_mock_entity_string on mock_entity (string)",
+			},
+		},
+		{
+			name:     "Error when the primary key changes",
+			dialect:  Postgres,
+			existing: existingTable("string", Column{Name: "id", DBType: "text"}, Column{Name: "string", DBType: "text"}),
+			err:      ErrUnsupportedChange,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				statements, err := scenario.dialect.Diff(scenario.existing, mockEntityTable(t))
+				require.True(t, errors.Is(err, scenario.err), "diff error %v", err)
+				require.Equal(t, scenario.statements, statements, "statements")
+			},
+		)
+	}
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var postgresAliases = map[string]string{
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"character varying":           "varchar",
+	"character":                   "char",
+	"bpchar":                      "char",
+	"int":                         "integer",
+	"int4":                        "integer",
+	"int8":                        "bigint",
+	"int2":                        "smallint",
+	"float4":                      "real",
+	"float8":                      "double precision",
+	"bool":                        "boolean",
+}
+
+type postgres struct{}
+
+func (postgres) scalarType(columnType reflect.Type) (string, bool) {
+	switch columnType {
+	case timeType:
+		return "timestamp", true
+	case bytesType:
+		return "bytea", true
+	}
+	switch columnType.Kind() {
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint", true
+	case reflect.Int32, reflect.Uint16:
+		return "integer", true
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint", true
+	case reflect.Float32:
+		return "real", true
+	case reflect.Float64:
+		return "double precision", true
+	case reflect.String:
+		return "text", true
+	}
+	return "", false
+}
+
+func (postgres) sliceType(element string) string {
+	return element + "[]"
+}
+
+func (postgres) mapType(key, value string) (string, bool) {
+	return "jsonb", true
+}
+
+func (postgres) valuerType() string {
+	return "jsonb"
+}
+
+// normalize returns the alias of the type, of the element type of arrays,
+// keeping the type modifiers like the varchar(n) length.
+func (p postgres) normalize(columnType string) string {
+	columnType = strings.Join(strings.Fields(strings.ToLower(columnType)), " ")
+	if strings.HasSuffix(columnType, "[]") {
+		return p.normalize(strings.TrimSuffix(columnType, "[]")) + "[]"
+	}
+	modifiers := ""
+	if open := strings.IndexByte(columnType, '('); open >= 0 {
+		columnType, modifiers = strings.TrimSpace(columnType[:open]), strings.ReplaceAll(columnType[open:], " ", "")
+	}
+	if alias, found := postgresAliases[columnType]; found {
+		return alias + modifiers
+	}
+	return columnType + modifiers
+}
+
+func (postgres) notNull() bool {
+	return true
+}
+
+func postgresNull(column Column) string {
+	if column.Nullable {
+		return ""
+	}
+	return " not null"
+}
+
+func (postgres) createTable(table *Table, types []string) string {
+	var ddl strings.Builder
+	fmt.Fprintf(&ddl, "create table %s (\n", table.Name)
+	for index, column := range table.Columns {
+		fmt.Fprintf(&ddl, "    %s %s%s,\n", column.Name, types[index], postgresNull(column))
+	}
+	fmt.Fprintf(&ddl, "    constraint pk_%s primary key(%s)\n)", table.Name, joinColumns(table.keys()))
+	return ddl.String()
+}
+
+func (postgres) createIndex(table *Table, index Index) (string, error) {
+	unique := ""
+	if index.Unique {
+		unique = "unique "
+	}
+	return fmt.Sprintf(
+		"create %sindex %s on %s (%s)", unique, index.Name, table.Name, joinColumns(index.Columns),
+	), nil
+}
+
+func (postgres) dropIndex(table *Table, index Index) string {
+	return fmt.Sprintf("drop index %s", index.Name)
+}
+
+func (postgres) addColumn(table *Table, column Column, columnType string) string {
+	return fmt.Sprintf("alter table %s add column %s %s%s", table.Name, column.Name, columnType, postgresNull(column))
+}
+
+func (postgres) dropColumn(table *Table, column Column) string {
+	return fmt.Sprintf("alter table %s drop column %s", table.Name, column.Name)
+}
+
+func (postgres) alterColumn(table *Table, column Column, columnType string) (string, error) {
+	nullable := "set not null"
+	if column.Nullable {
+		nullable = "drop not null"
+	}
+	return fmt.Sprintf(
+		"alter table %s alter column %s type %s, alter column %s %s",
+		table.Name, column.Name, columnType, column.Name, nullable,
+	), nil
+}
@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTag = "db"
+)
+
+var (
+	ErrInvalidEntity     = errors.New("err_invalidentity")
+	ErrUnknownColumn     = errors.New("err_unknowncolumn")
+	ErrUnsupportedType   = errors.New("err_unsupportedtype")
+	ErrUnsupportedChange = errors.New("err_unsupportedchange")
+	ErrMissingKey        = errors.New("err_missingkey")
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	nullTypes  = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	}
+)
+
+// Column is a table column. Type is the Go type of the entity field, DBType is
+// the column type read from an existing schema and takes precedence over Type.
+type Column struct {
+	Name     string
+	Type     reflect.Type
+	DBType   string
+	Nullable bool
+}
+
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Table describes the table of an entity. PrimaryKey holds the partition key
+// columns and ClusteringKey the columns that sort the rows of a partition, the
+// backends without partitions use both as the primary key. Parent names the
+// table that spanner interleaves the table in, the primary key must start with
+// the parent primary key.
+type Table struct {
+	Name          string
+	Parent        string
+	Columns       []Column
+	PrimaryKey    []string
+	ClusteringKey []string
+	Indexes       []Index
+}
+
+// NewTable reads the exported fields of the entity struct, the column name is
+// the tag value or the field name. Pointer and sql.Null* fields are nullable.
+func NewTable(name string, entity interface{}, tag string) (*Table, error) {
+	entityType := reflect.TypeOf(entity)
+	for entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType == nil || entityType.Kind() != reflect.Struct {
+		return nil, ErrInvalidEntity
+	}
+	table := &Table{Name: name}
+	table.addFields(entityType, tag)
+	return table, nil
+}
+
+func (t *Table) addFields(entityType reflect.Type, tag string) {
+	for index := 0; index < entityType.NumField(); index++ {
+		field := entityType.Field(index)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			t.addFields(field.Type, tag)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		var (
+			fieldType = field.Type
+			nullable  bool
+		)
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType, nullable = fieldType.Elem(), true
+		}
+		if valueType, isNull := nullTypes[fieldType]; isNull {
+			fieldType, nullable = valueType, true
+		}
+		t.Columns = append(t.Columns, Column{Name: name, Type: fieldType, Nullable: nullable})
+	}
+}
+
+// Column returns the named column.
+func (t *Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+func (t *Table) columnsExist(columns []string) error {
+	for _, name := range columns {
+		if _, found := t.Column(name); !found {
+			return fmt.Errorf("%w: %s.%s", ErrUnknownColumn, t.Name, name)
+		}
+	}
+	return nil
+}
+
+// WithPrimaryKey sets the primary, or partition, key columns.
+func (t *Table) WithPrimaryKey(columns ...string) *Table {
+	t.PrimaryKey = columns
+	return t
+}
+
+// WithClusteringKey sets the columns that sort the rows of a partition.
+func (t *Table) WithClusteringKey(columns ...string) *Table {
+	t.ClusteringKey = columns
+	return t
+}
+
+// WithIndex adds an index named after the table and the columns.
+func (t *Table) WithIndex(unique bool, columns ...string) *Table {
+	t.Indexes = append(t.Indexes, Index{
+		Name:    fmt.Sprintf("ix_%s_%s", t.Name, strings.Join(columns, "_")),
+		Columns: columns,
+		Unique:  unique,
+	})
+	return t
+}
+
+// Validate checks that the table has a primary key and that the keys and
+// indexes refer to its columns.
+func (t *Table) Validate() error {
+	if len(t.PrimaryKey) == 0 {
+		return fmt.Errorf("%w: %s", ErrMissingKey, t.Name)
+	}
+	if err := t.columnsExist(t.PrimaryKey); err != nil {
+		return err
+	}
+	if err := t.columnsExist(t.ClusteringKey); err != nil {
+		return err
+	}
+	for _, index := range t.Indexes {
+		if err := t.columnsExist(index.Columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Table) keys() []string {
+	keys := make([]string, 0, len(t.PrimaryKey)+len(t.ClusteringKey))
+	return append(append(keys, t.PrimaryKey...), t.ClusteringKey...)
+}
+
+func (t *Table) isKey(column string) bool {
+	for _, key := range t.keys() {
+		if key == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the tables of the registered entities.
+type Registry struct {
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tables: make(map[string]*Table)}
+}
+
+// Register validates and stores the table, replacing a table with the same
+// name.
+func (r *Registry) Register(table *Table) error {
+	if err := table.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tables[table.Name] = table
+	return nil
+}
+
+func (r *Registry) Table(name string) (*Table, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	table, found := r.tables[name]
+	return table, found
+}
+
+// Tables returns the registered tables sorted by name.
+func (r *Registry) Tables() []*Table {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tables := make([]*Table, 0, len(r.tables))
+	for _, table := range r.tables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+	return tables
+}
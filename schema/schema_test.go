@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type auditFields struct {
+	CreatedAt time.Time  `db:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+type tableEntity struct {
+	auditFields
+	ID       string         `db:"id"`
+	Tenant   string         `db:"tenant"`
+	Nickname sql.NullString `db:"nickname"`
+	Tags     []string       `db:"tags"`
+	Ignored  string         `db:"-"`
+	Age      int
+	hidden   int
+}
+
+func TestNewTable(test *testing.T) {
+	table, err := NewTable("entity", &tableEntity{}, DefaultTag)
+	require.Nil(test, err, "new table error")
+	require.Equal(
+		test,
+		[]Column{
+			{Name: "created_at", Type: timeType},
+			{Name: "deleted_at", Type: timeType, Nullable: true},
+			{Name: "id", Type: reflect.TypeOf("")},
+			{Name: "tenant", Type: reflect.TypeOf("")},
+			{Name: "nickname", Type: reflect.TypeOf(""), Nullable: true},
+			{Name: "tags", Type: reflect.TypeOf([]string(nil))},
+			{Name: "Age", Type: reflect.TypeOf(0)},
+		},
+		table.Columns,
+		"table columns",
+	)
+
+	_, err = NewTable("entity", "invalid", DefaultTag)
+	require.Equal(test, ErrInvalidEntity, err, "invalid entity error")
+}
+
+type testTableValidate struct {
+	name  string
+	table *Table
+	err   error
+}
+
+func (scenario *testTableValidate) setup(t *testing.T) {
+	table, err := NewTable("mock_entity", mock.NewMockEntity(), DefaultTag)
+	require.Nil(t, err, "new table error")
+	scenario.table.Name = table.Name
+	scenario.table.Columns = table.Columns
+}
+
+func TestTableValidate(test *testing.T) {
+	scenarios := []testTableValidate{
+		{
+			name:  "Valid table",
+			table: (&Table{}).WithPrimaryKey("id").WithClusteringKey("date_time").WithIndex(false, "string"),
+		},
+		{
+			name:  "Error when the primary key is missing",
+			table: &Table{},
+			err:   ErrMissingKey,
+		},
+		{
+			name:  "Error when the key column is unknown",
+			table: (&Table{}).WithPrimaryKey("missing"),
+			err:   ErrUnknownColumn,
+		},
+		{
+			name:  "Error when the index column is unknown",
+			table: (&Table{}).WithPrimaryKey("id").WithIndex(true, "missing"),
+			err:   ErrUnknownColumn,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+				err := scenario.table.Validate()
+				require.True(t, errors.Is(err, scenario.err) || err == scenario.err, "validate error %v", err)
+
+				registry := NewRegistry()
+				require.Equal(t, err, registry.Register(scenario.table), "register error")
+				_, found := registry.Table(scenario.table.Name)
+				require.Equal(t, err == nil, found, "registered table")
+			},
+		)
+	}
+}
+
+func TestRegistryTables(test *testing.T) {
+	registry := NewRegistry()
+	for _, name := range []string{"b", "c", "a"} {
+		table, err := NewTable(name, mock.MockEntity{}, DefaultTag)
+		require.Nil(test, err, "new table error")
+		require.Nil(test, registry.Register(table.WithPrimaryKey("id")), "register error")
+	}
+	tables := registry.Tables()
+	require.Len(test, tables, 3, "tables length")
+	for index, name := range []string{"a", "b", "c"} {
+		require.Equal(test, name, tables[index].Name, "table name")
+	}
+}
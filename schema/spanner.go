@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type spanner struct{}
+
+func (spanner) scalarType(columnType reflect.Type) (string, bool) {
+	switch columnType {
+	case timeType:
+		return "TIMESTAMP", true
+	case bytesType:
+		return "BYTES(MAX)", true
+	}
+	switch columnType.Kind() {
+	case reflect.Bool:
+		return "BOOL", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "INT64", true
+	case reflect.Float32, reflect.Float64:
+		return "FLOAT64", true
+	case reflect.String:
+		return "STRING(MAX)", true
+	}
+	return "", false
+}
+
+func (spanner) sliceType(element string) string {
+	return fmt.Sprintf("ARRAY<%s>", element)
+}
+
+// mapType is false because spanner has no map columns, maps implementing
+// driver.Valuer are stored as strings.
+func (spanner) mapType(key, value string) (string, bool) {
+	return "", false
+}
+
+func (spanner) valuerType() string {
+	return "STRING(MAX)"
+}
+
+func (spanner) normalize(columnType string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(columnType), ""))
+}
+
+func (spanner) notNull() bool {
+	return true
+}
+
+func spannerNull(column Column) string {
+	if column.Nullable {
+		return ""
+	}
+	return " NOT NULL"
+}
+
+func (spanner) createTable(table *Table, types []string) string {
+	var ddl strings.Builder
+	fmt.Fprintf(&ddl, "CREATE TABLE %s (\n", table.Name)
+	for index, column := range table.Columns {
+		fmt.Fprintf(&ddl, "    %s %s%s,\n", column.Name, types[index], spannerNull(column))
+	}
+	fmt.Fprintf(&ddl, ") PRIMARY KEY (%s)", joinColumns(table.keys()))
+	if table.Parent != "" {
+		fmt.Fprintf(&ddl, ",\n    INTERLEAVE IN PARENT %s ON DELETE CASCADE", table.Parent)
+	}
+	return ddl.String()
+}
+
+func (spanner) createIndex(table *Table, index Index) (string, error) {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf(
+		"CREATE %sINDEX %s ON %s (%s)", unique, index.Name, table.Name, joinColumns(index.Columns),
+	), nil
+}
+
+func (spanner) dropIndex(table *Table, index Index) string {
+	return fmt.Sprintf("DROP INDEX %s", index.Name)
+}
+
+// addColumn always adds a nullable column, spanner only accepts new not null
+// columns on empty tables. A following Diff alters the column to not null.
+func (spanner) addColumn(table *Table, column Column, columnType string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table.Name, column.Name, columnType)
+}
+
+func (spanner) dropColumn(table *Table, column Column) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table.Name, column.Name)
+}
+
+func (spanner) alterColumn(table *Table, column Column, columnType string) (string, error) {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ALTER COLUMN %s %s%s", table.Name, column.Name, columnType, spannerNull(column),
+	), nil
+}
@@ -0,0 +1,37 @@
+package spanner
+
+import (
+	"context"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	databasepb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+// DatabaseAdmin changes the schema of spanner databases.
+type DatabaseAdmin interface {
+	// UpdateDDL applies the statements as one batch and waits for it.
+	UpdateDDL(context.Context, string, []string) error
+	Close() error
+}
+
+type databaseAdmin struct {
+	*database.DatabaseAdminClient
+}
+
+func NewDatabaseAdmin(c *database.DatabaseAdminClient) DatabaseAdmin {
+	return &databaseAdmin{DatabaseAdminClient: c}
+}
+
+func (a *databaseAdmin) UpdateDDL(ctx context.Context, db string, statements []string) error {
+	operation, err := a.DatabaseAdminClient.UpdateDatabaseDdl(
+		ctx,
+		&databasepb.UpdateDatabaseDdlRequest{
+			Database:   db,
+			Statements: statements,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return operation.Wait(ctx)
+}
@@ -0,0 +1,170 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// measureExpression returns the aggregate function call of the measure, the
+// sums are cast to FLOAT64 like the averages.
+func measureExpression(measure raizel.Measure) string {
+	switch measure.Func {
+	case raizel.CountFunc:
+		if measure.Field == "" {
+			return "COUNT(*)"
+		}
+	case raizel.SumFunc:
+		return fmt.Sprintf("CAST(SUM(%s) AS FLOAT64)", quoteIdentifier(measure.Field))
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(string(measure.Func)), quoteIdentifier(measure.Field))
+}
+
+// genericValue returns the Go value of a column of a scalar type, nil for a
+// null column.
+func genericValue(column spanner.GenericColumnValue) (interface{}, error) {
+	switch column.Type.GetCode() {
+	case sppb.TypeCode_STRING:
+		var value spanner.NullString
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.StringVal, nil
+	case sppb.TypeCode_INT64:
+		var value spanner.NullInt64
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.Int64, nil
+	case sppb.TypeCode_FLOAT64:
+		var value spanner.NullFloat64
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.Float64, nil
+	case sppb.TypeCode_BOOL:
+		var value spanner.NullBool
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.Bool, nil
+	case sppb.TypeCode_TIMESTAMP:
+		var value spanner.NullTime
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.Time, nil
+	case sppb.TypeCode_DATE:
+		var value spanner.NullDate
+		if err := column.Decode(&value); err != nil || !value.Valid {
+			return nil, err
+		}
+		return value.Date, nil
+	case sppb.TypeCode_BYTES:
+		var value []byte
+		if err := column.Decode(&value); err != nil || value == nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("%w: column type %s", ErrUnmappedColumn, column.Type.GetCode())
+}
+
+// aggregateValue reads the measure or group column at index of the row.
+func aggregateValue(row Row, index int, function raizel.AggregateFunc) (interface{}, error) {
+	switch function {
+	case raizel.CountFunc:
+		var count int64
+		if err := row.Column(index, &count); err != nil {
+			return nil, err
+		}
+		return count, nil
+	case raizel.SumFunc, raizel.AvgFunc:
+		var number spanner.NullFloat64
+		if err := row.Column(index, &number); err != nil || !number.Valid {
+			return nil, err
+		}
+		return number.Float64, nil
+	}
+	var column spanner.GenericColumnValue
+	if err := row.Column(index, &column); err != nil {
+		return nil, err
+	}
+	return genericValue(column)
+}
+
+// Aggregate runs a statement of the aggregate functions grouped and ordered
+// by the group columns, the query tenant filters the TenantColumn.
+func (r *repository) Aggregate(ctx context.Context, aggregation raizel.Aggregation) ([]raizel.AggregateResult, error) {
+	var (
+		query   = aggregation.Query
+		filters = append(query.ParentFilters(), query.Filters...)
+		columns = make([]string, 0, len(aggregation.GroupBy)+len(aggregation.Measures))
+		orders  = make([]raizel.Order, len(aggregation.GroupBy))
+	)
+	if query.Tenant != "" {
+		filters = append(
+			[]raizel.Filter{{Field: TenantColumn, Operator: raizel.Equal, Value: query.Tenant}}, filters...,
+		)
+	}
+	params := make(map[string]interface{}, len(filters))
+	conditions, err := filterConditions(filters, params)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, len(aggregation.GroupBy))
+	for index, field := range aggregation.GroupBy {
+		groups[index] = quoteIdentifier(field)
+	}
+	columns = append(columns, groups...)
+	for _, measure := range aggregation.Measures {
+		columns = append(columns, fmt.Sprintf("%s AS %s", measureExpression(measure), quoteIdentifier(measure.Alias)))
+	}
+	clause := statementClause(conditions, nil, 0)
+	if len(aggregation.GroupBy) > 0 {
+		for index, field := range aggregation.GroupBy {
+			orders[index] = raizel.Order{Field: field}
+		}
+		clause = fmt.Sprintf(
+			"%s GROUP BY %s%s",
+			clause, strings.Join(groups, ", "), statementClause(nil, orders, 0),
+		)
+	}
+	iterator := r.client.Single().Query(ctx, Statement{
+		SQL:    fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(columns, ", "), quoteIdentifier(query.EntityName), clause),
+		Params: params,
+	})
+	defer iterator.Stop()
+
+	results := []raizel.AggregateResult{}
+	err = iterator.Do(func(row Row) error {
+		result := raizel.AggregateResult{
+			Group:  make(map[string]interface{}, len(aggregation.GroupBy)),
+			Values: make(map[string]interface{}, len(aggregation.Measures)),
+		}
+		for index, field := range aggregation.GroupBy {
+			value, err := aggregateValue(row, index, "")
+			if err != nil {
+				return err
+			}
+			result.Group[field] = value
+		}
+		for index, measure := range aggregation.Measures {
+			value, err := aggregateValue(row, len(aggregation.GroupBy)+index, measure.Func)
+			if err != nil {
+				return err
+			}
+			result.Values[measure.Alias] = value
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,133 @@
+package spanner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+func stringColumn(value string) spanner.GenericColumnValue {
+	return spanner.GenericColumnValue{
+		Type:  &sppb.Type{Code: sppb.TypeCode_STRING},
+		Value: &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: value}},
+	}
+}
+
+type testRepositoryAggregate struct {
+	name        string
+	aggregation raizel.Aggregation
+	statement   rspanner.Statement
+	columns     map[int]interface{}
+	results     []raizel.AggregateResult
+	err         error
+}
+
+func TestRepositoryAggregate(test *testing.T) {
+	scenarios := []testRepositoryAggregate{
+		{
+			name: "Aggregate the filtered rows",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table").Where("age", raizel.Greater, 18),
+				raizel.CountAll("total"),
+				raizel.Sum("age", "ages"),
+			),
+			statement: rspanner.Statement{
+				SQL:    "SELECT COUNT(*) AS `total`, CAST(SUM(`age`) AS FLOAT64) AS `ages` FROM `entity_table` WHERE `age` > @p0",
+				Params: map[string]interface{}{"p0": 18},
+			},
+			columns: map[int]interface{}{
+				0: int64(2),
+				1: spanner.NullFloat64{Float64: 61, Valid: true},
+			},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{},
+					Values: map[string]interface{}{"total": int64(2), "ages": 61.0},
+				},
+			},
+		},
+		{
+			name: "Aggregate the tenant rows by group",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table").InTenant("tenant_a"),
+				raizel.Avg("age", "average"),
+				raizel.Min("name", "first"),
+			).GroupedBy("city"),
+			statement: rspanner.Statement{
+				SQL: "SELECT `city`, AVG(`age`) AS `average`, MIN(`name`) AS `first` FROM `entity_table` " +
+					"WHERE `tenant_id` = @p0 GROUP BY `city` ORDER BY `city` ASC",
+				Params: map[string]interface{}{"p0": "tenant_a"},
+			},
+			columns: map[int]interface{}{
+				0: stringColumn("city_a"),
+				1: spanner.NullFloat64{},
+				2: stringColumn("mock"),
+			},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{"city": "city_a"},
+					Values: map[string]interface{}{"average": nil, "first": "mock"},
+				},
+			},
+		},
+		{
+			name: "Error when the filter is invalid",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 18), raizel.CountAll("total"),
+			),
+			err: rspanner.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					client      = new(spmock.ClientMock)
+					transaction = new(spmock.ReadOnlyTransactionMock)
+					iterator    = spmock.NewRowIteratorMock()
+					row         = spmock.NewRowMock()
+				)
+				client.On("Single").Return(transaction)
+				transaction.On("Query", mock.Anything, scenario.statement).Return(iterator)
+				iterator.On("Do", mock.Anything).Run(func(args mock.Arguments) {
+					require.Nil(t, args.Get(0).(func(rspanner.Row) error)(row), "row error")
+				}).Return(nil)
+				iterator.On("Stop")
+				for index, value := range scenario.columns {
+					value := value
+					switch value.(type) {
+					case int64:
+						row.On("Column", index, mock.Anything).Run(func(args mock.Arguments) {
+							*args.Get(1).(*int64) = value.(int64)
+						}).Return(nil)
+					case spanner.NullFloat64:
+						row.On("Column", index, mock.Anything).Run(func(args mock.Arguments) {
+							*args.Get(1).(*spanner.NullFloat64) = value.(spanner.NullFloat64)
+						}).Return(nil)
+					case spanner.GenericColumnValue:
+						row.On("Column", index, mock.Anything).Run(func(args mock.Arguments) {
+							*args.Get(1).(*spanner.GenericColumnValue) = value.(spanner.GenericColumnValue)
+						}).Return(nil)
+					}
+				}
+
+				results, err := raizel.Aggregate(context.Background(), rspanner.NewRepository(client), scenario.aggregation)
+				require.Equal(t, scenario.err, err, "aggregate error")
+				require.Equal(t, scenario.results, results, "aggregate results")
+				if scenario.err == nil {
+					iterator.AssertExpectations(t)
+				}
+			},
+		)
+	}
+}
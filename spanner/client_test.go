@@ -1,4 +1,4 @@
-package spanner
+package spanner_test
 
 import (
 	"context"
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/spanner"
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/rjansen/raizel/spanner/internal/testutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
@@ -32,20 +33,20 @@ func newSpannerClientMock(t *testing.T) (*testutil.MockCloudSpanner, *spanner.Cl
 }
 
 func TestClient(t *testing.T) {
-	client := NewClient(new(spanner.Client))
+	client := rspanner.NewClient(new(spanner.Client))
 	require.NotNil(t, client, "invalid client instance")
-	require.Implements(t, (*Client)(nil), client, "invalid client type")
+	require.Implements(t, (*rspanner.Client)(nil), client, "invalid client type")
 }
 
 func TestClientReadOnlyTransaction(t *testing.T) {
-	client := NewClient(new(spanner.Client))
+	client := rspanner.NewClient(new(spanner.Client))
 	require.NotNil(t, client, "invalid client instance")
 	transaction := client.ReadOnlyTransaction()
 	require.NotNil(t, transaction, "invalid transaction instance")
 }
 
 func TestClientSingle(t *testing.T) {
-	client := NewClient(new(spanner.Client))
+	client := rspanner.NewClient(new(spanner.Client))
 	require.NotNil(t, client, "invalid client instance")
 	transaction := client.Single()
 	require.NotNil(t, transaction, "invalid transaction instance")
@@ -55,7 +56,7 @@ func TestClientBatchReadOnlyTransaction(t *testing.T) {
 	mockServer, mockClient := newSpannerClientMock(t)
 	defer mockServer.Stop()
 
-	client := NewClient(mockClient)
+	client := rspanner.NewClient(mockClient)
 	require.NotNil(t, client, "invalid client instance")
 	transaction, err := client.BatchReadOnlyTransaction(context.Background(), spanner.StrongRead())
 	require.Nil(t, err, "transaction error")
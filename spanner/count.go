@@ -0,0 +1,53 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc/codes"
+)
+
+// Exists reads only the primary key columns of the key row.
+func (r *repository) Exists(ctx context.Context, key raizel.EntityKey) (bool, error) {
+	columns, _ := keyColumns(key)
+	_, err := r.client.Single().ReadRow(ctx, key.EntityName(), entityKey(key), columns)
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Count runs a COUNT(*) of the query filters, the query tenant filters the
+// TenantColumn.
+func (r *repository) Count(ctx context.Context, query raizel.Query) (int64, error) {
+	filters := append(query.ParentFilters(), query.Filters...)
+	if query.Tenant != "" {
+		filters = append(
+			[]raizel.Filter{{Field: TenantColumn, Operator: raizel.Equal, Value: query.Tenant}}, filters...,
+		)
+	}
+	params := make(map[string]interface{}, len(filters))
+	conditions, err := filterConditions(filters, params)
+	if err != nil {
+		return 0, err
+	}
+	iterator := r.client.Single().Query(ctx, Statement{
+		SQL:    fmt.Sprintf("SELECT COUNT(*) FROM %s%s", quoteIdentifier(query.EntityName), statementClause(conditions, nil, 0)),
+		Params: params,
+	})
+	defer iterator.Stop()
+	row, err := iterator.Next()
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := row.Column(0, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
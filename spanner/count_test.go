@@ -0,0 +1,148 @@
+package spanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testRepositoryExists struct {
+	name    string
+	key     raizel.EntityKey
+	columns []string
+	readErr error
+	exists  bool
+	err     error
+}
+
+func TestRepositoryExists(test *testing.T) {
+	var (
+		key      = testEntityKey{table: "entity_table", name: "id", value: "identifier"}
+		readErr  = errors.New("read error")
+		notFound = status.Error(codes.NotFound, "row not found")
+	)
+	scenarios := []testRepositoryExists{
+		{
+			name:    "Exists reads the key columns",
+			key:     key,
+			columns: []string{"id"},
+			exists:  true,
+		},
+		{
+			name:    "Exists reads the tenant key columns",
+			key:     raizel.NewTenantKey("tenant_a", key),
+			columns: []string{rspanner.TenantColumn, "id"},
+			exists:  true,
+		},
+		{
+			name:    "Missing row",
+			key:     key,
+			columns: []string{"id"},
+			readErr: notFound,
+		},
+		{
+			name:    "Error when the read fails",
+			key:     key,
+			columns: []string{"id"},
+			readErr: readErr,
+			err:     readErr,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					client      = new(spmock.ClientMock)
+					transaction = new(spmock.ReadOnlyTransactionMock)
+				)
+				client.On("Single").Return(transaction)
+				if scenario.readErr != nil {
+					transaction.On(
+						"ReadRow", mock.Anything, "entity_table", rspanner.EntityKey(scenario.key), scenario.columns,
+					).Return(nil, scenario.readErr)
+				} else {
+					transaction.On(
+						"ReadRow", mock.Anything, "entity_table", rspanner.EntityKey(scenario.key), scenario.columns,
+					).Return(spmock.NewRowMock(), nil)
+				}
+
+				exists, err := raizel.Exists(context.Background(), rspanner.NewRepository(client), scenario.key)
+				require.Equal(t, scenario.err, err, "exists error")
+				require.Equal(t, scenario.exists, exists, "exists result")
+				transaction.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+type testRepositoryCount struct {
+	name      string
+	query     raizel.Query
+	statement rspanner.Statement
+	err       error
+}
+
+func TestRepositoryCount(test *testing.T) {
+	scenarios := []testRepositoryCount{
+		{
+			name:  "Count the filtered rows",
+			query: raizel.NewQuery("entity_table").Where("age", raizel.Greater, 18).WithLimit(10),
+			statement: rspanner.Statement{
+				SQL:    "SELECT COUNT(*) FROM `entity_table` WHERE `age` > @p0",
+				Params: map[string]interface{}{"p0": 18},
+			},
+		},
+		{
+			name:  "Count the tenant rows",
+			query: raizel.NewQuery("entity_table").InTenant("tenant_a"),
+			statement: rspanner.Statement{
+				SQL:    "SELECT COUNT(*) FROM `entity_table` WHERE `tenant_id` = @p0",
+				Params: map[string]interface{}{"p0": "tenant_a"},
+			},
+		},
+		{
+			name:  "Error when the filter is invalid",
+			query: raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 18),
+			err:   rspanner.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					client      = new(spmock.ClientMock)
+					transaction = new(spmock.ReadOnlyTransactionMock)
+					iterator    = spmock.NewRowIteratorMock()
+					row         = spmock.NewRowMock()
+				)
+				client.On("Single").Return(transaction)
+				transaction.On("Query", mock.Anything, scenario.statement).Return(iterator)
+				iterator.On("Next").Return(row, nil)
+				iterator.On("Stop")
+				row.On("Column", 0, mock.Anything).Run(func(args mock.Arguments) {
+					*args.Get(1).(*int64) = 42
+				}).Return(nil)
+
+				count, err := raizel.Count(context.Background(), rspanner.NewRepository(client), scenario.query)
+				require.Equal(t, scenario.err, err, "count error")
+				if scenario.err != nil {
+					client.AssertNotCalled(t, "Single")
+					return
+				}
+				require.Equal(t, int64(42), count, "count result")
+				iterator.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -0,0 +1,14 @@
+package spanner
+
+var (
+	EntityColumns               = entityColumns
+	EntityKey                   = entityKey
+	EntityMutation              = entityMutation
+	NewBatchReadOnlyTransaction = newBatchReadOnlyTransaction
+	NewReadOnlyTransaction      = newReadOnlyTransaction
+	NewRow                      = newRow
+	NewRowIterator              = newRowIterator
+	PatchMutation               = patchMutation
+	PatchStatement              = patchStatement
+	QuoteIdentifier             = quoteIdentifier
+)
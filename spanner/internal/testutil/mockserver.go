@@ -47,8 +47,9 @@ type MockCtlMsg struct {
 	Err error
 }
 
-// MockCloudSpanner is a mock implementation of SpannerServer interface.
-// TODO: make MockCloudSpanner a full-fleged Cloud Spanner implementation.
+// MockCloudSpanner is a mock implementation of SpannerServer interface that
+// answers a fixed set of statements, it is kept for the stream and resume
+// token tests. spannertest.Server is the functional in-memory implementation.
 type MockCloudSpanner struct {
 	sppb.SpannerServer
 
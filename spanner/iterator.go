@@ -0,0 +1,125 @@
+package spanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	ErrInvalidFilter  = errors.New("err_invalidfilter")
+	ErrUnmappedColumn = errors.New("err_unmappedcolumn")
+)
+
+var operators = map[raizel.Operator]string{
+	raizel.Equal:        "=",
+	raizel.Less:         "<",
+	raizel.LessEqual:    "<=",
+	raizel.Greater:      ">",
+	raizel.GreaterEqual: ">=",
+}
+
+type entityIterator struct {
+	ctx    context.Context
+	client Client
+	table  string
+	clause string
+	params map[string]interface{}
+	rows   RowIterator
+}
+
+// NewIterator returns a raizel.Iterator that reads one row at a time from
+// rows.
+func NewIterator(rows RowIterator) raizel.Iterator {
+	return &entityIterator{rows: rows}
+}
+
+// Next runs the query with the columns of the first entity, spanner does not
+// allow reading columns that the entity does not map.
+func (i *entityIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	if i.rows == nil {
+		i.rows = i.client.Single().Query(i.ctx, Statement{
+			SQL: fmt.Sprintf(
+				"SELECT %s FROM %s%s", strings.Join(entityColumns(entity), ", "), i.table, i.clause,
+			),
+			Params: i.params,
+		})
+	}
+	row, err := i.rows.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return raizel.ErrIteratorDone
+		}
+		return err
+	}
+	return toStruct(row, entity)
+}
+
+func (i *entityIterator) Stop() {
+	if i.rows != nil {
+		i.rows.Stop()
+	}
+}
+
+// quoteIdentifier quotes the table or column name of a query with backticks,
+// the query names never reach the statements unquoted.
+func quoteIdentifier(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
+
+func filterConditions(filters []raizel.Filter, params map[string]interface{}) ([]string, error) {
+	conditions := make([]string, len(filters))
+	for index, filter := range filters {
+		operator, valid := operators[filter.Operator]
+		if !valid {
+			return nil, ErrInvalidFilter
+		}
+		param := fmt.Sprintf("p%d", index)
+		conditions[index] = fmt.Sprintf("%s %s @%s", quoteIdentifier(filter.Field), operator, param)
+		params[param] = filter.Value
+	}
+	return conditions, nil
+}
+
+func statementClause(conditions []string, orders []raizel.Order, limit int) string {
+	var clause strings.Builder
+	if len(conditions) > 0 {
+		clause.WriteString(" WHERE ")
+		clause.WriteString(strings.Join(conditions, " AND "))
+	}
+	for index, order := range orders {
+		if index == 0 {
+			clause.WriteString(" ORDER BY ")
+		} else {
+			clause.WriteString(", ")
+		}
+		direction := "ASC"
+		if order.Direction == raizel.Desc {
+			direction = "DESC"
+		}
+		fmt.Fprintf(&clause, "%s %s", quoteIdentifier(order.Field), direction)
+	}
+	if limit > 0 {
+		fmt.Fprintf(&clause, " LIMIT %d", limit)
+	}
+	return clause.String()
+}
+
+func (r *repository) Query(ctx context.Context, query raizel.Query) (raizel.Iterator, error) {
+	params := make(map[string]interface{}, len(query.Filters))
+	conditions, err := filterConditions(append(query.ParentFilters(), query.Filters...), params)
+	if err != nil {
+		return nil, err
+	}
+	return &entityIterator{
+		ctx:    ctx,
+		client: r.client,
+		table:  quoteIdentifier(query.EntityName),
+		clause: statementClause(conditions, query.Orders, query.Limit),
+		params: params,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package spanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+type testRepositoryQuery struct {
+	name        string
+	client      *spmock.ClientMock
+	transaction *spmock.ReadOnlyTransactionMock
+	rows        *spmock.RowIteratorMock
+	query       raizel.Query
+	statement   rspanner.Statement
+	results     int
+	err         error
+}
+
+func (scenario *testRepositoryQuery) setup(t *testing.T) {
+	var (
+		client      = new(spmock.ClientMock)
+		transaction = new(spmock.ReadOnlyTransactionMock)
+		rows        = spmock.NewRowIteratorMock()
+		row         = spmock.NewRowMock()
+	)
+	row.On("ToStruct", mock.Anything).Return(nil)
+	if scenario.results > 0 {
+		rows.On("Next").Return(row, nil).Times(scenario.results)
+	}
+	if scenario.err != nil {
+		rows.On("Next").Return(nil, scenario.err)
+	} else {
+		rows.On("Next").Return(nil, iterator.Done)
+	}
+	rows.On("Stop")
+	transaction.On("Query", mock.Anything, scenario.statement).Return(rows)
+	client.On("Single").Return(transaction)
+
+	scenario.client = client
+	scenario.transaction = transaction
+	scenario.rows = rows
+}
+
+func TestRepositoryQuery(test *testing.T) {
+	scenarios := []testRepositoryQuery{
+		{
+			name: "Query entities one row at a time",
+			query: raizel.NewQuery("entity_table").
+				Where("Age", raizel.Greater, 10).
+				Where("tenant_id", raizel.Equal, "tenant_a").
+				OrderBy("Age", raizel.Desc).
+				OrderBy("name", raizel.Asc).
+				WithLimit(2),
+			statement: rspanner.Statement{
+				SQL: "SELECT tenant_id, id, name, Age, created_at FROM `entity_table` " +
+					"WHERE `Age` > @p0 AND `tenant_id` = @p1 ORDER BY `Age` DESC, `name` ASC LIMIT 2",
+				Params: map[string]interface{}{"p0": 10, "p1": "tenant_a"},
+			},
+			results: 2,
+		},
+		{
+			name:  "Query all entities",
+			query: raizel.NewQuery("entity_table"),
+			statement: rspanner.Statement{
+				SQL:    "SELECT tenant_id, id, name, Age, created_at FROM `entity_table`",
+				Params: map[string]interface{}{},
+			},
+			results: 1,
+		},
+		{
+			name:  "Error when the rows fail",
+			query: raizel.NewQuery("entity_table"),
+			statement: rspanner.Statement{
+				SQL:    "SELECT tenant_id, id, name, Age, created_at FROM `entity_table`",
+				Params: map[string]interface{}{},
+			},
+			err: errors.New("errMock"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := rspanner.NewRepository(scenario.client)
+				queryable, ok := repository.(raizel.Queryable)
+				require.True(t, ok, "repository is not queryable")
+				iterator, err := queryable.Query(context.Background(), scenario.query)
+				require.Nil(t, err, "query error")
+				for result := 0; result < scenario.results; result++ {
+					err := iterator.Next(context.Background(), &testEntity{})
+					require.Nil(t, err, "next error")
+				}
+				err = iterator.Next(context.Background(), &testEntity{})
+				if scenario.err != nil {
+					require.Equal(t, scenario.err, err, "next error")
+				} else {
+					require.Equal(t, raizel.ErrIteratorDone, err, "next done error")
+				}
+				iterator.Stop()
+				scenario.client.AssertNumberOfCalls(t, "Single", 1)
+				scenario.transaction.AssertExpectations(t)
+				scenario.rows.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+func TestRepositoryQueryInvalidFilter(test *testing.T) {
+	var (
+		client        = new(spmock.ClientMock)
+		repository    = rspanner.NewRepository(client)
+		iterator, err = repository.(raizel.Queryable).Query(
+			context.Background(),
+			raizel.NewQuery("entity_table").Where("Age", raizel.Operator("!="), 10),
+		)
+	)
+	require.Equal(test, rspanner.ErrInvalidFilter, err, "query error")
+	require.Nil(test, iterator, "iterator instance")
+	client.AssertNotCalled(test, "Single")
+}
+
+func TestQuoteIdentifier(test *testing.T) {
+	require.Equal(test, "`age`", rspanner.QuoteIdentifier("age"), "quoted name")
+	require.Equal(test, "`age\\` > 0 OR \\`1\\\\`", rspanner.QuoteIdentifier("age` > 0 OR `1\\"), "escaped quotes")
+}
@@ -0,0 +1,133 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel/migrate"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+type migrationDriver struct {
+	client   Client
+	admin    DatabaseAdmin
+	database string
+}
+
+// NewMigrationDriver returns a spanner migrate.Driver that applies the DDL
+// statements of a script as one batch of the database, the full
+// projects/{project}/instances/{instance}/databases/{database} name.
+func NewMigrationDriver(client Client, admin DatabaseAdmin, database string) migrate.Driver {
+	return &migrationDriver{client: client, admin: admin, database: database}
+}
+
+func (d *migrationDriver) Init(ctx context.Context) error {
+	var (
+		tables = map[string]string{
+			migrate.Table: fmt.Sprintf(
+				"CREATE TABLE %s (version INT64 NOT NULL, name STRING(256) NOT NULL, "+
+					"applied_at TIMESTAMP NOT NULL) PRIMARY KEY (version)",
+				migrate.Table,
+			),
+			migrate.LockTable: fmt.Sprintf(
+				"CREATE TABLE %s (id INT64 NOT NULL, locked_at TIMESTAMP NOT NULL) PRIMARY KEY (id)",
+				migrate.LockTable,
+			),
+		}
+		rows = d.client.Single().Query(ctx, Statement{
+			SQL: "SELECT table_name FROM information_schema.tables " +
+				"WHERE table_schema = '' AND table_name IN UNNEST(@tables)",
+			Params: map[string]interface{}{
+				"tables": []string{migrate.Table, migrate.LockTable},
+			},
+		})
+	)
+	defer rows.Stop()
+	for {
+		row, err := rows.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var table string
+		if err := row.Columns(&table); err != nil {
+			return err
+		}
+		delete(tables, table)
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	statements := make([]string, 0, len(tables))
+	for _, table := range []string{migrate.Table, migrate.LockTable} {
+		if statement, missing := tables[table]; missing {
+			statements = append(statements, statement)
+		}
+	}
+	return d.admin.UpdateDDL(ctx, d.database, statements)
+}
+
+func (d *migrationDriver) Lock(ctx context.Context) error {
+	_, err := d.client.Apply(ctx, []*Mutation{
+		Insert(
+			migrate.LockTable,
+			[]string{"id", "locked_at"},
+			[]interface{}{int64(1), spanner.CommitTimestamp},
+		),
+	})
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return migrate.ErrLocked
+	}
+	return err
+}
+
+func (d *migrationDriver) Unlock(ctx context.Context) error {
+	_, err := d.client.Apply(ctx, []*Mutation{Delete(migrate.LockTable, Key{int64(1)})})
+	return err
+}
+
+func (d *migrationDriver) Applied(ctx context.Context) ([]migrate.Record, error) {
+	rows := d.client.Single().Query(ctx, Statement{
+		SQL: fmt.Sprintf("SELECT version, name, applied_at FROM %s ORDER BY version", migrate.Table),
+	})
+	defer rows.Stop()
+	var records []migrate.Record
+	for {
+		row, err := rows.Next()
+		if err == iterator.Done {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record migrate.Record
+		if err := row.Columns(&record.Version, &record.Name, &record.AppliedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func (d *migrationDriver) Exec(ctx context.Context, script string) error {
+	return d.admin.UpdateDDL(ctx, d.database, migrate.Statements(script))
+}
+
+func (d *migrationDriver) Insert(ctx context.Context, record migrate.Record) error {
+	_, err := d.client.Apply(ctx, []*Mutation{
+		Insert(
+			migrate.Table,
+			[]string{"version", "name", "applied_at"},
+			[]interface{}{record.Version, record.Name, record.AppliedAt},
+		),
+	})
+	return err
+}
+
+func (d *migrationDriver) Delete(ctx context.Context, version int64) error {
+	_, err := d.client.Apply(ctx, []*Mutation{Delete(migrate.Table, Key{version})})
+	return err
+}
@@ -0,0 +1,159 @@
+package spanner_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel/migrate"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testDatabase = "projects/mock/instances/mock/databases/mock"
+)
+
+func newTableRows(values ...[]interface{}) *spmock.RowIteratorMock {
+	rows := spmock.NewRowIteratorMock()
+	for _, columns := range values {
+		var (
+			columns = columns
+			row     = spmock.NewRowMock()
+		)
+		row.On("Columns", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			dest := args.Get(0).([]interface{})
+			for index, value := range columns {
+				switch target := dest[index].(type) {
+				case *string:
+					*target = value.(string)
+				case *int64:
+					*target = value.(int64)
+				case *time.Time:
+					*target = value.(time.Time)
+				}
+			}
+		})
+		rows.On("Next").Return(row, nil).Once()
+	}
+	rows.On("Next").Return(nil, iterator.Done)
+	rows.On("Stop")
+	return rows
+}
+
+func TestMigrationDriverInit(test *testing.T) {
+	scenarios := []struct {
+		name       string
+		tables     [][]interface{}
+		statements []string
+	}{
+		{
+			name: "Creates the missing tables",
+			tables: [][]interface{}{
+				{"schema_migrations_lock"},
+			},
+			statements: []string{
+				"CREATE TABLE schema_migrations (version INT64 NOT NULL, name STRING(256) NOT NULL, " +
+					"applied_at TIMESTAMP NOT NULL) PRIMARY KEY (version)",
+			},
+		},
+		{
+			name: "Skips the existing tables",
+			tables: [][]interface{}{
+				{"schema_migrations"}, {"schema_migrations_lock"},
+			},
+		},
+	}
+	for _, scenario := range scenarios {
+		test.Run(scenario.name, func(t *testing.T) {
+			var (
+				client      = new(spmock.ClientMock)
+				transaction = new(spmock.ReadOnlyTransactionMock)
+				admin       = spmock.NewDatabaseAdminMock()
+			)
+			transaction.On("Query", mock.Anything, mock.Anything).Return(newTableRows(scenario.tables...))
+			client.On("Single").Return(transaction)
+			admin.On("UpdateDDL", mock.Anything, testDatabase, mock.Anything).Return(nil)
+
+			driver := rspanner.NewMigrationDriver(client, admin, testDatabase)
+			require.Nil(t, driver.Init(context.Background()), "init error")
+			if scenario.statements != nil {
+				admin.AssertCalled(t, "UpdateDDL", mock.Anything, testDatabase, scenario.statements)
+			} else {
+				admin.AssertNotCalled(t, "UpdateDDL", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMigrationDriverLock(test *testing.T) {
+	scenarios := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Lock"},
+		{name: "Locked by another migrator", err: status.Error(codes.AlreadyExists, "row exists"), want: migrate.ErrLocked},
+		{name: "Lock error", err: errors.New("errMock"), want: errors.New("errMock")},
+	}
+	for _, scenario := range scenarios {
+		test.Run(scenario.name, func(t *testing.T) {
+			client := new(spmock.ClientMock)
+			client.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(time.Time{}, scenario.err).Once()
+			client.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+			driver := rspanner.NewMigrationDriver(client, spmock.NewDatabaseAdminMock(), testDatabase)
+			require.Equal(t, scenario.want, driver.Lock(context.Background()), "lock error")
+			require.Nil(t, driver.Unlock(context.Background()), "unlock error")
+		})
+	}
+}
+
+func TestMigrationDriver(test *testing.T) {
+	var (
+		ctx         = context.Background()
+		client      = new(spmock.ClientMock)
+		transaction = new(spmock.ReadOnlyTransactionMock)
+		admin       = spmock.NewDatabaseAdminMock()
+		appliedAt   = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+		driver      = rspanner.NewMigrationDriver(client, admin, testDatabase)
+	)
+	transaction.On(
+		"Query", mock.Anything,
+		rspanner.Statement{SQL: "SELECT version, name, applied_at FROM schema_migrations ORDER BY version"},
+	).Return(newTableRows([]interface{}{int64(1), "create_entity", appliedAt}))
+	client.On("Single").Return(transaction)
+	client.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	admin.On("UpdateDDL", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.Nil(
+		test,
+		driver.Exec(ctx, "CREATE TABLE entity (id STRING(36)) PRIMARY KEY (id);\nCREATE INDEX ix_entity ON entity (id);"),
+		"exec error",
+	)
+	admin.AssertCalled(
+		test, "UpdateDDL", mock.Anything, testDatabase,
+		[]string{"CREATE TABLE entity (id STRING(36)) PRIMARY KEY (id)", "CREATE INDEX ix_entity ON entity (id)"},
+	)
+	require.Nil(
+		test,
+		driver.Insert(ctx, migrate.Record{Version: 1, Name: "create_entity", AppliedAt: appliedAt}),
+		"insert error",
+	)
+	require.Nil(test, driver.Delete(ctx, 1), "delete error")
+	client.AssertNumberOfCalls(test, "Apply", 2)
+	records, err := driver.Applied(ctx)
+	require.Nil(test, err, "applied error")
+	require.Equal(
+		test,
+		[]migrate.Record{{Version: 1, Name: "create_entity", AppliedAt: appliedAt}},
+		records,
+		"applied records",
+	)
+}
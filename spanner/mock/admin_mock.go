@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type DatabaseAdminMock struct {
+	mock.Mock
+}
+
+func NewDatabaseAdminMock() *DatabaseAdminMock {
+	return new(DatabaseAdminMock)
+}
+
+func (mock *DatabaseAdminMock) UpdateDDL(ctx context.Context, db string, statements []string) error {
+	args := mock.Called(ctx, db, statements)
+	return args.Error(0)
+}
+
+func (mock *DatabaseAdminMock) Close() error {
+	args := mock.Called()
+	return args.Error(0)
+}
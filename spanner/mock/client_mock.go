@@ -1,10 +1,11 @@
-package spanner
+package mock
 
 import (
 	"context"
 	"time"
 
 	"cloud.google.com/go/spanner"
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -12,46 +13,50 @@ type ClientMock struct {
 	mock.Mock
 }
 
-func (mock *ClientMock) Apply(ctx context.Context, ms []*Mutation, opts ...ApplyOption) (time.Time, error) {
+func NewClientMock() *ClientMock {
+	return new(ClientMock)
+}
+
+func (mock *ClientMock) Apply(ctx context.Context, ms []*rspanner.Mutation, opts ...rspanner.ApplyOption) (time.Time, error) {
 	args := mock.Called(ctx, ms, opts)
 	return args.Get(0).(time.Time), args.Error(1)
 }
 
-func (mock *ClientMock) BatchReadOnlyTransaction(ctx context.Context, tb TimestampBound) (BatchReadOnlyTransaction, error) {
+func (mock *ClientMock) BatchReadOnlyTransaction(ctx context.Context, tb rspanner.TimestampBound) (rspanner.BatchReadOnlyTransaction, error) {
 	args := mock.Called(ctx, tb)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.(BatchReadOnlyTransaction), args.Error(1)
+	return result.(rspanner.BatchReadOnlyTransaction), args.Error(1)
 
 }
 
-func (mock *ClientMock) BatchReadOnlyTransactionFromID(tid BatchReadOnlyTransactionID) BatchReadOnlyTransaction {
+func (mock *ClientMock) BatchReadOnlyTransactionFromID(tid rspanner.BatchReadOnlyTransactionID) rspanner.BatchReadOnlyTransaction {
 	args := mock.Called(tid)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(BatchReadOnlyTransaction)
+	return result.(rspanner.BatchReadOnlyTransaction)
 }
 
 func (mock *ClientMock) Close() {
 	mock.Called()
 }
 
-func (mock *ClientMock) PartitionedUpdate(ctx context.Context, statement Statement) (int64, error) {
+func (mock *ClientMock) PartitionedUpdate(ctx context.Context, statement rspanner.Statement) (int64, error) {
 	args := mock.Called(ctx, statement)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (mock *ClientMock) ReadOnlyTransaction() ReadOnlyTransaction {
+func (mock *ClientMock) ReadOnlyTransaction() rspanner.ReadOnlyTransaction {
 	args := mock.Called()
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(ReadOnlyTransaction)
+	return result.(rspanner.ReadOnlyTransaction)
 }
 
 func (mock *ClientMock) ReadWriteTransaction(
@@ -61,11 +66,11 @@ func (mock *ClientMock) ReadWriteTransaction(
 	return args.Get(0).(time.Time), args.Error(1)
 }
 
-func (mock *ClientMock) Single() ReadOnlyTransaction {
+func (mock *ClientMock) Single() rspanner.ReadOnlyTransaction {
 	args := mock.Called()
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(ReadOnlyTransaction)
+	return result.(rspanner.ReadOnlyTransaction)
 }
@@ -0,0 +1,120 @@
+// Package mock provides testify mocks of the raizel spanner interfaces.
+package mock
+
+import (
+	rmock "github.com/rjansen/raizel/mock"
+	rspanner "github.com/rjansen/raizel/spanner"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/api/iterator"
+)
+
+type RowMock struct {
+	mock.Mock
+}
+
+func NewRowMock() *RowMock {
+	return new(RowMock)
+}
+
+func (mock *RowMock) Column(i int, ptr interface{}) error {
+	args := mock.Called(i, ptr)
+	return args.Error(0)
+}
+
+func (mock *RowMock) ColumnByName(name string, ptr interface{}) error {
+	args := mock.Called(name, ptr)
+	return args.Error(0)
+}
+
+func (mock *RowMock) ColumnIndex(name string) (int, error) {
+	args := mock.Called(name)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *RowMock) ColumnName(i int) string {
+	args := mock.Called(i)
+	return args.String(0)
+}
+
+func (mock *RowMock) ColumnNames() []string {
+	args := mock.Called()
+	result := args.Get(0)
+	if result == nil {
+		return nil
+	}
+	return result.([]string)
+}
+
+func (mock *RowMock) Columns(ptrs ...interface{}) error {
+	args := mock.Called(ptrs)
+	return args.Error(0)
+}
+
+func (mock *RowMock) Size() int {
+	args := mock.Called()
+	return args.Int(0)
+}
+
+func (mock *RowMock) ToStruct(ptr interface{}) error {
+	args := mock.Called(ptr)
+	return args.Error(0)
+}
+
+// OnToStruct expects a ToStruct that assigns the source struct to the
+// target.
+func (row *RowMock) OnToStruct(source interface{}) *mock.Call {
+	return row.On("ToStruct", mock.Anything).Run(rmock.Copy(source)).Return(nil)
+}
+
+// OnColumns expects a Columns that assigns the values to the destinations.
+func (row *RowMock) OnColumns(values ...interface{}) *mock.Call {
+	return row.On("Columns", mock.Anything).Run(rmock.Scan(values...)).Return(nil)
+}
+
+type RowIteratorMock struct {
+	mock.Mock
+}
+
+func NewRowIteratorMock() *RowIteratorMock {
+	return new(RowIteratorMock)
+}
+
+func (mock *RowIteratorMock) Do(f func(rspanner.Row) error) error {
+	args := mock.Called(f)
+	return args.Error(0)
+}
+
+func (mock *RowIteratorMock) Next() (rspanner.Row, error) {
+	args := mock.Called()
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(rspanner.Row), args.Error(1)
+}
+
+func (mock *RowIteratorMock) Stop() {
+	mock.Called()
+}
+
+// OnRows expects the Next iteration of the rows, Next returns iterator.Done
+// after the last row.
+func (rows *RowIteratorMock) OnRows(values ...rspanner.Row) {
+	for _, row := range values {
+		rows.On("Next").Return(row, nil).Once()
+	}
+	rows.On("Next").Return(nil, iterator.Done)
+	rows.On("Stop")
+}
+
+// OnStructs expects the Next iteration of rows whose ToStruct assigns the
+// source structs.
+func (rows *RowIteratorMock) OnStructs(sources ...interface{}) {
+	values := make([]rspanner.Row, len(sources))
+	for index, source := range sources {
+		row := NewRowMock()
+		row.OnToStruct(source)
+		values[index] = row
+	}
+	rows.OnRows(values...)
+}
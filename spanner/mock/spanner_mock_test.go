@@ -0,0 +1,70 @@
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rspanner "github.com/rjansen/raizel/spanner"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+type entity struct {
+	ID   string `spanner:"id"`
+	Name string `spanner:"name"`
+}
+
+func TestClientMock(t *testing.T) {
+	var (
+		client      = NewClientMock()
+		transaction = NewReadOnlyTransactionMock()
+		rows        = NewRowIteratorMock()
+	)
+	require.Implements(t, (*rspanner.Client)(nil), client, "invalid client type")
+	require.Implements(t, (*rspanner.ReadOnlyTransaction)(nil), transaction, "invalid transaction type")
+	require.Implements(t, (*rspanner.BatchReadOnlyTransaction)(nil), NewBatchReadOnlyTransactionMock(), "invalid batch transaction type")
+	require.Implements(t, (*rspanner.DatabaseAdmin)(nil), NewDatabaseAdminMock(), "invalid admin type")
+	require.Implements(t, (*rspanner.Row)(nil), NewRowMock(), "invalid row type")
+	require.Implements(t, (*rspanner.RowIterator)(nil), rows, "invalid row iterator type")
+
+	client.On("Single").Return(transaction)
+	client.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	transaction.On("Query", mock.Anything, mock.Anything).Return(rows)
+	rows.OnStructs(entity{ID: "one"}, entity{ID: "two"})
+
+	var (
+		ctx     = context.Background()
+		iter    = client.Single().Query(ctx, rspanner.Statement{SQL: "SELECT id, name FROM entity"})
+		scanned []entity
+		_, err  = client.Apply(ctx, nil)
+	)
+	require.Nil(t, err, "apply error")
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		require.Nil(t, err, "next error")
+		var current entity
+		require.Nil(t, row.ToStruct(&current), "to_struct error")
+		scanned = append(scanned, current)
+	}
+	iter.Stop()
+	require.Equal(t, []entity{{ID: "one"}, {ID: "two"}}, scanned, "scanned entities")
+	client.AssertExpectations(t)
+	rows.AssertExpectations(t)
+}
+
+func TestRowMock(t *testing.T) {
+	var (
+		row  = NewRowMock()
+		id   string
+		size int64
+	)
+	row.OnColumns("id", 10)
+	require.Nil(t, row.Columns(&id, &size), "columns error")
+	require.Equal(t, "id", id, "column id")
+	require.Equal(t, int64(10), size, "column size")
+}
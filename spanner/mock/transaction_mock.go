@@ -1,10 +1,11 @@
-package spanner
+package mock
 
 import (
 	"context"
 	"time"
 
 	"cloud.google.com/go/spanner"
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -12,79 +13,83 @@ type ReadOnlyTransactionMock struct {
 	mock.Mock
 }
 
-func (mock *ReadOnlyTransactionMock) AnalyzeQuery(ctx context.Context, statement spanner.Statement) (*QueryPlan, error) {
+func NewReadOnlyTransactionMock() *ReadOnlyTransactionMock {
+	return new(ReadOnlyTransactionMock)
+}
+
+func (mock *ReadOnlyTransactionMock) AnalyzeQuery(ctx context.Context, statement spanner.Statement) (*rspanner.QueryPlan, error) {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.(*QueryPlan), args.Error(1)
+	return result.(*rspanner.QueryPlan), args.Error(1)
 }
 
 func (mock *ReadOnlyTransactionMock) Close() {
 	mock.Called()
 }
 
-func (mock *ReadOnlyTransactionMock) Query(ctx context.Context, statement spanner.Statement) RowIterator {
+func (mock *ReadOnlyTransactionMock) Query(ctx context.Context, statement spanner.Statement) rspanner.RowIterator {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
-func (mock *ReadOnlyTransactionMock) QueryWithStats(ctx context.Context, statement Statement) RowIterator {
+func (mock *ReadOnlyTransactionMock) QueryWithStats(ctx context.Context, statement rspanner.Statement) rspanner.RowIterator {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *ReadOnlyTransactionMock) Read(
-	ctx context.Context, table string, keys KeySet, columns []string,
-) RowIterator {
+	ctx context.Context, table string, keys rspanner.KeySet, columns []string,
+) rspanner.RowIterator {
 	args := mock.Called(ctx, table, keys, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *ReadOnlyTransactionMock) ReadRow(
 	ctx context.Context, table string, key spanner.Key, columns []string,
-) (Row, error) {
+) (rspanner.Row, error) {
 	args := mock.Called(ctx, table, key, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.(Row), args.Error(1)
+	return result.(rspanner.Row), args.Error(1)
 }
 
 func (mock *ReadOnlyTransactionMock) ReadUsingIndex(
-	ctx context.Context, table, index string, keys KeySet, columns []string,
-) RowIterator {
+	ctx context.Context, table, index string, keys rspanner.KeySet, columns []string,
+) rspanner.RowIterator {
 	args := mock.Called(ctx, table, index, keys, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *ReadOnlyTransactionMock) ReadWithOptions(
-	ctx context.Context, table string, keys KeySet, columns []string, opts *ReadOptions,
-) RowIterator {
+	ctx context.Context, table string, keys rspanner.KeySet, columns []string, opts *rspanner.ReadOptions,
+) rspanner.RowIterator {
 	args := mock.Called(ctx, table, keys, columns, opts)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *ReadOnlyTransactionMock) Timestamp() (time.Time, error) {
@@ -92,31 +97,35 @@ func (mock *ReadOnlyTransactionMock) Timestamp() (time.Time, error) {
 	return args.Get(0).(time.Time), args.Error(1)
 }
 
-func (mock *ReadOnlyTransactionMock) WithTimestampBound(tb TimestampBound) ReadOnlyTransaction {
+func (mock *ReadOnlyTransactionMock) WithTimestampBound(tb rspanner.TimestampBound) rspanner.ReadOnlyTransaction {
 	args := mock.Called(tb)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(ReadOnlyTransaction)
+	return result.(rspanner.ReadOnlyTransaction)
 }
 
 type BatchReadOnlyTransactionMock struct {
 	mock.Mock
 }
 
-func (mock *BatchReadOnlyTransactionMock) GetID() BatchReadOnlyTransactionID {
+func NewBatchReadOnlyTransactionMock() *BatchReadOnlyTransactionMock {
+	return new(BatchReadOnlyTransactionMock)
+}
+
+func (mock *BatchReadOnlyTransactionMock) GetID() rspanner.BatchReadOnlyTransactionID {
 	args := mock.Called()
-	return args.Get(0).(BatchReadOnlyTransactionID)
+	return args.Get(0).(rspanner.BatchReadOnlyTransactionID)
 }
 
-func (mock *BatchReadOnlyTransactionMock) AnalyzeQuery(ctx context.Context, statement Statement) (*QueryPlan, error) {
+func (mock *BatchReadOnlyTransactionMock) AnalyzeQuery(ctx context.Context, statement rspanner.Statement) (*rspanner.QueryPlan, error) {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.(*QueryPlan), args.Error(1)
+	return result.(*rspanner.QueryPlan), args.Error(1)
 }
 
 func (mock *BatchReadOnlyTransactionMock) Cleanup(ctx context.Context) {
@@ -127,105 +136,105 @@ func (mock *BatchReadOnlyTransactionMock) Close() {
 	mock.Called()
 }
 
-func (mock *BatchReadOnlyTransactionMock) Execute(ctx context.Context, p *Partition) RowIterator {
+func (mock *BatchReadOnlyTransactionMock) Execute(ctx context.Context, p *rspanner.Partition) rspanner.RowIterator {
 	args := mock.Called(ctx, p)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *BatchReadOnlyTransactionMock) PartitionQuery(
-	ctx context.Context, statement Statement, opts PartitionOptions,
-) ([]*Partition, error) {
+	ctx context.Context, statement rspanner.Statement, opts rspanner.PartitionOptions,
+) ([]*rspanner.Partition, error) {
 	args := mock.Called(ctx, statement, opts)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.([]*Partition), args.Error(1)
+	return result.([]*rspanner.Partition), args.Error(1)
 }
 
 func (mock *BatchReadOnlyTransactionMock) PartitionRead(
-	ctx context.Context, table string, keys KeySet, columns []string, opts PartitionOptions,
+	ctx context.Context, table string, keys rspanner.KeySet, columns []string, opts rspanner.PartitionOptions,
 ) ([]*spanner.Partition, error) {
 	args := mock.Called(ctx, table, keys, columns, opts)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.([]*Partition), args.Error(1)
+	return result.([]*rspanner.Partition), args.Error(1)
 }
 
 func (mock *BatchReadOnlyTransactionMock) PartitionReadUsingIndex(
-	ctx context.Context, table, index string, keys KeySet, columns []string, opts PartitionOptions,
+	ctx context.Context, table, index string, keys rspanner.KeySet, columns []string, opts rspanner.PartitionOptions,
 ) ([]*spanner.Partition, error) {
 	args := mock.Called(ctx, table, index, keys, columns, opts)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.([]*Partition), args.Error(1)
+	return result.([]*rspanner.Partition), args.Error(1)
 }
 
-func (mock *BatchReadOnlyTransactionMock) Query(ctx context.Context, statement Statement) RowIterator {
+func (mock *BatchReadOnlyTransactionMock) Query(ctx context.Context, statement rspanner.Statement) rspanner.RowIterator {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
-func (mock *BatchReadOnlyTransactionMock) QueryWithStats(ctx context.Context, statement Statement) RowIterator {
+func (mock *BatchReadOnlyTransactionMock) QueryWithStats(ctx context.Context, statement rspanner.Statement) rspanner.RowIterator {
 	args := mock.Called(ctx, statement)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *BatchReadOnlyTransactionMock) Read(
-	ctx context.Context, table string, keys KeySet, columns []string,
-) RowIterator {
+	ctx context.Context, table string, keys rspanner.KeySet, columns []string,
+) rspanner.RowIterator {
 	args := mock.Called(ctx, table, keys, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *BatchReadOnlyTransactionMock) ReadRow(
-	ctx context.Context, table string, key Key, columns []string,
-) (Row, error) {
+	ctx context.Context, table string, key rspanner.Key, columns []string,
+) (rspanner.Row, error) {
 	args := mock.Called(ctx, table, key, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.(Row), args.Error(1)
+	return result.(rspanner.Row), args.Error(1)
 }
 
 func (mock *BatchReadOnlyTransactionMock) ReadUsingIndex(
-	ctx context.Context, table, index string, keys KeySet, columns []string,
-) RowIterator {
+	ctx context.Context, table, index string, keys rspanner.KeySet, columns []string,
+) rspanner.RowIterator {
 	args := mock.Called(ctx, table, index, keys, columns)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
 
 func (mock *BatchReadOnlyTransactionMock) ReadWithOptions(
-	ctx context.Context, table string, keys KeySet, columns []string, opts *ReadOptions) RowIterator {
+	ctx context.Context, table string, keys rspanner.KeySet, columns []string, opts *rspanner.ReadOptions) rspanner.RowIterator {
 	args := mock.Called(ctx, table, keys, columns, opts)
 	result := args.Get(0)
 	if result == nil {
 		return nil
 	}
-	return result.(RowIterator)
+	return result.(rspanner.RowIterator)
 }
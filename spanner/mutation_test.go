@@ -1,18 +1,19 @@
-package spanner
+package spanner_test
 
 import (
 	"testing"
 
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDeleteMutation(t *testing.T) {
-	mutations := Delete("MockTable", Key{1, 2})
+	mutations := rspanner.Delete("MockTable", rspanner.Key{1, 2})
 	require.NotNil(t, mutations, "invalid mutations instance")
 }
 
 func TestInsertMutation(t *testing.T) {
-	mutations := Insert(
+	mutations := rspanner.Insert(
 		"MockTable",
 		[]string{"Column1", "Column2", "ColumnsN"},
 		[]interface{}{1, 2, "N"},
@@ -21,7 +22,7 @@ func TestInsertMutation(t *testing.T) {
 }
 
 func TestInsertMapMutation(t *testing.T) {
-	mutations := InsertMap(
+	mutations := rspanner.InsertMap(
 		"MockTable",
 		map[string]interface{}{
 			"Column1": 1,
@@ -33,7 +34,7 @@ func TestInsertMapMutation(t *testing.T) {
 }
 
 func TestInsertOrUpdateMutation(t *testing.T) {
-	mutations := InsertOrUpdate(
+	mutations := rspanner.InsertOrUpdate(
 		"MockTable",
 		[]string{"Column1", "Column2", "ColumnsN"},
 		[]interface{}{1, 2, "N"},
@@ -42,7 +43,7 @@ func TestInsertOrUpdateMutation(t *testing.T) {
 }
 
 func TestInsertOrUpdateMapMutation(t *testing.T) {
-	mutations := InsertOrUpdateMap(
+	mutations := rspanner.InsertOrUpdateMap(
 		"MockTable",
 		map[string]interface{}{
 			"Column1": 1,
@@ -59,7 +60,7 @@ type data struct {
 }
 
 func TestInsertOrUpdateStructMutation(t *testing.T) {
-	mutations, err := InsertOrUpdateStruct(
+	mutations, err := rspanner.InsertOrUpdateStruct(
 		"MockTable",
 		data{
 			Column1: 1,
@@ -72,7 +73,7 @@ func TestInsertOrUpdateStructMutation(t *testing.T) {
 }
 
 func TestInsertStructMutation(t *testing.T) {
-	mutations, err := InsertStruct(
+	mutations, err := rspanner.InsertStruct(
 		"MockTable",
 		data{
 			Column1: 1,
@@ -85,7 +86,7 @@ func TestInsertStructMutation(t *testing.T) {
 }
 
 func TestReplaceMutation(t *testing.T) {
-	mutations := Replace(
+	mutations := rspanner.Replace(
 		"MockTable",
 		[]string{"Column1", "Column2", "ColumnsN"},
 		[]interface{}{1, 2, "N"},
@@ -94,7 +95,7 @@ func TestReplaceMutation(t *testing.T) {
 }
 
 func TestReplaceMapMutation(t *testing.T) {
-	mutations := ReplaceMap(
+	mutations := rspanner.ReplaceMap(
 		"MockTable",
 		map[string]interface{}{
 			"Column1": 1,
@@ -106,7 +107,7 @@ func TestReplaceMapMutation(t *testing.T) {
 }
 
 func TestReplaceStructMutation(t *testing.T) {
-	mutations, err := ReplaceStruct(
+	mutations, err := rspanner.ReplaceStruct(
 		"MockTable",
 		data{
 			Column1: 1,
@@ -119,7 +120,7 @@ func TestReplaceStructMutation(t *testing.T) {
 }
 
 func TestUpdateMutation(t *testing.T) {
-	mutations := Update(
+	mutations := rspanner.Update(
 		"MockTable",
 		[]string{"Column1", "Column2", "ColumnsN"},
 		[]interface{}{1, 2, "N"},
@@ -128,7 +129,7 @@ func TestUpdateMutation(t *testing.T) {
 }
 
 func TestUpdateMapMutation(t *testing.T) {
-	mutations := UpdateMap(
+	mutations := rspanner.UpdateMap(
 		"MockTable",
 		map[string]interface{}{
 			"Column1": 1,
@@ -140,7 +141,7 @@ func TestUpdateMapMutation(t *testing.T) {
 }
 
 func TestUpdateStructMutation(t *testing.T) {
-	mutations, err := UpdateStruct(
+	mutations, err := rspanner.UpdateStruct(
 		"MockTable",
 		data{
 			Column1: 1,
@@ -0,0 +1,102 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel"
+)
+
+type pageIterator struct {
+	*entityIterator
+	orders        []raizel.Order
+	size          int
+	count         int
+	last          []interface{}
+	nextPageToken string
+}
+
+func (i *pageIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	err := i.entityIterator.Next(ctx, entity)
+	if err != nil {
+		if err == raizel.ErrIteratorDone && i.count == i.size && i.last != nil {
+			token, err := raizel.EncodePageToken(i.last...)
+			if err != nil {
+				return err
+			}
+			i.nextPageToken, i.last = token, nil
+		}
+		return err
+	}
+	i.count++
+	i.last = make([]interface{}, len(i.orders))
+	for index, order := range i.orders {
+		value, found := entityField(entity, order.Field)
+		if !found {
+			return ErrUnmappedColumn
+		}
+		i.last[index] = value
+	}
+	return nil
+}
+
+func (i *pageIterator) NextPageToken() string {
+	return i.nextPageToken
+}
+
+// keysetCondition returns the predicate of the rows ordered after values.
+func keysetCondition(orders []raizel.Order, values []interface{}, params map[string]interface{}) string {
+	alternatives := make([]string, len(orders))
+	for index, order := range orders {
+		terms := make([]string, 0, index+1)
+		for previous := 0; previous < index; previous++ {
+			terms = append(terms, fmt.Sprintf("%s = @c%d", quoteIdentifier(orders[previous].Field), previous))
+		}
+		operator := ">"
+		if order.Direction == raizel.Desc {
+			operator = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s @c%d", quoteIdentifier(order.Field), operator, index))
+		params[fmt.Sprintf("c%d", index)] = values[index]
+		alternatives[index] = fmt.Sprintf("(%s)", strings.Join(terms, " AND "))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(alternatives, " OR "))
+}
+
+// Page reads the query page ordered after the ordering values carried by
+// pageToken, the ordering columns must be mapped by the entity.
+func (r *repository) Page(ctx context.Context, query raizel.Query, pageToken string) (raizel.PageIterator, error) {
+	if len(query.Orders) == 0 {
+		return nil, raizel.ErrUnorderedPage
+	}
+	var (
+		size   = raizel.PageSize(query)
+		params = make(map[string]interface{}, len(query.Filters)+len(query.Orders))
+	)
+	conditions, err := filterConditions(append(query.ParentFilters(), query.Filters...), params)
+	if err != nil {
+		return nil, err
+	}
+	if pageToken != "" {
+		values, err := raizel.DecodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(query.Orders) {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		conditions = append(conditions, keysetCondition(query.Orders, values, params))
+	}
+	return &pageIterator{
+		entityIterator: &entityIterator{
+			ctx:    ctx,
+			client: r.client,
+			table:  quoteIdentifier(query.EntityName),
+			clause: statementClause(conditions, query.Orders, size),
+			params: params,
+		},
+		orders: query.Orders,
+		size:   size,
+	}, nil
+}
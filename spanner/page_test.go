@@ -0,0 +1,136 @@
+package spanner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	selectTestEntity = "SELECT tenant_id, id, name, Age, created_at FROM `entity_table`"
+)
+
+func mustPageToken(values ...interface{}) string {
+	token, err := raizel.EncodePageToken(values...)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+type testRepositoryPage struct {
+	name          string
+	client        *spmock.ClientMock
+	transaction   *spmock.ReadOnlyTransactionMock
+	query         raizel.Query
+	pageToken     string
+	statement     rspanner.Statement
+	entities      []testEntity
+	nextPageToken string
+	err           error
+}
+
+func (scenario *testRepositoryPage) setup(t *testing.T) {
+	var (
+		client      = new(spmock.ClientMock)
+		transaction = new(spmock.ReadOnlyTransactionMock)
+		rows        = spmock.NewRowIteratorMock()
+	)
+	for _, entity := range scenario.entities {
+		var (
+			entity = entity
+			row    = spmock.NewRowMock()
+		)
+		row.On("ToStruct", mock.Anything).Return(nil).Run(
+			func(args mock.Arguments) {
+				*args.Get(0).(*testEntity) = entity
+			},
+		)
+		rows.On("Next").Return(row, nil).Once()
+	}
+	rows.On("Next").Return(nil, iterator.Done)
+	rows.On("Stop")
+	transaction.On("Query", mock.Anything, scenario.statement).Return(rows)
+	client.On("Single").Return(transaction)
+
+	scenario.client = client
+	scenario.transaction = transaction
+}
+
+func TestRepositoryPage(test *testing.T) {
+	scenarios := []testRepositoryPage{
+		{
+			name: "Read the first page",
+			query: raizel.NewQuery("entity_table").
+				Where("tenant_id", raizel.Equal, "tenant_a").
+				OrderBy("Age", raizel.Asc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			statement: rspanner.Statement{
+				SQL:    selectTestEntity + " WHERE `tenant_id` = @p0 ORDER BY `Age` ASC, `id` ASC LIMIT 2",
+				Params: map[string]interface{}{"p0": "tenant_a"},
+			},
+			entities:      []testEntity{{ID: "a", Age: 20}, {ID: "b", Age: 30}},
+			nextPageToken: mustPageToken(30, "b"),
+		},
+		{
+			name: "Read the next page after the token values",
+			query: raizel.NewQuery("entity_table").
+				Where("tenant_id", raizel.Equal, "tenant_a").
+				OrderBy("Age", raizel.Desc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			pageToken: mustPageToken(30, "b"),
+			statement: rspanner.Statement{
+				SQL: selectTestEntity + " WHERE `tenant_id` = @p0 AND " +
+					"((`Age` < @c0) OR (`Age` = @c0 AND `id` > @c1)) ORDER BY `Age` DESC, `id` ASC LIMIT 2",
+				Params: map[string]interface{}{"p0": "tenant_a", "c0": int64(30), "c1": "b"},
+			},
+			entities: []testEntity{{ID: "c", Age: 10}},
+		},
+		{
+			name:      "Error when the token does not match the orders",
+			query:     raizel.NewQuery("entity_table").OrderBy("id", raizel.Asc),
+			pageToken: mustPageToken(30, "b"),
+			err:       raizel.ErrInvalidPageToken,
+		},
+		{
+			name:  "Error when the query is not ordered",
+			query: raizel.NewQuery("entity_table"),
+			err:   raizel.ErrUnorderedPage,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := rspanner.NewRepository(scenario.client).(raizel.Pageable)
+				page, err := repository.Page(context.Background(), scenario.query, scenario.pageToken)
+				require.Equal(t, scenario.err, err, "page error")
+				if scenario.err != nil {
+					require.Nil(t, page, "page instance")
+					scenario.client.AssertNotCalled(t, "Single")
+					return
+				}
+				defer page.Stop()
+				for _, expected := range scenario.entities {
+					var entity testEntity
+					require.Nil(t, page.Next(context.Background(), &entity), "next error")
+					require.Equal(t, expected.ID, entity.ID, "entity id")
+				}
+				require.Equal(t, raizel.ErrIteratorDone, page.Next(context.Background(), &testEntity{}), "next done error")
+				require.Equal(t, scenario.nextPageToken, page.NextPageToken(), "next page token")
+				scenario.transaction.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// TenantColumn is the tenant primary key column written by Patch and
+	// filtered by Count.
+	TenantColumn = "tenant_id"
+)
+
+// keyColumns returns the primary key columns and values of the key.
+func keyColumns(key raizel.EntityKey) ([]string, []interface{}) {
+	names, values := raizel.KeyPathParts(key)
+	if tenant, ok := raizel.TenantOf(key); ok {
+		return append([]string{TenantColumn}, names...), append([]interface{}{tenant}, values...)
+	}
+	return names, values
+}
+
+// typedSlice converts the values of an array transform to a slice of their
+// element type, spanner does not encode []interface{} parameters.
+func typedSlice(value interface{}) interface{} {
+	values, ok := value.([]interface{})
+	if !ok || len(values) == 0 {
+		return value
+	}
+	elemType := reflect.TypeOf(values[0])
+	slice := reflect.MakeSlice(reflect.SliceOf(elemType), len(values), len(values))
+	for index, elem := range values {
+		if reflect.TypeOf(elem) != elemType {
+			return value
+		}
+		slice.Index(index).Set(reflect.ValueOf(elem))
+	}
+	return slice.Interface()
+}
+
+// patchMutation returns the UpdateMap mutation of the updates that do not read
+// the current values, it returns false for the other transforms.
+func patchMutation(key raizel.EntityKey, updates []raizel.Update) (*Mutation, bool) {
+	var (
+		names, values = keyColumns(key)
+		columns       = make(map[string]interface{}, len(names)+len(updates))
+	)
+	for index, name := range names {
+		columns[name] = values[index]
+	}
+	for _, update := range updates {
+		switch update.Transform {
+		case raizel.AssignTransform:
+			columns[update.Field] = update.Value
+		case raizel.ServerTimestampTransform:
+			columns[update.Field] = spanner.CommitTimestamp
+		case raizel.DeleteTransform:
+			columns[update.Field] = nil
+		default:
+			return nil, false
+		}
+	}
+	return UpdateMap(key.EntityName(), columns), true
+}
+
+// patchStatement returns the DML of the updates with the names quoted,
+// ServerTimestamp requires a column with the allow_commit_timestamp option.
+func patchStatement(key raizel.EntityKey, updates []raizel.Update) (Statement, error) {
+	var (
+		params      = make(map[string]interface{}, len(updates))
+		assignments = make([]string, len(updates))
+		names, keys = keyColumns(key)
+		conditions  = make([]string, len(names))
+	)
+	for index, update := range updates {
+		var (
+			param = fmt.Sprintf("u%d", index)
+			field = quoteIdentifier(update.Field)
+		)
+		switch update.Transform {
+		case raizel.AssignTransform:
+			assignments[index] = fmt.Sprintf("%s = @%s", field, param)
+		case raizel.IncrementTransform:
+			assignments[index] = fmt.Sprintf("%s = %s + @%s", field, field, param)
+		case raizel.ServerTimestampTransform:
+			assignments[index] = fmt.Sprintf("%s = PENDING_COMMIT_TIMESTAMP()", field)
+		case raizel.ArrayAppendTransform:
+			assignments[index] = fmt.Sprintf("%s = ARRAY_CONCAT(IFNULL(%s, []), @%s)", field, field, param)
+		case raizel.ArrayRemoveTransform:
+			assignments[index] = fmt.Sprintf(
+				"%s = ARRAY(SELECT e FROM UNNEST(%s) AS e WHERE e NOT IN UNNEST(@%s))",
+				field, field, param,
+			)
+		case raizel.DeleteTransform:
+			assignments[index] = fmt.Sprintf("%s = NULL", field)
+		default:
+			return Statement{}, fmt.Errorf("%w: transform %d", raizel.ErrInvalidArgument, update.Transform)
+		}
+		if strings.Contains(assignments[index], "@"+param) {
+			params[param] = typedSlice(update.Value)
+		}
+	}
+	for index, name := range names {
+		param := fmt.Sprintf("k%d", index)
+		conditions[index] = fmt.Sprintf("%s = @%s", quoteIdentifier(name), param)
+		params[param] = keys[index]
+	}
+	return Statement{
+		SQL: fmt.Sprintf(
+			"UPDATE %s SET %s WHERE %s",
+			quoteIdentifier(key.EntityName()), strings.Join(assignments, ", "), strings.Join(conditions, " AND "),
+		),
+		Params: params,
+	}, nil
+}
+
+// Patch applies an UpdateMap mutation when the updates only assign values,
+// the increments and array transforms run as DML in a read write transaction.
+// A missing row returns raizel.ErrNotFound and the updates of the key columns
+// or the TenantColumn return raizel.ErrInvalidArgument.
+func (r *repository) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	if err := raizel.CheckUpdates(key, updates, TenantColumn); err != nil {
+		return err
+	}
+	if mutation, ok := patchMutation(key, updates); ok {
+		_, err := r.client.Apply(ctx, []*Mutation{mutation})
+		if spanner.ErrCode(err) == codes.NotFound {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	statement, err := patchStatement(key, updates)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.ReadWriteTransaction(ctx, func(ctx context.Context, transaction *ReadWriteTransaction) error {
+		count, err := transaction.Update(ctx, statement)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return raizel.ErrNotFound
+		}
+		return nil
+	})
+	return err
+}
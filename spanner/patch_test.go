@@ -0,0 +1,112 @@
+package spanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testPatchStatement struct {
+	name      string
+	key       raizel.EntityKey
+	updates   []raizel.Update
+	statement rspanner.Statement
+	err       error
+}
+
+func TestPatchStatement(test *testing.T) {
+	scenarios := []testPatchStatement{
+		{
+			name: "Statement of the transforms",
+			key: raizel.NewTenantKey("tenant1", raizel.NewChildKey(
+				raizel.NewDynamicKey("orders", "order_id", "order1"),
+				raizel.NewDynamicKey("items", "item_id", int64(2)),
+			)),
+			updates: []raizel.Update{
+				raizel.Increment("quantity", int64(1)),
+				raizel.ArrayAppend("tags", "a", "b"),
+				raizel.ArrayRemove("tags", "c"),
+				raizel.ServerTimestamp("updated_at"),
+				raizel.DeleteField("note"),
+			},
+			statement: rspanner.Statement{
+				SQL: "UPDATE `items` SET `quantity` = `quantity` + @u0, `tags` = ARRAY_CONCAT(IFNULL(`tags`, []), @u1), " +
+					"`tags` = ARRAY(SELECT e FROM UNNEST(`tags`) AS e WHERE e NOT IN UNNEST(@u2)), " +
+					"`updated_at` = PENDING_COMMIT_TIMESTAMP(), `note` = NULL " +
+					"WHERE `tenant_id` = @k0 AND `order_id` = @k1 AND `item_id` = @k2",
+				Params: map[string]interface{}{
+					"u0": int64(1),
+					"u1": []string{"a", "b"},
+					"u2": []string{"c"},
+					"k0": "tenant1",
+					"k1": "order1",
+					"k2": int64(2),
+				},
+			},
+		},
+		{
+			name:    "Statement of a quoted field",
+			key:     raizel.NewDynamicKey("items", "item_id", int64(2)),
+			updates: []raizel.Update{raizel.Increment("quantity` = 0, `price", int64(1))},
+			statement: rspanner.Statement{
+				SQL: "UPDATE `items` SET `quantity\\` = 0, \\`price` = `quantity\\` = 0, \\`price` + @u0 " +
+					"WHERE `item_id` = @k0",
+				Params: map[string]interface{}{"u0": int64(1), "k0": int64(2)},
+			},
+		},
+		{
+			name:    "Error when the transform is unknown",
+			key:     raizel.NewDynamicKey("items", "item_id", int64(2)),
+			updates: []raizel.Update{{Field: "quantity", Transform: raizel.Transform(99)}},
+			err:     raizel.ErrInvalidArgument,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				statement, err := rspanner.PatchStatement(scenario.key, scenario.updates)
+				require.True(t, errors.Is(err, scenario.err), "statement error %v", err)
+				require.Equal(t, scenario.statement, statement, "statement")
+			},
+		)
+	}
+}
+
+func TestRepositoryPatch(test *testing.T) {
+	var (
+		ctx    = context.Background()
+		client = new(spmock.ClientMock)
+		key    = raizel.NewDynamicKey("entity_table", "id", "identifier")
+	)
+	client.On("Apply", ctx, mock.Anything, []rspanner.ApplyOption(nil)).Return(time.Time{}, nil).Once()
+	client.On("Apply", ctx, mock.Anything, []rspanner.ApplyOption(nil)).Return(
+		time.Time{}, status.Error(codes.NotFound, "row not found"),
+	)
+
+	_, ok := rspanner.PatchMutation(key, []raizel.Update{raizel.Assign("name", "mock"), raizel.ServerTimestamp("at")})
+	require.True(test, ok, "assign mutation")
+	_, ok = rspanner.PatchMutation(key, []raizel.Update{raizel.Increment("age", 1)})
+	require.False(test, ok, "increment mutation")
+
+	repository := rspanner.NewRepository(client)
+	err := raizel.Patch(ctx, repository, key, raizel.Assign("name", "mock"), raizel.DeleteField("age"))
+	require.Nil(test, err, "patch error")
+	err = raizel.Patch(ctx, repository, key, raizel.Assign("name", "mock"))
+	require.Equal(test, raizel.ErrNotFound, err, "patch not found error")
+	err = raizel.Patch(ctx, repository, raizel.NewTenantKey("tenant1", key), raizel.Assign(rspanner.TenantColumn, "tenant2"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "patch tenant column error")
+	err = raizel.Patch(ctx, repository, key, raizel.Assign("id", "other"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "patch key column error")
+	client.AssertNumberOfCalls(test, "Apply", 2)
+}
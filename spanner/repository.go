@@ -0,0 +1,208 @@
+package spanner
+
+import (
+	"context"
+	"reflect"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc/codes"
+)
+
+type repository struct {
+	client Client
+}
+
+func NewRepository(client Client) raizel.Repository {
+	return &repository{client: client}
+}
+
+// NewTenantRepository returns a repository that prefixes every primary key
+// with the context tenant, tables must declare the TenantColumn as the first
+// primary key part. Set writes the context tenant to the TenantColumn over
+// the tenant of the entity.
+func NewTenantRepository(client Client) raizel.Repository {
+	return raizel.NewTenantRepository(NewRepository(client))
+}
+
+// entityKey returns the primary key of the key, the parent key parts come
+// first for the child keys of interleaved tables.
+func entityKey(key raizel.EntityKey) Key {
+	_, values := raizel.KeyPathParts(key)
+	if tenant, ok := raizel.TenantOf(key); ok {
+		return append(Key{tenant}, values...)
+	}
+	return Key(values)
+}
+
+func entityColumns(entity raizel.Entity) []string {
+	if descriptor, described := raizel.DescriptorOf(entity); described {
+		return descriptor.Names()
+	}
+	entityType := reflect.TypeOf(entity)
+	for entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType == nil || entityType.Kind() != reflect.Struct {
+		return nil
+	}
+	columns := make([]string, 0, entityType.NumField())
+	for index := 0; index < entityType.NumField(); index++ {
+		field := entityType.Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		column := field.Tag.Get("spanner")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = field.Name
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func entityField(entity raizel.Entity, column string) (interface{}, bool) {
+	if descriptor, described := raizel.DescriptorOf(entity); described {
+		values, err := descriptor.Map(entity)
+		if err != nil {
+			return nil, false
+		}
+		value, found := values[column]
+		return value, found
+	}
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("spanner")
+		if name == "" {
+			name = field.Name
+		}
+		if name == column {
+			return value.Field(index).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// toStruct reads the row into the entity, through the spanner tagged struct of
+// its descriptor when the entity type is registered.
+func toStruct(row Row, entity raizel.Entity) error {
+	descriptor, described := raizel.DescriptorOf(entity)
+	if !described {
+		return row.ToStruct(entity)
+	}
+	value := reflect.New(descriptor.TaggedType("spanner", false)).Interface()
+	if err := row.ToStruct(value); err != nil {
+		return err
+	}
+	return descriptor.FromTagged(value, entity)
+}
+
+// entityMutation returns the insert or update mutation of the entity, with
+// the descriptor columns when the entity type is registered. The tenant of
+// the key replaces the TenantColumn of the entity.
+func entityMutation(key raizel.EntityKey, entity raizel.Entity) (*Mutation, error) {
+	var (
+		tenant, tenanted      = raizel.TenantOf(key)
+		descriptor, described = raizel.DescriptorOf(entity)
+	)
+	if !described && !tenanted {
+		return InsertOrUpdateStruct(key.EntityName(), entity)
+	}
+	var (
+		columns []string
+		values  []interface{}
+	)
+	if described {
+		var err error
+		if values, err = descriptor.Values(entity); err != nil {
+			return nil, err
+		}
+		columns = descriptor.Names()
+	} else {
+		if columns = entityColumns(entity); columns == nil {
+			return InsertOrUpdateStruct(key.EntityName(), entity)
+		}
+		values = make([]interface{}, len(columns))
+		for index, column := range columns {
+			values[index], _ = entityField(entity, column)
+		}
+	}
+	if tenanted {
+		columns, values = tenantColumn(columns, values, tenant)
+	}
+	return InsertOrUpdate(key.EntityName(), columns, values), nil
+}
+
+// tenantColumn sets the TenantColumn of the columns to the tenant, so a
+// tenant never writes the rows of another tenant.
+func tenantColumn(columns []string, values []interface{}, tenant string) ([]string, []interface{}) {
+	for index, column := range columns {
+		if column == TenantColumn {
+			values[index] = tenant
+			return columns, values
+		}
+	}
+	return append(columns, TenantColumn), append(values, tenant)
+}
+
+func (r *repository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	row, err := r.client.Single().ReadRow(
+		ctx, key.EntityName(), entityKey(key), entityColumns(entity),
+	)
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	if err := toStruct(row, entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (r *repository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	mutation, err := entityMutation(key, entity)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Apply(ctx, []*Mutation{mutation})
+	return err
+}
+
+func (r *repository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	_, err := r.client.Apply(
+		ctx, []*Mutation{Delete(key.EntityName(), entityKey(key))},
+	)
+	return err
+}
+
+// DeleteCascade deletes the row of the key, spanner deletes the rows of the
+// interleaved tables declared with ON DELETE CASCADE.
+func (r *repository) DeleteCascade(ctx context.Context, key raizel.EntityKey) error {
+	return r.Delete(ctx, key)
+}
+
+func (r *repository) Close(ctx context.Context) error {
+	r.client.Close()
+	return nil
+}
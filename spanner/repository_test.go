@@ -0,0 +1,277 @@
+package spanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	spmock "github.com/rjansen/raizel/spanner/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testEntity struct {
+	Tenant    string `spanner:"tenant_id"`
+	ID        string `spanner:"id"`
+	Name      string `spanner:"name"`
+	Age       int64
+	CreatedAt time.Time `spanner:"created_at"`
+	ignored   string
+	Skipped   string `spanner:"-"`
+}
+
+type testEntityKey struct {
+	table string
+	name  string
+	value interface{}
+}
+
+func (k testEntityKey) EntityName() string {
+	return k.table
+}
+
+func (k testEntityKey) Name() string {
+	return k.name
+}
+
+func (k testEntityKey) Value() interface{} {
+	return k.value
+}
+
+func TestNewRepository(test *testing.T) {
+	repository := rspanner.NewRepository(nil)
+	require.NotNil(test, repository, "invalid repository instance")
+}
+
+func TestEntityColumns(test *testing.T) {
+	require.Equal(
+		test,
+		[]string{"tenant_id", "id", "name", "Age", "created_at"},
+		rspanner.EntityColumns(&testEntity{}),
+		"columns invalid value",
+	)
+	require.Nil(test, rspanner.EntityColumns("not a struct"), "columns of a non struct")
+}
+
+type testRepositoryGet struct {
+	name        string
+	ctx         context.Context
+	client      *spmock.ClientMock
+	transaction *spmock.ReadOnlyTransactionMock
+	row         *spmock.RowMock
+	key         raizel.EntityKey
+	spannerKey  rspanner.Key
+	result      raizel.Entity
+	readErr     error
+	err         error
+}
+
+func (scenario *testRepositoryGet) setup(t *testing.T) {
+	var (
+		client      = new(spmock.ClientMock)
+		transaction = new(spmock.ReadOnlyTransactionMock)
+		row         = spmock.NewRowMock()
+	)
+	row.On("ToStruct", scenario.result).Return(nil)
+	if scenario.readErr != nil {
+		transaction.On(
+			"ReadRow", mock.Anything, "entity_table", scenario.spannerKey, mock.Anything,
+		).Return(nil, scenario.readErr)
+	} else {
+		transaction.On(
+			"ReadRow", mock.Anything, "entity_table", scenario.spannerKey, mock.Anything,
+		).Return(row, nil)
+	}
+	client.On("Single").Return(transaction)
+	client.On("Close")
+
+	scenario.client = client
+	scenario.transaction = transaction
+	scenario.row = row
+}
+
+func TestRepositoryGet(test *testing.T) {
+	scenarios := []testRepositoryGet{
+		{
+			name: "Get entity",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			spannerKey: rspanner.Key{"identifier"},
+			result:     &testEntity{},
+		},
+		{
+			name: "Get entity with the tenant key part",
+			ctx:  raizel.WithTenant(context.Background(), "tenant_a"),
+			key: raizel.NewTenantKey("tenant_a", testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			}),
+			spannerKey: rspanner.Key{"tenant_a", "identifier"},
+			result:     &testEntity{},
+		},
+		{
+			name: "Error when try to Get a not found entity",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			spannerKey: rspanner.Key{"identifier"},
+			result:     &testEntity{},
+			readErr:    status.Error(codes.NotFound, "row not found"),
+			err:        raizel.ErrNotFound,
+		},
+		{
+			name: "Error when try to Get an entity",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			spannerKey: rspanner.Key{"identifier"},
+			result:     &testEntity{},
+			readErr:    errors.New("errMock"),
+			err:        errors.New("errMock"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := rspanner.NewRepository(scenario.client)
+				err := repository.Get(scenario.ctx, scenario.key, scenario.result)
+				require.Equal(t, scenario.err, err, "get error")
+				err = repository.Close(scenario.ctx)
+				require.Nil(t, err, "close error")
+				scenario.client.AssertExpectations(t)
+				scenario.transaction.AssertExpectations(t)
+				if scenario.err == nil {
+					scenario.row.AssertExpectations(t)
+				}
+			},
+		)
+	}
+}
+
+type testRepositoryWrite struct {
+	name   string
+	ctx    context.Context
+	client *spmock.ClientMock
+	key    raizel.EntityKey
+	data   raizel.Entity
+	err    error
+}
+
+func (scenario *testRepositoryWrite) setup(t *testing.T) {
+	client := new(spmock.ClientMock)
+	client.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(time.Now(), scenario.err)
+	client.On("Close")
+	scenario.client = client
+}
+
+func TestRepositorySetAndDelete(test *testing.T) {
+	scenarios := []testRepositoryWrite{
+		{
+			name: "Set and Delete entity",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			data: &testEntity{ID: "identifier"},
+		},
+		{
+			name: "Error when try to Set and Delete an entity",
+			ctx:  context.Background(),
+			key: testEntityKey{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			data: &testEntity{ID: "identifier"},
+			err:  errors.New("errMock"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := rspanner.NewRepository(scenario.client)
+				err := repository.Set(scenario.ctx, scenario.key, scenario.data)
+				require.Equal(t, scenario.err, err, "set error")
+				err = repository.Delete(scenario.ctx, scenario.key)
+				require.Equal(t, scenario.err, err, "delete error")
+				repository.Close(scenario.ctx)
+				scenario.client.AssertNumberOfCalls(t, "Apply", 2)
+			},
+		)
+	}
+}
+
+func TestTenantRepository(test *testing.T) {
+	client := new(spmock.ClientMock)
+	repository := rspanner.NewTenantRepository(client)
+	err := repository.Delete(
+		context.Background(),
+		testEntityKey{table: "entity_table", name: "id", value: "identifier"},
+	)
+	require.Equal(test, raizel.ErrTenantRequired, err, "delete error")
+	client.AssertNotCalled(test, "Apply", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEntityMutation(test *testing.T) {
+	var (
+		key     = testEntityKey{table: "entity_table", name: "id", value: "identifier"}
+		entity  = &testEntity{Tenant: "tenant_b", ID: "identifier", Name: "mock", Age: 3}
+		columns = []string{"tenant_id", "id", "name", "Age", "created_at"}
+	)
+	mutation, err := rspanner.EntityMutation(key, entity)
+	require.Nil(test, err, "mutation error")
+	expected, err := rspanner.InsertOrUpdateStruct("entity_table", entity)
+	require.Nil(test, err, "struct mutation error")
+	require.Equal(test, expected, mutation, "entity mutation")
+
+	mutation, err = rspanner.EntityMutation(raizel.NewTenantKey("tenant_a", key), entity)
+	require.Nil(test, err, "tenant mutation error")
+	require.Equal(
+		test,
+		rspanner.InsertOrUpdate("entity_table", columns, []interface{}{"tenant_a", "identifier", "mock", int64(3), time.Time{}}),
+		mutation, "tenant mutation",
+	)
+	require.Equal(test, "tenant_b", entity.Tenant, "entity tenant")
+
+	_, err = rspanner.EntityMutation(raizel.NewTenantKey("tenant_a", key), map[string]interface{}{"id": "identifier"})
+	require.NotNil(test, err, "invalid entity mutation error")
+}
+
+func TestEntityKey(test *testing.T) {
+	var (
+		order = raizel.NewDynamicKey("orders", "order_id", "order1")
+		item  = raizel.NewChildKey(order, raizel.NewCompositeKey(
+			"items", []string{"item_id", "version"}, []interface{}{int64(2), 1},
+		))
+	)
+	require.Equal(test, rspanner.Key{"order1"}, rspanner.EntityKey(order), "root key")
+	require.Equal(test, rspanner.Key{"order1", int64(2), 1}, rspanner.EntityKey(item), "interleaved key")
+	require.Equal(
+		test, rspanner.Key{"tenant1", "order1", int64(2), 1}, rspanner.EntityKey(raizel.NewTenantKey("tenant1", item)), "tenant interleaved key",
+	)
+}
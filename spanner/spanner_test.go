@@ -1,20 +1,21 @@
-package spanner
+package spanner_test
 
 import (
 	"context"
 	"testing"
 
 	"cloud.google.com/go/spanner"
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRow(t *testing.T) {
-	row := newRow(new(spanner.Row))
+	row := rspanner.NewRow(new(spanner.Row))
 	require.NotNil(t, row, "invalid row instance")
 }
 
 func TestRowIterator(t *testing.T) {
-	iterator := newRowIterator(new(spanner.RowIterator))
+	iterator := rspanner.NewRowIterator(new(spanner.RowIterator))
 	require.NotNil(t, iterator, "invalid iterator instance")
 }
 
@@ -22,9 +23,9 @@ func TestRowIteratorDo(t *testing.T) {
 	mockServer, mockClient := newSpannerClientMock(t)
 	defer mockServer.Stop()
 
-	client := NewClient(mockClient)
-	iterator := client.ReadOnlyTransaction().Query(context.Background(), Statement{SQL: "UPDATE t SET x = 2 WHERE x = 1"})
-	err := iterator.Do(func(Row) error { return nil })
+	client := rspanner.NewClient(mockClient)
+	iterator := client.ReadOnlyTransaction().Query(context.Background(), rspanner.Statement{SQL: "UPDATE t SET x = 2 WHERE x = 1"})
+	err := iterator.Do(func(rspanner.Row) error { return nil })
 	require.Nil(t, err, "iterator do error")
 	iterator.Stop()
 }
@@ -33,8 +34,8 @@ func TestRowIteratorNext(t *testing.T) {
 	mockServer, mockClient := newSpannerClientMock(t)
 	defer mockServer.Stop()
 
-	client := NewClient(mockClient)
-	iterator := client.ReadOnlyTransaction().Query(context.Background(), Statement{SQL: "SELECT column1, column2, columnN from available"})
+	client := rspanner.NewClient(mockClient)
+	iterator := client.ReadOnlyTransaction().Query(context.Background(), rspanner.Statement{SQL: "SELECT column1, column2, columnN from available"})
 	_, err := iterator.Next()
 	require.Nil(t, err, "iterator next error")
 	iterator.Stop()
@@ -0,0 +1,257 @@
+package spannertest
+
+import (
+	"fmt"
+	"strings"
+
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+type column struct {
+	name    string
+	typ     *sppb.Type
+	notNull bool
+}
+
+type keyPart struct {
+	column int
+	desc   bool
+}
+
+type row []*proto3.Value
+
+// table is a copy on write table, the commits clone the table and its rows
+// slice before they change and never change a stored row.
+type table struct {
+	name    string
+	columns []column
+	index   map[string]int
+	key     []keyPart
+	parent  string
+	cascade bool
+	rows    []row
+}
+
+func (t *table) clone() *table {
+	cloned := *t
+	cloned.rows = append([]row(nil), t.rows...)
+	return &cloned
+}
+
+func (t *table) columnIndex(name string) (int, bool) {
+	index, found := t.index[strings.ToLower(name)]
+	return index, found
+}
+
+// schemaName is the case insensitive name of tables and columns.
+func schemaName(name string) string {
+	return strings.ToLower(name)
+}
+
+// parseType parses a column type, the lengths of STRING and BYTES are ignored.
+func parseType(p *parser) (*sppb.Type, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToUpper(name) {
+	case "BOOL":
+		return newType(sppb.TypeCode_BOOL), nil
+	case "INT64":
+		return newType(sppb.TypeCode_INT64), nil
+	case "FLOAT64":
+		return newType(sppb.TypeCode_FLOAT64), nil
+	case "DATE":
+		return newType(sppb.TypeCode_DATE), nil
+	case "TIMESTAMP":
+		return newType(sppb.TypeCode_TIMESTAMP), nil
+	case "STRING", "BYTES":
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		p.next()
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		if strings.ToUpper(name) == "STRING" {
+			return newType(sppb.TypeCode_STRING), nil
+		}
+		return newType(sppb.TypeCode_BYTES), nil
+	case "ARRAY":
+		if err := p.expectSymbol("<"); err != nil {
+			return nil, err
+		}
+		element, err := parseType(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(">"); err != nil {
+			return nil, err
+		}
+		return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: element}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", name)
+}
+
+// skipOptions skips a parenthesized OPTIONS list.
+func skipOptions(p *parser) error {
+	if err := p.expectSymbol("("); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		current := p.next()
+		switch {
+		case current.kind == endToken:
+			return fmt.Errorf("unterminated options")
+		case current == token{kind: symbolToken, text: "("}:
+			depth++
+		case current == token{kind: symbolToken, text: ")"}:
+			depth--
+		}
+	}
+	return nil
+}
+
+// parseCreateTable parses the statement after CREATE TABLE:
+// name ( column type [NOT NULL] [OPTIONS (...)], ... ) PRIMARY KEY (column
+// [ASC|DESC], ...) [, INTERLEAVE IN PARENT parent [ON DELETE CASCADE|NO ACTION]].
+func parseCreateTable(p *parser, tables map[string]*table) (*table, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := tables[schemaName(name)]; exists {
+		return nil, fmt.Errorf("duplicate table %q", name)
+	}
+	created := &table{name: name, index: make(map[string]int)}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.symbol(")") {
+		if len(created.columns) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+			if p.symbol(")") {
+				break
+			}
+		}
+		columnName, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := created.columnIndex(columnName); exists {
+			return nil, fmt.Errorf("duplicate column %q", columnName)
+		}
+		typ, err := parseType(p)
+		if err != nil {
+			return nil, err
+		}
+		definition := column{name: columnName, typ: typ, notNull: p.keyword("NOT", "NULL")}
+		if p.keyword("OPTIONS") {
+			if err := skipOptions(p); err != nil {
+				return nil, err
+			}
+		}
+		created.index[schemaName(columnName)] = len(created.columns)
+		created.columns = append(created.columns, definition)
+	}
+	if err := p.expectKeyword("PRIMARY", "KEY"); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.symbol(")") {
+		if len(created.key) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		columnName, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		index, found := created.columnIndex(columnName)
+		if !found {
+			return nil, fmt.Errorf("unknown key column %q", columnName)
+		}
+		part := keyPart{column: index, desc: p.keyword("DESC")}
+		if !part.desc {
+			p.keyword("ASC")
+		}
+		created.key = append(created.key, part)
+	}
+	if p.symbol(",") {
+		if err := p.expectKeyword("INTERLEAVE", "IN", "PARENT"); err != nil {
+			return nil, err
+		}
+		parentName, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		parent, found := tables[schemaName(parentName)]
+		if !found {
+			return nil, fmt.Errorf("unknown parent table %q", parentName)
+		}
+		if len(parent.key) >= len(created.key) {
+			return nil, fmt.Errorf("table %q key must extend the key of %q", name, parentName)
+		}
+		for index, part := range parent.key {
+			if !strings.EqualFold(parent.columns[part.column].name, created.columns[created.key[index].column].name) {
+				return nil, fmt.Errorf("table %q key must start with the key of %q", name, parentName)
+			}
+		}
+		created.parent = schemaName(parentName)
+		if p.keyword("ON", "DELETE") {
+			created.cascade = p.keyword("CASCADE")
+			if !created.cascade {
+				if err := p.expectKeyword("NO", "ACTION"); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return created, nil
+}
+
+// applyDDL applies one DDL statement to the tables. It supports CREATE TABLE
+// and DROP TABLE, the index statements are accepted and ignored.
+func applyDDL(statement string, tables map[string]*table) error {
+	p, err := newParser(statement)
+	if err != nil {
+		return err
+	}
+	switch {
+	case p.keyword("CREATE", "TABLE"):
+		created, err := parseCreateTable(p, tables)
+		if err != nil {
+			return err
+		}
+		if !p.done() {
+			return fmt.Errorf("unexpected %q after CREATE TABLE", p.peek().text)
+		}
+		tables[schemaName(created.name)] = created
+		return nil
+	case p.keyword("DROP", "TABLE"):
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if _, found := tables[schemaName(name)]; !found {
+			return fmt.Errorf("unknown table %q", name)
+		}
+		for _, child := range tables {
+			if child.parent == schemaName(name) {
+				return fmt.Errorf("table %q has interleaved table %q", name, child.name)
+			}
+		}
+		delete(tables, schemaName(name))
+		return nil
+	case p.keyword("CREATE", "INDEX"), p.keyword("CREATE", "UNIQUE"),
+		p.keyword("CREATE", "NULL_FILTERED"), p.keyword("DROP", "INDEX"):
+		return nil
+	}
+	return fmt.Errorf("unsupported DDL statement %q", statement)
+}
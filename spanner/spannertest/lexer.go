@@ -0,0 +1,200 @@
+package spannertest
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	endToken tokenKind = iota
+	identToken
+	quotedIdentToken
+	numberToken
+	stringToken
+	paramToken
+	symbolToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits the SQL or DDL statement into tokens, the string tokens
+// are unquoted and unescaped.
+func tokenize(statement string) ([]token, error) {
+	var (
+		tokens []token
+		input  = []rune(statement)
+	)
+	for index := 0; index < len(input); {
+		char := input[index]
+		switch {
+		case unicode.IsSpace(char):
+			index++
+		case char == '-' && index+1 < len(input) && input[index+1] == '-':
+			for index < len(input) && input[index] != '\n' {
+				index++
+			}
+		case unicode.IsLetter(char) || char == '_':
+			start := index
+			for index < len(input) && (unicode.IsLetter(input[index]) || unicode.IsDigit(input[index]) || input[index] == '_') {
+				index++
+			}
+			tokens = append(tokens, token{kind: identToken, text: string(input[start:index])})
+		case unicode.IsDigit(char) || (char == '.' && index+1 < len(input) && unicode.IsDigit(input[index+1])):
+			start := index
+			for index < len(input) && (unicode.IsDigit(input[index]) || input[index] == '.' ||
+				input[index] == 'e' || input[index] == 'E' ||
+				((input[index] == '+' || input[index] == '-') && (input[index-1] == 'e' || input[index-1] == 'E'))) {
+				index++
+			}
+			tokens = append(tokens, token{kind: numberToken, text: string(input[start:index])})
+		case char == '@':
+			start := index + 1
+			index++
+			for index < len(input) && (unicode.IsLetter(input[index]) || unicode.IsDigit(input[index]) || input[index] == '_') {
+				index++
+			}
+			if index == start {
+				return nil, fmt.Errorf("blank parameter name at %d", start)
+			}
+			tokens = append(tokens, token{kind: paramToken, text: string(input[start:index])})
+		case char == '`' || char == '\'' || char == '"':
+			var (
+				text   strings.Builder
+				closed bool
+			)
+			for index++; index < len(input); index++ {
+				if input[index] == '\\' && index+1 < len(input) {
+					index++
+					switch input[index] {
+					case 'n':
+						text.WriteRune('\n')
+					case 't':
+						text.WriteRune('\t')
+					default:
+						text.WriteRune(input[index])
+					}
+					continue
+				}
+				if input[index] == char {
+					closed = true
+					index++
+					break
+				}
+				text.WriteRune(input[index])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quote %q", string(char))
+			}
+			kind := stringToken
+			if char == '`' {
+				kind = quotedIdentToken
+			}
+			tokens = append(tokens, token{kind: kind, text: text.String()})
+		default:
+			symbol := string(char)
+			if index+1 < len(input) {
+				switch pair := string(input[index : index+2]); pair {
+				case "<=", ">=", "<>", "!=":
+					symbol = pair
+				}
+			}
+			if !strings.Contains("=<>!(),.*;+-/", string(char)) {
+				return nil, fmt.Errorf("unexpected character %q", string(char))
+			}
+			index += len(symbol)
+			tokens = append(tokens, token{kind: symbolToken, text: symbol})
+		}
+	}
+	return tokens, nil
+}
+
+// parser reads the tokens of one statement.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(statement string) (*parser, error) {
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return nil, err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1] == (token{kind: symbolToken, text: ";"}) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: endToken}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	current := p.peek()
+	if current.kind != endToken {
+		p.pos++
+	}
+	return current
+}
+
+func (p *parser) done() bool {
+	return p.peek().kind == endToken
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	current := p.peek()
+	return current.kind == identToken && strings.EqualFold(current.text, keyword)
+}
+
+// keyword consumes the keywords when the next tokens match all of them.
+func (p *parser) keyword(keywords ...string) bool {
+	start := p.pos
+	for _, keyword := range keywords {
+		if !p.isKeyword(keyword) {
+			p.pos = start
+			return false
+		}
+		p.pos++
+	}
+	return true
+}
+
+func (p *parser) expectKeyword(keywords ...string) error {
+	if !p.keyword(keywords...) {
+		return fmt.Errorf("expected %s, found %q", strings.Join(keywords, " "), p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) symbol(symbol string) bool {
+	if current := p.peek(); current.kind == symbolToken && current.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.symbol(symbol) {
+		return fmt.Errorf("expected %q, found %q", symbol, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	current := p.peek()
+	if current.kind != identToken && current.kind != quotedIdentToken {
+		return "", fmt.Errorf("expected identifier, found %q", current.text)
+	}
+	p.pos++
+	return current.text, nil
+}
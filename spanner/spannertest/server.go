@@ -0,0 +1,421 @@
+// Package spannertest provides an in-memory Spanner gRPC service for the tests
+// of spanner clients and raizel spanner repositories, without the emulator or
+// network access.
+package spannertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/api/option"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server is an in-memory Spanner database listening on the local loopback
+// interface. The tables are created with UpdateDDL, rows are read by key, key
+// range and with simple SELECT statements, and written by the mutations of
+// commits. Transactions are serialized by the server and only abort when an
+// error is injected. DML, partitions and reads by index are not implemented.
+type Server struct {
+	sppb.UnimplementedSpannerServer
+
+	Addr string
+
+	grpc         *grpc.Server
+	mu           sync.Mutex
+	tables       map[string]*table
+	sessions     map[string]*sppb.Session
+	transactions map[string]bool
+	errors       map[string][]error
+	sequence     int
+	lastTime     time.Time
+}
+
+// NewServer starts a Server with the tables of the DDL statements.
+func NewServer(ddl ...string) (*Server, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		Addr:         listener.Addr().String(),
+		tables:       make(map[string]*table),
+		sessions:     make(map[string]*sppb.Session),
+		transactions: make(map[string]bool),
+		errors:       make(map[string][]error),
+	}
+	if err := server.UpdateDDL(ddl...); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	server.grpc = grpc.NewServer(
+		grpc.UnaryInterceptor(server.unaryInterceptor),
+		grpc.StreamInterceptor(server.streamInterceptor),
+	)
+	sppb.RegisterSpannerServer(server.grpc, server)
+	go server.grpc.Serve(listener)
+	return server, nil
+}
+
+// NewClient returns a spanner client of database connected to the server,
+// the server has one database and ignores the database name. Closing the
+// client closes its connection.
+func (s *Server) NewClient(ctx context.Context, database string) (*spanner.Client, error) {
+	conn, err := grpc.DialContext(ctx, s.Addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return spanner.NewClient(ctx, database, option.WithGRPCConn(conn))
+}
+
+// UpdateDDL applies the CREATE TABLE and DROP TABLE statements, the index
+// statements are accepted and ignored. A failed statement discards the
+// statements of the call.
+func (s *Server) UpdateDDL(statements ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	staged := make(map[string]*table, len(s.tables))
+	for name, stored := range s.tables {
+		staged[name] = stored
+	}
+	for _, statement := range statements {
+		if err := applyDDL(statement, staged); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
+	s.tables = staged
+	return nil
+}
+
+// InjectError makes the next calls of the RPC method fail with errs, one call
+// for each error. The method is the RPC name, like Commit, StreamingRead or
+// ExecuteStreamingSql.
+func (s *Server) InjectError(method string, errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[method] = append(s.errors[method], errs...)
+}
+
+// Abort makes the next count commits fail with codes.Aborted, the clients
+// retry their read write transactions.
+func (s *Server) Abort(count int) {
+	for index := 0; index < count; index++ {
+		s.InjectError("Commit", status.Error(codes.Aborted, "transaction aborted"))
+	}
+}
+
+// Reset removes the rows of every table, the injected errors and the
+// transactions.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, stored := range s.tables {
+		emptied := stored.clone()
+		emptied.rows = nil
+		s.tables[name] = emptied
+	}
+	s.transactions = make(map[string]bool)
+	s.errors = make(map[string][]error)
+}
+
+func (s *Server) Close() {
+	s.grpc.Stop()
+}
+
+func (s *Server) injectedError(fullMethod string) error {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := s.errors[method]
+	if len(errs) == 0 {
+		return nil
+	}
+	s.errors[method] = errs[1:]
+	return errs[0]
+}
+
+func (s *Server) unaryInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := s.injectedError(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamInterceptor(
+	srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if err := s.injectedError(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// timestamp returns a strictly increasing time, so every commit has its own
+// timestamp. It must be called with the lock held.
+func (s *Server) timestamp() *tspb.Timestamp {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.lastTime) {
+		now = s.lastTime.Add(time.Microsecond)
+	}
+	s.lastTime = now
+	ts, _ := ptypes.TimestampProto(now)
+	return ts
+}
+
+// newTransaction returns a new transaction, it must be called with the lock
+// held.
+func (s *Server) newTransaction(readOnly bool) *sppb.Transaction {
+	s.sequence++
+	id := fmt.Sprintf("transaction-%d", s.sequence)
+	s.transactions[id] = readOnly
+	return &sppb.Transaction{Id: []byte(id), ReadTimestamp: s.timestamp()}
+}
+
+// selectTransaction checks the transaction of a read, it returns the new
+// transaction of a begin selector and the read timestamp of a single use
+// one. It must be called with the lock held.
+func (s *Server) selectTransaction(selector *sppb.TransactionSelector) (*sppb.Transaction, error) {
+	switch selected := selector.GetSelector().(type) {
+	case *sppb.TransactionSelector_Id:
+		if _, active := s.transactions[string(selected.Id)]; !active {
+			return nil, status.Errorf(codes.NotFound, "transaction %q not found", selected.Id)
+		}
+		return nil, nil
+	case *sppb.TransactionSelector_Begin:
+		return s.newTransaction(selected.Begin.GetReadOnly() != nil), nil
+	}
+	return &sppb.Transaction{ReadTimestamp: s.timestamp()}, nil
+}
+
+func (s *Server) CreateSession(_ context.Context, req *sppb.CreateSessionRequest) (*sppb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequence++
+	session := &sppb.Session{Name: fmt.Sprintf("%s/sessions/session-%d", req.GetDatabase(), s.sequence)}
+	s.sessions[session.Name] = session
+	return session, nil
+}
+
+func (s *Server) BatchCreateSessions(
+	ctx context.Context, req *sppb.BatchCreateSessionsRequest,
+) (*sppb.BatchCreateSessionsResponse, error) {
+	response := &sppb.BatchCreateSessionsResponse{}
+	for index := int32(0); index < req.GetSessionCount(); index++ {
+		session, err := s.CreateSession(ctx, &sppb.CreateSessionRequest{Database: req.GetDatabase()})
+		if err != nil {
+			return nil, err
+		}
+		response.Session = append(response.Session, session)
+	}
+	return response, nil
+}
+
+func (s *Server) GetSession(_ context.Context, req *sppb.GetSessionRequest) (*sppb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, found := s.sessions[req.GetName()]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "session not found: %s", req.GetName())
+	}
+	return session, nil
+}
+
+func (s *Server) DeleteSession(_ context.Context, req *sppb.DeleteSessionRequest) (*empty.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, req.GetName())
+	return &empty.Empty{}, nil
+}
+
+func (s *Server) BeginTransaction(_ context.Context, req *sppb.BeginTransactionRequest) (*sppb.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newTransaction(req.GetOptions().GetReadOnly() != nil), nil
+}
+
+func (s *Server) Rollback(_ context.Context, req *sppb.RollbackRequest) (*empty.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.transactions, string(req.GetTransactionId()))
+	return &empty.Empty{}, nil
+}
+
+// Commit applies the mutations atomically, a failed mutation discards every
+// mutation of the request.
+func (s *Server) Commit(_ context.Context, req *sppb.CommitRequest) (*sppb.CommitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id := req.GetTransactionId(); id != nil {
+		readOnly, active := s.transactions[string(id)]
+		if !active {
+			return nil, status.Errorf(codes.NotFound, "transaction %q not found", id)
+		}
+		if readOnly {
+			return nil, status.Errorf(codes.FailedPrecondition, "transaction %q is read only", id)
+		}
+	}
+	var (
+		commitTimestamp = s.timestamp()
+		commitTime      = ptypes.TimestampString(commitTimestamp)
+		staged          = newDatabase(s.tables)
+	)
+	for _, mutation := range req.GetMutations() {
+		if err := staged.apply(mutation, commitTime); err != nil {
+			return nil, err
+		}
+	}
+	s.tables = staged.tables
+	delete(s.transactions, string(req.GetTransactionId()))
+	return &sppb.CommitResponse{CommitTimestamp: commitTimestamp}, nil
+}
+
+func resultMetadata(fields []*sppb.StructType_Field, transaction *sppb.Transaction) *sppb.ResultSetMetadata {
+	return &sppb.ResultSetMetadata{RowType: &sppb.StructType{Fields: fields}, Transaction: transaction}
+}
+
+func sendRows(
+	send func(*sppb.PartialResultSet) error, fields []*sppb.StructType_Field, transaction *sppb.Transaction, rows []row,
+) error {
+	result := &sppb.PartialResultSet{Metadata: resultMetadata(fields, transaction)}
+	for _, current := range rows {
+		result.Values = append(result.Values, current...)
+	}
+	return send(result)
+}
+
+// read returns the columns of the rows of the read request in key order, it
+// must be called with the lock held.
+func (s *Server) read(req *sppb.ReadRequest) ([]*sppb.StructType_Field, []row, error) {
+	if req.GetIndex() != "" {
+		return nil, nil, status.Errorf(codes.Unimplemented, "spannertest does not read by index")
+	}
+	target, found := s.tables[schemaName(req.GetTable())]
+	if !found {
+		return nil, nil, status.Errorf(codes.NotFound, "table not found: %s", req.GetTable())
+	}
+	var (
+		fields  = make([]*sppb.StructType_Field, len(req.GetColumns()))
+		columns = make([]int, len(req.GetColumns()))
+	)
+	for index, name := range req.GetColumns() {
+		position, found := target.columnIndex(name)
+		if !found {
+			return nil, nil, status.Errorf(codes.NotFound, "column not found in table %s: %s", target.name, name)
+		}
+		columns[index] = position
+		fields[index] = &sppb.StructType_Field{Name: target.columns[position].name, Type: target.columns[position].typ}
+	}
+	matches, err := target.keyMatcher(req.GetKeySet())
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows []row
+	for _, current := range target.rows {
+		if req.GetLimit() > 0 && int64(len(rows)) >= req.GetLimit() {
+			break
+		}
+		if !matches(target.rowKey(current)) {
+			continue
+		}
+		selected := make(row, len(columns))
+		for index, position := range columns {
+			selected[index] = current[position]
+		}
+		rows = append(rows, selected)
+	}
+	return fields, rows, nil
+}
+
+func (s *Server) StreamingRead(req *sppb.ReadRequest, stream sppb.Spanner_StreamingReadServer) error {
+	s.mu.Lock()
+	transaction, err := s.selectTransaction(req.GetTransaction())
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	fields, rows, err := s.read(req)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return sendRows(stream.Send, fields, transaction, rows)
+}
+
+func (s *Server) Read(_ context.Context, req *sppb.ReadRequest) (*sppb.ResultSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transaction, err := s.selectTransaction(req.GetTransaction())
+	if err != nil {
+		return nil, err
+	}
+	fields, rows, err := s.read(req)
+	if err != nil {
+		return nil, err
+	}
+	return resultSet(fields, transaction, rows), nil
+}
+
+func resultSet(fields []*sppb.StructType_Field, transaction *sppb.Transaction, rows []row) *sppb.ResultSet {
+	result := &sppb.ResultSet{Metadata: resultMetadata(fields, transaction)}
+	for _, current := range rows {
+		result.Rows = append(result.Rows, &proto3.ListValue{Values: current})
+	}
+	return result
+}
+
+// query runs the SELECT statement of the request, it must be called with the
+// lock held.
+func (s *Server) query(req *sppb.ExecuteSqlRequest) ([]*sppb.StructType_Field, []row, error) {
+	statement, err := parseSelect(req.GetSql())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unimplemented, "spannertest: %v", err)
+	}
+	fields, rows, err := statement.execute(s.tables, decodeParams(req.GetParams(), req.GetParamTypes()))
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return fields, rows, nil
+}
+
+func (s *Server) ExecuteStreamingSql(req *sppb.ExecuteSqlRequest, stream sppb.Spanner_ExecuteStreamingSqlServer) error {
+	s.mu.Lock()
+	transaction, err := s.selectTransaction(req.GetTransaction())
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	fields, rows, err := s.query(req)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return sendRows(stream.Send, fields, transaction, rows)
+}
+
+func (s *Server) ExecuteSql(_ context.Context, req *sppb.ExecuteSqlRequest) (*sppb.ResultSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transaction, err := s.selectTransaction(req.GetTransaction())
+	if err != nil {
+		return nil, err
+	}
+	fields, rows, err := s.query(req)
+	if err != nil {
+		return nil, err
+	}
+	return resultSet(fields, transaction, rows), nil
+}
@@ -0,0 +1,397 @@
+package spannertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	rspanner "github.com/rjansen/raizel/spanner"
+	"github.com/rjansen/raizel/spanner/spannertest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testDatabase = "projects/spannertest/instances/spannertest/databases/spannertest"
+)
+
+var testDDL = []string{
+	`CREATE TABLE orders (
+		order_id STRING(MAX) NOT NULL,
+		customer STRING(64),
+		total FLOAT64,
+		updated_at TIMESTAMP OPTIONS (allow_commit_timestamp = true),
+	) PRIMARY KEY (order_id)`,
+	`CREATE TABLE items (
+		order_id STRING(MAX) NOT NULL,
+		item_id INT64 NOT NULL,
+		quantity INT64,
+	) PRIMARY KEY (order_id, item_id),
+	INTERLEAVE IN PARENT orders ON DELETE CASCADE`,
+}
+
+type order struct {
+	OrderID  string  `spanner:"order_id"`
+	Customer string  `spanner:"customer"`
+	Total    float64 `spanner:"total"`
+}
+
+type item struct {
+	OrderID  string `spanner:"order_id"`
+	ItemID   int64  `spanner:"item_id"`
+	Quantity int64  `spanner:"quantity"`
+}
+
+func newTestClient(t *testing.T) (*spannertest.Server, *spanner.Client) {
+	server, err := spannertest.NewServer(testDDL...)
+	require.Nil(t, err, "new server error")
+	client, err := server.NewClient(context.Background(), testDatabase)
+	require.Nil(t, err, "new client error")
+	return server, client
+}
+
+func setupOrders(t *testing.T, client *spanner.Client) {
+	mutations := make([]*spanner.Mutation, 0, 4)
+	for index, customer := range []string{"ann", "bob", "cid", "bob"} {
+		orderID := fmt.Sprintf("order%d", index)
+		mutations = append(mutations, spanner.Insert(
+			"orders", []string{"order_id", "customer", "total", "updated_at"},
+			[]interface{}{orderID, customer, float64(index * 10), spanner.CommitTimestamp},
+		))
+		for itemID := int64(1); itemID <= int64(index); itemID++ {
+			mutations = append(mutations, spanner.Insert(
+				"items", []string{"order_id", "item_id", "quantity"}, []interface{}{orderID, itemID, itemID},
+			))
+		}
+	}
+	_, err := client.Apply(context.Background(), mutations)
+	require.Nil(t, err, "setup error")
+}
+
+func readIDs(t *testing.T, rows *spanner.RowIterator) []string {
+	var ids []string
+	err := rows.Do(func(row *spanner.Row) error {
+		var id string
+		if err := row.Column(0, &id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	require.Nil(t, err, "read rows error")
+	return ids
+}
+
+func TestServerMutationsAndReads(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	ctx := context.Background()
+	setupOrders(test, client)
+
+	row, err := client.Single().ReadRow(ctx, "orders", spanner.Key{"order1"}, []string{"customer", "total", "updated_at"})
+	require.Nil(test, err, "read row error")
+	var (
+		customer  string
+		total     float64
+		updatedAt spanner.NullTime
+	)
+	require.Nil(test, row.Columns(&customer, &total, &updatedAt), "columns error")
+	require.Equal(test, "bob", customer, "customer")
+	require.Equal(test, 10.0, total, "total")
+	require.True(test, updatedAt.Valid, "commit timestamp")
+
+	_, err = client.Single().ReadRow(ctx, "orders", spanner.Key{"missing"}, []string{"customer"})
+	require.Equal(test, codes.NotFound, spanner.ErrCode(err), "read missing row error")
+
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("orders", []string{"order_id"}, []interface{}{"order0"}),
+	})
+	require.Equal(test, codes.AlreadyExists, spanner.ErrCode(err), "insert existing error")
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.Update("orders", []string{"order_id", "customer"}, []interface{}{"missing", "x"}),
+	})
+	require.Equal(test, codes.NotFound, spanner.ErrCode(err), "update missing error")
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("items", []string{"order_id", "item_id"}, []interface{}{"missing", 1}),
+	})
+	require.Equal(test, codes.NotFound, spanner.ErrCode(err), "insert orphan error")
+
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.Update("orders", []string{"order_id", "customer"}, []interface{}{"order1", "bea"}),
+	})
+	require.Nil(test, err, "update error")
+	var updated order
+	row, err = client.Single().ReadRow(ctx, "orders", spanner.Key{"order1"}, []string{"order_id", "customer", "total"})
+	require.Nil(test, err, "read updated error")
+	require.Nil(test, row.ToStruct(&updated), "to struct error")
+	require.Equal(test, order{OrderID: "order1", Customer: "bea", Total: 10}, updated, "updated order")
+
+	ids := readIDs(test, client.Single().Read(
+		ctx, "orders", spanner.KeyRange{Start: spanner.Key{"order1"}, End: spanner.Key{"order3"}, Kind: spanner.ClosedOpen},
+		[]string{"order_id"},
+	))
+	require.Equal(test, []string{"order1", "order2"}, ids, "key range ids")
+
+	rows := client.Single().Read(ctx, "items", spanner.Key{"order3"}.AsPrefix(), []string{"order_id", "item_id"})
+	var itemIDs []int64
+	require.Nil(test, rows.Do(func(row *spanner.Row) error {
+		var itemID int64
+		if err := row.Column(1, &itemID); err != nil {
+			return err
+		}
+		itemIDs = append(itemIDs, itemID)
+		return nil
+	}), "read prefix error")
+	require.Equal(test, []int64{1, 2, 3}, itemIDs, "prefix item ids")
+
+	_, err = client.Apply(ctx, []*spanner.Mutation{spanner.Delete("orders", spanner.Key{"order3"})})
+	require.Nil(test, err, "delete error")
+	ids = readIDs(test, client.Single().Read(ctx, "items", spanner.Key{"order3"}.AsPrefix(), []string{"order_id"}))
+	require.Empty(test, ids, "cascaded items")
+}
+
+type testServerQuery struct {
+	name      string
+	statement spanner.Statement
+	ids       []string
+	code      codes.Code
+}
+
+func TestServerQuery(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	ctx := context.Background()
+	setupOrders(test, client)
+
+	scenarios := []testServerQuery{
+		{
+			name:      "Selects every row in key order",
+			statement: spanner.NewStatement("SELECT order_id FROM orders"),
+			ids:       []string{"order0", "order1", "order2", "order3"},
+		},
+		{
+			name: "Filters by parameter and orders descending",
+			statement: spanner.Statement{
+				SQL:    "SELECT o.order_id FROM orders o WHERE o.customer = @customer ORDER BY total DESC",
+				Params: map[string]interface{}{"customer": "bob"},
+			},
+			ids: []string{"order3", "order1"},
+		},
+		{
+			name: "Filters with OR, NOT and IS NULL",
+			statement: spanner.NewStatement(
+				"SELECT order_id FROM orders WHERE (total < 10 OR total >= 30) AND NOT customer IS NULL",
+			),
+			ids: []string{"order0", "order3"},
+		},
+		{
+			name:      "Limits and offsets",
+			statement: spanner.NewStatement("SELECT order_id FROM orders ORDER BY order_id DESC LIMIT 2 OFFSET 1"),
+			ids:       []string{"order2", "order1"},
+		},
+		{
+			name:      "Fails with an unknown table",
+			statement: spanner.NewStatement("SELECT order_id FROM unknown"),
+			code:      codes.InvalidArgument,
+		},
+		{
+			name:      "Fails with DML",
+			statement: spanner.NewStatement("UPDATE orders SET total = 0 WHERE true"),
+			code:      codes.Unimplemented,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					rows = client.Single().Query(ctx, scenario.statement)
+					ids  []string
+				)
+				defer rows.Stop()
+				for {
+					row, err := rows.Next()
+					if err == iterator.Done {
+						require.Equal(t, codes.OK, scenario.code, "query error code")
+						break
+					}
+					if scenario.code != codes.OK {
+						require.Equal(t, scenario.code, spanner.ErrCode(err), "query error code")
+						return
+					}
+					require.Nil(t, err, "next row error")
+					var id string
+					require.Nil(t, row.Column(0, &id), "column error")
+					ids = append(ids, id)
+				}
+				require.Equal(t, scenario.ids, ids, "query ids")
+			},
+		)
+	}
+
+	row, err := client.Single().Query(ctx, spanner.NewStatement("SELECT COUNT(*) FROM items WHERE quantity > 1")).Next()
+	require.Nil(test, err, "count error")
+	var count int64
+	require.Nil(test, row.Column(0, &count), "count column error")
+	require.Equal(test, int64(3), count, "count")
+}
+
+func TestServerTransactions(test *testing.T) {
+	server, client := newTestClient(test)
+	defer server.Close()
+	defer client.Close()
+	ctx := context.Background()
+	setupOrders(test, client)
+
+	server.Abort(2)
+	var attempts int
+	_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+		attempts++
+		row, err := tx.ReadRow(ctx, "orders", spanner.Key{"order2"}, []string{"total"})
+		if err != nil {
+			return err
+		}
+		var total float64
+		if err := row.Column(0, &total); err != nil {
+			return err
+		}
+		return tx.BufferWrite([]*spanner.Mutation{
+			spanner.Update("orders", []string{"order_id", "total"}, []interface{}{"order2", total + 1}),
+		})
+	})
+	require.Nil(test, err, "read write transaction error")
+	require.Equal(test, 3, attempts, "aborted attempts")
+
+	row, err := client.Single().ReadRow(ctx, "orders", spanner.Key{"order2"}, []string{"total"})
+	require.Nil(test, err, "read error")
+	var total float64
+	require.Nil(test, row.Column(0, &total), "column error")
+	require.Equal(test, 21.0, total, "committed total")
+
+	transaction := client.ReadOnlyTransaction()
+	defer transaction.Close()
+	ids := readIDs(test, transaction.Query(ctx, spanner.NewStatement("SELECT order_id FROM orders WHERE total > 20")))
+	require.Equal(test, []string{"order2", "order3"}, ids, "read only query ids")
+
+	injected := status.Error(codes.Unavailable, "injected")
+	server.InjectError("ExecuteStreamingSql", injected, injected, injected, injected, injected, injected)
+	server.InjectError("StreamingRead", status.Error(codes.PermissionDenied, "denied"))
+	_, err = client.Single().ReadRow(ctx, "orders", spanner.Key{"order2"}, []string{"total"})
+	require.Equal(test, codes.PermissionDenied, spanner.ErrCode(err), "injected error")
+	server.Reset()
+	ids = readIDs(test, client.Single().Query(ctx, spanner.NewStatement("SELECT order_id FROM orders")))
+	require.Empty(test, ids, "reset rows")
+}
+
+func TestServerRepository(test *testing.T) {
+	server, sclient := newTestClient(test)
+	defer server.Close()
+	var (
+		ctx        = context.Background()
+		repository = rspanner.NewRepository(rspanner.NewClient(sclient))
+		parent     = raizel.NewDynamicKey("orders", "order_id", "order1")
+	)
+	defer repository.Close(ctx)
+	require.Nil(test, repository.Set(ctx, parent, &order{OrderID: "order1", Customer: "ann"}), "set parent error")
+	for itemID := int64(1); itemID <= 3; itemID++ {
+		key := raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "item_id", itemID))
+		require.Nil(test, repository.Set(ctx, key, &item{OrderID: "order1", ItemID: itemID, Quantity: itemID * 10}), "set error")
+	}
+
+	var entity item
+	key := raizel.NewChildKey(parent, raizel.NewDynamicKey("items", "item_id", int64(2)))
+	require.Nil(test, repository.Get(ctx, key, &entity), "get error")
+	require.Equal(test, item{OrderID: "order1", ItemID: 2, Quantity: 20}, entity, "entity")
+
+	query := raizel.Children(parent, "items").Where("quantity", raizel.GreaterEqual, int64(20)).
+		OrderBy("quantity", raizel.Desc).OrderBy("item_id", raizel.Desc)
+	count, err := raizel.Count(ctx, repository, query)
+	require.Nil(test, err, "count error")
+	require.Equal(test, int64(2), count, "count")
+
+	page, err := repository.(raizel.Pageable).Page(ctx, query.WithLimit(1), "")
+	require.Nil(test, err, "page error")
+	require.Nil(test, page.Next(ctx, &entity), "page next error")
+	require.Equal(test, int64(3), entity.ItemID, "first page item")
+	require.Equal(test, raizel.ErrIteratorDone, page.Next(ctx, &entity), "first page done")
+	next, err := repository.(raizel.Pageable).Page(ctx, query.WithLimit(1), page.NextPageToken())
+	require.Nil(test, err, "next page error")
+	require.Nil(test, next.Next(ctx, &entity), "next page next error")
+	require.Equal(test, int64(2), entity.ItemID, "next page item")
+
+	require.Nil(test, raizel.DeleteCascade(ctx, repository, parent), "delete cascade error")
+	count, err = raizel.Count(ctx, repository, raizel.Children(parent, "items"))
+	require.Nil(test, err, "count deleted error")
+	require.Zero(test, count, "count deleted")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, parent, &order{}), "get deleted error")
+}
+
+func TestServerOutbox(test *testing.T) {
+	server, err := spannertest.NewServer(append(testDDL, `CREATE TABLE outbox_events (
+		id STRING(MAX) NOT NULL,
+		topic STRING(MAX),
+		key STRING(MAX),
+		payload BYTES(MAX),
+		created_at TIMESTAMP,
+		published BOOL,
+		published_at TIMESTAMP,
+		attempts INT64,
+		next_attempt TIMESTAMP,
+		last_error STRING(MAX),
+	) PRIMARY KEY (id)`)...)
+	require.Nil(test, err, "new server error")
+	defer server.Close()
+	sclient, err := server.NewClient(context.Background(), testDatabase)
+	require.Nil(test, err, "new client error")
+	_, err = raizel.RegisterEntity(raizel.OutboxEvent{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = context.Background()
+		repository = rspanner.NewRepository(rspanner.NewClient(sclient))
+		key        = raizel.NewDynamicKey("orders", "order_id", "order1")
+		event      = raizel.NewOutboxEvent("orders.created", "order1", []byte(`{"order_id":"order1"}`))
+	)
+	defer repository.Close(ctx)
+
+	err = raizel.Transaction(ctx, repository, func(ctx context.Context, tx raizel.Repository) error {
+		if err := tx.Set(ctx, key, &order{OrderID: "order1", Customer: "ann"}); err != nil {
+			return err
+		}
+		if err := raizel.Enqueue(ctx, tx, event); err != nil {
+			return err
+		}
+		return raizel.ErrInvalidArgument
+	})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "rolled back transaction error")
+	require.Equal(test, raizel.ErrNotFound, repository.Get(ctx, key, &order{}), "rolled back set")
+
+	require.Nil(test, raizel.SetWithEvents(ctx, repository, key, &order{OrderID: "order1", Customer: "ann"}, event), "set with events error")
+	var stored order
+	require.Nil(test, repository.Get(ctx, key, &stored), "get error")
+	require.Equal(test, "ann", stored.Customer, "stored customer")
+
+	publisher := new(recordingPublisher)
+	published, err := raizel.NewRelay(repository, publisher).Dispatch(ctx)
+	require.Nil(test, err, "dispatch error")
+	require.Equal(test, 1, published, "published count")
+	require.Equal(test, []string{"orders.created"}, publisher.topics, "published topics")
+	cleaned, err := raizel.NewRelay(repository, publisher).Cleanup(ctx)
+	require.Nil(test, err, "cleanup error")
+	require.Equal(test, 1, cleaned, "cleaned count")
+}
+
+type recordingPublisher struct {
+	topics []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event raizel.OutboxEvent) error {
+	p.topics = append(p.topics, event.Topic)
+	return nil
+}
@@ -0,0 +1,606 @@
+package spannertest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// environment is the row and parameters an expression is evaluated with.
+type environment struct {
+	table  *table
+	row    row
+	params map[string]value
+}
+
+type expression interface {
+	eval(*environment) (value, error)
+	typ(*environment) *sppb.Type
+}
+
+type literalExpression struct {
+	value value
+}
+
+func (e literalExpression) eval(*environment) (value, error) {
+	return e.value, nil
+}
+
+func (e literalExpression) typ(*environment) *sppb.Type {
+	return e.value.typ
+}
+
+type paramExpression struct {
+	name string
+}
+
+func (e paramExpression) eval(env *environment) (value, error) {
+	param, found := env.params[strings.ToLower(e.name)]
+	if !found {
+		return value{}, fmt.Errorf("no value for parameter @%s", e.name)
+	}
+	return param, nil
+}
+
+func (e paramExpression) typ(env *environment) *sppb.Type {
+	return env.params[strings.ToLower(e.name)].typ
+}
+
+type columnExpression struct {
+	name string
+}
+
+func (e columnExpression) column(env *environment) (int, error) {
+	if env.table == nil {
+		return 0, fmt.Errorf("unrecognized name: %s", e.name)
+	}
+	index, found := env.table.columnIndex(e.name)
+	if !found {
+		return 0, fmt.Errorf("unrecognized name: %s", e.name)
+	}
+	return index, nil
+}
+
+func (e columnExpression) eval(env *environment) (value, error) {
+	index, err := e.column(env)
+	if err != nil {
+		return value{}, err
+	}
+	return value{typ: env.table.columns[index].typ, val: env.row[index]}, nil
+}
+
+func (e columnExpression) typ(env *environment) *sppb.Type {
+	index, err := e.column(env)
+	if err != nil {
+		return nil
+	}
+	return env.table.columns[index].typ
+}
+
+// countExpression is COUNT(*), it is evaluated by the query and not by row.
+type countExpression struct{}
+
+func (e countExpression) eval(*environment) (value, error) {
+	return value{}, fmt.Errorf("COUNT(*) is only supported as a select column")
+}
+
+func (e countExpression) typ(*environment) *sppb.Type {
+	return newType(sppb.TypeCode_INT64)
+}
+
+type notExpression struct {
+	operand expression
+}
+
+func (e notExpression) eval(env *environment) (value, error) {
+	operand, err := e.operand.eval(env)
+	if err != nil || isNull(operand.val) {
+		return value{typ: newType(sppb.TypeCode_BOOL), val: nullValue}, err
+	}
+	return boolValue(!operand.val.GetBoolValue()), nil
+}
+
+func (e notExpression) typ(*environment) *sppb.Type {
+	return newType(sppb.TypeCode_BOOL)
+}
+
+// logicalExpression is AND or OR with the three valued logic of NULL.
+type logicalExpression struct {
+	and         bool
+	left, right expression
+}
+
+func (e logicalExpression) eval(env *environment) (value, error) {
+	var (
+		sawNull bool
+		result  = !e.and
+	)
+	for _, operand := range []expression{e.left, e.right} {
+		evaluated, err := operand.eval(env)
+		if err != nil {
+			return value{}, err
+		}
+		if isNull(evaluated.val) {
+			sawNull = true
+			continue
+		}
+		if evaluated.typ.GetCode() != sppb.TypeCode_BOOL {
+			return value{}, fmt.Errorf("logical operand of type %s", typeString(evaluated.typ))
+		}
+		if evaluated.val.GetBoolValue() == result {
+			return boolValue(result), nil
+		}
+	}
+	if sawNull {
+		return value{typ: newType(sppb.TypeCode_BOOL), val: nullValue}, nil
+	}
+	return boolValue(!result), nil
+}
+
+func (e logicalExpression) typ(*environment) *sppb.Type {
+	return newType(sppb.TypeCode_BOOL)
+}
+
+type compareExpression struct {
+	operator    string
+	left, right expression
+}
+
+func (e compareExpression) eval(env *environment) (value, error) {
+	left, err := e.left.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	right, err := e.right.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	if isNull(left.val) || isNull(right.val) {
+		return value{typ: newType(sppb.TypeCode_BOOL), val: nullValue}, nil
+	}
+	compared, err := compareValues(left, right)
+	if err != nil {
+		return value{}, err
+	}
+	switch e.operator {
+	case "=":
+		return boolValue(compared == 0), nil
+	case "!=", "<>":
+		return boolValue(compared != 0), nil
+	case "<":
+		return boolValue(compared < 0), nil
+	case "<=":
+		return boolValue(compared <= 0), nil
+	case ">":
+		return boolValue(compared > 0), nil
+	}
+	return boolValue(compared >= 0), nil
+}
+
+func (e compareExpression) typ(*environment) *sppb.Type {
+	return newType(sppb.TypeCode_BOOL)
+}
+
+type isNullExpression struct {
+	operand expression
+	not     bool
+}
+
+func (e isNullExpression) eval(env *environment) (value, error) {
+	operand, err := e.operand.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	return boolValue(isNull(operand.val) != e.not), nil
+}
+
+func (e isNullExpression) typ(*environment) *sppb.Type {
+	return newType(sppb.TypeCode_BOOL)
+}
+
+type selectColumn struct {
+	expression expression
+	alias      string
+	star       bool
+}
+
+type orderTerm struct {
+	expression expression
+	desc       bool
+}
+
+// selectStatement is the supported subset of spanner SQL: SELECT columns
+// [FROM table [alias]] [WHERE condition] [ORDER BY terms] [LIMIT n [OFFSET m]].
+type selectStatement struct {
+	columns []selectColumn
+	table   string
+	alias   string
+	where   expression
+	orders  []orderTerm
+	limit   expression
+	offset  expression
+}
+
+func parseSelect(sql string) (*selectStatement, error) {
+	p, err := newParser(sql)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	statement := &selectStatement{}
+	for {
+		if p.symbol("*") {
+			statement.columns = append(statement.columns, selectColumn{star: true})
+		} else {
+			expr, err := parseExpression(p)
+			if err != nil {
+				return nil, err
+			}
+			column := selectColumn{expression: expr}
+			if p.keyword("AS") {
+				if column.alias, err = p.ident(); err != nil {
+					return nil, err
+				}
+			} else if named, isColumn := expr.(columnExpression); isColumn {
+				column.alias = named.name
+			}
+			statement.columns = append(statement.columns, column)
+		}
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if p.keyword("FROM") {
+		if statement.table, err = p.ident(); err != nil {
+			return nil, err
+		}
+		p.keyword("AS")
+		if current := p.peek(); current.kind == quotedIdentToken ||
+			(current.kind == identToken && !isReserved(current.text)) {
+			statement.alias = p.next().text
+		}
+	}
+	if p.keyword("WHERE") {
+		if statement.where, err = parseExpression(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("ORDER", "BY") {
+		for {
+			expr, err := parseExpression(p)
+			if err != nil {
+				return nil, err
+			}
+			term := orderTerm{expression: expr, desc: p.keyword("DESC")}
+			if !term.desc {
+				p.keyword("ASC")
+			}
+			statement.orders = append(statement.orders, term)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("LIMIT") {
+		if statement.limit, err = parsePrimary(p); err != nil {
+			return nil, err
+		}
+		if p.keyword("OFFSET") {
+			if statement.offset, err = parsePrimary(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unsupported SQL near %q", p.peek().text)
+	}
+	return statement, nil
+}
+
+var reservedWords = map[string]bool{
+	"WHERE": true, "ORDER": true, "LIMIT": true, "GROUP": true, "JOIN": true, "HAVING": true,
+}
+
+func isReserved(word string) bool {
+	return reservedWords[strings.ToUpper(word)]
+}
+
+func parseExpression(p *parser) (expression, error) {
+	left, err := parseAnd(p)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := parseAnd(p)
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{left: left, right: right}
+	}
+	return left, nil
+}
+
+func parseAnd(p *parser) (expression, error) {
+	left, err := parseNot(p)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := parseNot(p)
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func parseNot(p *parser) (expression, error) {
+	if p.keyword("NOT") {
+		operand, err := parseNot(p)
+		if err != nil {
+			return nil, err
+		}
+		return notExpression{operand: operand}, nil
+	}
+	left, err := parsePrimary(p)
+	if err != nil {
+		return nil, err
+	}
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return isNullExpression{operand: left, not: not}, nil
+	}
+	if current := p.peek(); current.kind == symbolToken {
+		switch current.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := parsePrimary(p)
+			if err != nil {
+				return nil, err
+			}
+			return compareExpression{operator: current.text, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func parsePrimary(p *parser) (expression, error) {
+	current := p.next()
+	switch current.kind {
+	case paramToken:
+		return paramExpression{name: current.text}, nil
+	case stringToken:
+		return literalExpression{value: value{
+			typ: newType(sppb.TypeCode_STRING),
+			val: &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: current.text}},
+		}}, nil
+	case numberToken:
+		if integer, err := strconv.ParseInt(current.text, 10, 64); err == nil {
+			return literalExpression{value: int64Value(integer)}, nil
+		}
+		number, err := strconv.ParseFloat(current.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", current.text)
+		}
+		return literalExpression{value: value{
+			typ: newType(sppb.TypeCode_FLOAT64),
+			val: &proto3.Value{Kind: &proto3.Value_NumberValue{NumberValue: number}},
+		}}, nil
+	case symbolToken:
+		if current.text == "(" {
+			expr, err := parseExpression(p)
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expectSymbol(")")
+		}
+		if current.text == "-" && p.peek().kind == numberToken {
+			p.tokens[p.pos].text = "-" + p.tokens[p.pos].text
+			return parsePrimary(p)
+		}
+	case identToken, quotedIdentToken:
+		if current.kind == identToken {
+			switch strings.ToUpper(current.text) {
+			case "NULL":
+				return literalExpression{value: value{val: nullValue}}, nil
+			case "TRUE", "FALSE":
+				return literalExpression{value: boolValue(strings.EqualFold(current.text, "TRUE"))}, nil
+			case "COUNT":
+				if p.symbol("(") {
+					if err := p.expectSymbol("*"); err != nil {
+						return nil, err
+					}
+					return countExpression{}, p.expectSymbol(")")
+				}
+			}
+		}
+		name := current.text
+		if p.symbol(".") {
+			qualified, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			name = qualified
+		}
+		return columnExpression{name: name}, nil
+	}
+	return nil, fmt.Errorf("unsupported SQL near %q", current.text)
+}
+
+// decodeParams returns the statement parameters by lower case name, typed
+// with the request parameter types.
+func decodeParams(params *proto3.Struct, types map[string]*sppb.Type) map[string]value {
+	decoded := make(map[string]value, len(params.GetFields()))
+	for name, param := range params.GetFields() {
+		typ := types[name]
+		if typ == nil {
+			switch param.GetKind().(type) {
+			case *proto3.Value_BoolValue:
+				typ = newType(sppb.TypeCode_BOOL)
+			case *proto3.Value_NumberValue:
+				typ = newType(sppb.TypeCode_FLOAT64)
+			case *proto3.Value_StringValue:
+				typ = newType(sppb.TypeCode_STRING)
+			}
+		}
+		decoded[strings.ToLower(name)] = value{typ: typ, val: param}
+	}
+	return decoded
+}
+
+func intOf(expr expression, env *environment) (int, error) {
+	evaluated, err := expr.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	integer, ok := int64Of(evaluated.val)
+	if evaluated.typ.GetCode() != sppb.TypeCode_INT64 || !ok || integer < 0 {
+		return 0, fmt.Errorf("LIMIT and OFFSET must be non negative integers")
+	}
+	return int(integer), nil
+}
+
+// execute runs the select over the tables and returns the result columns
+// and rows.
+func (s *selectStatement) execute(tables map[string]*table, params map[string]value) ([]*sppb.StructType_Field, []row, error) {
+	env := &environment{params: params}
+	if s.table != "" {
+		found, exists := tables[schemaName(s.table)]
+		if !exists {
+			return nil, nil, fmt.Errorf("table not found: %s", s.table)
+		}
+		env.table = found
+	}
+	var (
+		fields    []*sppb.StructType_Field
+		aggregate bool
+	)
+	for _, column := range s.columns {
+		if column.star {
+			if env.table == nil {
+				return nil, nil, fmt.Errorf("SELECT * must have a FROM clause")
+			}
+			for _, definition := range env.table.columns {
+				fields = append(fields, &sppb.StructType_Field{Name: definition.name, Type: definition.typ})
+			}
+			continue
+		}
+		if _, isCount := column.expression.(countExpression); isCount {
+			aggregate = true
+		}
+		typ := column.expression.typ(env)
+		if typ == nil {
+			typ = newType(sppb.TypeCode_INT64)
+		}
+		fields = append(fields, &sppb.StructType_Field{Name: column.alias, Type: typ})
+	}
+	var candidates []row
+	if env.table == nil {
+		candidates = []row{nil}
+	} else {
+		candidates = env.table.rows
+	}
+	var selected []row
+	for _, candidate := range candidates {
+		env.row = candidate
+		if s.where != nil {
+			matched, err := s.where.eval(env)
+			if err != nil {
+				return nil, nil, err
+			}
+			if isNull(matched.val) || !matched.val.GetBoolValue() {
+				continue
+			}
+		}
+		selected = append(selected, candidate)
+	}
+	if aggregate {
+		result := make(row, len(s.columns))
+		for index, column := range s.columns {
+			if _, isCount := column.expression.(countExpression); !isCount {
+				return nil, nil, fmt.Errorf("only COUNT(*) columns are supported in aggregate queries")
+			}
+			result[index] = int64Value(int64(len(selected))).val
+		}
+		return fields, []row{result}, nil
+	}
+	var sortErr error
+	sort.SliceStable(selected, func(i, j int) bool {
+		for _, order := range s.orders {
+			env.row = selected[i]
+			left, err := order.expression.eval(env)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			env.row = selected[j]
+			right, err := order.expression.eval(env)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			compared, err := compareValues(left, right)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			if order.desc {
+				compared = -compared
+			}
+			if compared != 0 {
+				return compared < 0
+			}
+		}
+		return false
+	})
+	if sortErr != nil {
+		return nil, nil, sortErr
+	}
+	if s.offset != nil {
+		offset, err := intOf(s.offset, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if offset > len(selected) {
+			offset = len(selected)
+		}
+		selected = selected[offset:]
+	}
+	if s.limit != nil {
+		limit, err := intOf(s.limit, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if limit < len(selected) {
+			selected = selected[:limit]
+		}
+	}
+	results := make([]row, len(selected))
+	for index, current := range selected {
+		env.row = current
+		result := make(row, 0, len(fields))
+		for _, column := range s.columns {
+			if column.star {
+				result = append(result, current...)
+				continue
+			}
+			evaluated, err := column.expression.eval(env)
+			if err != nil {
+				return nil, nil, err
+			}
+			result = append(result, evaluated.val)
+		}
+		results[index] = result
+	}
+	return fields, results, nil
+}
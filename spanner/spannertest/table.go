@@ -0,0 +1,327 @@
+package spannertest
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (t *table) rowKey(r row) []*proto3.Value {
+	key := make([]*proto3.Value, len(t.key))
+	for index, part := range t.key {
+		key[index] = r[part.column]
+	}
+	return key
+}
+
+// compareKeys compares the common prefix of the keys in the table key order.
+func (t *table) compareKeys(a, b []*proto3.Value) int {
+	for index := 0; index < len(a) && index < len(b); index++ {
+		part := t.key[index]
+		typ := t.columns[part.column].typ
+		compared, _ := compareValues(value{typ: typ, val: a[index]}, value{typ: typ, val: b[index]})
+		if part.desc {
+			compared = -compared
+		}
+		if compared != 0 {
+			return compared
+		}
+	}
+	return 0
+}
+
+// find returns the position of the row of the full key, or the position
+// where it would be inserted.
+func (t *table) find(key []*proto3.Value) (int, bool) {
+	position := sort.Search(len(t.rows), func(index int) bool {
+		return t.compareKeys(t.rowKey(t.rows[index]), key) >= 0
+	})
+	return position, position < len(t.rows) && t.compareKeys(t.rowKey(t.rows[position]), key) == 0
+}
+
+// parseKey checks the values of a key or key prefix of the table.
+func (t *table) parseKey(list *proto3.ListValue) ([]*proto3.Value, error) {
+	values := list.GetValues()
+	if len(values) > len(t.key) {
+		return nil, status.Errorf(codes.InvalidArgument, "key of table %s has %d columns", t.name, len(t.key))
+	}
+	for index, keyValue := range values {
+		if err := checkValue(t.columns[t.key[index].column].typ, keyValue); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "key of table %s: %v", t.name, err)
+		}
+	}
+	return values, nil
+}
+
+// keyMatcher returns a function that reports whether a row key is in the
+// key set.
+func (t *table) keyMatcher(keySet *sppb.KeySet) (func([]*proto3.Value) bool, error) {
+	if keySet.GetAll() {
+		return func([]*proto3.Value) bool { return true }, nil
+	}
+	type bound struct {
+		key  []*proto3.Value
+		open bool
+	}
+	var (
+		keys   [][]*proto3.Value
+		starts []bound
+		ends   []bound
+	)
+	for _, list := range keySet.GetKeys() {
+		key, err := t.parseKey(list)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	for _, keyRange := range keySet.GetRanges() {
+		var start, end bound
+		switch startKey := keyRange.GetStartKeyType().(type) {
+		case *sppb.KeyRange_StartClosed:
+			start.key = startKey.StartClosed.GetValues()
+		case *sppb.KeyRange_StartOpen:
+			start = bound{key: startKey.StartOpen.GetValues(), open: true}
+		}
+		switch endKey := keyRange.GetEndKeyType().(type) {
+		case *sppb.KeyRange_EndClosed:
+			end.key = endKey.EndClosed.GetValues()
+		case *sppb.KeyRange_EndOpen:
+			end = bound{key: endKey.EndOpen.GetValues(), open: true}
+		}
+		if _, err := t.parseKey(&proto3.ListValue{Values: start.key}); err != nil {
+			return nil, err
+		}
+		if _, err := t.parseKey(&proto3.ListValue{Values: end.key}); err != nil {
+			return nil, err
+		}
+		starts, ends = append(starts, start), append(ends, end)
+	}
+	return func(rowKey []*proto3.Value) bool {
+		for _, key := range keys {
+			if len(key) == len(rowKey) && t.compareKeys(rowKey, key) == 0 {
+				return true
+			}
+		}
+		for index := range starts {
+			var (
+				afterStart = t.compareKeys(rowKey, starts[index].key)
+				beforeEnd  = t.compareKeys(rowKey, ends[index].key)
+			)
+			if (afterStart > 0 || (afterStart == 0 && !starts[index].open)) &&
+				(beforeEnd < 0 || (beforeEnd == 0 && !ends[index].open)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// database is the set of tables of one commit, it clones a table before its
+// first change.
+type database struct {
+	tables  map[string]*table
+	changed map[string]bool
+}
+
+func newDatabase(tables map[string]*table) *database {
+	staged := make(map[string]*table, len(tables))
+	for name, stored := range tables {
+		staged[name] = stored
+	}
+	return &database{tables: staged, changed: make(map[string]bool)}
+}
+
+func (d *database) table(name string) (*table, error) {
+	found, exists := d.tables[schemaName(name)]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "table not found: %s", name)
+	}
+	return found, nil
+}
+
+func (d *database) mutable(name string) (*table, error) {
+	found, err := d.table(name)
+	if err != nil {
+		return nil, err
+	}
+	if !d.changed[schemaName(name)] {
+		found = found.clone()
+		d.tables[schemaName(name)] = found
+		d.changed[schemaName(name)] = true
+	}
+	return found, nil
+}
+
+// checkParent checks that the parent row of an interleaved row exists.
+func (d *database) checkParent(child *table, key []*proto3.Value) error {
+	if child.parent == "" {
+		return nil
+	}
+	parent := d.tables[child.parent]
+	if _, found := parent.find(key[:len(parent.key)]); !found {
+		return status.Errorf(codes.NotFound, "parent row of table %s not found in %s", child.name, parent.name)
+	}
+	return nil
+}
+
+// write applies an insert, update, insert or update, or replace mutation.
+func (d *database) write(operation string, write *sppb.Mutation_Write, commitTime string) error {
+	target, err := d.mutable(write.GetTable())
+	if err != nil {
+		return err
+	}
+	columns := make([]int, len(write.GetColumns()))
+	for index, name := range write.GetColumns() {
+		position, found := target.columnIndex(name)
+		if !found {
+			return status.Errorf(codes.NotFound, "column not found in table %s: %s", target.name, name)
+		}
+		columns[index] = position
+	}
+	written := make(map[int]bool, len(columns))
+	for _, position := range columns {
+		written[position] = true
+	}
+	for _, part := range target.key {
+		if !written[part.column] {
+			return status.Errorf(
+				codes.FailedPrecondition, "key column %s of table %s must be written",
+				target.columns[part.column].name, target.name,
+			)
+		}
+	}
+	for _, list := range write.GetValues() {
+		values := list.GetValues()
+		if len(values) != len(columns) {
+			return status.Errorf(codes.InvalidArgument, "mutation has %d columns and %d values", len(columns), len(values))
+		}
+		updated := make(row, len(target.columns))
+		for index := range updated {
+			updated[index] = nullValue
+		}
+		for index, position := range columns {
+			current := values[index]
+			definition := target.columns[position]
+			if definition.typ.GetCode() == sppb.TypeCode_TIMESTAMP && current.GetStringValue() == commitTimestampSentinel {
+				current = &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: commitTime}}
+			}
+			if err := checkValue(definition.typ, current); err != nil {
+				return status.Errorf(codes.FailedPrecondition, "column %s of table %s: %v", definition.name, target.name, err)
+			}
+			updated[position] = proto.Clone(current).(*proto3.Value)
+		}
+		key := target.rowKey(updated)
+		position, exists := target.find(key)
+		switch {
+		case operation == "insert" && exists:
+			return status.Errorf(codes.AlreadyExists, "row in table %s already exists", target.name)
+		case operation == "update" && !exists:
+			return status.Errorf(codes.NotFound, "row in table %s not found", target.name)
+		case exists && operation != "replace":
+			merged := append(row(nil), target.rows[position]...)
+			for _, column := range columns {
+				merged[column] = updated[column]
+			}
+			updated = merged
+		case !exists:
+			if err := d.checkParent(target, key); err != nil {
+				return err
+			}
+		}
+		for index, definition := range target.columns {
+			if definition.notNull && isNull(updated[index]) {
+				return status.Errorf(
+					codes.FailedPrecondition, "column %s of table %s must not be null", definition.name, target.name,
+				)
+			}
+		}
+		if exists {
+			target.rows[position] = updated
+		} else {
+			target.rows = append(target.rows, nil)
+			copy(target.rows[position+1:], target.rows[position:])
+			target.rows[position] = updated
+		}
+	}
+	return nil
+}
+
+// delete deletes the rows of the key set and the rows of the tables
+// interleaved on delete cascade.
+func (d *database) delete(name string, keySet *sppb.KeySet) error {
+	target, err := d.mutable(name)
+	if err != nil {
+		return err
+	}
+	matches, err := target.keyMatcher(keySet)
+	if err != nil {
+		return err
+	}
+	var (
+		kept    = target.rows[:0]
+		deleted [][]*proto3.Value
+	)
+	for _, current := range target.rows {
+		if key := target.rowKey(current); matches(key) {
+			deleted = append(deleted, key)
+			continue
+		}
+		kept = append(kept, current)
+	}
+	target.rows = kept
+	if len(deleted) == 0 {
+		return nil
+	}
+	for _, child := range d.tables {
+		if child.parent != schemaName(target.name) {
+			continue
+		}
+		if !child.cascade {
+			for _, childRow := range child.rows {
+				for _, key := range deleted {
+					if child.compareKeys(child.rowKey(childRow), key) == 0 {
+						return status.Errorf(
+							codes.FailedPrecondition, "row in table %s has interleaved rows in %s", target.name, child.name,
+						)
+					}
+				}
+			}
+			continue
+		}
+		ranges := make([]*sppb.KeyRange, len(deleted))
+		for index, key := range deleted {
+			prefix := &proto3.ListValue{Values: key}
+			ranges[index] = &sppb.KeyRange{
+				StartKeyType: &sppb.KeyRange_StartClosed{StartClosed: prefix},
+				EndKeyType:   &sppb.KeyRange_EndClosed{EndClosed: prefix},
+			}
+		}
+		if err := d.delete(child.name, &sppb.KeySet{Ranges: ranges}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies the mutation, timestamp columns written with the commit
+// timestamp sentinel get commitTime.
+func (d *database) apply(mutation *sppb.Mutation, commitTime string) error {
+	switch operation := mutation.GetOperation().(type) {
+	case *sppb.Mutation_Insert:
+		return d.write("insert", operation.Insert, commitTime)
+	case *sppb.Mutation_Update:
+		return d.write("update", operation.Update, commitTime)
+	case *sppb.Mutation_InsertOrUpdate:
+		return d.write("insertOrUpdate", operation.InsertOrUpdate, commitTime)
+	case *sppb.Mutation_Replace:
+		return d.write("replace", operation.Replace, commitTime)
+	case *sppb.Mutation_Delete_:
+		return d.delete(operation.Delete.GetTable(), operation.Delete.GetKeySet())
+	}
+	return status.Error(codes.InvalidArgument, "unknown mutation")
+}
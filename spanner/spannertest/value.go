@@ -0,0 +1,234 @@
+package spannertest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+const (
+	dateLayout              = "2006-01-02"
+	commitTimestampSentinel = "spanner.commit_timestamp()"
+)
+
+var (
+	nullValue = &proto3.Value{Kind: &proto3.Value_NullValue{}}
+)
+
+// value is an evaluated SQL value, the type is nil for the untyped NULL.
+type value struct {
+	typ *sppb.Type
+	val *proto3.Value
+}
+
+func newType(code sppb.TypeCode) *sppb.Type {
+	return &sppb.Type{Code: code}
+}
+
+func boolValue(b bool) value {
+	return value{typ: newType(sppb.TypeCode_BOOL), val: &proto3.Value{Kind: &proto3.Value_BoolValue{BoolValue: b}}}
+}
+
+func int64Value(i int64) value {
+	return value{
+		typ: newType(sppb.TypeCode_INT64),
+		val: &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: strconv.FormatInt(i, 10)}},
+	}
+}
+
+func isNull(v *proto3.Value) bool {
+	_, null := v.GetKind().(*proto3.Value_NullValue)
+	return v == nil || null
+}
+
+func typeString(typ *sppb.Type) string {
+	if typ.GetCode() == sppb.TypeCode_ARRAY {
+		return fmt.Sprintf("ARRAY<%s>", typeString(typ.GetArrayElementType()))
+	}
+	return typ.GetCode().String()
+}
+
+func floatOf(v *proto3.Value) (float64, bool) {
+	switch kind := v.GetKind().(type) {
+	case *proto3.Value_NumberValue:
+		return kind.NumberValue, true
+	case *proto3.Value_StringValue:
+		switch kind.StringValue {
+		case "NaN":
+			return math.NaN(), true
+		case "Infinity":
+			return math.Inf(1), true
+		case "-Infinity":
+			return math.Inf(-1), true
+		}
+	}
+	return 0, false
+}
+
+func int64Of(v *proto3.Value) (int64, bool) {
+	i, err := strconv.ParseInt(v.GetStringValue(), 10, 64)
+	return i, err == nil && v.GetKind() != nil
+}
+
+func timeOf(v *proto3.Value) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+	return t, err == nil
+}
+
+// checkValue checks that the encoded value is a value of typ, every type
+// accepts NULL.
+func checkValue(typ *sppb.Type, v *proto3.Value) error {
+	if isNull(v) {
+		return nil
+	}
+	var valid bool
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		_, valid = v.GetKind().(*proto3.Value_BoolValue)
+	case sppb.TypeCode_INT64:
+		_, valid = int64Of(v)
+	case sppb.TypeCode_FLOAT64:
+		_, valid = floatOf(v)
+	case sppb.TypeCode_STRING:
+		_, valid = v.GetKind().(*proto3.Value_StringValue)
+	case sppb.TypeCode_BYTES:
+		_, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+		_, isString := v.GetKind().(*proto3.Value_StringValue)
+		valid = isString && err == nil
+	case sppb.TypeCode_TIMESTAMP:
+		_, valid = timeOf(v)
+	case sppb.TypeCode_DATE:
+		_, err := time.Parse(dateLayout, v.GetStringValue())
+		valid = err == nil
+	case sppb.TypeCode_ARRAY:
+		list, isList := v.GetKind().(*proto3.Value_ListValue)
+		if !isList {
+			break
+		}
+		for _, element := range list.ListValue.GetValues() {
+			if err := checkValue(typ.GetArrayElementType(), element); err != nil {
+				return err
+			}
+		}
+		valid = true
+	}
+	if !valid {
+		return fmt.Errorf("invalid value for type %s", typeString(typ))
+	}
+	return nil
+}
+
+func isNumeric(typ *sppb.Type) bool {
+	return typ.GetCode() == sppb.TypeCode_INT64 || typ.GetCode() == sppb.TypeCode_FLOAT64
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareValues orders the values like spanner, NULL comes first. Values of
+// different types other than numbers are not comparable.
+func compareValues(a, b value) (int, error) {
+	switch nullA, nullB := isNull(a.val), isNull(b.val); {
+	case nullA && nullB:
+		return 0, nil
+	case nullA:
+		return -1, nil
+	case nullB:
+		return 1, nil
+	}
+	if isNumeric(a.typ) && isNumeric(b.typ) {
+		if a.typ.GetCode() == sppb.TypeCode_INT64 && b.typ.GetCode() == sppb.TypeCode_INT64 {
+			ia, _ := int64Of(a.val)
+			ib, _ := int64Of(b.val)
+			switch {
+			case ia < ib:
+				return -1, nil
+			case ia > ib:
+				return 1, nil
+			}
+			return 0, nil
+		}
+		fa, okA := floatOf(a.val)
+		if !okA {
+			ia, _ := int64Of(a.val)
+			fa = float64(ia)
+		}
+		fb, okB := floatOf(b.val)
+		if !okB {
+			ib, _ := int64Of(b.val)
+			fb = float64(ib)
+		}
+		return compareFloats(fa, fb), nil
+	}
+	if a.typ.GetCode() != b.typ.GetCode() {
+		return 0, fmt.Errorf("cannot compare %s with %s", typeString(a.typ), typeString(b.typ))
+	}
+	switch a.typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		switch ba, bb := a.val.GetBoolValue(), b.val.GetBoolValue(); {
+		case ba == bb:
+			return 0, nil
+		case bb:
+			return -1, nil
+		}
+		return 1, nil
+	case sppb.TypeCode_STRING, sppb.TypeCode_DATE:
+		return strings.Compare(a.val.GetStringValue(), b.val.GetStringValue()), nil
+	case sppb.TypeCode_TIMESTAMP:
+		ta, _ := timeOf(a.val)
+		tb, _ := timeOf(b.val)
+		switch {
+		case ta.Before(tb):
+			return -1, nil
+		case ta.After(tb):
+			return 1, nil
+		}
+		return 0, nil
+	case sppb.TypeCode_BYTES:
+		ba, _ := base64.StdEncoding.DecodeString(a.val.GetStringValue())
+		bb, _ := base64.StdEncoding.DecodeString(b.val.GetStringValue())
+		return bytes.Compare(ba, bb), nil
+	case sppb.TypeCode_ARRAY:
+		var (
+			elementType = a.typ.GetArrayElementType()
+			valuesA     = a.val.GetListValue().GetValues()
+			valuesB     = b.val.GetListValue().GetValues()
+		)
+		for index := 0; index < len(valuesA) && index < len(valuesB); index++ {
+			compared, err := compareValues(
+				value{typ: elementType, val: valuesA[index]}, value{typ: elementType, val: valuesB[index]},
+			)
+			if err != nil || compared != 0 {
+				return compared, err
+			}
+		}
+		switch {
+		case len(valuesA) < len(valuesB):
+			return -1, nil
+		case len(valuesA) > len(valuesB):
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot compare values of type %s", typeString(a.typ))
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"cloud.google.com/go/spanner"
+	"github.com/rjansen/raizel"
+	"google.golang.org/grpc/codes"
 )
 
 type ReadOnlyTransaction interface {
@@ -132,3 +134,56 @@ func (t *batchReadOnlyTransaction) ReadWithOptions(
 ) RowIterator {
 	return newRowIterator(t.BatchReadOnlyTransaction.ReadWithOptions(ctx, table, keys, cols, options))
 }
+
+// transactionRepository reads and buffers the writes of a read write
+// transaction, the reads do not see the buffered writes.
+type transactionRepository struct {
+	transaction *ReadWriteTransaction
+}
+
+func (r *transactionRepository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	row, err := r.transaction.ReadRow(
+		ctx, key.EntityName(), entityKey(key), entityColumns(entity),
+	)
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	if err := toStruct(newRow(row), entity); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
+}
+
+func (r *transactionRepository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	mutation, err := entityMutation(key, entity)
+	if err != nil {
+		return err
+	}
+	return r.transaction.BufferWrite([]*Mutation{mutation})
+}
+
+func (r *transactionRepository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	if err := raizel.BeforeDelete(ctx, r, key); err != nil {
+		return err
+	}
+	return r.transaction.BufferWrite([]*Mutation{Delete(key.EntityName(), entityKey(key))})
+}
+
+func (r *transactionRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+// Transaction runs fn in a read write transaction, spanner runs fn again
+// when the transaction aborts so fn must not keep state between the runs.
+func (r *repository) Transaction(ctx context.Context, fn func(context.Context, raizel.Repository) error) error {
+	_, err := r.client.ReadWriteTransaction(ctx, func(ctx context.Context, transaction *ReadWriteTransaction) error {
+		return fn(ctx, &transactionRepository{transaction: transaction})
+	})
+	return err
+}
@@ -1,25 +1,26 @@
-package spanner
+package spanner_test
 
 import (
 	"testing"
 
 	"cloud.google.com/go/spanner"
+	rspanner "github.com/rjansen/raizel/spanner"
 	"github.com/stretchr/testify/require"
 )
 
 func TestReadOnlyTransaction(t *testing.T) {
-	transaction := newReadOnlyTransaction(new(spanner.ReadOnlyTransaction))
+	transaction := rspanner.NewReadOnlyTransaction(new(spanner.ReadOnlyTransaction))
 	require.NotNil(t, transaction, "invalid transaction instance")
 }
 
 func TestWithTimestampBound(t *testing.T) {
-	transaction := newReadOnlyTransaction(new(spanner.ReadOnlyTransaction))
+	transaction := rspanner.NewReadOnlyTransaction(new(spanner.ReadOnlyTransaction))
 	require.NotNil(t, transaction, "invalid transaction instance")
-	boundedTransaction := transaction.WithTimestampBound(TimestampBound{})
+	boundedTransaction := transaction.WithTimestampBound(rspanner.TimestampBound{})
 	require.NotNil(t, boundedTransaction, "invalid bounded transaction instance")
 }
 
 func TestBatchReadOnlyTransaction(t *testing.T) {
-	transaction := newBatchReadOnlyTransaction(new(spanner.BatchReadOnlyTransaction))
+	transaction := rspanner.NewBatchReadOnlyTransaction(new(spanner.BatchReadOnlyTransaction))
 	require.NotNil(t, transaction, "invalid transaction instance")
 }
@@ -0,0 +1,132 @@
+package sql
+
+import (
+	"context"
+	database "database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+// measureExpression returns the SQL aggregate function call of the measure
+// with the field quoted, an unknown function returns raizel.ErrInvalidArgument.
+func measureExpression(measure raizel.Measure) (string, error) {
+	switch measure.Func {
+	case raizel.CountFunc, raizel.SumFunc, raizel.AvgFunc, raizel.MinFunc, raizel.MaxFunc:
+	default:
+		return "", fmt.Errorf("%w: aggregate function %q", raizel.ErrInvalidArgument, measure.Func)
+	}
+	field := "*"
+	if measure.Field != "" {
+		field = pq.QuoteIdentifier(measure.Field)
+	}
+	return fmt.Sprintf("%s(%s)", measure.Func, field), nil
+}
+
+// measureDest returns the scan destination of the measure column and the
+// function that reads the scanned value.
+func measureDest(measure raizel.Measure) (interface{}, func() interface{}) {
+	switch measure.Func {
+	case raizel.CountFunc:
+		count := new(int64)
+		return count, func() interface{} { return *count }
+	case raizel.SumFunc, raizel.AvgFunc:
+		number := new(database.NullFloat64)
+		return number, func() interface{} {
+			if !number.Valid {
+				return nil
+			}
+			return number.Float64
+		}
+	}
+	return columnDest()
+}
+
+// columnDest returns a scan destination of any column type, the text columns
+// scanned as bytes are read as strings.
+func columnDest() (interface{}, func() interface{}) {
+	value := new(interface{})
+	return value, func() interface{} {
+		if bytes, isBytes := (*value).([]byte); isBytes {
+			return string(bytes)
+		}
+		return *value
+	}
+}
+
+// Aggregate runs a SELECT of the SQL aggregate functions grouped and ordered
+// by the group columns.
+func (repository repository) Aggregate(ctx context.Context, aggregation raizel.Aggregation) ([]raizel.AggregateResult, error) {
+	query := aggregation.Query
+	sqlStruct, err := repository.entityStruct(query.EntityName)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		builder = sqlStruct.Flavor.NewSelectBuilder()
+		groups  = make([]string, len(aggregation.GroupBy))
+		columns = make([]string, 0, len(aggregation.GroupBy)+len(aggregation.Measures))
+	)
+	for index, field := range aggregation.GroupBy {
+		groups[index] = pq.QuoteIdentifier(field)
+	}
+	columns = append(columns, groups...)
+	for _, measure := range aggregation.Measures {
+		expression, err := measureExpression(measure)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, builder.As(expression, pq.QuoteIdentifier(measure.Alias)))
+	}
+	builder.Select(columns...).From(repository.tenantTable(query.EntityName, query.Tenant))
+	conditions, err := repository.queryConditions(&builder.Cond, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(conditions) > 0 {
+		builder.Where(conditions...)
+	}
+	if len(aggregation.GroupBy) > 0 {
+		builder.GroupBy(groups...).OrderBy(groups...)
+	}
+	sql, args := builder.Build()
+	rows, err := repository.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []raizel.AggregateResult{}
+	for rows.Next() {
+		var (
+			dests  = make([]interface{}, len(columns))
+			reads  = make([]func() interface{}, len(columns))
+			result = raizel.AggregateResult{
+				Group:  make(map[string]interface{}, len(aggregation.GroupBy)),
+				Values: make(map[string]interface{}, len(aggregation.Measures)),
+			}
+		)
+		for index := range aggregation.GroupBy {
+			dests[index], reads[index] = columnDest()
+		}
+		for index, measure := range aggregation.Measures {
+			column := len(aggregation.GroupBy) + index
+			dests[column], reads[column] = measureDest(measure)
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, err
+		}
+		for index, field := range aggregation.GroupBy {
+			result.Group[field] = reads[index]()
+		}
+		for index, measure := range aggregation.Measures {
+			result.Values[measure.Alias] = reads[len(aggregation.GroupBy)+index]()
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,116 @@
+package sql_test
+
+import (
+	"context"
+	database "database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryAggregate struct {
+	name        string
+	tenancy     sql.TenantStrategy
+	aggregation raizel.Aggregation
+	sql         string
+	args        []interface{}
+	scan        func([]interface{})
+	results     []raizel.AggregateResult
+	err         error
+}
+
+func TestRepositoryAggregate(test *testing.T) {
+	scenarios := []testRepositoryAggregate{
+		{
+			name: "Aggregate the tenant schema rows",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table").Where("age", raizel.Greater, 18),
+				raizel.CountAll("total"),
+				raizel.Avg("age", "average"),
+			),
+			sql:  `SELECT count(*) AS "total", avg("age") AS "average" FROM "tenant1".entity_table WHERE "age" > $1`,
+			args: []interface{}{18},
+			scan: func(dests []interface{}) {
+				*dests[0].(*int64) = 2
+				*dests[1].(*database.NullFloat64) = database.NullFloat64{Float64: 30.5, Valid: true}
+			},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{},
+					Values: map[string]interface{}{"total": int64(2), "average": 30.5},
+				},
+			},
+		},
+		{
+			name:    "Aggregate the tenant column rows by group",
+			tenancy: sql.ColumnTenancy,
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table"),
+				raizel.Sum("age", "ages"),
+				raizel.Max("name", "last"),
+			).GroupedBy("deleted"),
+			sql: `SELECT "deleted", sum("age") AS "ages", max("name") AS "last" FROM entity_table WHERE tenant_id = $1 ` +
+				`GROUP BY "deleted" ORDER BY "deleted"`,
+			args: []interface{}{"tenant1"},
+			scan: func(dests []interface{}) {
+				*dests[0].(*interface{}) = false
+				*dests[2].(*interface{}) = []byte("mock")
+			},
+			results: []raizel.AggregateResult{
+				{
+					Group:  map[string]interface{}{"deleted": false},
+					Values: map[string]interface{}{"ages": nil, "last": "mock"},
+				},
+			},
+		},
+		{
+			name: "Error when the filter is invalid",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 18), raizel.CountAll("total"),
+			),
+			err: sql.ErrInvalidFilter,
+		},
+		{
+			name: "Error when the aggregate function is unknown",
+			aggregation: raizel.NewAggregation(
+				raizel.NewQuery("entity_table"),
+				raizel.Measure{Func: raizel.AggregateFunc("pg_sleep"), Field: "age", Alias: "slept"},
+			),
+			err: raizel.ErrInvalidArgument,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					db   = smock.NewDBMock()
+					rows = smock.NewRowsMock()
+					ctx  = raizel.WithTenant(context.Background(), "tenant1")
+				)
+				db.On("Query", scenario.sql, scenario.args).Return(rows, nil)
+				rows.On("Next").Return(true).Once()
+				rows.On("Next").Return(false)
+				rows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					scenario.scan(args.Get(0).([]interface{}))
+				}).Return(nil)
+				rows.On("Err").Return(nil)
+				rows.On("Close").Return(nil)
+
+				repository := newTenantTestRepository(db, scenario.tenancy)
+				results, err := raizel.Aggregate(ctx, repository, scenario.aggregation)
+				require.True(t, errors.Is(err, scenario.err), "aggregate error %v", err)
+				require.Equal(t, scenario.results, results, "aggregate results")
+				if scenario.err == nil {
+					rows.AssertExpectations(t)
+				}
+			},
+		)
+	}
+}
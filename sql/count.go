@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"context"
+	database "database/sql"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+)
+
+// Exists selects a constant of the key row, the entity columns are not read.
+func (repository repository) Exists(ctx context.Context, key raizel.EntityKey) (bool, error) {
+	sqlStruct, err := repository.entityStruct(key.EntityName())
+	if err != nil {
+		return false, err
+	}
+	builder := sqlStruct.Flavor.NewSelectBuilder()
+	builder.Select("1").From(repository.entityTable(key))
+	var (
+		sql, args = builder.Where(
+			repository.keyConditions(&builder.Cond, key)...,
+		).Limit(1).Build()
+		row   = repository.db.QueryRow(sql, args...)
+		found int
+	)
+	if err := row.Scan(&found); err != nil {
+		if err == database.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// queryConditions returns the predicates of the parent and query filters and
+// of the query tenant column.
+func (repository repository) queryConditions(cond *sqlbuilder.Cond, query raizel.Query) ([]string, error) {
+	var (
+		filters    = append(query.ParentFilters(), query.Filters...)
+		conditions = make([]string, len(filters))
+	)
+	for index, filter := range filters {
+		condition, err := filterCondition(cond, filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions[index] = condition
+	}
+	if query.Tenant != "" && repository.tenancy == ColumnTenancy {
+		conditions = append(conditions, cond.E(TenantColumn, query.Tenant))
+	}
+	return conditions, nil
+}
+
+// Count runs a count(*) of the query filters inside the query tenant.
+func (repository repository) Count(ctx context.Context, query raizel.Query) (int64, error) {
+	sqlStruct, err := repository.entityStruct(query.EntityName)
+	if err != nil {
+		return 0, err
+	}
+	builder := sqlStruct.Flavor.NewSelectBuilder()
+	builder.Select("count(*)").From(repository.tenantTable(query.EntityName, query.Tenant))
+	conditions, err := repository.queryConditions(&builder.Cond, query)
+	if err != nil {
+		return 0, err
+	}
+	if len(conditions) > 0 {
+		builder.Where(conditions...)
+	}
+	var (
+		sql, args = builder.Build()
+		row       = repository.db.QueryRow(sql, args...)
+		count     int64
+	)
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
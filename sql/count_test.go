@@ -0,0 +1,147 @@
+package sql_test
+
+import (
+	"context"
+	database "database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTenantTestRepository(db sql.DB, tenancy sql.TenantStrategy) raizel.Repository {
+	mapper := sql.NewMapperBuilder().
+		Set("entity_table", sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL)).
+		NewMapper()
+	return sql.NewTenantRepository(db, mapper, tenancy)
+}
+
+type testRepositoryExists struct {
+	name    string
+	tenancy sql.TenantStrategy
+	sql     string
+	args    []interface{}
+	scanErr error
+	exists  bool
+	err     error
+}
+
+func TestRepositoryExists(test *testing.T) {
+	scenarios := []testRepositoryExists{
+		{
+			name:   "Exists in the tenant schema",
+			sql:    `SELECT 1 FROM "tenant1".entity_table WHERE id = $1 LIMIT 1`,
+			args:   []interface{}{"identifier"},
+			exists: true,
+		},
+		{
+			name:    "Exists by the tenant column",
+			tenancy: sql.ColumnTenancy,
+			sql:     "SELECT 1 FROM entity_table WHERE id = $1 AND tenant_id = $2 LIMIT 1",
+			args:    []interface{}{"identifier", "tenant1"},
+			exists:  true,
+		},
+		{
+			name:    "Missing row",
+			sql:     `SELECT 1 FROM "tenant1".entity_table WHERE id = $1 LIMIT 1`,
+			args:    []interface{}{"identifier"},
+			scanErr: database.ErrNoRows,
+		},
+		{
+			name:    "Error when the select fails",
+			sql:     `SELECT 1 FROM "tenant1".entity_table WHERE id = $1 LIMIT 1`,
+			args:    []interface{}{"identifier"},
+			scanErr: database.ErrConnDone,
+			err:     database.ErrConnDone,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					db  = smock.NewDBMock()
+					row = smock.NewRowMock()
+					ctx = raizel.WithTenant(context.Background(), "tenant1")
+					key = entityKeyMock{table: "entity_table", name: "id", value: "identifier"}
+				)
+				db.On("QueryRow", scenario.sql, scenario.args).Return(row)
+				row.On("Scan", mock.Anything).Return(scenario.scanErr)
+
+				exists, err := raizel.Exists(ctx, newTenantTestRepository(db, scenario.tenancy), key)
+				require.True(t, errors.Is(err, scenario.err), "exists error %v", err)
+				require.Equal(t, scenario.exists, exists, "exists result")
+				db.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+type testRepositoryCount struct {
+	name    string
+	tenancy sql.TenantStrategy
+	query   raizel.Query
+	sql     string
+	args    []interface{}
+	err     error
+}
+
+func TestRepositoryCount(test *testing.T) {
+	scenarios := []testRepositoryCount{
+		{
+			name:  "Count in the tenant schema",
+			query: raizel.NewQuery("entity_table").Where("age", raizel.Greater, 18).OrderBy("age", raizel.Asc),
+			sql:   `SELECT count(*) FROM "tenant1".entity_table WHERE "age" > $1`,
+			args:  []interface{}{18},
+		},
+		{
+			name:    "Count by the tenant column",
+			tenancy: sql.ColumnTenancy,
+			query:   raizel.NewQuery("entity_table"),
+			sql:     "SELECT count(*) FROM entity_table WHERE tenant_id = $1",
+			args:    []interface{}{"tenant1"},
+		},
+		{
+			name:  "Count the children of a parent",
+			query: raizel.Children(raizel.NewDynamicKey("parent_table", "parent_id", 7), "entity_table"),
+			sql:   `SELECT count(*) FROM "tenant1".entity_table WHERE "parent_id" = $1`,
+			args:  []interface{}{7},
+		},
+		{
+			name:  "Error when the filter is invalid",
+			query: raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 18),
+			err:   sql.ErrInvalidFilter,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					db  = smock.NewDBMock()
+					row = smock.NewRowMock()
+					ctx = raizel.WithTenant(context.Background(), "tenant1")
+				)
+				db.On("QueryRow", scenario.sql, scenario.args).Return(row)
+				row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					*args.Get(0).([]interface{})[0].(*int64) = 42
+				}).Return(nil)
+
+				count, err := raizel.Count(ctx, newTenantTestRepository(db, scenario.tenancy), scenario.query)
+				require.True(t, errors.Is(err, scenario.err), "count error %v", err)
+				if scenario.err != nil {
+					db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything)
+					return
+				}
+				require.Equal(t, int64(42), count, "count result")
+				db.AssertExpectations(t)
+			},
+		)
+	}
+}
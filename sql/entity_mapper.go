@@ -1,7 +1,10 @@
 package sql
 
 import (
+	"reflect"
+
 	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
 )
 
 type MapperBuilder struct {
@@ -13,6 +16,32 @@ func (builder *MapperBuilder) Set(entityName string, structBuilder *sqlbuilder.S
 	return builder
 }
 
+// Describe maps the entity of the descriptor with the descriptor field names.
+func (builder *MapperBuilder) Describe(descriptor *raizel.Descriptor, flavor sqlbuilder.Flavor) *MapperBuilder {
+	return builder.Set(descriptor.Name, NewDescribedStruct(descriptor).For(flavor))
+}
+
+// NewDescribedStruct returns the sqlbuilder.Struct of the descriptor fields,
+// the repositories copy the described entities to and from the struct.
+func NewDescribedStruct(descriptor *raizel.Descriptor) *sqlbuilder.Struct {
+	return sqlbuilder.NewStruct(reflect.New(descriptor.TaggedType("db", false)).Interface())
+}
+
+// describedValue returns the value mapped by sqlStruct for the entity and a
+// load func that copies the value back to the entity. A registered entity
+// that sqlStruct does not map is copied to its described struct.
+func describedValue(sqlStruct *sqlbuilder.Struct, entity raizel.Entity) (interface{}, func() error, error) {
+	descriptor, described := raizel.DescriptorOf(entity)
+	if !described || sqlStruct.Addr(entity) != nil {
+		return entity, func() error { return nil }, nil
+	}
+	value, err := descriptor.ToTagged(entity, descriptor.TaggedType("db", false))
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, func() error { return descriptor.FromTagged(value, entity) }, nil
+}
+
 type Mapper interface {
 	Get(string) *sqlbuilder.Struct
 }
@@ -24,7 +53,11 @@ type mapper struct {
 func (mapper mapper) Get(entityName string) *sqlbuilder.Struct {
 	structBuilder, exists := mapper.register[entityName]
 	if !exists {
-		return nil
+		descriptor, registered := raizel.EntityDescriptor(entityName)
+		if !registered {
+			return nil
+		}
+		return NewDescribedStruct(descriptor)
 	}
 	return structBuilder
 }
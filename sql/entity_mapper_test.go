@@ -1,10 +1,11 @@
-package sql
+package sql_test
 
 import (
 	"testing"
 
 	sqlbuilder "github.com/huandu/go-sqlbuilder"
 	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,7 +34,7 @@ func TestEntityMapper(test *testing.T) {
 			}: entityKeyMock{},
 		}
 	)
-	builder := NewMapperBuilder()
+	builder := sql.NewMapperBuilder()
 	for key, entity := range entities {
 		builder.Set(key.EntityName(), sqlbuilder.NewStruct(entity))
 	}
@@ -0,0 +1,7 @@
+package sql
+
+var (
+	ColumnsQuery    = columnsQuery
+	PrimaryKeyQuery = primaryKeyQuery
+	IndexesQuery    = indexesQuery
+)
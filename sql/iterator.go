@@ -0,0 +1,109 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+type entityIterator struct {
+	rows      Rows
+	sqlStruct *sqlbuilder.Struct
+}
+
+// NewIterator returns a raizel.Iterator that scans one row at a time from
+// rows into the sqlStruct fields.
+func NewIterator(rows Rows, sqlStruct *sqlbuilder.Struct) raizel.Iterator {
+	return &entityIterator{rows: rows, sqlStruct: sqlStruct}
+}
+
+func (i *entityIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	if !i.rows.Next() {
+		if err := i.rows.Err(); err != nil {
+			return err
+		}
+		return raizel.ErrIteratorDone
+	}
+	value, load, err := describedValue(i.sqlStruct, entity)
+	if err != nil {
+		return err
+	}
+	if err := i.rows.Scan(i.sqlStruct.Addr(value)...); err != nil {
+		return err
+	}
+	return load()
+}
+
+func (i *entityIterator) Stop() {
+	_ = i.rows.Close()
+}
+
+// filterCondition returns the predicate of the filter, the field names of the
+// queries are quoted and never reach the statements unquoted.
+func filterCondition(cond *sqlbuilder.Cond, filter raizel.Filter) (string, error) {
+	field := pq.QuoteIdentifier(filter.Field)
+	switch filter.Operator {
+	case raizel.Equal:
+		return cond.E(field, filter.Value), nil
+	case raizel.Less:
+		return cond.L(field, filter.Value), nil
+	case raizel.LessEqual:
+		return cond.LE(field, filter.Value), nil
+	case raizel.Greater:
+		return cond.G(field, filter.Value), nil
+	case raizel.GreaterEqual:
+		return cond.GE(field, filter.Value), nil
+	default:
+		return "", ErrInvalidFilter
+	}
+}
+
+func orderClauses(orders []raizel.Order) []string {
+	clauses := make([]string, len(orders))
+	for index, order := range orders {
+		direction := "ASC"
+		if order.Direction == raizel.Desc {
+			direction = "DESC"
+		}
+		clauses[index] = fmt.Sprintf("%s %s", pq.QuoteIdentifier(order.Field), direction)
+	}
+	return clauses
+}
+
+func (repository repository) Query(ctx context.Context, query raizel.Query) (raizel.Iterator, error) {
+	sqlStruct, err := repository.entityStruct(query.EntityName)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		filters    = append(query.ParentFilters(), query.Filters...)
+		builder    = sqlStruct.SelectFrom(query.EntityName)
+		conditions = make([]string, len(filters))
+		orders     = orderClauses(query.Orders)
+	)
+	for index, filter := range filters {
+		condition, err := filterCondition(&builder.Cond, filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions[index] = condition
+	}
+	if len(conditions) > 0 {
+		builder.Where(conditions...)
+	}
+	if len(orders) > 0 {
+		builder.OrderBy(orders...)
+	}
+	if query.Limit > 0 {
+		builder.Limit(query.Limit)
+	}
+	sql, args := builder.Build()
+	rows, err := repository.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return NewIterator(rows, sqlStruct), nil
+}
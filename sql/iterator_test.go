@@ -0,0 +1,144 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryQuery struct {
+	name    string
+	rows    *smock.RowsMock
+	db      *smock.DBMock
+	mapper  sql.Mapper
+	query   raizel.Query
+	sql     string
+	args    []interface{}
+	results int
+	err     error
+}
+
+func (scenario *testRepositoryQuery) setup(t *testing.T) {
+	var (
+		rows = smock.NewRowsMock()
+		db   = smock.NewDBMock()
+	)
+	require.NotNil(t, rows, "mock rows instance")
+	require.NotNil(t, db, "mock db instance")
+
+	if scenario.results > 0 {
+		rows.On("Next").Return(true).Times(scenario.results)
+	}
+	rows.On("Next").Return(false)
+	rows.On("Scan", mock.Anything).Return(nil)
+	rows.On("Err").Return(scenario.err)
+	rows.On("Close").Return(nil)
+	db.On("Query", scenario.sql, scenario.args).Return(rows, nil)
+
+	scenario.rows = rows
+	scenario.db = db
+}
+
+func TestRepositoryQuery(test *testing.T) {
+	scenarios := []testRepositoryQuery{
+		{
+			name: "Query entities one row at a time",
+			query: raizel.NewQuery("entity_table").
+				Where("age", raizel.Greater, 10).
+				Where("deleted", raizel.Equal, false).
+				OrderBy("age", raizel.Desc).
+				OrderBy("name", raizel.Asc).
+				WithLimit(2),
+			sql: "SELECT id, name, age, data, deleted, created_at, updated_at FROM entity_table " +
+				`WHERE "age" > ? AND "deleted" = ? ORDER BY "age" DESC, "name" ASC LIMIT 2`,
+			args:    []interface{}{10, false},
+			results: 2,
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name: "Query with quoted field names",
+			query: raizel.NewQuery("entity_table").
+				Where(`age" > 0; DROP TABLE entity_table; --`, raizel.Equal, 1).
+				OrderBy(`name" DESC --`, raizel.Asc),
+			sql: "SELECT id, name, age, data, deleted, created_at, updated_at FROM entity_table " +
+				`WHERE "age"" > 0; DROP TABLE entity_table; --" = ? ORDER BY "name"" DESC --" ASC`,
+			args:    []interface{}{1},
+			results: 1,
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name:    "Query all entities",
+			query:   raizel.NewQuery("entity_table"),
+			sql:     "SELECT id, name, age, data, deleted, created_at, updated_at FROM entity_table",
+			results: 1,
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name:  "Error when the rows fail",
+			query: raizel.NewQuery("entity_table"),
+			sql:   "SELECT id, name, age, data, deleted, created_at, updated_at FROM entity_table",
+			err:   errors.New("errMock"),
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
+				iterator, err := repository.Query(context.Background(), scenario.query)
+				require.Nil(t, err, "query error")
+				for result := 0; result < scenario.results; result++ {
+					err := iterator.Next(context.Background(), new(entityMock))
+					require.Nil(t, err, "next error")
+				}
+				err = iterator.Next(context.Background(), new(entityMock))
+				if scenario.err != nil {
+					require.Equal(t, scenario.err, err, "next error")
+				} else {
+					require.Equal(t, raizel.ErrIteratorDone, err, "next done error")
+				}
+				iterator.Stop()
+				scenario.db.AssertExpectations(t)
+				scenario.rows.AssertCalled(t, "Close")
+			},
+		)
+	}
+}
+
+func TestRepositoryQueryInvalidFilter(test *testing.T) {
+	var (
+		db         = smock.NewDBMock()
+		repository = sql.NewRepository(
+			db,
+			sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		)
+		iterator, err = repository.Query(
+			context.Background(),
+			raizel.NewQuery("entity_table").Where("age", raizel.Operator("!="), 10),
+		)
+	)
+	require.Equal(test, sql.ErrInvalidFilter, err, "query error")
+	require.Nil(test, iterator, "iterator instance")
+	db.AssertNotCalled(test, "Query", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,132 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/migrate"
+)
+
+type migrationDriver struct {
+	db   DB
+	lock Tx
+}
+
+// NewMigrationDriver returns a postgres migrate.Driver that applies every
+// migration in a transaction. The lock is a transaction level advisory lock
+// held by a transaction open until Unlock, so it is released by postgres
+// when a crashed migrator loses its connection. The DB must implement
+// Beginner or Lock returns raizel.ErrTransactionUnsupported.
+func NewMigrationDriver(db DB) migrate.Driver {
+	return &migrationDriver{db: db}
+}
+
+func (d *migrationDriver) Init(ctx context.Context) error {
+	_, err := d.db.Exec(fmt.Sprintf(`create table if not exists %s (
+    version bigint not null,
+    name varchar(256) not null,
+    applied_at timestamp not null,
+    constraint pk_%[1]s primary key(version)
+)
+;`, migrate.Table))
+	return err
+}
+
+func (d *migrationDriver) Lock(ctx context.Context) error {
+	beginner, ok := d.db.(Beginner)
+	if !ok {
+		return raizel.ErrTransactionUnsupported
+	}
+	transaction, err := beginner.Begin()
+	if err != nil {
+		return err
+	}
+	var locked bool
+	err = transaction.QueryRow(
+		"select pg_try_advisory_xact_lock(hashtext($1))", migrate.LockTable,
+	).Scan(&locked)
+	if err != nil || !locked {
+		_ = transaction.Rollback()
+		if err != nil {
+			return err
+		}
+		return migrate.ErrLocked
+	}
+	d.lock = transaction
+	return nil
+}
+
+func (d *migrationDriver) Unlock(ctx context.Context) error {
+	if d.lock == nil {
+		return nil
+	}
+	err := d.lock.Rollback()
+	d.lock = nil
+	return err
+}
+
+// Transaction runs fn with a driver over a transaction of the DB, fn runs
+// without a transaction when the DB does not implement Beginner.
+func (d *migrationDriver) Transaction(ctx context.Context, fn func(migrate.Driver) error) (err error) {
+	beginner, ok := d.db.(Beginner)
+	if !ok {
+		return fn(d)
+	}
+	transaction, err := beginner.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = transaction.Rollback()
+			panic(recovered)
+		}
+		if err != nil {
+			_ = transaction.Rollback()
+		}
+	}()
+	if err = fn(&migrationDriver{db: txDB{Tx: transaction}}); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+func (d *migrationDriver) Applied(ctx context.Context) ([]migrate.Record, error) {
+	rows, err := d.db.Query(
+		fmt.Sprintf("select version, name, applied_at from %s order by version", migrate.Table),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []migrate.Record
+	for rows.Next() {
+		var record migrate.Record
+		if err := rows.Scan(&record.Version, &record.Name, &record.AppliedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func (d *migrationDriver) Exec(ctx context.Context, script string) error {
+	_, err := d.db.Exec(script)
+	return err
+}
+
+func (d *migrationDriver) Insert(ctx context.Context, record migrate.Record) error {
+	_, err := d.db.Exec(
+		fmt.Sprintf("insert into %s (version, name, applied_at) values ($1, $2, $3)", migrate.Table),
+		record.Version, record.Name, record.AppliedAt,
+	)
+	return err
+}
+
+func (d *migrationDriver) Delete(ctx context.Context, version int64) error {
+	_, err := d.db.Exec(
+		fmt.Sprintf("delete from %s where version = $1", migrate.Table), version,
+	)
+	return err
+}
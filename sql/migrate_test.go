@@ -0,0 +1,155 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/migrate"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationDriverLock(test *testing.T) {
+	scenarios := []struct {
+		name   string
+		locked bool
+		err    error
+		want   error
+	}{
+		{name: "Lock", locked: true},
+		{name: "Locked by another migrator", want: migrate.ErrLocked},
+		{name: "Lock error", err: errors.New("errMock"), want: errors.New("errMock")},
+	}
+	for index, scenario := range scenarios {
+		test.Run(fmt.Sprintf("[%d]-%s", index, scenario.name), func(t *testing.T) {
+			var (
+				db  = new(smock.DBMock)
+				tx  = new(smock.TxMock)
+				row = smock.NewRowMock()
+			)
+			db.On("Begin").Return(tx, nil)
+			tx.On(
+				"QueryRow", "select pg_try_advisory_xact_lock(hashtext($1))",
+				[]interface{}{"schema_migrations_lock"},
+			).Return(row)
+			row.On("Scan", mock.Anything).Return(scenario.err).Run(func(args mock.Arguments) {
+				*args.Get(0).([]interface{})[0].(*bool) = scenario.locked
+			})
+			tx.On("Rollback").Return(nil)
+
+			driver := sql.NewMigrationDriver(db)
+			require.Equal(t, scenario.want, driver.Lock(context.Background()), "lock error")
+			require.Nil(t, driver.Unlock(context.Background()), "unlock error")
+			tx.AssertNumberOfCalls(t, "Rollback", 1)
+		})
+	}
+	driver := sql.NewMigrationDriver(dbOnly{smock.NewDBMock()})
+	require.Equal(
+		test, raizel.ErrTransactionUnsupported, driver.Lock(context.Background()), "lock without transactions",
+	)
+}
+
+func TestMigrationDriverTransaction(test *testing.T) {
+	scenarios := []struct {
+		name    string
+		execErr error
+		err     error
+	}{
+		{name: "Commits the script and the version"},
+		{name: "Rolls back the script when the version fails", execErr: errors.New("errMock"), err: errors.New("errMock")},
+	}
+	for index, scenario := range scenarios {
+		test.Run(fmt.Sprintf("[%d]-%s", index, scenario.name), func(t *testing.T) {
+			var (
+				db     = new(smock.DBMock)
+				tx     = new(smock.TxMock)
+				record = migrate.Record{Version: 1, Name: "create_entity", AppliedAt: time.Now().UTC()}
+			)
+			db.On("Begin").Return(tx, nil)
+			tx.On("Exec", "create table entity", []interface{}(nil)).Return(nil, nil)
+			tx.On(
+				"Exec", "insert into schema_migrations (version, name, applied_at) values ($1, $2, $3)",
+				[]interface{}{record.Version, record.Name, record.AppliedAt},
+			).Return(nil, scenario.execErr)
+			tx.On("Commit").Return(nil)
+			tx.On("Rollback").Return(nil)
+
+			driver := sql.NewMigrationDriver(db).(migrate.TxDriver)
+			err := driver.Transaction(context.Background(), func(tx migrate.Driver) error {
+				if err := tx.Exec(context.Background(), "create table entity"); err != nil {
+					return err
+				}
+				return tx.Insert(context.Background(), record)
+			})
+			require.Equal(t, scenario.err, err, "transaction error")
+			if scenario.err != nil {
+				tx.AssertNotCalled(t, "Commit")
+				tx.AssertCalled(t, "Rollback")
+			} else {
+				tx.AssertCalled(t, "Commit")
+				tx.AssertNotCalled(t, "Rollback")
+			}
+			db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMigrationDriver(test *testing.T) {
+	var (
+		ctx       = context.Background()
+		db        = smock.NewDBMock()
+		rows      = smock.NewRowsMock()
+		appliedAt = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+		driver    = sql.NewMigrationDriver(db)
+	)
+	db.On("Exec", mock.MatchedBy(func(sql string) bool {
+		return strings.HasPrefix(sql, "create table if not exists schema_migrations ")
+	}), []interface{}(nil)).Return(nil, nil)
+	db.On("Exec", "create table entity", []interface{}(nil)).Return(nil, nil)
+	db.On(
+		"Exec", "insert into schema_migrations (version, name, applied_at) values ($1, $2, $3)",
+		[]interface{}{int64(1), "create_entity", appliedAt},
+	).Return(nil, nil)
+	db.On(
+		"Exec", "delete from schema_migrations where version = $1", []interface{}{int64(1)},
+	).Return(nil, nil)
+	db.On(
+		"Query", "select version, name, applied_at from schema_migrations order by version", []interface{}(nil),
+	).Return(rows, nil)
+	rows.On("Next").Return(true).Once()
+	rows.On("Next").Return(false)
+	rows.On("Scan", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		dest := args.Get(0).([]interface{})
+		*dest[0].(*int64) = 1
+		*dest[1].(*string) = "create_entity"
+		*dest[2].(*time.Time) = appliedAt
+	})
+	rows.On("Err").Return(nil)
+	rows.On("Close").Return(nil)
+
+	require.Nil(test, driver.Init(ctx), "init error")
+	require.Nil(test, driver.Exec(ctx, "create table entity"), "exec error")
+	require.Nil(
+		test,
+		driver.Insert(ctx, migrate.Record{Version: 1, Name: "create_entity", AppliedAt: appliedAt}),
+		"insert error",
+	)
+	records, err := driver.Applied(ctx)
+	require.Nil(test, err, "applied error")
+	require.Equal(
+		test,
+		[]migrate.Record{{Version: 1, Name: "create_entity", AppliedAt: appliedAt}},
+		records,
+		"applied records",
+	)
+	require.Nil(test, driver.Delete(ctx, 1), "delete error")
+	db.AssertExpectations(test)
+	rows.AssertCalled(test, "Close")
+}
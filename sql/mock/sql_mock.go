@@ -0,0 +1,218 @@
+// Package mock provides testify mocks of the raizel sql interfaces.
+package mock
+
+import (
+	rmock "github.com/rjansen/raizel/mock"
+	"github.com/rjansen/raizel/sql"
+	"github.com/stretchr/testify/mock"
+)
+
+type DBMock struct {
+	mock.Mock
+}
+
+func NewDBMock() *DBMock {
+	return new(DBMock)
+}
+
+func (mock *DBMock) QueryRow(query string, params ...interface{}) sql.Row {
+	var (
+		args   = mock.Called(query, params)
+		result = args.Get(0)
+	)
+	if result == nil {
+		return nil
+	}
+	return result.(sql.Row)
+}
+
+func (mock *DBMock) Query(query string, params ...interface{}) (sql.Rows, error) {
+	var (
+		args   = mock.Called(query, params)
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(sql.Rows), err
+}
+
+func (mock *DBMock) Exec(query string, params ...interface{}) (sql.Result, error) {
+	var (
+		args   = mock.Called(query, params)
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(sql.Result), err
+}
+
+func (mock *DBMock) Ping() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+func (mock *DBMock) Close() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+// Begin returns the Tx of the expectation, a *TxMock usually.
+func (mock *DBMock) Begin() (sql.Tx, error) {
+	var (
+		args   = mock.Called()
+		result = args.Get(0)
+		err    = args.Error(1)
+	)
+	if result == nil {
+		return nil, err
+	}
+	return result.(sql.Tx), err
+}
+
+type TxMock struct {
+	DBMock
+}
+
+func NewTxMock() *TxMock {
+	return new(TxMock)
+}
+
+func (mock *TxMock) Commit() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+func (mock *TxMock) Rollback() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+type RowMock struct {
+	mock.Mock
+}
+
+func NewRowMock() *RowMock {
+	return new(RowMock)
+}
+
+func (mock *RowMock) Scan(dest ...interface{}) error {
+	args := mock.Called(dest)
+	return args.Error(0)
+}
+
+// OnScan expects a Scan that assigns the values to the destinations.
+func (row *RowMock) OnScan(values ...interface{}) *mock.Call {
+	return row.On("Scan", mock.Anything).Run(rmock.Scan(values...)).Return(nil)
+}
+
+// OnScanStruct expects a Scan that assigns the source struct fields to the
+// destinations.
+func (row *RowMock) OnScanStruct(source interface{}) *mock.Call {
+	return row.OnScan(rmock.StructValues(source)...)
+}
+
+type RowsMock struct {
+	mock.Mock
+}
+
+func NewRowsMock() *RowsMock {
+	return new(RowsMock)
+}
+
+func (mock *RowsMock) Next() bool {
+	args := mock.Called()
+	return args.Bool(0)
+}
+
+func (mock *RowsMock) Scan(dest ...interface{}) error {
+	args := mock.Called(dest)
+	return args.Error(0)
+}
+
+func (mock *RowsMock) Err() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+func (mock *RowsMock) Close() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+// OnRows expects the iteration of the rows, each Scan assigns the values of
+// the next row to the destinations. Err and Close return nil.
+func (rows *RowsMock) OnRows(values ...[]interface{}) {
+	for _, row := range values {
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything).Run(rmock.Scan(row...)).Return(nil).Once()
+	}
+	rows.On("Next").Return(false)
+	rows.On("Err").Return(nil)
+	rows.On("Close").Return(nil)
+}
+
+// OnStructs expects the iteration of the rows of the source structs fields.
+func (rows *RowsMock) OnStructs(sources ...interface{}) {
+	values := make([][]interface{}, len(sources))
+	for index, source := range sources {
+		values[index] = rmock.StructValues(source)
+	}
+	rows.OnRows(values...)
+}
+
+type ResultMock struct {
+	mock.Mock
+}
+
+func NewResultMock() *ResultMock {
+	return new(ResultMock)
+}
+
+func (mock *ResultMock) LastInsertId() (int64, error) {
+	args := mock.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (mock *ResultMock) RowsAffected() (int64, error) {
+	args := mock.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type ListenerMock struct {
+	mock.Mock
+	notifications chan *sql.Notification
+}
+
+// NewListenerMock returns a ListenerMock with a buffered notifications
+// channel, the notifications are sent with Notify.
+func NewListenerMock() *ListenerMock {
+	return &ListenerMock{notifications: make(chan *sql.Notification, 10)}
+}
+
+func (mock *ListenerMock) Listen(channel string) error {
+	args := mock.Called(channel)
+	return args.Error(0)
+}
+
+func (mock *ListenerMock) Unlisten(channel string) error {
+	args := mock.Called(channel)
+	return args.Error(0)
+}
+
+func (mock *ListenerMock) Notifications() <-chan *sql.Notification {
+	return mock.notifications
+}
+
+// Notify sends the notification to the Notifications channel.
+func (mock *ListenerMock) Notify(notification *sql.Notification) {
+	mock.notifications <- notification
+}
+
+func (mock *ListenerMock) Close() error {
+	args := mock.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,115 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type entity struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestDBMock(t *testing.T) {
+	var (
+		db     = NewDBMock()
+		row    = NewRowMock()
+		rows   = NewRowsMock()
+		result = NewResultMock()
+	)
+	require.Implements(t, (*sql.DB)(nil), db, "invalid db type")
+	require.Implements(t, (*sql.Row)(nil), row, "invalid row type")
+	require.Implements(t, (*sql.Rows)(nil), rows, "invalid rows type")
+	require.Implements(t, (*sql.Result)(nil), result, "invalid result type")
+	require.Implements(t, (*sql.Listener)(nil), NewListenerMock(), "invalid listener type")
+
+	db.On("QueryRow", "select", []interface{}{1}).Return(row)
+	db.On("Query", "select", []interface{}(nil)).Return(nil, errors.New("errMock"))
+	db.On("Exec", "update", []interface{}(nil)).Return(result, nil)
+	db.On("Ping").Return(nil)
+	db.On("Close").Return(nil)
+	result.On("RowsAffected").Return(int64(2), nil)
+
+	require.Equal(t, row, db.QueryRow("select", 1), "invalid query_row() response")
+	queried, err := db.Query("select")
+	require.Nil(t, queried, "invalid query() response")
+	require.NotNil(t, err, "invalid query() error")
+	executed, err := db.Exec("update")
+	require.Nil(t, err, "exec error")
+	affected, err := executed.RowsAffected()
+	require.Nil(t, err, "rows_affected error")
+	require.Equal(t, int64(2), affected, "invalid rows_affected() response")
+	require.Nil(t, db.Ping(), "ping error")
+	require.Nil(t, db.Close(), "close error")
+	db.AssertExpectations(t)
+}
+
+func TestTxMock(t *testing.T) {
+	var (
+		db = NewDBMock()
+		tx = NewTxMock()
+	)
+	require.Implements(t, (*sql.Beginner)(nil), db, "invalid beginner type")
+	require.Implements(t, (*sql.Tx)(nil), tx, "invalid tx type")
+
+	db.On("Begin").Return(tx, nil)
+	tx.On("Commit").Return(nil)
+	tx.On("Rollback").Return(errors.New("errMock"))
+
+	begun, err := db.Begin()
+	require.Nil(t, err, "begin error")
+	require.Equal(t, tx, begun, "invalid begin() response")
+	require.Nil(t, begun.Commit(), "commit error")
+	require.NotNil(t, begun.Rollback(), "invalid rollback() error")
+	db.AssertExpectations(t)
+	tx.AssertExpectations(t)
+}
+
+func TestRowMock(t *testing.T) {
+	var (
+		row  = NewRowMock()
+		id   int
+		name string
+	)
+	row.OnScanStruct(&entity{ID: 1, Name: "name"})
+	require.Nil(t, row.Scan(&id, &name), "scan error")
+	require.Equal(t, 1, id, "scanned id")
+	require.Equal(t, "name", name, "scanned name")
+
+	row = NewRowMock()
+	row.On("Scan", mock.Anything).Return(errors.New("errMock"))
+	require.NotNil(t, row.Scan(&id), "scan error")
+}
+
+func TestRowsMock(t *testing.T) {
+	var (
+		rows    = NewRowsMock()
+		scanned []entity
+	)
+	rows.OnStructs(entity{ID: 1, Name: "one"}, entity{ID: 2, Name: "two"})
+	for rows.Next() {
+		var current entity
+		require.Nil(t, rows.Scan(&current.ID, &current.Name), "scan error")
+		scanned = append(scanned, current)
+	}
+	require.Nil(t, rows.Err(), "rows error")
+	require.Nil(t, rows.Close(), "close error")
+	require.Equal(t, []entity{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}}, scanned, "scanned entities")
+	rows.AssertExpectations(t)
+}
+
+func TestListenerMock(t *testing.T) {
+	listener := NewListenerMock()
+	listener.On("Listen", "channel").Return(nil)
+	listener.On("Close").Return(nil)
+
+	require.Nil(t, listener.Listen("channel"), "listen error")
+	listener.Notify(&sql.Notification{Channel: "channel", Extra: "payload"})
+	notification := <-listener.Notifications()
+	require.Equal(t, "payload", notification.Extra, "notification payload")
+	require.Nil(t, listener.Close(), "close error")
+}
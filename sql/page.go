@@ -0,0 +1,166 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+type pageIterator struct {
+	rows          Rows
+	sqlStruct     *sqlbuilder.Struct
+	columns       []string
+	size          int
+	count         int
+	last          []interface{}
+	nextPageToken string
+}
+
+func (i *pageIterator) Next(ctx context.Context, entity raizel.Entity) error {
+	if !i.rows.Next() {
+		if err := i.rows.Err(); err != nil {
+			return err
+		}
+		if i.count == i.size && i.last != nil {
+			token, err := raizel.EncodePageToken(i.last...)
+			if err != nil {
+				return err
+			}
+			i.nextPageToken, i.last = token, nil
+		}
+		return raizel.ErrIteratorDone
+	}
+	value, load, err := describedValue(i.sqlStruct, entity)
+	if err != nil {
+		return err
+	}
+	if err := i.rows.Scan(i.sqlStruct.Addr(value)...); err != nil {
+		return err
+	}
+	if err := load(); err != nil {
+		return err
+	}
+	addrs := i.sqlStruct.AddrWithCols(i.columns, value)
+	if addrs == nil {
+		return ErrUnmappedColumn
+	}
+	i.count++
+	i.last = make([]interface{}, len(addrs))
+	for index, addr := range addrs {
+		i.last[index] = reflect.ValueOf(addr).Elem().Interface()
+	}
+	return nil
+}
+
+func (i *pageIterator) NextPageToken() string {
+	return i.nextPageToken
+}
+
+func (i *pageIterator) Stop() {
+	_ = i.rows.Close()
+}
+
+// keysetCondition returns the predicate of the rows ordered after values, it
+// uses the row value comparison when every order has the same direction.
+func keysetCondition(cond *sqlbuilder.Cond, orders []raizel.Order, values []interface{}) string {
+	var (
+		operator = func(order raizel.Order) string {
+			if order.Direction == raizel.Desc {
+				return "<"
+			}
+			return ">"
+		}
+		sameDirection = true
+	)
+	for _, order := range orders[1:] {
+		sameDirection = sameDirection && order.Direction == orders[0].Direction
+	}
+	if sameDirection {
+		var (
+			columns = make([]string, len(orders))
+			vars    = make([]string, len(orders))
+		)
+		for index, order := range orders {
+			columns[index] = pq.QuoteIdentifier(order.Field)
+			vars[index] = cond.Var(values[index])
+		}
+		if len(orders) == 1 {
+			return fmt.Sprintf("%s %s %s", columns[0], operator(orders[0]), vars[0])
+		}
+		return fmt.Sprintf(
+			"(%s) %s (%s)",
+			strings.Join(columns, ", "), operator(orders[0]), strings.Join(vars, ", "),
+		)
+	}
+	alternatives := make([]string, len(orders))
+	for index, order := range orders {
+		terms := make([]string, 0, index+1)
+		for previous := 0; previous < index; previous++ {
+			terms = append(terms, cond.E(pq.QuoteIdentifier(orders[previous].Field), values[previous]))
+		}
+		terms = append(
+			terms,
+			fmt.Sprintf("%s %s %s", pq.QuoteIdentifier(order.Field), operator(order), cond.Var(values[index])),
+		)
+		alternatives[index] = fmt.Sprintf("(%s)", strings.Join(terms, " AND "))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(alternatives, " OR "))
+}
+
+// Page reads the query page with a keyset predicate over the ordering values
+// carried by pageToken, the ordering columns must be mapped by the entity.
+func (repository repository) Page(ctx context.Context, query raizel.Query, pageToken string) (raizel.PageIterator, error) {
+	if len(query.Orders) == 0 {
+		return nil, raizel.ErrUnorderedPage
+	}
+	sqlStruct, err := repository.entityStruct(query.EntityName)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		filters    = append(query.ParentFilters(), query.Filters...)
+		size       = raizel.PageSize(query)
+		builder    = sqlStruct.SelectFrom(query.EntityName)
+		conditions = make([]string, len(filters), len(filters)+1)
+		columns    = make([]string, len(query.Orders))
+	)
+	for index, filter := range filters {
+		condition, err := filterCondition(&builder.Cond, filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions[index] = condition
+	}
+	if pageToken != "" {
+		values, err := raizel.DecodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(query.Orders) {
+			return nil, raizel.ErrInvalidPageToken
+		}
+		conditions = append(conditions, keysetCondition(&builder.Cond, query.Orders, values))
+	}
+	if len(conditions) > 0 {
+		builder.Where(conditions...)
+	}
+	for index, order := range query.Orders {
+		columns[index] = order.Field
+	}
+	sql, args := builder.OrderBy(orderClauses(query.Orders)...).Limit(size).Build()
+	rows, err := repository.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &pageIterator{
+		rows:      rows,
+		sqlStruct: sqlStruct,
+		columns:   columns,
+		size:      size,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectEntityMock = "SELECT id, name, age, data, deleted, created_at, updated_at FROM entity_table "
+)
+
+func mustPageToken(values ...interface{}) string {
+	token, err := raizel.EncodePageToken(values...)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+type testRepositoryPage struct {
+	name          string
+	rows          *smock.RowsMock
+	db            *smock.DBMock
+	query         raizel.Query
+	pageToken     string
+	sql           string
+	args          []interface{}
+	entities      []entityMock
+	nextPageToken string
+	err           error
+}
+
+func (scenario *testRepositoryPage) setup(t *testing.T) {
+	var (
+		rows = smock.NewRowsMock()
+		db   = smock.NewDBMock()
+	)
+	for _, entity := range scenario.entities {
+		entity := entity
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything).Return(nil).Run(
+			func(args mock.Arguments) {
+				dest := args.Get(0).([]interface{})
+				*dest[0].(*int) = entity.ID
+				*dest[2].(*int) = entity.Age
+			},
+		).Once()
+	}
+	rows.On("Next").Return(false)
+	rows.On("Err").Return(nil)
+	rows.On("Close").Return(nil)
+	db.On("Query", scenario.sql, scenario.args).Return(rows, nil)
+
+	scenario.rows = rows
+	scenario.db = db
+}
+
+func TestRepositoryPage(test *testing.T) {
+	scenarios := []testRepositoryPage{
+		{
+			name: "Read the first page",
+			query: raizel.NewQuery("entity_table").
+				Where("deleted", raizel.Equal, false).
+				OrderBy("age", raizel.Asc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			sql:           selectEntityMock + `WHERE "deleted" = ? ORDER BY "age" ASC, "id" ASC LIMIT 2`,
+			args:          []interface{}{false},
+			entities:      []entityMock{{ID: 1, Age: 20}, {ID: 2, Age: 30}},
+			nextPageToken: mustPageToken(30, 2),
+		},
+		{
+			name: "Read the next page with a row value predicate",
+			query: raizel.NewQuery("entity_table").
+				Where("deleted", raizel.Equal, false).
+				OrderBy("age", raizel.Asc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(2),
+			pageToken: mustPageToken(30, 2),
+			sql:       selectEntityMock + `WHERE "deleted" = ? AND ("age", "id") > (?, ?) ORDER BY "age" ASC, "id" ASC LIMIT 2`,
+			args:      []interface{}{false, int64(30), int64(2)},
+			entities:  []entityMock{{ID: 3, Age: 40}},
+		},
+		{
+			name: "Read the next page with mixed directions",
+			query: raizel.NewQuery("entity_table").
+				OrderBy("age", raizel.Desc).
+				OrderBy("id", raizel.Asc).
+				WithLimit(1),
+			pageToken:     mustPageToken(30, 2),
+			sql:           selectEntityMock + `WHERE (("age" < ?) OR ("age" = ? AND "id" > ?)) ORDER BY "age" DESC, "id" ASC LIMIT 1`,
+			args:          []interface{}{int64(30), int64(30), int64(2)},
+			entities:      []entityMock{{ID: 1, Age: 20}},
+			nextPageToken: mustPageToken(20, 1),
+		},
+		{
+			name:      "Read a page with the default size",
+			query:     raizel.NewQuery("entity_table").OrderBy("id", raizel.Desc),
+			pageToken: mustPageToken(9),
+			sql:       selectEntityMock + `WHERE "id" < ? ORDER BY "id" DESC LIMIT 100`,
+			args:      []interface{}{int64(9)},
+		},
+		{
+			name:      "Error when the token is invalid",
+			query:     raizel.NewQuery("entity_table").OrderBy("id", raizel.Asc),
+			pageToken: "!invalid",
+			err:       raizel.ErrInvalidPageToken,
+		},
+		{
+			name:      "Error when the token does not match the orders",
+			query:     raizel.NewQuery("entity_table").OrderBy("id", raizel.Asc),
+			pageToken: mustPageToken(30, 2),
+			err:       raizel.ErrInvalidPageToken,
+		},
+		{
+			name:  "Error when the query is not ordered",
+			query: raizel.NewQuery("entity_table"),
+			err:   raizel.ErrUnorderedPage,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := sql.NewRepository(
+					scenario.db,
+					sql.NewMapperBuilder().
+						Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+						NewMapper(),
+				)
+				page, err := repository.Page(context.Background(), scenario.query, scenario.pageToken)
+				require.Equal(t, scenario.err, err, "page error")
+				if scenario.err != nil {
+					require.Nil(t, page, "page instance")
+					scenario.db.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+					return
+				}
+				defer page.Stop()
+				for _, expected := range scenario.entities {
+					var entity entityMock
+					require.Nil(t, page.Next(context.Background(), &entity), "next error")
+					require.Equal(t, expected.ID, entity.ID, "entity id")
+				}
+				require.Equal(t, raizel.ErrIteratorDone, page.Next(context.Background(), new(entityMock)), "next done error")
+				require.Equal(t, scenario.nextPageToken, page.NextPageToken(), "next page token")
+				scenario.db.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -0,0 +1,80 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+// assignment returns the SET assignment of the update with the field quoted,
+// the array transforms use the postgres array operators.
+func assignment(builder *sqlbuilder.UpdateBuilder, update raizel.Update) (string, error) {
+	field := pq.QuoteIdentifier(update.Field)
+	switch update.Transform {
+	case raizel.AssignTransform:
+		return builder.Assign(field, update.Value), nil
+	case raizel.IncrementTransform:
+		return builder.Add(field, update.Value), nil
+	case raizel.ServerTimestampTransform:
+		return fmt.Sprintf("%s = CURRENT_TIMESTAMP", field), nil
+	case raizel.ArrayAppendTransform:
+		return fmt.Sprintf(
+			"%s = %s || %s", field, field, builder.Var(pq.Array(update.Value)),
+		), nil
+	case raizel.ArrayRemoveTransform:
+		return fmt.Sprintf(
+			"%s = ARRAY(SELECT e FROM unnest(%s) AS e WHERE NOT e = ANY(%s))",
+			field, field, builder.Var(pq.Array(update.Value)),
+		), nil
+	case raizel.DeleteTransform:
+		return fmt.Sprintf("%s = NULL", field), nil
+	}
+	return "", fmt.Errorf("%w: transform %d", raizel.ErrInvalidArgument, update.Transform)
+}
+
+// Patch runs an UPDATE of the updated columns only, an update that changes no
+// row returns raizel.ErrNotFound. The key columns, and the TenantColumn under
+// ColumnTenancy, are refused with raizel.ErrInvalidArgument.
+func (repository repository) Patch(ctx context.Context, key raizel.EntityKey, updates ...raizel.Update) error {
+	var reserved []string
+	if repository.tenancy == ColumnTenancy {
+		reserved = append(reserved, TenantColumn)
+	}
+	if err := raizel.CheckUpdates(key, updates, reserved...); err != nil {
+		return err
+	}
+	sqlStruct, err := repository.entityStruct(key.EntityName())
+	if err != nil {
+		return err
+	}
+	var (
+		builder     = sqlStruct.Flavor.NewUpdateBuilder()
+		assignments = make([]string, len(updates))
+	)
+	builder.Update(repository.entityTable(key))
+	for index, update := range updates {
+		assignment, err := assignment(builder, update)
+		if err != nil {
+			return err
+		}
+		assignments[index] = assignment
+	}
+	sql, args := builder.Set(assignments...).Where(
+		repository.keyConditions(&builder.Cond, key)...,
+	).Build()
+	result, err := repository.db.Exec(sql, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return raizel.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryPatch struct {
+	name     string
+	updates  []raizel.Update
+	sql      string
+	args     []interface{}
+	affected int64
+	err      error
+}
+
+func TestRepositoryPatch(test *testing.T) {
+	scenarios := []testRepositoryPatch{
+		{
+			name: "Patch the updated columns",
+			updates: []raizel.Update{
+				raizel.Assign("name", "mock"),
+				raizel.Increment("age", 1),
+				raizel.ServerTimestamp("updated_at"),
+				raizel.DeleteField("nickname"),
+			},
+			sql: `UPDATE entity_table SET "name" = $1, "age" = "age" + $2, "updated_at" = CURRENT_TIMESTAMP, ` +
+				`"nickname" = NULL WHERE id = $3`,
+			args:     []interface{}{"mock", 1, "identifier"},
+			affected: 1,
+		},
+		{
+			name: "Patch the array columns",
+			updates: []raizel.Update{
+				raizel.ArrayAppend("tags", "a"),
+				raizel.ArrayRemove("tags", "b"),
+			},
+			sql: `UPDATE entity_table SET "tags" = "tags" || $1, ` +
+				`"tags" = ARRAY(SELECT e FROM unnest("tags") AS e WHERE NOT e = ANY($2)) WHERE id = $3`,
+			args: []interface{}{
+				pq.Array([]interface{}{"a"}), pq.Array([]interface{}{"b"}), "identifier",
+			},
+			affected: 1,
+		},
+		{
+			name:    "Error when the entity is not found",
+			updates: []raizel.Update{raizel.Assign("name", "mock")},
+			sql:     `UPDATE entity_table SET "name" = $1 WHERE id = $2`,
+			args:    []interface{}{"mock", "identifier"},
+			err:     raizel.ErrNotFound,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					db     = smock.NewDBMock()
+					result = smock.NewResultMock()
+					mapper = sql.NewMapperBuilder().
+						Set("entity_table", sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL)).
+						NewMapper()
+					key = entityKeyMock{table: "entity_table", name: "id", value: "identifier"}
+				)
+				db.On("Exec", scenario.sql, scenario.args).Return(result, nil)
+				result.On("RowsAffected").Return(scenario.affected, nil)
+
+				repository := sql.NewRepository(db, mapper)
+				err := raizel.Patch(context.Background(), repository, key, scenario.updates...)
+				require.Equal(t, scenario.err, err, "patch error")
+				db.AssertExpectations(t)
+			},
+		)
+	}
+}
+
+func TestRepositoryPatchKeyColumns(test *testing.T) {
+	var (
+		ctx    = raizel.WithTenant(context.Background(), "tenant_a")
+		db     = smock.NewDBMock()
+		mapper = sql.NewMapperBuilder().
+			Set("entity_table", sqlbuilder.NewStruct(new(tenantEntityMock)).For(sqlbuilder.PostgreSQL)).
+			NewMapper()
+		key        = entityKeyMock{table: "entity_table", name: "id", value: 1}
+		repository = sql.NewTenantRepository(db, mapper, sql.ColumnTenancy)
+	)
+	for _, update := range []raizel.Update{raizel.Assign("id", 2), raizel.Assign(sql.TenantColumn, "tenant_b")} {
+		err := raizel.Patch(ctx, repository, key, raizel.Assign("name", "mock"), update)
+		require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "patch %s error", update.Field)
+	}
+	db.AssertNotCalled(test, "Exec", mock.Anything, mock.Anything)
+}
@@ -3,45 +3,173 @@ package sql
 import (
 	"context"
 	database "database/sql"
+	"fmt"
+	"reflect"
 
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
 	"github.com/lib/pq"
 	"github.com/rjansen/raizel"
 )
 
+// TenantStrategy defines how a tenant repository isolates the tenant rows.
+type TenantStrategy int
+
+const (
+	// SchemaTenancy reads and writes the entity table inside the tenant schema.
+	SchemaTenancy TenantStrategy = iota
+	// ColumnTenancy filters the entity table by the TenantColumn predicate,
+	// the entity must map the TenantColumn, Set writes the key tenant to it.
+	ColumnTenancy
+)
+
+const (
+	TenantColumn = "tenant_id"
+	setSavepoint = "raizel_set"
+)
+
 type repository struct {
-	db     DB
-	mapper Mapper
+	db      DB
+	mapper  Mapper
+	tenancy TenantStrategy
 }
 
 func NewRepository(db DB, mapper Mapper) repository {
 	return repository{db: db, mapper: mapper}
 }
 
+func NewTenantRepository(db DB, mapper Mapper, tenancy TenantStrategy) raizel.Repository {
+	return raizel.NewTenantRepository(
+		repository{db: db, mapper: mapper, tenancy: tenancy},
+	)
+}
+
+func (repository repository) entityTable(key raizel.EntityKey) string {
+	tenant, _ := raizel.TenantOf(key)
+	return repository.tenantTable(key.EntityName(), tenant)
+}
+
+func (repository repository) tenantTable(entityName string, tenant string) string {
+	if tenant == "" || repository.tenancy != SchemaTenancy {
+		return entityName
+	}
+	return fmt.Sprintf("%s.%s", pq.QuoteIdentifier(tenant), entityName)
+}
+
+func (repository repository) keyConditions(cond *sqlbuilder.Cond, key raizel.EntityKey) []string {
+	var (
+		names, values = raizel.KeyPathParts(key)
+		conditions    = make([]string, len(names))
+	)
+	for index, name := range names {
+		conditions[index] = cond.E(name, values[index])
+	}
+	if tenant, ok := raizel.TenantOf(key); ok && repository.tenancy == ColumnTenancy {
+		conditions = append(conditions, cond.E(TenantColumn, tenant))
+	}
+	return conditions
+}
+
+// entityStruct returns the struct the mapper maps to the entity name, an
+// unmapped entity returns raizel.ErrInvalidArgument.
+func (repository repository) entityStruct(entityName string) (*sqlbuilder.Struct, error) {
+	sqlStruct := repository.mapper.Get(entityName)
+	if sqlStruct == nil {
+		return nil, fmt.Errorf("%w: unmapped entity %s", raizel.ErrInvalidArgument, entityName)
+	}
+	return sqlStruct, nil
+}
+
+// tenantValue returns a copy of the value with the TenantColumn set to the
+// key tenant under ColumnTenancy, so a tenant never writes the rows of
+// another tenant.
+func (repository repository) tenantValue(sqlStruct *sqlbuilder.Struct, key raizel.EntityKey, value interface{}) (interface{}, error) {
+	tenant, ok := raizel.TenantOf(key)
+	if !ok || repository.tenancy != ColumnTenancy {
+		return value, nil
+	}
+	source := reflect.Indirect(reflect.ValueOf(value))
+	if !source.IsValid() {
+		return nil, fmt.Errorf("%w: nil entity", raizel.ErrInvalidArgument)
+	}
+	tenantValue := reflect.New(source.Type())
+	tenantValue.Elem().Set(source)
+	addrs := sqlStruct.AddrWithCols([]string{TenantColumn}, tenantValue.Interface())
+	if len(addrs) != 1 {
+		return nil, fmt.Errorf("%w: entity without the %s column", raizel.ErrInvalidArgument, TenantColumn)
+	}
+	column, ok := addrs[0].(*string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s column is not a string", raizel.ErrInvalidArgument, TenantColumn)
+	}
+	*column = tenant
+	return tenantValue.Interface(), nil
+}
+
 func (repository repository) Get(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	sqlStruct, err := repository.entityStruct(key.EntityName())
+	if err != nil {
+		return err
+	}
+	value, load, err := describedValue(sqlStruct, entity)
+	if err != nil {
+		return err
+	}
 	var (
-		sqlStruct = repository.mapper.Get(key.EntityName())
-		builder   = sqlStruct.SelectFrom(key.EntityName())
+		builder   = sqlStruct.SelectFrom(repository.entityTable(key))
 		sql, args = builder.Where(
-			builder.E(key.Name(), key.Value()),
+			repository.keyConditions(&builder.Cond, key)...,
 		).Build()
 		row = repository.db.QueryRow(sql, args...)
-		err = row.Scan(sqlStruct.Addr(entity)...)
 	)
-	if err != nil {
+	if err := row.Scan(sqlStruct.Addr(value)...); err != nil {
 		if err == database.ErrNoRows {
 			return raizel.ErrNotFound
 		}
 		return err
 	}
-	return nil
+	if err := load(); err != nil {
+		return err
+	}
+	return raizel.AfterGet(ctx, entity)
 }
 
 func (repository repository) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
-	var (
-		sqlStruct = repository.mapper.Get(key.EntityName())
-		sql, args = sqlStruct.InsertInto(key.EntityName(), entity).Build()
-		_, err    = repository.db.Exec(sql, args...)
-	)
+	if err := raizel.BeforeSet(ctx, entity); err != nil {
+		return err
+	}
+	sqlStruct, err := repository.entityStruct(key.EntityName())
+	if err != nil {
+		return err
+	}
+	value, _, err := describedValue(sqlStruct, entity)
+	if err != nil {
+		return err
+	}
+	if value, err = repository.tenantValue(sqlStruct, key, value); err != nil {
+		return err
+	}
+	if _, inTransaction := repository.db.(txDB); !inTransaction {
+		return repository.insertOrUpdate(sqlStruct, key, value)
+	}
+	// a failed INSERT aborts a postgres transaction, the savepoint rolls back
+	// the INSERT alone before the UPDATE of the existing row.
+	if _, err := repository.db.Exec("SAVEPOINT " + setSavepoint); err != nil {
+		return err
+	}
+	if err := repository.insertOrUpdate(sqlStruct, key, value); err != nil {
+		return err
+	}
+	_, err = repository.db.Exec("RELEASE SAVEPOINT " + setSavepoint)
+	return err
+}
+
+// insertOrUpdate inserts the value and updates the row of the key when the
+// INSERT violates a unique constraint. An UPDATE without rows returns
+// ErrKeyConflict, the conflicting row is not the row of the key, a row of
+// another tenant under ColumnTenancy.
+func (repository repository) insertOrUpdate(sqlStruct *sqlbuilder.Struct, key raizel.EntityKey, value interface{}) error {
+	sql, args := sqlStruct.InsertInto(repository.entityTable(key), value).Build()
+	_, err := repository.db.Exec(sql, args...)
 	if err != nil {
 		pgerr, ispgerr := err.(*pq.Error)
 		if !ispgerr {
@@ -62,29 +190,46 @@ func (repository repository) Set(ctx context.Context, key raizel.EntityKey, enti
 		if pgerr.Code != "23505" {
 			return err
 		}
+		if _, inTransaction := repository.db.(txDB); inTransaction {
+			if _, err := repository.db.Exec("ROLLBACK TO SAVEPOINT " + setSavepoint); err != nil {
+				return err
+			}
+		}
 
-		builder := sqlStruct.Update(key.EntityName(), entity)
+		builder := sqlStruct.Update(repository.entityTable(key), value)
 		sql, args = builder.Where(
-			builder.E(key.Name(), key.Value()),
+			repository.keyConditions(&builder.Cond, key)...,
 		).Build()
-		_, err = repository.db.Exec(sql, args...)
+		result, err := repository.db.Exec(sql, args...)
 		if err != nil {
 			return err
 		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return fmt.Errorf("%w: %s", ErrKeyConflict, key.EntityName())
+		}
 	}
 	return nil
 }
 
 func (repository repository) Delete(ctx context.Context, key raizel.EntityKey) error {
+	if err := raizel.BeforeDelete(ctx, repository, key); err != nil {
+		return err
+	}
+	sqlStruct, err := repository.entityStruct(key.EntityName())
+	if err != nil {
+		return err
+	}
 	var (
-		sqlStruct = repository.mapper.Get(key.EntityName())
-		builder   = sqlStruct.DeleteFrom(key.EntityName())
+		builder   = sqlStruct.DeleteFrom(repository.entityTable(key))
 		sql, args = builder.Where(
-			builder.E(key.Name(), key.Value()),
+			repository.keyConditions(&builder.Cond, key)...,
 		).Build()
-		_, err = repository.db.Exec(sql, args...)
 	)
-	if err != nil {
+	if _, err := repository.db.Exec(sql, args...); err != nil {
 		return err
 	}
 	return nil
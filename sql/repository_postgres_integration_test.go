@@ -1,10 +1,11 @@
+//go:build integration
 // +build integration
 
-package sql
+package sql_test
 
 import (
 	"context"
-	"database/sql"
+	database "database/sql"
 	"fmt"
 	"testing"
 	"time"
@@ -12,6 +13,8 @@ import (
 	sqlbuilder "github.com/huandu/go-sqlbuilder"
 	_ "github.com/lib/pq"
 	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/schema"
+	"github.com/rjansen/raizel/sql"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,8 +32,8 @@ const (
 type testRepositoryPostgresGet struct {
 	name     string
 	ctx      context.Context
-	db       DB
-	mapper   Mapper
+	db       sql.DB
+	mapper   sql.Mapper
 	mockData *entityMock
 	key      entityKeyMock
 	result   raizel.Entity
@@ -41,8 +44,8 @@ func (scenario *testRepositoryPostgresGet) setup(t *testing.T) {
 	var (
 		driver          = "postgres"
 		dsn             = "postgres://postgres:@127.0.0.1:5432/postgres?sslmode=disable"
-		sqlDB, errSqlDB = sql.Open(driver, dsn)
-		db, errDB       = NewDB(sqlDB)
+		sqlDB, errSqlDB = database.Open(driver, dsn)
+		db, errDB       = sql.NewDB(sqlDB)
 	)
 	require.Nil(t, errSqlDB, "sqlopen error")
 	require.Nil(t, errDB, "newdb error")
@@ -85,7 +88,7 @@ func TestRepositoryPostgresGet(test *testing.T) {
 				name:  "id",
 			},
 			result: &entityMock{},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_mock",
 					sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL),
 				).NewMapper(),
@@ -98,7 +101,7 @@ func TestRepositoryPostgresGet(test *testing.T) {
 				value: 133,
 			},
 			result: &entityMock{},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_mock",
 					sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL),
 				).NewMapper(),
@@ -112,7 +115,7 @@ func TestRepositoryPostgresGet(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Get(scenario.ctx, scenario.key, scenario.result)
 				require.Equal(t, scenario.err, err, "get error")
@@ -124,8 +127,8 @@ func TestRepositoryPostgresGet(test *testing.T) {
 type testRepositoryPostgresSet struct {
 	name     string
 	ctx      context.Context
-	db       DB
-	mapper   Mapper
+	db       sql.DB
+	mapper   sql.Mapper
 	mockData *entityMock
 	key      entityKeyMock
 	data     *entityMock
@@ -136,8 +139,8 @@ func (scenario *testRepositoryPostgresSet) setup(t *testing.T) {
 	var (
 		driver          = "postgres"
 		dsn             = "postgres://postgres:@127.0.0.1:5432/postgres?sslmode=disable"
-		sqlDB, errSqlDB = sql.Open(driver, dsn)
-		db, errDB       = NewDB(sqlDB)
+		sqlDB, errSqlDB = database.Open(driver, dsn)
+		db, errDB       = sql.NewDB(sqlDB)
 	)
 	require.Nil(t, errSqlDB, "sqlopen error")
 	require.Nil(t, errDB, "newdb error")
@@ -192,7 +195,7 @@ func TestRepositoryPostgresSet(test *testing.T) {
 				CreatedAt: currentTime,
 				UpdatedAt: currentTime,
 			},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_mock",
 					sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL),
 				).NewMapper(),
@@ -226,7 +229,7 @@ func TestRepositoryPostgresSet(test *testing.T) {
 				CreatedAt: currentTime,
 				UpdatedAt: currentTime,
 			},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_mock",
 					sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL),
 				).NewMapper(),
@@ -240,7 +243,7 @@ func TestRepositoryPostgresSet(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Set(scenario.ctx, scenario.key, scenario.data)
 				require.Equal(t, scenario.err, err, "set error")
@@ -252,8 +255,8 @@ func TestRepositoryPostgresSet(test *testing.T) {
 type testRepositoryPostgresDelete struct {
 	name     string
 	ctx      context.Context
-	db       DB
-	mapper   Mapper
+	db       sql.DB
+	mapper   sql.Mapper
 	mockData *entityMock
 	key      entityKeyMock
 	err      error
@@ -263,8 +266,8 @@ func (scenario *testRepositoryPostgresDelete) setup(t *testing.T) {
 	var (
 		driver          = "postgres"
 		dsn             = "postgres://postgres:@127.0.0.1:5432/postgres?sslmode=disable"
-		sqlDB, errSqlDB = sql.Open(driver, dsn)
-		db, errDB       = NewDB(sqlDB)
+		sqlDB, errSqlDB = database.Open(driver, dsn)
+		db, errDB       = sql.NewDB(sqlDB)
 	)
 	require.Nil(t, errSqlDB, "sqlopen error")
 	require.Nil(t, errDB, "newdb error")
@@ -309,7 +312,7 @@ func TestRepositoryPostgresDelete(test *testing.T) {
 					"deletedkey":  "deletedvalue",
 				},
 			},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_mock",
 					sqlbuilder.NewStruct(new(entityMock)).For(sqlbuilder.PostgreSQL),
 				).NewMapper(),
@@ -322,7 +325,7 @@ func TestRepositoryPostgresDelete(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Delete(scenario.ctx, scenario.key)
 				require.Equal(t, scenario.err, err, "set error")
@@ -330,3 +333,34 @@ func TestRepositoryPostgresDelete(test *testing.T) {
 		)
 	}
 }
+
+func TestReadTablePostgresDiff(test *testing.T) {
+	sqlDB, err := database.Open("postgres", "postgres://postgres:@127.0.0.1:5432/postgres?sslmode=disable")
+	require.Nil(test, err, "sqlopen error")
+	db, err := sql.NewDB(sqlDB)
+	require.Nil(test, err, "newdb error")
+	defer db.Close()
+
+	desired, err := schema.NewTable("schema_entity", schemaEntity{}, schema.DefaultTag)
+	require.Nil(test, err, "new table error")
+	desired.Columns[2].DBType = "varchar(16)"
+	desired.WithPrimaryKey("id").WithIndex(true, "code")
+	_, err = db.Exec("drop table if exists schema_entity")
+	require.Nil(test, err, "drop table error")
+	defer db.Exec("drop table if exists schema_entity")
+
+	missing, err := sql.ReadTable(db, "schema_entity")
+	require.Nil(test, err, "read missing table error")
+	statements, err := schema.Postgres.Diff(missing, desired)
+	require.Nil(test, err, "diff missing table error")
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.Nil(test, err, "create table error")
+	}
+
+	existing, err := sql.ReadTable(db, "schema_entity")
+	require.Nil(test, err, "read table error")
+	statements, err = schema.Postgres.Diff(existing, desired)
+	require.Nil(test, err, "diff error")
+	require.Empty(test, statements, "diff statements")
+}
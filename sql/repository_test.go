@@ -1,4 +1,4 @@
-package sql
+package sql_test
 
 import (
 	"context"
@@ -7,22 +7,25 @@ import (
 	"testing"
 
 	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
 	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNewRepository(test *testing.T) {
-	repository := NewRepository(nil, NewMapperBuilder().NewMapper())
+	repository := sql.NewRepository(nil, sql.NewMapperBuilder().NewMapper())
 	require.NotNil(test, repository, "invalid repository instance")
 }
 
 type testRepositoryGet struct {
 	name   string
 	ctx    context.Context
-	row    *rowMock
-	db     *dbMock
-	mapper Mapper
+	row    *smock.RowMock
+	db     *smock.DBMock
+	mapper sql.Mapper
 	key    raizel.EntityKey
 	result raizel.Entity
 	err    error
@@ -30,8 +33,8 @@ type testRepositoryGet struct {
 
 func (scenario *testRepositoryGet) setup(t *testing.T) {
 	var (
-		row = newRowMock()
-		db  = newDBMock()
+		row = smock.NewRowMock()
+		db  = smock.NewDBMock()
 	)
 	require.NotNil(t, row, "mock row instance")
 	require.NotNil(t, db, "mock db instance")
@@ -55,7 +58,7 @@ func TestRepositoryGet(test *testing.T) {
 				value: "identifier",
 			},
 			result: &entityMock{},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 		},
@@ -67,7 +70,7 @@ func TestRepositoryGet(test *testing.T) {
 				value: "identifier",
 			},
 			result: &entityMock{},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 			err: errors.New("errMock"),
@@ -79,7 +82,7 @@ func TestRepositoryGet(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Get(scenario.ctx, scenario.key, scenario.result)
 				require.Equal(t, scenario.err, err, "get error")
@@ -95,9 +98,9 @@ func TestRepositoryGet(test *testing.T) {
 type testRepositorySet struct {
 	name   string
 	ctx    context.Context
-	result *resultMock
-	db     *dbMock
-	mapper Mapper
+	result *smock.ResultMock
+	db     *smock.DBMock
+	mapper sql.Mapper
 	key    raizel.EntityKey
 	data   raizel.Entity
 	err    error
@@ -105,8 +108,8 @@ type testRepositorySet struct {
 
 func (scenario *testRepositorySet) setup(t *testing.T) {
 	var (
-		result = newResultMock()
-		db     = newDBMock()
+		result = smock.NewResultMock()
+		db     = smock.NewDBMock()
 	)
 	require.NotNil(t, result, "mock result instance")
 	require.NotNil(t, db, "mock db instance")
@@ -130,7 +133,7 @@ func TestRepositorySet(test *testing.T) {
 				value: "identifier",
 			},
 			data: &entityMock{},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 		},
@@ -143,7 +146,7 @@ func TestRepositorySet(test *testing.T) {
 			},
 			data: &entityMock{},
 			err:  errors.New("errMock"),
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 		},
@@ -154,7 +157,7 @@ func TestRepositorySet(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Set(scenario.ctx, scenario.key, scenario.data)
 				require.Equal(t, scenario.err, err, "set error")
@@ -170,9 +173,9 @@ func TestRepositorySet(test *testing.T) {
 type testRepositoryDelete struct {
 	name   string
 	ctx    context.Context
-	result *resultMock
-	db     *dbMock
-	mapper Mapper
+	result *smock.ResultMock
+	db     *smock.DBMock
+	mapper sql.Mapper
 	key    raizel.EntityKey
 	data   raizel.Entity
 	err    error
@@ -180,8 +183,8 @@ type testRepositoryDelete struct {
 
 func (scenario *testRepositoryDelete) setup(t *testing.T) {
 	var (
-		result = newResultMock()
-		db     = newDBMock()
+		result = smock.NewResultMock()
+		db     = smock.NewDBMock()
 	)
 	require.NotNil(t, result, "mock result instance")
 	require.NotNil(t, db, "mock db instance")
@@ -204,7 +207,7 @@ func TestRepositoryDelete(test *testing.T) {
 				name:  "id",
 				value: "identifier",
 			},
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 		},
@@ -216,7 +219,7 @@ func TestRepositoryDelete(test *testing.T) {
 				value: "identifier",
 			},
 			err: errors.New("errMock"),
-			mapper: NewMapperBuilder().
+			mapper: sql.NewMapperBuilder().
 				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
 				NewMapper(),
 		},
@@ -227,7 +230,7 @@ func TestRepositoryDelete(test *testing.T) {
 			func(t *testing.T) {
 				scenario.setup(t)
 
-				repository := NewRepository(scenario.db, scenario.mapper)
+				repository := sql.NewRepository(scenario.db, scenario.mapper)
 				require.NotNil(t, repository, "repository instance")
 				err := repository.Delete(scenario.ctx, scenario.key)
 				require.Equal(t, scenario.err, err, "set error")
@@ -238,3 +241,247 @@ func TestRepositoryDelete(test *testing.T) {
 		)
 	}
 }
+
+type testTenantRepository struct {
+	name    string
+	ctx     context.Context
+	result  *smock.ResultMock
+	db      *smock.DBMock
+	mapper  sql.Mapper
+	tenancy sql.TenantStrategy
+	key     raizel.EntityKey
+	sql     string
+	args    []interface{}
+	err     error
+}
+
+func (scenario *testTenantRepository) setup(t *testing.T) {
+	var (
+		result = smock.NewResultMock()
+		db     = smock.NewDBMock()
+	)
+	require.NotNil(t, result, "mock result instance")
+	require.NotNil(t, db, "mock db instance")
+
+	db.On("Exec", scenario.sql, scenario.args).Return(result, nil)
+	db.On("Close").Return(nil)
+
+	scenario.db = db
+}
+
+func TestTenantRepository(test *testing.T) {
+	scenarios := []testTenantRepository{
+		{
+			name:    "Delete entity from the tenant schema",
+			ctx:     raizel.WithTenant(context.Background(), "tenant_a"),
+			tenancy: sql.SchemaTenancy,
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			sql:  `DELETE FROM "tenant_a".entity_table WHERE id = ?`,
+			args: []interface{}{"identifier"},
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name:    "Delete entity filtered by the tenant column",
+			ctx:     raizel.WithTenant(context.Background(), "tenant_a"),
+			tenancy: sql.ColumnTenancy,
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			sql:  `DELETE FROM entity_table WHERE id = ? AND tenant_id = ?`,
+			args: []interface{}{"identifier", "tenant_a"},
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name:    "Delete entity with a composite key filtered by the tenant column",
+			ctx:     raizel.WithTenant(context.Background(), "tenant_a"),
+			tenancy: sql.ColumnTenancy,
+			key: raizel.NewCompositeKey(
+				"entity_table", []string{"id", "name"}, []interface{}{"identifier", "mock"},
+			),
+			sql:  `DELETE FROM entity_table WHERE id = ? AND name = ? AND tenant_id = ?`,
+			args: []interface{}{"identifier", "mock", "tenant_a"},
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+		{
+			name:    "Error when try to Delete an entity without tenant",
+			ctx:     context.Background(),
+			tenancy: sql.SchemaTenancy,
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: "identifier",
+			},
+			err: raizel.ErrTenantRequired,
+			mapper: sql.NewMapperBuilder().
+				Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+				NewMapper(),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				repository := sql.NewTenantRepository(scenario.db, scenario.mapper, scenario.tenancy)
+				require.NotNil(t, repository, "repository instance")
+				err := repository.Delete(scenario.ctx, scenario.key)
+				require.Equal(t, scenario.err, err, "delete error")
+				repository.Close(scenario.ctx)
+				if scenario.err == nil {
+					scenario.db.AssertExpectations(t)
+				} else {
+					scenario.db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything)
+				}
+			},
+		)
+	}
+}
+
+type tenantEntityMock struct {
+	ID     int    `db:"id"`
+	Tenant string `db:"tenant_id"`
+	Name   string `db:"name"`
+}
+
+func TestTenantRepositorySet(test *testing.T) {
+	var (
+		ctx    = raizel.WithTenant(context.Background(), "tenant_a")
+		key    = entityKeyMock{table: "entity_table", name: "id", value: 1}
+		db     = smock.NewDBMock()
+		mapper = sql.NewMapperBuilder().
+			Set("entity_table", sqlbuilder.NewStruct(new(tenantEntityMock))).
+			Set("untenanted_table", sqlbuilder.NewStruct(new(entityMock))).
+			NewMapper()
+		repository = sql.NewTenantRepository(db, mapper, sql.ColumnTenancy)
+		entity     = tenantEntityMock{ID: 1, Tenant: "tenant_b", Name: "mock"}
+	)
+	db.On(
+		"Exec", "INSERT INTO entity_table (id, tenant_id, name) VALUES (?, ?, ?)",
+		[]interface{}{1, "tenant_a", "mock"},
+	).Return(smock.NewResultMock(), nil)
+
+	require.Nil(test, repository.Set(ctx, key, &entity), "set error")
+	require.Equal(test, "tenant_b", entity.Tenant, "entity tenant")
+
+	var (
+		conflictKey = entityKeyMock{table: "entity_table", name: "id", value: 2}
+		notUpdated  = smock.NewResultMock()
+	)
+	notUpdated.On("RowsAffected").Return(int64(0), nil)
+	db.On(
+		"Exec", "INSERT INTO entity_table (id, tenant_id, name) VALUES (?, ?, ?)",
+		[]interface{}{2, "tenant_a", "conflict"},
+	).Return(nil, &pq.Error{Code: "23505"})
+	db.On(
+		"Exec", "UPDATE entity_table SET id = ?, tenant_id = ?, name = ? WHERE id = ? AND tenant_id = ?",
+		[]interface{}{2, "tenant_a", "conflict", 2, "tenant_a"},
+	).Return(notUpdated, nil)
+	err := repository.Set(ctx, conflictKey, &tenantEntityMock{ID: 2, Name: "conflict"})
+	require.True(test, errors.Is(err, sql.ErrKeyConflict), "other tenant key error")
+
+	err = repository.Set(ctx, entityKeyMock{table: "untenanted_table", name: "id", value: 1}, &entityMock{ID: 1})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "untenanted entity error")
+	db.AssertExpectations(test)
+}
+
+type describedEntity struct {
+	_        struct{} `raizel:"described_table,entity"`
+	ID       string   `raizel:"id,key"`
+	FullName string
+}
+
+func TestDescribedRepository(test *testing.T) {
+	_, err := raizel.RegisterEntity(&describedEntity{})
+	require.Nil(test, err, "register error")
+	var (
+		ctx        = context.Background()
+		key        = raizel.NewDynamicKey("described_table", "id", "mock1")
+		row        = smock.NewRowMock()
+		db         = smock.NewDBMock()
+		result     = smock.NewResultMock()
+		repository = sql.NewRepository(db, sql.NewMapperBuilder().NewMapper())
+	)
+	db.On(
+		"Exec", "INSERT INTO described_table (id, full_name) VALUES (?, ?)", []interface{}{"mock1", "mock name"},
+	).Return(result, nil)
+	db.On(
+		"QueryRow", "SELECT id, full_name FROM described_table WHERE id = ?",
+		[]interface{}{"mock1"},
+	).Return(row)
+	row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		dest := args.Get(0).([]interface{})
+		*dest[0].(*string) = "mock1"
+		*dest[1].(*string) = "mock name"
+	}).Return(nil)
+
+	err = repository.Set(ctx, key, &describedEntity{ID: "mock1", FullName: "mock name"})
+	require.Nil(test, err, "set error")
+	var entity describedEntity
+	err = repository.Get(ctx, key, &entity)
+	require.Nil(test, err, "get error")
+	require.Equal(test, describedEntity{ID: "mock1", FullName: "mock name"}, entity, "get entity")
+	db.AssertExpectations(test)
+	row.AssertExpectations(test)
+}
+
+type validatedEntityMock struct {
+	entityMock
+}
+
+func (entity *validatedEntityMock) Validate() error {
+	if entity.Name == "" {
+		return raizel.ErrInvalidArgument
+	}
+	return nil
+}
+
+func TestRepositorySetValidate(test *testing.T) {
+	var (
+		db     = smock.NewDBMock()
+		mapper = sql.NewMapperBuilder().
+			Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+			NewMapper()
+		repository = sql.NewRepository(db, mapper)
+		key        = entityKeyMock{table: "entity_table", name: "id", value: 1}
+	)
+	err := repository.Set(context.Background(), key, &validatedEntityMock{entityMock{ID: 1}})
+	require.Equal(test, raizel.ErrInvalidArgument, err, "validate error")
+	db.AssertNotCalled(test, "Exec", mock.Anything, mock.Anything)
+}
+
+func TestRepositoryUnmappedEntity(test *testing.T) {
+	var (
+		ctx        = context.Background()
+		db         = smock.NewDBMock()
+		repository = sql.NewRepository(db, sql.NewMapperBuilder().NewMapper())
+		key        = entityKeyMock{table: "unmapped_table", name: "id", value: 1}
+		query      = raizel.Query{EntityName: "unmapped_table"}
+	)
+	err := repository.Get(ctx, key, &entityMock{})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "get error")
+	err = repository.Set(ctx, key, &entityMock{ID: 1})
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "set error")
+	err = repository.Delete(ctx, key)
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "delete error")
+	_, err = repository.Query(ctx, query)
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "query error")
+	_, err = repository.Count(ctx, query)
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "count error")
+	err = repository.Patch(ctx, key, raizel.Assign("name", "mock"))
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "patch error")
+	db.AssertNotCalled(test, "Exec", mock.Anything, mock.Anything)
+	db.AssertNotCalled(test, "QueryRow", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,108 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel/schema"
+)
+
+const (
+	columnsQuery = `select column_name, data_type, udt_name, coalesce(character_maximum_length, 0), is_nullable
+from information_schema.columns where table_name = $1 order by ordinal_position`
+	primaryKeyQuery = `select kcu.column_name from information_schema.table_constraints tc
+join information_schema.key_column_usage kcu
+    on kcu.constraint_name = tc.constraint_name and kcu.table_name = tc.table_name
+where tc.table_name = $1 and tc.constraint_type = 'PRIMARY KEY' order by kcu.ordinal_position`
+	indexesQuery = `select i.relname, ix.indisunique, a.attname from pg_class t
+join pg_index ix on ix.indrelid = t.oid
+join pg_class i on i.oid = ix.indexrelid
+join pg_attribute a on a.attrelid = t.oid and a.attnum = any(ix.indkey)
+where t.relname = $1 and not ix.indisprimary
+order by i.relname, array_position(ix.indkey::int2[], a.attnum)`
+)
+
+// ReadTable reads the columns, primary key and indexes of an existing postgres
+// table, the result is the existing table of schema.Postgres.Diff. A missing
+// table returns a table without columns, that Diff creates.
+func ReadTable(db DB, name string) (*schema.Table, error) {
+	table := &schema.Table{Name: name}
+	err := scanRows(db, columnsQuery, name, func(rows Rows) error {
+		var (
+			column, dataType, udtName, nullable string
+			length                              int64
+		)
+		if err := rows.Scan(&column, &dataType, &udtName, &length, &nullable); err != nil {
+			return err
+		}
+		table.Columns = append(table.Columns, schema.Column{
+			Name: column, DBType: columnType(dataType, udtName, length), Nullable: nullable == "YES",
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(db, primaryKeyQuery, name, func(rows Rows) error {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return err
+		}
+		table.PrimaryKey = append(table.PrimaryKey, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(db, indexesQuery, name, func(rows Rows) error {
+		var (
+			index, column string
+			unique        bool
+		)
+		if err := rows.Scan(&index, &unique, &column); err != nil {
+			return err
+		}
+		last := len(table.Indexes) - 1
+		if last < 0 || table.Indexes[last].Name != index {
+			table.Indexes = append(table.Indexes, schema.Index{Name: index, Unique: unique})
+			last++
+		}
+		table.Indexes[last].Columns = append(table.Indexes[last].Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// columnType returns the type of an information_schema column, arrays are
+// typed by their element udt name, like int8[], and the character types keep
+// their length.
+func columnType(dataType, udtName string, length int64) string {
+	switch dataType {
+	case "ARRAY":
+		return strings.TrimPrefix(udtName, "_") + "[]"
+	case "USER-DEFINED":
+		return udtName
+	case "character varying", "character":
+		if length > 0 {
+			return fmt.Sprintf("%s(%d)", dataType, length)
+		}
+	}
+	return dataType
+}
+
+func scanRows(db DB, query string, name string, scan func(Rows) error) error {
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
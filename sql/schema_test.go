@@ -0,0 +1,160 @@
+package sql_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel/schema"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newScanRowsMock(values ...[]interface{}) *smock.RowsMock {
+	rows := smock.NewRowsMock()
+	for _, row := range values {
+		row := row
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything).Return(nil).Once().Run(func(args mock.Arguments) {
+			dest := args.Get(0).([]interface{})
+			for index, value := range row {
+				switch target := dest[index].(type) {
+				case *string:
+					*target = value.(string)
+				case *bool:
+					*target = value.(bool)
+				case *int64:
+					*target = int64(value.(int))
+				}
+			}
+		})
+	}
+	rows.On("Next").Return(false)
+	rows.On("Err").Return(nil)
+	rows.On("Close").Return(nil)
+	return rows
+}
+
+func TestReadTable(test *testing.T) {
+	db := smock.NewDBMock()
+	db.On("Query", sql.ColumnsQuery, []interface{}{"mock_entity"}).Return(
+		newScanRowsMock(
+			[]interface{}{"id", "text", "text", 0, "NO"},
+			[]interface{}{"string", "character varying", "varchar", 64, "YES"},
+			[]interface{}{"integer", "integer", "int4", 0, "NO"},
+			[]interface{}{"tags", "ARRAY", "_text", 0, "YES"},
+		), nil,
+	)
+	db.On("Query", sql.PrimaryKeyQuery, []interface{}{"mock_entity"}).Return(
+		newScanRowsMock([]interface{}{"id"}), nil,
+	)
+	db.On("Query", sql.IndexesQuery, []interface{}{"mock_entity"}).Return(
+		newScanRowsMock(
+			[]interface{}{"ix_mock_entity_integer_string", true, "integer"},
+			[]interface{}{"ix_mock_entity_integer_string", true, "string"},
+			[]interface{}{"ix_mock_entity_string", false, "string"},
+		), nil,
+	)
+
+	table, err := sql.ReadTable(db, "mock_entity")
+	require.Nil(test, err, "read table error")
+	require.Equal(
+		test,
+		&schema.Table{
+			Name: "mock_entity",
+			Columns: []schema.Column{
+				{Name: "id", DBType: "text"},
+				{Name: "string", DBType: "character varying(64)", Nullable: true},
+				{Name: "integer", DBType: "integer"},
+				{Name: "tags", DBType: "text[]", Nullable: true},
+			},
+			PrimaryKey: []string{"id"},
+			Indexes: []schema.Index{
+				{Name: "ix_mock_entity_integer_string", Columns: []string{"integer", "string"}, Unique: true},
+				{Name: "ix_mock_entity_string", Columns: []string{"string"}},
+			},
+		},
+		table,
+		"table",
+	)
+	db.AssertExpectations(test)
+}
+
+func TestReadTableError(test *testing.T) {
+	db := smock.NewDBMock()
+	db.On("Query", sql.ColumnsQuery, []interface{}{"mock_entity"}).Return(nil, errors.New("errMock"))
+
+	table, err := sql.ReadTable(db, "mock_entity")
+	require.Equal(test, errors.New("errMock"), err, "read table error")
+	require.Nil(test, table, "table")
+}
+
+type schemaEntity struct {
+	ID        string            `db:"id"`
+	Name      *string           `db:"name"`
+	Code      string            `db:"code"`
+	Tags      []string          `db:"tags"`
+	Scores    []int64           `db:"scores"`
+	Data      map[string]string `db:"data"`
+	Payload   []byte            `db:"payload"`
+	Count     int32             `db:"count"`
+	CreatedAt time.Time         `db:"created_at"`
+}
+
+// TestReadTableDiff reads the information_schema rows postgres returns for
+// the created table, the diff of the read and the created tables is empty.
+func TestReadTableDiff(test *testing.T) {
+	desired, err := schema.NewTable("schema_entity", schemaEntity{}, schema.DefaultTag)
+	require.Nil(test, err, "new table error")
+	desired.Columns[2].DBType = "varchar(16)"
+	desired.WithPrimaryKey("id").WithIndex(true, "code")
+	_, err = schema.Postgres.CreateTable(desired)
+	require.Nil(test, err, "create table error")
+
+	db := smock.NewDBMock()
+	db.On("Query", sql.ColumnsQuery, []interface{}{"schema_entity"}).Return(
+		newScanRowsMock(
+			[]interface{}{"id", "text", "text", 0, "NO"},
+			[]interface{}{"name", "text", "text", 0, "YES"},
+			[]interface{}{"code", "character varying", "varchar", 16, "NO"},
+			[]interface{}{"tags", "ARRAY", "_text", 0, "NO"},
+			[]interface{}{"scores", "ARRAY", "_int8", 0, "NO"},
+			[]interface{}{"data", "jsonb", "jsonb", 0, "NO"},
+			[]interface{}{"payload", "bytea", "bytea", 0, "NO"},
+			[]interface{}{"count", "integer", "int4", 0, "NO"},
+			[]interface{}{"created_at", "timestamp without time zone", "timestamp", 0, "NO"},
+		), nil,
+	)
+	db.On("Query", sql.PrimaryKeyQuery, []interface{}{"schema_entity"}).Return(
+		newScanRowsMock([]interface{}{"id"}), nil,
+	)
+	db.On("Query", sql.IndexesQuery, []interface{}{"schema_entity"}).Return(
+		newScanRowsMock([]interface{}{"ix_schema_entity_code", true, "code"}), nil,
+	)
+
+	existing, err := sql.ReadTable(db, "schema_entity")
+	require.Nil(test, err, "read table error")
+	statements, err := schema.Postgres.Diff(existing, desired)
+	require.Nil(test, err, "diff error")
+	require.Empty(test, statements, "diff statements")
+}
+
+func TestReadTableMissing(test *testing.T) {
+	db := smock.NewDBMock()
+	for _, query := range []string{sql.ColumnsQuery, sql.PrimaryKeyQuery, sql.IndexesQuery} {
+		db.On("Query", query, []interface{}{"schema_entity"}).Return(newScanRowsMock(), nil)
+	}
+	desired, err := schema.NewTable("schema_entity", schemaEntity{}, schema.DefaultTag)
+	require.Nil(test, err, "new table error")
+	desired.WithPrimaryKey("id")
+
+	existing, err := sql.ReadTable(db, "schema_entity")
+	require.Nil(test, err, "read table error")
+	statements, err := schema.Postgres.Diff(existing, desired)
+	require.Nil(test, err, "diff error")
+	create, err := schema.Postgres.CreateTable(desired)
+	require.Nil(test, err, "create table error")
+	require.Equal(test, create, statements, "diff statements")
+}
@@ -3,10 +3,16 @@ package sql
 import (
 	"database/sql"
 	"errors"
+
+	"github.com/lib/pq"
 )
 
 var (
-	ErrBlankDB = errors.New("err_blankdb")
+	ErrBlankDB        = errors.New("err_blankdb")
+	ErrBlankListener  = errors.New("err_blanklistener")
+	ErrInvalidFilter  = errors.New("err_invalidfilter")
+	ErrUnmappedColumn = errors.New("err_unmappedcolumn")
+	ErrKeyConflict    = errors.New("err_keyconflict")
 )
 
 type DB interface {
@@ -32,6 +38,7 @@ type Rows interface {
 	Row
 	Next() bool
 	Err() error
+	Close() error
 }
 
 type Result interface {
@@ -39,6 +46,15 @@ type Result interface {
 	RowsAffected() (int64, error)
 }
 
+type Notification = pq.Notification
+
+type Listener interface {
+	Listen(string) error
+	Unlisten(string) error
+	Notifications() <-chan *Notification
+	Close() error
+}
+
 type db struct {
 	*sql.DB
 }
@@ -65,3 +81,18 @@ func NewDB(sqlDB *sql.DB) (DB, error) {
 	}
 	return &db{DB: sqlDB}, nil
 }
+
+type listener struct {
+	*pq.Listener
+}
+
+func (l *listener) Notifications() <-chan *Notification {
+	return l.Listener.Notify
+}
+
+func NewListener(pqListener *pq.Listener) (Listener, error) {
+	if pqListener == nil {
+		return nil, ErrBlankListener
+	}
+	return &listener{Listener: pqListener}, nil
+}
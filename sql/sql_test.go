@@ -1,19 +1,20 @@
-package sql
+package sql_test
 
 import (
-	"database/sql"
+	database "database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/rjansen/raizel/sql"
 	"github.com/stretchr/testify/require"
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
 type testDB struct {
 	name string
-	db   *sql.DB
+	db   *database.DB
 	err  error
 }
 
@@ -45,7 +46,7 @@ func TestDB(test *testing.T) {
 		},
 		{
 			name: "Returns error because db is blank",
-			err:  ErrBlankDB,
+			err:  sql.ErrBlankDB,
 		},
 	}
 
@@ -56,7 +57,7 @@ func TestDB(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				db, err := NewDB(scenario.db)
+				db, err := sql.NewDB(scenario.db)
 				require.Equal(t, scenario.err, err, "newDB error")
 				if scenario.err == nil {
 					require.NotNil(t, db, "db instance")
@@ -72,7 +73,7 @@ func TestDB(test *testing.T) {
 
 type testQuery struct {
 	name      string
-	db        *sql.DB
+	db        *database.DB
 	sqlMock   sqlmock.Sqlmock
 	query     string
 	arguments []interface{}
@@ -150,7 +151,7 @@ func TestQuery(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				db, err := NewDB(scenario.db)
+				db, err := sql.NewDB(scenario.db)
 				require.Nil(t, err, "newDB error")
 				require.NotNil(t, db, "db instance")
 				rows, err := db.Query(scenario.query, scenario.arguments...)
@@ -183,7 +184,7 @@ func TestQuery(test *testing.T) {
 
 type testQueryRow struct {
 	name      string
-	db        *sql.DB
+	db        *database.DB
 	sqlMock   sqlmock.Sqlmock
 	query     string
 	arguments []interface{}
@@ -247,7 +248,7 @@ func TestQueryRow(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				db, err := NewDB(scenario.db)
+				db, err := sql.NewDB(scenario.db)
 				require.Nil(t, err, "newDB error")
 				require.NotNil(t, db, "db instance")
 				row := db.QueryRow(scenario.query, scenario.arguments...)
@@ -273,7 +274,7 @@ func TestQueryRow(test *testing.T) {
 
 type testExec struct {
 	name         string
-	db           *sql.DB
+	db           *database.DB
 	sqlMock      sqlmock.Sqlmock
 	query        string
 	arguments    []interface{}
@@ -332,7 +333,7 @@ func TestExec(test *testing.T) {
 				scenario.setup(t)
 				defer scenario.tearDown(t)
 
-				db, err := NewDB(scenario.db)
+				db, err := sql.NewDB(scenario.db)
 				require.Nil(t, err, "newDB error")
 				require.NotNil(t, db, "db instance")
 				result, err := db.Exec(scenario.query, scenario.arguments...)
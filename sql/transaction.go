@@ -0,0 +1,94 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rjansen/raizel"
+)
+
+// Tx is a DB transaction.
+type Tx interface {
+	Query(string, ...interface{}) (Rows, error)
+	QueryRow(string, ...interface{}) Row
+	Exec(string, ...interface{}) (Result, error)
+	Commit() error
+	Rollback() error
+}
+
+// Beginner is implemented by the DBs that begin transactions, like the DB
+// returned by NewDB.
+type Beginner interface {
+	Begin() (Tx, error)
+}
+
+type tx struct {
+	*sql.Tx
+}
+
+func (tx *tx) Query(sql string, arguments ...interface{}) (Rows, error) {
+	rows, err := tx.Tx.Query(sql, arguments...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (tx *tx) QueryRow(sql string, arguments ...interface{}) Row {
+	return tx.Tx.QueryRow(sql, arguments...)
+}
+
+func (tx *tx) Exec(sql string, arguments ...interface{}) (Result, error) {
+	return tx.Tx.Exec(sql, arguments...)
+}
+
+func (db *db) Begin() (Tx, error) {
+	sqlTx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: sqlTx}, nil
+}
+
+// txDB runs the statements of a repository in a transaction, the
+// transaction is committed or rolled back by Transaction instead of Close.
+type txDB struct {
+	Tx
+}
+
+func (db txDB) Ping() error {
+	return nil
+}
+
+func (db txDB) Close() error {
+	return nil
+}
+
+// Transaction runs fn with a repository of the same mapper and tenancy over
+// a transaction of the DB, committed when fn returns nil and rolled back
+// otherwise. The DB must implement Beginner or
+// raizel.ErrTransactionUnsupported is returned.
+func (repository repository) Transaction(ctx context.Context, fn func(context.Context, raizel.Repository) error) (err error) {
+	beginner, ok := repository.db.(Beginner)
+	if !ok {
+		return raizel.ErrTransactionUnsupported
+	}
+	transaction, err := beginner.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = transaction.Rollback()
+			panic(recovered)
+		}
+		if err != nil {
+			_ = transaction.Rollback()
+		}
+	}()
+	repository.db = txDB{Tx: transaction}
+	if err = fn(ctx, repository); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
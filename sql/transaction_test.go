@@ -0,0 +1,132 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepositoryTransaction struct {
+	name     string
+	beginErr error
+	fnErr    error
+	commit   bool
+	rollback bool
+	err      error
+}
+
+func TestRepositoryTransaction(test *testing.T) {
+	var (
+		key    = entityKeyMock{table: "entity_table", name: "id", value: 1}
+		mapper = sql.NewMapperBuilder().
+			Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+			NewMapper()
+		fnErr = errors.New("err_fn")
+	)
+	scenarios := []testRepositoryTransaction{
+		{
+			name:   "Commits the statements of the transaction",
+			commit: true,
+		},
+		{
+			name:     "Rolls back when the function fails",
+			fnErr:    fnErr,
+			rollback: true,
+			err:      fnErr,
+		},
+		{
+			name:     "Fails when the transaction does not begin",
+			beginErr: errors.New("err_begin"),
+			err:      errors.New("err_begin"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					db = new(smock.DBMock)
+					tx = new(smock.TxMock)
+				)
+				if scenario.beginErr != nil {
+					db.On("Begin").Return(nil, scenario.beginErr)
+				} else {
+					db.On("Begin").Return(tx, nil)
+				}
+				tx.On("Exec", mock.AnythingOfType("string"), mock.Anything).Return(smock.NewResultMock(), nil)
+				tx.On("Commit").Return(nil)
+				tx.On("Rollback").Return(nil)
+
+				err := raizel.Transaction(
+					context.Background(), sql.NewRepository(db, mapper),
+					func(ctx context.Context, repository raizel.Repository) error {
+						if err := repository.Delete(ctx, key); err != nil {
+							return err
+						}
+						return scenario.fnErr
+					},
+				)
+				require.Equal(t, scenario.err, err, "transaction error")
+				db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything)
+				if scenario.beginErr == nil {
+					tx.AssertCalled(t, "Exec", mock.AnythingOfType("string"), mock.Anything)
+				}
+				if scenario.commit {
+					tx.AssertCalled(t, "Commit")
+				} else {
+					tx.AssertNotCalled(t, "Commit")
+				}
+				if scenario.rollback {
+					tx.AssertCalled(t, "Rollback")
+				} else {
+					tx.AssertNotCalled(t, "Rollback")
+				}
+			},
+		)
+	}
+
+	err := raizel.Transaction(context.Background(), sql.NewRepository(dbOnly{smock.NewDBMock()}, mapper), nil)
+	require.Equal(test, raizel.ErrTransactionUnsupported, err, "unsupported transaction error")
+}
+
+func TestRepositoryTransactionSet(test *testing.T) {
+	var (
+		key    = entityKeyMock{table: "entity_table", name: "id", value: 1}
+		mapper = sql.NewMapperBuilder().
+			Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+			NewMapper()
+		db = new(smock.DBMock)
+		tx = new(smock.TxMock)
+	)
+	db.On("Begin").Return(tx, nil)
+	tx.On("Exec", "SAVEPOINT raizel_set", mock.Anything).Return(smock.NewResultMock(), nil).Once()
+	tx.On("Exec", mock.MatchedBy(func(sql string) bool { return strings.HasPrefix(sql, "INSERT INTO entity_table") }), mock.Anything).
+		Return(nil, &pq.Error{Code: "23505"}).Once()
+	tx.On("Exec", "ROLLBACK TO SAVEPOINT raizel_set", mock.Anything).Return(smock.NewResultMock(), nil).Once()
+	updated := smock.NewResultMock()
+	updated.On("RowsAffected").Return(int64(1), nil)
+	tx.On("Exec", mock.MatchedBy(func(sql string) bool { return strings.HasPrefix(sql, "UPDATE entity_table") }), mock.Anything).
+		Return(updated, nil).Once()
+	tx.On("Exec", "RELEASE SAVEPOINT raizel_set", mock.Anything).Return(smock.NewResultMock(), nil).Once()
+	tx.On("Commit").Return(nil)
+
+	err := raizel.Transaction(
+		context.Background(), sql.NewRepository(db, mapper),
+		func(ctx context.Context, repository raizel.Repository) error {
+			return repository.Set(ctx, key, &entityMock{ID: 1, Name: "updated"})
+		},
+	)
+	require.Nil(test, err, "transaction error")
+	tx.AssertExpectations(test)
+	tx.AssertNotCalled(test, "Rollback")
+}
@@ -0,0 +1,329 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	watchPrefix = "raizel_watch_"
+)
+
+// WatchChannel returns the notification channel used by the watch trigger
+// of the entity table.
+func WatchChannel(entityName string) string {
+	return watchPrefix + entityName
+}
+
+// quoteLiteral quotes the value as a string literal, the trigger statements
+// assume standard_conforming_strings.
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// WatchTriggerSQL returns the statements that create the trigger notifying the
+// changes of table to WatchChannel(table). The notification carries the
+// operation and the keyColumn value, the changed row is read by the watcher.
+// The names are quoted, table and keyColumn must match their case.
+func WatchTriggerSQL(table, keyColumn string) string {
+	var (
+		name    = pq.QuoteIdentifier(watchPrefix + table)
+		channel = quoteLiteral(WatchChannel(table))
+		payload = func(record string) string {
+			return fmt.Sprintf(
+				"json_build_object('operation', TG_OP, 'key_name', %s, 'key', %s.%s)::text",
+				quoteLiteral(keyColumn), record, pq.QuoteIdentifier(keyColumn),
+			)
+		}
+	)
+	return fmt.Sprintf(`create or replace function %[1]s()
+returns trigger as $$
+begin
+   if (TG_OP = 'DELETE') then
+      perform pg_notify(%[5]s, %[2]s);
+      return old;
+   end if;
+   perform pg_notify(%[5]s, %[3]s);
+   return new;
+end;
+$$ language 'plpgsql'
+;
+drop trigger if exists %[1]s on %[4]s
+;
+create trigger %[1]s after insert or update or delete on %[4]s for each row execute procedure %[1]s()
+;`, name, payload("old"), payload("new"), pq.QuoteIdentifier(table), channel)
+}
+
+// DropWatchTriggerSQL returns the statements that remove the trigger created
+// by WatchTriggerSQL.
+func DropWatchTriggerSQL(table string) string {
+	name := pq.QuoteIdentifier(watchPrefix + table)
+	return fmt.Sprintf(`drop trigger if exists %[1]s on %[2]s
+;
+drop function if exists %[1]s()
+;`, name, pq.QuoteIdentifier(table))
+}
+
+type notificationPayload struct {
+	Operation string      `json:"operation"`
+	KeyName   string      `json:"key_name"`
+	Key       interface{} `json:"key"`
+}
+
+type change struct {
+	ctx        context.Context
+	kind       raizel.ChangeKind
+	key        raizel.EntityKey
+	repository raizel.Repository
+}
+
+func (c change) Kind() raizel.ChangeKind {
+	return c.kind
+}
+
+func (c change) Key() raizel.EntityKey {
+	return c.key
+}
+
+func (c change) DataTo(entity raizel.Entity) error {
+	if c.kind == raizel.EntityRemoved {
+		return raizel.ErrNotFound
+	}
+	return c.repository.Get(c.ctx, c.key, entity)
+}
+
+type watcher struct {
+	listener   Listener
+	repository raizel.Repository
+
+	mu          sync.Mutex
+	streams     map[string]map[*changeStream]struct{}
+	dispatching bool
+}
+
+// NewWatcher returns a raizel.Watcher fed by the postgres notifications sent
+// by the WatchTriggerSQL triggers, repository reads the changed entities.
+func NewWatcher(listener Listener, repository raizel.Repository) raizel.Watcher {
+	return &watcher{
+		listener:   listener,
+		repository: repository,
+		streams:    make(map[string]map[*changeStream]struct{}),
+	}
+}
+
+func (w *watcher) Watch(ctx context.Context, entityName string) (raizel.ChangeStream, error) {
+	return w.watch(ctx, entityName, nil)
+}
+
+func (w *watcher) WatchKey(ctx context.Context, key raizel.EntityKey) (raizel.ChangeStream, error) {
+	return w.watch(ctx, key.EntityName(), key)
+}
+
+func (w *watcher) watch(ctx context.Context, entityName string, key raizel.EntityKey) (raizel.ChangeStream, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	channel := WatchChannel(entityName)
+	streams, listening := w.streams[channel]
+	if !listening {
+		if err := w.listener.Listen(channel); err != nil {
+			return nil, err
+		}
+		streams = make(map[*changeStream]struct{})
+		w.streams[channel] = streams
+	}
+	stream := &changeStream{
+		ctx:        ctx,
+		channel:    channel,
+		entityName: entityName,
+		key:        key,
+		watcher:    w,
+		notify:     make(chan struct{}, 1),
+	}
+	streams[stream] = struct{}{}
+	if !w.dispatching {
+		w.dispatching = true
+		go w.dispatch()
+	}
+	return stream, nil
+}
+
+func (w *watcher) unwatch(stream *changeStream) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	streams, listening := w.streams[stream.channel]
+	if !listening {
+		return
+	}
+	delete(streams, stream)
+	if len(streams) == 0 {
+		delete(w.streams, stream.channel)
+		_ = w.listener.Unlisten(stream.channel)
+	}
+}
+
+// keyValue converts the notification key to the type of like, the watched
+// key value. A json number without like is an int64 when it is an integer
+// and a float64 otherwise.
+func keyValue(key interface{}, like interface{}) (interface{}, error) {
+	number, isNumber := key.(json.Number)
+	if !isNumber {
+		return key, nil
+	}
+	if like == nil {
+		if value, err := number.Int64(); err == nil {
+			return value, nil
+		}
+		return number.Float64()
+	}
+	var (
+		likeType = reflect.TypeOf(like)
+		value    = reflect.New(likeType).Elem()
+	)
+	switch likeType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(number.String(), 10, likeType.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(number.String(), 10, likeType.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(number.String(), likeType.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetFloat(parsed)
+	case reflect.String:
+		value.SetString(number.String())
+	default:
+		return nil, fmt.Errorf("%w: key type %s", raizel.ErrInvalidArgument, likeType)
+	}
+	return value.Interface(), nil
+}
+
+func (w *watcher) dispatch() {
+	for notification := range w.listener.Notifications() {
+		// pq sends a nil notification after the connection is reestablished
+		if notification == nil {
+			continue
+		}
+		var (
+			payload notificationPayload
+			decoder = json.NewDecoder(strings.NewReader(notification.Extra))
+		)
+		// the numbers are decoded as json.Number, a float64 loses the
+		// precision of the int64 keys above 2^53
+		decoder.UseNumber()
+		if err := decoder.Decode(&payload); err != nil {
+			continue
+		}
+		kind := raizel.EntityModified
+		switch payload.Operation {
+		case "INSERT":
+			kind = raizel.EntityAdded
+		case "DELETE":
+			kind = raizel.EntityRemoved
+		}
+		w.mu.Lock()
+		for stream := range w.streams[notification.Channel] {
+			var like interface{}
+			if stream.key != nil {
+				like = stream.key.Value()
+			}
+			key, err := keyValue(payload.Key, like)
+			if err != nil || (stream.key != nil && !reflect.DeepEqual(key, like)) {
+				continue
+			}
+			stream.push(change{
+				ctx:        stream.ctx,
+				kind:       kind,
+				key:        raizel.NewDynamicKey(stream.entityName, payload.KeyName, key),
+				repository: w.repository,
+			})
+		}
+		w.mu.Unlock()
+	}
+	w.mu.Lock()
+	w.dispatching = false
+	for _, streams := range w.streams {
+		for stream := range streams {
+			stream.stop()
+		}
+	}
+	w.mu.Unlock()
+}
+
+type changeStream struct {
+	ctx        context.Context
+	channel    string
+	entityName string
+	key        raizel.EntityKey
+	watcher    *watcher
+
+	mu      sync.Mutex
+	pending []raizel.Change
+	stopped bool
+	notify  chan struct{}
+}
+
+func (s *changeStream) push(c raizel.Change) {
+	s.mu.Lock()
+	s.pending = append(s.pending, c)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *changeStream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *changeStream) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.pending = nil
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *changeStream) Next() (raizel.Change, error) {
+	for {
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			return nil, raizel.ErrWatchStopped
+		}
+		if len(s.pending) > 0 {
+			next := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+			return next, nil
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+func (s *changeStream) Stop() {
+	s.watcher.unwatch(s)
+	s.stop()
+}
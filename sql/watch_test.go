@@ -0,0 +1,180 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sqlbuilder "github.com/huandu/go-sqlbuilder"
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/sql"
+	smock "github.com/rjansen/raizel/sql/mock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchTriggerSQL(test *testing.T) {
+	var (
+		create = sql.WatchTriggerSQL("entity_mock", "id")
+		drop   = sql.DropWatchTriggerSQL("entity_mock")
+	)
+	require.Contains(test, create, `create or replace function "raizel_watch_entity_mock"()`, "trigger function")
+	require.Contains(test, create, "pg_notify('raizel_watch_entity_mock'", "trigger channel")
+	require.Contains(test, create, `'key_name', 'id', 'key', old."id"`, "trigger delete key")
+	require.Contains(test, create, `'key', new."id"`, "trigger write key")
+	require.Contains(test, create, `after insert or update or delete on "entity_mock"`, "trigger events")
+	require.Contains(test, drop, `drop trigger if exists "raizel_watch_entity_mock" on "entity_mock"`, "drop trigger")
+	require.Contains(test, drop, `drop function if exists "raizel_watch_entity_mock"()`, "drop function")
+	require.Equal(test, "raizel_watch_entity_mock", sql.WatchChannel("entity_mock"), "channel invalid value")
+
+	create = sql.WatchTriggerSQL(`entity"; drop table users; --`, `id'`)
+	require.Contains(test, create, `on "entity""; drop table users; --"`, "quoted table")
+	require.Contains(test, create, `'key_name', 'id''', 'key', new."id'"`, "quoted key column")
+}
+
+func TestNewListener(test *testing.T) {
+	listener, err := sql.NewListener(nil)
+	require.Equal(test, sql.ErrBlankListener, err, "newlistener error")
+	require.Nil(test, listener, "listener invalid instance")
+}
+
+type testWatcher struct {
+	name      string
+	listener  *smock.ListenerMock
+	db        *smock.DBMock
+	row       *smock.RowMock
+	key       raizel.EntityKey
+	payloads  []string
+	kinds     []raizel.ChangeKind
+	values    []interface{}
+	listenErr error
+}
+
+func (scenario *testWatcher) setup(t *testing.T) {
+	var (
+		listener = smock.NewListenerMock()
+		db       = smock.NewDBMock()
+		row      = smock.NewRowMock()
+		channel  = sql.WatchChannel("entity_table")
+	)
+	listener.On("Listen", channel).Return(scenario.listenErr)
+	listener.On("Unlisten", channel).Return(nil)
+	row.On("Scan", mock.Anything).Return(nil)
+	db.On("QueryRow", mock.AnythingOfType("string"), mock.Anything).Return(row)
+	for _, payload := range scenario.payloads {
+		listener.Notify(&sql.Notification{Channel: channel, Extra: payload})
+	}
+
+	scenario.listener = listener
+	scenario.db = db
+	scenario.row = row
+}
+
+func TestWatcher(test *testing.T) {
+	scenarios := []testWatcher{
+		{
+			name: "Watch entity changes",
+			payloads: []string{
+				`{"operation": "INSERT", "key_name": "id", "key": 1}`,
+				`{"operation": "UPDATE", "key_name": "id", "key": 2}`,
+				`{"operation": "DELETE", "key_name": "id", "key": 1}`,
+			},
+			kinds:  []raizel.ChangeKind{raizel.EntityAdded, raizel.EntityModified, raizel.EntityRemoved},
+			values: []interface{}{int64(1), int64(2), int64(1)},
+		},
+		{
+			name: "Watch key changes",
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: 1,
+			},
+			payloads: []string{
+				`{"operation": "INSERT", "key_name": "id", "key": 1}`,
+				`{"operation": "UPDATE", "key_name": "id", "key": 2}`,
+				`invalid payload`,
+				`{"operation": "DELETE", "key_name": "id", "key": 1}`,
+			},
+			kinds:  []raizel.ChangeKind{raizel.EntityAdded, raizel.EntityRemoved},
+			values: []interface{}{1, 1},
+		},
+		{
+			name: "Watch large integer key changes",
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: int64(9007199254740993),
+			},
+			payloads: []string{
+				`{"operation": "UPDATE", "key_name": "id", "key": 9007199254740992}`,
+				`{"operation": "UPDATE", "key_name": "id", "key": 9007199254740993}`,
+				`{"operation": "UPDATE", "key_name": "id", "key": 1e+06}`,
+			},
+			kinds:  []raizel.ChangeKind{raizel.EntityModified},
+			values: []interface{}{int64(9007199254740993)},
+		},
+		{
+			name: "Watch million key changes",
+			key: entityKeyMock{
+				table: "entity_table",
+				name:  "id",
+				value: 1000000,
+			},
+			payloads: []string{
+				`{"operation": "INSERT", "key_name": "id", "key": 1000000}`,
+			},
+			kinds:  []raizel.ChangeKind{raizel.EntityAdded},
+			values: []interface{}{1000000},
+		},
+		{
+			name:      "Error when try to Listen the entity channel",
+			listenErr: errors.New("errMock"),
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				scenario.setup(t)
+
+				var (
+					ctx        = context.Background()
+					repository = sql.NewRepository(scenario.db, sql.NewMapperBuilder().
+							Set("entity_table", sqlbuilder.NewStruct(new(entityMock))).
+							NewMapper())
+					watcher = sql.NewWatcher(scenario.listener, repository)
+					stream  raizel.ChangeStream
+					err     error
+				)
+				if scenario.key != nil {
+					stream, err = watcher.WatchKey(ctx, scenario.key)
+				} else {
+					stream, err = watcher.Watch(ctx, "entity_table")
+				}
+				require.Equal(t, scenario.listenErr, err, "watch error")
+				if scenario.listenErr != nil {
+					return
+				}
+				for index, kind := range scenario.kinds {
+					change, err := stream.Next()
+					require.Nil(t, err, "next error")
+					require.Equal(t, kind, change.Kind(), "change kind invalid value")
+					require.Equal(t, scenario.values[index], change.Key().Value(), "change key value")
+					require.Equal(t, "entity_table", change.Key().EntityName(), "change entityname")
+					require.Equal(t, "id", change.Key().Name(), "change keyname")
+					err = change.DataTo(new(entityMock))
+					if kind == raizel.EntityRemoved {
+						require.Equal(t, raizel.ErrNotFound, err, "removed dataTo error")
+					} else {
+						require.Nil(t, err, "dataTo error")
+					}
+				}
+				stream.Stop()
+				_, err = stream.Next()
+				require.Equal(t, raizel.ErrWatchStopped, err, "stopped next error")
+				scenario.listener.AssertExpectations(t)
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrTenantRequired = errors.New("err_tenantrequired")
+)
+
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant identifier used by
+// tenant scoped repositories.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, it returns false when
+// ctx has no tenant or the tenant is blank.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	if !ok || tenant == "" {
+		return "", false
+	}
+	return tenant, true
+}
+
+// TenantKey is an EntityKey scoped to a tenant. Backends check keys for this
+// interface to isolate the tenant data.
+type TenantKey interface {
+	EntityKey
+	Tenant() string
+}
+
+type tenantEntityKey struct {
+	EntityKey
+	tenant string
+}
+
+func (key tenantEntityKey) Tenant() string {
+	return key.tenant
+}
+
+func NewTenantKey(tenant string, key EntityKey) TenantKey {
+	return tenantEntityKey{
+		EntityKey: key,
+		tenant:    tenant,
+	}
+}
+
+// TenantOf returns the tenant of a TenantKey, it returns false for keys
+// without tenant.
+func TenantOf(key EntityKey) (string, bool) {
+	tenantKey, ok := key.(TenantKey)
+	if !ok || tenantKey.Tenant() == "" {
+		return "", false
+	}
+	return tenantKey.Tenant(), true
+}
+
+type tenantRepository struct {
+	repository Repository
+}
+
+// NewTenantRepository wraps repository scoping every key with the tenant
+// carried by the context. Calls without a tenant fail with ErrTenantRequired.
+func NewTenantRepository(repository Repository) Repository {
+	return &tenantRepository{repository: repository}
+}
+
+func (r *tenantRepository) tenantKey(ctx context.Context, key EntityKey) (EntityKey, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrTenantRequired
+	}
+	return NewTenantKey(tenant, key), nil
+}
+
+func (r *tenantRepository) Get(ctx context.Context, key EntityKey, entity Entity) error {
+	tenantKey, err := r.tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.repository.Get(ctx, tenantKey, entity)
+}
+
+func (r *tenantRepository) Set(ctx context.Context, key EntityKey, entity Entity) error {
+	tenantKey, err := r.tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.repository.Set(ctx, tenantKey, entity)
+}
+
+func (r *tenantRepository) Delete(ctx context.Context, key EntityKey) error {
+	tenantKey, err := r.tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.repository.Delete(ctx, tenantKey)
+}
+
+func (r *tenantRepository) Patch(ctx context.Context, key EntityKey, updates ...Update) error {
+	tenantKey, err := r.tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return Patch(ctx, r.repository, tenantKey, updates...)
+}
+
+func (r *tenantRepository) Exists(ctx context.Context, key EntityKey) (bool, error) {
+	tenantKey, err := r.tenantKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return Exists(ctx, r.repository, tenantKey)
+}
+
+// Count counts the entities of the context tenant.
+func (r *tenantRepository) Count(ctx context.Context, query Query) (int64, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return 0, ErrTenantRequired
+	}
+	return Count(ctx, r.repository, query.InTenant(tenant))
+}
+
+// Aggregate aggregates the entities of the context tenant.
+func (r *tenantRepository) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateResult, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrTenantRequired
+	}
+	aggregation.Query = aggregation.Query.InTenant(tenant)
+	return Aggregate(ctx, r.repository, aggregation)
+}
+
+// Transaction scopes the repository of the transaction with the context
+// tenant.
+func (r *tenantRepository) Transaction(ctx context.Context, fn func(context.Context, Repository) error) error {
+	return Transaction(ctx, r.repository, func(ctx context.Context, tx Repository) error {
+		return fn(ctx, NewTenantRepository(tx))
+	})
+}
+
+func (r *tenantRepository) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
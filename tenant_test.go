@@ -0,0 +1,107 @@
+package raizel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyRecorderRepository struct {
+	repositoryMock
+	keys []EntityKey
+}
+
+func (r *keyRecorderRepository) Get(_ context.Context, key EntityKey, _ Entity) error {
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *keyRecorderRepository) Set(_ context.Context, key EntityKey, _ Entity) error {
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *keyRecorderRepository) Delete(_ context.Context, key EntityKey) error {
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func TestTenantFromContext(test *testing.T) {
+	tenant, ok := TenantFromContext(context.Background())
+	require.False(test, ok, "tenant without context value")
+	require.Zero(test, tenant, "tenant invalid value")
+
+	_, ok = TenantFromContext(WithTenant(context.Background(), ""))
+	require.False(test, ok, "blank tenant")
+
+	tenant, ok = TenantFromContext(WithTenant(context.Background(), "tenant_a"))
+	require.True(test, ok, "tenant with context value")
+	require.Equal(test, "tenant_a", tenant, "tenant invalid value")
+}
+
+func TestTenantKey(test *testing.T) {
+	var (
+		key       = NewDynamicKey("entity_name", "key_name", "key_value")
+		tenantKey = NewTenantKey("tenant_a", key)
+	)
+	require.Equal(test, key.EntityName(), tenantKey.EntityName(), "entityname invalid value")
+	require.Equal(test, key.Name(), tenantKey.Name(), "keyname invalid value")
+	require.Equal(test, key.Value(), tenantKey.Value(), "keyvalue invalid value")
+
+	tenant, ok := TenantOf(tenantKey)
+	require.True(test, ok, "tenantof tenant key")
+	require.Equal(test, "tenant_a", tenant, "tenant invalid value")
+
+	_, ok = TenantOf(key)
+	require.False(test, ok, "tenantof plain key")
+}
+
+type testTenantRepository struct {
+	name   string
+	ctx    context.Context
+	tenant string
+	err    error
+}
+
+func TestTenantRepository(test *testing.T) {
+	scenarios := []testTenantRepository{
+		{
+			name:   "Scopes keys with the context tenant",
+			ctx:    WithTenant(context.Background(), "tenant_a"),
+			tenant: "tenant_a",
+		},
+		{
+			name: "Fails closed without a context tenant",
+			ctx:  context.Background(),
+			err:  ErrTenantRequired,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					recorder   = new(keyRecorderRepository)
+					repository = NewTenantRepository(recorder)
+					key        = NewDynamicKey("entity_name", "key_name", "key_value")
+				)
+				require.Equal(t, scenario.err, repository.Get(scenario.ctx, key, nil), "get error")
+				require.Equal(t, scenario.err, repository.Set(scenario.ctx, key, nil), "set error")
+				require.Equal(t, scenario.err, repository.Delete(scenario.ctx, key), "delete error")
+				require.Nil(t, repository.Close(scenario.ctx), "close error")
+				if scenario.err != nil {
+					require.Empty(t, recorder.keys, "keys reached the repository")
+					return
+				}
+				require.Len(t, recorder.keys, 3, "keys invalid length")
+				for _, recorded := range recorder.keys {
+					tenant, ok := TenantOf(recorded)
+					require.True(t, ok, "recorded key without tenant")
+					require.Equal(t, scenario.tenant, tenant, "recorded tenant invalid value")
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,28 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrTransactionUnsupported = errors.New("err_transactionunsupported")
+)
+
+// Transactional is implemented by repositories that run many calls
+// atomically. The repository passed to fn reads and writes inside the
+// transaction, its writes are committed together when fn returns nil and
+// discarded otherwise.
+type Transactional interface {
+	Transaction(context.Context, func(context.Context, Repository) error) error
+}
+
+// Transaction runs fn in a transaction of the repository, the repository must
+// implement Transactional or ErrTransactionUnsupported is returned.
+func Transaction(ctx context.Context, repository Repository, fn func(context.Context, Repository) error) error {
+	transactional, ok := repository.(Transactional)
+	if !ok {
+		return ErrTransactionUnsupported
+	}
+	return transactional.Transaction(ctx, fn)
+}
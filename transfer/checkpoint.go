@@ -0,0 +1,54 @@
+package transfer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checkpoint stores the page token of a copy. Load returns a blank token when
+// nothing was saved and Save with a blank token marks the copy finished.
+type Checkpoint interface {
+	Load(ctx context.Context) (string, error)
+	Save(ctx context.Context, pageToken string) error
+}
+
+type fileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint returns a Checkpoint stored in the file at path, the file
+// is replaced by a rename so an interrupted Save keeps the previous token.
+func NewFileCheckpoint(path string) Checkpoint {
+	return fileCheckpoint{path: path}
+}
+
+func (c fileCheckpoint) Load(ctx context.Context) (string, error) {
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (c fileCheckpoint) Save(ctx context.Context, pageToken string) error {
+	file, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path))
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(pageToken); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), c.path)
+}
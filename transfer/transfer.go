@@ -0,0 +1,217 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/rjansen/raizel"
+)
+
+const (
+	DefaultBatchSize = raizel.DefaultPageSize
+)
+
+var (
+	ErrInvalidOptions = errors.New("err_invalidoptions")
+	ErrUnknownKey     = errors.New("err_unknownkey")
+	ErrMismatch       = errors.New("err_mismatch")
+
+	keyTags = []string{raizel.TagName, "db", "firestore", "spanner", "json"}
+)
+
+// Options configures the copy of the entities named EntityName. KeyField is
+// the entity field, read by its db, firestore, spanner or json tag, that holds
+// the key of the copied entities and orders the pages of the source.
+type Options struct {
+	EntityName string
+	KeyField   string
+	// NewEntity returns a pointer to a new entity the source loads.
+	NewEntity func() raizel.Entity
+	// BatchSize is the page size read from the source and written before
+	// every checkpoint, DefaultBatchSize when not set.
+	BatchSize int
+	// Rate limits the entities written per second, no limit when zero.
+	Rate float64
+	// DryRun reads the source without writing the target or the checkpoint.
+	DryRun bool
+	// Checkpoint stores the page token of the last written batch, a copy
+	// resumes from the stored token.
+	Checkpoint Checkpoint
+}
+
+func (o Options) validate() error {
+	switch {
+	case o.EntityName == "":
+		return fmt.Errorf("%w: blank entity name", ErrInvalidOptions)
+	case o.KeyField == "":
+		return fmt.Errorf("%w: blank key field", ErrInvalidOptions)
+	case o.NewEntity == nil:
+		return fmt.Errorf("%w: nil entity constructor", ErrInvalidOptions)
+	case o.BatchSize < 0 || o.Rate < 0:
+		return fmt.Errorf("%w: negative batch size or rate", ErrInvalidOptions)
+	}
+	return nil
+}
+
+func (o Options) query() raizel.Query {
+	batchSize := o.BatchSize
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	return raizel.NewQuery(o.EntityName).OrderBy(o.KeyField, raizel.Asc).WithLimit(batchSize)
+}
+
+// Report describes a copy. PageToken is the token of the last written batch,
+// blank when the copy finished.
+type Report struct {
+	Batches   int
+	Entities  int
+	Resumed   bool
+	PageToken string
+}
+
+// keyValue returns the value of the entity field named by a key tag or by the
+// case insensitive field name.
+func keyValue(entity raizel.Entity, name string) (interface{}, error) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, name)
+	}
+	valueType := value.Type()
+	for index := 0; index < valueType.NumField(); index++ {
+		field := valueType.Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		for _, tag := range keyTags {
+			if strings.Split(field.Tag.Get(tag), ",")[0] == name {
+				return value.Field(index).Interface(), nil
+			}
+		}
+		if strings.EqualFold(field.Name, name) {
+			return value.Field(index).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKey, name)
+}
+
+// limiter spaces the calls of wait by the rate interval.
+type limiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return &limiter{}
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return nil
+	}
+	now := time.Now()
+	if l.next.After(now) {
+		timer := time.NewTimer(l.next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		now = l.next
+	}
+	l.next = now.Add(l.interval)
+	return nil
+}
+
+// readPage reads every entity of the page and returns the next page token.
+func readPage(
+	ctx context.Context, source raizel.Pageable, query raizel.Query, pageToken string,
+	newEntity func() raizel.Entity,
+) ([]raizel.Entity, string, error) {
+	page, err := source.Page(ctx, query, pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	defer page.Stop()
+	var entities []raizel.Entity
+	for {
+		entity := newEntity()
+		err := page.Next(ctx, entity)
+		if err == raizel.ErrIteratorDone {
+			return entities, page.NextPageToken(), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		entities = append(entities, entity)
+	}
+}
+
+// Copy streams the entities of the source into the target one batch at a
+// time, ordered by the key field. The target Set must be idempotent because a
+// resumed copy writes again the entities of an interrupted batch.
+func Copy(ctx context.Context, source raizel.Pageable, target raizel.Repository, options Options) (Report, error) {
+	var report Report
+	if err := options.validate(); err != nil {
+		return report, err
+	}
+	var (
+		query   = options.query()
+		limiter = newLimiter(options.Rate)
+		token   string
+	)
+	if options.Checkpoint != nil {
+		loaded, err := options.Checkpoint.Load(ctx)
+		if err != nil {
+			return report, err
+		}
+		token, report.Resumed, report.PageToken = loaded, loaded != "", loaded
+	}
+	for {
+		entities, nextToken, err := readPage(ctx, source, query, token, options.NewEntity)
+		if err != nil {
+			return report, err
+		}
+		for _, entity := range entities {
+			value, err := keyValue(entity, options.KeyField)
+			if err != nil {
+				return report, err
+			}
+			if options.DryRun {
+				continue
+			}
+			if err := limiter.wait(ctx); err != nil {
+				return report, err
+			}
+			key := raizel.NewDynamicKey(options.EntityName, options.KeyField, value)
+			if err := target.Set(ctx, key, entity); err != nil {
+				return report, fmt.Errorf("set %s/%v: %w", options.EntityName, value, err)
+			}
+		}
+		if len(entities) > 0 {
+			report.Batches++
+			report.Entities += len(entities)
+		}
+		if !options.DryRun && options.Checkpoint != nil {
+			if err := options.Checkpoint.Save(ctx, nextToken); err != nil {
+				return report, err
+			}
+		}
+		report.PageToken = nextToken
+		if nextToken == "" {
+			return report, nil
+		}
+		token = nextToken
+	}
+}
@@ -0,0 +1,307 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type transferEntity struct {
+	ID   string `db:"id"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+type store interface {
+	raizel.Repository
+	raizel.Pageable
+}
+
+func newStore(t *testing.T, count int) store {
+	repository := memory.NewRepository()
+	for index := 1; index <= count; index++ {
+		entity := transferEntity{ID: fmt.Sprintf("mock%02d", index), Name: "Mock", Age: index}
+		key := raizel.NewDynamicKey("entity", "id", entity.ID)
+		require.Nil(t, repository.Set(context.Background(), key, entity), "set error")
+	}
+	return repository
+}
+
+func testOptions() Options {
+	return Options{
+		EntityName: "entity",
+		KeyField:   "id",
+		NewEntity:  func() raizel.Entity { return new(transferEntity) },
+		BatchSize:  2,
+	}
+}
+
+type failingStore struct {
+	store
+	failAt string
+	err    error
+}
+
+func (s failingStore) Set(ctx context.Context, key raizel.EntityKey, entity raizel.Entity) error {
+	if key.Value() == s.failAt {
+		return s.err
+	}
+	return s.store.Set(ctx, key, entity)
+}
+
+type memoryCheckpoint struct {
+	tokens []string
+}
+
+func (c *memoryCheckpoint) Load(context.Context) (string, error) {
+	if len(c.tokens) == 0 {
+		return "", nil
+	}
+	return c.tokens[len(c.tokens)-1], nil
+}
+
+func (c *memoryCheckpoint) Save(ctx context.Context, pageToken string) error {
+	c.tokens = append(c.tokens, pageToken)
+	return nil
+}
+
+type testCopy struct {
+	name    string
+	options func(Options) Options
+	target  func(*testing.T) raizel.Repository
+	report  Report
+	copied  int
+	saves   int
+	err     error
+}
+
+func TestCopy(test *testing.T) {
+	scenarios := []testCopy{
+		{
+			name:    "Copy every entity in batches",
+			options: func(o Options) Options { return o },
+			report:  Report{Batches: 3, Entities: 5},
+			copied:  5,
+		},
+		{
+			name: "Dry run reads without writing",
+			options: func(o Options) Options {
+				o.DryRun = true
+				return o
+			},
+			report: Report{Batches: 3, Entities: 5},
+		},
+		{
+			name: "Copy with checkpoint",
+			options: func(o Options) Options {
+				o.Checkpoint = new(memoryCheckpoint)
+				return o
+			},
+			report: Report{Batches: 3, Entities: 5},
+			copied: 5,
+			saves:  3,
+		},
+		{
+			name: "Error when the target fails keeps the previous checkpoint",
+			options: func(o Options) Options {
+				o.Checkpoint = new(memoryCheckpoint)
+				return o
+			},
+			target: func(t *testing.T) raizel.Repository {
+				return failingStore{store: newStore(t, 0), failAt: "mock04", err: errors.New("errMock")}
+			},
+			report: Report{Batches: 1, Entities: 2},
+			saves:  1,
+			err:    errors.New("set entity/mock04: errMock"),
+		},
+		{
+			name: "Error when the key field is unknown",
+			options: func(o Options) Options {
+				o.KeyField = "missing"
+				return o
+			},
+			err: memory.ErrUnknownField,
+		},
+		{
+			name: "Error when the options are invalid",
+			options: func(o Options) Options {
+				o.NewEntity = nil
+				return o
+			},
+			err: ErrInvalidOptions,
+		},
+	}
+	for index, scenario := range scenarios {
+		test.Run(
+			fmt.Sprintf("[%d]-%s", index, scenario.name),
+			func(t *testing.T) {
+				var (
+					ctx     = context.Background()
+					source  = newStore(t, 5)
+					target  = newStore(t, 0)
+					options = scenario.options(testOptions())
+				)
+				var repository raizel.Repository = target
+				if scenario.target != nil {
+					repository = scenario.target(t)
+				}
+				report, err := Copy(ctx, source, repository, options)
+				if scenario.err != nil {
+					require.NotNil(t, err, "copy error")
+					require.True(
+						t,
+						errors.Is(err, scenario.err) || err.Error() == scenario.err.Error(),
+						"copy error %v", err,
+					)
+				} else {
+					require.Nil(t, err, "copy error")
+				}
+				report.PageToken = ""
+				require.Equal(t, scenario.report, report, "copy report")
+
+				if checkpoint, ok := options.Checkpoint.(*memoryCheckpoint); ok {
+					require.Len(t, checkpoint.tokens, scenario.saves, "checkpoint saves")
+				}
+				if scenario.target == nil {
+					count, _, err := checksum(ctx, target, testOptions())
+					require.Nil(t, err, "target checksum error")
+					require.Equal(t, scenario.copied, count, "copied entities")
+				}
+			},
+		)
+	}
+}
+
+func TestCopyResume(test *testing.T) {
+	var (
+		ctx     = context.Background()
+		source  = newStore(test, 5)
+		target  = newStore(test, 0)
+		options = testOptions()
+	)
+	dir, err := ioutil.TempDir("", "transfer")
+	require.Nil(test, err, "tempdir error")
+	defer os.RemoveAll(dir)
+
+	checkpoint := NewFileCheckpoint(filepath.Join(dir, "checkpoint"))
+	options.Checkpoint = checkpoint
+
+	failing := failingStore{store: target, failAt: "mock03", err: errors.New("errMock")}
+	_, err = Copy(ctx, source, failing, options)
+	require.NotNil(test, err, "interrupted copy error")
+
+	token, err := checkpoint.Load(ctx)
+	require.Nil(test, err, "load error")
+	require.NotEmpty(test, token, "checkpoint token")
+
+	report, err := Copy(ctx, source, target, options)
+	require.Nil(test, err, "resumed copy error")
+	require.True(test, report.Resumed, "resumed copy")
+	require.Equal(test, 3, report.Entities, "resumed entities")
+
+	token, err = checkpoint.Load(ctx)
+	require.Nil(test, err, "load error")
+	require.Empty(test, token, "finished checkpoint token")
+
+	verification, err := Verify(ctx, source, target, testOptions())
+	require.Nil(test, err, "verify error")
+	require.Equal(test, 5, verification.TargetCount, "target count")
+}
+
+func TestCopyRate(test *testing.T) {
+	var (
+		ctx     = context.Background()
+		options = testOptions()
+		started = time.Now()
+	)
+	options.Rate = 100
+	_, err := Copy(ctx, newStore(test, 5), newStore(test, 0), options)
+	require.Nil(test, err, "copy error")
+	require.True(test, time.Since(started) >= 40*time.Millisecond, "rate limited copy")
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	options.Rate = 1
+	_, err = Copy(ctx, newStore(test, 5), newStore(test, 0), options)
+	require.Equal(test, context.Canceled, err, "canceled copy error")
+}
+
+func TestVerify(test *testing.T) {
+	var (
+		ctx    = context.Background()
+		source = newStore(test, 5)
+		target = newStore(test, 5)
+	)
+	verification, err := Verify(ctx, source, target, testOptions())
+	require.Nil(test, err, "verify error")
+	require.True(test, verification.Match(), "verification match")
+
+	changed := transferEntity{ID: "mock03", Name: "Changed", Age: 3}
+	require.Nil(test, target.Set(ctx, raizel.NewDynamicKey("entity", "id", changed.ID), changed), "set error")
+	verification, err = Verify(ctx, source, target, testOptions())
+	require.True(test, errors.Is(err, ErrMismatch), "verify mismatch error")
+	require.Equal(test, verification.SourceCount, verification.TargetCount, "verification counts")
+	require.NotEqual(test, verification.SourceChecksum, verification.TargetChecksum, "verification checksums")
+
+	require.Nil(test, target.Delete(ctx, raizel.NewDynamicKey("entity", "id", changed.ID)), "delete error")
+	verification, err = Verify(ctx, source, target, testOptions())
+	require.True(test, errors.Is(err, ErrMismatch), "verify mismatch error")
+	require.Equal(test, 4, verification.TargetCount, "target count")
+}
+
+// anonymousEntity leaves the key out of the checksum, so the entities of the
+// same name have the same checksum.
+type anonymousEntity struct {
+	ID   string `db:"id" json:"-"`
+	Name string `db:"name"`
+}
+
+func TestVerifyDuplicates(test *testing.T) {
+	var (
+		ctx     = context.Background()
+		source  = memory.NewRepository()
+		target  = memory.NewRepository()
+		options = testOptions()
+	)
+	options.NewEntity = func() raizel.Entity { return new(anonymousEntity) }
+	for index, name := range []string{"A", "A", "B", "C"} {
+		entity := anonymousEntity{ID: fmt.Sprintf("mock%02d", index), Name: name}
+		require.Nil(test, source.Set(ctx, raizel.NewDynamicKey("entity", "id", entity.ID), entity), "set source error")
+	}
+	for index, name := range []string{"B", "C", "D", "D"} {
+		entity := anonymousEntity{ID: fmt.Sprintf("mock%02d", index), Name: name}
+		require.Nil(test, target.Set(ctx, raizel.NewDynamicKey("entity", "id", entity.ID), entity), "set target error")
+	}
+	verification, err := Verify(ctx, source, target, options)
+	require.True(test, errors.Is(err, ErrMismatch), "verify duplicates error")
+	require.Equal(test, 4, verification.SourceCount, "source count")
+	require.Equal(test, 4, verification.TargetCount, "target count")
+}
+
+func TestFileCheckpoint(test *testing.T) {
+	dir, err := ioutil.TempDir("", "transfer")
+	require.Nil(test, err, "tempdir error")
+	defer os.RemoveAll(dir)
+
+	var (
+		ctx        = context.Background()
+		checkpoint = NewFileCheckpoint(filepath.Join(dir, "checkpoint"))
+	)
+	token, err := checkpoint.Load(ctx)
+	require.Nil(test, err, "load missing error")
+	require.Empty(test, token, "missing token")
+
+	require.Nil(test, checkpoint.Save(ctx, "token"), "save error")
+	token, err = checkpoint.Load(ctx)
+	require.Nil(test, err, "load error")
+	require.Equal(test, "token", token, "token")
+}
@@ -0,0 +1,87 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rjansen/raizel"
+)
+
+// Verification compares the entities of the source and target of a copy.
+type Verification struct {
+	SourceCount    int
+	TargetCount    int
+	SourceChecksum string
+	TargetChecksum string
+}
+
+func (v Verification) Match() bool {
+	return v.SourceCount == v.TargetCount && v.SourceChecksum == v.TargetChecksum
+}
+
+// checksum reads every entity and returns the count and the sum modulo 2^256
+// of the sha256 of the entities json, so the checksum does not depend on the
+// order the backend sorts the keys and duplicated entities do not cancel out.
+func checksum(ctx context.Context, repository raizel.Pageable, options Options) (int, string, error) {
+	var (
+		query = options.query()
+		sum   [sha256.Size]byte
+		count int
+		token string
+	)
+	for {
+		entities, nextToken, err := readPage(ctx, repository, query, token, options.NewEntity)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, entity := range entities {
+			data, err := json.Marshal(entity)
+			if err != nil {
+				return 0, "", err
+			}
+			var (
+				entitySum = sha256.Sum256(data)
+				carry     uint16
+			)
+			for index := len(sum) - 1; index >= 0; index-- {
+				total := uint16(sum[index]) + uint16(entitySum[index]) + carry
+				sum[index], carry = byte(total), total>>8
+			}
+		}
+		count += len(entities)
+		if nextToken == "" {
+			return count, hex.EncodeToString(sum[:]), nil
+		}
+		token = nextToken
+	}
+}
+
+// Verify reads the entities of the source and target and compares the counts
+// and checksums, it returns ErrMismatch with the verification when they
+// differ.
+func Verify(ctx context.Context, source, target raizel.Pageable, options Options) (Verification, error) {
+	var verification Verification
+	if err := options.validate(); err != nil {
+		return verification, err
+	}
+	var err error
+	verification.SourceCount, verification.SourceChecksum, err = checksum(ctx, source, options)
+	if err != nil {
+		return verification, fmt.Errorf("source: %w", err)
+	}
+	verification.TargetCount, verification.TargetChecksum, err = checksum(ctx, target, options)
+	if err != nil {
+		return verification, fmt.Errorf("target: %w", err)
+	}
+	if !verification.Match() {
+		return verification, fmt.Errorf(
+			"%w: source %d entities %s, target %d entities %s", ErrMismatch,
+			verification.SourceCount, verification.SourceChecksum,
+			verification.TargetCount, verification.TargetChecksum,
+		)
+	}
+	return verification, nil
+}
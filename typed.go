@@ -0,0 +1,160 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	TagName = "raizel"
+)
+
+var (
+	ErrInvalidEntity = errors.New("err_invalidentity")
+	ErrMissingKey    = errors.New("err_missingkey")
+	ErrNotQueryable  = errors.New("err_notqueryable")
+)
+
+// TypedRepository wraps a Repository with the entities of type T and the
+// keys of type K, so the entity and key types are checked at compile time
+// instead of inside the backend mapping. T must be a struct with the fields
+// tagged as keys. K is the type of the key field, or a struct with a field of
+// the same type for every key field in order for the composite keys.
+type TypedRepository[T any, K any] struct {
+	repository Repository
+	entityName string
+	keys       []Field
+}
+
+func NewTypedRepository[T any, K any](repository Repository, entityName string) (*TypedRepository[T, K], error) {
+	descriptor, found := DescriptorOf((*T)(nil))
+	if !found {
+		var err error
+		if descriptor, err = Describe((*T)(nil)); err != nil {
+			return nil, err
+		}
+	}
+	keys := descriptor.Keys()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingKey, descriptor.Type)
+	}
+	if err := checkKeyType(reflect.TypeOf((*K)(nil)).Elem(), keys); err != nil {
+		return nil, err
+	}
+	return &TypedRepository[T, K]{repository: repository, entityName: entityName, keys: keys}, nil
+}
+
+// checkKeyType checks that the values of keyType match the key fields.
+func checkKeyType(keyType reflect.Type, keys []Field) error {
+	if len(keys) == 1 {
+		if keyType != keys[0].Type {
+			return fmt.Errorf("%w: key type %s, key field %s", ErrInvalidArgument, keyType, keys[0].Type)
+		}
+		return nil
+	}
+	if keyType.Kind() != reflect.Struct || keyType.NumField() != len(keys) {
+		return fmt.Errorf("%w: key type %s, %d key fields", ErrInvalidArgument, keyType, len(keys))
+	}
+	for index, key := range keys {
+		field := keyType.Field(index)
+		if field.PkgPath != "" || field.Type != key.Type {
+			return fmt.Errorf(
+				"%w: key type field %s %s, key field %s %s", ErrInvalidArgument, field.Name, field.Type, key.Name, key.Type,
+			)
+		}
+	}
+	return nil
+}
+
+// entityKey returns the key of the key field values.
+func (r *TypedRepository[T, K]) entityKey(values []interface{}) EntityKey {
+	if len(r.keys) == 1 {
+		return NewDynamicKey(r.entityName, r.keys[0].Name, values[0])
+	}
+	names := make([]string, len(r.keys))
+	for index, key := range r.keys {
+		names[index] = key.Name
+	}
+	return NewCompositeKey(r.entityName, names, values)
+}
+
+// Key returns the entity key of the key value.
+func (r *TypedRepository[T, K]) Key(key K) EntityKey {
+	if len(r.keys) == 1 {
+		return r.entityKey([]interface{}{key})
+	}
+	var (
+		value  = reflect.ValueOf(key)
+		values = make([]interface{}, len(r.keys))
+	)
+	for index := range r.keys {
+		values[index] = value.Field(index).Interface()
+	}
+	return r.entityKey(values)
+}
+
+// KeyOf returns the key of the entity read from the key fields.
+func (r *TypedRepository[T, K]) KeyOf(entity *T) EntityKey {
+	var (
+		value  = reflect.ValueOf(entity).Elem()
+		values = make([]interface{}, len(r.keys))
+	)
+	for index, key := range r.keys {
+		values[index] = value.FieldByIndex(key.Path).Interface()
+	}
+	return r.entityKey(values)
+}
+
+func (r *TypedRepository[T, K]) Get(ctx context.Context, key K) (*T, error) {
+	entity := new(T)
+	if err := r.repository.Get(ctx, r.Key(key), entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *TypedRepository[T, K]) Set(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return ErrInvalidEntity
+	}
+	return r.repository.Set(ctx, r.KeyOf(entity), entity)
+}
+
+func (r *TypedRepository[T, K]) Delete(ctx context.Context, key K) error {
+	return r.repository.Delete(ctx, r.Key(key))
+}
+
+// List reads every entity of the query, a blank Query.EntityName is the
+// repository entity name. The repository must implement Queryable.
+func (r *TypedRepository[T, K]) List(ctx context.Context, query Query) ([]T, error) {
+	queryable, ok := r.repository.(Queryable)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	if query.EntityName == "" {
+		query.EntityName = r.entityName
+	}
+	iterator, err := queryable.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Stop()
+	var entities []T
+	for {
+		var entity T
+		err := iterator.Next(ctx, &entity)
+		if err == ErrIteratorDone {
+			return entities, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+}
+
+func (r *TypedRepository[T, K]) Close(ctx context.Context) error {
+	return r.repository.Close(ctx)
+}
@@ -0,0 +1,122 @@
+package raizel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rjansen/raizel"
+	"github.com/rjansen/raizel/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type typedBase struct {
+	ID string `raizel:"id,key" db:"id"`
+}
+
+type typedEntity struct {
+	typedBase
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+type untaggedEntity struct {
+	ID string `db:"id"`
+}
+
+func TestNewTypedRepository(test *testing.T) {
+	_, err := raizel.NewTypedRepository[untaggedEntity, string](memory.NewRepository(), "entity")
+	require.True(test, errors.Is(err, raizel.ErrMissingKey), "missing key error")
+
+	_, err = raizel.NewTypedRepository[string, string](memory.NewRepository(), "entity")
+	require.True(test, errors.Is(err, raizel.ErrInvalidEntity), "invalid entity error")
+
+	_, err = raizel.NewTypedRepository[typedEntity, int](memory.NewRepository(), "entity")
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "key type error")
+
+	_, err = raizel.NewTypedRepository[compositeEntity, string](memory.NewRepository(), "entity")
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "composite key type error")
+
+	_, err = raizel.NewTypedRepository[compositeEntity, struct{ Order string }](memory.NewRepository(), "entity")
+	require.True(test, errors.Is(err, raizel.ErrInvalidArgument), "composite key fields error")
+}
+
+func TestTypedRepository(test *testing.T) {
+	var (
+		ctx             = context.Background()
+		repository, err = raizel.NewTypedRepository[typedEntity, string](memory.NewRepository(), "entity")
+	)
+	require.Nil(test, err, "new typed repository error")
+
+	key := repository.KeyOf(&typedEntity{typedBase: typedBase{ID: "mock1"}})
+	require.Equal(test, "entity", key.EntityName(), "key entity name")
+	require.Equal(test, "id", key.Name(), "key name")
+	require.Equal(test, "mock1", key.Value(), "key value")
+
+	for _, entity := range []*typedEntity{
+		{typedBase: typedBase{ID: "mock1"}, Name: "Mock One", Age: 20},
+		{typedBase: typedBase{ID: "mock2"}, Name: "Mock Two", Age: 10},
+	} {
+		require.Nil(test, repository.Set(ctx, entity), "set error")
+	}
+	require.Equal(test, raizel.ErrInvalidEntity, repository.Set(ctx, nil), "set nil error")
+
+	entity, err := repository.Get(ctx, "mock1")
+	require.Nil(test, err, "get error")
+	require.Equal(test, "Mock One", entity.Name, "entity name")
+
+	entities, err := repository.List(ctx, raizel.Query{}.OrderBy("age", raizel.Asc))
+	require.Nil(test, err, "list error")
+	require.Len(test, entities, 2, "entities length")
+	require.Equal(test, "mock2", entities[0].ID, "first entity")
+
+	require.Nil(test, repository.Delete(ctx, "mock1"), "delete error")
+	_, err = repository.Get(ctx, "mock1")
+	require.Equal(test, raizel.ErrNotFound, err, "get deleted error")
+	require.Nil(test, repository.Close(ctx), "close error")
+}
+
+type compositeEntity struct {
+	Order string `raizel:"order_id,key"`
+	Line  int    `raizel:"line,key"`
+	Item  string `raizel:"item"`
+}
+
+type compositeKey struct {
+	Order string
+	Line  int
+}
+
+func TestTypedRepositoryCompositeKey(test *testing.T) {
+	var (
+		ctx             = context.Background()
+		repository, err = raizel.NewTypedRepository[compositeEntity, compositeKey](memory.NewRepository(), "lines")
+		entity          = compositeEntity{Order: "order1", Line: 2, Item: "item1"}
+	)
+	require.Nil(test, err, "new typed repository error")
+	require.Equal(
+		test,
+		raizel.NewCompositeKey("lines", []string{"order_id", "line"}, []interface{}{"order1", 2}),
+		repository.Key(compositeKey{Order: "order1", Line: 2}),
+		"composite key",
+	)
+	require.Nil(test, repository.Set(ctx, &entity), "set error")
+	stored, err := repository.Get(ctx, compositeKey{Order: "order1", Line: 2})
+	require.Nil(test, err, "get error")
+	require.Equal(test, entity, *stored, "stored entity")
+	require.Nil(test, repository.Delete(ctx, compositeKey{Order: "order1", Line: 2}), "delete error")
+	_, err = repository.Get(ctx, compositeKey{Order: "order1", Line: 2})
+	require.Equal(test, raizel.ErrNotFound, err, "get deleted error")
+}
+
+type repositoryOnly struct {
+	raizel.Repository
+}
+
+func TestTypedRepositoryNotQueryable(test *testing.T) {
+	repository, err := raizel.NewTypedRepository[typedEntity, string](repositoryOnly{memory.NewRepository()}, "entity")
+	require.Nil(test, err, "new typed repository error")
+
+	_, err = repository.List(context.Background(), raizel.Query{})
+	require.Equal(test, raizel.ErrNotQueryable, err, "list error")
+}
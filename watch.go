@@ -0,0 +1,53 @@
+package raizel
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrWatchStopped     = errors.New("err_watchstopped")
+	ErrWatchUnsupported = errors.New("err_watchunsupported")
+)
+
+type ChangeKind int
+
+const (
+	EntityAdded ChangeKind = iota
+	EntityModified
+	EntityRemoved
+)
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case EntityAdded:
+		return "added"
+	case EntityModified:
+		return "modified"
+	case EntityRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is an entity change event. DataTo loads the entity value after the
+// change, for removed entities it loads the last known value when the backend
+// keeps it or returns ErrNotFound otherwise.
+type Change interface {
+	Kind() ChangeKind
+	Key() EntityKey
+	DataTo(Entity) error
+}
+
+// ChangeStream is a stream of entity changes. Next blocks until a change
+// happens and returns ErrWatchStopped after Stop.
+type ChangeStream interface {
+	Next() (Change, error)
+	Stop()
+}
+
+type Watcher interface {
+	Watch(context.Context, string) (ChangeStream, error)
+	WatchKey(context.Context, EntityKey) (ChangeStream, error)
+}
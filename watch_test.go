@@ -0,0 +1,15 @@
+package raizel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeKind(test *testing.T) {
+	require.Equal(test, "added", EntityAdded.String(), "added invalid value")
+	require.Equal(test, "modified", EntityModified.String(), "modified invalid value")
+	require.Equal(test, "removed", EntityRemoved.String(), "removed invalid value")
+	require.Equal(test, "unknown", ChangeKind(-1).String(), "unknown invalid value")
+	require.NotNil(test, ErrWatchStopped, "errwatchstopped invalid instance")
+}